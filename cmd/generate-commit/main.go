@@ -1,28 +1,85 @@
 package main
 
 import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
 
 	"ai-commit-message-generator/internal/ai"
 	"ai-commit-message-generator/internal/app"
 	"ai-commit-message-generator/internal/config"
 	"ai-commit-message-generator/internal/git"
+	"ai-commit-message-generator/internal/logging"
+	"ai-commit-message-generator/internal/mcp"
+	"ai-commit-message-generator/internal/server"
+
+	"golang.org/x/term"
 )
 
 func main() {
-	if len(os.Args) < 2 {
+	if len(os.Args) < 2 || strings.HasPrefix(os.Args[1], "-") && !isHelpFlag(os.Args[1]) && os.Args[1] != "--version" {
 		// Default behavior: generate commit message
-		runGenerate()
+		runGenerate(os.Args[1:])
 		return
 	}
 
 	command := os.Args[1]
 	switch command {
 	case "init":
-		runInit()
+		runInit(os.Args[2:])
+	case "deinit":
+		runDeinit(os.Args[2:])
+	case "doctor":
+		runDoctor(os.Args[2:])
+	case "lint-rules":
+		runLintRules(os.Args[2:])
+	case "config":
+		runConfig(os.Args[2:])
 	case "generate", "gen":
-		runGenerate()
+		runGenerate(os.Args[2:])
+	case "split":
+		runSplit(os.Args[2:])
+	case "changelog":
+		runChangelog(os.Args[2:])
+	case "pr":
+		runPR(os.Args[2:])
+	case "reword":
+		runReword(os.Args[2:])
+	case "improve-last":
+		runImproveLast(os.Args[2:])
+	case "tag":
+		runTag(os.Args[2:])
+	case "branch":
+		runBranch(os.Args[2:])
+	case "semver":
+		runSemver(os.Args[2:])
+	case "history":
+		runHistory(os.Args[2:])
+	case "explain":
+		runExplain(os.Args[2:])
+	case "review":
+		runReview(os.Args[2:])
+	case "hook":
+		runHook(os.Args[2:])
+	case "serve":
+		runServe(os.Args[2:])
+	case "mcp":
+		runMCP(os.Args[2:])
+	case "action":
+		runAction(os.Args[2:])
+	case "off", "on":
+		runToggle(command == "on")
+	case "version", "--version":
+		runVersion(os.Args[2:])
 	case "help", "-h", "--help":
 		printHelp()
 	default:
@@ -32,16 +89,78 @@ func main() {
 	}
 }
 
-func runInit() {
-	force := false
-	if len(os.Args) > 2 {
-		for _, arg := range os.Args[2:] {
-			if arg == "--force" || arg == "-f" {
-				force = true
-				break
-			}
-		}
+func isHelpFlag(arg string) bool {
+	return arg == "-h" || arg == "--help"
+}
+
+// isTerminal reports whether f is a character device such as a TTY
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// promptTerminal returns the terminal to prompt on, or nil when nobody can
+// answer: --non-interactive or AI_COMMIT_NON_INTERACTIVE was given, stdin is
+// not a TTY, or there is no controlling terminal (CI, GUI git clients).
+// Hooks never get a TTY on stdin, so they skip that check.
+func promptTerminal(nonInteractive, hook bool) *app.Terminal {
+	if nonInteractive || os.Getenv("AI_COMMIT_NON_INTERACTIVE") != "" {
+		return nil
+	}
+	if !hook && !isTerminal(os.Stdin) {
+		return nil
+	}
+	terminal, err := app.OpenTerminal()
+	if err != nil {
+		return nil
 	}
+	terminal.Timeout = app.DefaultPromptTimeout
+	return terminal
+}
+
+// outputFlags are the output options shared by the generating commands
+type outputFlags struct {
+	// quiet prints only the result
+	quiet bool
+	// plain disables the progress spinner
+	plain bool
+	// verbose adds the raw API responses to errors and logs how commits
+	// are signed
+	verbose bool
+}
+
+// register adds --quiet/-q, --plain and --verbose to fs
+func (o *outputFlags) register(fs *flag.FlagSet) {
+	fs.BoolVar(&o.quiet, "quiet", false, "Print only the result, without progress")
+	fs.BoolVar(&o.quiet, "q", false, "Shorthand for --quiet")
+	fs.BoolVar(&o.plain, "plain", false, "Print progress as plain lines instead of a spinner")
+	fs.BoolVar(&o.verbose, "verbose", false, "Show the raw API response when a request fails and how commits are signed")
+}
+
+// stringList is a repeatable string flag
+type stringList []string
+
+func (s *stringList) String() string {
+	return strings.Join(*s, ", ")
+}
+
+func (s *stringList) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+func runInit(args []string) {
+	fs := flag.NewFlagSet("init", flag.ExitOnError)
+	force := fs.Bool("force", false, "Reinitialize even if the repository is already initialized")
+	fs.BoolVar(force, "f", false, "Shorthand for --force")
+	hookType := fs.String("hook-type", app.HookPreCommit, "Hooks to install: comma-separated pre-commit, prepare-commit-msg, commit-msg, or both")
+	onExisting := fs.String("on-existing", app.OnExistingAbort, "What to do with hooks not written by init: abort, backup or chain")
+	lintFix := fs.Bool("lint-fix", false, "Make the commit-msg hook rewrite non-compliant messages instead of rejecting them")
+	hookShell := fs.String("hook-shell", "", "Language of the hooks: sh (default), powershell, or batch (default on Windows)")
+	yes := fs.Bool("yes", false, "Skip the setup questions and write the default config")
+	fs.BoolVar(yes, "y", false, "Shorthand for --yes")
+	framework := fs.String("framework", "", "pre-commit, husky or lefthook: add the hook to that framework's config instead of writing .git/hooks; auto picks the one the repository uses")
+	fs.Parse(args)
 
 	gitClient := git.NewClient()
 	rulesLoader := config.NewLoader()
@@ -49,55 +168,1118 @@ func runInit() {
 
 	application := app.NewApp(gitClient, rulesLoader, configLoader, nil)
 
-	if err := application.Init(force); err != nil {
+	// Ask the setup questions only when someone is there to answer them
+	wizard := !*yes && isTerminal(os.Stdin)
+	if wizard {
+		application.Terminal = &app.Terminal{In: os.Stdin, Out: os.Stdout}
+	}
+
+	opts := app.InitOptions{Force: *force, HookType: *hookType, LintFix: *lintFix, OnExisting: *onExisting, HookShell: *hookShell, Wizard: wizard, Framework: *framework}
+	if err := application.Init(opts); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 }
 
-func runGenerate() {
-	gitClient := git.NewClient()
-	rulesLoader := config.NewLoader()
+func runDeinit(args []string) {
+	fs := flag.NewFlagSet("deinit", flag.ExitOnError)
+	purge := fs.Bool("purge", false, "Also delete .commit-generator-config and .git-commit-rules-for-ai")
+	fs.Parse(args)
+
+	application := app.NewApp(git.NewClient(), config.NewLoader(), config.NewConfigLoader(), nil)
+	if err := application.Deinit(app.DeinitOptions{Purge: *purge}); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func runToggle(enabled bool) {
+	application := app.NewApp(git.NewClient(), config.NewLoader(), config.NewConfigLoader(), nil)
+	if err := application.SetEnabled(enabled); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func runDoctor(args []string) {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	jsonOutput := fs.Bool("json", false, "Print the report as JSON")
+	configPath := fs.String("config", "", "Check this config file instead of the repository's")
+	profile := fs.String("profile", "", "Check the config with this named profile overlaid")
+	fs.Parse(args)
+
 	configLoader := config.NewConfigLoader()
+	if *configPath != "" {
+		configLoader = config.NewConfigLoaderWithPath(*configPath)
+	}
+	configLoader.SetProfile(*profile)
 
-	// Load configuration
-	cfg, err := configLoader.LoadConfig()
+	application := app.NewApp(git.NewClient(), config.NewLoader(), configLoader, nil)
+	err := application.Doctor(app.DoctorOptions{JSON: *jsonOutput})
+	if errors.Is(err, app.ErrDoctorFailed) {
+		// The report already explains what failed
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func runVersion(args []string) {
+	fs := flag.NewFlagSet("version", flag.ExitOnError)
+	jsonOutput := fs.Bool("json", false, "Print the build metadata as JSON")
+	configPath := fs.String("config", "", "Read check_updates from this file instead of the repository's config")
+	fs.Parse(args)
+
+	configLoader := config.NewConfigLoader()
+	if *configPath != "" {
+		configLoader = config.NewConfigLoaderWithPath(*configPath)
+	}
+	application := app.NewApp(git.NewClient(), config.NewLoader(), configLoader, nil)
+	// The version is printed even when the config cannot be read
+	if cfg, err := configLoader.LoadConfig(); err == nil {
+		application.CheckUpdates = cfg.CheckUpdates
+	}
+	if err := application.PrintVersion(app.VersionOptions{JSON: *jsonOutput}); err != nil {
+		exitWithJSONError(err, *jsonOutput)
+	}
+}
+
+func runLintRules(args []string) {
+	fs := flag.NewFlagSet("lint-rules", flag.ExitOnError)
+	strict := fs.Bool("strict", false, "Exit non-zero when any issue is found")
+	fs.Parse(args)
+
+	application := app.NewApp(git.NewClient(), config.NewLoader(), config.NewConfigLoader(), nil)
+	if err := application.LintRules(app.LintRulesOptions{Strict: *strict}); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func runConfig(args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: generate-commit config <get|set|set-key|list|validate> [--global] [args]")
+		os.Exit(1)
+	}
+	verb := args[0]
+
+	fs := flag.NewFlagSet("config "+verb, flag.ExitOnError)
+	global := fs.Bool("global", false, "Use the per-user config instead of the repository's")
+	configPath := fs.String("config", "", "Use this config file instead of the repository's")
+	fileFallback := fs.Bool("file-fallback", false, "set-key: use the credentials file when the OS keychain is unavailable")
+	profile := fs.String("profile", "", "list: overlay this named profile from the config's \"profiles\"")
+	fs.Parse(args[1:])
+	rest := fs.Args()
+
+	configLoader := config.NewConfigLoader()
+	if *configPath != "" {
+		configLoader = config.NewConfigLoaderWithPath(*configPath)
+	}
+	configLoader.SetProfile(*profile)
+	application := app.NewApp(git.NewClient(), config.NewLoader(), configLoader, nil)
+	scope := app.ConfigScope{Global: *global}
+
+	var err error
+	switch {
+	case verb == "get" && len(rest) == 1:
+		err = application.ConfigGet(scope, rest[0])
+	case verb == "set" && len(rest) == 2:
+		err = application.ConfigSet(scope, rest[0], rest[1])
+	case verb == "set-key" && len(rest) == 0:
+		var key string
+		if key, err = readSecret("API key: "); err == nil {
+			err = application.ConfigSetKey(scope, key, *fileFallback)
+		}
+	case verb == "list" && len(rest) == 0:
+		err = application.ConfigList(scope)
+	case verb == "validate" && len(rest) == 0:
+		err = application.ConfigValidate(scope)
+		if errors.Is(err, app.ErrInvalidConfig) {
+			// The problems have already been printed
+			os.Exit(1)
+		}
+	default:
+		fmt.Fprintln(os.Stderr, "Usage: generate-commit config <get <key>|set <key> <value>|set-key|list|validate> [--global]")
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// readSecret reads a secret without echoing it when stdin is a terminal, or
+// the first line of stdin when it is piped
+func readSecret(prompt string) (string, error) {
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		fmt.Fprint(os.Stderr, prompt)
+		secret, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", fmt.Errorf("failed to read the API key: %w", err)
+		}
+		return string(secret), nil
+	}
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("failed to read the API key from stdin: %w", err)
+	}
+	return line, nil
+}
+
+func runGenerate(args []string) {
+	fs := flag.NewFlagSet("generate", flag.ExitOnError)
+	interactive := fs.Bool("interactive", false, "Accept, edit, regenerate or copy the message before committing")
+	fs.BoolVar(interactive, "i", false, "Shorthand for --interactive")
+	configPath := fs.String("config", "", "Load configuration from this file instead of the repository")
+	profile := fs.String("profile", "", "Overlay this named profile from the config's \"profiles\"")
+	var refine stringList
+	fs.Var(&refine, "refine", "Revise the generated message with this instruction (repeatable)")
+	preview := fs.Bool("preview", false, "Print the commit that would be created without committing")
+	var only, ignore stringList
+	fs.Var(&only, "only", "Only describe staged paths matching this glob (repeatable)")
+	fs.Var(&ignore, "ignore", "Leave staged paths matching this glob out of the message (repeatable)")
+	prDescription := fs.Bool("pr-description", false, "Print a pull request title and markdown body for the branch instead of a commit message (same as 'pr')")
+	base := fs.String("base", "", "Branch the pull request targets (with --pr-description; default the upstream, then main, master or origin/HEAD)")
+	yes := fs.Bool("yes", false, "Commit the generated message without prompting")
+	fs.BoolVar(yes, "y", false, "Shorthand for --yes")
+	nonInteractive := fs.Bool("non-interactive", false, "Never prompt or open an editor, even on a terminal")
+	stdin := fs.Bool("stdin", false, "Describe the diff read from stdin instead of the staged changes")
+	all := fs.Bool("all", false, "Describe every change to tracked files against HEAD, staged or not")
+	fs.BoolVar(all, "a", false, "Shorthand for --all")
+	includeUntracked := fs.Bool("include-untracked", false, "With --all, also describe untracked files that are not ignored")
+	includeNew := fs.Bool("include-new", false, "Also describe untracked files as additions, without staging or committing them")
+	add := fs.Bool("add", false, "With --all, stage the described changes right before committing (requires --yes or --interactive)")
+	byDir := fs.Bool("by-dir", false, "Commit the staged files as one commit per package directory, like 'split --by-dir'")
+	showFiles := fs.Bool("show-files", false, "List the files that will be committed above the message (always on with --interactive)")
+	explain := fs.Bool("explain", false, "Also print why the model chose the type and scope; it is never committed")
+	copyMessage := fs.Bool("copy", false, "Also place the message on the clipboard (pbcopy, wl-copy/xclip/xsel, clip.exe or OSC 52)")
+	signoff := fs.Bool("signoff", false, "Append a Signed-off-by trailer from user.name and user.email")
+	fs.BoolVar(signoff, "s", false, "Shorthand for --signoff")
+	noSplit := fs.Bool("no-split", false, "Always write a single message; never suggest splitting the changes")
+	noTrailingNewline := fs.Bool("no-trailing-newline", false, "Print the message without a newline after it")
+	var coAuthors stringList
+	fs.Var(&coAuthors, "co-author", "Append a Co-authored-by trailer for \"Name <email>\" or a co_authors shortcut (repeatable)")
+	var output outputFlags
+	output.register(fs)
+	fs.Parse(args)
+
+	if *prDescription && (*interactive || *preview || *yes || *stdin || *all || *includeNew || *explain || *copyMessage || len(refine) > 0) {
+		fmt.Fprintln(os.Stderr, "Error: --pr-description cannot be combined with --interactive, --preview, --yes, --stdin, --all, --include-new, --explain, --copy or --refine")
+		os.Exit(1)
+	}
+
+	if *byDir && (*prDescription || *interactive || *preview || *stdin || *all || *includeNew || *showFiles || *explain || *copyMessage || len(refine) > 0 || len(only) > 0 || len(ignore) > 0) {
+		fmt.Fprintln(os.Stderr, "Error: --by-dir can only be combined with --yes, --non-interactive, --config, --profile and the output flags")
+		os.Exit(1)
+	}
+
+	diffOpts := git.DiffOptions{Only: only, Ignore: ignore}
+	for _, patterns := range [][]string{diffOpts.Only, diffOpts.Ignore} {
+		if err := git.ValidateGlobs(patterns); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	switch {
+	case (len(only) == 0 && len(ignore) == 0) || *stdin:
+	case *add:
+		fmt.Fprintln(os.Stderr, "Note: --only/--ignore also limit what --add stages; changes that are already staged are still committed.")
+	default:
+		fmt.Fprintln(os.Stderr, "Note: --only/--ignore only affect the generated message; every staged change is still committed.")
+	}
+
+	application := newGenerateApp(*configPath, *profile, diffOpts, output)
+	application.Signoff = application.Signoff || *signoff
+	application.NoSplit = application.NoSplit || *noSplit
+	if err := application.AddCoAuthors(coAuthors); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if *byDir {
+		if !*yes {
+			if terminal := promptTerminal(*nonInteractive, false); terminal != nil {
+				defer terminal.Close()
+				application.Terminal = terminal
+			}
+		}
+		if err := application.CommitSplit(app.SplitOptions{ByDir: true, Yes: *yes}); err != nil {
+			exitWithError(err)
+		}
+		return
+	}
+	if *prDescription {
+		if err := application.PRDescription(app.PRDescriptionOptions{Base: *base}); err != nil {
+			exitWithError(err)
+		}
+		return
+	}
+
+	opts := app.RunOptions{
+		Interactive:       *interactive,
+		Refine:            refine,
+		Preview:           *preview,
+		Yes:               *yes,
+		All:               *all,
+		IncludeUntracked:  *includeUntracked,
+		Add:               *add,
+		ShowFiles:         *showFiles,
+		Explain:           *explain,
+		Copy:              *copyMessage,
+		IncludeNew:        *includeNew,
+		NoTrailingNewline: *noTrailingNewline,
+	}
+	if *stdin {
+		opts.Stdin = os.Stdin
+		application.StdinFilter = diffOpts
+	}
+
+	if opts.Interactive && !opts.Yes {
+		if terminal := promptTerminal(*nonInteractive, false); terminal != nil {
+			defer terminal.Close()
+			application.Terminal = terminal
+		}
+	}
+
+	updates := application.StartUpdateCheck()
+	err := application.Run(opts)
+	if notice := updates.Notice(); notice != "" {
+		fmt.Fprintln(os.Stderr, notice)
+	}
+	if err != nil {
+		exitWithError(err)
+	}
+}
+
+func runServe(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	socket := fs.String("socket", "", "Unix socket (named pipe on Windows, e.g. \\\\.\\pipe\\generate-commit) to listen on")
+	configPath := fs.String("config", "", "Load configuration from this file instead of the repository")
+	profile := fs.String("profile", "", "Overlay this named profile from the config's \"profiles\"")
+	timeout := fs.Duration("timeout", server.DefaultTimeout, "Give up on a request after this long")
+	verbose := fs.Bool("verbose", false, "Show the raw API response when a request fails")
+	fs.Parse(args)
+
+	if *socket == "" {
+		fmt.Fprintln(os.Stderr, "Usage: generate-commit serve --socket <path> [--config <path>] [--profile <name>] [--timeout <duration>]")
+		os.Exit(1)
+	}
+
+	// Progress goes nowhere: there is no terminal to draw it on
+	// The config method answers with the configuration the App was built from
+	application, cfg := newGenerateAppWithConfig(*configPath, *profile, git.DiffOptions{}, outputFlags{quiet: true, verbose: *verbose})
+
+	listener, err := server.Listen(*socket)
+	if err != nil {
+		exitWithError(err)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	srv := &server.Server{App: application, Config: cfg, Timeout: *timeout}
+	fmt.Fprintf(os.Stderr, "Listening on %s\n", *socket)
+	if err := srv.Serve(ctx, listener); err != nil {
+		exitWithError(err)
+	}
+	fmt.Fprintln(os.Stderr, "Server stopped")
+}
+
+func runMCP(args []string) {
+	fs := flag.NewFlagSet("mcp", flag.ExitOnError)
+	configPath := fs.String("config", "", "Load configuration from this file instead of the repository")
+	profile := fs.String("profile", "", "Overlay this named profile from the config's \"profiles\"")
+	verbose := fs.Bool("verbose", false, "Show the raw API response when a request fails")
+	fs.Parse(args)
+
+	application := newGenerateApp(*configPath, *profile, git.DiffOptions{}, outputFlags{quiet: true, verbose: *verbose})
+
+	// Stdout carries the protocol; anything else printed goes to stderr,
+	// which MCP clients show as the server's log
+	protocol := os.Stdout
+	os.Stdout = os.Stderr
+
+	srv := &mcp.Server{
+		Name:    "generate-commit",
+		Version: app.CurrentBuild().Version,
+		Tools:   application.MCPTools(),
+	}
+	if err := srv.Serve(os.Stdin, protocol); err != nil {
+		exitWithError(err)
+	}
+}
+
+func runAction(args []string) {
+	fs := flag.NewFlagSet("action", flag.ExitOnError)
+	base := fs.String("base", "", "Revision to diff the pull request against (default: origin/$GITHUB_BASE_REF)")
+	comment := fs.Bool("comment", false, "Post the message as a pull request comment, updated on reruns (needs GITHUB_TOKEN)")
+	strict := fs.Bool("strict", false, "Fail the step when no message can be suggested; by default it only warns")
+	configPath := fs.String("config", "", "Load configuration from this file instead of the repository")
+	profile := fs.String("profile", "", "Overlay this named profile from the config's \"profiles\"")
+	var output outputFlags
+	output.register(fs)
+	fs.Parse(args)
+
+	// Runners have no terminal, so progress is printed as lines
+	output.plain = true
+	application, _, err := loadGenerateApp(*configPath, *profile, git.DiffOptions{}, output)
+	if err != nil {
+		if *strict {
+			exitWithError(err)
+		}
+		app.PrintWorkflowWarning(err)
+		return
+	}
+	opts := app.ActionOptions{Base: *base, Comment: *comment, Strict: *strict}
+	if err := application.Action(opts); err != nil {
+		exitWithError(err)
+	}
+}
+
+func runSplit(args []string) {
+	fs := flag.NewFlagSet("split", flag.ExitOnError)
+	var groups stringList
+	fs.Var(&groups, "group", "Comma-separated globs selecting the files of one commit (repeatable, in commit order; default: ask the model for a plan)")
+	byDir := fs.Bool("by-dir", false, "Commit each package directory separately instead of using --group or a model plan")
+	yes := fs.Bool("yes", false, "Commit without asking for confirmation")
+	nonInteractive := fs.Bool("non-interactive", false, "Never prompt, even on a terminal (requires --yes)")
+	configPath := fs.String("config", "", "Load configuration from this file instead of the repository")
+	profile := fs.String("profile", "", "Overlay this named profile from the config's \"profiles\"")
+	var output outputFlags
+	output.register(fs)
+	fs.Parse(args)
+
+	application := newGenerateApp(*configPath, *profile, git.DiffOptions{}, output)
+	if !*yes {
+		if terminal := promptTerminal(*nonInteractive, false); terminal != nil {
+			defer terminal.Close()
+			application.Terminal = terminal
+		}
+	}
+
+	if err := application.CommitSplit(app.SplitOptions{Groups: groups, ByDir: *byDir, Yes: *yes}); err != nil {
+		exitWithError(err)
+	}
+}
+
+func runChangelog(args []string) {
+	fs := flag.NewFlagSet("changelog", flag.ExitOnError)
+	style := fs.String("style", ai.ChangelogKeepAChangelog, "Changelog style: keepachangelog or conventional")
+	write := fs.Bool("write", false, "Prepend the section to CHANGELOG.md instead of printing it")
+	configPath := fs.String("config", "", "Load configuration from this file instead of the repository")
+	profile := fs.String("profile", "", "Overlay this named profile from the config's \"profiles\"")
+	var output outputFlags
+	output.register(fs)
+
+	// The range may come before the flags: changelog v1.2.0..HEAD --write
+	var revRange string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		revRange, args = args[0], args[1:]
+	}
+	fs.Parse(args)
+	if revRange == "" && fs.NArg() == 1 {
+		revRange = fs.Arg(0)
+	} else if revRange == "" || fs.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, "Usage: generate-commit changelog <from>..<to> [--style keepachangelog|conventional] [--write]")
+		os.Exit(1)
+	}
+
+	application := newGenerateApp(*configPath, *profile, git.DiffOptions{}, output)
+	if err := application.Changelog(app.ChangelogOptions{Range: revRange, Style: *style, Write: *write}); err != nil {
+		exitWithError(err)
+	}
+}
+
+func runPR(args []string) {
+	fs := flag.NewFlagSet("pr", flag.ExitOnError)
+	base := fs.String("base", "", "Branch the pull request targets (default: the upstream, then main, master or origin/HEAD)")
+	jsonOutput := fs.Bool("json", false, "Print {\"title\", \"body\"} as JSON instead of markdown")
+	var only, ignore stringList
+	fs.Var(&only, "only", "Only describe changed paths matching this glob (repeatable)")
+	fs.Var(&ignore, "ignore", "Leave changed paths matching this glob out of the description (repeatable)")
+	configPath := fs.String("config", "", "Load configuration from this file instead of the repository")
+	profile := fs.String("profile", "", "Overlay this named profile from the config's \"profiles\"")
+	var output outputFlags
+	output.register(fs)
+	fs.Parse(args)
+
+	diffOpts := git.DiffOptions{Only: only, Ignore: ignore}
+	for _, patterns := range [][]string{diffOpts.Only, diffOpts.Ignore} {
+		if err := git.ValidateGlobs(patterns); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	application := newGenerateApp(*configPath, *profile, diffOpts, output)
+	if err := application.PRDescription(app.PRDescriptionOptions{Base: *base, JSON: *jsonOutput}); err != nil {
+		exitWithJSONError(err, *jsonOutput)
+	}
+}
+
+func runExplain(args []string) {
+	fs := flag.NewFlagSet("explain", flag.ExitOnError)
+	file := fs.String("file", "", "Only explain the staged changes to this path (a glob, as for --only)")
+	jsonOutput := fs.Bool("json", false, "Print {\"file\", \"explanation\"} as JSON instead of text")
+	configPath := fs.String("config", "", "Load configuration from this file instead of the repository")
+	profile := fs.String("profile", "", "Overlay this named profile from the config's \"profiles\"")
+	var output outputFlags
+	output.register(fs)
+	fs.Parse(args)
+	if fs.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, "Usage: generate-commit explain [--file <path>] [--json]")
+		os.Exit(1)
+	}
+
+	var diffOpts git.DiffOptions
+	if *file != "" {
+		diffOpts.Only = []string{*file}
+		if err := git.ValidateGlobs(diffOpts.Only); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	application := newGenerateApp(*configPath, *profile, diffOpts, output)
+	if err := application.Explain(app.ExplainOptions{File: *file, JSON: *jsonOutput}); err != nil {
+		exitWithJSONError(err, *jsonOutput)
+	}
+}
+
+func runReview(args []string) {
+	fs := flag.NewFlagSet("review", flag.ExitOnError)
+	failOn := fs.String("fail-on", "", "Exit with status 1 when a finding is this severe or worse: bug, issue or nit")
+	configPath := fs.String("config", "", "Load configuration from this file instead of the repository")
+	profile := fs.String("profile", "", "Overlay this named profile from the config's \"profiles\"")
+	var output outputFlags
+	output.register(fs)
+	fs.Parse(args)
+	if fs.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, "Usage: generate-commit review [--fail-on bug|issue|nit]")
+		os.Exit(1)
+	}
+
+	application := newGenerateApp(*configPath, *profile, git.DiffOptions{}, output)
+	if err := application.Review(app.ReviewOptions{FailOn: *failOn}); err != nil {
+		exitWithError(err)
+	}
+}
+
+func runSemver(args []string) {
+	fs := flag.NewFlagSet("semver", flag.ExitOnError)
+	revRange := fs.String("range", "", "Commits to look at, e.g. v1.2.0..HEAD (default: from the highest version tag to HEAD)")
+	staged := fs.Bool("staged", false, "Also ask the model whether the staged changes break compatibility")
+	next := fs.Bool("next", false, "Print the next version instead of the bump")
+	jsonOutput := fs.Bool("json", false, "Print the bump, current and next version and the reasons as JSON")
+	configPath := fs.String("config", "", "Load configuration from this file instead of the repository")
+	profile := fs.String("profile", "", "Overlay this named profile from the config's \"profiles\"")
+	var output outputFlags
+	output.register(fs)
+	fs.Parse(args)
+	if fs.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, "Usage: generate-commit semver [--range <from>..<to>] [--staged] [--next | --json]")
+		os.Exit(1)
+	}
+
+	application := newGenerateApp(*configPath, *profile, git.DiffOptions{}, output)
+	if err := application.Semver(app.SemverOptions{Range: *revRange, Staged: *staged, Next: *next, JSON: *jsonOutput}); err != nil {
+		exitWithJSONError(err, *jsonOutput)
+	}
+}
+
+func runHistory(args []string) {
+	fs := flag.NewFlagSet("history", flag.ExitOnError)
+	commit := fs.Bool("commit", false, "With use, commit the staged changes with the message instead of printing it")
+	configPath := fs.String("config", "", "Load configuration from this file instead of the repository")
+	profile := fs.String("profile", "", "Overlay this named profile from the config's \"profiles\"")
+	var output outputFlags
+	output.register(fs)
+
+	usage := func() {
+		fmt.Fprintln(os.Stderr, "Usage: generate-commit history [use <n> [--commit]]")
+		os.Exit(1)
+	}
+	var use int
+	if len(args) > 0 && args[0] == "use" {
+		if len(args) < 2 {
+			usage()
+		}
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 1 {
+			usage()
+		}
+		use, args = n, args[2:]
+	}
+	fs.Parse(args)
+	if fs.NArg() > 0 {
+		usage()
+	}
+
+	application := newGenerateApp(*configPath, *profile, git.DiffOptions{}, output)
+	if err := application.History(app.HistoryOptions{Use: use, Commit: *commit}); err != nil {
+		exitWithError(err)
+	}
+}
+
+func runReword(args []string) {
+	fs := flag.NewFlagSet("reword", flag.ExitOnError)
+	apply := fs.Bool("apply", false, "Rewrite the commits with the new messages instead of only printing them")
+	force := fs.Bool("force", false, "With --apply, also rewrite commits already on a protected branch or a remote")
+	configPath := fs.String("config", "", "Load configuration from this file instead of the repository")
+	profile := fs.String("profile", "", "Overlay this named profile from the config's \"profiles\"")
+	var output outputFlags
+	output.register(fs)
+
+	// The range may come before the flags: reword main..HEAD --apply
+	var revRange string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		revRange, args = args[0], args[1:]
+	}
+	fs.Parse(args)
+	if revRange == "" && fs.NArg() == 1 {
+		revRange = fs.Arg(0)
+	} else if revRange == "" || fs.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, "Usage: generate-commit reword <from>..<to> [--apply [--force]]")
+		os.Exit(1)
+	}
+	if *force && !*apply {
+		fmt.Fprintln(os.Stderr, "Error: --force only makes sense with --apply")
+		os.Exit(1)
+	}
+
+	application := newGenerateApp(*configPath, *profile, git.DiffOptions{}, output)
+	if err := application.Reword(app.RewordOptions{Range: revRange, Apply: *apply, Force: *force}); err != nil {
+		exitWithError(err)
+	}
+}
+
+func runImproveLast(args []string) {
+	fs := flag.NewFlagSet("improve-last", flag.ExitOnError)
+	yes := fs.Bool("yes", false, "Amend the last commit without asking for confirmation")
+	force := fs.Bool("force", false, "With --yes, also amend a commit already on a protected branch or a remote")
+	nonInteractive := fs.Bool("non-interactive", false, "Never prompt, even on a terminal; only print the message unless --yes is given")
+	configPath := fs.String("config", "", "Load configuration from this file instead of the repository")
+	profile := fs.String("profile", "", "Overlay this named profile from the config's \"profiles\"")
+	var output outputFlags
+	output.register(fs)
+	fs.Parse(args)
+	if fs.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, "Usage: generate-commit improve-last [--yes [--force]]")
+		os.Exit(1)
+	}
+
+	application := newGenerateApp(*configPath, *profile, git.DiffOptions{}, output)
+	if !*yes {
+		if terminal := promptTerminal(*nonInteractive, false); terminal != nil {
+			defer terminal.Close()
+			application.Terminal = terminal
+		}
+	}
+	if err := application.ImproveLast(app.ImproveLastOptions{Yes: *yes, Force: *force}); err != nil {
+		exitWithError(err)
+	}
+}
+
+func runTag(args []string) {
+	fs := flag.NewFlagSet("tag", flag.ExitOnError)
+	since := fs.String("since", "", "Tag the release starts from (default: the highest version tag below the new one)")
+	create := fs.Bool("create", false, "Create the annotated tag at HEAD with the message")
+	file := fs.String("file", "", "Write the message to this file, for git tag -a -F, instead of printing it")
+	configPath := fs.String("config", "", "Load configuration from this file instead of the repository")
+	profile := fs.String("profile", "", "Overlay this named profile from the config's \"profiles\"")
+	var output outputFlags
+	output.register(fs)
+
+	// The tag name may come before the flags: tag v1.3.0 --since v1.2.0
+	var version string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		version, args = args[0], args[1:]
+	}
+	fs.Parse(args)
+	if version == "" && fs.NArg() == 1 {
+		version = fs.Arg(0)
+	} else if version == "" || fs.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, "Usage: generate-commit tag <version> [--since <tag>] [--create] [--file <path>]")
+		os.Exit(1)
+	}
+
+	application := newGenerateApp(*configPath, *profile, git.DiffOptions{}, output)
+	if err := application.Tag(app.TagOptions{Version: version, Since: *since, Create: *create, File: *file}); err != nil {
+		exitWithError(err)
+	}
+}
+
+func runBranch(args []string) {
+	fs := flag.NewFlagSet("branch", flag.ExitOnError)
+	from := fs.String("from", "", "Describe the work instead of using the staged changes")
+	ticket := fs.String("ticket", "", "Ticket for {ticket} (default: an issue key in --from)")
+	checkout := fs.Bool("checkout", false, "Create the chosen branch and switch to it")
+	pick := fs.Int("pick", 0, "With --checkout, take this suggestion (1-3) instead of asking")
+	nonInteractive := fs.Bool("non-interactive", false, "Never prompt; --checkout takes the first suggestion")
+	configPath := fs.String("config", "", "Load configuration from this file instead of the repository")
+	profile := fs.String("profile", "", "Overlay this named profile from the config's \"profiles\"")
+	var output outputFlags
+	output.register(fs)
+	fs.Parse(args)
+	if fs.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, "Usage: generate-commit branch [--from <description>] [--ticket <key>] [--checkout [--pick <n>]]")
+		os.Exit(1)
+	}
+	if *pick != 0 && !*checkout {
+		exitWithError(errors.New("--pick requires --checkout"))
+	}
+
+	application := newGenerateApp(*configPath, *profile, git.DiffOptions{}, output)
+	if *checkout && *pick == 0 {
+		if terminal := promptTerminal(*nonInteractive, false); terminal != nil {
+			defer terminal.Close()
+			application.Terminal = terminal
+		}
+	}
+	err := application.Branch(app.BranchOptions{Description: *from, Ticket: *ticket, Checkout: *checkout, Pick: *pick})
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		exitWithError(err)
+	}
+}
+
+func runHook(args []string) {
+	if len(args) < 1 {
+		fmt.Fprintf(os.Stderr, "Usage: generate-commit hook <hook-name>\n")
 		os.Exit(1)
 	}
 
-	// Check for API key
-	if cfg.APIKey == "" {
+	switch args[0] {
+	case "pre-commit":
+		application := newGenerateApp("", "", git.DiffOptions{}, outputFlags{})
+		if terminal := promptTerminal(false, true); terminal != nil {
+			defer terminal.Close()
+			application.Terminal = terminal
+		}
+
+		err := application.PreCommitHook()
+		if errors.Is(err, app.ErrHookCommitted) {
+			// Non-zero exit so git does not create a second commit
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if err != nil {
+			exitWithError(err)
+		}
+	case "prepare-commit-msg":
+		hookArgs, err := app.ParseHookRunArgs(args[1:], os.Getenv)
+		if err != nil || hookArgs.MsgFile == "" {
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			}
+			fmt.Fprintf(os.Stderr, "Usage: generate-commit hook prepare-commit-msg [--files <paths>] <msg-file> [source] [sha]\n")
+			os.Exit(1)
+		}
+
+		application := newGenerateApp("", "", git.DiffOptions{Files: hookArgs.Files}, outputFlags{})
+		if err := application.PrepareCommitMsgHook(hookArgs.MsgFile, hookArgs.Source, hookArgs.SHA); err != nil {
+			exitWithError(err)
+		}
+	case "run":
+		// The entrypoint for the pre-commit framework, which runs hooks
+		// without a terminal and passes the message file only when
+		// pass_filenames is true
+		hookArgs, err := app.ParseHookRunArgs(args[1:], os.Getenv)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			fmt.Fprintf(os.Stderr, "Usage: generate-commit hook run [--msg-file <path>] [--files <paths>] [msg-file [source [sha]]]\n")
+			os.Exit(1)
+		}
+		application := newGenerateApp("", "", git.DiffOptions{Files: hookArgs.Files}, outputFlags{})
+		if err := application.HookRun(hookArgs); err != nil {
+			exitWithError(err)
+		}
+	case "commit-msg":
+		fs := flag.NewFlagSet("hook commit-msg", flag.ExitOnError)
+		fix := fs.Bool("fix", false, "Rewrite a non-compliant message with the model instead of rejecting it")
+		fs.Parse(args[1:])
+		if fs.NArg() < 1 {
+			fmt.Fprintf(os.Stderr, "Usage: generate-commit hook commit-msg [--fix] <msg-file>\n")
+			os.Exit(1)
+		}
+
+		var application *app.App
+		if *fix {
+			application = newGenerateApp("", "", git.DiffOptions{}, outputFlags{})
+		} else {
+			// Linting alone needs no model, so it works without an API key
+			configLoader := config.NewConfigLoader()
+			cfg, err := configLoader.LoadConfig()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+				os.Exit(1)
+			}
+			application = app.NewApp(git.NewClient(), config.NewLoader(), configLoader, nil)
+			application.HeaderFormat, _ = ai.ParseHeaderFormat(cfg.HeaderFormat) // validated by LoadConfig
+			application.ScopePolicy = cfg.ScopePolicy
+			application.SubjectStyle = ai.SubjectStyle{Capitalize: cfg.SubjectCapitalize, TrailingPeriod: cfg.SubjectTrailingPeriod}
+			application.Types = cfg.Types
+			application.Scopes = cfg.Scopes
+			application.PathOverrides = pathOverrides(cfg.Paths)
+		}
+		if err := application.CommitMsgHook(fs.Arg(0), app.CommitMsgOptions{Fix: *fix}); err != nil {
+			exitWithError(err)
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown hook: %s\n", args[0])
+		os.Exit(1)
+	}
+}
+
+// newGenerateApp loads the configuration and wires up an App with an AI client.
+// An empty configPath means the repository's .commit-generator-config, and an
+// empty profile the top-level configuration alone. The
+// progress spinner only runs when stdout and stderr are terminals, so hooks,
+// pipes and redirected output never see its frames.
+func newGenerateApp(configPath, profile string, diffOpts git.DiffOptions, output outputFlags) *app.App {
+	application, _ := newGenerateAppWithConfig(configPath, profile, diffOpts, output)
+	return application
+}
+
+// newGenerateAppWithConfig is newGenerateApp that also returns the effective
+// configuration the App was built from, for callers that need it as well
+func newGenerateAppWithConfig(configPath, profile string, diffOpts git.DiffOptions, output outputFlags) (*app.App, *config.Config) {
+	application, cfg, err := loadGenerateApp(configPath, profile, diffOpts, output)
+	if errors.Is(err, errNoAPIKey) {
 		fmt.Fprintf(os.Stderr, "Error: OLLAMA_API_KEY environment variable is not set and not found in config.\n")
 		fmt.Fprintf(os.Stderr, "Please set your Ollama API key:\n")
-		fmt.Fprintf(os.Stderr, "  export OLLAMA_API_KEY=your_api_key\n")
-		fmt.Fprintf(os.Stderr, "  or add it to .commit-generator-config\n")
+		fmt.Fprintf(os.Stderr, "  generate-commit config set-key   (stores it in the OS keychain)\n")
+		fmt.Fprintf(os.Stderr, "  or export OLLAMA_API_KEY=your_api_key\n")
 		os.Exit(1)
 	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	return application, cfg
+}
 
-	aiClient := ai.NewClient(cfg.APIKey, cfg.BaseURL, cfg.Model, cfg.GetTimeout())
+// errNoAPIKey is returned by loadGenerateApp when no API key is configured
+var errNoAPIKey = errors.New("OLLAMA_API_KEY is not set and not found in config")
+
+// loadGenerateApp is newGenerateAppWithConfig for callers that handle the
+// error, such as action, which only warns by default
+func loadGenerateApp(configPath, profile string, diffOpts git.DiffOptions, output outputFlags) (*app.App, *config.Config, error) {
+	rulesLoader := config.NewLoader()
+	configLoader := config.NewConfigLoader()
+	if configPath != "" {
+		configLoader = config.NewConfigLoaderWithPath(configPath)
+	}
+	configLoader.SetProfile(profile)
+
+	// Load configuration
+	cfg, err := configLoader.LoadConfig()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Resolve the API key, which may live in the OS keychain
+	apiKey, err := configLoader.ResolveAPIKey(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	if apiKey == "" {
+		return nil, nil, errNoAPIKey
+	}
+
+	diffOpts.ContextLines = cfg.DiffContextLines
+	diffOpts.MaxFileBytes = cfg.MaxFileBytes
+	backend, _ := git.ParseBackendKind(cfg.GitBackend) // validated by LoadConfig
+	commitBackend, _ := git.ParseCommitBackendKind(cfg.CommitBackend)
+	logger := logging.New(os.Stderr, cfg.LogFormat, output.verbose)
+	gitClient := git.NewClientWithBackend(diffOpts, backend, git.WithCommitBackend(commitBackend), git.WithLogger(logger))
+
+	progress := app.NewProgress(os.Stderr, !output.quiet && !output.plain && isTerminal(os.Stdout) && isTerminal(os.Stderr))
+	progress.SetTimeout(cfg.GetTimeout())
+	aiClient := ai.NewClient(apiKey, cfg.BaseURL, cfg.Model, cfg.GetTimeout(),
+		ai.WithSystemPrompt(cfg.SystemPrompt, cfg.SystemPromptMode),
+		ai.WithProgress(progress),
+		ai.WithStream(cfg.Stream),
+		ai.WithKeepAlive(cfg.KeepAlive),
+		ai.WithRequestsPerMinute(cfg.RequestsPerMinute),
+		ai.WithCache(cfg.Cache),
+		ai.WithAuth(cfg.AuthHeader, cfg.AuthScheme),
+		ai.WithVerbose(output.verbose),
+		ai.WithLogger(logger),
+	)
 	application := app.NewApp(gitClient, rulesLoader, configLoader, aiClient)
+	application.Progress = progress
+	application.Logger = logger
+	application.Quiet = output.quiet
+	application.CheckUpdates = cfg.CheckUpdates
+	application.TestFiles = ai.TestFileOptions{Patterns: cfg.TestPathPatterns, Policy: cfg.TestFilePolicy}
+	application.FastPath = ai.FastPathOptions{
+		Disabled:     !cfg.FastPathEnabled(),
+		Docs:         cfg.FastPathDocs,
+		Config:       cfg.FastPathConfig,
+		Dependencies: cfg.FastPathDeps,
+	}
+	application.ProtectedBranches = cfg.ProtectedBranches
+	application.MaxBodyLength = cfg.MaxBodyLength
+	application.BodyOverflow = cfg.BodyOverflow
+	application.BranchPattern = cfg.BranchPattern
+	application.AnalyzeGo = cfg.AnalyzeGo
+	application.PrependDiffStat = cfg.PrependDiffStat
+	application.Signoff = cfg.Signoff
+	application.IncludeDiffDigest = cfg.IncludeDiffDigest
+	application.AddressBook = cfg.CoAuthors
+	application.Trailers = cfg.Trailers
+	application.Examples = cfg.Examples
+	application.ScopeMap = cfg.ScopeMap
+	application.NoSplit = !cfg.SuggestSplitsEnabled()
+	application.MergeExistingMessage = cfg.MergeExistingMessageEnabled()
+	application.HeaderFormat, _ = ai.ParseHeaderFormat(cfg.HeaderFormat) // validated by LoadConfig
+	application.ScopePolicy = cfg.ScopePolicy
+	application.SubjectStyle = ai.SubjectStyle{Capitalize: cfg.SubjectCapitalize, TrailingPeriod: cfg.SubjectTrailingPeriod}
+	application.Types = cfg.Types
+	application.Scopes = cfg.Scopes
+	application.PathOverrides = pathOverrides(cfg.Paths)
+	return application, cfg, nil
+}
 
-	if err := application.Run(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+// pathOverrides converts the paths section of the config, in the order of
+// its globs
+func pathOverrides(paths map[string]config.PathConfig) []app.PathOverride {
+	patterns := make([]string, 0, len(paths))
+	for pattern := range paths {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+
+	overrides := make([]app.PathOverride, 0, len(patterns))
+	for _, pattern := range patterns {
+		path := paths[pattern]
+		override := app.PathOverride{
+			Pattern:        pattern,
+			RulesFile:      path.RulesFile,
+			ScopePolicy:    path.ScopePolicy,
+			Capitalize:     path.SubjectCapitalize,
+			TrailingPeriod: path.SubjectTrailingPeriod,
+			Scopes:         path.Scopes,
+			Language:       path.Language,
+		}
+		if path.HeaderFormat != "" {
+			override.HeaderFormat, _ = ai.ParseHeaderFormat(path.HeaderFormat) // validated by LoadConfig
+		}
+		overrides = append(overrides, override)
+	}
+	return overrides
+}
+
+// exitWithError prints err and exits with the code app.ExitCode maps it to
+func exitWithError(err error) {
+	if errors.Is(err, app.ErrCancelled) {
+		fmt.Fprintln(os.Stderr, "Commit aborted by user")
+		os.Exit(app.ExitCode(err))
+	}
+	fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	os.Exit(app.ExitCode(err))
+}
+
+// exitWithJSONError is exitWithError for --json output: the error and its
+// exit code are printed to stdout as {"error", "code"}
+func exitWithJSONError(err error, jsonOutput bool) {
+	if !jsonOutput {
+		exitWithError(err)
 	}
+	data, _ := json.Marshal(struct {
+		Error string `json:"error"`
+		Code  int    `json:"code"`
+	}{err.Error(), app.ExitCode(err)})
+	fmt.Println(string(data))
+	os.Exit(app.ExitCode(err))
 }
 
 func printHelp() {
 	fmt.Println("AI Commit Message Generator")
 	fmt.Println("")
 	fmt.Println("Usage:")
-	fmt.Println("  generate-commit [command]")
+	fmt.Println("  generate-commit [command] [flags]")
 	fmt.Println("")
 	fmt.Println("Commands:")
-	fmt.Println("  init       Initialize repository with config, rules, and pre-commit hook")
+	fmt.Println("  init       Initialize repository with config, rules, and git hooks")
+	fmt.Println("  deinit     Remove the installed hooks (--purge also deletes config and rules)")
+	fmt.Println("  doctor     Check the environment and configuration (--json for JSON output)")
+	fmt.Println("  lint-rules Check .git-commit-rules-for-ai for conflicts and bloat (--strict for CI)")
+	fmt.Println("  config     Get, set, list or validate configuration (get|set|set-key|list|validate)")
 	fmt.Println("  generate   Generate commit message from staged changes (default)")
+	fmt.Println("  split      Commit the staged changes as several commits, per --group or as the model plans")
+	fmt.Println("  changelog  Write a changelog section for a commit range, e.g. v1.2.0..HEAD")
+	fmt.Println("  pr         Write a pull request title and description for the current branch")
+	fmt.Println("  explain    Explain in plain language what the staged changes do and why")
+	fmt.Println("  review     Ask the model to review the staged changes before committing")
+	fmt.Println("  reword     Suggest better messages for the commits in a range; --apply rewrites them")
+	fmt.Println("  improve-last Suggest a better message for the last commit and offer to amend it")
+	fmt.Println("  tag        Write an annotated tag message for a release; --create tags HEAD")
+	fmt.Println("  branch     Suggest branch names for the staged changes; --checkout switches to one")
+	fmt.Println("  semver     Recommend a major, minor or patch bump from the commits since the last tag")
+	fmt.Println("  history    List recently generated messages; 'history use <n>' prints or commits one")
+	fmt.Println("  off, on    Turn the installed hooks off or back on for this repository")
+	fmt.Println("  hook       Entrypoint used by the installed git hooks")
+	fmt.Println("  serve      Answer generate, status and config requests from editors on a socket")
+	fmt.Println("  mcp        Offer the staged diff, message generation and commits as MCP tools on stdio")
+	fmt.Println("  action     Suggest a squash-merge message for a pull request in a GitHub Actions job")
+	fmt.Println("  version    Print the version, commit, build date, Go version and platform (--json)")
 	fmt.Println("  help       Show this help message")
 	fmt.Println("")
+	fmt.Println("Init flags:")
+	fmt.Println("  -f, --force          Reinitialize an already initialized repository")
+	fmt.Println("  --hook-type <types>  Comma-separated: pre-commit (default), prepare-commit-msg,")
+	fmt.Println("                       commit-msg, or both (pre-commit and prepare-commit-msg)")
+	fmt.Println("  --on-existing <mode> For hooks not written by init: abort (default), backup, or chain")
+	fmt.Println("  --lint-fix           Make the commit-msg hook fix messages instead of rejecting them")
+	fmt.Println("  --hook-shell <shell> Write the hooks for sh (default), powershell, or batch (default on Windows)")
+	fmt.Println("  -y, --yes            Skip the setup questions and write the default config")
+	fmt.Println("  --framework <name>   Add the hook to pre-commit, husky or lefthook instead of .git/hooks;")
+	fmt.Println("                       auto picks the framework the repository uses")
+	fmt.Println("")
+	fmt.Println("Config flags:")
+	fmt.Println("  --global           Use the per-user config (~/.config/ai-commit/config)")
+	fmt.Println("  --config <path>    Use this file instead of the repository's config")
+	fmt.Println("  --file-fallback    set-key: store the key in a 0600 file if the OS keychain is unavailable")
+	fmt.Println("")
+	fmt.Println("Generate flags:")
+	fmt.Println("  -i, --interactive  Accept, edit, regenerate or copy the message, then commit")
+	fmt.Println("  --config <path>    Load configuration from this file instead of the repository")
+	fmt.Println("  --refine <text>    Revise the generated message with an instruction (repeatable)")
+	fmt.Println("  --preview          Show the final message, author/committer and files without committing")
+	fmt.Println("  --show-files       List the files to be committed (A/M/D/R) above the message")
+	fmt.Println("  --explain          Also print why the model chose the type and scope (never committed)")
+	fmt.Println("  --copy             Also place the message on the clipboard, e.g. for an IDE's commit box")
+	fmt.Println("  --no-split         Always write a single message, however large the change")
+	fmt.Println("  -s, --signoff      Append a Signed-off-by trailer from user.name and user.email")
+	fmt.Println("  --co-author <who>  Append a Co-authored-by trailer: \"Name <email>\" or a co_authors")
+	fmt.Println("                     shortcut from the config (repeatable)")
+	fmt.Println("  --by-dir           One commit per package directory (same as 'split --by-dir')")
+	fmt.Println("  --only <glob>      Only describe staged paths matching the glob (repeatable)")
+	fmt.Println("  --ignore <glob>    Leave matching staged paths out of the message (repeatable)")
+	fmt.Println("                     Filters change the message only; all staged changes are committed")
+	fmt.Println("  --pr-description   Print a pull request title and markdown body (same as 'pr')")
+	fmt.Println("  --base <branch>    Branch the pull request targets (as for pr)")
+	fmt.Println("  -y, --yes          Commit the generated message without prompting")
+	fmt.Println("  --non-interactive  Never prompt or open an editor; also AI_COMMIT_NON_INTERACTIVE=1")
+	fmt.Println("                     Implied when stdin is not a terminal (CI, GUI git clients)")
+	fmt.Println("  --stdin            Describe a diff piped on stdin; no repository or staged changes needed")
+	fmt.Println("  --include-new      Also describe untracked files; they are not staged or committed")
+	fmt.Println("  -q, --quiet        Print only the message, without colors or progress")
+	fmt.Println("  --no-trailing-newline")
+	fmt.Println("                     Print the message without a newline after it")
+	fmt.Println("  --plain            Print progress as plain lines instead of a spinner")
+	fmt.Println("  --verbose          Show the raw API response when a request fails, and how commits are signed")
+	fmt.Println("")
+	fmt.Println("Split flags:")
+	fmt.Println("  --group <globs>    Comma-separated globs for one commit (repeatable, in commit order);\n                     without it the model groups the staged files")
+	fmt.Println("  --by-dir           One commit per package directory; single-file groups can be merged")
+	fmt.Println("  --yes              Commit without asking for confirmation")
+	fmt.Println("  --non-interactive  Never prompt, even on a terminal (requires --yes)")
+	fmt.Println("  -q, --quiet, --plain  As for generate")
+	fmt.Println("  --config <path>    Load configuration from this file instead of the repository")
+	fmt.Println("")
+	fmt.Println("Changelog flags:")
+	fmt.Println("  --style <style>    keepachangelog (default) or conventional")
+	fmt.Println("  --write            Prepend the section to CHANGELOG.md instead of printing it")
+	fmt.Println("  -q, --quiet, --plain, --config, --profile  As for generate")
+	fmt.Println("")
+	fmt.Println("PR flags:")
+	fmt.Println("  --base <branch>    Branch the pull request targets (default: the upstream,")
+	fmt.Println("                     then main, master or origin/HEAD)")
+	fmt.Println("  --json             Print {\"title\", \"body\"} as JSON for gh pr create")
+	fmt.Println("  --only, --ignore, -q, --quiet, --plain, --config, --profile  As for generate")
+	fmt.Println("")
+	fmt.Println("Explain flags:")
+	fmt.Println("  --file <path>      Only explain the staged changes to this path (a glob, as for --only)")
+	fmt.Println("  --json             Print {\"file\", \"explanation\"} as JSON")
+	fmt.Println("  -q, --quiet, --plain, --config, --profile  As for generate")
+	fmt.Println("")
+	fmt.Println("Review flags:")
+	fmt.Println("  --fail-on <level>  Exit with status 1 on a finding this severe or worse: bug, issue or nit")
+	fmt.Println("  -q, --quiet, --plain, --config, --profile  As for generate")
+	fmt.Println("")
+	fmt.Println("Reword flags:")
+	fmt.Println("  --apply            Rewrite the commits; the range must end at HEAD")
+	fmt.Println("  --force            With --apply, also rewrite commits on a protected branch or a remote")
+	fmt.Println("  -q, --quiet, --plain, --config, --profile  As for generate")
+	fmt.Println("")
+	fmt.Println("Tag flags:")
+	fmt.Println("  --since <tag>      Tag the release starts from (default: the highest version tag")
+	fmt.Println("                     below the new one)")
+	fmt.Println("  --create           Create the annotated tag at HEAD with the message")
+	fmt.Println("  --file <path>      Write the message to a file for git tag -a -F instead of printing it")
+	fmt.Println("  -q, --quiet, --plain, --config, --profile  As for generate")
+	fmt.Println("")
+	fmt.Println("Branch flags:")
+	fmt.Println("  --from <text>      Describe the work instead of using the staged changes")
+	fmt.Println("  --ticket <key>     Ticket for {ticket} in branch_pattern (default: an issue key in --from)")
+	fmt.Println("  --checkout         Create the chosen branch and switch to it")
+	fmt.Println("  --pick <n>         With --checkout, take suggestion n instead of asking")
+	fmt.Println("  --non-interactive, -q, --quiet, --plain, --config, --profile  As for generate")
+	fmt.Println("")
+	fmt.Println("Semver flags:")
+	fmt.Println("  --range <range>    Commits to look at (default: from the highest version tag to HEAD)")
+	fmt.Println("  --staged           Also ask the model whether the staged changes break compatibility")
+	fmt.Println("  --next             Print the next version, e.g. v1.3.0, instead of the bump")
+	fmt.Println("  --json             Print the bump, current and next version and the reasons as JSON")
+	fmt.Println("  -q, --quiet, --plain, --config, --profile  As for generate")
+	fmt.Println("")
+	fmt.Println("Version flags:")
+	fmt.Println("  --json             Print the build metadata as JSON")
+	fmt.Println("  --config <path>    Read check_updates from this file instead of the repository's config")
+	fmt.Println("")
+	fmt.Println("History flags:")
+	fmt.Println("  use <n>            Print message n of the list (1 is the newest)")
+	fmt.Println("  --commit           With use, commit the staged changes with the message")
+	fmt.Println("  --config, --profile  As for generate")
+	fmt.Println("")
+	fmt.Println("Serve flags:")
+	fmt.Println("  --socket <path>    Unix socket, or named pipe on Windows, to listen on (required)")
+	fmt.Println("  --timeout <d>      Give up on a request after this long (default 2m)")
+	fmt.Println("  --verbose, --config, --profile  As for generate")
+	fmt.Println("")
+	fmt.Println("MCP flags:")
+	fmt.Println("  --verbose, --config, --profile  As for generate")
+	fmt.Println("")
+	fmt.Println("Action flags:")
+	fmt.Println("  --base <rev>       Revision to diff against (default: origin/$GITHUB_BASE_REF)")
+	fmt.Println("  --comment          Post the message on the pull request, updating it on reruns")
+	fmt.Println("  --strict           Fail the step when no message can be suggested instead of warning")
+	fmt.Println("  -q, --quiet, --verbose, --config, --profile  As for generate")
+	fmt.Println("")
 	fmt.Println("Examples:")
 	fmt.Println("  generate-commit init              # Initialize the repository")
+	fmt.Println("  generate-commit init --hook-type prepare-commit-msg,commit-msg")
+	fmt.Println("  generate-commit config set timeout_seconds 2m")
+	fmt.Println("  generate-commit config list --global")
+	fmt.Println("  generate-commit config set-key --global  # Store the API key in the OS keychain")
 	fmt.Println("  generate-commit generate          # Generate commit message")
+	fmt.Println("  generate-commit -i                # Generate, review and commit")
+	fmt.Println("  generate-commit --yes             # Generate and commit without prompting (CI)")
+	fmt.Println("  generate-commit --refine \"make it shorter\"")
+	fmt.Println("  generate-commit --ignore go.sum --ignore 'vendor/'")
+	fmt.Println("  generate-commit -y --signoff --co-author jane")
+	fmt.Println("  generate-commit pr --base develop > pr.md")
+	fmt.Println("  generate-commit pr --json | jq -r .body")
+	fmt.Println("  generate-commit explain --file internal/auth/login.go")
+	fmt.Println("  generate-commit review --fail-on issues")
+	fmt.Println("  generate-commit reword main..HEAD --apply")
+	fmt.Println("  generate-commit improve-last --yes")
+	fmt.Println("  generate-commit tag v1.3.0 --create")
+	fmt.Println("  generate-commit branch --from \"PROJ-42 add a login form\" --checkout")
+	fmt.Println("  generate-commit semver --next     # e.g. v1.3.0")
+	fmt.Println("  generate-commit history use 1 --commit")
+	fmt.Println("  generate-commit serve --socket /tmp/generate-commit.sock")
+	fmt.Println("  git diff main...feature | generate-commit --stdin")
+	fmt.Println("  generate-commit split             # Commit the staged files in the model's groups")
+	fmt.Println("  generate-commit --by-dir          # One commit per package directory")
+	fmt.Println("  generate-commit changelog v1.2.0..HEAD --write")
+	fmt.Println("  generate-commit split --group 'internal/ai/' --group 'cmd/,README.md'")
 	fmt.Println("  generate-commit                   # Same as 'generate'")
+	fmt.Println("")
+	fmt.Println("Exit codes:")
+	fmt.Println("  0 success, 1 other error, 2 not a git repository, 3 no staged changes,")
+	fmt.Println("  4 AI provider unavailable, 5 API key rejected, 6 invalid options, 130 cancelled")
 }
@@ -0,0 +1,26 @@
+package ai
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// APIError is returned when the provider answers with a status other than
+// 200 OK and 429 Too Many Requests
+type APIError struct {
+	// StatusCode is the HTTP status code of the response
+	StatusCode int
+	// Status is the status line, e.g. "401 Unauthorized"
+	Status string
+	// Body is the response body
+	Body string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API returned error: %s (body: %s)", e.Status, e.Body)
+}
+
+// IsAuth reports whether the provider rejected the API key
+func (e *APIError) IsAuth() bool {
+	return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+}
@@ -0,0 +1,51 @@
+package ai
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	// DefaultAuthHeader is the header the API key is sent in by default
+	DefaultAuthHeader = "Authorization"
+	// DefaultAuthScheme is the prefix of the key in DefaultAuthHeader
+	DefaultAuthScheme = "Bearer"
+)
+
+// SetAuth puts apiKey on h as header says, e.g. "Authorization: Bearer
+// <key>" or "api-key: <key>". An empty header means DefaultAuthHeader. An
+// empty scheme means DefaultAuthScheme for DefaultAuthHeader and no prefix
+// for any other header.
+func SetAuth(h http.Header, header, scheme, apiKey string) {
+	if header == "" {
+		header = DefaultAuthHeader
+	}
+	if scheme == "" && http.CanonicalHeaderKey(header) == DefaultAuthHeader {
+		scheme = DefaultAuthScheme
+	}
+	if scheme != "" {
+		apiKey = scheme + " " + apiKey
+	}
+	h.Set(header, apiKey)
+}
+
+// ValidateAuthHeader checks that name can be used as an HTTP header name
+func ValidateAuthHeader(name string) error {
+	for _, r := range name {
+		if r <= ' ' || r >= 0x7f || strings.ContainsRune(`"(),/:;<=>?@[\]{}`, r) {
+			return fmt.Errorf("%q is not a valid HTTP header name", name)
+		}
+	}
+	return nil
+}
+
+// WithAuth sends the API key in header with scheme as its prefix instead of
+// "Authorization: Bearer", for gateways with their own auth. See SetAuth
+// for the defaults.
+func WithAuth(header, scheme string) Option {
+	return func(c *OllamaClient) {
+		c.authHeader = header
+		c.authScheme = scheme
+	}
+}
@@ -0,0 +1,58 @@
+package ai
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestOllamaClient_Auth(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []Option
+		header   string
+		expected string
+	}{
+		{name: "Bearer by default", header: "Authorization", expected: "Bearer test-api-key"},
+		{name: "Other scheme", opts: []Option{WithAuth("", "Token")}, header: "Authorization", expected: "Token test-api-key"},
+		{name: "Custom header without a scheme", opts: []Option{WithAuth("api-key", "")}, header: "Api-Key", expected: "test-api-key"},
+		{name: "Custom header and scheme", opts: []Option{WithAuth("X-Gateway-Auth", "Key")}, header: "X-Gateway-Auth", expected: "Key test-api-key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var received http.Header
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received = r.Header.Clone()
+				w.Write([]byte(`{"response": "feat: added login", "done": true}`))
+			}))
+			defer server.Close()
+
+			client := NewClient("test-api-key", server.URL+"/api/generate", "", time.Second, tt.opts...)
+			if _, err := client.GenerateCommitMessage(CommitRequest{Diff: "diff"}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := received.Get(tt.header); got != tt.expected {
+				t.Errorf("expected %s: %q, got %q", tt.header, tt.expected, got)
+			}
+			if tt.header != "Authorization" && received.Get("Authorization") != "" {
+				t.Errorf("expected no Authorization header, got %q", received.Get("Authorization"))
+			}
+		})
+	}
+}
+
+func TestValidateAuthHeader(t *testing.T) {
+	for _, name := range []string{"", "Authorization", "api-key", "X-Api_Key.1"} {
+		if err := ValidateAuthHeader(name); err != nil {
+			t.Errorf("expected %q to be valid, got %v", name, err)
+		}
+	}
+	for _, name := range []string{"api key", "api-key:", "Authorization\n"} {
+		if err := ValidateAuthHeader(name); err == nil {
+			t.Errorf("expected %q to be rejected", name)
+		}
+	}
+}
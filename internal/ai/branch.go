@@ -0,0 +1,89 @@
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// BranchRequest holds what branch names are suggested from: the staged
+// diff, or a description of the work when nothing is staged yet
+type BranchRequest struct {
+	Diff        string
+	Description string
+	// Count is how many suggestions to ask for
+	Count int
+}
+
+// BranchSuggestion is one proposed branch: a Conventional Commits type and
+// a few words describing the work, which the caller turns into a name
+type BranchSuggestion struct {
+	Type        string `json:"type"`
+	Description string `json:"description"`
+}
+
+// branchIntro opens the branch prompt unless a system prompt replaces it
+const branchIntro = "You are an expert software engineer who names git branches so that their purpose is clear at a glance."
+
+// SuggestBranches asks the model for branch name suggestions. Suggestions
+// without a description are dropped.
+func (c *OllamaClient) SuggestBranches(req BranchRequest) ([]BranchSuggestion, error) {
+	c.phase(PhaseBuildingPrompt)
+	response, err := c.send(c.buildBranchPrompt(req), "json")
+	if err != nil {
+		return nil, err
+	}
+
+	c.phase(PhasePostProcessing)
+	start := strings.Index(response, "{")
+	end := strings.LastIndex(response, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("failed to parse branch suggestions: no JSON object in the response")
+	}
+	var parsed struct {
+		Branches []BranchSuggestion `json:"branches"`
+	}
+	if err := json.Unmarshal([]byte(response[start:end+1]), &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse branch suggestions: %w", err)
+	}
+
+	var suggestions []BranchSuggestion
+	for _, s := range parsed.Branches {
+		s.Type = strings.ToLower(strings.TrimSpace(s.Type))
+		s.Description = strings.TrimSpace(s.Description)
+		if s.Description != "" {
+			suggestions = append(suggestions, s)
+		}
+	}
+	if len(suggestions) == 0 {
+		return nil, fmt.Errorf("the model suggested no branch names")
+	}
+	return suggestions, nil
+}
+
+func (c *OllamaClient) buildBranchPrompt(req BranchRequest) string {
+	var sb strings.Builder
+	if c.systemPrompt == "" || c.systemPromptMode == SystemPromptPrepend {
+		sb.WriteString(branchIntro + "\n\n")
+	}
+
+	sb.WriteString(fmt.Sprintf("Suggest %d different names for the git branch this work will be done on.\n\n", req.Count))
+	sb.WriteString("Rules:\n")
+	sb.WriteString("- type is the Conventional Commits type of the work: feat, fix, docs, style, refactor, perf, test, build, ci, chore or revert.\n")
+	sb.WriteString("- description is 2 to 5 lowercase words in the imperative, e.g. \"add login form\". No ticket numbers, no punctuation.\n")
+	sb.WriteString("- Make the suggestions differ in wording or focus, best first.\n\n")
+	sb.WriteString("Respond with JSON only, in exactly this shape:\n")
+	sb.WriteString(`{"branches": [{"type": "feat", "description": "add login form"}]}`)
+	sb.WriteString("\n\n")
+
+	if req.Description != "" {
+		sb.WriteString("Description of the work:\n")
+		sb.WriteString(req.Description)
+		sb.WriteString("\n")
+	}
+	if req.Diff != "" {
+		sb.WriteString("\nStaged changes:\n")
+		sb.WriteString(req.Diff)
+	}
+	return sb.String()
+}
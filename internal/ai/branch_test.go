@@ -0,0 +1,76 @@
+package ai
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOllamaClient_SuggestBranches(t *testing.T) {
+	tests := []struct {
+		name          string
+		response      string
+		expected      []BranchSuggestion
+		expectedError string
+	}{
+		{
+			name:     "Suggestions are cleaned up",
+			response: "```json\n" + `{"branches": [{"type": " Feat", "description": "add login form "}, {"type": "fix", "description": ""}, {"type": "feat", "description": "support sso login"}]}` + "\n```",
+			expected: []BranchSuggestion{
+				{Type: "feat", Description: "add login form"},
+				{Type: "feat", Description: "support sso login"},
+			},
+		},
+		{
+			name:          "No suggestions",
+			response:      `{"branches": []}`,
+			expectedError: "suggested no branch names",
+		},
+		{
+			name:          "Not JSON",
+			response:      "feat/add-login",
+			expectedError: "failed to parse branch suggestions",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body ollamaRequest
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					t.Errorf("failed to decode request body: %v", err)
+				}
+				response, _ := json.Marshal(ollamaResponse{Response: tt.response, Done: true})
+				w.Write(response)
+			}))
+			defer server.Close()
+
+			client := NewClient("test-api-key", server.URL+"/api/generate", "", time.Second)
+			suggestions, err := client.SuggestBranches(BranchRequest{Description: "Add a login form", Count: 3})
+			if body.Format != "json" {
+				t.Errorf("expected a JSON response to be requested, got format %q", body.Format)
+			}
+			for _, want := range []string{"Suggest 3 different names", "Description of the work:\nAdd a login form\n"} {
+				if !strings.Contains(body.Prompt, want) {
+					t.Errorf("expected prompt to contain %q, got:\n%s", want, body.Prompt)
+				}
+			}
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("expected error containing %q, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SuggestBranches failed: %v", err)
+			}
+			if !reflect.DeepEqual(suggestions, tt.expected) {
+				t.Errorf("expected %+v, got %+v", tt.expected, suggestions)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// BreakingCheck is the model's verdict on whether a change breaks its users
+type BreakingCheck struct {
+	Breaking bool `json:"breaking"`
+	// Reason says what breaks, in one sentence; empty when nothing does
+	Reason string `json:"reason"`
+}
+
+// breakingIntro opens the breaking change prompt unless a system prompt
+// replaces it
+const breakingIntro = "You are a careful release manager deciding whether a change needs a major version."
+
+// CheckBreakingChange asks the model whether the change in req breaks
+// backward compatibility. It takes the same request as
+// GenerateCommitMessage and is meant as a second opinion for changes
+// nobody marked as breaking.
+func (c *OllamaClient) CheckBreakingChange(req CommitRequest) (*BreakingCheck, error) {
+	c.phase(PhaseBuildingPrompt)
+	response, err := c.send(c.buildBreakingPrompt(req), "json")
+	if err != nil {
+		return nil, err
+	}
+
+	c.phase(PhasePostProcessing)
+	start := strings.Index(response, "{")
+	end := strings.LastIndex(response, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("failed to parse breaking change check: no JSON object in the response")
+	}
+	var check BreakingCheck
+	if err := json.Unmarshal([]byte(response[start:end+1]), &check); err != nil {
+		return nil, fmt.Errorf("failed to parse breaking change check: %w", err)
+	}
+	check.Reason = strings.TrimSpace(check.Reason)
+	if !check.Breaking {
+		check.Reason = ""
+	}
+	return &check, nil
+}
+
+func (c *OllamaClient) buildBreakingPrompt(req CommitRequest) string {
+	var sb strings.Builder
+	if c.systemPrompt == "" || c.systemPromptMode == SystemPromptPrepend {
+		sb.WriteString(breakingIntro + "\n\n")
+	}
+
+	sb.WriteString("Decide whether the following staged diff breaks backward compatibility for the users of this code. A change is breaking when existing callers, configuration or scripts stop working without changes of their own, for example:\n")
+	sb.WriteString("- An exported function, type, method, endpoint or command is removed or renamed.\n")
+	sb.WriteString("- A public signature, response shape or file format changes incompatibly.\n")
+	sb.WriteString("- A configuration key, environment variable or command-line flag is removed or changes meaning.\n")
+	sb.WriteString("- Documented behavior that callers rely on changes.\n\n")
+	sb.WriteString("Internal refactoring, new features, bug fixes and changes to tests or documentation are not breaking. When unsure, answer false.\n\n")
+	sb.WriteString("Respond with JSON only, in exactly this shape, with reason one short sentence naming what breaks, or empty:\n")
+	sb.WriteString(`{"breaking": false, "reason": ""}`)
+	sb.WriteString("\n\nDiff:\n")
+	sb.WriteString(req.Diff)
+	return sb.String()
+}
@@ -0,0 +1,74 @@
+package ai
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOllamaClient_CheckBreakingChange(t *testing.T) {
+	tests := []struct {
+		name          string
+		response      string
+		expected      *BreakingCheck
+		expectedError string
+	}{
+		{
+			name:     "Breaking",
+			response: "```json\n" + `{"breaking": true, "reason": " NewClient takes options now. "}` + "\n```",
+			expected: &BreakingCheck{Breaking: true, Reason: "NewClient takes options now."},
+		},
+		{
+			name:     "A reason without a break is dropped",
+			response: `{"breaking": false, "reason": "Only tests changed."}`,
+			expected: &BreakingCheck{},
+		},
+		{
+			name:          "Not JSON",
+			response:      "No.",
+			expectedError: "failed to parse breaking change check",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body ollamaRequest
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					t.Errorf("failed to decode request body: %v", err)
+				}
+				response, _ := json.Marshal(ollamaResponse{Response: tt.response, Done: true})
+				w.Write(response)
+			}))
+			defer server.Close()
+
+			client := NewClient("test-api-key", server.URL+"/api/generate", "", time.Second)
+			check, err := client.CheckBreakingChange(CommitRequest{Diff: "diff --git a/client.go b/client.go", Rules: "Use past tense"})
+			if body.Format != "json" {
+				t.Errorf("expected a JSON response to be requested, got format %q", body.Format)
+			}
+			if !strings.Contains(body.Prompt, "breaks backward compatibility") || !strings.Contains(body.Prompt, "Diff:\ndiff --git a/client.go b/client.go") {
+				t.Errorf("expected the breaking change prompt, got:\n%s", body.Prompt)
+			}
+			if strings.Contains(body.Prompt, "Use past tense") {
+				t.Errorf("expected no commit message rules in the prompt, got:\n%s", body.Prompt)
+			}
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("expected error containing %q, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CheckBreakingChange failed: %v", err)
+			}
+			if !reflect.DeepEqual(check, tt.expected) {
+				t.Errorf("expected %+v, got %+v", tt.expected, check)
+			}
+		})
+	}
+}
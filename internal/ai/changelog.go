@@ -0,0 +1,114 @@
+package ai
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Changelog styles
+const (
+	// ChangelogKeepAChangelog groups entries under Added, Changed, Fixed,
+	// ... as described at https://keepachangelog.com
+	ChangelogKeepAChangelog = "keepachangelog"
+	// ChangelogConventional groups entries under Features, Bug Fixes, ...
+	// like conventional-changelog
+	ChangelogConventional = "conventional"
+)
+
+// ChangelogRequest holds what a changelog section is written from
+type ChangelogRequest struct {
+	// Version heads the section, e.g. "v1.3.0" or "Unreleased"
+	Version string
+	// Date is the release date, YYYY-MM-DD
+	Date string
+	// Style is ChangelogKeepAChangelog or ChangelogConventional
+	Style string
+	// Groups holds the commits by type, in the order to present them
+	Groups []ChangelogGroup
+}
+
+// ChangelogGroup is the commits of one Conventional Commits type. Type is
+// empty for commits that do not follow the convention.
+type ChangelogGroup struct {
+	Type    string
+	Commits []ChangelogCommit
+}
+
+// ChangelogCommit is one commit of a changelog group
+type ChangelogCommit struct {
+	Scope       string
+	Description string
+	Breaking    bool
+	// Hash is the abbreviated commit hash
+	Hash string
+}
+
+// changelogIntro opens the changelog prompt unless a system prompt replaces
+// it
+const changelogIntro = "You are an expert release manager writing changelogs for the users of a project."
+
+// GenerateChangelog asks the model for a markdown changelog section
+func (c *OllamaClient) GenerateChangelog(req ChangelogRequest) (string, error) {
+	c.phase(PhaseBuildingPrompt)
+	response, err := c.generate(c.buildChangelogPrompt(req))
+	if err != nil {
+		return "", err
+	}
+
+	c.phase(PhasePostProcessing)
+	section := normalizeMessage(response)
+	if section == "" {
+		return "", fmt.Errorf("empty response from model")
+	}
+	return section, nil
+}
+
+func (c *OllamaClient) buildChangelogPrompt(req ChangelogRequest) string {
+	var sb strings.Builder
+	if c.systemPrompt == "" || c.systemPromptMode == SystemPromptPrepend {
+		sb.WriteString(changelogIntro + "\n\n")
+	}
+
+	sb.WriteString("Write one changelog section for the following commits, grouped by their Conventional Commits type.\n\n")
+	sb.WriteString("Output markdown in exactly this format:\n\n")
+	if req.Style == ChangelogConventional {
+		sb.WriteString(fmt.Sprintf("## %s (%s)\n\n", req.Version, req.Date))
+		sb.WriteString("### <heading>\n\n* **<scope>:** <entry> (<hash>)\n\n")
+		sb.WriteString("Use these headings, in this order, and only when they have entries: BREAKING CHANGES, Features (feat), Bug Fixes (fix), Performance Improvements (perf), Reverts (revert), Documentation (docs), Code Refactoring (refactor), Tests (test), Build System (build), Continuous Integration (ci), Miscellaneous Chores (chore, style), Other.\n")
+		sb.WriteString("Omit the **<scope>:** prefix for entries without a scope. Keep the hash of every entry.\n")
+	} else {
+		sb.WriteString(fmt.Sprintf("## [%s] - %s\n\n", req.Version, req.Date))
+		sb.WriteString("### <heading>\n\n- <entry>\n\n")
+		sb.WriteString("Use these headings, in this order, and only when they have entries: Added, Changed, Deprecated, Removed, Fixed, Security. ")
+		sb.WriteString("New features go under Added, bug fixes under Fixed, and other user-visible changes under Changed. Leave out commits that do not affect users, such as tests, CI and refactoring, unless they are all there is.\n")
+		sb.WriteString("Mark breaking changes with **BREAKING:** at the start of the entry.\n")
+	}
+	sb.WriteString("Rewrite each entry as a clear sentence for users, merge entries that describe the same change, and keep commits listed under Other unless they are clearly internal.\n")
+	sb.WriteString("Describe only what the commits say. Do not wrap the output in a code block and do not output anything else.\n\n")
+
+	writeCommitGroups(&sb, req.Groups)
+	return sb.String()
+}
+
+// writeCommitGroups lists commits by type, marking breaking changes and
+// keeping the hashes
+func writeCommitGroups(sb *strings.Builder, groups []ChangelogGroup) {
+	sb.WriteString("Commits:\n")
+	for _, group := range groups {
+		if group.Type == "" {
+			sb.WriteString("\nOther (not Conventional Commits):\n")
+		} else {
+			sb.WriteString("\n" + group.Type + ":\n")
+		}
+		for _, commit := range group.Commits {
+			sb.WriteString("- ")
+			if commit.Breaking {
+				sb.WriteString("[BREAKING] ")
+			}
+			if commit.Scope != "" {
+				sb.WriteString(commit.Scope + ": ")
+			}
+			sb.WriteString(commit.Description + " (" + commit.Hash + ")\n")
+		}
+	}
+}
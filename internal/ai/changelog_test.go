@@ -0,0 +1,56 @@
+package ai
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildChangelogPrompt(t *testing.T) {
+	groups := []ChangelogGroup{
+		{Type: "feat", Commits: []ChangelogCommit{
+			{Scope: "ui", Description: "replaced the theme option", Breaking: true, Hash: "ccccccc"},
+		}},
+		{Commits: []ChangelogCommit{{Description: "Update README", Hash: "ddddddd"}}},
+	}
+
+	tests := []struct {
+		name     string
+		style    string
+		expected []string
+	}{
+		{
+			name:  "Keep a Changelog",
+			style: ChangelogKeepAChangelog,
+			expected: []string{
+				"## [v1.3.0] - 2026-10-15",
+				"Added, Changed, Deprecated, Removed, Fixed, Security",
+				"**BREAKING:**",
+			},
+		},
+		{
+			name:  "conventional-changelog",
+			style: ChangelogConventional,
+			expected: []string{
+				"## v1.3.0 (2026-10-15)",
+				"BREAKING CHANGES, Features (feat), Bug Fixes (fix)",
+				"* **<scope>:** <entry> (<hash>)",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &OllamaClient{}
+			prompt := client.buildChangelogPrompt(ChangelogRequest{Version: "v1.3.0", Date: "2026-10-15", Style: tt.style, Groups: groups})
+			expected := append(tt.expected,
+				"feat:\n- [BREAKING] ui: replaced the theme option (ccccccc)\n",
+				"Other (not Conventional Commits):\n- Update README (ddddddd)\n",
+			)
+			for _, want := range expected {
+				if !strings.Contains(prompt, want) {
+					t.Errorf("expected prompt to contain %q, got:\n%s", want, prompt)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package ai
+
+import (
+	"strings"
+)
+
+// DefaultTypes are the Conventional Commits types, the ones a message may
+// use when CommitRequest.Types is empty
+var DefaultTypes = []string{
+	"feat", "fix", "docs", "style", "refactor", "perf",
+	"test", "build", "ci", "chore", "revert",
+}
+
+// AllowedTypes returns types, or DefaultTypes when it is empty
+func AllowedTypes(types []string) []string {
+	if len(types) == 0 {
+		return DefaultTypes
+	}
+	return types
+}
+
+// typeAllowed reports whether commitType is one of the allowed types
+func typeAllowed(types []string, commitType string) bool {
+	for _, allowed := range AllowedTypes(types) {
+		if allowed == commitType {
+			return true
+		}
+	}
+	return false
+}
+
+// filterTypes keeps the candidates that are allowed, in their order
+func filterTypes(types []string, candidates ...string) []string {
+	var kept []string
+	for _, candidate := range candidates {
+		if typeAllowed(types, candidate) {
+			kept = append(kept, candidate)
+		}
+	}
+	return kept
+}
+
+// exampleType returns preferred when it is allowed, or else the first
+// allowed type, for the example headers of the prompt
+func exampleType(types []string, preferred string) string {
+	if typeAllowed(types, preferred) {
+		return preferred
+	}
+	return AllowedTypes(types)[0]
+}
+
+// quoteTypes lists types as 'a', 'b' or 'c'
+func quoteTypes(types []string) string {
+	quoted := make([]string, len(types))
+	for i, t := range types {
+		quoted[i] = "'" + t + "'"
+	}
+	if len(quoted) < 2 {
+		return strings.Join(quoted, "")
+	}
+	return strings.Join(quoted[:len(quoted)-1], ", ") + " or " + quoted[len(quoted)-1]
+}
+
+// stateTypeChoices describe when to use each type in the merge, rebase and
+// cherry-pick instructions
+var stateTypeChoices = []struct {
+	commitType string
+	when       string
+}{
+	{"feat", "if adding new features or capabilities"},
+	{"fix", "if fixing bugs or issues"},
+	{"refactor", "if restructuring code without changing functionality"},
+	{"chore", "if updating dependencies, configs, or maintenance tasks"},
+	{"docs", "if primarily documentation changes"},
+}
+
+// writeStateTypes lists the allowed types to choose the <type> of a merge,
+// rebase or cherry-pick header from
+func writeStateTypes(sb *strings.Builder, types []string) {
+	sb.WriteString("2. Analyze the diff and choose the appropriate <type> based on the changes:\n")
+	listed := false
+	for _, choice := range stateTypeChoices {
+		if typeAllowed(types, choice.commitType) {
+			sb.WriteString("   - " + choice.commitType + ": " + choice.when + "\n")
+			listed = true
+		}
+	}
+	if !listed {
+		sb.WriteString("   - one of: " + strings.Join(AllowedTypes(types), ", ") + "\n")
+	}
+}
@@ -0,0 +1,59 @@
+package ai
+
+import (
+	"regexp"
+	"testing"
+
+	"ai-commit-message-generator/internal/git"
+)
+
+func TestBuildPrompt_CustomTypesOnly(t *testing.T) {
+	client := &OllamaClient{}
+	types := []string{"feat", "fix"}
+	// A type named as one, e.g. 'docs', docs: or docs(
+	unlisted := regexp.MustCompile(`\b(docs|style|refactor|perf|test|build|ci|chore|revert)['(:]`)
+
+	tests := []struct {
+		name string
+		req  CommitRequest
+	}{
+		{name: "Merge", req: CommitRequest{GitState: &git.GitState{Type: git.StateMerge, OriginalMessage: "Merge branch 'feature'"}}},
+		{name: "Rebase", req: CommitRequest{GitState: &git.GitState{Type: git.StateRebase}}},
+		{name: "Cherry-pick", req: CommitRequest{GitState: &git.GitState{Type: git.StateCherryPick}}},
+		{name: "Bisect", req: CommitRequest{GitState: &git.GitState{Type: git.StateBisect}}},
+		{name: "Initial commit", req: CommitRequest{GitState: &git.GitState{Type: git.StateNormal, InitialCommit: true}}},
+		{name: "Docs only", req: CommitRequest{Meta: &DiffMeta{FileCount: 1, SuggestedType: "docs"}}},
+		{name: "Tests only", req: CommitRequest{Meta: &DiffMeta{FileCount: 1, SuggestedType: "test", Classification: FilesTestsOnly}}},
+		{name: "Code and tests", req: CommitRequest{Meta: &DiffMeta{FileCount: 2, TestsTouched: true, Classification: FilesMixed}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.req.Diff = "diff"
+			tt.req.Types = types
+			prompt := client.buildPrompt(tt.req)
+			if found := unlisted.FindString(prompt); found != "" {
+				t.Errorf("expected only feat and fix, found %q in the prompt:\n%s", found, prompt)
+			}
+
+			tt.req.Types = nil
+			if prompt := client.buildPrompt(tt.req); !unlisted.MatchString(prompt) {
+				t.Errorf("expected the default prompt to name other types, got:\n%s", prompt)
+			}
+		})
+	}
+}
+
+func TestQuoteTypes(t *testing.T) {
+	tests := map[string][]string{
+		"":                         nil,
+		"'fix'":                    {"fix"},
+		"'test' or 'fix'":          {"test", "fix"},
+		"'test', 'fix' or 'chore'": {"test", "fix", "chore"},
+	}
+	for expected, types := range tests {
+		if got := quoteTypes(types); got != expected {
+			t.Errorf("quoteTypes(%q) = %q, expected %q", types, got, expected)
+		}
+	}
+}
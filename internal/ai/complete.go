@@ -0,0 +1,39 @@
+package ai
+
+import "context"
+
+// CompleteFunc answers prompt, sent with the system prompt system, in place
+// of the Ollama API. format is "" for free text or "json" when the answer
+// must be a JSON value.
+type CompleteFunc func(system, prompt, format string) (string, error)
+
+// WithComplete sends every prompt to complete instead of the Ollama API.
+// The prompts, and what is done with the answers, stay the same.
+func WithComplete(complete CompleteFunc) Option {
+	return func(c *OllamaClient) {
+		c.complete = complete
+	}
+}
+
+// WithContext ties the client's API calls to ctx: cancelling it aborts the
+// call in flight
+func WithContext(ctx context.Context) Option {
+	return func(c *OllamaClient) {
+		c.ctx = ctx
+	}
+}
+
+// requestContext is the context API calls are made with
+func (c *OllamaClient) requestContext() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
+// Complete sends prompt with the system prompt system and returns the
+// model's answer as it is, with the client's retries on rate limits and
+// model loading. format is "" for free text or "json".
+func (c *OllamaClient) Complete(system, prompt, format string) (string, error) {
+	return c.sendWithSystem(system, prompt, format)
+}
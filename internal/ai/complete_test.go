@@ -0,0 +1,81 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOllamaClient_WithComplete(t *testing.T) {
+	var system, prompt, format string
+	client := NewClient("", "", "", 0,
+		WithSystemPrompt("Write commit messages for the payments team.", SystemPromptPrepend),
+		WithComplete(func(s, p, f string) (string, error) {
+			system, prompt, format = s, p, f
+			return "```\nfeat(auth): add login\n```", nil
+		}))
+
+	message, err := client.GenerateCommitMessage(CommitRequest{Diff: "diff --git a/login.go b/login.go\n+func Login() {}\n"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if message != "feat(auth): add login" {
+		t.Errorf("expected the normalized message, got %q", message)
+	}
+	if system != "Write commit messages for the payments team." {
+		t.Errorf("expected the system prompt, got %q", system)
+	}
+	if !strings.Contains(prompt, "+func Login() {}") || format != "" {
+		t.Errorf("expected the commit prompt in free text, got format %q and prompt:\n%s", format, prompt)
+	}
+
+	wantErr := errors.New("provider down")
+	client = NewClient("", "", "", 0, WithComplete(func(string, string, string) (string, error) {
+		return "", wantErr
+	}))
+	if _, err := client.GenerateCommitMessage(CommitRequest{Diff: "x"}); !errors.Is(err, wantErr) {
+		t.Errorf("expected the provider's error, got %v", err)
+	}
+}
+
+func TestOllamaClient_Complete(t *testing.T) {
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		w.Write([]byte(`{"response":"  raw answer\n","done":true}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("", server.URL, "", 0, WithSystemPrompt("ignored", SystemPromptReplace)).(*OllamaClient)
+	answer, err := client.Complete("Be brief.", "Hello", "json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if answer != "  raw answer\n" {
+		t.Errorf("expected the answer as it is, got %q", answer)
+	}
+	for _, want := range []string{`"system":"Be brief."`, `"prompt":"Hello"`, `"format":"json"`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %s in request %s", want, body)
+		}
+	}
+}
+
+func TestOllamaClient_WithContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("a cancelled call reached the server")
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	client := NewClient("", server.URL, "", 0, WithContext(ctx))
+	if _, err := client.GenerateCommitMessage(CommitRequest{Diff: "x"}); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the call to be cancelled, got %v", err)
+	}
+}
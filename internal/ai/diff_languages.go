@@ -0,0 +1,35 @@
+package ai
+
+import "strings"
+
+// tagDiffLanguages adds a "Language: <name>" line below the "diff --git"
+// line of every file in diff whose language languageForPath knows, so the
+// model reads +func as Go or JavaScript as the file says. Other lines are
+// kept as they are.
+func tagDiffLanguages(diff string) string {
+	if !strings.Contains(diff, "diff --git ") {
+		return diff
+	}
+	lines := strings.Split(diff, "\n")
+	tagged := make([]string, 0, len(lines))
+	for _, line := range lines {
+		tagged = append(tagged, line)
+		if lang := languageForPath(diffHeaderPath(line)); lang != "" {
+			tagged = append(tagged, "Language: "+lang)
+		}
+	}
+	return strings.Join(tagged, "\n")
+}
+
+// diffHeaderPath returns the new path of a "diff --git a/<old> b/<new>"
+// line, or "" for any other line
+func diffHeaderPath(line string) string {
+	if !strings.HasPrefix(line, "diff --git ") {
+		return ""
+	}
+	i := strings.LastIndex(line, " b/")
+	if i == -1 {
+		return ""
+	}
+	return strings.Trim(line[i+len(" b/"):], `"`)
+}
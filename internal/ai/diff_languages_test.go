@@ -0,0 +1,51 @@
+package ai
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildPrompt_LanguageTags(t *testing.T) {
+	client := &OllamaClient{}
+	diff := strings.Join([]string{
+		"diff --git a/internal/auth/login.go b/internal/auth/login.go",
+		"index 1111111..2222222 100644",
+		"--- a/internal/auth/login.go",
+		"+++ b/internal/auth/login.go",
+		"@@ -1 +1,2 @@",
+		"+func Login() {}",
+		"diff --git a/web/src/login.tsx b/web/src/login.tsx",
+		"new file mode 100644",
+		"+export function Login() {}",
+		"diff --git a/scripts/build tools.py b/scripts/build tools.py",
+		"+def build(): pass",
+		"diff --git a/Dockerfile b/Dockerfile",
+		"+FROM golang:1.23",
+		"diff --git a/LICENSE b/LICENSE",
+		"+MIT",
+		"",
+	}, "\n")
+
+	prompt := client.buildPrompt(CommitRequest{Diff: diff})
+	for _, expected := range []string{
+		"diff --git a/internal/auth/login.go b/internal/auth/login.go\nLanguage: Go\nindex 1111111..2222222 100644\n",
+		"diff --git a/web/src/login.tsx b/web/src/login.tsx\nLanguage: TypeScript\nnew file mode 100644\n",
+		"diff --git a/scripts/build tools.py b/scripts/build tools.py\nLanguage: Python\n",
+		"diff --git a/Dockerfile b/Dockerfile\nLanguage: Dockerfile\n",
+		"diff --git a/LICENSE b/LICENSE\n+MIT\n",
+	} {
+		if !strings.Contains(prompt, expected) {
+			t.Errorf("expected %q in the prompt, got:\n%s", expected, prompt)
+		}
+	}
+	if strings.Count(prompt, "Language: ") != 4 {
+		t.Errorf("expected four language tags, got:\n%s", prompt)
+	}
+}
+
+func TestTagDiffLanguages_NoHeaders(t *testing.T) {
+	diff := "+Language: added by hand\n-func old() {}\n"
+	if got := tagDiffLanguages(diff); got != diff {
+		t.Errorf("expected a diff without headers to be unchanged, got %q", got)
+	}
+}
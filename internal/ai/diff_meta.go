@@ -0,0 +1,264 @@
+package ai
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"ai-commit-message-generator/internal/git"
+)
+
+// DiffMeta is lightweight structured context about the staged changes.
+// It is passed alongside the diff so the model doesn't have to infer
+// everything from raw lines.
+type DiffMeta struct {
+	// FileCount is the number of staged files
+	FileCount int
+	// Languages are the distinct languages of the staged files, sorted
+	Languages []string
+	// TestsTouched reports whether any staged file is a test file
+	TestsTouched bool
+	// SuggestedType is "test", "docs" or "chore" when every staged file
+	// falls into that category, and empty otherwise
+	SuggestedType string
+	// Classification is FilesTestsOnly, FilesProductionOnly or FilesMixed
+	Classification string
+	// TestPolicy is how test files affect the type, see TestFileOptions
+	TestPolicy string
+	// ChangedSymbols are the Go declarations the change adds or modifies,
+	// see ChangedGoSymbols. Only set when Go analysis is enabled.
+	ChangedSymbols []string
+}
+
+// Test file policies
+const (
+	// TestPolicyPreferTestType gives changes to test files only the test
+	// type, and mixed changes the type of the production code
+	TestPolicyPreferTestType = "prefer_test_type_when_only_tests"
+	// TestPolicyFoldIntoMain never lets test files decide the type; they
+	// are described as part of the change they cover
+	TestPolicyFoldIntoMain = "fold_into_main"
+)
+
+// Classifications of the staged files
+const (
+	FilesTestsOnly      = "tests-only"
+	FilesProductionOnly = "production-only"
+	FilesMixed          = "mixed"
+)
+
+// TestFileOptions controls how NewDiffMeta recognizes and weighs test files
+type TestFileOptions struct {
+	// Patterns are globs marking a path as a test file. They replace the
+	// built-in heuristics (_test.go, *.spec.*, tests/ and so on).
+	Patterns []string
+	// Policy is TestPolicyPreferTestType (the default) or
+	// TestPolicyFoldIntoMain
+	Policy string
+}
+
+// isTest reports whether p is a test file
+func (o TestFileOptions) isTest(p string) bool {
+	if len(o.Patterns) == 0 {
+		return isTestPath(p)
+	}
+	for _, pattern := range o.Patterns {
+		if git.MatchGlob(pattern, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// extensionLanguages maps file extensions to a human readable language name
+var extensionLanguages = map[string]string{
+	".go":    "Go",
+	".py":    "Python",
+	".pyi":   "Python",
+	".js":    "JavaScript",
+	".jsx":   "JavaScript",
+	".mjs":   "JavaScript",
+	".cjs":   "JavaScript",
+	".ts":    "TypeScript",
+	".tsx":   "TypeScript",
+	".java":  "Java",
+	".kt":    "Kotlin",
+	".rb":    "Ruby",
+	".rs":    "Rust",
+	".c":     "C",
+	".h":     "C",
+	".cc":    "C++",
+	".cpp":   "C++",
+	".hpp":   "C++",
+	".cs":    "C#",
+	".swift": "Swift",
+	".php":   "PHP",
+	".scala": "Scala",
+	".dart":  "Dart",
+	".lua":   "Lua",
+	".ex":    "Elixir",
+	".exs":   "Elixir",
+	".vue":   "Vue",
+	".sh":    "Shell",
+	".bash":  "Shell",
+	".zsh":   "Shell",
+	".ps1":   "PowerShell",
+	".bat":   "Batch",
+	".sql":   "SQL",
+	".html":  "HTML",
+	".css":   "CSS",
+	".scss":  "SCSS",
+	".md":    "Markdown",
+	".rst":   "reStructuredText",
+	".json":  "JSON",
+	".yml":   "YAML",
+	".yaml":  "YAML",
+	".toml":  "TOML",
+	".xml":   "XML",
+	".proto": "Protocol Buffers",
+	".tf":    "Terraform",
+}
+
+// fileLanguages maps file names without a telling extension to a language
+var fileLanguages = map[string]string{
+	"Dockerfile":  "Dockerfile",
+	"Makefile":    "Makefile",
+	"GNUmakefile": "Makefile",
+}
+
+// choreFiles are well-known build, dependency and tooling files
+var choreFiles = map[string]bool{
+	"go.mod":            true,
+	"go.sum":            true,
+	"package.json":      true,
+	"package-lock.json": true,
+	"yarn.lock":         true,
+	"pnpm-lock.yaml":    true,
+	"Cargo.toml":        true,
+	"Cargo.lock":        true,
+	"requirements.txt":  true,
+	"Pipfile.lock":      true,
+	"poetry.lock":       true,
+	"Gemfile.lock":      true,
+	"Makefile":          true,
+	"Dockerfile":        true,
+	".gitignore":        true,
+	".gitattributes":    true,
+	".editorconfig":     true,
+	".dockerignore":     true,
+	".goreleaser.yml":   true,
+	".goreleaser.yaml":  true,
+}
+
+// NewDiffMeta computes DiffMeta from the staged file list, ignoring files
+// excluded by the path filters
+func NewDiffMeta(files []git.StagedFile, tests TestFileOptions) *DiffMeta {
+	files = describedFiles(files)
+	policy := tests.Policy
+	if policy == "" {
+		policy = TestPolicyPreferTestType
+	}
+	meta := &DiffMeta{FileCount: len(files), TestPolicy: policy}
+	if len(files) == 0 {
+		return meta
+	}
+
+	languages := make(map[string]bool)
+	allTests, allDocs, allChore := true, true, true
+	for _, file := range files {
+		if lang := languageForPath(file.Path); lang != "" {
+			languages[lang] = true
+		}
+
+		isTest := tests.isTest(file.Path)
+		if isTest {
+			meta.TestsTouched = true
+		}
+		allTests = allTests && isTest
+		allDocs = allDocs && isDocPath(file.Path)
+		allChore = allChore && isChorePath(file.Path)
+	}
+
+	for lang := range languages {
+		meta.Languages = append(meta.Languages, lang)
+	}
+	sort.Strings(meta.Languages)
+
+	switch {
+	case allTests:
+		meta.Classification = FilesTestsOnly
+	case meta.TestsTouched:
+		meta.Classification = FilesMixed
+	default:
+		meta.Classification = FilesProductionOnly
+	}
+
+	switch {
+	case allTests && policy == TestPolicyFoldIntoMain:
+		// The tests decide nothing, so leave the type to the model
+	case allTests:
+		meta.SuggestedType = "test"
+	case allDocs:
+		meta.SuggestedType = "docs"
+	case allChore:
+		meta.SuggestedType = "chore"
+	}
+
+	return meta
+}
+
+// languageForPath returns the language for a path based on its extension
+func languageForPath(p string) string {
+	if lang, ok := fileLanguages[path.Base(p)]; ok {
+		return lang
+	}
+	return extensionLanguages[strings.ToLower(path.Ext(p))]
+}
+
+// isTestPath reports whether the path looks like a test file
+func isTestPath(p string) bool {
+	base := path.Base(p)
+	if strings.HasSuffix(base, "_test.go") ||
+		strings.Contains(base, ".test.") ||
+		strings.Contains(base, ".spec.") ||
+		(strings.HasPrefix(base, "test_") && strings.HasSuffix(base, ".py")) {
+		return true
+	}
+	for _, dir := range strings.Split(path.Dir(p), "/") {
+		if dir == "test" || dir == "tests" || dir == "__tests__" || dir == "testdata" {
+			return true
+		}
+	}
+	return false
+}
+
+// isDocPath reports whether the path looks like documentation
+func isDocPath(p string) bool {
+	if strings.HasPrefix(p, "docs/") || strings.HasPrefix(p, "doc/") {
+		return true
+	}
+	switch strings.ToLower(path.Ext(p)) {
+	case ".md", ".rst", ".adoc", ".txt":
+		return !choreFiles[path.Base(p)]
+	}
+	upper := strings.ToUpper(path.Base(p))
+	return strings.HasPrefix(upper, "README") ||
+		strings.HasPrefix(upper, "LICENSE") ||
+		strings.HasPrefix(upper, "CHANGELOG")
+}
+
+// isChorePath reports whether the path is a build, dependency or tooling file
+func isChorePath(p string) bool {
+	return choreFiles[path.Base(p)] || strings.HasPrefix(p, ".github/")
+}
+
+// describedFiles drops files the path filters left out of the diff
+func describedFiles(files []git.StagedFile) []git.StagedFile {
+	described := make([]git.StagedFile, 0, len(files))
+	for _, file := range files {
+		if !file.Excluded {
+			described = append(described, file)
+		}
+	}
+	return described
+}
@@ -0,0 +1,247 @@
+package ai
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"ai-commit-message-generator/internal/git"
+)
+
+func stagedFiles(paths ...string) []git.StagedFile {
+	files := make([]git.StagedFile, 0, len(paths))
+	for _, p := range paths {
+		files = append(files, git.StagedFile{Path: p, Change: git.ChangeModified})
+	}
+	return files
+}
+
+func TestNewDiffMeta(t *testing.T) {
+	tests := []struct {
+		name              string
+		files             []git.StagedFile
+		expectedCount     int
+		expectedLanguages []string
+		expectedTests     bool
+		expectedType      string
+	}{
+		{
+			name:          "No files",
+			files:         nil,
+			expectedCount: 0,
+		},
+		{
+			name:              "Only Go tests",
+			files:             stagedFiles("internal/app/app_test.go", "internal/git/client_test.go"),
+			expectedCount:     2,
+			expectedLanguages: []string{"Go"},
+			expectedTests:     true,
+			expectedType:      "test",
+		},
+		{
+			name:              "Only docs",
+			files:             stagedFiles("README.md", "docs/usage.rst", "LICENSE"),
+			expectedCount:     3,
+			expectedLanguages: []string{"Markdown", "reStructuredText"},
+			expectedType:      "docs",
+		},
+		{
+			name: "Excluded files are ignored",
+			files: append(stagedFiles("README.md"),
+				git.StagedFile{Path: "main.go", Change: git.ChangeModified, Excluded: true}),
+			expectedCount:     1,
+			expectedLanguages: []string{"Markdown"},
+			expectedType:      "docs",
+		},
+		{
+			name:          "Only chore",
+			files:         stagedFiles("go.mod", "go.sum", ".github/workflows/release.yml"),
+			expectedCount: 3,
+			// go.mod/go.sum have no mapped language
+			expectedLanguages: []string{"YAML"},
+			expectedType:      "chore",
+		},
+		{
+			name:              "Code with tests has no suggestion",
+			files:             stagedFiles("internal/ai/diff_meta.go", "internal/ai/diff_meta_test.go"),
+			expectedCount:     2,
+			expectedLanguages: []string{"Go"},
+			expectedTests:     true,
+			expectedType:      "",
+		},
+		{
+			name:              "Mixed languages",
+			files:             stagedFiles("web/app.ts", "server/main.go", "scripts/build.sh"),
+			expectedCount:     3,
+			expectedLanguages: []string{"Go", "Shell", "TypeScript"},
+			expectedType:      "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta := NewDiffMeta(tt.files, TestFileOptions{})
+
+			if meta.FileCount != tt.expectedCount {
+				t.Errorf("expected file count %d, got %d", tt.expectedCount, meta.FileCount)
+			}
+			if !reflect.DeepEqual(meta.Languages, tt.expectedLanguages) {
+				t.Errorf("expected languages %v, got %v", tt.expectedLanguages, meta.Languages)
+			}
+			if meta.TestsTouched != tt.expectedTests {
+				t.Errorf("expected tests touched %v, got %v", tt.expectedTests, meta.TestsTouched)
+			}
+			if meta.SuggestedType != tt.expectedType {
+				t.Errorf("expected suggested type %q, got %q", tt.expectedType, meta.SuggestedType)
+			}
+		})
+	}
+}
+
+func TestNewDiffMeta_TestFiles(t *testing.T) {
+	tests := []struct {
+		name                   string
+		files                  []git.StagedFile
+		options                TestFileOptions
+		expectedClassification string
+		expectedType           string
+	}{
+		{
+			name:                   "Test-only",
+			files:                  stagedFiles("internal/app/app_test.go", "internal/app/testdata/golden.txt"),
+			expectedClassification: FilesTestsOnly,
+			expectedType:           "test",
+		},
+		{
+			name:                   "Production-only",
+			files:                  stagedFiles("internal/app/app.go", "cmd/main.go"),
+			expectedClassification: FilesProductionOnly,
+		},
+		{
+			name:                   "Mixed",
+			files:                  stagedFiles("internal/app/app.go", "internal/app/app_test.go"),
+			expectedClassification: FilesMixed,
+		},
+		{
+			name:                   "Test-only folded into main",
+			files:                  stagedFiles("internal/app/app_test.go"),
+			options:                TestFileOptions{Policy: TestPolicyFoldIntoMain},
+			expectedClassification: FilesTestsOnly,
+		},
+		{
+			name:                   "Patterns replace the heuristics",
+			files:                  stagedFiles("src/LoginCheck.java", "e2e/login.cy.ts"),
+			options:                TestFileOptions{Patterns: []string{"*Check.java", "e2e/"}},
+			expectedClassification: FilesTestsOnly,
+			expectedType:           "test",
+		},
+		{
+			name:                   "Patterns make other test files production code",
+			files:                  stagedFiles("e2e/login.cy.ts", "internal/app/app_test.go"),
+			options:                TestFileOptions{Patterns: []string{"e2e/"}},
+			expectedClassification: FilesMixed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta := NewDiffMeta(tt.files, tt.options)
+
+			if meta.Classification != tt.expectedClassification {
+				t.Errorf("expected classification %q, got %q", tt.expectedClassification, meta.Classification)
+			}
+			if meta.SuggestedType != tt.expectedType {
+				t.Errorf("expected suggested type %q, got %q", tt.expectedType, meta.SuggestedType)
+			}
+			expectedPolicy := tt.options.Policy
+			if expectedPolicy == "" {
+				expectedPolicy = TestPolicyPreferTestType
+			}
+			if meta.TestPolicy != expectedPolicy {
+				t.Errorf("expected policy %q, got %q", expectedPolicy, meta.TestPolicy)
+			}
+		})
+	}
+}
+
+func TestBuildPrompt_DiffMeta(t *testing.T) {
+	client := &OllamaClient{}
+
+	tests := []struct {
+		name             string
+		meta             *DiffMeta
+		expectedContains []string
+		expectedAbsent   []string
+	}{
+		{
+			name:           "No metadata",
+			meta:           nil,
+			expectedAbsent: []string{"Change Context:"},
+		},
+		{
+			name: "Test-only changes",
+			meta: &DiffMeta{FileCount: 1, Languages: []string{"Go"}, TestsTouched: true, SuggestedType: "test"},
+			expectedContains: []string{
+				"Files changed: 1",
+				"Languages: Go",
+				"Tests touched: yes",
+				"type should almost certainly be 'test'",
+			},
+		},
+		{
+			name: "Docs-only changes",
+			meta: &DiffMeta{FileCount: 2, Languages: []string{"Markdown"}, SuggestedType: "docs"},
+			expectedContains: []string{
+				"Files changed: 2",
+				"Tests touched: no",
+				"type should almost certainly be 'docs'",
+			},
+		},
+		{
+			name:             "Chore-only changes",
+			meta:             &DiffMeta{FileCount: 2, SuggestedType: "chore"},
+			expectedContains: []string{"type should almost certainly be 'chore'"},
+			expectedAbsent:   []string{"Languages:"},
+		},
+		{
+			name:             "Mixed changes have no type hint",
+			meta:             &DiffMeta{FileCount: 3, Languages: []string{"Go"}, TestsTouched: true},
+			expectedContains: []string{"Files changed: 3"},
+			expectedAbsent:   []string{"should almost certainly be"},
+		},
+		{
+			name:             "Mixed production and test changes use the production type",
+			meta:             &DiffMeta{FileCount: 2, TestsTouched: true, Classification: FilesMixed},
+			expectedContains: []string{"Choose the type from the production code", "never 'test'"},
+			expectedAbsent:   []string{"should almost certainly be"},
+		},
+		{
+			name:             "Test-only changes folded into main",
+			meta:             &DiffMeta{FileCount: 1, TestsTouched: true, Classification: FilesTestsOnly, TestPolicy: TestPolicyFoldIntoMain},
+			expectedContains: []string{"Test files do not decide the type"},
+			expectedAbsent:   []string{"should almost certainly be 'test'"},
+		},
+		{
+			name:           "Production-only changes",
+			meta:           &DiffMeta{FileCount: 1, Classification: FilesProductionOnly},
+			expectedAbsent: []string{"production code", "Test files do not decide"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prompt := client.buildPrompt(CommitRequest{Diff: "diff", Meta: tt.meta})
+
+			for _, want := range tt.expectedContains {
+				if !strings.Contains(prompt, want) {
+					t.Errorf("expected prompt to contain %q", want)
+				}
+			}
+			for _, unwanted := range tt.expectedAbsent {
+				if strings.Contains(prompt, unwanted) {
+					t.Errorf("expected prompt not to contain %q", unwanted)
+				}
+			}
+		})
+	}
+}
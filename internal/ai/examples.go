@@ -0,0 +1,63 @@
+package ai
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MaxExamples is the most examples put in a prompt; later ones are left out
+const MaxExamples = 5
+
+// maxExampleBytes caps the examples in a prompt at roughly 600 tokens (~4
+// bytes per token), so they never crowd out the diff. An example that does
+// not fit in what is left is skipped.
+const maxExampleBytes = 2400
+
+// Example is a commit message the team wrote, shown to the model as a
+// demonstration of their style
+type Example struct {
+	// DiffSummary says in a line or two what the change was
+	DiffSummary string `json:"diff_summary"`
+	// Message is the commit message written for it
+	Message string `json:"message"`
+}
+
+// promptExamples returns the examples that fit in the prompt: at most
+// MaxExamples, in order, within maxExampleBytes. Examples without a message
+// are skipped.
+func promptExamples(examples []Example) []Example {
+	var fitting []Example
+	budget := maxExampleBytes
+	for _, example := range examples {
+		if len(fitting) == MaxExamples {
+			break
+		}
+		message := strings.TrimSpace(example.Message)
+		size := len(example.DiffSummary) + len(message)
+		if message == "" || size > budget {
+			continue
+		}
+		budget -= size
+		fitting = append(fitting, Example{DiffSummary: strings.TrimSpace(example.DiffSummary), Message: message})
+	}
+	return fitting
+}
+
+// writeExamples shows the model messages the team wrote before, so it
+// copies their style rather than their content
+func writeExamples(sb *strings.Builder, examples []Example) {
+	examples = promptExamples(examples)
+	if len(examples) == 0 {
+		return
+	}
+	sb.WriteString("=== EXAMPLES ===\n")
+	sb.WriteString("These are commit messages this team wrote for earlier changes. Match their style: wording, tense, level of detail and layout. Do not copy their content; describe only the diff below.\n\n")
+	for i, example := range examples {
+		fmt.Fprintf(sb, "Example %d\n", i+1)
+		if example.DiffSummary != "" {
+			sb.WriteString("Change: " + example.DiffSummary + "\n")
+		}
+		sb.WriteString("Message:\n" + example.Message + "\n\n")
+	}
+	sb.WriteString("================\n\n")
+}
@@ -0,0 +1,52 @@
+package ai
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestBuildPrompt_Examples(t *testing.T) {
+	client := &OllamaClient{}
+	examples := []Example{
+		{DiffSummary: "add a login form", Message: "feat(auth): add login form"},
+		{Message: "fix(api): retry on 503\n\nThe gateway drops requests during deploys.\n"},
+	}
+
+	prompt := client.buildPrompt(CommitRequest{Diff: "diff --git a/x b/x", Examples: examples})
+	for _, expected := range []string{
+		"=== EXAMPLES ===",
+		"Example 1\nChange: add a login form\nMessage:\nfeat(auth): add login form\n",
+		"Example 2\nMessage:\nfix(api): retry on 503\n\nThe gateway drops requests during deploys.\n",
+	} {
+		if !strings.Contains(prompt, expected) {
+			t.Errorf("expected %q in the prompt, got:\n%s", expected, prompt)
+		}
+	}
+	if strings.Index(prompt, "=== EXAMPLES ===") > strings.Index(prompt, "Diff:\n") {
+		t.Errorf("expected the examples before the diff, got:\n%s", prompt)
+	}
+
+	for _, empty := range [][]Example{nil, {{DiffSummary: "no message"}}} {
+		prompt := client.buildPrompt(CommitRequest{Diff: "diff --git a/x b/x", Examples: empty})
+		if strings.Contains(prompt, "EXAMPLES") {
+			t.Errorf("expected no examples section for %v, got:\n%s", empty, prompt)
+		}
+	}
+}
+
+func TestPromptExamples_Bounded(t *testing.T) {
+	var many []Example
+	for i := 0; i < MaxExamples+2; i++ {
+		many = append(many, Example{Message: fmt.Sprintf("feat: change %d", i)})
+	}
+	if got := promptExamples(many); len(got) != MaxExamples || got[MaxExamples-1].Message != fmt.Sprintf("feat: change %d", MaxExamples-1) {
+		t.Errorf("expected the first %d examples, got %v", MaxExamples, got)
+	}
+
+	long := Example{Message: "feat: long\n\n" + strings.Repeat("x", maxExampleBytes)}
+	got := promptExamples([]Example{{Message: "fix: a"}, long, {Message: "fix: b"}})
+	if len(got) != 2 || got[0].Message != "fix: a" || got[1].Message != "fix: b" {
+		t.Errorf("expected the example over the budget to be skipped, got %v", got)
+	}
+}
@@ -0,0 +1,12 @@
+package ai
+
+import "strings"
+
+// writeExistingMessage asks the model to build on the message the user
+// already started, such as a ticket line or notes another hook wrote
+func writeExistingMessage(sb *strings.Builder, existing string) {
+	sb.WriteString("=== MESSAGE STARTED BY THE USER ===\n")
+	sb.WriteString("The user already started the commit message below. Extend it into a complete message for this diff instead of starting over: write a new first line that works in anything their first line refers to, such as a ticket, keep their other lines word for word, trailers included, and add what the diff shows to the body.\n\n")
+	sb.WriteString(strings.TrimSpace(existing))
+	sb.WriteString("\n===================================\n\n")
+}
@@ -0,0 +1,25 @@
+package ai
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildPrompt_ExistingMessage(t *testing.T) {
+	client := &OllamaClient{}
+
+	prompt := client.buildPrompt(CommitRequest{Diff: "diff", ExistingMessage: "PROJ-42\n\nRefs: #12\n"})
+	for _, want := range []string{
+		"=== MESSAGE STARTED BY THE USER ===",
+		"keep their other lines word for word",
+		"\n\nPROJ-42\n\nRefs: #12\n===",
+	} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("expected prompt to contain %q, got:\n%s", want, prompt)
+		}
+	}
+
+	if prompt := client.buildPrompt(CommitRequest{Diff: "diff"}); strings.Contains(prompt, "MESSAGE STARTED BY THE USER") {
+		t.Errorf("expected no existing message section without one, got:\n%s", prompt)
+	}
+}
@@ -0,0 +1,64 @@
+package ai
+
+import (
+	"fmt"
+	"strings"
+
+	"ai-commit-message-generator/internal/git"
+)
+
+// explainIntro opens the explain prompt unless a system prompt replaces it
+const explainIntro = "You are an experienced software engineer explaining code changes to a colleague who is about to review them."
+
+// ExplainChanges asks the model for a plain-language explanation of what
+// the change in req does and why it might have been made. It takes the
+// same request as GenerateCommitMessage; the commit message instructions,
+// template and team rules are left out of the prompt.
+func (c *OllamaClient) ExplainChanges(req CommitRequest) (string, error) {
+	c.phase(PhaseBuildingPrompt)
+	response, err := c.generate(c.buildExplainPrompt(req))
+	if err != nil {
+		return "", err
+	}
+
+	c.phase(PhasePostProcessing)
+	explanation := normalizeMessage(response)
+	if explanation == "" {
+		return "", fmt.Errorf("empty response from model")
+	}
+	return explanation, nil
+}
+
+func (c *OllamaClient) buildExplainPrompt(req CommitRequest) string {
+	var sb strings.Builder
+	if c.systemPrompt == "" || c.systemPromptMode == SystemPromptPrepend {
+		sb.WriteString(explainIntro + "\n\n")
+	}
+
+	sb.WriteString("Explain the following code diff in plain language.\n\n")
+	sb.WriteString("Write one to three short paragraphs of prose:\n")
+	sb.WriteString("- What the change does, in terms of behavior rather than line by line.\n")
+	sb.WriteString("- Why it was most likely made. Say so when you are guessing.\n")
+	sb.WriteString("- Anything a reviewer should look at closely, such as risky edits, missing tests or unfinished work.\n\n")
+	sb.WriteString("Do not write a commit message, a title or headings, and do not quote the diff back.\n\n")
+
+	if req.GitState != nil && req.GitState.Type != git.StateNormal {
+		sb.WriteString(fmt.Sprintf("The change completes a %s that is in progress.\n\n", req.GitState.Type))
+	}
+	if meta := req.Meta; meta != nil && meta.FileCount > 0 {
+		sb.WriteString("Change Context:\n")
+		sb.WriteString(fmt.Sprintf("- Files changed: %d\n", meta.FileCount))
+		if len(meta.Languages) > 0 {
+			sb.WriteString(fmt.Sprintf("- Languages: %s\n", strings.Join(meta.Languages, ", ")))
+		}
+		if meta.TestsTouched {
+			sb.WriteString("- Tests touched: yes\n\n")
+		} else {
+			sb.WriteString("- Tests touched: no\n\n")
+		}
+	}
+
+	sb.WriteString("Diff:\n")
+	sb.WriteString(req.Diff)
+	return sb.String()
+}
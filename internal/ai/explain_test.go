@@ -0,0 +1,69 @@
+package ai
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"ai-commit-message-generator/internal/git"
+)
+
+func TestOllamaClient_ExplainChanges(t *testing.T) {
+	req := CommitRequest{
+		Diff:     "diff --git a/login.go b/login.go",
+		Rules:    "Always use the imperative mood",
+		GitState: &git.GitState{Type: git.StateMerge, OriginalMessage: "Merge branch 'feature'"},
+		Meta:     &DiffMeta{FileCount: 2, Languages: []string{"Go"}, TestsTouched: true, SuggestedType: "test"},
+		FastPath: &FastPath{Kind: ChangesetDocs},
+		Template: &CommitTemplate{Text: "Ticket: [TICKET]"},
+	}
+
+	var body ollamaRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("failed to decode request body: %v", err)
+		}
+		response, _ := json.Marshal(ollamaResponse{Response: "```\nThe change adds a login handler.\n```", Done: true})
+		w.Write(response)
+	}))
+	defer server.Close()
+
+	client := NewClient("test-api-key", server.URL+"/api/generate", "", time.Second)
+	explanation, err := client.ExplainChanges(req)
+	if err != nil {
+		t.Fatalf("ExplainChanges failed: %v", err)
+	}
+	if expected := "The change adds a login handler."; explanation != expected {
+		t.Errorf("expected %q, got %q", expected, explanation)
+	}
+
+	for _, want := range []string{
+		explainIntro,
+		"Explain the following code diff in plain language.",
+		"Why it was most likely made.",
+		"The change completes a merge that is in progress.",
+		"- Files changed: 2\n- Languages: Go\n- Tests touched: yes\n",
+		"Diff:\ndiff --git a/login.go b/login.go",
+	} {
+		if !strings.Contains(body.Prompt, want) {
+			t.Errorf("expected prompt to contain %q, got:\n%s", want, body.Prompt)
+		}
+	}
+	// The commit message instructions are replaced, not added to
+	for _, unwanted := range []string{
+		defaultIntro,
+		"Conventional Commits",
+		"split",
+		"SPECIAL GIT STATE CONTEXT",
+		"Team Rules",
+		"Ticket: [TICKET]",
+		"type should almost certainly be",
+	} {
+		if strings.Contains(body.Prompt, unwanted) {
+			t.Errorf("expected prompt not to contain %q, got:\n%s", unwanted, body.Prompt)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package ai
+
+import (
+	"ai-commit-message-generator/internal/git"
+)
+
+// Changeset kinds the fast path recognizes
+const (
+	ChangesetDocs         = "docs"
+	ChangesetConfig       = "config"
+	ChangesetDependencies = "dependencies"
+)
+
+// fastPathDiffBytes caps the diff sent for a fast path changeset. The type
+// is already known, so the model only needs enough to write the subject.
+const fastPathDiffBytes = 4000
+
+// Default path patterns of the fast path changesets
+var (
+	DefaultDocsPatterns = []string{
+		"*.md", "*.rst", "*.adoc", "docs/", "doc/", "README*", "CHANGELOG*", "LICENSE*",
+	}
+	DefaultConfigPatterns = []string{
+		"*.yml", "*.yaml", "*.toml", "*.ini", ".editorconfig", ".gitignore", ".gitattributes",
+		".dockerignore", ".env.example", ".commit-generator-config",
+	}
+	DefaultDependencyPatterns = []string{
+		"go.mod", "go.sum", "package.json", "package-lock.json", "yarn.lock", "pnpm-lock.yaml",
+		"Cargo.toml", "Cargo.lock", "requirements*.txt", "Pipfile", "Pipfile.lock", "poetry.lock",
+		"Gemfile", "Gemfile.lock", "composer.json", "composer.lock",
+	}
+)
+
+// FastPathOptions controls how trivial changesets are recognized. Nil
+// pattern lists use the defaults.
+type FastPathOptions struct {
+	// Disabled turns the fast path off, so every changeset gets the full
+	// prompt
+	Disabled bool
+	// Docs, Config and Dependencies are globs marking a path as belonging
+	// to that kind of changeset
+	Docs         []string
+	Config       []string
+	Dependencies []string
+}
+
+// FastPath describes a changeset whose commit type is known before the
+// model is asked, so the prompt only asks for the description
+type FastPath struct {
+	// Kind is ChangesetDocs, ChangesetConfig or ChangesetDependencies
+	Kind string
+	// Type and Scope are the Conventional Commits type and scope to use;
+	// Scope may be empty
+	Type  string
+	Scope string
+}
+
+// Header returns the "<type>(<scope>)" prefix of the message
+func (f *FastPath) Header() string {
+	if f.Scope == "" {
+		return f.Type
+	}
+	return f.Type + "(" + f.Scope + ")"
+}
+
+// ClassifyChangeset returns the fast path for files when every described
+// file belongs to the same kind of changeset, and nil otherwise.
+// Dependency files are checked first, so lockfiles in YAML are not taken
+// for configuration.
+func ClassifyChangeset(files []git.StagedFile, opts FastPathOptions) *FastPath {
+	files = describedFiles(files)
+	if opts.Disabled || len(files) == 0 {
+		return nil
+	}
+
+	candidates := []struct {
+		patterns []string
+		defaults []string
+		path     FastPath
+	}{
+		{opts.Dependencies, DefaultDependencyPatterns, FastPath{Kind: ChangesetDependencies, Type: "chore", Scope: "deps"}},
+		{opts.Docs, DefaultDocsPatterns, FastPath{Kind: ChangesetDocs, Type: "docs"}},
+		{opts.Config, DefaultConfigPatterns, FastPath{Kind: ChangesetConfig, Type: "chore", Scope: "config"}},
+	}
+	for _, candidate := range candidates {
+		patterns := candidate.patterns
+		if patterns == nil {
+			patterns = candidate.defaults
+		}
+		if allMatch(files, patterns) {
+			path := candidate.path
+			return &path
+		}
+	}
+	return nil
+}
+
+// allMatch reports whether every file matches one of patterns
+func allMatch(files []git.StagedFile, patterns []string) bool {
+	if len(patterns) == 0 {
+		return false
+	}
+	for _, file := range files {
+		matched := false
+		for _, pattern := range patterns {
+			if git.MatchGlob(pattern, file.Path) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,115 @@
+package ai
+
+import (
+	"strings"
+	"testing"
+
+	"ai-commit-message-generator/internal/git"
+)
+
+func TestClassifyChangeset(t *testing.T) {
+	tests := []struct {
+		name           string
+		paths          []string
+		excluded       []string
+		opts           FastPathOptions
+		expectedHeader string
+	}{
+		{
+			name:           "Docs only",
+			paths:          []string{"README.md", "docs/setup.txt"},
+			expectedHeader: "docs",
+		},
+		{
+			name:           "Dependencies only",
+			paths:          []string{"go.mod", "go.sum"},
+			expectedHeader: "chore(deps)",
+		},
+		{
+			name:           "YAML lockfile is a dependency, not config",
+			paths:          []string{"package.json", "pnpm-lock.yaml"},
+			expectedHeader: "chore(deps)",
+		},
+		{
+			name:           "Config only",
+			paths:          []string{".golangci.yml", ".editorconfig"},
+			expectedHeader: "chore(config)",
+		},
+		{
+			name:  "Docs and code",
+			paths: []string{"README.md", "main.go"},
+		},
+		{
+			name:  "Docs and dependencies",
+			paths: []string{"README.md", "go.mod"},
+		},
+		{
+			name:           "Excluded files are ignored",
+			paths:          []string{"CHANGELOG.md"},
+			excluded:       []string{"main.go"},
+			expectedHeader: "docs",
+		},
+		{
+			name:  "Disabled",
+			paths: []string{"README.md"},
+			opts:  FastPathOptions{Disabled: true},
+		},
+		{
+			name:           "Custom patterns replace the defaults",
+			paths:          []string{"handbook/intro.html"},
+			opts:           FastPathOptions{Docs: []string{"handbook/"}},
+			expectedHeader: "docs",
+		},
+		{
+			name:  "Custom patterns drop the defaults",
+			paths: []string{"README.md"},
+			opts:  FastPathOptions{Docs: []string{"handbook/"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var files []git.StagedFile
+			for _, p := range tt.paths {
+				files = append(files, git.StagedFile{Path: p, Change: git.ChangeModified})
+			}
+			for _, p := range tt.excluded {
+				files = append(files, git.StagedFile{Path: p, Change: git.ChangeModified, Excluded: true})
+			}
+
+			fastPath := ClassifyChangeset(files, tt.opts)
+			header := ""
+			if fastPath != nil {
+				header = fastPath.Header()
+			}
+			if header != tt.expectedHeader {
+				t.Errorf("expected %q, got %q", tt.expectedHeader, header)
+			}
+		})
+	}
+}
+
+func TestBuildPrompt_FastPath(t *testing.T) {
+	client := &OllamaClient{}
+	diff := strings.Repeat("+line\n", 2000)
+
+	prompt := client.buildPrompt(CommitRequest{
+		Diff:     diff,
+		FastPath: &FastPath{Kind: ChangesetDependencies, Type: "chore", Scope: "deps"},
+	})
+	for _, want := range []string{
+		"Every file in the following diff is a dependency manifest or lockfile",
+		`MUST start with "chore(deps): "`,
+		"...[TRUNCATED]",
+	} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("expected prompt to contain %q", want)
+		}
+	}
+	if strings.Contains(prompt, "should be split") {
+		t.Error("expected no split analysis on the fast path")
+	}
+	if len(prompt) >= len(diff) {
+		t.Errorf("expected the diff to be shortened, prompt has %d bytes", len(prompt))
+	}
+}
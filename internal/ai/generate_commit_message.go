@@ -2,32 +2,152 @@ package ai
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
-	"os"
 	"strings"
 	"time"
 
 	"ai-commit-message-generator/internal/git"
+	"ai-commit-message-generator/internal/logging"
+
+	"golang.org/x/sync/singleflight"
+	"golang.org/x/time/rate"
 )
 
 // Client defines the interface for AI operations
 type Client interface {
-	GenerateCommitMessage(diff string, rules string, gitState *git.GitState) (string, error)
+	GenerateCommitMessage(req CommitRequest) (string, error)
+	GeneratePRDescription(req PRRequest) (string, error)
+	PlanSplit(req SplitRequest) (*SplitPlan, error)
+	GenerateChangelog(req ChangelogRequest) (string, error)
+	GenerateTagMessage(req TagRequest) (string, error)
+	SuggestBranches(req BranchRequest) ([]BranchSuggestion, error)
+	ExplainChanges(req CommitRequest) (string, error)
+	ReviewChanges(req CommitRequest) ([]ReviewFinding, error)
+	CheckBreakingChange(req CommitRequest) (*BreakingCheck, error)
+}
+
+// CommitRequest holds everything the commit message prompt is built from
+type CommitRequest struct {
+	// Diff is the staged diff
+	Diff string
+	// Rules are the team rules from .git-commit-rules-for-ai
+	Rules string
+	// GitState is the in-progress operation (merge, rebase, ...), if any
+	GitState *git.GitState
+	// Meta is optional structured context about the staged files
+	Meta *DiffMeta
+	// Feedback is an optional note from the user when regenerating
+	Feedback string
+	// PreviousMessage is the candidate the Feedback refers to. When both are
+	// set the model is asked to revise it rather than start from scratch.
+	PreviousMessage string
+	// FastPath, when set, fixes the type and scope of a trivial changeset;
+	// the prompt skips the split analysis and sends a shorter diff
+	FastPath *FastPath
+	// Template, when set, is the commit.template the message fills in
+	Template *CommitTemplate
+	// ExistingMessage, when set, is what the user already wrote in the
+	// message file; the generated message extends it
+	ExistingMessage string
+	// Explain asks for a short rationale for the type and scope after the
+	// message, separated by RationaleSeparator; see SplitRationale
+	Explain bool
+	// Scope, when set, is the scope the scope map derived from the changed
+	// paths; it overrides the model's guess
+	Scope *ScopeHint
+	// NoSplit leaves the split analysis out of the prompt, so the model
+	// always writes a single message
+	NoSplit bool
+	// HeaderFormat is the house layout of the first line; nil means
+	// DefaultHeaderFormat
+	HeaderFormat *HeaderFormat
+	// ScopePolicy is ScopeRequired or ScopeForbidden to insist on a scope
+	// or rule it out; "" and ScopeOptional leave it to the model
+	ScopePolicy string
+	// Types, when set, replace DefaultTypes as the types the message may
+	// use
+	Types []string
+	// Scopes, when set, are the only scopes the message may use
+	Scopes []string
+	// SubjectStyle is the case and final period of the description; the
+	// zero value adds nothing to the prompt
+	SubjectStyle SubjectStyle
+	// Language, when set, is the language to write the message in
+	Language string
+	// Examples are messages the team wrote before, shown as few-shot
+	// demonstrations of their style; see MaxExamples
+	Examples []Example
 }
 
+// defaultIntro opens the prompt unless a system prompt replaces it
+const defaultIntro = "You are an expert DevOps engineer specialized in writing git commit messages."
+
+// System prompt modes
+const (
+	// SystemPromptReplace uses the system prompt instead of the default intro
+	SystemPromptReplace = "replace"
+	// SystemPromptPrepend sends the system prompt ahead of the default intro
+	SystemPromptPrepend = "prepend"
+)
+
 // OllamaClient implements the Client interface for Ollama API
 type OllamaClient struct {
 	apiKey  string
 	baseURL string
 	model   string
 	client  *http.Client
+
+	systemPrompt     string
+	systemPromptMode string
+
+	progress Progress
+	stream   bool
+	verbose  bool
+	// logger receives the requests and retries; see WithLogger
+	logger *slog.Logger
+	// keepAlive is sent as keep_alive, see ParseKeepAlive
+	keepAlive interface{}
+	// authHeader and authScheme say how the API key is sent, see SetAuth
+	authHeader string
+	authScheme string
+	// retryDelay is the wait before the first retry after a rate limit
+	retryDelay time.Duration
+
+	// cache turns on inflight, see WithCache
+	cache bool
+	// inflight lets concurrent identical generations share one API call
+	inflight singleflight.Group
+
+	// ctx and complete are set by WithContext and WithComplete
+	ctx      context.Context
+	complete CompleteFunc
+	// limiter spaces API calls as WithRequestsPerMinute says; nil means
+	// no limit
+	limiter *rate.Limiter
+}
+
+// Option configures optional OllamaClient behavior
+type Option func(*OllamaClient)
+
+// WithSystemPrompt sends prompt as the system message. mode is
+// SystemPromptReplace (the default) or SystemPromptPrepend.
+func WithSystemPrompt(prompt, mode string) Option {
+	return func(c *OllamaClient) {
+		c.systemPrompt = prompt
+		c.systemPromptMode = mode
+	}
 }
 
 // NewClient creates a new Ollama AI client from config
-func NewClient(apiKey, baseURL, model string, timeout time.Duration) Client {
+func NewClient(apiKey, baseURL, model string, timeout time.Duration, opts ...Option) Client {
 	if baseURL == "" {
 		baseURL = "http://localhost:11434/api/generate"
 	}
@@ -37,36 +157,151 @@ func NewClient(apiKey, baseURL, model string, timeout time.Duration) Client {
 	if timeout == 0 {
 		timeout = 60 * time.Second
 	}
-	return &OllamaClient{
+	c := &OllamaClient{
 		apiKey:  apiKey,
 		baseURL: baseURL,
 		model:   model,
 		client: &http.Client{
 			Timeout: timeout,
 		},
+		retryDelay: defaultRetryDelay,
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
 // Request/Response structures for Ollama API
 type ollamaRequest struct {
 	Model  string `json:"model"`
 	Prompt string `json:"prompt"`
+	System string `json:"system,omitempty"`
 	Stream bool   `json:"stream"`
+	// Format "json" constrains the response to a JSON value
+	Format string `json:"format,omitempty"`
+	// KeepAlive is how long the model stays loaded after the request, in
+	// seconds or as a duration string; nil leaves it to the server
+	KeepAlive interface{} `json:"keep_alive,omitempty"`
 }
 
 type ollamaResponse struct {
 	Response string `json:"response"`
 	Done     bool   `json:"done"`
+	// DoneReason is "load" when the request only loaded the model
+	DoneReason string `json:"done_reason,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// decodeResponse reads the generated text from a response body. Some
+// Ollama-compatible servers stream newline-delimited JSON objects even when
+// stream is false, so every object up to the one with done set is read and
+// their response fields are concatenated. onChunk, if set, is called with
+// the number of chunks read so far. A response saying the model is still
+// loading is errModelLoading.
+func decodeResponse(body io.Reader, onChunk func(count int)) (string, error) {
+	decoder := json.NewDecoder(body)
+	var sb strings.Builder
+	for chunks := 0; ; chunks++ {
+		var chunk ollamaResponse
+		err := decoder.Decode(&chunk)
+		if err == io.EOF && chunks > 0 {
+			// The server closed the stream without a done chunk
+			return sb.String(), nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to decode response: %w", err)
+		}
+		if sb.Len() == 0 && isLoadingChunk(chunk) {
+			return "", errModelLoading
+		}
+		sb.WriteString(chunk.Response)
+		if onChunk != nil {
+			onChunk(chunks + 1)
+		}
+		if chunk.Done {
+			return sb.String(), nil
+		}
+	}
 }
 
 // GenerateCommitMessage sends the diff and rules to Ollama and returns the generated message
-func (c *OllamaClient) GenerateCommitMessage(diff string, rules string, gitState *git.GitState) (string, error) {
-	prompt := c.buildPrompt(diff, rules, gitState)
+func (c *OllamaClient) GenerateCommitMessage(req CommitRequest) (string, error) {
+	c.phase(PhaseBuildingPrompt)
+	prompt := c.buildPrompt(req)
+	response, err := c.generateShared(prompt)
+	if err != nil {
+		return "", err
+	}
+
+	c.phase(PhasePostProcessing)
+	message := normalizeMessage(response)
+	if message == "" {
+		return "", fmt.Errorf("empty response from model")
+	}
+	return message, nil
+}
+
+// WithCache lets concurrent identical generations share one API call:
+// callers asking for the same prompt while a request is in flight wait for
+// it instead of sending their own. Without it every call is sent.
+func WithCache(enabled bool) Option {
+	return func(c *OllamaClient) {
+		c.cache = enabled
+	}
+}
+
+// generateShared generates for prompt, joining an identical request in
+// flight when caching is enabled
+func (c *OllamaClient) generateShared(prompt string) (string, error) {
+	if !c.cache {
+		return c.generate(prompt)
+	}
+	result, err, _ := c.inflight.Do(c.promptKey(prompt), func() (interface{}, error) {
+		return c.generate(prompt)
+	})
+	if err != nil {
+		return "", err
+	}
+	return result.(string), nil
+}
+
+// promptKey identifies a generation by everything that is sent to the model
+func (c *OllamaClient) promptKey(prompt string) string {
+	sum := sha256.Sum256([]byte(c.model + "\x00" + c.systemPrompt + "\x00" + prompt))
+	return hex.EncodeToString(sum[:])
+}
+
+// generate sends prompt to Ollama, retrying when rate limited or while the
+// model is loading, and returns the raw response text
+func (c *OllamaClient) generate(prompt string) (string, error) {
+	return c.send(prompt, "")
+}
+
+// send is generate with the response format, "" for free text or "json"
+func (c *OllamaClient) send(prompt, format string) (string, error) {
+	return c.sendWithSystem(c.systemPrompt, prompt, format)
+}
+
+// sendWithSystem is send with the system prompt given rather than the
+// client's. A CompleteFunc set with WithComplete answers in place of the
+// API.
+func (c *OllamaClient) sendWithSystem(system, prompt, format string) (string, error) {
+	if c.complete != nil {
+		c.phase(PhaseWaiting)
+		if err := c.waitForLimit(); err != nil {
+			return "", err
+		}
+		return c.complete(system, prompt, format)
+	}
 
 	reqBody := ollamaRequest{
-		Model:  c.model,
-		Prompt: prompt,
-		Stream: false,
+		Model:     c.model,
+		Prompt:    prompt,
+		System:    system,
+		Stream:    c.stream,
+		Format:    format,
+		KeepAlive: c.keepAlive,
 	}
 
 	jsonBody, err := json.Marshal(reqBody)
@@ -74,61 +309,94 @@ func (c *OllamaClient) GenerateCommitMessage(diff string, rules string, gitState
 		return "", fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	// Retry loop
-	maxRetries := 3
-	baseDelay := 2 * time.Second
+	c.phase(PhaseWaiting)
 
+	// Retry loop
+	var waited time.Duration
+	// loading is set while the last response said the model is loading
+	var loading bool
 	for attempt := 0; attempt <= maxRetries; attempt++ {
 		if attempt > 0 {
 			// Backoff logic
-			delay := baseDelay * time.Duration(1<<uint(attempt-1)) // 2s, 4s, 8s
-			fmt.Fprintf(os.Stderr, "\033[33mRate limit hit. Retrying in %v...\033[0m\n", delay)
+			delay := c.retryDelay * time.Duration(1<<uint(attempt-1)) // 2s, 4s, 8s
+			if loading {
+				c.log().Warn(fmt.Sprintf("model loading, waiting %v", delay), logging.KeyStage, "generate", logging.KeyModel, c.model, "attempt", attempt+1)
+			} else {
+				c.log().Warn(fmt.Sprintf("rate limit hit, retrying in %v", delay), logging.KeyStage, "generate", logging.KeyModel, c.model, "attempt", attempt+1)
+			}
 			time.Sleep(delay)
+			waited += delay
+		}
+
+		if err := c.waitForLimit(); err != nil {
+			return "", err
 		}
 
-		req, err := http.NewRequest("POST", c.baseURL, bytes.NewBuffer(jsonBody))
+		req, err := http.NewRequestWithContext(c.requestContext(), "POST", c.baseURL, bytes.NewBuffer(jsonBody))
 		if err != nil {
 			return "", fmt.Errorf("failed to create request: %w", err)
 		}
 		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+		SetAuth(req.Header, c.authHeader, c.authScheme, c.apiKey)
 
+		start := time.Now()
 		resp, err := c.client.Do(req)
 		if err != nil {
 			return "", fmt.Errorf("API call failed: %w", err)
 		}
 		defer resp.Body.Close()
+		c.log().Debug("API response", logging.KeyStage, "generate", logging.KeyModel, c.model, logging.KeyStatus, resp.StatusCode, logging.KeyDuration, time.Since(start), "attempt", attempt+1)
 
 		if resp.StatusCode == 429 {
+			loading = false
 			if attempt == maxRetries {
 				body, _ := io.ReadAll(resp.Body)
-				return "", fmt.Errorf("API rate limit exceeded after %d retries: %s", maxRetries, string(body))
+				return "", &RateLimitError{Attempts: attempt + 1, Waited: waited, Body: string(body), Verbose: c.verbose}
+			}
+			continue // Retry
+		}
+
+		// Ollama answers 503 while it loads the model
+		if resp.StatusCode == http.StatusServiceUnavailable {
+			loading = true
+			if attempt == maxRetries {
+				body, _ := io.ReadAll(resp.Body)
+				return "", &ModelLoadingError{Attempts: attempt + 1, Waited: waited, Body: string(body), Verbose: c.verbose}
 			}
 			continue // Retry
 		}
 
 		if resp.StatusCode != http.StatusOK {
 			body, _ := io.ReadAll(resp.Body)
-			return "", fmt.Errorf("API returned error: %s (body: %s)", resp.Status, string(body))
+			return "", &APIError{StatusCode: resp.StatusCode, Status: resp.Status, Body: string(body)}
 		}
 
-		var ollamaResp ollamaResponse
-		if err := json.NewDecoder(resp.Body).Decode(&ollamaResp); err != nil {
-			return "", fmt.Errorf("failed to decode response: %w", err)
+		var onChunk func(count int)
+		if c.stream {
+			onChunk = c.tokens
 		}
-
-		if ollamaResp.Response == "" {
-			return "", fmt.Errorf("empty response from model")
+		text, err := decodeResponse(resp.Body, onChunk)
+		if errors.Is(err, errModelLoading) {
+			loading = true
+			if attempt == maxRetries {
+				return "", &ModelLoadingError{Attempts: attempt + 1, Waited: waited, Verbose: c.verbose}
+			}
+			continue // Retry
 		}
-
-		return strings.TrimSpace(ollamaResp.Response), nil
+		return text, err
 	}
 	return "", fmt.Errorf("unreachable")
 }
 
-func (c *OllamaClient) buildPrompt(diff string, rules string, gitState *git.GitState) string {
+func (c *OllamaClient) buildPrompt(req CommitRequest) string {
+	gitState := req.GitState
+
 	var sb strings.Builder
-	sb.WriteString("You are an expert DevOps engineer specialized in writing git commit messages.\n\n")
+	// A custom system prompt travels in the request's system field; in
+	// replace mode it takes the place of the default intro
+	if c.systemPrompt == "" || c.systemPromptMode == SystemPromptPrepend {
+		sb.WriteString(defaultIntro + "\n\n")
+	}
 	
 	// Inject git state context if not normal
 	if gitState != nil && gitState.Type != git.StateNormal {
@@ -143,17 +411,12 @@ func (c *OllamaClient) buildPrompt(diff string, rules string, gitState *git.GitS
 			sb.WriteString("\nIMPORTANT INSTRUCTIONS:\n")
 			sb.WriteString("1. You MUST use the following EXACT format for the first line:\n")
 			sb.WriteString("   <type>(merge): Merged <Source_Branch> into <Target_Branch>\n")
-			sb.WriteString("2. Analyze the diff and choose the appropriate <type> based on the changes:\n")
-			sb.WriteString("   - feat: if adding new features or capabilities\n")
-			sb.WriteString("   - fix: if fixing bugs or issues\n")
-			sb.WriteString("   - refactor: if restructuring code without changing functionality\n")
-			sb.WriteString("   - chore: if updating dependencies, configs, or maintenance tasks\n")
-			sb.WriteString("   - docs: if primarily documentation changes\n")
+			writeStateTypes(&sb, req.Types)
 			sb.WriteString("3. Extract <Source_Branch> from the Original merge intent (e.g. 'Merge branch feature-x' -> feature-x).\n")
 			sb.WriteString("4. If <Target_Branch> is unknown, use 'main' or infer from the diff/context.\n")
 			sb.WriteString("5. After the first line, leave a blank line and then provide a detailed description of what code changes were merged.\n")
 			sb.WriteString("6. Explain HOW conflicts were resolved if applicable.\n")
-			sb.WriteString("7. Example First Line: feat(merge): Merged feature-auth into main\n\n")
+			sb.WriteString("7. Example First Line: " + exampleType(req.Types, "feat") + "(merge): Merged feature-auth into main\n\n")
 			
 		case git.StateRebase:
 			sb.WriteString("CONTEXT: You are completing a REBASE conflict resolution.\n")
@@ -163,17 +426,12 @@ func (c *OllamaClient) buildPrompt(diff string, rules string, gitState *git.GitS
 			sb.WriteString("\nIMPORTANT INSTRUCTIONS:\n")
 			sb.WriteString("1. You MUST use the following EXACT format for the first line:\n")
 			sb.WriteString("   <type>(rebase): Rebased <Branch_Name> onto <Target_Branch>\n")
-			sb.WriteString("2. Analyze the diff and choose the appropriate <type> based on the changes:\n")
-			sb.WriteString("   - feat: if adding new features or capabilities\n")
-			sb.WriteString("   - fix: if fixing bugs or issues\n")
-			sb.WriteString("   - refactor: if restructuring code without changing functionality\n")
-			sb.WriteString("   - chore: if updating dependencies, configs, or maintenance tasks\n")
-			sb.WriteString("   - docs: if primarily documentation changes\n")
+			writeStateTypes(&sb, req.Types)
 			sb.WriteString("3. Extract <Branch_Name> from the Rebase context if available, otherwise infer from diff.\n")
 			sb.WriteString("4. If <Target_Branch> is unknown, use 'main' or infer from context.\n")
 			sb.WriteString("5. After the first line, leave a blank line and then provide a detailed description of what code changes were rebased.\n")
 			sb.WriteString("6. Explain HOW conflicts were resolved if applicable.\n")
-			sb.WriteString("7. Example First Line: feat(rebase): Rebased feature-auth onto main\n\n")
+			sb.WriteString("7. Example First Line: " + exampleType(req.Types, "feat") + "(rebase): Rebased feature-auth onto main\n\n")
 			
 		case git.StateCherryPick:
 			sb.WriteString("CONTEXT: You are completing a CHERRY-PICK operation.\n")
@@ -184,38 +442,219 @@ func (c *OllamaClient) buildPrompt(diff string, rules string, gitState *git.GitS
 			sb.WriteString("1. You MUST use the following EXACT format for the first line:\n")
 			sb.WriteString("   <type>(cherry-pick): Cherry-picked <Commit_Description> into <Target_Branch>\n")
 			sb.WriteString("   ⚠️  CRITICAL: The scope MUST be 'cherry-pick', NOT the original scope from the commit!\n")
-			sb.WriteString("2. Analyze the diff and choose the appropriate <type> based on the changes:\n")
-			sb.WriteString("   - feat: if adding new features or capabilities\n")
-			sb.WriteString("   - fix: if fixing bugs or issues\n")
-			sb.WriteString("   - refactor: if restructuring code without changing functionality\n")
-			sb.WriteString("   - chore: if updating dependencies, configs, or maintenance tasks\n")
-			sb.WriteString("   - docs: if primarily documentation changes\n")
+			writeStateTypes(&sb, req.Types)
 			sb.WriteString("3. Extract <Commit_Description> from the Original commit message if available.\n")
 			sb.WriteString("4. If <Target_Branch> is unknown, use 'main' or infer from context.\n")
 			sb.WriteString("5. After the first line, leave a blank line and then provide a detailed description of what was cherry-picked.\n")
 			sb.WriteString("6. Explain HOW conflicts were resolved and what adaptations were made if applicable.\n")
-			sb.WriteString("7. CORRECT Example: docs(cherry-pick): Cherry-picked feature entries update into main\n")
-			sb.WriteString("8. WRONG Example: docs(file): updated feature entries (missing cherry-pick scope!)\n\n")
+			sb.WriteString("7. CORRECT Example: " + exampleType(req.Types, "docs") + "(cherry-pick): Cherry-picked feature entries update into main\n")
+			sb.WriteString("8. WRONG Example: " + exampleType(req.Types, "docs") + "(file): updated feature entries (missing cherry-pick scope!)\n\n")
+
+		case git.StateSquash:
+			sb.WriteString("CONTEXT: You are writing the message of a SQUASH commit that combines several commits.\n")
+			if gitState.OriginalMessage != "" {
+				sb.WriteString("The messages of the squashed commits were:\n")
+				sb.WriteString(gitState.OriginalMessage)
+				sb.WriteString("\n")
+			}
+			sb.WriteString("\nIMPORTANT INSTRUCTIONS:\n")
+			sb.WriteString("1. Write ONE coherent commit message for the combined diff, not a list of the squashed commits.\n")
+			sb.WriteString("2. Choose the <type> and scope that describe the change as a whole.\n")
+			sb.WriteString("3. Use the squashed messages for intent the diff does not show; drop fixups, WIP notes and changes that later commits undid.\n")
+			sb.WriteString("4. Keep any BREAKING CHANGE the squashed messages mention.\n")
+			sb.WriteString("5. Do not mention the squash itself or the commit hashes.\n\n")
+
+		case git.StateBisect:
+			sb.WriteString("CONTEXT: The repository is in the middle of a GIT BISECT, hunting for the commit that introduced a bug.\n")
+			if gitState.OriginalMessage != "" {
+				sb.WriteString(gitState.OriginalMessage + "\n")
+			}
+			sb.WriteString("\nIMPORTANT INSTRUCTIONS:\n")
+			sb.WriteString("1. Changes committed during a bisect are usually test tweaks, debugging aids or workarounds needed to test an old commit, not new features.\n")
+			if preferred := filterTypes(req.Types, "test", "fix", "chore"); typeAllowed(req.Types, "feat") && len(preferred) > 0 {
+				sb.WriteString("2. Do not use 'feat' unless the diff clearly adds a capability; prefer " + quoteTypes(preferred) + " as the diff shows.\n")
+			} else {
+				sb.WriteString("2. Choose the type from what the diff changes, not from the bug being hunted.\n")
+			}
+			sb.WriteString("3. Describe only what the diff changes; do not claim the bug was found or fixed unless the diff fixes it.\n\n")
 		}
 		
 		sb.WriteString("=================================\n\n")
 	}
 	
-	sb.WriteString("Analyze the following code diff.\n\n")
-	sb.WriteString("First, determine whether the diff represents a single logical change or multiple independent changes that should be split into smaller commits to follow clean code and best practices.\n\n")
-	sb.WriteString("If the diff should be split, briefly state that it can be broken down and list the suggested commit scopes or purposes (do not generate the commits yet).\n\n")
-	sb.WriteString("If the diff represents a single logical change, generate a single-line git commit message following the Conventional Commits specification.\n\n")
-	sb.WriteString("Format for commit message:\n<type>(<scope>): <description>\n\n")
-	sb.WriteString("Allowed types: feat, fix, docs, style, refactor, test, chore.\n\n")
-	sb.WriteString("IMPORTANT: Use past tense for the description (e.g., 'added feature' not 'add feature', 'fixed bug' not 'fix bug').\n\n")
-	sb.WriteString("Do not output anything other than the message or the split suggestion.\n\n")
+	if req.FastPath != nil {
+		writeFastPath(&sb, req.FastPath, req.HeaderFormat)
+	} else {
+		writeInstructions(&sb, req.NoSplit, req.HeaderFormat, req.Types)
+	}
+	writeScopePolicy(&sb, req.ScopePolicy, req.HeaderFormat)
+	writeAllowedScopes(&sb, req.Scopes, req.ScopePolicy)
+	writeSubjectStyle(&sb, req.SubjectStyle)
+	writeLanguage(&sb, req.Language)
+	if gitState != nil && gitState.InitialCommit {
+		writeInitialCommit(&sb, req.HeaderFormat, req.FastPath != nil, req.Types)
+	}
+
+	if req.Meta != nil && req.Meta.FileCount > 0 {
+		writeDiffMeta(&sb, req.Meta, req.Types)
+	}
+
+	if req.Scope != nil {
+		writeScopeHint(&sb, req.Scope, req.NoSplit)
+	}
 
-	if rules != "" {
+	if req.Template != nil {
+		writeTemplate(&sb, req.Template)
+	}
+
+	if req.ExistingMessage != "" {
+		writeExistingMessage(&sb, req.ExistingMessage)
+	}
+
+	if req.Feedback != "" && req.PreviousMessage != "" {
+		sb.WriteString("=== REVISION REQUEST ===\n")
+		sb.WriteString("Revise the message according to this feedback. Keep everything the feedback does not ask to change, and output only the revised message.\n\n")
+		sb.WriteString("Previous message:\n")
+		sb.WriteString(req.PreviousMessage)
+		sb.WriteString("\n\nFeedback:\n")
+		sb.WriteString(req.Feedback)
+		sb.WriteString("\n========================\n\n")
+	} else if req.Feedback != "" {
+		sb.WriteString("User Feedback:\n")
+		sb.WriteString("A previous suggestion was rejected. Take this feedback into account:\n")
+		sb.WriteString(req.Feedback)
+		sb.WriteString("\n\n")
+	}
+
+	if req.Rules != "" {
 		sb.WriteString("Team Rules:\n")
-		sb.WriteString(rules)
+		sb.WriteString(req.Rules)
 		sb.WriteString("\n\n")
 	}
+	writeExamples(&sb, req.Examples)
+	if req.Explain {
+		writeRationaleRequest(&sb)
+	}
 	sb.WriteString("Diff:\n")
-	sb.WriteString(diff)
+	if req.FastPath != nil && len(req.Diff) > fastPathDiffBytes {
+		sb.WriteString(tagDiffLanguages(req.Diff[:fastPathDiffBytes]) + "\n...[TRUNCATED]")
+	} else {
+		sb.WriteString(tagDiffLanguages(req.Diff))
+	}
 	return sb.String()
 }
+
+// writeInstructions asks for a Conventional Commits message of one of
+// types, laid out in format or, unless noSplit is set, a split suggestion
+func writeInstructions(sb *strings.Builder, noSplit bool, format *HeaderFormat, types []string) {
+	sb.WriteString("Analyze the following code diff.\n\n")
+	if noSplit {
+		sb.WriteString("Treat the diff as a single change, however large, and generate a single-line git commit message following the Conventional Commits specification. Do not suggest splitting it.\n\n")
+	} else {
+		sb.WriteString("First, determine whether the diff represents a single logical change or multiple independent changes that should be split into smaller commits to follow clean code and best practices.\n\n")
+		sb.WriteString("If the diff should be split, briefly state that it can be broken down and list the suggested commit scopes or purposes (do not generate the commits yet).\n\n")
+		sb.WriteString("If the diff represents a single logical change, generate a single-line git commit message following the Conventional Commits specification.\n\n")
+	}
+	sb.WriteString("Format for commit message:\n" + format.Layout() + "\n\n")
+	if !format.IsDefault() {
+		sb.WriteString("This repository lays out the first line this way instead of the usual <type>(<scope>): <description>. Follow it exactly, and leave out <scope> together with the characters around it when there is no scope.\n\n")
+	}
+	sb.WriteString("Allowed types: " + strings.Join(AllowedTypes(types), ", ") + ".\n\n")
+	sb.WriteString("IMPORTANT: Use past tense for the description (e.g., 'added feature' not 'add feature', 'fixed bug' not 'fix bug').\n\n")
+	if noSplit {
+		sb.WriteString("Do not output anything other than the message.\n\n")
+	} else {
+		sb.WriteString("Do not output anything other than the message or the split suggestion.\n\n")
+	}
+}
+
+// fastPathKinds describes each fast path changeset in the prompt
+var fastPathKinds = map[string]string{
+	ChangesetDocs:         "a documentation file",
+	ChangesetConfig:       "a configuration file",
+	ChangesetDependencies: "a dependency manifest or lockfile",
+}
+
+// writeInitialCommit notes that the change is the first commit of the
+// repository, which has no history to describe it against. The usual
+// chore header is only suggested when chore is one of types.
+func writeInitialCommit(sb *strings.Builder, format *HeaderFormat, fastPath bool, types []string) {
+	sb.WriteString("This is the initial commit of the repository: there is no earlier history, and every file in the diff is new.\n")
+	if fastPath {
+		sb.WriteString("Describe what the files set up, e.g. 'initial commit with the project documentation'.\n\n")
+		return
+	}
+	if !typeAllowed(types, "chore") {
+		sb.WriteString("Choose the type from what the files set up, summarise it in the body, and do not suggest splitting the initial commit.\n\n")
+		return
+	}
+	header := format.Render(Header{Type: "chore", Description: "initial commit"})
+	sb.WriteString(fmt.Sprintf("Unless the diff clearly does something more specific, use \"%s\" as the first line and summarise in the body what the files set up. Do not suggest splitting the initial commit.\n\n", header))
+}
+
+// writeFastPath asks for a single message with a fixed type and scope
+func writeFastPath(sb *strings.Builder, fastPath *FastPath, format *HeaderFormat) {
+	sb.WriteString(fmt.Sprintf("Every file in the following diff is %s, so this is a single change; do not suggest splitting it.\n\n", fastPathKinds[fastPath.Kind]))
+	sb.WriteString("Write a single-line git commit message following the Conventional Commits specification.\n\n")
+	if format.IsDefault() {
+		sb.WriteString(fmt.Sprintf("The message MUST start with \"%s: \" followed by a short description of what changed.\n\n", fastPath.Header()))
+	} else {
+		layout := format.Render(Header{Type: fastPath.Type, Scope: fastPath.Scope, Description: "<description>"})
+		sb.WriteString(fmt.Sprintf("The first line MUST be \"%s\", with <description> replaced by a short description of what changed.\n\n", layout))
+	}
+	sb.WriteString("IMPORTANT: Use past tense for the description (e.g., 'added feature' not 'add feature', 'fixed bug' not 'fix bug').\n\n")
+	sb.WriteString("Do not output anything other than the message.\n\n")
+}
+
+// writeDiffMeta renders the staged file context and type hint into the
+// prompt, naming only types that are allowed
+func writeDiffMeta(sb *strings.Builder, meta *DiffMeta, types []string) {
+	sb.WriteString("Change Context:\n")
+	sb.WriteString(fmt.Sprintf("- Files changed: %d\n", meta.FileCount))
+	if len(meta.Languages) > 0 {
+		sb.WriteString(fmt.Sprintf("- Languages: %s\n", strings.Join(meta.Languages, ", ")))
+	}
+	if meta.TestsTouched {
+		sb.WriteString("- Tests touched: yes\n")
+	} else {
+		sb.WriteString("- Tests touched: no\n")
+	}
+	if symbols := meta.ChangedSymbols; len(symbols) > 0 {
+		more := ""
+		if len(symbols) > maxChangedSymbols {
+			more = fmt.Sprintf(" and %d more", len(symbols)-maxChangedSymbols)
+			symbols = symbols[:maxChangedSymbols]
+		}
+		sb.WriteString(fmt.Sprintf("- Changed symbols: %s%s. Use them for the scope and description.\n", strings.Join(symbols, ", "), more))
+	}
+	switch {
+	case meta.Classification == FilesMixed:
+		sb.WriteString("- Production code and its tests changed together. Choose the type from the production code")
+		if examples := filterTypes(types, "feat", "fix"); len(examples) > 0 {
+			sb.WriteString(" (e.g. " + strings.Join(examples, " or ") + ")")
+		}
+		if typeAllowed(types, "test") {
+			sb.WriteString(", never 'test'")
+		}
+		sb.WriteString(".\n")
+	case meta.Classification == FilesTestsOnly && meta.TestPolicy == TestPolicyFoldIntoMain:
+		sb.WriteString("- Every changed file is a test file. Test files do not decide the type: choose it from what the tests change")
+		if typeAllowed(types, "fix") {
+			sb.WriteString(" (e.g. fix for a broken test)")
+		}
+		sb.WriteString(", as if they were production code.\n")
+	}
+	suggested := meta.SuggestedType
+	if !typeAllowed(types, suggested) {
+		suggested = ""
+	}
+	switch suggested {
+	case "test":
+		sb.WriteString("- Every changed file is a test file, so the type should almost certainly be 'test'.\n")
+	case "docs":
+		sb.WriteString("- Every changed file is documentation, so the type should almost certainly be 'docs'.\n")
+	case "chore":
+		sb.WriteString("- Every changed file is a build, dependency or tooling file, so the type should almost certainly be 'chore'.\n")
+	}
+	sb.WriteString("\n")
+}
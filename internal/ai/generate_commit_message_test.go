@@ -1,11 +1,16 @@
 package ai
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	"ai-commit-message-generator/internal/git"
 )
 
 func TestOllamaClient_GenerateCommitMessage(t *testing.T) {
@@ -30,6 +35,37 @@ func TestOllamaClient_GenerateCommitMessage(t *testing.T) {
 			expectedMsg:    "feat: added login",
 			expectedErr:    "",
 		},
+		{
+			name:           "Fenced response is normalized",
+			diff:           "diff content",
+			mockResponse:   `{"response": "` + "```" + `\nfeat: added login\n\n\n- add handler\n` + "```" + `\n", "done": true}`,
+			mockStatusCode: http.StatusOK,
+			expectedMsg:    "feat: added login\n\n- add handler",
+		},
+		{
+			name: "Streamed chunks are concatenated",
+			diff: "diff content",
+			mockResponse: `{"response": "feat: add", "done": false}
+{"response": " login\n\n- add ", "done": false}
+{"response": "handler", "done": true, "total_duration": 123}
+{"response": "ignored after done"}`,
+			mockStatusCode: http.StatusOK,
+			expectedMsg:    "feat: add login\n\n- add handler",
+		},
+		{
+			name:           "Stream ends without done",
+			diff:           "diff content",
+			mockResponse:   `{"response": "fix: "}{"response": "handle nil config"}`,
+			mockStatusCode: http.StatusOK,
+			expectedMsg:    "fix: handle nil config",
+		},
+		{
+			name:           "Malformed chunk",
+			diff:           "diff content",
+			mockResponse:   "{\"response\": \"feat: add\"}\n{\"response\": ",
+			mockStatusCode: http.StatusOK,
+			expectedErr:    "failed to decode response",
+		},
 		{
 			name:           "API Error",
 			diff:           "diff",
@@ -103,7 +139,7 @@ func TestOllamaClient_GenerateCommitMessage(t *testing.T) {
 				},
 			}
 
-			msg, err := client.GenerateCommitMessage(tt.diff, tt.rules, nil)
+			msg, err := client.GenerateCommitMessage(CommitRequest{Diff: tt.diff, Rules: tt.rules})
 
 			if tt.expectedErr != "" {
 				if err == nil {
@@ -122,3 +158,238 @@ func TestOllamaClient_GenerateCommitMessage(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildPrompt_Feedback(t *testing.T) {
+	client := &OllamaClient{}
+
+	t.Run("Feedback without previous message", func(t *testing.T) {
+		prompt := client.buildPrompt(CommitRequest{Diff: "diff", Feedback: "be specific"})
+		if !strings.Contains(prompt, "be specific") {
+			t.Error("expected feedback in prompt")
+		}
+		if strings.Contains(prompt, "Revise the message") {
+			t.Error("expected no revision section without a previous message")
+		}
+	})
+
+	t.Run("Revision of previous message", func(t *testing.T) {
+		prompt := client.buildPrompt(CommitRequest{
+			Diff:            "diff",
+			Feedback:        "make it shorter",
+			PreviousMessage: "feat(auth): added a very long description",
+		})
+		for _, want := range []string{
+			"Revise the message according to this feedback",
+			"Previous message:\nfeat(auth): added a very long description",
+			"Feedback:\nmake it shorter",
+		} {
+			if !strings.Contains(prompt, want) {
+				t.Errorf("expected prompt to contain %q", want)
+			}
+		}
+	})
+}
+
+func TestBuildPrompt_NoSplit(t *testing.T) {
+	client := &OllamaClient{}
+
+	prompt := client.buildPrompt(CommitRequest{Diff: "diff"})
+	if !strings.Contains(prompt, "If the diff should be split") {
+		t.Errorf("expected the split analysis by default, got:\n%s", prompt)
+	}
+
+	prompt = client.buildPrompt(CommitRequest{Diff: "diff", NoSplit: true, Scope: &ScopeHint{Scope: "ai", Others: []string{"cli"}}})
+	for _, unwanted := range []string{"should be split", "split suggestion", "one commit per scope"} {
+		if strings.Contains(prompt, unwanted) {
+			t.Errorf("expected no split instructions, got %q in:\n%s", unwanted, prompt)
+		}
+	}
+	if !strings.Contains(prompt, "Do not suggest splitting it") {
+		t.Errorf("expected the single message instruction, got:\n%s", prompt)
+	}
+}
+
+func TestBuildPrompt_Bisect(t *testing.T) {
+	client := &OllamaClient{}
+
+	prompt := client.buildPrompt(CommitRequest{Diff: "diff", GitState: &git.GitState{Type: git.StateBisect, OriginalMessage: "Bisect started from: main"}})
+	for _, want := range []string{"middle of a GIT BISECT", "Bisect started from: main", "Do not use 'feat'"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("expected %q in the prompt, got:\n%s", want, prompt)
+		}
+	}
+
+	prompt = client.buildPrompt(CommitRequest{Diff: "diff", GitState: &git.GitState{Type: git.StateNormal}})
+	if strings.Contains(prompt, "BISECT") {
+		t.Errorf("expected no bisect note in the normal state, got:\n%s", prompt)
+	}
+}
+
+func TestBuildPrompt_InitialCommit(t *testing.T) {
+	client := &OllamaClient{}
+	initial := &git.GitState{Type: git.StateNormal, InitialCommit: true}
+
+	prompt := client.buildPrompt(CommitRequest{Diff: "diff", GitState: initial})
+	for _, want := range []string{"initial commit of the repository", "\"chore: initial commit\"", "summarise in the body"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("expected %q in the prompt, got:\n%s", want, prompt)
+		}
+	}
+
+	prompt = client.buildPrompt(CommitRequest{Diff: "diff", GitState: initial, FastPath: &FastPath{Kind: ChangesetDocs, Type: "docs"}})
+	if !strings.Contains(prompt, "initial commit of the repository") || strings.Contains(prompt, "chore: initial commit") {
+		t.Errorf("expected the note without the chore header on the fast path, got:\n%s", prompt)
+	}
+
+	prompt = client.buildPrompt(CommitRequest{Diff: "diff", GitState: &git.GitState{Type: git.StateNormal}})
+	if strings.Contains(prompt, "initial commit") {
+		t.Errorf("expected no initial commit note with history, got:\n%s", prompt)
+	}
+}
+
+func TestBuildPrompt_HeaderFormat(t *testing.T) {
+	client := &OllamaClient{}
+	format, err := ParseHeaderFormat("[{{.Scope}}] {{.Type}}: {{.Description}}")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	prompt := client.buildPrompt(CommitRequest{Diff: "diff"})
+	if !strings.Contains(prompt, "Format for commit message:\n<type>(<scope>): <description>\n\n") || strings.Contains(prompt, "lays out the first line") {
+		t.Errorf("expected the Conventional Commits layout by default, got:\n%s", prompt)
+	}
+
+	prompt = client.buildPrompt(CommitRequest{Diff: "diff", HeaderFormat: format})
+	if !strings.Contains(prompt, "Format for commit message:\n[<scope>] <type>: <description>\n\n") || !strings.Contains(prompt, "lays out the first line") {
+		t.Errorf("expected the custom layout, got:\n%s", prompt)
+	}
+
+	prompt = client.buildPrompt(CommitRequest{Diff: "diff", HeaderFormat: format, FastPath: &FastPath{Kind: ChangesetDocs, Type: "docs", Scope: "readme"}})
+	if !strings.Contains(prompt, "The first line MUST be \"[readme] docs: <description>\"") {
+		t.Errorf("expected the fast path header in the custom layout, got:\n%s", prompt)
+	}
+}
+
+func TestOllamaClient_SystemPrompt(t *testing.T) {
+	tests := []struct {
+		name           string
+		opts           []Option
+		expectedSystem string
+		expectIntro    bool
+	}{
+		{
+			name:        "Default keeps the intro and sends no system prompt",
+			expectIntro: true,
+		},
+		{
+			name:           "Replace drops the intro",
+			opts:           []Option{WithSystemPrompt("You write terse commit messages for a bank.", "")},
+			expectedSystem: "You write terse commit messages for a bank.",
+			expectIntro:    false,
+		},
+		{
+			name:           "Prepend keeps the intro",
+			opts:           []Option{WithSystemPrompt("Never mention ticket numbers.", SystemPromptPrepend)},
+			expectedSystem: "Never mention ticket numbers.",
+			expectIntro:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body ollamaRequest
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					t.Errorf("failed to decode request body: %v", err)
+				}
+				w.Write([]byte(`{"response": "feat: added login", "done": true}`))
+			}))
+			defer server.Close()
+
+			client := NewClient("test-api-key", server.URL+"/api/generate", "", time.Second, tt.opts...)
+			if _, err := client.GenerateCommitMessage(CommitRequest{Diff: "diff"}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if body.System != tt.expectedSystem {
+				t.Errorf("expected system %q, got %q", tt.expectedSystem, body.System)
+			}
+			if strings.Contains(body.Prompt, defaultIntro) != tt.expectIntro {
+				t.Errorf("expected intro in prompt=%v, got prompt:\n%s", tt.expectIntro, body.Prompt)
+			}
+		})
+	}
+}
+
+func TestOllamaClient_GenerateCommitMessage_Concurrent(t *testing.T) {
+	tests := []struct {
+		name          string
+		cache         bool
+		diffs         []string
+		expectedCalls int32
+	}{
+		{
+			name:          "Identical diffs share one call",
+			cache:         true,
+			diffs:         []string{"diff", "diff", "diff", "diff", "diff", "diff", "diff", "diff"},
+			expectedCalls: 1,
+		},
+		{
+			name:          "Different diffs are sent separately",
+			cache:         true,
+			diffs:         []string{"diff a", "diff b", "diff a", "diff b"},
+			expectedCalls: 2,
+		},
+		{
+			name:          "Identical diffs are each sent without caching",
+			diffs:         []string{"diff", "diff"},
+			expectedCalls: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			release := make(chan struct{})
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				// Hold the response until every caller has started
+				<-release
+				w.Write([]byte(`{"response": "feat: added login", "done": true}`))
+			}))
+			defer server.Close()
+
+			client := NewClient("test-api-key", server.URL+"/api/generate", "", 5*time.Second, WithCache(tt.cache))
+			var wg sync.WaitGroup
+			messages := make([]string, len(tt.diffs))
+			errs := make([]error, len(tt.diffs))
+			for i, diff := range tt.diffs {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					messages[i], errs[i] = client.GenerateCommitMessage(CommitRequest{Diff: diff})
+				}()
+			}
+
+			deadline := time.Now().Add(2 * time.Second)
+			for calls.Load() < tt.expectedCalls && time.Now().Before(deadline) {
+				time.Sleep(5 * time.Millisecond)
+			}
+			// Give the remaining callers time to join the requests in flight
+			time.Sleep(50 * time.Millisecond)
+			close(release)
+			wg.Wait()
+
+			if got := calls.Load(); got != tt.expectedCalls {
+				t.Errorf("expected %d API calls, got %d", tt.expectedCalls, got)
+			}
+			for i := range tt.diffs {
+				if errs[i] != nil {
+					t.Errorf("caller %d failed: %v", i, errs[i])
+				} else if messages[i] != "feat: added login" {
+					t.Errorf("caller %d got %q", i, messages[i])
+				}
+			}
+		})
+	}
+}
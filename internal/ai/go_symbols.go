@@ -0,0 +1,125 @@
+package ai
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+)
+
+// maxChangedSymbols caps the changed symbols listed in the prompt
+const maxChangedSymbols = 20
+
+// goDecl is a top-level declaration: its name as shown in the prompt, a key
+// that is unique within the file, and its source text
+type goDecl struct {
+	name string
+	key  string
+	text string
+}
+
+// ChangedGoSymbols returns the top-level declarations that after adds or
+// changes compared with before, in the order they appear in after:
+// functions as Foo, methods as (*Bar).Baz or Bar.Baz, and the names of
+// types, variables and constants. Either side may be invalid Go, as a file
+// mid-edit often is; whatever parses is compared, and nothing is returned
+// when after does not parse at all.
+func ChangedGoSymbols(before, after string) []string {
+	old := make(map[string]string)
+	for _, decl := range goDeclarations(before) {
+		old[decl.key] = decl.text
+	}
+
+	var symbols []string
+	for _, decl := range goDeclarations(after) {
+		if text, ok := old[decl.key]; !ok || text != decl.text {
+			symbols = append(symbols, decl.name)
+		}
+	}
+	return symbols
+}
+
+// goDeclarations parses src and lists its top-level declarations. A file
+// with syntax errors yields the declarations the parser recovered.
+func goDeclarations(src string) []goDecl {
+	if src == "" {
+		return nil
+	}
+	fset := token.NewFileSet()
+	file, _ := parser.ParseFile(fset, "", src, parser.ParseComments|parser.SkipObjectResolution)
+	if file == nil {
+		return nil
+	}
+	text := func(from, to token.Pos) string {
+		start, end := fset.Position(from).Offset, fset.Position(to).Offset
+		if !from.IsValid() || !to.IsValid() || start > end || end > len(src) {
+			return ""
+		}
+		return src[start:end]
+	}
+
+	var decls []goDecl
+	seen := make(map[string]int)
+	add := func(name, source string) {
+		// init may be declared many times
+		seen[name]++
+		decls = append(decls, goDecl{name: name, key: fmt.Sprintf("%s#%d", name, seen[name]), text: source})
+	}
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			from := d.Pos()
+			if d.Doc != nil {
+				from = d.Doc.Pos()
+			}
+			add(funcSymbol(d), text(from, d.End()))
+		case *ast.GenDecl:
+			var doc string
+			if !d.Lparen.IsValid() && d.Doc != nil {
+				// The comment of an ungrouped declaration belongs to it
+				doc = text(d.Doc.Pos(), d.Doc.End())
+			}
+			for _, spec := range d.Specs {
+				source := doc + text(spec.Pos(), spec.End())
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					add(s.Name.Name, source)
+				case *ast.ValueSpec:
+					for _, name := range s.Names {
+						if name.Name != "_" {
+							add(name.Name, source)
+						}
+					}
+				}
+			}
+		}
+	}
+	return decls
+}
+
+// funcSymbol names a function Foo and a method (*Bar).Baz or Bar.Baz
+func funcSymbol(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return fn.Name.Name
+	}
+	recv := fn.Recv.List[0].Type
+	if star, ok := recv.(*ast.StarExpr); ok {
+		return "(*" + receiverName(star.X) + ")." + fn.Name.Name
+	}
+	return receiverName(recv) + "." + fn.Name.Name
+}
+
+// receiverName is the type name of a receiver, without type parameters
+func receiverName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.IndexExpr:
+		return receiverName(t.X)
+	case *ast.IndexListExpr:
+		return receiverName(t.X)
+	case *ast.ParenExpr:
+		return receiverName(t.X)
+	}
+	return "?"
+}
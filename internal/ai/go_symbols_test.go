@@ -0,0 +1,109 @@
+package ai
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestChangedGoSymbols(t *testing.T) {
+	before := `package auth
+
+// Login signs a user in
+func Login(name string) error { return nil }
+
+func Logout() {}
+
+type Session struct{ ID string }
+
+func (s *Session) Refresh() {}
+
+func (s Session) Valid() bool { return true }
+
+const Timeout = 10
+
+var (
+	retries = 3
+	backoff = 2
+)
+
+func init() {}
+`
+
+	tests := []struct {
+		name     string
+		before   string
+		after    string
+		expected []string
+	}{
+		{
+			name:   "Added and modified declarations",
+			before: before,
+			after: strings.NewReplacer(
+				"return nil }", "return validate(name) }",
+				"func (s *Session) Refresh() {}", "func (s *Session) Refresh() { s.ID = \"\" }",
+				"retries = 3", "retries = 5",
+				"func init() {}", "func init() {}\n\nfunc validate(name string) error { return nil }\n\ntype Store[T any] struct{}\n\nfunc (s *Store[T]) Put(v T) {}",
+			).Replace(before),
+			expected: []string{"Login", "(*Session).Refresh", "retries", "validate", "Store", "(*Store).Put"},
+		},
+		{
+			name:     "Doc comment change",
+			before:   before,
+			after:    strings.Replace(before, "// Login signs a user in", "// Login signs a user in with a password", 1),
+			expected: []string{"Login"},
+		},
+		{
+			name:     "Value receiver",
+			before:   before,
+			after:    strings.Replace(before, "return true", "return s.ID != \"\"", 1),
+			expected: []string{"Session.Valid"},
+		},
+		{
+			name:     "New file",
+			after:    "package auth\n\nfunc Login() {}\n\nvar _ = Login\n",
+			expected: []string{"Login"},
+		},
+		{
+			name:     "Removed declarations are not listed",
+			before:   before,
+			after:    strings.Replace(before, "func Logout() {}\n", "", 1),
+			expected: nil,
+		},
+		{
+			name:     "File mid-edit",
+			before:   before,
+			after:    strings.Replace(before, "func Logout() {}", "func Logout() {\n\tif", 1),
+			expected: []string{"Logout"},
+		},
+		{
+			name:   "Not Go at all",
+			before: before,
+			after:  "<<<<<<< HEAD\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ChangedGoSymbols(tt.before, tt.after)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestWriteDiffMeta_ChangedSymbols(t *testing.T) {
+	symbols := make([]string, maxChangedSymbols+2)
+	for i := range symbols {
+		symbols[i] = "F"
+	}
+	symbols[0] = "Login"
+	symbols[1] = "(*Session).Refresh"
+
+	var sb strings.Builder
+	writeDiffMeta(&sb, &DiffMeta{FileCount: 1, ChangedSymbols: symbols}, nil)
+	if !strings.Contains(sb.String(), "- Changed symbols: Login, (*Session).Refresh, F, ") || !strings.Contains(sb.String(), "F and 2 more.") {
+		t.Errorf("expected the capped symbol list, got:\n%s", sb.String())
+	}
+}
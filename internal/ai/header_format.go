@@ -0,0 +1,315 @@
+package ai
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// DefaultHeaderFormat is the Conventional Commits header layout
+const DefaultHeaderFormat = "{{.Type}}({{.Scope}}): {{.Description}}"
+
+// Sentinels the header format is rendered with to find its placeholders
+const (
+	typeSentinel        = "\x00type\x00"
+	scopeSentinel       = "\x00scope\x00"
+	descriptionSentinel = "\x00description\x00"
+)
+
+// scopeBrackets pairs the brackets that may surround the scope. They are
+// dropped along with an empty scope, as in "feat: x" for "feat(): x".
+var scopeBrackets = map[byte]byte{'(': ')', '[': ']', '{': '}', '<': '>'}
+
+// Header is the first line of a commit message, split into its parts
+type Header struct {
+	Type  string
+	Scope string
+	// HasScope is set when the scope brackets are present, even if empty
+	HasScope bool
+	// Breaking is set by a "!" before the colon, e.g. "feat(api)!: x"
+	Breaking    bool
+	Description string
+}
+
+// HeaderFormat is a parsed header_format. It renders headers in the
+// configured layout and recognizes headers that follow it.
+type HeaderFormat struct {
+	format string
+	// parts are the literal texts around the placeholders: parts[i] comes
+	// before placeholders[i], and the last part after all of them
+	parts        []string
+	placeholders []string
+	// breakingAt is the index of the literal that gets a "!" in front of
+	// it, or -1 when the layout has no colon after the type
+	breakingAt int
+	// scopeAt is the index of the scope placeholder, or -1
+	scopeAt int
+	// bracketed is set when the scope has brackets of its own in parts
+	bracketed bool
+	// spaceBefore and spaceAfter are set when the space that separates the
+	// bracketed scope from the rest goes away with it, as in "[ui] fix: x"
+	// and "docs: x [readme]"
+	spaceBefore bool
+	spaceAfter  bool
+	pattern     *regexp.Regexp
+}
+
+// defaultHeaderFormat is the format used when none is configured
+var defaultHeaderFormat = mustParseHeaderFormat(DefaultHeaderFormat)
+
+func mustParseHeaderFormat(format string) *HeaderFormat {
+	f, err := ParseHeaderFormat(format)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+// ParseHeaderFormat parses a text/template header layout with the fields
+// {{.Type}}, {{.Scope}} and {{.Description}}, e.g. "[{{.Scope}}]
+// {{.Type}}: {{.Description}}". Type and Description must each appear once
+// and Scope at most once. An empty format is DefaultHeaderFormat.
+func ParseHeaderFormat(format string) (*HeaderFormat, error) {
+	if format == "" {
+		format = DefaultHeaderFormat
+	}
+	tmpl, err := template.New("header_format").Parse(format)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse header format: %w", err)
+	}
+	var sb strings.Builder
+	fields := struct{ Type, Scope, Description string }{typeSentinel, scopeSentinel, descriptionSentinel}
+	if err := tmpl.Execute(&sb, fields); err != nil {
+		return nil, fmt.Errorf("failed to render header format: %w", err)
+	}
+	rendered := sb.String()
+	if strings.Contains(rendered, "\n") {
+		return nil, fmt.Errorf("header format %q must be a single line", format)
+	}
+	for _, field := range []struct {
+		name     string
+		sentinel string
+		required bool
+	}{
+		{"{{.Type}}", typeSentinel, true},
+		{"{{.Scope}}", scopeSentinel, false},
+		{"{{.Description}}", descriptionSentinel, true},
+	} {
+		count := strings.Count(rendered, field.sentinel)
+		if count > 1 || (field.required && count == 0) {
+			return nil, fmt.Errorf("header format %q must contain %s exactly once", format, field.name)
+		}
+	}
+
+	f := &HeaderFormat{format: format, breakingAt: -1, scopeAt: -1}
+	rest := rendered
+	for {
+		i := strings.IndexByte(rest, 0)
+		if i < 0 {
+			f.parts = append(f.parts, rest)
+			break
+		}
+		end := strings.IndexByte(rest[i+1:], 0) + i + 2
+		f.parts = append(f.parts, rest[:i])
+		f.placeholders = append(f.placeholders, rest[i:end])
+		rest = rest[end:]
+	}
+
+	for i, placeholder := range f.placeholders {
+		if placeholder == scopeSentinel {
+			f.scopeAt = i
+			before, after := f.parts[i], f.parts[i+1]
+			if before != "" && after != "" {
+				close, ok := scopeBrackets[before[len(before)-1]]
+				f.bracketed = ok && after[0] == close
+			}
+		}
+	}
+	if f.bracketed {
+		before, after := f.literal(f.scopeAt, false), f.literal(f.scopeAt+1, false)
+		f.spaceAfter = strings.HasPrefix(after, " ") && (before == "" || strings.HasSuffix(before, " "))
+		f.spaceBefore = !f.spaceAfter && strings.HasSuffix(before, " ") && after == ""
+	}
+	// The "!" of a breaking change goes before the first colon after the
+	// type, once any scope brackets are closed
+	for i, placeholder := range f.placeholders {
+		if placeholder != typeSentinel {
+			continue
+		}
+		for j := i + 1; j < len(f.parts); j++ {
+			if strings.HasPrefix(f.literal(j, false), ":") {
+				f.breakingAt = j
+				break
+			}
+			if j < len(f.placeholders) && f.placeholders[j] != scopeSentinel {
+				break
+			}
+		}
+	}
+
+	f.pattern, err = regexp.Compile(f.expression())
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile header format %q: %w", format, err)
+	}
+	return f, nil
+}
+
+// literal returns parts[i] without the scope brackets. With group set the
+// brackets are kept, as when the scope is rendered.
+func (f *HeaderFormat) literal(i int, group bool) string {
+	part := f.parts[i]
+	if !f.bracketed || group {
+		return part
+	}
+	if i == f.scopeAt {
+		part = part[:len(part)-1]
+	}
+	if i == f.scopeAt+1 {
+		part = part[1:]
+	}
+	return part
+}
+
+// expression returns the regular expression matching headers in the layout
+func (f *HeaderFormat) expression() string {
+	var sb strings.Builder
+	sb.WriteString("^")
+	for i := range f.parts {
+		if i == f.breakingAt {
+			sb.WriteString("(?P<breaking>!)?")
+		}
+		part := f.literal(i, false)
+		if f.spaceBefore && i == f.scopeAt {
+			part = strings.TrimSuffix(part, " ")
+		}
+		if f.spaceAfter && i == f.scopeAt+1 {
+			part = strings.TrimPrefix(part, " ")
+		}
+		sb.WriteString(regexp.QuoteMeta(part))
+		if i == len(f.placeholders) {
+			break
+		}
+		switch f.placeholders[i] {
+		case typeSentinel:
+			sb.WriteString("(?P<type>[A-Za-z]+)")
+		case descriptionSentinel:
+			sb.WriteString("(?P<description>.*?)")
+		case scopeSentinel:
+			if f.bracketed {
+				open, close := string(f.parts[i][len(f.parts[i])-1]), string(f.parts[i+1][0])
+				if f.spaceBefore {
+					open = " " + open
+				}
+				if f.spaceAfter {
+					close += " "
+				}
+				sb.WriteString(fmt.Sprintf("(?P<scoped>%s(?P<scope>[^%s]*)%s)?",
+					regexp.QuoteMeta(open), regexp.QuoteMeta(string(f.parts[i][len(f.parts[i])-1])+string(f.parts[i+1][0])), regexp.QuoteMeta(close)))
+			} else {
+				sb.WriteString(`(?P<scope>[^\s:]*)`)
+			}
+		}
+	}
+	sb.WriteString("$")
+	return sb.String()
+}
+
+// IsDefault reports whether f is the Conventional Commits layout
+func (f *HeaderFormat) IsDefault() bool {
+	return f == nil || f.format == DefaultHeaderFormat
+}
+
+// Parse splits header into its parts, reporting whether it follows the
+// layout. A nil format is DefaultHeaderFormat.
+func (f *HeaderFormat) Parse(header string) (Header, bool) {
+	if f == nil {
+		f = defaultHeaderFormat
+	}
+	match := f.pattern.FindStringSubmatch(header)
+	if match == nil {
+		return Header{}, false
+	}
+	group := func(name string) string {
+		if i := f.pattern.SubexpIndex(name); i >= 0 {
+			return match[i]
+		}
+		return ""
+	}
+	h := Header{
+		Type:        group("type"),
+		Scope:       group("scope"),
+		Breaking:    group("breaking") != "",
+		Description: group("description"),
+	}
+	h.HasScope = h.Scope != "" || group("scoped") != ""
+	return h, true
+}
+
+// Render lays h out in the format. An empty scope is left out together
+// with its brackets.
+func (f *HeaderFormat) Render(h Header) string {
+	if f == nil {
+		f = defaultHeaderFormat
+	}
+	var sb strings.Builder
+	for i := range f.parts {
+		part := f.literal(i, h.Scope != "")
+		if h.Scope == "" && i == f.scopeAt+1 && strings.HasPrefix(part, " ") && strings.HasSuffix(sb.String(), " ") {
+			// Dropping "[scope] " must not leave two spaces behind
+			part = part[1:]
+		}
+		if i == f.breakingAt && h.Breaking {
+			if f.bracketed && i == f.scopeAt+1 && h.Scope != "" {
+				// After the closing bracket: "feat(api)!: x"
+				part = part[:1] + "!" + part[1:]
+			} else {
+				part = "!" + part
+			}
+		}
+		sb.WriteString(part)
+		if i == len(f.placeholders) {
+			break
+		}
+		switch f.placeholders[i] {
+		case typeSentinel:
+			sb.WriteString(h.Type)
+		case scopeSentinel:
+			sb.WriteString(h.Scope)
+		case descriptionSentinel:
+			sb.WriteString(h.Description)
+		}
+	}
+	header := strings.TrimSpace(sb.String())
+	if h.Breaking && f.breakingAt < 0 {
+		header = strings.Replace(header, h.Type, h.Type+"!", 1)
+	}
+	return header
+}
+
+// Layout returns the format with <type>, <scope> and <description> in
+// place of the fields, as shown to the model
+func (f *HeaderFormat) Layout() string {
+	return f.Render(Header{Type: "<type>", Scope: "<scope>", Description: "<description>"})
+}
+
+// Reformat rewrites the header of message into the format when the model
+// wrote a Conventional Commits header instead. Messages already in the
+// format, and anything that is not a commit header, are left alone.
+func (f *HeaderFormat) Reformat(message string) string {
+	if f.IsDefault() {
+		return message
+	}
+	header, rest, hasBody := strings.Cut(message, "\n")
+	if _, ok := f.Parse(header); ok {
+		return message
+	}
+	h, ok := defaultHeaderFormat.Parse(header)
+	if !ok {
+		return message
+	}
+	if !hasBody {
+		return f.Render(h)
+	}
+	return f.Render(h) + "\n" + rest
+}
@@ -0,0 +1,147 @@
+package ai
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseHeaderFormat_Errors(t *testing.T) {
+	tests := []struct {
+		name          string
+		format        string
+		expectedError string
+	}{
+		{"No type", "{{.Scope}}: {{.Description}}", "{{.Type}} exactly once"},
+		{"No description", "{{.Type}}({{.Scope}})", "{{.Description}} exactly once"},
+		{"Scope twice", "{{.Scope}} {{.Type}}({{.Scope}}): {{.Description}}", "{{.Scope}} exactly once"},
+		{"Unknown field", "{{.Kind}}: {{.Description}}", "failed to render"},
+		{"Bad template", "{{.Type}: {{.Description}}", "failed to parse"},
+		{"Two lines", "{{.Type}}:\n{{.Description}}", "single line"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseHeaderFormat(tt.format)
+			if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+				t.Errorf("expected error containing %q, got %v", tt.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestHeaderFormat_Render(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		header   Header
+		expected string
+	}{
+		{"Default", "", Header{Type: "feat", Scope: "auth", Description: "added login"}, "feat(auth): added login"},
+		{"Default without scope", "", Header{Type: "feat", Description: "added login"}, "feat: added login"},
+		{"Default breaking", "", Header{Type: "feat", Scope: "api", Breaking: true, Description: "dropped v1"}, "feat(api)!: dropped v1"},
+		{"Default breaking without scope", "", Header{Type: "feat", Breaking: true, Description: "dropped v1"}, "feat!: dropped v1"},
+		{"Scope first", "[{{.Scope}}] {{.Type}}: {{.Description}}", Header{Type: "fix", Scope: "ui", Description: "fixed layout"}, "[ui] fix: fixed layout"},
+		{"Scope first without scope", "[{{.Scope}}] {{.Type}}: {{.Description}}", Header{Type: "fix", Description: "fixed layout"}, "fix: fixed layout"},
+		{"Scope first breaking", "[{{.Scope}}] {{.Type}}: {{.Description}}", Header{Type: "fix", Scope: "ui", Breaking: true, Description: "fixed layout"}, "[ui] fix!: fixed layout"},
+		{"Scope last", "{{.Type}}: {{.Description}} [{{.Scope}}]", Header{Type: "docs", Scope: "readme", Description: "documented flags"}, "docs: documented flags [readme]"},
+		{"Scope last without scope", "{{.Type}}: {{.Description}} [{{.Scope}}]", Header{Type: "docs", Description: "documented flags"}, "docs: documented flags"},
+		{"No scope in layout", "{{.Type}} - {{.Description}}", Header{Type: "chore", Scope: "deps", Breaking: true, Description: "bumped go"}, "chore! - bumped go"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			format, err := ParseHeaderFormat(tt.format)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := format.Render(tt.header); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestHeaderFormat_Parse(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		header   string
+		expected Header
+		ok       bool
+	}{
+		{"Default", "", "feat(auth): added login", Header{Type: "feat", Scope: "auth", HasScope: true, Description: "added login"}, true},
+		{"Default breaking", "", "feat(api)!: dropped v1", Header{Type: "feat", Scope: "api", HasScope: true, Breaking: true, Description: "dropped v1"}, true},
+		{"Default empty scope", "", "feat(): added login", Header{Type: "feat", HasScope: true, Description: "added login"}, true},
+		{"Default empty subject", "", "feat: ", Header{Type: "feat"}, true},
+		{"Default rejects other layouts", "", "[ui] fix: fixed layout", Header{}, false},
+		{"Scope first", "[{{.Scope}}] {{.Type}}: {{.Description}}", "[ui] fix!: fixed layout", Header{Type: "fix", Scope: "ui", HasScope: true, Breaking: true, Description: "fixed layout"}, true},
+		{"Scope first without scope", "[{{.Scope}}] {{.Type}}: {{.Description}}", "fix: fixed layout", Header{Type: "fix", Description: "fixed layout"}, true},
+		{"Scope first rejects default", "[{{.Scope}}] {{.Type}}: {{.Description}}", "fix(ui): fixed layout", Header{}, false},
+		{"Scope last", "{{.Type}}: {{.Description}} [{{.Scope}}]", "docs: documented flags [readme]", Header{Type: "docs", Scope: "readme", HasScope: true, Description: "documented flags"}, true},
+		{"Scope last without scope", "{{.Type}}: {{.Description}} [{{.Scope}}]", "docs: documented flags", Header{Type: "docs", Description: "documented flags"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			format, err := ParseHeaderFormat(tt.format)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got, ok := format.Parse(tt.header)
+			if ok != tt.ok || got != tt.expected {
+				t.Errorf("expected %+v (%v), got %+v (%v)", tt.expected, tt.ok, got, ok)
+			}
+		})
+	}
+}
+
+func TestHeaderFormat_Reformat(t *testing.T) {
+	scopeFirst, err := ParseHeaderFormat("[{{.Scope}}] {{.Type}}: {{.Description}}")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		format   *HeaderFormat
+		message  string
+		expected string
+	}{
+		{"Default leaves messages alone", nil, "feat(auth): added login", "feat(auth): added login"},
+		{"Conventional header is reformatted", scopeFirst, "feat(auth): added login\n\nBody text.", "[auth] feat: added login\n\nBody text."},
+		{"Breaking header is reformatted", scopeFirst, "feat(api)!: dropped v1", "[api] feat!: dropped v1"},
+		{"Matching header is kept", scopeFirst, "[auth] feat: added login", "[auth] feat: added login"},
+		{"Free text is kept", scopeFirst, "Added a login form", "Added a login form"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.format.Reformat(tt.message); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestHeaderFormat_Layout(t *testing.T) {
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{"", "<type>(<scope>): <description>"},
+		{"[{{.Scope}}] {{.Type}}: {{.Description}}", "[<scope>] <type>: <description>"},
+		{"{{.Type}}: {{.Description}} [{{.Scope}}]", "<type>: <description> [<scope>]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			format, err := ParseHeaderFormat(tt.format)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := format.Layout(); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package ai
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// ParseKeepAlive converts a keep_alive setting into the value Ollama
+// expects: a whole number of seconds as a number (-1 keeps the model loaded
+// until the server stops, 0 unloads it right away) or a duration such as
+// "5m" as a string. Empty means the server's default and returns nil.
+func ParseKeepAlive(value string) (interface{}, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return seconds, nil
+	}
+	if _, err := time.ParseDuration(value); err != nil {
+		return nil, fmt.Errorf("%q is not a number of seconds (-1 for always) or a duration like 5m", value)
+	}
+	return value, nil
+}
+
+// WithKeepAlive asks Ollama to keep the model loaded for keepAlive after
+// each request, so the next commit does not wait for it to load again. See
+// ParseKeepAlive for the values; invalid ones are left out.
+func WithKeepAlive(keepAlive string) Option {
+	return func(c *OllamaClient) {
+		c.keepAlive, _ = ParseKeepAlive(keepAlive)
+	}
+}
@@ -0,0 +1,86 @@
+package ai
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseKeepAlive(t *testing.T) {
+	tests := []struct {
+		name        string
+		value       string
+		expected    interface{}
+		expectError string
+	}{
+		{name: "Empty", value: "", expected: nil},
+		{name: "Forever", value: "-1", expected: -1},
+		{name: "Seconds", value: "300", expected: 300},
+		{name: "Duration", value: "5m", expected: "5m"},
+		{name: "Compound duration", value: "1h30m", expected: "1h30m"},
+		{name: "Invalid", value: "forever", expectError: "not a number of seconds"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseKeepAlive(tt.value)
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Errorf("expected error containing %q, got %v", tt.expectError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("expected %#v, got %#v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestOllamaClient_KeepAlive(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []Option
+		expected string // the raw JSON of keep_alive; "" means left out
+	}{
+		{name: "Omitted by default", expected: ""},
+		{name: "Duration is sent as a string", opts: []Option{WithKeepAlive("5m")}, expected: `"5m"`},
+		{name: "Seconds are sent as a number", opts: []Option{WithKeepAlive("-1")}, expected: `-1`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body map[string]json.RawMessage
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					t.Errorf("failed to decode request body: %v", err)
+				}
+				w.Write([]byte(`{"response": "feat: added login", "done": true}`))
+			}))
+			defer server.Close()
+
+			client := NewClient("test-api-key", server.URL+"/api/generate", "", time.Second, tt.opts...)
+			if _, err := client.GenerateCommitMessage(CommitRequest{Diff: "diff"}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			keepAlive, ok := body["keep_alive"]
+			if tt.expected == "" {
+				if ok {
+					t.Errorf("expected no keep_alive, got %s", keepAlive)
+				}
+				return
+			}
+			if string(keepAlive) != tt.expected {
+				t.Errorf("expected keep_alive %s, got %s", tt.expected, keepAlive)
+			}
+		})
+	}
+}
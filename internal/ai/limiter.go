@@ -0,0 +1,34 @@
+package ai
+
+import (
+	"fmt"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// WithRequestsPerMinute spaces the client's API calls, retries included,
+// at least a minute / requestsPerMinute apart, so batches and
+// regenerations do not burst on a shared endpoint. Zero or less means no
+// limit.
+func WithRequestsPerMinute(requestsPerMinute int) Option {
+	return func(c *OllamaClient) {
+		if requestsPerMinute <= 0 {
+			c.limiter = nil
+			return
+		}
+		c.limiter = rate.NewLimiter(rate.Every(time.Minute/time.Duration(requestsPerMinute)), 1)
+	}
+}
+
+// waitForLimit blocks until the rate limiter allows another call, or the
+// client's context is done
+func (c *OllamaClient) waitForLimit() error {
+	if c.limiter == nil {
+		return nil
+	}
+	if err := c.limiter.Wait(c.requestContext()); err != nil {
+		return fmt.Errorf("waiting for the rate limit: %w", err)
+	}
+	return nil
+}
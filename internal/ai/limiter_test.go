@@ -0,0 +1,69 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestOllamaClient_RequestsPerMinute(t *testing.T) {
+	var mu sync.Mutex
+	var arrivals []time.Time
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		arrivals = append(arrivals, time.Now())
+		mu.Unlock()
+		w.Write([]byte(`{"response":"feat: add login","done":true}`))
+	}))
+	defer server.Close()
+
+	// 600 a minute spaces the calls 100ms apart
+	client := NewClient("", server.URL, "", 0, WithRequestsPerMinute(600))
+	for _, diff := range []string{"a", "b"} {
+		if _, err := client.GenerateCommitMessage(CommitRequest{Diff: diff}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if len(arrivals) != 2 {
+		t.Fatalf("expected two calls, got %d", len(arrivals))
+	}
+	if gap := arrivals[1].Sub(arrivals[0]); gap < 90*time.Millisecond {
+		t.Errorf("expected the calls at least 100ms apart, got %v", gap)
+	}
+}
+
+func TestOllamaClient_RequestsPerMinute_Unlimited(t *testing.T) {
+	calls := 0
+	client := NewClient("", "", "", 0, WithRequestsPerMinute(0), WithComplete(func(string, string, string) (string, error) {
+		calls++
+		return "feat: add login", nil
+	}))
+	start := time.Now()
+	for _, diff := range []string{"a", "b", "c"} {
+		if _, err := client.GenerateCommitMessage(CommitRequest{Diff: diff}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); calls != 3 || elapsed > 50*time.Millisecond {
+		t.Errorf("expected three calls without waiting, got %d in %v", calls, elapsed)
+	}
+}
+
+func TestOllamaClient_RequestsPerMinute_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	client := NewClient("", "", "", 0, WithRequestsPerMinute(1), WithContext(ctx), WithComplete(func(string, string, string) (string, error) {
+		cancel()
+		return "feat: add login", nil
+	}))
+	if _, err := client.GenerateCommitMessage(CommitRequest{Diff: "a"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The next call would wait a minute; the cancelled context ends it
+	if _, err := client.GenerateCommitMessage(CommitRequest{Diff: "b"}); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the wait to be cancelled, got %v", err)
+	}
+}
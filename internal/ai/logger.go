@@ -0,0 +1,24 @@
+package ai
+
+import (
+	"log/slog"
+
+	"ai-commit-message-generator/internal/logging"
+)
+
+// WithLogger logs each API response, with its status and duration, and the
+// retries to logger. Without it the retries are printed to stderr as
+// warnings.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *OllamaClient) {
+		c.logger = logger
+	}
+}
+
+// log returns the logger set with WithLogger, or the default one
+func (c *OllamaClient) log() *slog.Logger {
+	if c.logger == nil {
+		return logging.Default()
+	}
+	return c.logger
+}
@@ -0,0 +1,44 @@
+package ai
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// errModelLoading is returned by decodeResponse when Ollama answers 200 OK
+// before the model has loaded: an empty response with done_reason "load",
+// or an error saying it is loading
+var errModelLoading = errors.New("the model is loading")
+
+// isLoadingChunk reports whether chunk says the model is still loading
+// rather than carrying any of the generated text
+func isLoadingChunk(chunk ollamaResponse) bool {
+	if chunk.Error != "" {
+		return strings.Contains(strings.ToLower(chunk.Error), "loading")
+	}
+	return chunk.Done && chunk.DoneReason == "load" && chunk.Response == ""
+}
+
+// ModelLoadingError is returned when the model is still loading, or the
+// server still answers 503 Service Unavailable, after every retry
+type ModelLoadingError struct {
+	// Attempts counts the requests sent, the first one included
+	Attempts int
+	// Waited is the total time spent waiting between them
+	Waited time.Duration
+	// Body is the last response body, shown only in verbose mode
+	Body    string
+	Verbose bool
+}
+
+func (e *ModelLoadingError) Error() string {
+	msg := fmt.Sprintf("the model is still loading: gave up after %d attempts and %v of waiting. "+
+		"Large models can take minutes to load; try again shortly, or keep the model loaded with keep_alive",
+		e.Attempts, e.Waited)
+	if !e.Verbose || e.Body == "" {
+		return msg
+	}
+	return msg + "\nLast response: " + strings.TrimSpace(e.Body)
+}
@@ -0,0 +1,91 @@
+package ai
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOllamaClient_ModelLoading(t *testing.T) {
+	loaded := `{"model": "llama3", "response": "feat: add login", "done": true, "done_reason": "stop"}`
+	tests := []struct {
+		name      string
+		stream    bool
+		responses []string
+		statuses  []int
+	}{
+		{
+			name:      "Loaded with an empty response first",
+			responses: []string{`{"model": "llama3", "response": "", "done": true, "done_reason": "load"}`, loaded},
+		},
+		{
+			name:      "Service unavailable while loading",
+			responses: []string{`{"error": "model is loading"}`, `{"error": "model is loading"}`, loaded},
+			statuses:  []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable},
+		},
+		{
+			name:      "Loading error with 200 OK",
+			responses: []string{`{"error": "model 'llama3' is still loading"}`, loaded},
+		},
+		{
+			name:      "Streamed",
+			stream:    true,
+			responses: []string{`{"response": "", "done": true, "done_reason": "load"}`, `{"response": "feat: ", "done": false}` + "\n" + `{"response": "add login", "done": true}`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if calls < len(tt.statuses) {
+					w.WriteHeader(tt.statuses[calls])
+				}
+				w.Write([]byte(tt.responses[calls] + "\n"))
+				calls++
+			}))
+			defer server.Close()
+
+			client := NewClient("test-api-key", server.URL+"/api/generate", "", time.Second, WithStream(tt.stream)).(*OllamaClient)
+			client.retryDelay = time.Millisecond
+
+			message, err := client.GenerateCommitMessage(CommitRequest{Diff: "diff"})
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if message != "feat: add login" {
+				t.Errorf("expected the message from the loaded model, got %q", message)
+			}
+			if calls != len(tt.responses) {
+				t.Errorf("expected %d calls, got %d", len(tt.responses), calls)
+			}
+		})
+	}
+}
+
+func TestOllamaClient_ModelLoadingExhausted(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte(`{"response": "", "done": true, "done_reason": "load"}` + "\n"))
+	}))
+	defer server.Close()
+
+	client := NewClient("test-api-key", server.URL+"/api/generate", "", time.Second).(*OllamaClient)
+	client.retryDelay = time.Millisecond
+
+	_, err := client.GenerateCommitMessage(CommitRequest{Diff: "diff"})
+	var loading *ModelLoadingError
+	if !errors.As(err, &loading) {
+		t.Fatalf("expected a ModelLoadingError, got %v", err)
+	}
+	if calls != maxRetries+1 || loading.Attempts != calls {
+		t.Errorf("expected %d attempts, got %d calls and %d reported", maxRetries+1, calls, loading.Attempts)
+	}
+	if !strings.Contains(err.Error(), "gave up after 4 attempts and 7ms of waiting") {
+		t.Errorf("unexpected error %q", err.Error())
+	}
+}
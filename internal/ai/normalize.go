@@ -0,0 +1,54 @@
+package ai
+
+import (
+	"strings"
+)
+
+// normalizeMessage cleans up formatting models add around commit messages:
+// a markdown code fence wrapping the whole response, trailing whitespace,
+// runs of blank lines, and a missing blank line between subject and body
+func normalizeMessage(message string) string {
+	message = strings.ReplaceAll(message, "\r\n", "\n")
+	lines := strings.Split(strings.TrimSpace(message), "\n")
+
+	// Surrounding fence: ``` or ```text on the first line, ``` on the last.
+	// A one-line response may be wrapped inline: ```feat: add x```
+	if len(lines) == 1 {
+		lines[0] = strings.TrimSpace(strings.Trim(lines[0], "`"))
+	}
+	if len(lines) > 1 && strings.HasPrefix(strings.TrimSpace(lines[0]), "```") {
+		lines = lines[1:]
+	}
+	// A closing fence is stray only if it has no opening fence in the body
+	if n := len(lines); n > 0 && strings.TrimSpace(lines[n-1]) == "```" && countFences(lines)%2 == 1 {
+		lines = lines[:n-1]
+	}
+
+	var out []string
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t")
+		if line == "" {
+			// Drop leading blank lines and collapse runs
+			if len(out) == 0 || out[len(out)-1] == "" {
+				continue
+			}
+		} else if len(out) == 1 {
+			// The body is separated from the subject by one blank line
+			out = append(out, "")
+		}
+		out = append(out, line)
+	}
+
+	return strings.TrimSpace(strings.Join(out, "\n"))
+}
+
+// countFences counts the lines that open or close a code block
+func countFences(lines []string) int {
+	n := 0
+	for _, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,80 @@
+package ai
+
+import "testing"
+
+func TestNormalizeMessage(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "Already clean",
+			input:    "feat(auth): add login\n\nAdds the login endpoint.",
+			expected: "feat(auth): add login\n\nAdds the login endpoint.",
+		},
+		{
+			name:     "Surrounding whitespace",
+			input:    "\n\n  feat: add login  \n\n\n",
+			expected: "feat: add login",
+		},
+		{
+			name:     "Fenced message",
+			input:    "```\nfeat: add login\n\nAdds the login endpoint.\n```",
+			expected: "feat: add login\n\nAdds the login endpoint.",
+		},
+		{
+			name:     "Fence with language tag",
+			input:    "```text\nfix: handle nil config\n```\n",
+			expected: "fix: handle nil config",
+		},
+		{
+			name:     "Inline fence",
+			input:    "```fix: handle nil config```",
+			expected: "fix: handle nil config",
+		},
+		{
+			name:     "Unclosed fence",
+			input:    "```\nfix: handle nil config",
+			expected: "fix: handle nil config",
+		},
+		{
+			name:     "Fences inside the body are kept",
+			input:    "docs: show usage\n\nRun:\n```\ngenerate-commit -i\n```",
+			expected: "docs: show usage\n\nRun:\n```\ngenerate-commit -i\n```",
+		},
+		{
+			name:     "Fenced message with a code block in the body",
+			input:    "```\ndocs: show usage\n\n```\ngenerate-commit -i\n```\n```",
+			expected: "docs: show usage\n\n```\ngenerate-commit -i\n```",
+		},
+		{
+			name:     "Blank lines collapsed",
+			input:    "feat: add login\n\n\n\n- add handler\n\n\n- add tests",
+			expected: "feat: add login\n\n- add handler\n\n- add tests",
+		},
+		{
+			name:     "Missing blank line after subject",
+			input:    "feat: add login\n- add handler\n- add tests",
+			expected: "feat: add login\n\n- add handler\n- add tests",
+		},
+		{
+			name:     "Trailing whitespace and CRLF",
+			input:    "feat: add login \r\n\r\n- add handler\t\r\n",
+			expected: "feat: add login\n\n- add handler",
+		},
+		{
+			name:     "Only a fence",
+			input:    "```\n```",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeMessage(tt.input); got != tt.expected {
+				t.Errorf("normalizeMessage(%q)\ngot:  %q\nwant: %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
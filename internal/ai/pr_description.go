@@ -0,0 +1,62 @@
+package ai
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PRRequest holds what a pull request description is written from
+type PRRequest struct {
+	// Diff is the combined diff of the branch since it left Base
+	Diff string
+	// Base is the branch the pull request targets
+	Base string
+	// Commits holds the subjects of the branch's commits, oldest first
+	Commits []string
+}
+
+// prIntro opens the pull request prompt unless a system prompt replaces it
+const prIntro = "You are an expert software engineer writing pull request descriptions for code review."
+
+// GeneratePRDescription asks the model for a pull request title and a
+// markdown body with Summary, Changes, Breaking Changes and Testing sections
+func (c *OllamaClient) GeneratePRDescription(req PRRequest) (string, error) {
+	c.phase(PhaseBuildingPrompt)
+	response, err := c.generate(c.buildPRPrompt(req))
+	if err != nil {
+		return "", err
+	}
+
+	c.phase(PhasePostProcessing)
+	description := normalizeMessage(response)
+	if description == "" {
+		return "", fmt.Errorf("empty response from model")
+	}
+	return description, nil
+}
+
+func (c *OllamaClient) buildPRPrompt(req PRRequest) string {
+	var sb strings.Builder
+	if c.systemPrompt == "" || c.systemPromptMode == SystemPromptPrepend {
+		sb.WriteString(prIntro + "\n\n")
+	}
+
+	sb.WriteString(fmt.Sprintf("Write a pull request description for the following diff of a branch against %s.\n\n", req.Base))
+	sb.WriteString("Output markdown in exactly this format:\n\n")
+	sb.WriteString("# <concise title in the imperative mood, at most 72 characters>\n\n")
+	sb.WriteString("## Summary\n<one or two sentences on what the change does and why>\n\n")
+	sb.WriteString("## Changes\n- <one bullet per notable change, grouped by area>\n\n")
+	sb.WriteString("## Breaking Changes\n- <one bullet per change that breaks existing users, or \"None.\">\n\n")
+	sb.WriteString("## Testing\n- <how the change was or should be tested, based on the tests in the diff>\n\n")
+	sb.WriteString("Describe only what the diff shows. Do not wrap the output in a code block and do not output anything else.\n\n")
+	if len(req.Commits) > 0 {
+		sb.WriteString("Commits on the branch:\n")
+		for _, subject := range req.Commits {
+			sb.WriteString("- " + subject + "\n")
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("Diff:\n")
+	sb.WriteString(req.Diff)
+	return sb.String()
+}
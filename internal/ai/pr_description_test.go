@@ -0,0 +1,72 @@
+package ai
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOllamaClient_GeneratePRDescription(t *testing.T) {
+	tests := []struct {
+		name          string
+		mockResponse  string
+		expected      string
+		expectedError string
+	}{
+		{
+			name:         "Markdown is returned",
+			mockResponse: `{"response": "# Add login\n\n## Summary\nAdds a login handler.\n\n## Changes\n- add handler\n\n## Testing\n- unit tests", "done": true}`,
+			expected:     "# Add login\n\n## Summary\nAdds a login handler.\n\n## Changes\n- add handler\n\n## Testing\n- unit tests",
+		},
+		{
+			name:         "Code block wrapper is removed",
+			mockResponse: `{"response": "` + "```markdown" + `\n# Add login\n\n## Summary\nAdds a login handler.\n` + "```" + `", "done": true}`,
+			expected:     "# Add login\n\n## Summary\nAdds a login handler.",
+		},
+		{
+			name:          "Empty response",
+			mockResponse:  `{"response": "  ", "done": true}`,
+			expectedError: "empty response from model",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body ollamaRequest
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					t.Errorf("failed to decode request body: %v", err)
+				}
+				w.Write([]byte(tt.mockResponse))
+			}))
+			defer server.Close()
+
+			client := NewClient("test-api-key", server.URL+"/api/generate", "", time.Second)
+			description, err := client.GeneratePRDescription(PRRequest{Diff: "diff --git a/login.go b/login.go", Base: "main", Commits: []string{"feat: add login handler", "test: cover login"}})
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("expected error containing %q, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GeneratePRDescription failed: %v", err)
+			}
+			if description != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, description)
+			}
+
+			for _, want := range []string{prIntro, "against main", "## Summary", "## Changes", "## Breaking Changes", "## Testing", "Commits on the branch:\n- feat: add login handler\n- test: cover login\n", "diff --git a/login.go"} {
+				if !strings.Contains(body.Prompt, want) {
+					t.Errorf("expected prompt to contain %q, got:\n%s", want, body.Prompt)
+				}
+			}
+			if strings.Contains(body.Prompt, "Conventional Commits") {
+				t.Errorf("expected the pull request prompt, got the commit prompt:\n%s", body.Prompt)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package ai
+
+// Generation phases reported to a Progress
+const (
+	PhaseBuildingPrompt = "building prompt"
+	PhaseWaiting        = "waiting for model"
+	PhasePostProcessing = "post-processing"
+)
+
+// Progress receives updates while a message is generated
+type Progress interface {
+	// Phase reports that generation moved on to the named phase
+	Phase(name string)
+	// Tokens reports how many tokens have been streamed so far
+	Tokens(count int)
+}
+
+// WithProgress reports the generation phases, and the tokens received when
+// streaming, to p
+func WithProgress(p Progress) Option {
+	return func(c *OllamaClient) {
+		c.progress = p
+	}
+}
+
+// WithStream asks the server to stream the response so progress can count
+// tokens as they arrive
+func WithStream(stream bool) Option {
+	return func(c *OllamaClient) {
+		c.stream = stream
+	}
+}
+
+// phase reports name to the progress, if any
+func (c *OllamaClient) phase(name string) {
+	if c.progress != nil {
+		c.progress.Phase(name)
+	}
+}
+
+// tokens reports count to the progress, if any
+func (c *OllamaClient) tokens(count int) {
+	if c.progress != nil {
+		c.progress.Tokens(count)
+	}
+}
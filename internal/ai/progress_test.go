@@ -0,0 +1,74 @@
+package ai
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// recordingProgress records every update it receives
+type recordingProgress struct {
+	phases []string
+	tokens []int
+}
+
+func (r *recordingProgress) Phase(name string) { r.phases = append(r.phases, name) }
+func (r *recordingProgress) Tokens(count int)  { r.tokens = append(r.tokens, count) }
+
+func TestOllamaClient_Progress(t *testing.T) {
+	tests := []struct {
+		name           string
+		stream         bool
+		expectedTokens []int
+	}{
+		{
+			name: "Phases without streaming",
+		},
+		{
+			name:           "Streaming counts tokens",
+			stream:         true,
+			expectedTokens: []int{1, 2, 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body ollamaRequest
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					t.Errorf("failed to decode request body: %v", err)
+				}
+				w.Write([]byte(`{"response": "feat", "done": false}
+{"response": ": added", "done": false}
+{"response": " login", "done": true}
+`))
+			}))
+			defer server.Close()
+
+			progress := &recordingProgress{}
+			client := NewClient("test-api-key", server.URL+"/api/generate", "", time.Second,
+				WithProgress(progress), WithStream(tt.stream))
+			message, err := client.GenerateCommitMessage(CommitRequest{Diff: "diff"})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if message != "feat: added login" {
+				t.Errorf("expected the chunks to be joined, got %q", message)
+			}
+			if body.Stream != tt.stream {
+				t.Errorf("expected stream %v, got %v", tt.stream, body.Stream)
+			}
+			expectedPhases := []string{PhaseBuildingPrompt, PhaseWaiting, PhasePostProcessing}
+			if !reflect.DeepEqual(progress.phases, expectedPhases) {
+				t.Errorf("expected phases %q, got %q", expectedPhases, progress.phases)
+			}
+			if !reflect.DeepEqual(progress.tokens, tt.expectedTokens) {
+				t.Errorf("expected tokens %v, got %v", tt.expectedTokens, progress.tokens)
+			}
+		})
+	}
+}
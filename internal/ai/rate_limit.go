@@ -0,0 +1,44 @@
+package ai
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// maxRetries is how often a rate limited request is retried
+const maxRetries = 3
+
+// defaultRetryDelay is the wait before the first retry; it doubles on each
+// one after that
+const defaultRetryDelay = 2 * time.Second
+
+// RateLimitError is returned when the API still rate limits a request
+// after every retry
+type RateLimitError struct {
+	// Attempts counts the requests sent, the first one included
+	Attempts int
+	// Waited is the total time spent waiting between them
+	Waited time.Duration
+	// Body is the last response body, shown only in verbose mode
+	Body    string
+	Verbose bool
+}
+
+func (e *RateLimitError) Error() string {
+	msg := fmt.Sprintf("the API is rate limiting requests: gave up after %d attempts and %v of waiting. "+
+		"Wait a minute and try again, or use a less busy model (generate-commit config set model <name>)",
+		e.Attempts, e.Waited)
+	if !e.Verbose {
+		return msg + "; run with --verbose to see the server's response"
+	}
+	return msg + "\nLast response: " + strings.TrimSpace(e.Body)
+}
+
+// WithVerbose includes raw API responses in the errors that leave them out
+// by default, such as RateLimitError
+func WithVerbose(verbose bool) Option {
+	return func(c *OllamaClient) {
+		c.verbose = verbose
+	}
+}
@@ -0,0 +1,68 @@
+package ai
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOllamaClient_RateLimitExhausted(t *testing.T) {
+	tests := []struct {
+		name       string
+		verbose    bool
+		expected   []string
+		unexpected []string
+	}{
+		{
+			name:       "Body left out",
+			expected:   []string{"gave up after 4 attempts and 7ms of waiting", "Wait a minute and try again", "run with --verbose"},
+			unexpected: []string{"slow down"},
+		},
+		{
+			name:       "Body in verbose mode",
+			verbose:    true,
+			expected:   []string{"gave up after 4 attempts", "\nLast response: {\"error\": \"slow down\"}"},
+			unexpected: []string{"run with --verbose"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				w.WriteHeader(http.StatusTooManyRequests)
+				w.Write([]byte(`{"error": "slow down"}` + "\n"))
+			}))
+			defer server.Close()
+
+			client := NewClient("test-api-key", server.URL+"/api/generate", "", time.Second, WithVerbose(tt.verbose)).(*OllamaClient)
+			client.retryDelay = time.Millisecond
+
+			_, err := client.GenerateCommitMessage(CommitRequest{Diff: "diff"})
+			var rateLimit *RateLimitError
+			if !errors.As(err, &rateLimit) {
+				t.Fatalf("expected a RateLimitError, got %v", err)
+			}
+			if calls != maxRetries+1 || rateLimit.Attempts != calls {
+				t.Errorf("expected %d attempts, got %d calls and %d reported", maxRetries+1, calls, rateLimit.Attempts)
+			}
+			if rateLimit.Waited != 7*time.Millisecond {
+				t.Errorf("expected 7ms of waiting, got %v", rateLimit.Waited)
+			}
+			for _, want := range tt.expected {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("expected error to contain %q, got %q", want, err.Error())
+				}
+			}
+			for _, unwanted := range tt.unexpected {
+				if strings.Contains(err.Error(), unwanted) {
+					t.Errorf("expected error not to contain %q, got %q", unwanted, err.Error())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package ai
+
+import "strings"
+
+// RationaleSeparator is the line between the message and the rationale the
+// model writes when CommitRequest.Explain is set. Everything after it is
+// for the user only and must never be committed.
+const RationaleSeparator = "---RATIONALE---"
+
+// writeRationaleRequest asks for a short rationale after the message
+func writeRationaleRequest(sb *strings.Builder) {
+	sb.WriteString("After the message, output a line containing only " + RationaleSeparator + " and then two or three sentences explaining why you chose this type and scope (or why the changes should be split). The rationale is shown to the user and is not part of the commit message.\n\n")
+}
+
+// SplitRationale splits a response into the message before
+// RationaleSeparator and the rationale after it. Without a separator the
+// whole response is the message.
+func SplitRationale(response string) (string, string) {
+	message, rationale, found := strings.Cut(response, RationaleSeparator)
+	if !found {
+		return strings.TrimSpace(response), ""
+	}
+	return strings.TrimSpace(message), strings.TrimSpace(rationale)
+}
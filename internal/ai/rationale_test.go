@@ -0,0 +1,62 @@
+package ai
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildPrompt_Explain(t *testing.T) {
+	client := &OllamaClient{}
+
+	prompt := client.buildPrompt(CommitRequest{Diff: "diff", Explain: true})
+	if !strings.Contains(prompt, "output a line containing only "+RationaleSeparator) {
+		t.Errorf("expected the rationale request in the prompt, got:\n%s", prompt)
+	}
+	if !strings.HasSuffix(prompt, "Diff:\ndiff") {
+		t.Errorf("expected the diff to stay last, got:\n%s", prompt)
+	}
+
+	prompt = client.buildPrompt(CommitRequest{Diff: "diff"})
+	if strings.Contains(prompt, RationaleSeparator) {
+		t.Errorf("expected no rationale request without Explain, got:\n%s", prompt)
+	}
+}
+
+func TestSplitRationale(t *testing.T) {
+	tests := []struct {
+		name              string
+		response          string
+		expectedMessage   string
+		expectedRationale string
+	}{
+		{
+			name:              "Message and rationale",
+			response:          "feat(auth): added login\n\nAdded a handler.\n\n---RATIONALE---\nNew behavior, so feat; every file is in auth.",
+			expectedMessage:   "feat(auth): added login\n\nAdded a handler.",
+			expectedRationale: "New behavior, so feat; every file is in auth.",
+		},
+		{
+			name:              "Separator on the subject line",
+			response:          "fix: handled nil config ---RATIONALE--- A crash was fixed.",
+			expectedMessage:   "fix: handled nil config",
+			expectedRationale: "A crash was fixed.",
+		},
+		{
+			name:            "No separator",
+			response:        "docs: updated README\n",
+			expectedMessage: "docs: updated README",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message, rationale := SplitRationale(tt.response)
+			if message != tt.expectedMessage {
+				t.Errorf("expected message %q, got %q", tt.expectedMessage, message)
+			}
+			if rationale != tt.expectedRationale {
+				t.Errorf("expected rationale %q, got %q", tt.expectedRationale, rationale)
+			}
+		})
+	}
+}
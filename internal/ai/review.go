@@ -0,0 +1,118 @@
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"ai-commit-message-generator/internal/git"
+)
+
+// Review finding severities, most severe first
+const (
+	// SeverityBug is a likely defect: wrong logic, a crash, lost data
+	SeverityBug = "bug"
+	// SeverityIssue should be fixed before committing: missing tests, a
+	// TODO or debug print left in, a change that does not match its intent
+	SeverityIssue = "issue"
+	// SeverityNit is a minor suggestion
+	SeverityNit = "nit"
+)
+
+// Severities lists the review severities, most severe first
+var Severities = []string{SeverityBug, SeverityIssue, SeverityNit}
+
+// ReviewFinding is one point of a pre-commit review
+type ReviewFinding struct {
+	Severity string `json:"severity"`
+	// File and Line locate the finding when the model could tell; Line is
+	// 0 when unknown
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+// reviewIntro opens the review prompt unless a system prompt replaces it
+const reviewIntro = "You are a careful senior engineer reviewing a change before it is committed."
+
+// ReviewChanges asks the model for a short review of the change in req. It
+// takes the same request as GenerateCommitMessage. Findings without a
+// message are dropped, and a severity the model made up counts as an issue.
+func (c *OllamaClient) ReviewChanges(req CommitRequest) ([]ReviewFinding, error) {
+	c.phase(PhaseBuildingPrompt)
+	response, err := c.send(c.buildReviewPrompt(req), "json")
+	if err != nil {
+		return nil, err
+	}
+
+	c.phase(PhasePostProcessing)
+	start := strings.Index(response, "{")
+	end := strings.LastIndex(response, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("failed to parse review: no JSON object in the response")
+	}
+	var parsed struct {
+		Findings []ReviewFinding `json:"findings"`
+	}
+	if err := json.Unmarshal([]byte(response[start:end+1]), &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse review: %w", err)
+	}
+
+	var findings []ReviewFinding
+	for _, f := range parsed.Findings {
+		f.Message = strings.TrimSpace(f.Message)
+		if f.Message == "" {
+			continue
+		}
+		f.Severity = strings.ToLower(strings.TrimSpace(f.Severity))
+		if SeverityRank(f.Severity) < 0 {
+			f.Severity = SeverityIssue
+		}
+		f.File = strings.TrimSpace(f.File)
+		if f.Line < 0 {
+			f.Line = 0
+		}
+		findings = append(findings, f)
+	}
+	return findings, nil
+}
+
+// SeverityRank returns the position of severity in Severities, 0 being the
+// most severe, or -1 for an unknown severity
+func SeverityRank(severity string) int {
+	for i, s := range Severities {
+		if s == severity {
+			return i
+		}
+	}
+	return -1
+}
+
+func (c *OllamaClient) buildReviewPrompt(req CommitRequest) string {
+	var sb strings.Builder
+	if c.systemPrompt == "" || c.systemPromptMode == SystemPromptPrepend {
+		sb.WriteString(reviewIntro + "\n\n")
+	}
+
+	sb.WriteString("Review the following staged diff. Look for:\n")
+	sb.WriteString("- Potential bugs: wrong conditions, unhandled errors, nil dereferences, off-by-one mistakes, races.\n")
+	sb.WriteString("- Missing tests for new or changed behavior.\n")
+	sb.WriteString("- TODO, FIXME or commented-out code left in.\n")
+	sb.WriteString("- Debug output left in, such as print statements or console.log.\n")
+	sb.WriteString("- Whether the change does what it apparently sets out to do, and nothing unrelated.\n\n")
+	sb.WriteString("Rules:\n")
+	sb.WriteString("- severity is \"bug\" for a likely defect, \"issue\" for something that should be fixed before committing, or \"nit\" for a minor suggestion.\n")
+	sb.WriteString("- file is the path from the diff header and line the line number in the new file, from the hunk headers; use 0 when unsure.\n")
+	sb.WriteString("- message is one short sentence.\n")
+	sb.WriteString("- Report only real findings, most severe first. An empty list is a fine answer.\n\n")
+	sb.WriteString("Respond with JSON only, in exactly this shape:\n")
+	sb.WriteString(`{"findings": [{"severity": "issue", "file": "path/to/file.go", "line": 42, "message": "Debug print left in."}]}`)
+	sb.WriteString("\n\n")
+
+	if req.GitState != nil && req.GitState.Type != git.StateNormal {
+		sb.WriteString(fmt.Sprintf("The change completes a %s that is in progress.\n\n", req.GitState.Type))
+	}
+	sb.WriteString("Diff:\n")
+	sb.WriteString(req.Diff)
+	return sb.String()
+}
@@ -0,0 +1,82 @@
+package ai
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOllamaClient_ReviewChanges(t *testing.T) {
+	tests := []struct {
+		name          string
+		response      string
+		expected      []ReviewFinding
+		expectedError string
+	}{
+		{
+			name: "Findings are cleaned up",
+			response: "```json\n" + `{"findings": [
+				{"severity": "Bug", "file": "login.go", "line": 12, "message": " err is ignored "},
+				{"severity": "critical", "file": "login.go", "line": -1, "message": "Debug print left in."},
+				{"severity": "nit", "file": "", "line": 0, "message": ""}
+			]}` + "\n```",
+			expected: []ReviewFinding{
+				{Severity: SeverityBug, File: "login.go", Line: 12, Message: "err is ignored"},
+				{Severity: SeverityIssue, File: "login.go", Message: "Debug print left in."},
+			},
+		},
+		{
+			name:     "Clean change",
+			response: `{"findings": []}`,
+		},
+		{
+			name:          "Not JSON",
+			response:      "Looks good to me.",
+			expectedError: "failed to parse review",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body ollamaRequest
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					t.Errorf("failed to decode request body: %v", err)
+				}
+				response, _ := json.Marshal(ollamaResponse{Response: tt.response, Done: true})
+				w.Write(response)
+			}))
+			defer server.Close()
+
+			client := NewClient("test-api-key", server.URL+"/api/generate", "", time.Second)
+			findings, err := client.ReviewChanges(CommitRequest{Diff: "diff --git a/login.go b/login.go", Rules: "Use past tense"})
+			if body.Format != "json" {
+				t.Errorf("expected a JSON response to be requested, got format %q", body.Format)
+			}
+			for _, want := range []string{"Debug output left in", "Missing tests", "Diff:\ndiff --git a/login.go b/login.go"} {
+				if !strings.Contains(body.Prompt, want) {
+					t.Errorf("expected prompt to contain %q, got:\n%s", want, body.Prompt)
+				}
+			}
+			if strings.Contains(body.Prompt, "Conventional Commits") || strings.Contains(body.Prompt, "Use past tense") {
+				t.Errorf("expected no commit message instructions in the review prompt, got:\n%s", body.Prompt)
+			}
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("expected error containing %q, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReviewChanges failed: %v", err)
+			}
+			if !reflect.DeepEqual(findings, tt.expected) {
+				t.Errorf("expected %+v, got %+v", tt.expected, findings)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package ai
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"ai-commit-message-generator/internal/git"
+)
+
+// ScopeHint is the scope a scope map derives from the changed paths
+type ScopeHint struct {
+	// Scope is the scope of the most-changed mapped paths
+	Scope string
+	// Others are the other mapped scopes the change touches, most changed
+	// first. When there are any the change may need splitting.
+	Others []string
+}
+
+// ScopeFromMap maps the changed files to scopes with scopeMap, whose keys
+// are path prefixes such as "internal/ai" or "cmd/", and returns the scope
+// with the most changed lines in diff first. The longest matching prefix
+// wins, and a prefix only matches whole path segments. Files no prefix
+// matches, and files the path filters leave out, are ignored. It returns
+// nil when no file is mapped.
+func ScopeFromMap(files []git.StagedFile, diff string, scopeMap map[string]string) *ScopeHint {
+	if len(scopeMap) == 0 {
+		return nil
+	}
+	lines := make(map[string]int)
+	for _, stat := range git.DiffStat(diff) {
+		lines[stat.Path] += stat.Insertions + stat.Deletions
+	}
+
+	weights := make(map[string]int)
+	for _, file := range files {
+		if file.Excluded {
+			continue
+		}
+		scope, ok := lookupScope(file.Path, scopeMap)
+		if !ok {
+			continue
+		}
+		// A file without counted lines, e.g. a binary or a rename, still
+		// counts for its scope
+		weights[scope] += max(1, lines[file.Path])
+	}
+	if len(weights) == 0 {
+		return nil
+	}
+
+	scopes := make([]string, 0, len(weights))
+	for scope := range weights {
+		scopes = append(scopes, scope)
+	}
+	sort.Slice(scopes, func(i, j int) bool {
+		if weights[scopes[i]] != weights[scopes[j]] {
+			return weights[scopes[i]] > weights[scopes[j]]
+		}
+		return scopes[i] < scopes[j]
+	})
+	return &ScopeHint{Scope: scopes[0], Others: scopes[1:]}
+}
+
+// lookupScope returns the scope of the longest prefix in scopeMap that
+// path is in
+func lookupScope(path string, scopeMap map[string]string) (string, bool) {
+	best, scope := -1, ""
+	for prefix, s := range scopeMap {
+		prefix = strings.TrimSuffix(prefix, "/")
+		if path != prefix && !strings.HasPrefix(path, prefix+"/") {
+			continue
+		}
+		if len(prefix) > best {
+			best, scope = len(prefix), s
+		}
+	}
+	return scope, best >= 0
+}
+
+// writeScopeHint tells the model which scope to use. It overrides the
+// scope the model would guess from the file names.
+func writeScopeHint(sb *strings.Builder, hint *ScopeHint, noSplit bool) {
+	sb.WriteString("Scope:\n")
+	if len(hint.Others) == 0 {
+		sb.WriteString(fmt.Sprintf("- The changed files belong to the '%s' scope. You MUST use \"%s\" as the scope of the message, whatever the file names suggest.\n\n", hint.Scope, hint.Scope))
+		return
+	}
+	all := append([]string{hint.Scope}, hint.Others...)
+	sb.WriteString(fmt.Sprintf("- The changed files belong to several scopes: %s (most changed first).\n", strings.Join(all, ", ")))
+	if noSplit {
+		sb.WriteString(fmt.Sprintf("- You MUST use \"%s\", the most-changed scope.\n\n", hint.Scope))
+		return
+	}
+	sb.WriteString(fmt.Sprintf("- If this is one logical change, you MUST use \"%s\", the most-changed scope. Otherwise suggest splitting it into one commit per scope.\n\n", hint.Scope))
+}
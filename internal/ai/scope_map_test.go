@@ -0,0 +1,99 @@
+package ai
+
+import (
+	"strings"
+	"testing"
+
+	"ai-commit-message-generator/internal/git"
+)
+
+func TestScopeFromMap(t *testing.T) {
+	scopeMap := map[string]string{
+		"internal/ai":   "ai",
+		"internal/ai/x": "ai-x",
+		"cmd/":          "cli",
+	}
+	diffOf := func(path string, lines int) string {
+		return "diff --git a/" + path + " b/" + path + "\n--- a/" + path + "\n+++ b/" + path + "\n@@ -1 +1 @@\n" + strings.Repeat("+line\n", lines)
+	}
+
+	tests := []struct {
+		name     string
+		files    []git.StagedFile
+		diff     string
+		expected *ScopeHint
+	}{
+		{
+			name:     "Single scope",
+			files:    []git.StagedFile{{Path: "internal/ai/client.go"}, {Path: "internal/ai/prompt.go"}},
+			diff:     diffOf("internal/ai/client.go", 3) + diffOf("internal/ai/prompt.go", 1),
+			expected: &ScopeHint{Scope: "ai"},
+		},
+		{
+			name:     "Most-changed scope first",
+			files:    []git.StagedFile{{Path: "cmd/main.go"}, {Path: "internal/ai/client.go"}},
+			diff:     diffOf("cmd/main.go", 2) + diffOf("internal/ai/client.go", 9),
+			expected: &ScopeHint{Scope: "ai", Others: []string{"cli"}},
+		},
+		{
+			name:     "Longest prefix wins",
+			files:    []git.StagedFile{{Path: "internal/ai/x/y.go"}},
+			diff:     diffOf("internal/ai/x/y.go", 1),
+			expected: &ScopeHint{Scope: "ai-x"},
+		},
+		{
+			name:     "Prefix matches whole segments only",
+			files:    []git.StagedFile{{Path: "internal/aitools/a.go"}},
+			diff:     diffOf("internal/aitools/a.go", 1),
+			expected: nil,
+		},
+		{
+			name:     "Unmapped and excluded files are ignored",
+			files:    []git.StagedFile{{Path: "README.md"}, {Path: "cmd/main.go", Excluded: true}, {Path: "internal/ai/client.go"}},
+			diff:     diffOf("README.md", 50) + diffOf("internal/ai/client.go", 1),
+			expected: &ScopeHint{Scope: "ai"},
+		},
+		{
+			name:     "Files without counted lines still count",
+			files:    []git.StagedFile{{Path: "cmd/logo.png"}},
+			diff:     "diff --git a/cmd/logo.png b/cmd/logo.png\nBinary files differ\n",
+			expected: &ScopeHint{Scope: "cli"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ScopeFromMap(tt.files, tt.diff, scopeMap)
+			if (got == nil) != (tt.expected == nil) {
+				t.Fatalf("expected %+v, got %+v", tt.expected, got)
+			}
+			if got == nil {
+				return
+			}
+			if got.Scope != tt.expected.Scope || strings.Join(got.Others, ",") != strings.Join(tt.expected.Others, ",") {
+				t.Errorf("expected %+v, got %+v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestBuildPrompt_Scope(t *testing.T) {
+	client := &OllamaClient{}
+
+	prompt := client.buildPrompt(CommitRequest{Diff: "diff", Scope: &ScopeHint{Scope: "ai"}})
+	if !strings.Contains(prompt, `You MUST use "ai" as the scope`) {
+		t.Errorf("expected the scope hint in the prompt, got:\n%s", prompt)
+	}
+
+	prompt = client.buildPrompt(CommitRequest{Diff: "diff", Scope: &ScopeHint{Scope: "ai", Others: []string{"cli"}}})
+	for _, want := range []string{"several scopes: ai, cli", `you MUST use "ai", the most-changed scope`, "one commit per scope"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("expected prompt to contain %q, got:\n%s", want, prompt)
+		}
+	}
+
+	prompt = client.buildPrompt(CommitRequest{Diff: "diff"})
+	if strings.Contains(prompt, "Scope:\n") {
+		t.Errorf("expected no scope hint without a scope map, got:\n%s", prompt)
+	}
+}
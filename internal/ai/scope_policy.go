@@ -0,0 +1,63 @@
+package ai
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Scope policies, for CommitRequest.ScopePolicy
+const (
+	// ScopeOptional leaves the scope to the model
+	ScopeOptional = "optional"
+	// ScopeRequired asks for a scope on every message
+	ScopeRequired = "required"
+	// ScopeForbidden asks for headers without a scope
+	ScopeForbidden = "forbidden"
+)
+
+// writeScopePolicy tells the model whether the first line must or must not
+// have a scope. The optional policy adds nothing.
+func writeScopePolicy(sb *strings.Builder, policy string, format *HeaderFormat) {
+	switch policy {
+	case ScopeRequired:
+		sb.WriteString(fmt.Sprintf("A scope is REQUIRED: the first line MUST have a scope naming the module, package or area the change touches, as in \"%s\".\n\n", format.Layout()))
+	case ScopeForbidden:
+		sb.WriteString(fmt.Sprintf("Do NOT use a scope: the first line MUST be \"%s\", without a scope.\n\n", format.Render(Header{Type: "<type>", Description: "<description>"})))
+	}
+}
+
+// writeAllowedScopes offers the model scopes to choose from, unless the
+// policy rules out a scope altogether
+func writeAllowedScopes(sb *strings.Builder, scopes []string, policy string) {
+	if len(scopes) == 0 || policy == ScopeForbidden {
+		return
+	}
+	if policy == ScopeRequired {
+		sb.WriteString(fmt.Sprintf("Choose a scope from: %s. No other scope is allowed.\n\n", strings.Join(scopes, ", ")))
+		return
+	}
+	sb.WriteString(fmt.Sprintf("Choose a scope from: %s, or leave the scope out. No other scope is allowed.\n\n", strings.Join(scopes, ", ")))
+}
+
+// HasScope reports whether the header of message follows the format and
+// names a scope
+func (f *HeaderFormat) HasScope(message string) bool {
+	header, _, _ := strings.Cut(message, "\n")
+	h, ok := f.Parse(header)
+	return ok && strings.TrimSpace(h.Scope) != ""
+}
+
+// WithoutScope drops the scope, with its brackets, from the header of
+// message. Messages whose header does not follow the format are left alone.
+func (f *HeaderFormat) WithoutScope(message string) string {
+	header, rest, hasBody := strings.Cut(message, "\n")
+	h, ok := f.Parse(header)
+	if !ok || !h.HasScope {
+		return message
+	}
+	h.Scope, h.HasScope = "", false
+	if !hasBody {
+		return f.Render(h)
+	}
+	return f.Render(h) + "\n" + rest
+}
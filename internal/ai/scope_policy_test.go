@@ -0,0 +1,139 @@
+package ai
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildPrompt_ScopePolicy(t *testing.T) {
+	client := &OllamaClient{}
+	scopeFirst, err := ParseHeaderFormat("[{{.Scope}}] {{.Type}}: {{.Description}}")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		policy   string
+		format   *HeaderFormat
+		expected string
+	}{
+		{"Required", ScopeRequired, nil, "A scope is REQUIRED: the first line MUST have a scope naming the module, package or area the change touches, as in \"<type>(<scope>): <description>\"."},
+		{"Forbidden", ScopeForbidden, nil, "Do NOT use a scope: the first line MUST be \"<type>: <description>\", without a scope."},
+		{"Forbidden in a custom layout", ScopeForbidden, scopeFirst, "the first line MUST be \"<type>: <description>\""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prompt := client.buildPrompt(CommitRequest{Diff: "diff", ScopePolicy: tt.policy, HeaderFormat: tt.format})
+			if !strings.Contains(prompt, tt.expected) {
+				t.Errorf("expected %q in the prompt, got:\n%s", tt.expected, prompt)
+			}
+		})
+	}
+
+	for _, policy := range []string{"", ScopeOptional} {
+		prompt := client.buildPrompt(CommitRequest{Diff: "diff", ScopePolicy: policy})
+		if strings.Contains(prompt, "REQUIRED") || strings.Contains(prompt, "Do NOT use a scope") {
+			t.Errorf("expected no scope instruction for %q, got:\n%s", policy, prompt)
+		}
+	}
+}
+
+func TestBuildPrompt_TypesAndScopes(t *testing.T) {
+	client := &OllamaClient{}
+	tests := []struct {
+		name     string
+		req      CommitRequest
+		expected []string
+		excluded []string
+	}{
+		{
+			name:     "Defaults",
+			req:      CommitRequest{Diff: "diff"},
+			expected: []string{"Allowed types: feat, fix, docs, style, refactor, perf, test, build, ci, chore, revert.\n"},
+			excluded: []string{"Choose a scope from"},
+		},
+		{
+			name: "Custom lists",
+			req:  CommitRequest{Diff: "diff", Types: []string{"feat", "fix", "perf", "build", "ci"}, Scopes: []string{"ai", "git", "app", "config"}},
+			expected: []string{
+				"Allowed types: feat, fix, perf, build, ci.\n",
+				"Choose a scope from: ai, git, app, config, or leave the scope out. No other scope is allowed.",
+			},
+		},
+		{
+			name:     "Scopes with a required scope",
+			req:      CommitRequest{Diff: "diff", Scopes: []string{"ai", "git"}, ScopePolicy: ScopeRequired},
+			expected: []string{"Choose a scope from: ai, git. No other scope is allowed."},
+			excluded: []string{"leave the scope out"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prompt := client.buildPrompt(tt.req)
+			for _, want := range tt.expected {
+				if !strings.Contains(prompt, want) {
+					t.Errorf("expected %q in the prompt, got:\n%s", want, prompt)
+				}
+			}
+			for _, unwanted := range tt.excluded {
+				if strings.Contains(prompt, unwanted) {
+					t.Errorf("expected no %q in the prompt, got:\n%s", unwanted, prompt)
+				}
+			}
+		})
+	}
+}
+
+func TestHeaderFormat_WithoutScope(t *testing.T) {
+	scopeFirst, err := ParseHeaderFormat("[{{.Scope}}] {{.Type}}: {{.Description}}")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		format   *HeaderFormat
+		message  string
+		expected string
+	}{
+		{"Scope is dropped", nil, "feat(auth): added login\n\nBody text.", "feat: added login\n\nBody text."},
+		{"Breaking marker is kept", nil, "feat(api)!: dropped v1", "feat!: dropped v1"},
+		{"Empty scope is dropped", nil, "fix(): handled nil", "fix: handled nil"},
+		{"No scope", nil, "fix: handled nil", "fix: handled nil"},
+		{"Free text is kept", nil, "Handled nil (finally)", "Handled nil (finally)"},
+		{"Custom layout", scopeFirst, "[ui] fix: fixed layout", "fix: fixed layout"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.format.WithoutScope(tt.message); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestHeaderFormat_HasScope(t *testing.T) {
+	tests := []struct {
+		message  string
+		expected bool
+	}{
+		{"feat(auth): added login", true},
+		{"feat(auth)!: added login\n\nBody.", true},
+		{"feat: added login", false},
+		{"feat(): added login", false},
+		{"Added login", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.message, func(t *testing.T) {
+			var format *HeaderFormat
+			if got := format.HasScope(tt.message); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// SplitRequest holds what a split plan is made from
+type SplitRequest struct {
+	// Diff is the staged diff
+	Diff string
+	// Files are the staged paths to distribute over the commits
+	Files []string
+	// Rules are the team rules from .git-commit-rules-for-ai
+	Rules string
+}
+
+// SplitPlan is the model's proposal for committing staged changes in
+// several steps
+type SplitPlan struct {
+	Groups []PlannedGroup `json:"groups"`
+}
+
+// PlannedGroup is one proposed commit: whole files, never single hunks
+type PlannedGroup struct {
+	Files   []string `json:"files"`
+	Subject string   `json:"subject"`
+}
+
+// splitIntro opens the split prompt unless a system prompt replaces it
+const splitIntro = "You are an expert software engineer who keeps git history clean by committing one logical change at a time."
+
+// PlanSplit asks the model to group the staged files into commits. Files the
+// request does not list, and files already placed in an earlier group, are
+// dropped from the plan; files the model leaves out are not added back.
+func (c *OllamaClient) PlanSplit(req SplitRequest) (*SplitPlan, error) {
+	c.phase(PhaseBuildingPrompt)
+	response, err := c.send(c.buildSplitPrompt(req), "json")
+	if err != nil {
+		return nil, err
+	}
+
+	c.phase(PhasePostProcessing)
+	plan, err := parseSplitPlan(response)
+	if err != nil {
+		return nil, err
+	}
+	plan.keepFiles(req.Files)
+	if len(plan.Groups) == 0 {
+		return nil, fmt.Errorf("the split plan assigns none of the staged files")
+	}
+	return plan, nil
+}
+
+func (c *OllamaClient) buildSplitPrompt(req SplitRequest) string {
+	var sb strings.Builder
+	if c.systemPrompt == "" || c.systemPromptMode == SystemPromptPrepend {
+		sb.WriteString(splitIntro + "\n\n")
+	}
+
+	sb.WriteString("The following staged changes contain several independent changes. Group the staged files into separate commits, one logical change per commit, in the order they should be committed.\n\n")
+	sb.WriteString("Rules:\n")
+	sb.WriteString("- Assign every file to exactly one commit. Files cannot be split between commits.\n")
+	sb.WriteString("- Keep code together with its tests and the docs describing it.\n")
+	sb.WriteString("- Give each commit a Conventional Commits subject, <type>(<scope>): <description>, in past tense.\n\n")
+	sb.WriteString("Respond with JSON only, in exactly this shape:\n")
+	sb.WriteString(`{"groups": [{"files": ["path/one.go", "path/one_test.go"], "subject": "feat(one): added one"}]}`)
+	sb.WriteString("\n\nStaged files:\n")
+	for _, f := range req.Files {
+		sb.WriteString("- " + f + "\n")
+	}
+	sb.WriteString("\n")
+
+	if req.Rules != "" {
+		sb.WriteString("Team Rules:\n")
+		sb.WriteString(req.Rules)
+		sb.WriteString("\n\n")
+	}
+	sb.WriteString("Diff:\n")
+	sb.WriteString(req.Diff)
+	return sb.String()
+}
+
+// parseSplitPlan decodes the plan from a response, tolerating a code fence
+// or text around the JSON object
+func parseSplitPlan(response string) (*SplitPlan, error) {
+	start := strings.Index(response, "{")
+	end := strings.LastIndex(response, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("failed to parse split plan: no JSON object in the response")
+	}
+
+	var plan SplitPlan
+	if err := json.Unmarshal([]byte(response[start:end+1]), &plan); err != nil {
+		return nil, fmt.Errorf("failed to parse split plan: %w", err)
+	}
+	return &plan, nil
+}
+
+// keepFiles drops unknown and repeated files from the plan, then groups left
+// without files
+func (p *SplitPlan) keepFiles(files []string) {
+	known := make(map[string]bool, len(files))
+	for _, f := range files {
+		known[f] = true
+	}
+
+	assigned := make(map[string]bool, len(files))
+	groups := p.Groups[:0]
+	for _, group := range p.Groups {
+		var kept []string
+		for _, f := range group.Files {
+			if known[f] && !assigned[f] {
+				kept = append(kept, f)
+				assigned[f] = true
+			}
+		}
+		if len(kept) > 0 {
+			group.Files = kept
+			group.Subject = strings.TrimSpace(group.Subject)
+			groups = append(groups, group)
+		}
+	}
+	p.Groups = groups
+}
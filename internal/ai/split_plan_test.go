@@ -0,0 +1,86 @@
+package ai
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOllamaClient_PlanSplit(t *testing.T) {
+	files := []string{"README.md", "auth/login.go", "auth/login_test.go", "db/migrate.go"}
+
+	tests := []struct {
+		name          string
+		plan          string
+		expected      []PlannedGroup
+		expectedError string
+	}{
+		{
+			name: "Groups are returned in order",
+			plan: `{"groups": [{"files": ["auth/login.go", "auth/login_test.go"], "subject": "feat(auth): added login"}, {"files": ["db/migrate.go", "README.md"], "subject": " chore(db): added migration "}]}`,
+			expected: []PlannedGroup{
+				{Files: []string{"auth/login.go", "auth/login_test.go"}, Subject: "feat(auth): added login"},
+				{Files: []string{"db/migrate.go", "README.md"}, Subject: "chore(db): added migration"},
+			},
+		},
+		{
+			name: "Unknown, repeated and empty assignments are dropped",
+			plan: "```json\n" + `{"groups": [{"files": ["auth/login.go", "auth/session.go"], "subject": "feat(auth): added login"}, {"files": ["auth/login.go"], "subject": "fix(auth): fixed login"}, {"files": ["README.md"], "subject": "docs: updated readme"}]}` + "\n```",
+			expected: []PlannedGroup{
+				{Files: []string{"auth/login.go"}, Subject: "feat(auth): added login"},
+				{Files: []string{"README.md"}, Subject: "docs: updated readme"},
+			},
+		},
+		{
+			name:          "No known files",
+			plan:          `{"groups": [{"files": ["main.go"], "subject": "feat: added main"}]}`,
+			expectedError: "assigns none of the staged files",
+		},
+		{
+			name:          "Not JSON",
+			plan:          "This should be split into two commits.",
+			expectedError: "failed to parse split plan",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body ollamaRequest
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					t.Errorf("failed to decode request body: %v", err)
+				}
+				response, _ := json.Marshal(ollamaResponse{Response: tt.plan, Done: true})
+				w.Write(response)
+			}))
+			defer server.Close()
+
+			client := NewClient("test-api-key", server.URL+"/api/generate", "", time.Second)
+			plan, err := client.PlanSplit(SplitRequest{Diff: "diff --git a/auth/login.go b/auth/login.go", Files: files})
+			if body.Format != "json" {
+				t.Errorf("expected a JSON response to be requested, got format %q", body.Format)
+			}
+			for _, f := range files {
+				if !strings.Contains(body.Prompt, "- "+f+"\n") {
+					t.Errorf("expected %s to be listed in the prompt", f)
+				}
+			}
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("expected error containing %q, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("PlanSplit failed: %v", err)
+			}
+			if !reflect.DeepEqual(plan.Groups, tt.expected) {
+				t.Errorf("expected %+v, got %+v", tt.expected, plan.Groups)
+			}
+		})
+	}
+}
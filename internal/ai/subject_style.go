@@ -0,0 +1,97 @@
+package ai
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SubjectStyle is the house style of the description in the first line.
+// The zero value is the usual Conventional Commits style: lower case and
+// no period at the end.
+type SubjectStyle struct {
+	// Capitalize starts the description with a capital letter
+	Capitalize bool
+	// TrailingPeriod ends the description with a period
+	TrailingPeriod bool
+}
+
+// writeSubjectStyle asks for the case and final period of style. The zero
+// value adds nothing; WithSubjectStyle enforces it after generation.
+func writeSubjectStyle(sb *strings.Builder, style SubjectStyle) {
+	if style.Capitalize {
+		sb.WriteString("Start the description after the colon with a capital letter.\n")
+	}
+	if style.TrailingPeriod {
+		sb.WriteString("End the description after the colon with a period.\n")
+	}
+	if style != (SubjectStyle{}) {
+		sb.WriteString("\n")
+	}
+}
+
+// writeLanguage asks for the message in language, keeping the type in
+// English since tools parse it
+func writeLanguage(sb *strings.Builder, language string) {
+	if language == "" {
+		return
+	}
+	sb.WriteString(fmt.Sprintf("Write the description and the body in %s. Keep the type in English.\n\n", language))
+}
+
+// WithSubjectStyle rewrites the description in the header of message to
+// follow style, leaving the type and scope as they are. Messages whose
+// header does not follow the format are left alone.
+func (f *HeaderFormat) WithSubjectStyle(message string, style SubjectStyle) string {
+	header, rest, hasBody := strings.Cut(message, "\n")
+	h, ok := f.Parse(header)
+	if !ok {
+		return message
+	}
+	styled := style.apply(h.Description)
+	if styled == h.Description {
+		return message
+	}
+	h.Description = styled
+	if !hasBody {
+		return f.Render(h)
+	}
+	return f.Render(h) + "\n" + rest
+}
+
+// apply changes the case of the first letter of description and adds or
+// removes its final period
+func (s SubjectStyle) apply(description string) string {
+	description = strings.TrimSpace(description)
+	if description == "" {
+		return description
+	}
+
+	first, size := utf8.DecodeRuneInString(description)
+	switch {
+	case s.Capitalize:
+		description = string(unicode.ToUpper(first)) + description[size:]
+	case !isAcronym(description):
+		description = string(unicode.ToLower(first)) + description[size:]
+	}
+
+	// An ellipsis is not a period
+	ellipsis := strings.HasSuffix(description, "..")
+	switch {
+	case s.TrailingPeriod && !strings.HasSuffix(description, ".") && !strings.HasSuffix(description, "!") && !strings.HasSuffix(description, "?"):
+		description += "."
+	case !s.TrailingPeriod && strings.HasSuffix(description, ".") && !ellipsis:
+		description = strings.TrimSuffix(description, ".")
+	}
+	return description
+}
+
+// isAcronym reports whether the first word of description has a capital
+// letter after its first, as API, README and GitHub do. Lower-casing those
+// would misspell them.
+func isAcronym(description string) bool {
+	word, _, _ := strings.Cut(description, " ")
+	_, size := utf8.DecodeRuneInString(word)
+	return strings.IndexFunc(word[size:], unicode.IsUpper) >= 0
+}
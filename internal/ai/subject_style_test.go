@@ -0,0 +1,97 @@
+package ai
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHeaderFormat_WithSubjectStyle(t *testing.T) {
+	scopeFirst, err := ParseHeaderFormat("[{{.Scope}}] {{.Type}}: {{.Description}}")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	capitalized := SubjectStyle{Capitalize: true}
+	period := SubjectStyle{TrailingPeriod: true}
+	both := SubjectStyle{Capitalize: true, TrailingPeriod: true}
+
+	tests := []struct {
+		name     string
+		format   *HeaderFormat
+		style    SubjectStyle
+		message  string
+		expected string
+	}{
+		{"Default lowercases and drops the period", nil, SubjectStyle{}, "feat(auth): Add login form.", "feat(auth): add login form"},
+		{"Default leaves a conforming header", nil, SubjectStyle{}, "fix: handle nil config", "fix: handle nil config"},
+		{"Acronym keeps its case", nil, SubjectStyle{}, "docs: README covers setup.", "docs: README covers setup"},
+		{"Ellipsis is kept", nil, SubjectStyle{}, "chore: wip...", "chore: wip..."},
+		{"Capitalized", nil, capitalized, "feat(auth): add login form", "feat(auth): Add login form"},
+		{"Trailing period", nil, period, "feat(auth): add login form", "feat(auth): add login form."},
+		{"Period is not doubled", nil, period, "fix: handle nil config.", "fix: handle nil config."},
+		{"Question mark counts as the end", nil, period, "docs: why retry?", "docs: why retry?"},
+		{"Capitalized with a period", nil, both, "refactor(api)!: drop v1 routes", "refactor(api)!: Drop v1 routes."},
+		{"Type and scope untouched", nil, capitalized, "feat(ui): show errors", "feat(ui): Show errors"},
+		{"Body is kept", nil, both, "fix: handle nil\n\nthe body stays as it is.", "fix: Handle nil.\n\nthe body stays as it is."},
+		{"Custom layout", scopeFirst, both, "[ui] fix: fix layout", "[ui] fix: Fix layout."},
+		{"Free text is kept", nil, both, "handled nil (finally)", "handled nil (finally)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.format.WithSubjectStyle(tt.message, tt.style); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestBuildPrompt_PathStyle(t *testing.T) {
+	client := &OllamaClient{}
+	tests := []struct {
+		name     string
+		req      CommitRequest
+		expected []string
+		excluded []string
+	}{
+		{
+			name:     "Root style adds nothing",
+			req:      CommitRequest{Diff: "diff"},
+			excluded: []string{"capital letter", "with a period", "Choose a scope from", "Write the description"},
+		},
+		{
+			name: "Every override",
+			req: CommitRequest{
+				Diff:         "diff",
+				Scopes:       []string{"ui", "admin"},
+				SubjectStyle: SubjectStyle{Capitalize: true, TrailingPeriod: true},
+				Language:     "German",
+			},
+			expected: []string{
+				"Choose a scope from: ui, admin, or leave the scope out. No other scope is allowed.",
+				"Start the description after the colon with a capital letter.\nEnd the description after the colon with a period.\n",
+				"Write the description and the body in German. Keep the type in English.",
+			},
+		},
+		{
+			name:     "Scopes are moot when forbidden",
+			req:      CommitRequest{Diff: "diff", Scopes: []string{"ui"}, ScopePolicy: ScopeForbidden},
+			excluded: []string{"Choose a scope from"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prompt := client.buildPrompt(tt.req)
+			for _, want := range tt.expected {
+				if !strings.Contains(prompt, want) {
+					t.Errorf("expected %q in the prompt, got:\n%s", want, prompt)
+				}
+			}
+			for _, unwanted := range tt.excluded {
+				if strings.Contains(prompt, unwanted) {
+					t.Errorf("expected no %q in the prompt, got:\n%s", unwanted, prompt)
+				}
+			}
+		})
+	}
+}
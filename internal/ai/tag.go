@@ -0,0 +1,53 @@
+package ai
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TagRequest holds what an annotated tag message is written from
+type TagRequest struct {
+	// Version is the name of the new tag, e.g. "v1.3.0"
+	Version string
+	// Previous is the tag the commits are counted from, e.g. "v1.2.0"
+	Previous string
+	// Groups holds the commits by type, as for a changelog
+	Groups []ChangelogGroup
+}
+
+// tagIntro opens the tag prompt unless a system prompt replaces it
+const tagIntro = "You are an expert release manager writing the message of an annotated git tag for a release."
+
+// GenerateTagMessage asks the model for the body of an annotated tag
+// message: the highlights of a release and its breaking changes
+func (c *OllamaClient) GenerateTagMessage(req TagRequest) (string, error) {
+	c.phase(PhaseBuildingPrompt)
+	response, err := c.generate(c.buildTagPrompt(req))
+	if err != nil {
+		return "", err
+	}
+
+	c.phase(PhasePostProcessing)
+	message := normalizeMessage(response)
+	if message == "" {
+		return "", fmt.Errorf("empty response from model")
+	}
+	return message, nil
+}
+
+func (c *OllamaClient) buildTagPrompt(req TagRequest) string {
+	var sb strings.Builder
+	if c.systemPrompt == "" || c.systemPromptMode == SystemPromptPrepend {
+		sb.WriteString(tagIntro + "\n\n")
+	}
+
+	sb.WriteString(fmt.Sprintf("Summarize the changes from %s to %s for the message of the annotated tag %s.\n\n", req.Previous, req.Version, req.Version))
+	sb.WriteString("Output plain text in exactly this format:\n\n")
+	sb.WriteString("Highlights:\n- <highlight>\n\nBreaking changes:\n- <breaking change>\n\n")
+	sb.WriteString("List at most 7 highlights, the changes users care about most, each as one short sentence. Merge commits that describe the same change and leave out tests, CI and refactoring unless they are all there is.\n")
+	sb.WriteString("List every commit marked [BREAKING] under Breaking changes, with what users need to do. Leave out the Breaking changes section when there are none.\n")
+	sb.WriteString("Do not use markdown headings, do not add a title line or a version, and do not output anything else. Describe only what the commits say.\n\n")
+
+	writeCommitGroups(&sb, req.Groups)
+	return sb.String()
+}
@@ -0,0 +1,31 @@
+package ai
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildTagPrompt(t *testing.T) {
+	client := &OllamaClient{}
+	prompt := client.buildTagPrompt(TagRequest{
+		Version:  "v1.3.0",
+		Previous: "v1.2.0",
+		Groups: []ChangelogGroup{
+			{Type: "feat", Commits: []ChangelogCommit{
+				{Scope: "auth", Description: "added SSO login", Hash: "aaaaaaa"},
+				{Scope: "config", Description: "renamed the model option", Breaking: true, Hash: "bbbbbbb"},
+			}},
+		},
+	})
+
+	for _, want := range []string{
+		tagIntro,
+		"from v1.2.0 to v1.3.0 for the message of the annotated tag v1.3.0",
+		"Highlights:\n- <highlight>\n\nBreaking changes:\n",
+		"feat:\n- auth: added SSO login (aaaaaaa)\n- [BREAKING] config: renamed the model option (bbbbbbb)\n",
+	} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("expected prompt to contain %q, got:\n%s", want, prompt)
+		}
+	}
+}
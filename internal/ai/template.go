@@ -0,0 +1,86 @@
+package ai
+
+import (
+	"regexp"
+	"strings"
+)
+
+// CommitTemplate is the team's commit.template, which the generated message
+// fills in instead of starting from scratch
+type CommitTemplate struct {
+	// Text is the template without its comment lines
+	Text string
+	// Placeholders are the [NAME], <name> and {name} tokens of Text, in the
+	// order they appear
+	Placeholders []string
+}
+
+// placeholderPattern matches a template placeholder such as [TICKET],
+// <subject> or {scope}
+var placeholderPattern = regexp.MustCompile(`\[[A-Za-z][A-Za-z0-9_ -]*\]|<[A-Za-z][A-Za-z0-9_ -]*>|\{[A-Za-z][A-Za-z0-9_ -]*\}`)
+
+// ParseCommitTemplate reads a commit.template file. Comment lines, which
+// start with commentChar and which git strips from the message, are
+// dropped. It returns nil when nothing is left.
+func ParseCommitTemplate(content, commentChar string) *CommitTemplate {
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		if !strings.HasPrefix(line, commentChar) {
+			lines = append(lines, strings.TrimRight(line, " \t"))
+		}
+	}
+	text := strings.TrimSpace(strings.Join(lines, "\n"))
+	if text == "" {
+		return nil
+	}
+
+	t := &CommitTemplate{Text: text}
+	seen := make(map[string]bool)
+	for _, placeholder := range placeholderPattern.FindAllString(text, -1) {
+		if !seen[placeholder] {
+			seen[placeholder] = true
+			t.Placeholders = append(t.Placeholders, placeholder)
+		}
+	}
+	return t
+}
+
+// PlaceholderName returns the name inside a placeholder, upper-cased:
+// TICKET for [TICKET], <ticket> or {ticket}
+func PlaceholderName(placeholder string) string {
+	return strings.ToUpper(strings.TrimSpace(placeholder[1 : len(placeholder)-1]))
+}
+
+// Fill replaces every placeholder whose name is one of names with value
+func (t *CommitTemplate) Fill(value string, names ...string) {
+	var remaining []string
+	for _, placeholder := range t.Placeholders {
+		if containsName(names, PlaceholderName(placeholder)) {
+			t.Text = strings.ReplaceAll(t.Text, placeholder, value)
+		} else {
+			remaining = append(remaining, placeholder)
+		}
+	}
+	t.Placeholders = remaining
+}
+
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeTemplate asks the model to follow the team's template
+func writeTemplate(sb *strings.Builder, t *CommitTemplate) {
+	sb.WriteString("=== COMMIT TEMPLATE ===\n")
+	sb.WriteString("This team writes commit messages from the template below. Instead of a free-form message, output the template filled in for this diff: keep its literal text and line layout")
+	if len(t.Placeholders) > 0 {
+		sb.WriteString(", and replace each placeholder (" + strings.Join(t.Placeholders, ", ") + ") with the value for this change. Drop a placeholder whose value cannot be determined from the diff, together with any separator next to it")
+	}
+	sb.WriteString(". Any team rules still apply to the text you write.\n\n")
+	sb.WriteString(t.Text)
+	sb.WriteString("\n=======================\n\n")
+}
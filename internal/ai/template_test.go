@@ -0,0 +1,104 @@
+package ai
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseCommitTemplate(t *testing.T) {
+	tests := []struct {
+		name                 string
+		content              string
+		commentChar          string
+		expectedText         string
+		expectedPlaceholders []string
+		expectNil            bool
+	}{
+		{
+			name:                 "Placeholders in order, once each",
+			content:              "[TICKET] <type>(<scope>): <subject>\r\n\r\n<body>\r\n\r\nRefs: [TICKET]\r\n",
+			expectedText:         "[TICKET] <type>(<scope>): <subject>\n\n<body>\n\nRefs: [TICKET]",
+			expectedPlaceholders: []string{"[TICKET]", "<type>", "<scope>", "<subject>", "<body>"},
+		},
+		{
+			name:                 "Comment lines are dropped",
+			content:              "# Subject: what changed\n{summary}\n# Why?\n\nWhy: {reason}\n",
+			expectedText:         "{summary}\n\nWhy: {reason}",
+			expectedPlaceholders: []string{"{summary}", "{reason}"},
+		},
+		{
+			name:                 "Custom comment character",
+			content:              "; Reference the issue\n#{issue} {summary}\n",
+			commentChar:          ";",
+			expectedText:         "#{issue} {summary}",
+			expectedPlaceholders: []string{"{issue}", "{summary}"},
+		},
+		{
+			name:         "Structure without placeholders",
+			content:      "Summary:\n\nTesting:\n",
+			expectedText: "Summary:\n\nTesting:",
+		},
+		{
+			name:      "Only comments",
+			content:   "# Write a good message\n# Keep it short\n",
+			expectNil: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commentChar := tt.commentChar
+			if commentChar == "" {
+				commentChar = "#"
+			}
+			template := ParseCommitTemplate(tt.content, commentChar)
+			if tt.expectNil {
+				if template != nil {
+					t.Fatalf("expected no template, got %+v", template)
+				}
+				return
+			}
+			if template == nil {
+				t.Fatal("expected a template")
+			}
+			if template.Text != tt.expectedText {
+				t.Errorf("expected text %q, got %q", tt.expectedText, template.Text)
+			}
+			if !reflect.DeepEqual(template.Placeholders, tt.expectedPlaceholders) {
+				t.Errorf("expected placeholders %q, got %q", tt.expectedPlaceholders, template.Placeholders)
+			}
+		})
+	}
+}
+
+func TestCommitTemplate_Fill(t *testing.T) {
+	template := ParseCommitTemplate("[TICKET] <subject>\n\nRefs: {ticket}\n", "#")
+	template.Fill("PROJ-42", "TICKET", "ISSUE")
+
+	if expected := "PROJ-42 <subject>\n\nRefs: PROJ-42"; template.Text != expected {
+		t.Errorf("expected %q, got %q", expected, template.Text)
+	}
+	if expected := []string{"<subject>"}; !reflect.DeepEqual(template.Placeholders, expected) {
+		t.Errorf("expected placeholders %q, got %q", expected, template.Placeholders)
+	}
+}
+
+func TestBuildPrompt_Template(t *testing.T) {
+	client := &OllamaClient{}
+
+	prompt := client.buildPrompt(CommitRequest{Diff: "diff", Template: ParseCommitTemplate("PROJ-42 <type>: <subject>\n", "#")})
+	for _, want := range []string{
+		"=== COMMIT TEMPLATE ===",
+		"replace each placeholder (<type>, <subject>)",
+		"\n\nPROJ-42 <type>: <subject>\n",
+	} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("expected prompt to contain %q, got:\n%s", want, prompt)
+		}
+	}
+
+	if prompt := client.buildPrompt(CommitRequest{Diff: "diff"}); strings.Contains(prompt, "COMMIT TEMPLATE") {
+		t.Errorf("expected no template section without a template, got:\n%s", prompt)
+	}
+}
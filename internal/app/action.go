@@ -0,0 +1,235 @@
+package app
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"ai-commit-message-generator/internal/github"
+)
+
+// actionCommentMarker identifies the sticky comment the action command
+// updates on reruns
+const actionCommentMarker = "<!-- generate-commit:suggested-message -->"
+
+// ActionOptions controls Action
+type ActionOptions struct {
+	// Base is the revision the pull request is diffed against. Empty means
+	// origin/$GITHUB_BASE_REF.
+	Base string
+	// Comment posts the message as a pull request comment, or updates the
+	// one an earlier run posted
+	Comment bool
+	// Strict fails the step when the message cannot be generated or
+	// published. Otherwise the failure is reported as a workflow warning.
+	Strict bool
+	// Getenv reads the GITHUB_* variables of the run. Nil means os.Getenv.
+	Getenv func(string) string
+}
+
+// Action suggests a squash-merge commit message for a pull request in a
+// GitHub Actions job: it describes the diff between the base branch and
+// HEAD, prints it, sets the message and split step outputs, adds it to the
+// job summary and, with Comment, to the pull request.
+func (a *App) Action(opts ActionOptions) error {
+	if opts.Getenv == nil {
+		opts.Getenv = os.Getenv
+	}
+	err := a.runAction(opts)
+	if err == nil || opts.Strict {
+		return err
+	}
+	// A suggestion is not worth a red check
+	PrintWorkflowWarning(err)
+	return nil
+}
+
+// PrintWorkflowWarning reports err as a warning annotation of the GitHub
+// Actions run
+func PrintWorkflowWarning(err error) {
+	fmt.Printf("::warning title=generate-commit::%s\n", escapeWorkflowCommand(err.Error()))
+}
+
+func (a *App) runAction(opts ActionOptions) error {
+	isRepo, err := a.Git.IsInsideRepo()
+	if err != nil {
+		return fmt.Errorf("failed to check repository status: %w", err)
+	}
+	if !isRepo {
+		return fmt.Errorf("%w; check the repository out with actions/checkout first", ErrNotARepo)
+	}
+
+	base := opts.Base
+	if base == "" {
+		baseRef := opts.Getenv("GITHUB_BASE_REF")
+		if baseRef == "" {
+			return errors.New("GITHUB_BASE_REF is not set; run on pull_request events or pass --base")
+		}
+		base = "origin/" + baseRef
+	}
+
+	defer a.Progress.Stop()
+	a.Progress.Phase(PhaseReadingDiff)
+	diff, err := a.Git.GetBranchDiff(base)
+	if err != nil {
+		return fmt.Errorf("failed to get the pull request diff (check out with fetch-depth: 0 so %s is there): %w", base, err)
+	}
+	if diff == "" {
+		return fmt.Errorf("no changes between %s and HEAD", base)
+	}
+
+	generated, err := a.Generate(diff)
+	a.Progress.Stop()
+	if err != nil {
+		return err
+	}
+	fmt.Println(generated.Message)
+
+	if path := opts.Getenv("GITHUB_OUTPUT"); path != "" {
+		if err := appendFile(path, actionOutputs(generated)); err != nil {
+			return fmt.Errorf("failed to set the step outputs: %w", err)
+		}
+	}
+	if path := opts.Getenv("GITHUB_STEP_SUMMARY"); path != "" {
+		if err := appendFile(path, actionMarkdown(generated)); err != nil {
+			return fmt.Errorf("failed to write the job summary: %w", err)
+		}
+	}
+	if opts.Comment {
+		return a.postActionComment(opts.Getenv, generated)
+	}
+	return nil
+}
+
+// postActionComment adds the message to the pull request as its sticky
+// comment
+func (a *App) postActionComment(getenv func(string) string, generated *Generated) error {
+	number, err := pullRequestNumber(getenv)
+	if err != nil {
+		return err
+	}
+	token := getenv("GITHUB_TOKEN")
+	if token == "" {
+		return errors.New("GITHUB_TOKEN is not set; pass it in the step's env to comment")
+	}
+	repository := getenv("GITHUB_REPOSITORY")
+	if repository == "" {
+		return errors.New("GITHUB_REPOSITORY is not set")
+	}
+
+	client := &github.Client{APIURL: getenv("GITHUB_API_URL"), Token: token, Repository: repository}
+	updated, err := client.UpsertComment(number, actionCommentMarker, actionCommentMarker+"\n"+actionMarkdown(generated))
+	if err != nil {
+		return fmt.Errorf("failed to comment on pull request #%d: %w", number, err)
+	}
+	if !a.Quiet {
+		verb := "Commented on"
+		if updated {
+			verb = "Updated the comment on"
+		}
+		fmt.Fprintf(os.Stderr, "%s pull request #%d\n", verb, number)
+	}
+	return nil
+}
+
+// pullRefPattern matches the ref of a pull_request run, refs/pull/42/merge
+var pullRefPattern = regexp.MustCompile(`^refs/pull/(\d+)/`)
+
+// pullRequestNumber returns the number of the pull request the run is for,
+// from the event payload or else from GITHUB_REF
+func pullRequestNumber(getenv func(string) string) (int, error) {
+	if path := getenv("GITHUB_EVENT_PATH"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return 0, fmt.Errorf("failed to read the event payload: %w", err)
+		}
+		var event struct {
+			PullRequest struct {
+				Number int `json:"number"`
+			} `json:"pull_request"`
+		}
+		if err := json.Unmarshal(data, &event); err != nil {
+			return 0, fmt.Errorf("failed to parse the event payload: %w", err)
+		}
+		if event.PullRequest.Number > 0 {
+			return event.PullRequest.Number, nil
+		}
+	}
+	if match := pullRefPattern.FindStringSubmatch(getenv("GITHUB_REF")); match != nil {
+		return strconv.Atoi(match[1])
+	}
+	return 0, errors.New("the run is not for a pull request; comment only on pull_request events")
+}
+
+// actionOutputs returns the message and split step outputs in the
+// GITHUB_OUTPUT format, the message between delimiters since it has
+// several lines
+func actionOutputs(generated *Generated) string {
+	delimiter := "EOF_" + randomHex(8)
+	for strings.Contains(generated.Message, delimiter) {
+		delimiter = "EOF_" + randomHex(8)
+	}
+	return fmt.Sprintf("message<<%s\n%s\n%s\nsplit=%t\n", delimiter, generated.Message, delimiter, generated.Split)
+}
+
+// actionMarkdown is the job summary and comment for generated
+func actionMarkdown(generated *Generated) string {
+	heading := "### Suggested squash-merge commit message"
+	if generated.Split {
+		heading = "### The changes could be split into several commits"
+	}
+	fence := markdownFence(generated.Message)
+	return fmt.Sprintf("%s\n\n%s\n%s\n%s\n", heading, fence, generated.Message, fence)
+}
+
+// markdownFence returns a code fence longer than any run of backticks in
+// text, so text cannot close it
+func markdownFence(text string) string {
+	longest, run := 0, 0
+	for _, r := range text {
+		if r == '`' {
+			run++
+			if run > longest {
+				longest = run
+			}
+		} else {
+			run = 0
+		}
+	}
+	if longest < 3 {
+		return "```"
+	}
+	return strings.Repeat("`", longest+1)
+}
+
+// escapeWorkflowCommand escapes a workflow command's message, which must
+// stay on one line
+func escapeWorkflowCommand(message string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(message)
+}
+
+// appendFile appends content to the file at path, which the runner created
+func appendFile(path, content string) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// randomHex returns n random bytes as hex
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
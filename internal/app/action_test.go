@@ -0,0 +1,201 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"ai-commit-message-generator/internal/ai"
+)
+
+// newActionApp returns an app whose branch diff is diff and whose model
+// answers response
+func newActionApp(t *testing.T, diff, response string, aiErr error) *App {
+	t.Helper()
+	mockGit := &MockGit{
+		IsInsideRepoFunc: func() (bool, error) { return true, nil },
+		GetBranchDiffFunc: func(base string) (string, error) {
+			if base != "origin/main" {
+				t.Errorf("expected the diff against origin/main, got %s", base)
+			}
+			return diff, nil
+		},
+	}
+	mockAI := &MockAI{
+		GenerateCommitMessageFunc: func(req ai.CommitRequest) (string, error) {
+			return response, aiErr
+		},
+	}
+	application := NewApp(mockGit, &MockConfig{LoadRulesFunc: func() (string, error) { return "", nil }}, nil, mockAI)
+	application.Quiet = true
+	return application
+}
+
+// actionEnv returns the variables of a pull_request run with the step
+// output and summary files in a temporary directory
+func actionEnv(t *testing.T, apiURL string) map[string]string {
+	t.Helper()
+	dir := t.TempDir()
+	event := filepath.Join(dir, "event.json")
+	if err := os.WriteFile(event, []byte(`{"action": "synchronize", "pull_request": {"number": 7}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return map[string]string{
+		"GITHUB_BASE_REF":     "main",
+		"GITHUB_REPOSITORY":   "octo/repo",
+		"GITHUB_EVENT_PATH":   event,
+		"GITHUB_TOKEN":        "secret",
+		"GITHUB_API_URL":      apiURL,
+		"GITHUB_OUTPUT":       filepath.Join(dir, "output"),
+		"GITHUB_STEP_SUMMARY": filepath.Join(dir, "summary"),
+	}
+}
+
+func TestApp_Action_Outputs(t *testing.T) {
+	application := newActionApp(t, "diff --git a/login.go b/login.go\n+func Login() {}\n", "feat(auth): add login\n\nAdds a login form.", nil)
+	env := actionEnv(t, "")
+
+	stdout := captureStdout(t, func() {
+		if err := application.Action(ActionOptions{Getenv: func(key string) string { return env[key] }}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	if !strings.Contains(stdout, "feat(auth): add login\n") {
+		t.Errorf("expected the message in the log, got:\n%s", stdout)
+	}
+
+	output, err := os.ReadFile(env["GITHUB_OUTPUT"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	pattern := regexp.MustCompile(`^message<<(EOF_[0-9a-f]+)\nfeat\(auth\): add login\n\nAdds a login form.\n(EOF_[0-9a-f]+)\nsplit=false\n$`)
+	match := pattern.FindStringSubmatch(string(output))
+	if match == nil || match[1] != match[2] {
+		t.Errorf("unexpected step outputs:\n%s", output)
+	}
+
+	summary, err := os.ReadFile(env["GITHUB_STEP_SUMMARY"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "### Suggested squash-merge commit message\n\n```\nfeat(auth): add login\n\nAdds a login form.\n```\n"
+	if string(summary) != expected {
+		t.Errorf("expected the summary:\n%s\ngot:\n%s", expected, summary)
+	}
+}
+
+func TestApp_Action_StickyComment(t *testing.T) {
+	var mu sync.Mutex
+	var comments []map[string]interface{}
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("expected the token, got %q", r.Header.Get("Authorization"))
+		}
+		var payload map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&payload)
+		switch r.Method {
+		case http.MethodGet:
+			json.NewEncoder(w).Encode(comments)
+		case http.MethodPost:
+			payload["id"] = 99
+			comments = append(comments, payload)
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(payload)
+		case http.MethodPatch:
+			comments[0]["body"] = payload["body"]
+			json.NewEncoder(w).Encode(comments[0])
+		}
+	}))
+	defer server.Close()
+
+	for _, response := range []string{"feat(auth): add login", "feat(auth): add login form"} {
+		application := newActionApp(t, "diff --git a/login.go b/login.go\n+func Login() {}\n", response, nil)
+		env := actionEnv(t, server.URL)
+		captureStdout(t, func() {
+			if err := application.Action(ActionOptions{Comment: true, Strict: true, Getenv: func(key string) string { return env[key] }}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+
+	expectedRequests := []string{
+		"GET /repos/octo/repo/issues/7/comments",
+		"POST /repos/octo/repo/issues/7/comments",
+		"GET /repos/octo/repo/issues/7/comments",
+		"PATCH /repos/octo/repo/issues/comments/99",
+	}
+	if strings.Join(requests, "\n") != strings.Join(expectedRequests, "\n") {
+		t.Errorf("expected requests:\n%s\ngot:\n%s", strings.Join(expectedRequests, "\n"), strings.Join(requests, "\n"))
+	}
+	if len(comments) != 1 {
+		t.Fatalf("expected one comment, got %d", len(comments))
+	}
+	body := comments[0]["body"].(string)
+	if !strings.HasPrefix(body, actionCommentMarker+"\n") || !strings.Contains(body, "feat(auth): add login form") {
+		t.Errorf("expected the updated comment, got:\n%s", body)
+	}
+}
+
+func TestApp_Action_Failures(t *testing.T) {
+	tests := []struct {
+		name        string
+		diff        string
+		aiErr       error
+		env         map[string]string
+		comment     bool
+		expectError string
+	}{
+		{name: "No changes", expectError: "no changes between origin/main and HEAD"},
+		{name: "Model unavailable", diff: "diff --git a/a.go b/a.go\n+x\n", aiErr: errors.New("connection refused"), expectError: "connection refused"},
+		{name: "Not a pull request", diff: "diff --git a/a.go b/a.go\n+x\n", env: map[string]string{"GITHUB_EVENT_PATH": "", "GITHUB_BASE_REF": "main", "GITHUB_REF": "refs/heads/main"}, comment: true, expectError: "not for a pull request"},
+		{name: "No token", diff: "diff --git a/a.go b/a.go\n+x\n", env: map[string]string{"GITHUB_BASE_REF": "main", "GITHUB_REF": "refs/pull/7/merge"}, comment: true, expectError: "GITHUB_TOKEN is not set"},
+	}
+
+	for _, tt := range tests {
+		for _, strict := range []bool{false, true} {
+			t.Run(tt.name, func(t *testing.T) {
+				application := newActionApp(t, tt.diff, "feat: x", tt.aiErr)
+				env := tt.env
+				if env == nil {
+					env = actionEnv(t, "")
+				}
+				opts := ActionOptions{Comment: tt.comment, Strict: strict, Getenv: func(key string) string { return env[key] }}
+
+				var err error
+				stdout := captureStdout(t, func() {
+					err = application.Action(opts)
+				})
+				if strict {
+					if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+						t.Errorf("expected error containing %q, got %v", tt.expectError, err)
+					}
+					return
+				}
+				if err != nil {
+					t.Errorf("expected only a warning without --strict, got %v", err)
+				}
+				if !strings.Contains(stdout, "::warning title=generate-commit::") || !strings.Contains(stdout, tt.expectError) {
+					t.Errorf("expected a warning containing %q, got:\n%s", tt.expectError, stdout)
+				}
+			})
+		}
+	}
+}
+
+func TestActionMarkdown_Fence(t *testing.T) {
+	got := actionMarkdown(&Generated{Message: "docs: show ```go blocks"})
+	if !strings.Contains(got, "\n````\ndocs: show ```go blocks\n````\n") {
+		t.Errorf("expected a longer fence, got:\n%s", got)
+	}
+}
@@ -3,63 +3,449 @@ package app
 import (
 	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
-	"runtime"
+	"regexp"
 	"strings"
+	"time"
 
 	"ai-commit-message-generator/internal/ai"
 	"ai-commit-message-generator/internal/config"
 	"ai-commit-message-generator/internal/git"
+	"ai-commit-message-generator/internal/logging"
 )
 
+// ErrHookCommitted is returned by PreCommitHook after it created the commit
+// itself, so the hook exits non-zero and git abandons its own commit
+var ErrHookCommitted = errors.New("commit created by generate-commit; the original commit was cancelled")
+
 // App is the main application struct
 type App struct {
-	Git          git.Client
-	RulesLoader  config.Loader
-	ConfigLoader *config.ConfigLoader
-	AI           ai.Client
+	Git         git.Client
+	RulesLoader config.Loader
+	ConfigStore config.Store
+	AI          ai.Client
+
+	// Terminal is used by the interactive loop. Without one, or when it is
+	// not a TTY, nothing prompts and RunOptions.Interactive falls back to
+	// printing the message.
+	Terminal *Terminal
+	// Editor opens a file in the user's editor. Defaults to $EDITOR.
+	Editor func(path string) error
+	// Clipboard places text on the system clipboard and returns the
+	// mechanism it used, e.g. pbcopy
+	Clipboard func(text string) (string, error)
+	// TestFiles decides which staged files are tests and how they affect
+	// the commit type
+	TestFiles ai.TestFileOptions
+	// Progress shows the generation phases on stderr. Nil shows nothing.
+	Progress *Progress
+	// Logger receives the warnings and, at debug level, how long each
+	// stage took. Nil logs the warnings to stderr as text.
+	Logger *slog.Logger
+	// Quiet drops the "Generating commit message..." status lines so only
+	// the result is printed
+	Quiet bool
+	// StdinFilter holds the --only/--ignore filters for a diff read from
+	// stdin; staged diffs are filtered by the git client
+	StdinFilter git.DiffOptions
+	// FastPath recognizes docs-, config- and dependency-only changes, which
+	// get a fixed type and a shorter prompt
+	FastPath ai.FastPathOptions
+	// ProtectedBranches are the branch globs whose commits Reword refuses
+	// to rewrite without Force. Empty means main and master.
+	ProtectedBranches []string
+	// MaxBodyLength caps the characters of a generated body; 0 means no
+	// limit. BodyOverflow, BodyOverflowTruncate or BodyOverflowRegenerate,
+	// decides what happens to a longer one.
+	MaxBodyLength int
+	BodyOverflow  string
+	// BranchPattern is the pattern Branch renders names with, e.g.
+	// {type}/{ticket}-{slug}. Empty means DefaultBranchPattern.
+	BranchPattern string
+	// AnalyzeGo names the Go declarations the staged changes add or modify
+	// in the prompt
+	AnalyzeGo bool
+	// PrependDiffStat puts a per-file count of added and removed lines
+	// above the diff, so the model can weigh the files by size
+	PrependDiffStat bool
+	// Signoff appends a Signed-off-by trailer with the git identity to
+	// every generated message
+	Signoff bool
+	// IncludeDiffDigest appends an X-Diff-SHA256 trailer with the hash of
+	// the diff in the prompt, so a message can be traced to its change
+	IncludeDiffDigest bool
+	// Examples are messages the team wrote before, shown to the model as
+	// demonstrations of their style
+	Examples []ai.Example
+	// CoAuthors are "Name <email>" identities appended as Co-authored-by
+	// trailers
+	CoAuthors []string
+	// AddressBook maps the shortcuts AddCoAuthors accepts to identities
+	AddressBook map[string]string
+	// Trailers are further "Key: value" trailers appended to every
+	// generated message
+	Trailers []string
+	// ScopeMap maps path prefixes to the scope changes under them get,
+	// e.g. "internal/ai" to "ai"
+	ScopeMap map[string]string
+	// NoSplit turns split suggestions off: the prompt asks for a single
+	// message and no response is taken for a suggestion
+	NoSplit bool
+	// MergeExistingMessage has the prepare-commit-msg hook extend a
+	// message the user already started in the message file instead of
+	// replacing it
+	MergeExistingMessage bool
+	// HeaderFormat is the house layout of the first line. Nil means
+	// Conventional Commits.
+	HeaderFormat *ai.HeaderFormat
+	// ScopePolicy is ai.ScopeRequired or ai.ScopeForbidden to enforce a
+	// scope on every message or none; "" leaves it to the model
+	ScopePolicy string
+	// SubjectStyle is the case and final period enforced on the
+	// description in the first line
+	SubjectStyle ai.SubjectStyle
+	// Types are the commit types messages may use; empty means the
+	// Conventional Commits types
+	Types []string
+	// Scopes are the only scopes messages may use; empty means any
+	Scopes []string
+	// PathOverrides replace HeaderFormat, ScopePolicy, SubjectStyle and
+	// the rules for changes that all fall under one of their patterns
+	PathOverrides []PathOverride
+	// CheckUpdates asks GitHub for the latest release and prints a notice
+	// when it is newer than this build
+	CheckUpdates bool
+}
+
+// RunOptions controls a single generation run
+type RunOptions struct {
+	// Interactive presents the accept/edit/regenerate loop after generation
+	Interactive bool
+	// Refine holds revision instructions applied in order to the generated
+	// message, e.g. "make it shorter"
+	Refine []string
+	// Preview prints the commit that would be created instead of the bare
+	// message. Nothing is committed.
+	Preview bool
+	// Yes commits the generated message without prompting
+	Yes bool
+	// Stdin, when set, is read for the diff to describe instead of the
+	// staged changes. No git repository is needed.
+	Stdin io.Reader
+	// All describes every change to tracked files in the working tree
+	// against HEAD, staged or not
+	All bool
+	// IncludeUntracked adds untracked files to the All diff. Files matched
+	// by .gitignore are never included.
+	IncludeUntracked bool
+	// Add stages what All describes right before committing. It requires
+	// All and a mode that commits.
+	Add bool
+	// ShowFiles prints the files that will be committed, with their change
+	// type, above the message. Interactive runs always show them.
+	ShowFiles bool
+	// Explain asks the model why it chose the type and scope and prints
+	// the answer, dimmed, below the message. It is never committed.
+	Explain bool
+	// Copy places the printed message on the clipboard. Interactive runs
+	// offer a copy choice instead.
+	Copy bool
+	// IncludeNew also describes the untracked files, as additions, without
+	// staging them. They are not committed unless they are staged.
+	IncludeNew bool
+	// NoTrailingNewline leaves out the newline after the printed message,
+	// for shell composition
+	NoTrailingNewline bool
 }
 
 // NewApp creates a new App
-func NewApp(gitClient git.Client, rulesLoader config.Loader, configLoader *config.ConfigLoader, aiClient ai.Client) *App {
+func NewApp(gitClient git.Client, rulesLoader config.Loader, configStore config.Store, aiClient ai.Client) *App {
 	return &App{
-		Git:          gitClient,
-		RulesLoader:  rulesLoader,
-		ConfigLoader: configLoader,
-		AI:           aiClient,
+		Git:         gitClient,
+		RulesLoader: rulesLoader,
+		ConfigStore: configStore,
+		AI:          aiClient,
+		Clipboard:   copyToClipboard,
 	}
 }
 
-// Run executes the main logic
-func (a *App) Run() error {
-	// 1. Pre-flight Checks
-	isRepo, err := a.Git.IsInsideRepo()
+// Run executes the main logic. Its errors wrap the ones in errors.go where
+// they apply, so ExitCode can tell them apart.
+func (a *App) Run(opts RunOptions) error {
+	if err := validateRunOptions(opts); err != nil {
+		return withKind(ErrValidationFailed, err)
+	}
+	if skipRequested() {
+		printSkipped(SkipEnv + " is set")
+		return nil
+	}
+	if opts.Interactive && !opts.Yes && !a.canPrompt() {
+		fmt.Fprintln(os.Stderr, "No terminal to prompt on; printing the message instead. Pass --yes to commit it.")
+		opts.Interactive = false
+	}
+
+	defer a.Progress.Stop()
+	a.Progress.Phase(PhaseReadingDiff)
+	var req ai.CommitRequest
+	var err error
+	if opts.Stdin != nil {
+		req, err = a.prepareStdinRequest(opts.Stdin)
+	} else {
+		req, err = a.prepareRequest(opts)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to check repository status: %w", err)
+		return err
 	}
-	if !isRepo {
-		return errors.New("not a git repository")
+	req.Explain = opts.Explain
+
+	a.status("Generating commit message...")
+
+	// 5. AI Integration (with git state context)
+	message, rationale, err := a.generateWithRationale(req)
+	if err != nil {
+		return fmt.Errorf("failed to generate commit message: %w", classifyAIError(err))
+	}
+	if opts.Stdin == nil {
+		a.remember(req.Diff, message)
+	}
+
+	history := []string{message}
+	for _, instruction := range opts.Refine {
+		a.status("Refining commit message...")
+		message, err = a.refine(req, message, instruction)
+		if err != nil {
+			return fmt.Errorf("failed to refine commit message: %w", classifyAIError(err))
+		}
+		if opts.Stdin == nil {
+			a.remember(req.Diff, message)
+		}
+		history = append(history, message)
+	}
+
+	// 6. Output
+	if opts.Interactive && !opts.Yes {
+		a.showFiles(a.Terminal.Out, opts)
+		showRationale(a.Terminal.Out, rationale)
+		return a.interact(req, history, opts)
+	}
+	if opts.Preview {
+		if err := a.preview(message, opts); err != nil {
+			return err
+		}
+		showRationale(os.Stdout, rationale)
+		a.copyMessage(message, opts)
+		return nil
+	}
+	if opts.ShowFiles {
+		a.showFiles(os.Stdout, opts)
+	}
+
+	if a.suggestsSplit(message) {
+		// Output split suggestion in Yellow
+		fmt.Println("\n\033[33mAI Suggestion (Split Changes):\033[0m")
+		fmt.Println(message)
+		if !opts.All {
+			fmt.Println("\nRun 'generate-commit split' to commit the staged files in these groups.")
+		}
+	} else {
+		a.printMessage(message, opts)
+	}
+	showRationale(os.Stdout, rationale)
+	a.copyMessage(message, opts)
+
+	if opts.Yes {
+		if a.suggestsSplit(message) {
+			return errors.New("the model suggested splitting the changes; nothing was committed. Run 'generate-commit split --yes' to commit them in groups")
+		}
+		if err := a.commit(message, opts); err != nil {
+			return err
+		}
+		fmt.Println("\033[32m✓ Committed\033[0m")
+	}
+	return nil
+}
+
+// Generated is a message Generate produced
+type Generated struct {
+	Message string `json:"message"`
+	// Split is set when the message is a suggestion to split the changes
+	// rather than a commit message
+	Split bool `json:"split"`
+}
+
+// Generate writes a commit message without printing or committing it, for
+// callers such as the editor server. A non-empty diff is described like
+// one read with --stdin; otherwise the staged changes are, as Run does.
+func (a *App) Generate(diff string) (*Generated, error) {
+	var req ai.CommitRequest
+	var err error
+	if diff != "" {
+		req, err = a.prepareStdinRequest(strings.NewReader(diff))
+	} else {
+		req, err = a.prepareRequest(RunOptions{})
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	hasChanges, err := a.Git.HasStagedChanges()
+	message, err := a.generateMessage(req)
 	if err != nil {
-		return fmt.Errorf("failed to check for staged changes: %w", err)
+		return nil, fmt.Errorf("failed to generate commit message: %w", classifyAIError(err))
 	}
-	if !hasChanges {
-		return errors.New("no staged changes found. Please stage your changes using 'git add'")
+	if diff == "" {
+		a.remember(req.Diff, message)
 	}
+	return &Generated{Message: message, Split: a.suggestsSplit(message)}, nil
+}
 
-	// 2. Custom Rule Injection
-	rules, err := a.RulesLoader.LoadRules()
+// validateRunOptions rejects options that cannot be combined
+func validateRunOptions(opts RunOptions) error {
+	if opts.Interactive && opts.Preview {
+		return errors.New("--preview cannot be combined with --interactive")
+	}
+	if opts.Yes && opts.Preview {
+		return errors.New("--preview cannot be combined with --yes")
+	}
+	if opts.Stdin != nil && (opts.Interactive || opts.Yes || opts.Preview) {
+		return errors.New("--stdin cannot be combined with --interactive, --yes or --preview; there is nothing staged to commit")
+	}
+	if opts.NoTrailingNewline && (opts.Interactive || opts.Preview || opts.Yes || opts.Explain) {
+		return errors.New("--no-trailing-newline cannot be combined with --interactive, --preview, --yes or --explain; the message must be the last thing printed")
+	}
+	if opts.Stdin != nil && opts.ShowFiles {
+		return errors.New("--show-files cannot be combined with --stdin; there are no staged files to list")
+	}
+	if opts.Copy && opts.Interactive {
+		return errors.New("--copy cannot be combined with --interactive; choose [C]opy in the prompt instead")
+	}
+	return validateWorktreeOptions(opts)
+}
+
+// printMessage prints the commit message: in cyan after a blank line, or
+// with Quiet as the bare message so pipes get exactly its bytes. It ends in
+// a single newline unless opts.NoTrailingNewline is set.
+func (a *App) printMessage(message string, opts RunOptions) {
+	message = strings.TrimRight(message, "\n")
+	if !a.Quiet {
+		// Output commit message in Cyan (can be multi-line)
+		message = "\n\033[36m" + message + "\033[0m"
+	}
+	if !opts.NoTrailingNewline {
+		message += "\n"
+	}
+	fmt.Print(message)
+}
+
+// copyMessage places message on the clipboard when opts.Copy is set. The
+// message has been printed already, so a failure is only a warning.
+func (a *App) copyMessage(message string, opts RunOptions) {
+	if !opts.Copy || a.suggestsSplit(message) {
+		return
+	}
+	if a.Clipboard == nil {
+		fmt.Fprintln(os.Stderr, "Warning: no clipboard available")
+		return
+	}
+	mechanism, err := a.Clipboard(a.finalizeMessage(message))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to copy to clipboard: %v\n", err)
+		return
+	}
+	if !a.Quiet {
+		fmt.Fprintf(os.Stderr, "\033[32m✓ Copied to clipboard with %s\033[0m\n", mechanism)
+	}
+}
+
+// validateWorktreeOptions checks the All, IncludeUntracked and Add options.
+// A message describing unstaged changes is only committed once Add has
+// staged them, so committing modes require Add.
+func validateWorktreeOptions(opts RunOptions) error {
+	switch {
+	case !opts.All && (opts.IncludeUntracked || opts.Add):
+		return errors.New("--include-untracked and --add require --all")
+	case opts.All && opts.Stdin != nil:
+		return errors.New("--all cannot be combined with --stdin")
+	case opts.IncludeNew && opts.All:
+		return errors.New("--include-new cannot be combined with --all; use --include-untracked to describe untracked files with --all")
+	case opts.IncludeNew && opts.Stdin != nil:
+		return errors.New("--include-new cannot be combined with --stdin")
+	case opts.Add && !opts.Yes && !opts.Interactive && !opts.Preview:
+		return errors.New("--add stages changes before committing; combine it with --yes or --interactive")
+	case opts.All && !opts.Add && (opts.Yes || opts.Interactive || opts.Preview):
+		return errors.New("--all describes unstaged changes; pass --add to stage them before committing")
+	}
+	return nil
+}
+
+// commit stages the described changes when opts.Add is set and commits
+// message
+func (a *App) commit(message string, opts RunOptions) error {
+	if opts.Add {
+		if err := a.Git.StageAll(opts.IncludeUntracked); err != nil {
+			return fmt.Errorf("failed to stage changes: %w", err)
+		}
+	}
+	start := time.Now()
+	err := a.Git.CommitWithMessage(a.finalizeMessage(message))
+	a.logStage("commit", start, err)
 	if err != nil {
-		fmt.Printf("Warning: failed to load rules: %v. Proceeding without rules.\n", err)
+		return fmt.Errorf("failed to commit: %w", err)
+	}
+	return nil
+}
+
+// changedFiles lists the files the message describes: the staged ones, or
+// with opts.All every changed file in the working tree
+func (a *App) changedFiles(opts RunOptions) ([]git.StagedFile, error) {
+	if opts.All {
+		return a.Git.GetWorktreeFiles(opts.IncludeUntracked)
 	}
+	return a.Git.GetStagedFiles()
+}
 
-	// 3. Detect Git State (merge, rebase, cherry-pick)
+// filePaths returns the paths of files
+func filePaths(files []git.StagedFile) []string {
+	paths := make([]string, 0, len(files))
+	for _, file := range files {
+		paths = append(paths, file.Path)
+	}
+	return paths
+}
+
+// prepareRequest runs the pre-flight checks and gathers everything the
+// commit message prompt is built from. Only All, IncludeUntracked and the
+// path filters decide which changes are read.
+func (a *App) prepareRequest(opts RunOptions) (ai.CommitRequest, error) {
+	// 1. Pre-flight Checks
+	isRepo, err := a.Git.IsInsideRepo()
+	if err != nil {
+		return ai.CommitRequest{}, fmt.Errorf("failed to check repository status: %w", err)
+	}
+	if !isRepo {
+		return ai.CommitRequest{}, ErrNotARepo
+	}
+
+	// With All the staging area does not matter; the diff tells
+	if !opts.All {
+		hasChanges, err := a.Git.HasStagedChanges()
+		if err != nil {
+			return ai.CommitRequest{}, fmt.Errorf("failed to check for staged changes: %w", err)
+		}
+		switch {
+		case !hasChanges && opts.IncludeNew && (opts.Yes || opts.Interactive || opts.Preview):
+			return ai.CommitRequest{}, withKind(ErrNoStagedChanges, errors.New("nothing is staged to commit; --include-new only describes untracked files. Stage them with 'git add' first"))
+		case !hasChanges && !opts.IncludeNew:
+			return ai.CommitRequest{}, fmt.Errorf("%w. Please stage your changes using 'git add', or describe unstaged changes with --all", ErrNoStagedChanges)
+		}
+	}
+
+	// 2. Detect Git State (merge, rebase, cherry-pick)
 	gitState, err := a.Git.DetectState()
 	if err != nil {
-		fmt.Printf("Warning: failed to detect git state: %v. Proceeding with normal state.\n", err)
+		a.log().Warn("failed to detect git state, proceeding with the normal state", logging.KeyStage, "diff", logging.KeyError, err)
 		gitState = &git.GitState{Type: git.StateNormal}
 	}
 
@@ -71,51 +457,352 @@ func (a *App) Run() error {
 		}
 		fmt.Fprintln(os.Stderr)
 	}
+	if gitState.InitialCommit {
+		a.status("No commits yet: this is the initial commit.")
+	}
 
-	// 4. Smart Diff Reading
-	diff, err := a.Git.GetStagedDiff()
+	// 3. Smart Diff Reading
+	var diff string
+	start := time.Now()
+	if opts.All {
+		diff, err = a.Git.GetWorktreeDiff(opts.IncludeUntracked)
+	} else {
+		diff, err = a.Git.GetStagedDiff()
+	}
+	a.logStage("diff", start, err)
 	if err != nil {
-		return fmt.Errorf("failed to get diff: %w", err)
+		return ai.CommitRequest{}, fmt.Errorf("failed to get diff: %w", err)
 	}
 
-	fmt.Println("Generating commit message...")
+	// Staged file metadata is a hint only, so failures are not fatal
+	var meta *ai.DiffMeta
+	files, filesErr := a.changedFiles(opts)
 
-	// 5. AI Integration (with git state context)
-	message, err := a.AI.GenerateCommitMessage(diff, rules, gitState)
+	if opts.IncludeNew {
+		newFiles, err := a.Git.GetNewFiles()
+		if err != nil {
+			return ai.CommitRequest{}, fmt.Errorf("failed to read untracked files: %w", err)
+		}
+		a.showNewFiles(newFiles)
+		if strings.TrimSpace(diff+newFiles.Diff) == "" {
+			return ai.CommitRequest{}, withKind(ErrNoStagedChanges, errors.New("no staged changes or untracked files found"))
+		}
+		if newFiles.Diff != "" {
+			if diff != "" {
+				diff = strings.TrimRight(diff, "\n") + "\n"
+			}
+			diff += newFiles.Diff
+			files = append(files, newFiles.Files...)
+		}
+	}
+
+	if strings.TrimSpace(diff) == "" {
+		if opts.All && filesErr == nil && len(files) == 0 {
+			if opts.IncludeUntracked {
+				return ai.CommitRequest{}, withKind(ErrNoStagedChanges, errors.New("no changes found: the working tree matches HEAD"))
+			}
+			return ai.CommitRequest{}, withKind(ErrNoStagedChanges, errors.New("no changes to tracked files found; pass --include-untracked to describe new files"))
+		}
+		// Otherwise only possible when path filters exclude every file
+		if opts.All {
+			return ai.CommitRequest{}, withKind(ErrNoStagedChanges, errors.New("no changes match the path filters"))
+		}
+		return ai.CommitRequest{}, withKind(ErrNoStagedChanges, errors.New("no staged changes match the path filters"))
+	}
+
+	// 4. Custom Rule Injection: the rules of the directories the changes
+	// are in apply too, or those of the area of paths they all fall under
+	override := a.pathOverride(files)
+	var req ai.CommitRequest
+	a.applyStyle(&req, override)
+	rules, err := a.loadRules(files, override)
 	if err != nil {
-		return fmt.Errorf("failed to generate commit message: %w", err)
+		a.log().Warn("failed to load rules, proceeding without them", logging.KeyStage, "rules", logging.KeyError, err)
 	}
 
-	// 6. Output
-	// Check if the response suggests splitting into multiple commits
+	template := a.commitTemplate()
+
+	var fastPath *ai.FastPath
+	var scope *ai.ScopeHint
+	if err := filesErr; err != nil {
+		a.log().Warn("failed to list staged files, proceeding without file context", logging.KeyStage, "diff", logging.KeyError, err)
+	} else {
+		meta = ai.NewDiffMeta(files, a.TestFiles)
+		if a.AnalyzeGo && !opts.All {
+			meta.ChangedSymbols = a.changedGoSymbols(files)
+		}
+		// A merge or rebase needs its own instructions, however small, and
+		// a template decides the layout of the message itself
+		if gitState.Type == git.StateNormal && template == nil {
+			fastPath = a.classifyChangeset(files, req)
+		}
+		// Merges, rebases and the fast path fix the scope themselves, and a
+		// forbidden scope needs no hint
+		if gitState.Type == git.StateNormal && fastPath == nil && req.ScopePolicy != ai.ScopeForbidden {
+			scope = ai.ScopeFromMap(files, diff, a.ScopeMap)
+		}
+	}
+
+	req.Diff = a.withDiffStat(diff)
+	req.Rules = rules
+	req.GitState = gitState
+	req.Meta = meta
+	req.FastPath = fastPath
+	req.Template = template
+	req.Scope = scope
+	return req, nil
+}
+
+// showNewFiles lists the untracked files --include-new describes on stderr,
+// so it is clear they are not part of the commit, and the ones it skipped
+func (a *App) showNewFiles(newFiles *git.NewFiles) {
+	if a.Quiet {
+		return
+	}
+	if len(newFiles.Files) > 0 {
+		fmt.Fprintf(os.Stderr, "\033[33mAlso describing %s. Untracked files are not committed unless you stage them with 'git add':\033[0m\n",
+			countOf(len(newFiles.Files), "untracked file"))
+		for _, f := range newFiles.Files {
+			fmt.Fprintf(os.Stderr, "  ? %s\n", f.Path)
+		}
+	}
+	for _, skipped := range newFiles.Skipped {
+		fmt.Fprintf(os.Stderr, "Skipped untracked file %s\n", skipped)
+	}
+}
+
+// withDiffStat puts the diff stat of diff above it when PrependDiffStat is
+// set. The counts come from the diff itself, so they match what the model
+// is sent.
+func (a *App) withDiffStat(diff string) string {
+	if !a.PrependDiffStat {
+		return diff
+	}
+	stat := git.FormatDiffStat(git.DiffStat(diff))
+	if stat == "" {
+		return diff
+	}
+	return stat + "\n" + diff
+}
+
+// changedGoSymbols lists the top-level Go declarations the staged files
+// add or modify. The symbols are a hint only, so a file that cannot be read
+// is skipped.
+func (a *App) changedGoSymbols(files []git.StagedFile) []string {
+	var symbols []string
+	seen := make(map[string]bool)
+	for _, f := range files {
+		if f.Excluded || f.Change == git.ChangeDeleted || !strings.HasSuffix(f.Path, ".go") {
+			continue
+		}
+		before, after, err := a.Git.GetFileVersions(f.Path)
+		if err != nil {
+			continue
+		}
+		for _, symbol := range ai.ChangedGoSymbols(before, after) {
+			if !seen[symbol] {
+				seen[symbol] = true
+				symbols = append(symbols, symbol)
+			}
+		}
+	}
+	return symbols
+}
+
+// ticketPattern matches an issue key such as PROJ-123 in a branch name
+var ticketPattern = regexp.MustCompile(`[A-Z][A-Z0-9]+-[0-9]+`)
+
+// ticketPlaceholders name the template placeholders filled with the ticket
+var ticketPlaceholders = []string{"TICKET", "TICKET_ID", "ISSUE", "ISSUE_ID", "JIRA"}
+
+// commitTemplate returns the repository's commit.template, if any, with
+// its ticket placeholders filled from the branch name. A template that
+// cannot be read is skipped with a warning.
+func (a *App) commitTemplate() *ai.CommitTemplate {
+	content, err := a.Git.GetCommitTemplate()
+	if err != nil {
+		a.log().Warn("proceeding without the commit template", logging.KeyStage, "template", logging.KeyError, err)
+		return nil
+	}
+	// With core.commentChar=auto git picks a character no template line
+	// starts with, so none of them is a comment
+	commentChar := a.commentChar()
+	if commentChar == git.CommentCharAuto {
+		commentChar = git.PickCommentChar(content)
+	}
+	template := ai.ParseCommitTemplate(content, commentChar)
+	if template == nil {
+		return nil
+	}
+	if branch, err := a.Git.GetCurrentBranch(); err == nil {
+		if ticket := ticketPattern.FindString(branch); ticket != "" {
+			template.Fill(ticket, ticketPlaceholders...)
+		}
+	}
+	return template
+}
+
+// classifyChangeset returns the fast path for files, if any, and says so.
+// There is none when its fixed header breaks the style of req.
+func (a *App) classifyChangeset(files []git.StagedFile, req ai.CommitRequest) *ai.FastPath {
+	fastPath := ai.ClassifyChangeset(files, a.FastPath)
+	if fastPath == nil || typeViolation(fastPath.Type, req) != "" {
+		return nil
+	}
+	if scopeViolation(fastPath.Scope, req) != "" {
+		fastPath.Scope = ""
+	}
+	switch {
+	case req.ScopePolicy == ai.ScopeRequired && fastPath.Scope == "":
+		// The docs header has no scope; the model has to pick one
+		return nil
+	case req.ScopePolicy == ai.ScopeForbidden:
+		fastPath.Scope = ""
+	}
+	a.status(fmt.Sprintf("Only %s changed; the message will use %s.", fastPath.Kind, fastPath.Header()))
+	return fastPath
+}
+
+// refine asks the model to revise message according to instruction
+func (a *App) refine(req ai.CommitRequest, message, instruction string) (string, error) {
+	req.PreviousMessage = message
+	req.Feedback = instruction
+	return a.generateMessage(req)
+}
+
+// generateMessage asks the model for a commit message and keeps its body
+// within MaxBodyLength. The progress line is cleared before returning so the
+// result is printed on a clean line.
+func (a *App) generateMessage(req ai.CommitRequest) (string, error) {
+	message, _, err := a.generateWithRationale(req)
+	return message, err
+}
+
+// generateWithRationale is generateMessage that also returns the model's
+// rationale when req.Explain is set. The rationale is cut off the message
+// and the trailers are appended to it.
+func (a *App) generateWithRationale(req ai.CommitRequest) (string, string, error) {
+	defer a.Progress.Stop()
+	req.NoSplit = a.NoSplit
+	req.Examples = a.Examples
+	start := time.Now()
+	response, err := a.AI.GenerateCommitMessage(req)
+	a.logStage("generate", start, err)
+	if err != nil {
+		return "", "", err
+	}
+	message, rationale := ai.SplitRationale(response)
+	if !a.suggestsSplit(message) {
+		// Models fall back to type(scope): when a house layout is asked for
+		message = req.HeaderFormat.Reformat(message)
+	}
+	message, err = a.applyScopePolicy(req, message)
+	if err != nil {
+		return "", "", err
+	}
+	message, err = a.enforceConventions(req, message)
+	if err != nil {
+		return "", "", err
+	}
+	if !a.suggestsSplit(message) {
+		message = req.HeaderFormat.WithSubjectStyle(message, req.SubjectStyle)
+	}
+	message, err = a.limitBody(req, message)
+	if err != nil {
+		return "", "", err
+	}
+	message, err = a.withTrailers(message, req.Diff)
+	if err != nil {
+		return "", "", err
+	}
+	return message, rationale, nil
+}
+
+// showRationale prints the model's rationale dimmed, if there is one
+func showRationale(w io.Writer, rationale string) {
+	if rationale != "" {
+		fmt.Fprintln(w, "\n\033[2mWhy: "+rationale+"\033[0m")
+	}
+}
+
+// status prints a progress message to stdout unless Quiet is set or the
+// progress line already shows what is happening
+func (a *App) status(message string) {
+	if !a.Quiet && !a.Progress.Enabled() {
+		fmt.Println(message)
+	}
+}
+
+// suggestsSplit reports whether message is a split suggestion rather than a
+// commit message. With NoSplit there are none, so a message that merely
+// mentions splitting is still committed.
+func (a *App) suggestsSplit(message string) bool {
+	return !a.NoSplit && isSplitSuggestion(message)
+}
+
+// isSplitSuggestion reports whether the response suggests splitting into
+// multiple commits rather than being a commit message
+func isSplitSuggestion(message string) bool {
 	// Look for explicit keywords that indicate the AI is suggesting a split
 	lowerMessage := strings.ToLower(message)
-	isSplitSuggestion := strings.Contains(lowerMessage, "split") ||
+	return strings.Contains(lowerMessage, "split") ||
 		strings.Contains(lowerMessage, "separate commit") ||
 		strings.Contains(lowerMessage, "multiple commit") ||
 		strings.Contains(lowerMessage, "should be committed separately")
-	
-	if isSplitSuggestion {
-		// Output split suggestion in Yellow
-		fmt.Println("\n\033[33mAI Suggestion (Split Changes):\033[0m")
-		fmt.Println(message)
-	} else {
-		// Output commit message in Cyan (can be multi-line)
-		fmt.Println("\n\033[36m" + message + "\033[0m")
-	}
+}
 
-	return nil
+// InitOptions controls repository initialization
+type InitOptions struct {
+	// Force reinitializes a repository that already has a config file
+	Force bool
+	// HookType selects which git hooks to install: a comma-separated list
+	// of pre-commit, prepare-commit-msg, commit-msg or both. Empty means
+	// pre-commit.
+	HookType string
+	// LintFix makes the commit-msg hook rewrite non-compliant messages
+	// instead of rejecting them
+	LintFix bool
+	// OnExisting decides what happens to hooks not written by init: abort
+	// (default), backup or chain
+	OnExisting string
+	// HookShell is the language the hooks are written in: sh, powershell
+	// or batch. Empty means batch on Windows and sh elsewhere.
+	HookShell string
+	// Wizard asks for the provider, model, key storage and hook type on the
+	// Terminal instead of writing the default config. The answer to the
+	// hook question replaces HookType.
+	Wizard bool
+	// Framework is FrameworkPreCommit, FrameworkHusky or FrameworkLefthook
+	// to add a prepare-commit-msg hook to that framework's configuration
+	// instead of installing the HookType hooks, or FrameworkAuto to pick the
+	// one the repository uses. Empty means the hooks are installed in the
+	// hooks directory.
+	Framework string
 }
 
-// Init initializes the repository with config, rules file, and pre-commit hook
-func (a *App) Init(force bool) error {
+// Init initializes the repository with config, rules file, and git hooks
+func (a *App) Init(opts InitOptions) error {
+	hookNames, err := hookNamesForType(opts.HookType)
+	if err != nil {
+		return err
+	}
+	if err := validateOnExisting(opts.OnExisting); err != nil {
+		return err
+	}
+	if err := validateFramework(opts.Framework); err != nil {
+		return err
+	}
+	if opts.HookShell, err = resolveHookShell(opts.HookShell); err != nil {
+		return err
+	}
+
 	// Check if we're in a git repo
 	isRepo, err := a.Git.IsInsideRepo()
 	if err != nil {
 		return fmt.Errorf("failed to check repository status: %w", err)
 	}
 	if !isRepo {
-		return errors.New("not a git repository. Please run this command from within a git repository")
+		return fmt.Errorf("%w. Please run this command from within a git repository", ErrNotARepo)
 	}
 
 	// Get repo root
@@ -123,10 +810,15 @@ func (a *App) Init(force bool) error {
 	if err != nil {
 		return fmt.Errorf("failed to get repository root: %w", err)
 	}
+	if opts.Framework == FrameworkAuto {
+		if opts.Framework, err = detectFramework(repoRoot); err != nil {
+			return err
+		}
+	}
 
 	// Check if already initialized
-	if !force {
-		configExists, err := a.ConfigLoader.ConfigExists()
+	if !opts.Force {
+		configExists, err := a.ConfigStore.ConfigExists()
 		if err != nil {
 			return fmt.Errorf("failed to check config existence: %w", err)
 		}
@@ -138,10 +830,44 @@ func (a *App) Init(force bool) error {
 		fmt.Println("Forcing reinitialization...")
 	}
 
+	var cfg *config.Config
+	if opts.Wizard {
+		if a.Terminal == nil {
+			return errors.New("the init wizard requires a terminal")
+		}
+		result, err := a.runInitWizard()
+		if err != nil {
+			return err
+		}
+		cfg = result.Config
+		opts.HookType = result.HookType
+		if hookNames, err = hookNamesForType(opts.HookType); err != nil {
+			return err
+		}
+	}
+
+	// Honors core.hooksPath, e.g. when husky manages the hooks
+	var hooksDir string
+	if opts.Framework == "" {
+		if hooksDir, err = a.Git.GetHooksDir(); err != nil {
+			return fmt.Errorf("failed to get hooks directory: %w", err)
+		}
+	}
+
+	// Refuse before writing anything rather than leave a half-initialized repo
+	if opts.Framework == "" && (opts.OnExisting == "" || opts.OnExisting == OnExistingAbort) {
+		if err := checkExistingHooks(hooksDir, hookNames, opts.HookShell); err != nil {
+			return err
+		}
+	}
+
 	fmt.Println("Initializing commit generator...")
 
 	// 1. Generate config file
-	if err := a.ConfigLoader.SaveDefaultConfig(repoRoot); err != nil {
+	if cfg == nil {
+		cfg = config.DefaultConfig()
+	}
+	if err := a.ConfigStore.SaveConfig(repoRoot, cfg); err != nil {
 		return fmt.Errorf("failed to create config file: %w", err)
 	}
 	fmt.Printf("✓ Created .commit-generator-config\n")
@@ -166,207 +892,30 @@ func (a *App) Init(force bool) error {
 		fmt.Printf("✓ Rules file already exists\n")
 	}
 
-	// 3. Generate pre-commit hook
-	hookPath := filepath.Join(repoRoot, ".git", "hooks", "pre-commit")
-	hookContent, err := a.generatePreCommitHook()
-	if err != nil {
-		return fmt.Errorf("failed to generate pre-commit hook: %w", err)
-	}
-
-	// On Windows, use .bat extension for batch files, otherwise no extension
-	if runtime.GOOS == "windows" {
-		// Try to detect if PowerShell is preferred, otherwise use batch
-		// For now, we'll create a .bat file that can call PowerShell if needed
-		hookPath = hookPath + ".bat"
-	}
-
-	if err := os.WriteFile(hookPath, []byte(hookContent), 0755); err != nil {
-		return fmt.Errorf("failed to create pre-commit hook: %w", err)
+	// 3. Generate git hooks, or leave them to the hook framework
+	if opts.Framework != "" {
+		if err := installFramework(repoRoot, opts.Framework); err != nil {
+			return err
+		}
+	} else {
+		for _, hookName := range hookNames {
+			if err := a.installHook(hooksDir, hookName, opts); err != nil {
+				return err
+			}
+			fmt.Printf("✓ Created %s hook in %s\n", hookName, hooksDir)
+		}
 	}
-	fmt.Printf("✓ Created pre-commit hook\n")
 
 	fmt.Println("\nInitialization complete!")
 	fmt.Println("Next steps:")
 	fmt.Println("1. Update .commit-generator-config with your API key if needed")
 	fmt.Println("2. Customize .git-commit-rules-for-ai with your team's rules")
-	fmt.Println("3. Stage your changes and commit - the hook will generate your commit message!")
-
-	return nil
-}
-
-// generatePreCommitHook generates the pre-commit hook script for the current platform
-func (a *App) generatePreCommitHook() (string, error) {
-	if runtime.GOOS == "windows" {
-		return a.generateWindowsHook(), nil
-	}
-	return a.generateUnixHook(), nil
-}
-
-// generateUnixHook generates a bash pre-commit hook for Unix systems
-func (a *App) generateUnixHook() string {
-	exePath, err := os.Executable()
-	if err != nil {
-		exePath = "generate-commit" // Fallback
+	if opts.Framework != "" {
+		fmt.Println("3. " + frameworkNextStep(opts.Framework))
+		fmt.Println("4. Stage your changes and commit - the hook will generate your commit message!")
 	} else {
-		// Ensure we have an absolute path
-		if absPath, err := filepath.Abs(exePath); err == nil {
-			exePath = absPath
-		}
-	}
-	
-	return fmt.Sprintf(`#!/bin/bash
-# Pre-commit hook for AI commit message generator
-
-# Skip if commit message was provided via -m flag
-# Git doesn't set any env var for this, but we can detect it by checking
-# if we're being called with a message file argument
-# For pre-commit hooks, if a message is provided, git will call prepare-commit-msg instead
-# So we only run if no message was provided (i.e., bare 'git commit')
-
-# Check if there are staged changes
-if ! git diff --staged --quiet; then
-    # Generate commit message
-    COMMIT_MSG=$("%s")
-    EXIT_CODE=$?
-    
-    if [ $EXIT_CODE -ne 0 ]; then
-        echo "Error generating commit message: $COMMIT_MSG"
-        exit 1
-    fi
-    
-    # Extract just the message (skip "Generating commit message..." line)
-    COMMIT_MSG=$(echo "$COMMIT_MSG" | grep -v "Generating commit message" | perl -pe 's/\e\[[0-9;]*m//g' | sed 's/^[[:space:]]*//' | sed '/^$/d')
-    
-    if [ -z "$COMMIT_MSG" ]; then
-        echo "No commit message generated"
-        exit 1
-    fi
-    
-    # Display the generated message (with colors intact)
-    echo ""
-    echo "Generated commit message:"
-    echo "=========================="
-    echo "$COMMIT_MSG"
-    echo "=========================="
-    echo ""
-    echo "Options:"
-    echo "  [A]ccept and commit"
-    echo "  [R]eject (abort commit)"
-    echo "  [E]dit message"
-    echo ""
-    
-    # Read from terminal explicitly to handle git hook context
-    exec < /dev/tty
-    read -p "Your choice (A/R/E): " choice
-    
-    # Strip ANSI color codes for the actual commit
-    CLEAN_MSG=$(echo "$COMMIT_MSG" | sed 's/\x1b\[[0-9;]*m//g')
-    
-    case "$choice" in
-        [Aa]*)
-            # Accept: commit with the generated message (colors stripped)
-            git commit -m "$CLEAN_MSG" --no-verify
-            # Exit with error to prevent original commit from proceeding
-            # (since we already committed)
-            exit 1
-            ;;
-        [Rr]*)
-            # Reject: abort the commit
-            echo "Commit aborted by user"
-            exit 1
-            ;;
-        [Ee]*)
-            # Edit: allow user to modify (colors stripped)
-            echo "$CLEAN_MSG" > /tmp/commit_msg.txt
-            ${EDITOR:-nano} /tmp/commit_msg.txt
-            EDITED_MSG=$(cat /tmp/commit_msg.txt)
-            git commit -m "$EDITED_MSG" --no-verify
-            rm -f /tmp/commit_msg.txt
-            # Exit with error to prevent original commit from proceeding
-            exit 1
-            ;;
-        *)
-            echo "Invalid choice. Aborting commit."
-            exit 1
-            ;;
-    esac
-fi
-`, exePath)
-}
-
-// generateWindowsHook generates a batch pre-commit hook for Windows
-func (a *App) generateWindowsHook() string {
-	exePath, err := os.Executable()
-	if err != nil {
-		exePath = "generate-commit"
-	} else {
-		// Ensure we have an absolute path
-		if absPath, err := filepath.Abs(exePath); err == nil {
-			exePath = absPath
-		}
+		fmt.Println("3. Stage your changes and commit - the hook will generate your commit message!")
 	}
 
-	return fmt.Sprintf(`@echo off
-REM Pre-commit hook for AI commit message generator (Windows)
-
-REM Check if there are staged changes
-git diff --staged --quiet >nul 2>&1
-if %%errorlevel%% equ 0 exit /b 0
-
-REM Generate commit message
-for /f "delims=" %%%%i in ('"%s" 2^>^&1') do set OUTPUT=%%%%i
-if errorlevel 1 (
-    echo Error generating commit message
-    exit /b 1
-)
-
-REM Extract commit message (basic extraction - may need refinement)
-set COMMIT_MSG=%%OUTPUT%%
-REM Remove "Generating commit message..." line if present
-set COMMIT_MSG=%%COMMIT_MSG:Generating commit message...=%%
-
-if "%%COMMIT_MSG%%"=="" (
-    echo No commit message generated
-    exit /b 1
-)
-
-REM Display the generated message
-echo.
-echo Generated commit message:
-echo ==========================
-echo %%COMMIT_MSG%%
-echo ==========================
-echo.
-echo Options:
-echo   [A]ccept and commit
-echo   [R]eject (abort commit)
-echo   [E]dit message
-echo.
-set /p CHOICE=Your choice (A/R/E): 
-
-if /i "%%CHOICE%%"=="A" goto accept
-if /i "%%CHOICE:~0,1%%"=="A" goto accept
-if /i "%%CHOICE%%"=="R" goto reject
-if /i "%%CHOICE:~0,1%%"=="R" goto reject
-if /i "%%CHOICE%%"=="E" goto edit
-if /i "%%CHOICE:~0,1%%"=="E" goto edit
-echo Invalid choice. Aborting commit.
-exit /b 1
-
-:accept
-git commit -m "%%COMMIT_MSG%%" --no-verify
-exit /b 1
-
-:reject
-echo Commit aborted by user
-exit /b 1
-
-:edit
-echo %%COMMIT_MSG%% > %%TEMP%%\commit_msg.txt
-notepad %%TEMP%%\commit_msg.txt
-set /p EDITED_MSG=<%%TEMP%%\commit_msg.txt
-git commit -m "%%EDITED_MSG%%" --no-verify
-del %%TEMP%%\commit_msg.txt
-exit /b 1
-`, exePath)
+	return nil
 }
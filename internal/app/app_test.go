@@ -2,10 +2,17 @@ package app
 
 import (
 	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
+	"ai-commit-message-generator/internal/ai"
+	"ai-commit-message-generator/internal/config"
 	"ai-commit-message-generator/internal/git"
+
+	gogit "github.com/go-git/go-git/v5"
 )
 
 // Manual Mocks
@@ -14,9 +21,38 @@ type MockGit struct {
 	IsInsideRepoFunc      func() (bool, error)
 	HasStagedChangesFunc  func() (bool, error)
 	GetStagedDiffFunc     func() (string, error)
+	GetStagedChangesFunc  func() ([]git.FileChange, error)
+	GetStagedFilesFunc    func() ([]git.StagedFile, error)
+	GetUserIdentityFunc   func() (*git.Identity, error)
 	CommitWithMessageFunc func(message string) error
 	GetRepoRootFunc       func() (string, error)
+	GetHooksDirFunc       func() (string, error)
 	DetectStateFunc       func() (*git.GitState, error)
+	SnapshotIndexFunc     func() (*git.IndexSnapshot, error)
+	StageOnlyFunc         func(paths []string) error
+	RestoreIndexFunc      func() error
+	ResetToSnapshotFunc   func() error
+	HasCommitsFunc        func() (bool, error)
+	HeadChangedPathsFunc  func() ([]string, error)
+	GetBranchDiffFunc     func(base string) (string, error)
+	DetectBaseBranchFunc  func() (string, error)
+	GetWorktreeDiffFunc   func(includeUntracked bool) (string, error)
+	GetWorktreeFilesFunc  func(includeUntracked bool) ([]git.StagedFile, error)
+	GetNewFilesFunc       func() (*git.NewFiles, error)
+	StageAllFunc          func(includeUntracked bool) error
+	GetCommitRangeFunc    func(revRange string) ([]git.LogCommit, error)
+	GetCommitDiffFunc     func(hash string) (string, error)
+	FindPublishedFunc     func(hashes, protected []string) (map[string]string, error)
+	RewordCommitsFunc     func(revRange string, messages map[string]string) (string, string, error)
+	GetCommitTemplateFunc func() (string, error)
+	GetCurrentBranchFunc  func() (string, error)
+	ListTagsFunc          func() ([]string, error)
+	CreateTagFunc         func(name, message string) error
+	ListBranchesFunc      func() ([]string, error)
+	CreateBranchFunc      func(name string) error
+	GetFileVersionsFunc   func(path string) (string, string, error)
+	GetGitDirFunc         func() (string, error)
+	GetCommentCharFunc    func() (string, error)
 }
 
 func (m *MockGit) IsInsideRepo() (bool, error) {
@@ -31,6 +67,27 @@ func (m *MockGit) GetStagedDiff() (string, error) {
 	return m.GetStagedDiffFunc()
 }
 
+func (m *MockGit) GetStagedChanges() ([]git.FileChange, error) {
+	if m.GetStagedChangesFunc != nil {
+		return m.GetStagedChangesFunc()
+	}
+	return nil, nil
+}
+
+func (m *MockGit) GetStagedFiles() ([]git.StagedFile, error) {
+	if m.GetStagedFilesFunc != nil {
+		return m.GetStagedFilesFunc()
+	}
+	return nil, nil
+}
+
+func (m *MockGit) GetUserIdentity() (*git.Identity, error) {
+	if m.GetUserIdentityFunc != nil {
+		return m.GetUserIdentityFunc()
+	}
+	return &git.Identity{Name: "Test User", Email: "test@example.com"}, nil
+}
+
 func (m *MockGit) CommitWithMessage(message string) error {
 	if m.CommitWithMessageFunc != nil {
 		return m.CommitWithMessageFunc(message)
@@ -45,6 +102,17 @@ func (m *MockGit) GetRepoRoot() (string, error) {
 	return "/tmp/test-repo", nil
 }
 
+func (m *MockGit) GetHooksDir() (string, error) {
+	if m.GetHooksDirFunc != nil {
+		return m.GetHooksDirFunc()
+	}
+	repoRoot, err := m.GetRepoRoot()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(repoRoot, ".git", "hooks"), nil
+}
+
 func (m *MockGit) DetectState() (*git.GitState, error) {
 	if m.DetectStateFunc != nil {
 		return m.DetectStateFunc()
@@ -53,20 +121,376 @@ func (m *MockGit) DetectState() (*git.GitState, error) {
 	return &git.GitState{Type: git.StateNormal}, nil
 }
 
+func (m *MockGit) SnapshotIndex() (*git.IndexSnapshot, error) {
+	if m.SnapshotIndexFunc != nil {
+		return m.SnapshotIndexFunc()
+	}
+	return &git.IndexSnapshot{}, nil
+}
+
+func (m *MockGit) StageOnly(snapshot *git.IndexSnapshot, paths []string) error {
+	if m.StageOnlyFunc != nil {
+		return m.StageOnlyFunc(paths)
+	}
+	return nil
+}
+
+func (m *MockGit) RestoreIndex(snapshot *git.IndexSnapshot) error {
+	if m.RestoreIndexFunc != nil {
+		return m.RestoreIndexFunc()
+	}
+	return nil
+}
+
+func (m *MockGit) ResetToSnapshot(snapshot *git.IndexSnapshot) error {
+	if m.ResetToSnapshotFunc != nil {
+		return m.ResetToSnapshotFunc()
+	}
+	return nil
+}
+
+func (m *MockGit) HasCommits() (bool, error) {
+	if m.HasCommitsFunc != nil {
+		return m.HasCommitsFunc()
+	}
+	return false, nil
+}
+
+func (m *MockGit) HeadChangedPaths() ([]string, error) {
+	if m.HeadChangedPathsFunc != nil {
+		return m.HeadChangedPathsFunc()
+	}
+	return nil, nil
+}
+
+func (m *MockGit) GetBranchDiff(base string) (string, error) {
+	if m.GetBranchDiffFunc != nil {
+		return m.GetBranchDiffFunc(base)
+	}
+	return "", nil
+}
+
+func (m *MockGit) DetectBaseBranch() (string, error) {
+	if m.DetectBaseBranchFunc != nil {
+		return m.DetectBaseBranchFunc()
+	}
+	return "main", nil
+}
+
+func (m *MockGit) ListTags() ([]string, error) {
+	return m.ListTagsFunc()
+}
+
+func (m *MockGit) CreateTag(name, message string) error {
+	return m.CreateTagFunc(name, message)
+}
+
+func (m *MockGit) ListBranches() ([]string, error) {
+	return m.ListBranchesFunc()
+}
+
+func (m *MockGit) CreateBranch(name string) error {
+	return m.CreateBranchFunc(name)
+}
+
+func (m *MockGit) GetFileVersions(path string) (string, string, error) {
+	return m.GetFileVersionsFunc(path)
+}
+
+func (m *MockGit) GetGitDir() (string, error) {
+	if m.GetGitDirFunc != nil {
+		return m.GetGitDirFunc()
+	}
+	return "", errors.New("no git directory")
+}
+
+func (m *MockGit) GetWorktreeDiff(includeUntracked bool) (string, error) {
+	return m.GetWorktreeDiffFunc(includeUntracked)
+}
+
+func (m *MockGit) GetNewFiles() (*git.NewFiles, error) {
+	if m.GetNewFilesFunc != nil {
+		return m.GetNewFilesFunc()
+	}
+	return &git.NewFiles{}, nil
+}
+
+func (m *MockGit) GetCommentChar() (string, error) {
+	if m.GetCommentCharFunc != nil {
+		return m.GetCommentCharFunc()
+	}
+	return git.DefaultCommentChar, nil
+}
+
+func (m *MockGit) GetWorktreeFiles(includeUntracked bool) ([]git.StagedFile, error) {
+	if m.GetWorktreeFilesFunc != nil {
+		return m.GetWorktreeFilesFunc(includeUntracked)
+	}
+	return nil, nil
+}
+
+func (m *MockGit) StageAll(includeUntracked bool) error {
+	if m.StageAllFunc != nil {
+		return m.StageAllFunc(includeUntracked)
+	}
+	return nil
+}
+
+func (m *MockGit) GetCommitRange(revRange string) ([]git.LogCommit, error) {
+	return m.GetCommitRangeFunc(revRange)
+}
+
+func (m *MockGit) GetCommitDiff(hash string) (string, error) {
+	return m.GetCommitDiffFunc(hash)
+}
+
+func (m *MockGit) FindPublished(hashes []string, protected []string) (map[string]string, error) {
+	return m.FindPublishedFunc(hashes, protected)
+}
+
+func (m *MockGit) RewordCommits(revRange string, messages map[string]string) (string, string, error) {
+	return m.RewordCommitsFunc(revRange, messages)
+}
+
+func (m *MockGit) GetCommitTemplate() (string, error) {
+	if m.GetCommitTemplateFunc != nil {
+		return m.GetCommitTemplateFunc()
+	}
+	return "", nil
+}
+
+func (m *MockGit) GetCurrentBranch() (string, error) {
+	if m.GetCurrentBranchFunc != nil {
+		return m.GetCurrentBranchFunc()
+	}
+	return "main", nil
+}
+
 type MockConfig struct {
 	LoadRulesFunc func() (string, error)
 }
 
-func (m *MockConfig) LoadRules() (string, error) {
+func (m *MockConfig) LoadRules(paths []string) (string, error) {
 	return m.LoadRulesFunc()
 }
 
+type MockStore struct {
+	ConfigExistsFunc func() (bool, error)
+	LoadConfigFunc   func() (*config.Config, error)
+	SaveConfigFunc   func(repoRoot string, cfg *config.Config) error
+	StoreAPIKeyFunc  func(key string, fileFallback bool) (string, error)
+}
+
+func (m *MockStore) ConfigExists() (bool, error) {
+	if m.ConfigExistsFunc != nil {
+		return m.ConfigExistsFunc()
+	}
+	return false, nil
+}
+
+func (m *MockStore) LoadConfig() (*config.Config, error) {
+	if m.LoadConfigFunc != nil {
+		return m.LoadConfigFunc()
+	}
+	return config.DefaultConfig(), nil
+}
+
+func (m *MockStore) LoadEffective() (*config.Config, []config.Value, error) {
+	cfg, err := m.LoadConfig()
+	return cfg, nil, err
+}
+
+func (m *MockStore) SaveDefaultConfig(repoRoot string) error {
+	return m.SaveConfig(repoRoot, config.DefaultConfig())
+}
+
+func (m *MockStore) SaveConfig(repoRoot string, cfg *config.Config) error {
+	if m.SaveConfigFunc != nil {
+		return m.SaveConfigFunc(repoRoot, cfg)
+	}
+	return nil
+}
+
+func (m *MockStore) Path() (string, error) {
+	return "/tmp/test-repo/.commit-generator-config", nil
+}
+
+func (m *MockStore) ResolveAPIKey(cfg *config.Config) (string, error) {
+	return cfg.APIKey, nil
+}
+
+func (m *MockStore) StoreAPIKey(key string, fileFallback bool) (string, error) {
+	if m.StoreAPIKeyFunc != nil {
+		return m.StoreAPIKeyFunc(key, fileFallback)
+	}
+	return "the keychain", nil
+}
+
 type MockAI struct {
-	GenerateCommitMessageFunc func(diff string, rules string, gitState *git.GitState) (string, error)
+	GenerateCommitMessageFunc func(req ai.CommitRequest) (string, error)
+	GeneratePRDescriptionFunc func(req ai.PRRequest) (string, error)
+	PlanSplitFunc             func(req ai.SplitRequest) (*ai.SplitPlan, error)
+	GenerateChangelogFunc     func(req ai.ChangelogRequest) (string, error)
+	GenerateTagMessageFunc    func(req ai.TagRequest) (string, error)
+	SuggestBranchesFunc       func(req ai.BranchRequest) ([]ai.BranchSuggestion, error)
+	ExplainChangesFunc        func(req ai.CommitRequest) (string, error)
+	ReviewChangesFunc         func(req ai.CommitRequest) ([]ai.ReviewFinding, error)
+	CheckBreakingChangeFunc   func(req ai.CommitRequest) (*ai.BreakingCheck, error)
+}
+
+func (m *MockAI) GenerateCommitMessage(req ai.CommitRequest) (string, error) {
+	return m.GenerateCommitMessageFunc(req)
+}
+
+func (m *MockAI) GeneratePRDescription(req ai.PRRequest) (string, error) {
+	return m.GeneratePRDescriptionFunc(req)
+}
+
+func (m *MockAI) PlanSplit(req ai.SplitRequest) (*ai.SplitPlan, error) {
+	return m.PlanSplitFunc(req)
+}
+
+func (m *MockAI) GenerateChangelog(req ai.ChangelogRequest) (string, error) {
+	return m.GenerateChangelogFunc(req)
+}
+
+func (m *MockAI) GenerateTagMessage(req ai.TagRequest) (string, error) {
+	return m.GenerateTagMessageFunc(req)
+}
+
+func (m *MockAI) SuggestBranches(req ai.BranchRequest) ([]ai.BranchSuggestion, error) {
+	return m.SuggestBranchesFunc(req)
+}
+
+func (m *MockAI) ExplainChanges(req ai.CommitRequest) (string, error) {
+	return m.ExplainChangesFunc(req)
+}
+
+func (m *MockAI) ReviewChanges(req ai.CommitRequest) ([]ai.ReviewFinding, error) {
+	return m.ReviewChangesFunc(req)
+}
+
+func (m *MockAI) CheckBreakingChange(req ai.CommitRequest) (*ai.BreakingCheck, error) {
+	return m.CheckBreakingChangeFunc(req)
+}
+
+// TestApp_InitThenRun drives a repository from init to a commit with
+// every dependency mocked: git, the rules loader, the config store and AI
+func TestApp_InitThenRun(t *testing.T) {
+	repoRoot := t.TempDir()
+	var committed string
+	mockGit := &MockGit{
+		IsInsideRepoFunc:     func() (bool, error) { return true, nil },
+		GetRepoRootFunc:      func() (string, error) { return repoRoot, nil },
+		HasStagedChangesFunc: func() (bool, error) { return true, nil },
+		GetStagedDiffFunc:    func() (string, error) { return "diff --git a/login.go b/login.go\n+func Login() {}\n", nil },
+		CommitWithMessageFunc: func(message string) error {
+			committed = message
+			return nil
+		},
+	}
+	var savedRoot string
+	var saved *config.Config
+	store := &MockStore{
+		ConfigExistsFunc: func() (bool, error) { return saved != nil, nil },
+		SaveConfigFunc: func(root string, cfg *config.Config) error {
+			savedRoot, saved = root, cfg
+			return nil
+		},
+	}
+	var rules string
+	mockAI := &MockAI{
+		GenerateCommitMessageFunc: func(req ai.CommitRequest) (string, error) {
+			rules = req.Rules
+			return "feat(auth): added login", nil
+		},
+	}
+	application := NewApp(mockGit, &MockConfig{LoadRulesFunc: func() (string, error) { return "Use past tense.", nil }}, store, mockAI)
+
+	captureStdout(t, func() {
+		if err := application.Init(InitOptions{}); err != nil {
+			t.Fatalf("Init failed: %v", err)
+		}
+	})
+	if savedRoot != repoRoot || saved == nil || saved.Model != config.DefaultConfig().Model {
+		t.Errorf("expected the default config saved in %s, got %q, %+v", repoRoot, savedRoot, saved)
+	}
+	for _, path := range []string{
+		filepath.Join(repoRoot, ".git-commit-rules-for-ai"),
+		hookPath(filepath.Join(repoRoot, ".git", "hooks"), HookPreCommit),
+	} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("expected init to create %s: %v", path, err)
+		}
+	}
+
+	// A second init finds the config and leaves it alone
+	saved.Model = "custom"
+	output := captureStdout(t, func() {
+		if err := application.Init(InitOptions{}); err != nil {
+			t.Fatalf("second Init failed: %v", err)
+		}
+	})
+	if !strings.Contains(output, "already initialized") || saved.Model != "custom" {
+		t.Errorf("expected the second init to stop early, got model %q and output:\n%s", saved.Model, output)
+	}
+
+	application.Quiet = true
+	output = captureStdout(t, func() {
+		if err := application.Run(RunOptions{Yes: true}); err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+	})
+	if committed != "feat(auth): added login" {
+		t.Errorf("expected the generated message committed, got %q", committed)
+	}
+	if rules != "Use past tense." {
+		t.Errorf("expected the rules in the request, got %q", rules)
+	}
+	if !strings.Contains(output, "feat(auth): added login") {
+		t.Errorf("expected the message printed, got:\n%s", output)
+	}
 }
 
-func (m *MockAI) GenerateCommitMessage(diff string, rules string, gitState *git.GitState) (string, error) {
-	return m.GenerateCommitMessageFunc(diff, rules, gitState)
+func TestApp_Init_StoreErrors(t *testing.T) {
+	tests := []struct {
+		name          string
+		store         *MockStore
+		expectedError string
+	}{
+		{
+			name:          "Config check fails",
+			store:         &MockStore{ConfigExistsFunc: func() (bool, error) { return false, errors.New("permission denied") }},
+			expectedError: "failed to check config existence: permission denied",
+		},
+		{
+			name:          "Saving fails",
+			store:         &MockStore{SaveConfigFunc: func(string, *config.Config) error { return errors.New("disk full") }},
+			expectedError: "failed to create config file: disk full",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repoRoot := t.TempDir()
+			mockGit := &MockGit{
+				IsInsideRepoFunc: func() (bool, error) { return true, nil },
+				GetRepoRootFunc:  func() (string, error) { return repoRoot, nil },
+			}
+			application := NewApp(mockGit, &MockConfig{}, tt.store, nil)
+
+			var err error
+			captureStdout(t, func() {
+				err = application.Init(InitOptions{})
+			})
+			if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+				t.Fatalf("expected error containing %q, got %v", tt.expectedError, err)
+			}
+			if _, statErr := os.Stat(hookPath(filepath.Join(repoRoot, ".git", "hooks"), HookPreCommit)); statErr == nil {
+				t.Error("expected no hook after a failed init")
+			}
+		})
+	}
 }
 
 func TestApp_Run(t *testing.T) {
@@ -88,11 +512,11 @@ func TestApp_Run(t *testing.T) {
 				LoadRulesFunc: func() (string, error) { return "some rules", nil },
 			},
 			mockAI: &MockAI{
-				GenerateCommitMessageFunc: func(diff, rules string, gitState *git.GitState) (string, error) {
-					if diff != "diff content" {
+				GenerateCommitMessageFunc: func(req ai.CommitRequest) (string, error) {
+					if req.Diff != "diff content" {
 						return "", errors.New("unexpected diff")
 					}
-					if rules != "some rules" {
+					if req.Rules != "some rules" {
 						return "", errors.New("unexpected rules")
 					}
 					return "feat: something", nil
@@ -111,8 +535,8 @@ func TestApp_Run(t *testing.T) {
 				LoadRulesFunc: func() (string, error) { return "", nil },
 			},
 			mockAI: &MockAI{
-				GenerateCommitMessageFunc: func(diff, rules string, gitState *git.GitState) (string, error) {
-					if rules != "" {
+				GenerateCommitMessageFunc: func(req ai.CommitRequest) (string, error) {
+					if req.Rules != "" {
 						return "", errors.New("expected empty rules")
 					}
 					return "fix: something", nil
@@ -152,6 +576,19 @@ func TestApp_Run(t *testing.T) {
 			mockAI:        &MockAI{}, // Should not be called
 			expectedError: "failed to get diff: git error",
 		},
+		{
+			name: "Path filters exclude every staged file",
+			mockGit: &MockGit{
+				IsInsideRepoFunc:     func() (bool, error) { return true, nil },
+				HasStagedChangesFunc: func() (bool, error) { return true, nil },
+				GetStagedDiffFunc:    func() (string, error) { return "", nil },
+			},
+			mockConfig: &MockConfig{
+				LoadRulesFunc: func() (string, error) { return "", nil },
+			},
+			mockAI:        &MockAI{}, // Should not be called
+			expectedError: "no staged changes match the path filters",
+		},
 		{
 			name: "AI Error",
 			mockGit: &MockGit{
@@ -163,18 +600,68 @@ func TestApp_Run(t *testing.T) {
 				LoadRulesFunc: func() (string, error) { return "", nil },
 			},
 			mockAI: &MockAI{
-				GenerateCommitMessageFunc: func(diff, rules string, gitState *git.GitState) (string, error) {
+				GenerateCommitMessageFunc: func(req ai.CommitRequest) (string, error) {
 					return "", errors.New("ai service down")
 				},
 			},
 			expectedError: "failed to generate commit message: ai service down",
 		},
+		{
+			name: "Staged file metadata reaches AI",
+			mockGit: &MockGit{
+				IsInsideRepoFunc:     func() (bool, error) { return true, nil },
+				HasStagedChangesFunc: func() (bool, error) { return true, nil },
+				GetStagedDiffFunc:    func() (string, error) { return "diff", nil },
+				GetStagedFilesFunc: func() ([]git.StagedFile, error) {
+					return []git.StagedFile{
+						{Path: "internal/app/app_test.go", Change: git.ChangeModified},
+						{Path: "internal/ai/diff_meta_test.go", Change: git.ChangeAdded},
+					}, nil
+				},
+			},
+			mockConfig: &MockConfig{
+				LoadRulesFunc: func() (string, error) { return "", nil },
+			},
+			mockAI: &MockAI{
+				GenerateCommitMessageFunc: func(req ai.CommitRequest) (string, error) {
+					if req.Meta == nil || req.Meta.FileCount != 2 {
+						return "", errors.New("expected metadata for 2 files")
+					}
+					if req.Meta.SuggestedType != "test" {
+						return "", errors.New("expected test type suggestion")
+					}
+					return "test: added coverage", nil
+				},
+			},
+			expectedError: "",
+		},
+		{
+			name: "Staged file listing error is not fatal",
+			mockGit: &MockGit{
+				IsInsideRepoFunc:     func() (bool, error) { return true, nil },
+				HasStagedChangesFunc: func() (bool, error) { return true, nil },
+				GetStagedDiffFunc:    func() (string, error) { return "diff", nil },
+				GetStagedFilesFunc:   func() ([]git.StagedFile, error) { return nil, errors.New("status failed") },
+			},
+			mockConfig: &MockConfig{
+				LoadRulesFunc: func() (string, error) { return "", nil },
+			},
+			mockAI: &MockAI{
+				GenerateCommitMessageFunc: func(req ai.CommitRequest) (string, error) {
+					if req.Meta != nil {
+						return "", errors.New("expected no metadata")
+					}
+					return "fix: something", nil
+				},
+			},
+			expectedError: "",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			app := NewApp(tt.mockGit, tt.mockConfig, nil, tt.mockAI)
-			err := app.Run()
+			err := app.Run(RunOptions{})
 
 			if tt.expectedError != "" {
 				if err == nil {
@@ -190,3 +677,1152 @@ func TestApp_Run(t *testing.T) {
 		})
 	}
 }
+
+func TestApp_Run_TestFiles(t *testing.T) {
+	mockGit := &MockGit{
+		IsInsideRepoFunc:     func() (bool, error) { return true, nil },
+		HasStagedChangesFunc: func() (bool, error) { return true, nil },
+		GetStagedDiffFunc:    func() (string, error) { return "diff", nil },
+		GetStagedFilesFunc: func() ([]git.StagedFile, error) {
+			return []git.StagedFile{
+				{Path: "src/login.rb", Change: git.ChangeModified},
+				{Path: "spec/login_spec.rb", Change: git.ChangeModified},
+			}, nil
+		},
+	}
+	var meta *ai.DiffMeta
+	mockAI := &MockAI{
+		GenerateCommitMessageFunc: func(req ai.CommitRequest) (string, error) {
+			meta = req.Meta
+			return "fix(auth): fixed login", nil
+		},
+	}
+	mockConfig := &MockConfig{
+		LoadRulesFunc: func() (string, error) { return "", nil },
+	}
+	application := NewApp(mockGit, mockConfig, nil, mockAI)
+	application.TestFiles = ai.TestFileOptions{Patterns: []string{"*_spec.rb"}, Policy: ai.TestPolicyFoldIntoMain}
+
+	captureStdout(t, func() {
+		if err := application.Run(RunOptions{}); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	})
+	if meta == nil || meta.Classification != ai.FilesMixed || meta.TestPolicy != ai.TestPolicyFoldIntoMain {
+		t.Errorf("expected mixed files under fold_into_main, got %+v", meta)
+	}
+}
+
+func TestApp_Run_Refine(t *testing.T) {
+	var requests []ai.CommitRequest
+	mockGit := &MockGit{
+		IsInsideRepoFunc:     func() (bool, error) { return true, nil },
+		HasStagedChangesFunc: func() (bool, error) { return true, nil },
+		GetStagedDiffFunc:    func() (string, error) { return "diff", nil },
+	}
+	mockConfig := &MockConfig{
+		LoadRulesFunc: func() (string, error) { return "", nil },
+	}
+	mockAI := &MockAI{
+		GenerateCommitMessageFunc: func(req ai.CommitRequest) (string, error) {
+			requests = append(requests, req)
+			return fmt.Sprintf("feat: attempt %d", len(requests)), nil
+		},
+	}
+
+	app := NewApp(mockGit, mockConfig, nil, mockAI)
+	err := app.Run(RunOptions{Refine: []string{"make it shorter", "mention the migration"}})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(requests) != 3 {
+		t.Fatalf("expected 3 AI calls, got %d", len(requests))
+	}
+	if requests[0].Feedback != "" || requests[0].PreviousMessage != "" {
+		t.Errorf("expected plain first request, got %+v", requests[0])
+	}
+	if requests[1].Feedback != "make it shorter" || requests[1].PreviousMessage != "feat: attempt 1" {
+		t.Errorf("unexpected first refinement %+v", requests[1])
+	}
+	if requests[2].Feedback != "mention the migration" || requests[2].PreviousMessage != "feat: attempt 2" {
+		t.Errorf("unexpected second refinement %+v", requests[2])
+	}
+}
+
+func TestApp_Run_Explain(t *testing.T) {
+	response := "feat(auth): added login\n\nAdded a login handler.\n\n" + ai.RationaleSeparator + "\nNew behavior, so feat; every file is in auth."
+
+	tests := []struct {
+		name           string
+		opts           RunOptions
+		expectedOutput []string
+	}{
+		{
+			name: "Rationale is printed below the message",
+			opts: RunOptions{Explain: true},
+			expectedOutput: []string{
+				"feat(auth): added login\n\nAdded a login handler.\n",
+				"\n\033[2mWhy: New behavior, so feat; every file is in auth.\033[0m\n",
+			},
+		},
+		{
+			name:           "Rationale is never committed",
+			opts:           RunOptions{Explain: true, Yes: true},
+			expectedOutput: []string{"Why: New behavior", "✓ Committed"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var committed []string
+			mockGit := &MockGit{
+				IsInsideRepoFunc:     func() (bool, error) { return true, nil },
+				HasStagedChangesFunc: func() (bool, error) { return true, nil },
+				GetStagedDiffFunc:    func() (string, error) { return "diff", nil },
+				CommitWithMessageFunc: func(message string) error {
+					committed = append(committed, message)
+					return nil
+				},
+			}
+			mockConfig := &MockConfig{
+				LoadRulesFunc: func() (string, error) { return "", nil },
+			}
+			mockAI := &MockAI{
+				GenerateCommitMessageFunc: func(req ai.CommitRequest) (string, error) {
+					if !req.Explain {
+						t.Errorf("expected the rationale to be asked for")
+					}
+					return response, nil
+				},
+			}
+			application := NewApp(mockGit, mockConfig, nil, mockAI)
+			application.Quiet = true
+
+			var err error
+			output := captureStdout(t, func() {
+				err = application.Run(tt.opts)
+			})
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			for _, want := range tt.expectedOutput {
+				if !strings.Contains(output, want) {
+					t.Errorf("expected output to contain %q, got %q", want, output)
+				}
+			}
+			if tt.opts.Yes && (len(committed) != 1 || committed[0] != "feat(auth): added login\n\nAdded a login handler.") {
+				t.Errorf("expected only the message to be committed, got %q", committed)
+			}
+		})
+	}
+
+	t.Run("Finalized messages drop a rationale", func(t *testing.T) {
+		if got := (&App{}).finalizeMessage(response); got != "feat(auth): added login\n\nAdded a login handler." {
+			t.Errorf("expected the rationale to be cut off, got %q", got)
+		}
+	})
+}
+
+func TestApp_Run_AnalyzeGo(t *testing.T) {
+	files := []git.StagedFile{
+		{Path: "auth/login.go", Change: git.ChangeModified},
+		{Path: "auth/session.go", Change: git.ChangeModified},
+		{Path: "auth/legacy.go", Change: git.ChangeDeleted},
+		{Path: "README.md", Change: git.ChangeModified},
+	}
+	versions := map[string][2]string{
+		"auth/login.go": {
+			"package auth\n\nfunc Login() {}\n\nfunc Logout() {}\n",
+			"package auth\n\nfunc Login() { check() }\n\nfunc Logout() {}\n",
+		},
+		"auth/session.go": {"", "package auth\n\ntype Session struct{}\n\nfunc (s *Session) Close() {}\n"},
+	}
+
+	tests := []struct {
+		name            string
+		analyzeGo       bool
+		versionsErr     error
+		expectedSymbols []string
+	}{
+		{
+			name:            "Changed declarations of Go files",
+			analyzeGo:       true,
+			expectedSymbols: []string{"Login", "Session", "(*Session).Close"},
+		},
+		{
+			name: "Off by default",
+		},
+		{
+			name:        "Unreadable files are skipped",
+			analyzeGo:   true,
+			versionsErr: errors.New("show failed"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var read []string
+			mockGit := &MockGit{
+				IsInsideRepoFunc:     func() (bool, error) { return true, nil },
+				HasStagedChangesFunc: func() (bool, error) { return true, nil },
+				GetStagedDiffFunc:    func() (string, error) { return "diff", nil },
+				GetStagedFilesFunc:   func() ([]git.StagedFile, error) { return files, nil },
+				GetFileVersionsFunc: func(path string) (string, string, error) {
+					read = append(read, path)
+					return versions[path][0], versions[path][1], tt.versionsErr
+				},
+				CommitWithMessageFunc: func(message string) error { return nil },
+			}
+			mockConfig := &MockConfig{
+				LoadRulesFunc: func() (string, error) { return "", nil },
+			}
+			var symbols []string
+			mockAI := &MockAI{
+				GenerateCommitMessageFunc: func(req ai.CommitRequest) (string, error) {
+					if req.Meta != nil {
+						symbols = req.Meta.ChangedSymbols
+					}
+					return "feat(auth): added sessions", nil
+				},
+			}
+			application := NewApp(mockGit, mockConfig, nil, mockAI)
+			application.Quiet = true
+			application.AnalyzeGo = tt.analyzeGo
+
+			var err error
+			captureStdout(t, func() {
+				err = application.Run(RunOptions{Yes: true})
+			})
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if strings.Join(symbols, ",") != strings.Join(tt.expectedSymbols, ",") {
+				t.Errorf("expected symbols %v, got %v", tt.expectedSymbols, symbols)
+			}
+			if !tt.analyzeGo && len(read) > 0 {
+				t.Errorf("expected no files to be read, got %v", read)
+			}
+			for _, path := range read {
+				if !strings.HasSuffix(path, ".go") || path == "auth/legacy.go" {
+					t.Errorf("expected only staged Go files to be read, got %s", path)
+				}
+			}
+		})
+	}
+}
+
+func TestApp_Run_PrependDiffStat(t *testing.T) {
+	diff := "diff --git a/auth/login.go b/auth/login.go\n--- a/auth/login.go\n+++ b/auth/login.go\n@@ -1,2 +1,2 @@\n-func Login() {}\n+func Login() { check() }\n+\n"
+	stat := " auth/login.go | 3 ++-\n 1 file changed, 2 insertions(+), 1 deletion(-)\n"
+
+	tests := []struct {
+		name         string
+		prepend      bool
+		expectedDiff string
+	}{
+		{name: "Stat precedes the hunks", prepend: true, expectedDiff: stat + "\n" + diff},
+		{name: "Off by default", expectedDiff: diff},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockGit := &MockGit{
+				IsInsideRepoFunc:      func() (bool, error) { return true, nil },
+				HasStagedChangesFunc:  func() (bool, error) { return true, nil },
+				GetStagedDiffFunc:     func() (string, error) { return diff, nil },
+				CommitWithMessageFunc: func(message string) error { return nil },
+			}
+			mockConfig := &MockConfig{
+				LoadRulesFunc: func() (string, error) { return "", nil },
+			}
+			var sent string
+			mockAI := &MockAI{
+				GenerateCommitMessageFunc: func(req ai.CommitRequest) (string, error) {
+					sent = req.Diff
+					return "fix(auth): checked the session on login", nil
+				},
+			}
+			application := NewApp(mockGit, mockConfig, nil, mockAI)
+			application.Quiet = true
+			application.PrependDiffStat = tt.prepend
+
+			var err error
+			captureStdout(t, func() {
+				err = application.Run(RunOptions{Yes: true})
+			})
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if sent != tt.expectedDiff {
+				t.Errorf("expected diff:\n%s\ngot:\n%s", tt.expectedDiff, sent)
+			}
+			if tt.prepend && strings.Index(sent, "1 file changed") > strings.Index(sent, "@@") {
+				t.Errorf("expected the stat block before the hunks, got:\n%s", sent)
+			}
+		})
+	}
+}
+
+func TestApp_Run_NoSplit(t *testing.T) {
+	response := "refactor(config): split the loader into files"
+
+	tests := []struct {
+		name            string
+		noSplit         bool
+		expectedCommit  string
+		expectErrorText string
+	}{
+		{name: "Split suggestions on", expectErrorText: "the model suggested splitting the changes"},
+		{name: "Split suggestions off", noSplit: true, expectedCommit: response},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var committed string
+			mockGit := &MockGit{
+				IsInsideRepoFunc:     func() (bool, error) { return true, nil },
+				HasStagedChangesFunc: func() (bool, error) { return true, nil },
+				GetStagedDiffFunc:    func() (string, error) { return "diff --git a/config.go b/config.go", nil },
+				CommitWithMessageFunc: func(message string) error {
+					committed = message
+					return nil
+				},
+			}
+			mockConfig := &MockConfig{
+				LoadRulesFunc: func() (string, error) { return "", nil },
+			}
+			var sentNoSplit bool
+			mockAI := &MockAI{
+				GenerateCommitMessageFunc: func(req ai.CommitRequest) (string, error) {
+					sentNoSplit = req.NoSplit
+					return response, nil
+				},
+			}
+			application := NewApp(mockGit, mockConfig, nil, mockAI)
+			application.Quiet = true
+			application.NoSplit = tt.noSplit
+
+			var err error
+			captureStdout(t, func() {
+				err = application.Run(RunOptions{Yes: true})
+			})
+			if tt.expectErrorText != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectErrorText) {
+					t.Fatalf("expected error containing %q, got %v", tt.expectErrorText, err)
+				}
+			} else if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if sentNoSplit != tt.noSplit {
+				t.Errorf("expected NoSplit %v in the request, got %v", tt.noSplit, sentNoSplit)
+			}
+			if committed != tt.expectedCommit {
+				t.Errorf("expected commit %q, got %q", tt.expectedCommit, committed)
+			}
+		})
+	}
+}
+
+func TestApp_Run_HeaderFormat(t *testing.T) {
+	tests := []struct {
+		name           string
+		format         string
+		response       string
+		expectedCommit string
+	}{
+		{
+			name:           "Default keeps the header",
+			response:       "feat(auth): added login",
+			expectedCommit: "feat(auth): added login",
+		},
+		{
+			name:           "Conventional header is laid out",
+			format:         "[{{.Scope}}] {{.Type}}: {{.Description}}",
+			response:       "feat(auth): added login\n\nUsers can sign in.",
+			expectedCommit: "[auth] feat: added login\n\nUsers can sign in.",
+		},
+		{
+			name:           "Header in the layout is kept",
+			format:         "{{.Type}}: {{.Description}} [{{.Scope}}]",
+			response:       "feat: added login [auth]",
+			expectedCommit: "feat: added login [auth]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var committed string
+			mockGit := &MockGit{
+				IsInsideRepoFunc:     func() (bool, error) { return true, nil },
+				HasStagedChangesFunc: func() (bool, error) { return true, nil },
+				GetStagedDiffFunc:    func() (string, error) { return "diff --git a/auth.go b/auth.go", nil },
+				CommitWithMessageFunc: func(message string) error {
+					committed = message
+					return nil
+				},
+			}
+			mockConfig := &MockConfig{
+				LoadRulesFunc: func() (string, error) { return "", nil },
+			}
+			format, err := ai.ParseHeaderFormat(tt.format)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var sentFormat *ai.HeaderFormat
+			mockAI := &MockAI{
+				GenerateCommitMessageFunc: func(req ai.CommitRequest) (string, error) {
+					sentFormat = req.HeaderFormat
+					return tt.response, nil
+				},
+			}
+			application := NewApp(mockGit, mockConfig, nil, mockAI)
+			application.Quiet = true
+			application.HeaderFormat = format
+
+			captureStdout(t, func() {
+				err = application.Run(RunOptions{Yes: true})
+			})
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if sentFormat != format {
+				t.Error("expected the header format in the request")
+			}
+			if committed != tt.expectedCommit {
+				t.Errorf("expected commit %q, got %q", tt.expectedCommit, committed)
+			}
+		})
+	}
+}
+
+func TestApp_Run_IncludeNew(t *testing.T) {
+	stagedDiff := "diff --git a/main.go b/main.go\n--- a/main.go\n+++ b/main.go\n@@ -1 +1,2 @@\n package main\n+func main() { tool() }\n"
+	newFiles := &git.NewFiles{
+		Diff:    "diff --git a/tool.go b/tool.go\nnew file mode 100644\n--- /dev/null\n+++ b/tool.go\n+func tool() {}\n",
+		Files:   []git.StagedFile{{Path: "tool.go", Change: git.ChangeAdded}},
+		Skipped: []string{"logo.png (binary)"},
+	}
+
+	tests := []struct {
+		name            string
+		opts            RunOptions
+		staged          bool
+		expectedDiff    string
+		expectedStderr  []string
+		expectErrorText string
+	}{
+		{
+			name:           "Staged and new files",
+			opts:           RunOptions{IncludeNew: true},
+			staged:         true,
+			expectedDiff:   stagedDiff + newFiles.Diff,
+			expectedStderr: []string{"Also describing 1 untracked file", "  ? tool.go", "Skipped untracked file logo.png (binary)"},
+		},
+		{
+			name:         "Only new files",
+			opts:         RunOptions{IncludeNew: true},
+			expectedDiff: newFiles.Diff,
+		},
+		{
+			name:            "Nothing staged to commit",
+			opts:            RunOptions{IncludeNew: true, Yes: true},
+			expectErrorText: "nothing is staged to commit",
+		},
+		{
+			name:            "Not with --all",
+			opts:            RunOptions{IncludeNew: true, All: true},
+			staged:          true,
+			expectErrorText: "use --include-untracked",
+		},
+		{
+			name:            "Off by default",
+			opts:            RunOptions{},
+			expectErrorText: "no staged changes found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			staged := tt.staged
+			mockGit := &MockGit{
+				IsInsideRepoFunc:     func() (bool, error) { return true, nil },
+				HasStagedChangesFunc: func() (bool, error) { return staged, nil },
+				GetStagedDiffFunc: func() (string, error) {
+					if staged {
+						return stagedDiff, nil
+					}
+					return "", nil
+				},
+				GetNewFilesFunc: func() (*git.NewFiles, error) { return newFiles, nil },
+			}
+			mockConfig := &MockConfig{
+				LoadRulesFunc: func() (string, error) { return "", nil },
+			}
+			var sent string
+			var sentFiles int
+			mockAI := &MockAI{
+				GenerateCommitMessageFunc: func(req ai.CommitRequest) (string, error) {
+					sent = req.Diff
+					if req.Meta != nil {
+						sentFiles = req.Meta.FileCount
+					}
+					return "feat: added a tool", nil
+				},
+			}
+			application := NewApp(mockGit, mockConfig, nil, mockAI)
+
+			var err error
+			stderr := captureStderr(t, func() {
+				captureStdout(t, func() {
+					err = application.Run(tt.opts)
+				})
+			})
+			if tt.expectErrorText != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectErrorText) {
+					t.Fatalf("expected error containing %q, got %v", tt.expectErrorText, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if sent != tt.expectedDiff {
+				t.Errorf("expected diff:\n%s\ngot:\n%s", tt.expectedDiff, sent)
+			}
+			if sentFiles != 1 {
+				t.Errorf("expected the new file in the file context, got %d files", sentFiles)
+			}
+			for _, want := range tt.expectedStderr {
+				if !strings.Contains(stderr, want) {
+					t.Errorf("expected %q on stderr, got:\n%s", want, stderr)
+				}
+			}
+		})
+	}
+}
+
+func TestApp_Run_ScopeMap(t *testing.T) {
+	scopeMap := map[string]string{"internal/ai": "ai", "cmd/": "cli"}
+
+	tests := []struct {
+		name          string
+		files         []git.StagedFile
+		expectedScope *ai.ScopeHint
+	}{
+		{
+			name:          "Single mapped scope",
+			files:         []git.StagedFile{{Path: "internal/ai/client.go"}, {Path: "internal/ai/prompt.go"}},
+			expectedScope: &ai.ScopeHint{Scope: "ai"},
+		},
+		{
+			name:          "Several mapped scopes",
+			files:         []git.StagedFile{{Path: "cmd/main.go"}, {Path: "internal/ai/client.go"}},
+			expectedScope: &ai.ScopeHint{Scope: "ai", Others: []string{"cli"}},
+		},
+		{
+			name:  "Fast path keeps its own scope",
+			files: []git.StagedFile{{Path: "cmd/README.md"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var diff strings.Builder
+			for i, f := range tt.files {
+				fmt.Fprintf(&diff, "diff --git a/%s b/%s\n--- a/%s\n+++ b/%s\n@@ -1 +1 @@\n%s", f.Path, f.Path, f.Path, f.Path, strings.Repeat("+x\n", i+1))
+			}
+			mockGit := &MockGit{
+				IsInsideRepoFunc:     func() (bool, error) { return true, nil },
+				HasStagedChangesFunc: func() (bool, error) { return true, nil },
+				GetStagedDiffFunc:    func() (string, error) { return diff.String(), nil },
+				GetStagedFilesFunc:   func() ([]git.StagedFile, error) { return tt.files, nil },
+			}
+			mockConfig := &MockConfig{
+				LoadRulesFunc: func() (string, error) { return "", nil },
+			}
+			var sent *ai.ScopeHint
+			mockAI := &MockAI{
+				GenerateCommitMessageFunc: func(req ai.CommitRequest) (string, error) {
+					sent = req.Scope
+					return "feat(ai): added streaming", nil
+				},
+			}
+			application := NewApp(mockGit, mockConfig, nil, mockAI)
+			application.Quiet = true
+			application.ScopeMap = scopeMap
+
+			var err error
+			captureStdout(t, func() {
+				err = application.Run(RunOptions{})
+			})
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if fmt.Sprintf("%+v", sent) != fmt.Sprintf("%+v", tt.expectedScope) {
+				t.Errorf("expected scope %+v, got %+v", tt.expectedScope, sent)
+			}
+		})
+	}
+}
+
+func TestApp_Run_Copy(t *testing.T) {
+	response := "feat(auth): added login\n\n" + ai.RationaleSeparator + "\nNew behavior."
+
+	tests := []struct {
+		name              string
+		opts              RunOptions
+		clipboardErr      error
+		expectedClipboard string
+		expectedWarning   string
+		expectedError     string
+	}{
+		{
+			name:              "Plain message is copied",
+			opts:              RunOptions{Copy: true, Explain: true},
+			expectedClipboard: "feat(auth): added login",
+		},
+		{
+			name:              "Copied when committing too",
+			opts:              RunOptions{Copy: true, Yes: true},
+			expectedClipboard: "feat(auth): added login",
+		},
+		{
+			name:            "Failure only warns",
+			opts:            RunOptions{Copy: true},
+			clipboardErr:    errors.New("no clipboard utility found"),
+			expectedWarning: "Warning: failed to copy to clipboard: no clipboard utility found",
+		},
+		{
+			name: "Not copied without the option",
+		},
+		{
+			name:          "Interactive has its own choice",
+			opts:          RunOptions{Copy: true, Interactive: true},
+			expectedError: "--copy cannot be combined with --interactive",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockGit := &MockGit{
+				IsInsideRepoFunc:      func() (bool, error) { return true, nil },
+				HasStagedChangesFunc:  func() (bool, error) { return true, nil },
+				GetStagedDiffFunc:     func() (string, error) { return "diff", nil },
+				CommitWithMessageFunc: func(message string) error { return nil },
+			}
+			mockConfig := &MockConfig{
+				LoadRulesFunc: func() (string, error) { return "", nil },
+			}
+			mockAI := &MockAI{
+				GenerateCommitMessageFunc: func(req ai.CommitRequest) (string, error) { return response, nil },
+			}
+			application := NewApp(mockGit, mockConfig, nil, mockAI)
+			application.Quiet = true
+			var clipboard string
+			application.Clipboard = func(text string) (string, error) {
+				if tt.clipboardErr != nil {
+					return "", tt.clipboardErr
+				}
+				clipboard = text
+				return "pbcopy", nil
+			}
+
+			var err error
+			var output string
+			warnings := captureStderr(t, func() {
+				output = captureStdout(t, func() {
+					err = application.Run(tt.opts)
+				})
+			})
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("expected error containing %q, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if clipboard != tt.expectedClipboard {
+				t.Errorf("expected clipboard %q, got %q", tt.expectedClipboard, clipboard)
+			}
+			if !strings.Contains(warnings, tt.expectedWarning) {
+				t.Errorf("expected warning %q, got %q", tt.expectedWarning, warnings)
+			}
+			if !strings.Contains(output, "feat(auth): added login") {
+				t.Errorf("expected the message to be printed, got %q", output)
+			}
+		})
+	}
+}
+
+func TestApp_Run_All(t *testing.T) {
+	tests := []struct {
+		name              string
+		opts              RunOptions
+		files             []git.StagedFile
+		expectedUntracked bool
+		expectedSteps     []string
+		expectedError     string
+	}{
+		{
+			name:          "All describes the worktree without a staged check",
+			opts:          RunOptions{All: true},
+			files:         []git.StagedFile{{Path: "main.go", Change: git.ChangeModified}},
+			expectedSteps: []string{"diff", "generate"},
+		},
+		{
+			name:              "Untracked files are requested",
+			opts:              RunOptions{All: true, IncludeUntracked: true},
+			files:             []git.StagedFile{{Path: "new.go", Change: git.ChangeAdded}},
+			expectedUntracked: true,
+			expectedSteps:     []string{"diff", "generate"},
+		},
+		{
+			name:          "Add stages right before committing",
+			opts:          RunOptions{All: true, Add: true, Yes: true},
+			files:         []git.StagedFile{{Path: "main.go", Change: git.ChangeModified}},
+			expectedSteps: []string{"diff", "generate", "stage", "commit"},
+		},
+		{
+			name:          "Clean worktree",
+			opts:          RunOptions{All: true},
+			expectedError: "pass --include-untracked",
+		},
+		{
+			name:          "Committing requires add",
+			opts:          RunOptions{All: true, Yes: true},
+			expectedError: "pass --add",
+		},
+		{
+			name:          "Add requires a committing mode",
+			opts:          RunOptions{All: true, Add: true},
+			expectedError: "combine it with --yes or --interactive",
+		},
+		{
+			name:          "Add requires all",
+			opts:          RunOptions{Add: true, Yes: true},
+			expectedError: "require --all",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var steps []string
+			var untracked bool
+			diff := ""
+			if len(tt.files) > 0 {
+				diff = "diff --git a/" + tt.files[0].Path + " b/" + tt.files[0].Path + "\n"
+			}
+			mockGit := &MockGit{
+				IsInsideRepoFunc: func() (bool, error) { return true, nil },
+				GetWorktreeDiffFunc: func(includeUntracked bool) (string, error) {
+					steps = append(steps, "diff")
+					untracked = includeUntracked
+					return diff, nil
+				},
+				GetWorktreeFilesFunc: func(includeUntracked bool) ([]git.StagedFile, error) {
+					return tt.files, nil
+				},
+				StageAllFunc: func(includeUntracked bool) error {
+					steps = append(steps, "stage")
+					return nil
+				},
+				CommitWithMessageFunc: func(message string) error {
+					steps = append(steps, "commit")
+					return nil
+				},
+			}
+			mockAI := &MockAI{
+				GenerateCommitMessageFunc: func(req ai.CommitRequest) (string, error) {
+					steps = append(steps, "generate")
+					return "feat: added worktree support", nil
+				},
+			}
+			mockConfig := &MockConfig{
+				LoadRulesFunc: func() (string, error) { return "", nil },
+			}
+			application := NewApp(mockGit, mockConfig, nil, mockAI)
+
+			var err error
+			captureStdout(t, func() {
+				err = application.Run(tt.opts)
+			})
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("expected error containing %q, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if strings.Join(steps, ",") != strings.Join(tt.expectedSteps, ",") {
+				t.Errorf("expected steps %v, got %v", tt.expectedSteps, steps)
+			}
+			if untracked != tt.expectedUntracked {
+				t.Errorf("expected includeUntracked %v, got %v", tt.expectedUntracked, untracked)
+			}
+		})
+	}
+}
+
+func TestApp_Run_FastPath(t *testing.T) {
+	tests := []struct {
+		name           string
+		files          []string
+		state          git.GitStateType
+		opts           ai.FastPathOptions
+		expectedHeader string
+	}{
+		{
+			name:           "Docs only",
+			files:          []string{"README.md", "docs/usage.md"},
+			expectedHeader: "docs",
+		},
+		{
+			name:  "Code takes the full prompt",
+			files: []string{"README.md", "main.go"},
+		},
+		{
+			name:  "Merges keep their own instructions",
+			files: []string{"README.md"},
+			state: git.StateMerge,
+		},
+		{
+			name:  "Disabled",
+			files: []string{"README.md"},
+			opts:  ai.FastPathOptions{Disabled: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockGit := &MockGit{
+				IsInsideRepoFunc:     func() (bool, error) { return true, nil },
+				HasStagedChangesFunc: func() (bool, error) { return true, nil },
+				GetStagedDiffFunc:    func() (string, error) { return "diff", nil },
+				GetStagedFilesFunc: func() ([]git.StagedFile, error) {
+					var files []git.StagedFile
+					for _, p := range tt.files {
+						files = append(files, git.StagedFile{Path: p, Change: git.ChangeModified})
+					}
+					return files, nil
+				},
+				DetectStateFunc: func() (*git.GitState, error) {
+					return &git.GitState{Type: tt.state}, nil
+				},
+			}
+			var fastPath *ai.FastPath
+			mockAI := &MockAI{
+				GenerateCommitMessageFunc: func(req ai.CommitRequest) (string, error) {
+					fastPath = req.FastPath
+					return "docs: updated usage", nil
+				},
+			}
+			mockConfig := &MockConfig{
+				LoadRulesFunc: func() (string, error) { return "", nil },
+			}
+			application := NewApp(mockGit, mockConfig, nil, mockAI)
+			application.FastPath = tt.opts
+
+			captureStdout(t, func() {
+				if err := application.Run(RunOptions{}); err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+			})
+			header := ""
+			if fastPath != nil {
+				header = fastPath.Header()
+			}
+			if header != tt.expectedHeader {
+				t.Errorf("expected fast path %q, got %q", tt.expectedHeader, header)
+			}
+		})
+	}
+}
+
+func TestApp_Run_CommitTemplate(t *testing.T) {
+	tests := []struct {
+		name             string
+		template         string
+		templateErr      error
+		branch           string
+		files            []string
+		expectedText     string
+		expectedFastPath bool
+	}{
+		{
+			name:         "Ticket from the branch",
+			template:     "# Fill in the ticket\n[TICKET] <type>: <subject>\n",
+			branch:       "feature/PROJ-42-login",
+			files:        []string{"login.go"},
+			expectedText: "PROJ-42 <type>: <subject>",
+		},
+		{
+			name:         "No ticket in the branch",
+			template:     "[TICKET] <type>: <subject>\n",
+			branch:       "login",
+			files:        []string{"login.go"},
+			expectedText: "[TICKET] <type>: <subject>",
+		},
+		{
+			name:         "Template replaces the fast path",
+			template:     "[TICKET] <subject>\n",
+			branch:       "PROJ-7",
+			files:        []string{"README.md"},
+			expectedText: "PROJ-7 <subject>",
+		},
+		{
+			name:             "No template",
+			files:            []string{"README.md"},
+			expectedFastPath: true,
+		},
+		{
+			name:             "Unreadable template",
+			templateErr:      errors.New("failed to read commit.template: no such file"),
+			files:            []string{"README.md"},
+			expectedFastPath: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockGit := &MockGit{
+				IsInsideRepoFunc:     func() (bool, error) { return true, nil },
+				HasStagedChangesFunc: func() (bool, error) { return true, nil },
+				GetStagedDiffFunc:    func() (string, error) { return "diff", nil },
+				GetStagedFilesFunc: func() ([]git.StagedFile, error) {
+					var files []git.StagedFile
+					for _, p := range tt.files {
+						files = append(files, git.StagedFile{Path: p, Change: git.ChangeModified})
+					}
+					return files, nil
+				},
+				GetCommitTemplateFunc: func() (string, error) { return tt.template, tt.templateErr },
+				GetCurrentBranchFunc:  func() (string, error) { return tt.branch, nil },
+			}
+			var req ai.CommitRequest
+			mockAI := &MockAI{
+				GenerateCommitMessageFunc: func(r ai.CommitRequest) (string, error) {
+					req = r
+					return "PROJ-42 feat: added login", nil
+				},
+			}
+			mockConfig := &MockConfig{
+				LoadRulesFunc: func() (string, error) { return "", nil },
+			}
+			application := NewApp(mockGit, mockConfig, nil, mockAI)
+
+			captureStdout(t, func() {
+				if err := application.Run(RunOptions{}); err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+			})
+			text := ""
+			if req.Template != nil {
+				text = req.Template.Text
+			}
+			if text != tt.expectedText {
+				t.Errorf("expected template %q, got %q", tt.expectedText, text)
+			}
+			if (req.FastPath != nil) != tt.expectedFastPath {
+				t.Errorf("expected fast path %v, got %+v", tt.expectedFastPath, req.FastPath)
+			}
+		})
+	}
+}
+
+func TestApp_Run_NoTrailingNewline(t *testing.T) {
+	tests := []struct {
+		name           string
+		response       string
+		quiet          bool
+		opts           RunOptions
+		expectedStdout string
+		expectedError  string
+	}{
+		{
+			name:           "Default ends in one newline",
+			response:       "feat: added login",
+			expectedStdout: "Generating commit message...\n\n\033[36mfeat: added login\033[0m\n",
+		},
+		{
+			name:           "Quiet prints the bare message",
+			response:       "feat: added login\n\nAdded a login handler.\n\n",
+			quiet:          true,
+			expectedStdout: "feat: added login\n\nAdded a login handler.\n",
+		},
+		{
+			name:           "Quiet without the trailing newline",
+			response:       "feat: added login\n\nAdded a login handler.\n",
+			quiet:          true,
+			opts:           RunOptions{NoTrailingNewline: true},
+			expectedStdout: "feat: added login\n\nAdded a login handler.",
+		},
+		{
+			name:           "Colors without the trailing newline",
+			response:       "feat: added login",
+			opts:           RunOptions{NoTrailingNewline: true},
+			expectedStdout: "Generating commit message...\n\n\033[36mfeat: added login\033[0m",
+		},
+		{
+			name:          "Rejected with --yes",
+			opts:          RunOptions{NoTrailingNewline: true, Yes: true},
+			expectedError: "cannot be combined with --interactive, --preview, --yes or --explain",
+		},
+		{
+			name:          "Rejected with --explain",
+			opts:          RunOptions{NoTrailingNewline: true, Explain: true},
+			expectedError: "cannot be combined with --interactive, --preview, --yes or --explain",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAI := &MockAI{
+				GenerateCommitMessageFunc: func(req ai.CommitRequest) (string, error) {
+					return tt.response, nil
+				},
+			}
+			var committed []string
+			application, _ := newInteractiveApp(t, "", mockAI, &committed)
+			application.Quiet = tt.quiet
+
+			var err error
+			stdout := captureStdout(t, func() {
+				err = application.Run(tt.opts)
+			})
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("expected error containing %q, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if stdout != tt.expectedStdout {
+				t.Errorf("expected stdout %q, got %q", tt.expectedStdout, stdout)
+			}
+		})
+	}
+}
+
+func TestApp_Run_SubjectStyle(t *testing.T) {
+	tests := []struct {
+		name     string
+		style    ai.SubjectStyle
+		response string
+		expected string
+	}{
+		{
+			name:     "Conventional Commits by default",
+			response: "feat(auth): Added login.\n\nAdded a login handler.",
+			expected: "feat(auth): added login\n\nAdded a login handler.\n",
+		},
+		{
+			name:     "Capitalized with a period",
+			style:    ai.SubjectStyle{Capitalize: true, TrailingPeriod: true},
+			response: "feat(auth): added login",
+			expected: "feat(auth): Added login.\n",
+		},
+		{
+			name:     "Split suggestions are left alone",
+			response: "These changes should be split into multiple commits.",
+			expected: "These changes should be split into multiple commits.\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAI := &MockAI{
+				GenerateCommitMessageFunc: func(req ai.CommitRequest) (string, error) {
+					return tt.response, nil
+				},
+			}
+			var committed []string
+			application, _ := newInteractiveApp(t, "", mockAI, &committed)
+			application.Quiet = true
+			application.SubjectStyle = tt.style
+
+			var err error
+			stdout := captureStdout(t, func() {
+				err = application.Run(RunOptions{})
+			})
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if !strings.Contains(stdout, tt.expected) {
+				t.Errorf("expected %q in stdout, got %q", tt.expected, stdout)
+			}
+		})
+	}
+}
+
+func TestApp_Run_InitialCommit_Integration(t *testing.T) {
+	tempDir := t.TempDir()
+	originalWd, _ := os.Getwd()
+	t.Cleanup(func() { _ = os.Chdir(originalWd) })
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatalf("failed to change to temp dir: %v", err)
+	}
+	t.Setenv("GIT_AUTHOR_NAME", "Test User")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+
+	repo, err := gogit.PlainInit(tempDir, false)
+	if err != nil {
+		t.Fatalf("failed to git init: %v", err)
+	}
+	worktree, _ := repo.Worktree()
+	for name, content := range map[string]string{"README.md": "# Project\n", "main.go": "package main\n\nfunc main() {}\n"} {
+		if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+		if _, err := worktree.Add(name); err != nil {
+			t.Fatalf("failed to add %s: %v", name, err)
+		}
+	}
+
+	var requests []ai.CommitRequest
+	mockAI := &MockAI{
+		GenerateCommitMessageFunc: func(req ai.CommitRequest) (string, error) {
+			requests = append(requests, req)
+			return "chore: initial commit\n\n- Added the README and the main package", nil
+		},
+	}
+	mockConfig := &MockConfig{LoadRulesFunc: func() (string, error) { return "", nil }}
+	application := NewApp(git.NewClient(), mockConfig, nil, mockAI)
+	application.Quiet = true
+
+	captureStdout(t, func() {
+		if err := application.Run(RunOptions{Yes: true}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	if len(requests) != 1 {
+		t.Fatalf("expected one generation, got %d", len(requests))
+	}
+	if state := requests[0].GitState; state == nil || !state.InitialCommit {
+		t.Errorf("expected the request to mark the initial commit, got %+v", state)
+	}
+	for _, file := range []string{"README.md", "main.go"} {
+		if !strings.Contains(requests[0].Diff, file) {
+			t.Errorf("expected %s in the diff, got %q", file, requests[0].Diff)
+		}
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatalf("expected HEAD to have a commit: %v", err)
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		t.Fatalf("failed to read HEAD: %v", err)
+	}
+	if commit.NumParents() != 0 || !strings.HasPrefix(commit.Message, "chore: initial commit") {
+		t.Errorf("expected a root commit with the generated message, got %q with %d parents", commit.Message, commit.NumParents())
+	}
+}
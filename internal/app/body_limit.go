@@ -0,0 +1,120 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"ai-commit-message-generator/internal/ai"
+)
+
+// Body overflow policies, for App.BodyOverflow
+const (
+	// BodyOverflowTruncate cuts the body at a sentence boundary and ends it
+	// with an ellipsis
+	BodyOverflowTruncate = "truncate"
+	// BodyOverflowRegenerate asks the model once for a shorter body and
+	// truncates if that is still too long
+	BodyOverflowRegenerate = "regenerate"
+)
+
+// bodyOverflowFeedback asks the model to shorten a message it generated
+const bodyOverflowFeedback = "The body of this message is %d characters long. Rewrite it with a body of at most %d characters, keeping only the most important points. Keep the header as it is."
+
+// limitBody applies MaxBodyLength to a generated message according to
+// BodyOverflow. Split suggestions are not commit messages and are left
+// alone.
+func (a *App) limitBody(req ai.CommitRequest, message string) (string, error) {
+	if a.MaxBodyLength == 0 || a.suggestsSplit(message) {
+		return message, nil
+	}
+	length := bodyLength(message)
+	if length <= a.MaxBodyLength {
+		return message, nil
+	}
+
+	if a.BodyOverflow == BodyOverflowRegenerate {
+		a.status(fmt.Sprintf("The body is %d characters long (max_body_length is %d); asking for a shorter one...", length, a.MaxBodyLength))
+		req.PreviousMessage = message
+		req.Feedback = fmt.Sprintf(bodyOverflowFeedback, length, a.MaxBodyLength)
+		req.Explain = false
+		shorter, err := a.AI.GenerateCommitMessage(req)
+		if err != nil {
+			return "", fmt.Errorf("failed to shorten the message body: %w", err)
+		}
+		if !a.suggestsSplit(shorter) {
+			message = shorter
+		}
+	}
+	return truncateBody(message, a.MaxBodyLength), nil
+}
+
+// splitBody returns the header of a message and its body without the blank
+// line between them
+func splitBody(message string) (string, string) {
+	header, body, _ := strings.Cut(message, "\n")
+	return header, strings.TrimSpace(body)
+}
+
+// bodyLength counts the characters of a message's body
+func bodyLength(message string) int {
+	_, body := splitBody(message)
+	return utf8.RuneCountInString(body)
+}
+
+// truncateBody shortens the body of message to at most max characters,
+// ellipsis included. It cuts after the last sentence or line that fits, or
+// else after the last whole word. A body that fits is returned unchanged.
+func truncateBody(message string, max int) string {
+	header, body := splitBody(message)
+	if utf8.RuneCountInString(body) <= max {
+		return message
+	}
+	// Leave room for a space or line break and the ellipsis
+	limit := max - 2
+	if limit <= 0 {
+		return header
+	}
+	prefix := body
+	for i := range body {
+		if limit == 0 {
+			prefix = body[:i]
+			break
+		}
+		limit--
+	}
+
+	kept, ellipsis := "", "…"
+	if end := lastSentenceEnd(body, len(prefix)); end > 0 {
+		kept, ellipsis = body[:end], " …"
+		if body[end] == '\n' {
+			ellipsis = "\n…"
+		}
+	} else if space := strings.LastIndexAny(body[:len(prefix)+1], " \t\n"); space > 0 {
+		// The character after the prefix counts, so a word that ends right
+		// at the limit is kept
+		kept = body[:space]
+	} else {
+		kept = prefix
+	}
+	return header + "\n\n" + strings.TrimRight(kept, " \t\n") + ellipsis
+}
+
+// lastSentenceEnd returns the end of the last sentence or line of body that
+// ends within its first n bytes, or 0 when there is none. A sentence ends
+// with . ! or ? followed by whitespace.
+func lastSentenceEnd(body string, n int) int {
+	for i := n - 1; i > 0; i-- {
+		switch body[i] {
+		case '\n':
+			if strings.TrimSpace(body[:i]) != "" {
+				return i
+			}
+		case '.', '!', '?':
+			if i+1 < len(body) && (body[i+1] == ' ' || body[i+1] == '\n') {
+				return i + 1
+			}
+		}
+	}
+	return 0
+}
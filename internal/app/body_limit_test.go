@@ -0,0 +1,167 @@
+package app
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"ai-commit-message-generator/internal/ai"
+)
+
+func TestTruncateBody(t *testing.T) {
+	tests := []struct {
+		name     string
+		message  string
+		max      int
+		expected string
+	}{
+		{
+			name:     "Body that fits is unchanged",
+			message:  "feat: added login\n\nAdds a login form.",
+			max:      40,
+			expected: "feat: added login\n\nAdds a login form.",
+		},
+		{
+			name:     "Cut after the last sentence that fits",
+			message:  "feat: added login\n\nAdds a login form. Tokens are refreshed in the background. Sessions expire after a day.",
+			max:      60,
+			expected: "feat: added login\n\nAdds a login form. Tokens are refreshed in the background. …",
+		},
+		{
+			name:     "Cut after the last line that fits",
+			message:  "feat: added login\n\n- added the form\n- refreshed tokens\n- expired sessions after a day",
+			max:      40,
+			expected: "feat: added login\n\n- added the form\n- refreshed tokens\n…",
+		},
+		{
+			name:     "Cut after a word without a sentence boundary",
+			message:  "feat: added login\n\nAdds a login form that refreshes tokens in the background",
+			max:      30,
+			expected: "feat: added login\n\nAdds a login form that…",
+		},
+		{
+			name:     "Characters, not bytes, are counted",
+			message:  "docs: translated\n\nÜbersetzt die Anleitung. Ändert die Beispiele für Windows.",
+			max:      30,
+			expected: "docs: translated\n\nÜbersetzt die Anleitung. …",
+		},
+		{
+			name:     "No room for the body",
+			message:  "feat: added login\n\nAdds a login form.",
+			max:      1,
+			expected: "feat: added login",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncateBody(tt.message, tt.max)
+			if got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+			if _, body := splitBody(got); utf8.RuneCountInString(body) > tt.max {
+				t.Errorf("expected a body of at most %d characters, got %d", tt.max, utf8.RuneCountInString(body))
+			}
+		})
+	}
+}
+
+func TestApp_Run_BodyOverflow(t *testing.T) {
+	long := "feat(auth): added login\n\nAdds a login form. Tokens are refreshed in the background. Sessions expire after a day of inactivity."
+	short := "feat(auth): added login\n\nAdds a login form with token refresh."
+
+	tests := []struct {
+		name          string
+		maxLength     int
+		overflow      string
+		responses     []string
+		expected      string
+		expectedCalls int
+	}{
+		{
+			name:          "No limit",
+			responses:     []string{long},
+			expected:      long,
+			expectedCalls: 1,
+		},
+		{
+			name:          "Truncate",
+			maxLength:     60,
+			overflow:      BodyOverflowTruncate,
+			responses:     []string{long},
+			expected:      "feat(auth): added login\n\nAdds a login form. Tokens are refreshed in the background. …",
+			expectedCalls: 1,
+		},
+		{
+			name:          "Truncate is the default",
+			maxLength:     60,
+			responses:     []string{long},
+			expected:      "feat(auth): added login\n\nAdds a login form. Tokens are refreshed in the background. …",
+			expectedCalls: 1,
+		},
+		{
+			name:          "Regenerate",
+			maxLength:     60,
+			overflow:      BodyOverflowRegenerate,
+			responses:     []string{long, short},
+			expected:      short,
+			expectedCalls: 2,
+		},
+		{
+			name:          "Regenerated body still too long is truncated",
+			maxLength:     30,
+			overflow:      BodyOverflowRegenerate,
+			responses:     []string{long, short},
+			expected:      "feat(auth): added login\n\nAdds a login form with token…",
+			expectedCalls: 2,
+		},
+		{
+			name:          "Body within the limit",
+			maxLength:     200,
+			overflow:      BodyOverflowRegenerate,
+			responses:     []string{long},
+			expected:      long,
+			expectedCalls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests []ai.CommitRequest
+			mockGit := &MockGit{
+				IsInsideRepoFunc:     func() (bool, error) { return true, nil },
+				HasStagedChangesFunc: func() (bool, error) { return true, nil },
+				GetStagedDiffFunc:    func() (string, error) { return "diff", nil },
+			}
+			mockAI := &MockAI{
+				GenerateCommitMessageFunc: func(req ai.CommitRequest) (string, error) {
+					requests = append(requests, req)
+					return tt.responses[len(requests)-1], nil
+				},
+			}
+			application := NewApp(mockGit, &MockConfig{LoadRulesFunc: func() (string, error) { return "", nil }}, nil, mockAI)
+			application.MaxBodyLength = tt.maxLength
+			application.BodyOverflow = tt.overflow
+			application.Quiet = true
+
+			var err error
+			output := captureStdout(t, func() {
+				err = application.Run(RunOptions{})
+			})
+			if err != nil {
+				t.Fatalf("Run failed: %v", err)
+			}
+			if output != tt.expected+"\n" {
+				t.Errorf("expected the message %q, got:\n%s", tt.expected, output)
+			}
+			if len(requests) != tt.expectedCalls {
+				t.Fatalf("expected %d generations, got %d", tt.expectedCalls, len(requests))
+			}
+			if tt.expectedCalls == 2 {
+				if requests[1].PreviousMessage != long || !strings.Contains(requests[1].Feedback, "at most") {
+					t.Errorf("expected the long message to be sent back for shortening, got %q / %q", requests[1].PreviousMessage, requests[1].Feedback)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,261 @@
+package app
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"ai-commit-message-generator/internal/ai"
+	"ai-commit-message-generator/internal/git"
+)
+
+// DefaultBranchPattern is the branch name pattern used when branch_pattern
+// is not configured
+const DefaultBranchPattern = "{type}/{ticket}-{slug}"
+
+const (
+	// branchSuggestionCount is how many branch names Branch proposes
+	branchSuggestionCount = 3
+	// maxBranchNameLength caps a suggested branch name; the slug is cut
+	// at a word boundary to fit
+	maxBranchNameLength = 60
+)
+
+// BranchOptions controls Branch
+type BranchOptions struct {
+	// Description describes the work when nothing is staged yet. It takes
+	// the place of the staged diff.
+	Description string
+	// Ticket fills {ticket}. When empty it is taken from an issue key in
+	// Description, and left out of the name if there is none.
+	Ticket string
+	// Checkout creates the chosen branch and switches to it
+	Checkout bool
+	// Pick chooses the suggestion to check out, counting from 1, instead of
+	// asking. Zero asks on a terminal and takes the first one otherwise.
+	Pick int
+}
+
+// Branch suggests branch names for the staged changes, or for a description
+// of the work, following BranchPattern. The names are printed one per line
+// so they can be piped; with Checkout the chosen one is created and checked
+// out.
+func (a *App) Branch(opts BranchOptions) error {
+	isRepo, err := a.Git.IsInsideRepo()
+	if err != nil {
+		return fmt.Errorf("failed to check repository status: %w", err)
+	}
+	if !isRepo {
+		return ErrNotARepo
+	}
+
+	defer a.Progress.Stop()
+	a.Progress.Phase(PhaseReadingDiff)
+	req := ai.BranchRequest{Description: strings.TrimSpace(opts.Description), Count: branchSuggestionCount}
+	if req.Description == "" {
+		staged, err := a.Git.HasStagedChanges()
+		if err != nil {
+			return fmt.Errorf("failed to check for staged changes: %w", err)
+		}
+		if !staged {
+			return errors.New("nothing is staged; stage changes or describe the work with --from")
+		}
+		if req.Diff, err = a.Git.GetStagedDiff(); err != nil {
+			return fmt.Errorf("failed to get staged diff: %w", err)
+		}
+	}
+	existing, err := a.Git.ListBranches()
+	if err != nil {
+		return err
+	}
+
+	if !a.Quiet && !a.Progress.Enabled() {
+		fmt.Fprintln(os.Stderr, "Suggesting branch names...")
+	}
+	suggestions, err := a.AI.SuggestBranches(req)
+	a.Progress.Stop()
+	if err != nil {
+		return fmt.Errorf("failed to suggest branch names: %w", classifyAIError(err))
+	}
+
+	ticket := opts.Ticket
+	if ticket == "" {
+		ticket = ticketPattern.FindString(opts.Description)
+	}
+	pattern := a.BranchPattern
+	if pattern == "" {
+		pattern = DefaultBranchPattern
+	}
+	names := branchNames(pattern, ticket, suggestions, existing)
+	if len(names) == 0 {
+		return errors.New("none of the suggested branch names is valid; try again or describe the work with --from")
+	}
+	for _, name := range names {
+		fmt.Println(name)
+	}
+
+	if !opts.Checkout {
+		return nil
+	}
+	name, err := a.chooseBranch(names, opts.Pick)
+	if err != nil {
+		return err
+	}
+	if err := a.Git.CreateBranch(name); err != nil {
+		return err
+	}
+	if !a.Quiet {
+		fmt.Fprintf(os.Stderr, "\033[32m✓ Switched to a new branch %s\033[0m\n", name)
+	}
+	return nil
+}
+
+// chooseBranch returns the name to check out: the one picked with --pick,
+// the one chosen on the terminal, or the first when nobody can be asked
+func (a *App) chooseBranch(names []string, pick int) (string, error) {
+	if pick != 0 {
+		if pick < 1 || pick > len(names) {
+			return "", fmt.Errorf("--pick must be between 1 and %d", len(names))
+		}
+		return names[pick-1], nil
+	}
+	if !a.canPrompt() {
+		return names[0], nil
+	}
+
+	reader := bufio.NewReader(a.Terminal.In)
+	for {
+		fmt.Fprintf(a.Terminal.Out, "Check out which branch? [1-%d] (Enter for 1) ", len(names))
+		answer, err := a.Terminal.readLine(reader)
+		if err != nil {
+			return "", err
+		}
+		if answer == "" {
+			return names[0], nil
+		}
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(names) {
+			return names[n-1], nil
+		}
+		fmt.Fprintf(a.Terminal.Out, "Enter a number from 1 to %d.\n", len(names))
+	}
+}
+
+// branchNames renders the suggestions into branch names. Invalid names and
+// duplicates are dropped, and a name that is already taken by an existing
+// branch gets a -2, -3, ... suffix.
+func branchNames(pattern, ticket string, suggestions []ai.BranchSuggestion, existing []string) []string {
+	taken := make(map[string]bool, len(existing))
+	for _, branch := range existing {
+		taken[branch] = true
+	}
+	seen := make(map[string]bool)
+
+	var names []string
+	for _, s := range suggestions {
+		name := renderBranchName(pattern, s.Type, ticket, s.Description)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		name = uniqueBranchName(name, taken)
+		if git.ValidateBranchName(name) != nil {
+			continue
+		}
+		taken[name] = true
+		names = append(names, name)
+	}
+	return names
+}
+
+// uniqueBranchName returns name, or name with the lowest numeric suffix
+// that is not taken
+func uniqueBranchName(name string, taken map[string]bool) string {
+	if !taken[name] {
+		return name
+	}
+	for i := 2; ; i++ {
+		candidate := fmt.Sprintf("%s-%d", name, i)
+		if !taken[candidate] {
+			return candidate
+		}
+	}
+}
+
+// ticketPlaceholder matches {ticket} together with one separator next to
+// it, so the name stays tidy when there is no ticket
+var ticketPlaceholder = regexp.MustCompile(`[-_/.]?\{ticket\}[-_/.]?`)
+
+// renderBranchName fills pattern with the type, ticket and a slug of the
+// description. The slug is shortened at a word boundary so the name fits
+// maxBranchNameLength. It returns "" when the description has nothing to
+// slug.
+func renderBranchName(pattern, commitType, ticket, description string) string {
+	slug := slugify(description)
+	if slug == "" {
+		return ""
+	}
+	commitType = slugify(commitType)
+	if commitType == "" {
+		commitType = "chore"
+	}
+
+	name := strings.ReplaceAll(pattern, "{type}", commitType)
+	if ticket != "" {
+		name = strings.ReplaceAll(name, "{ticket}", ticket)
+	} else {
+		name = ticketPlaceholder.ReplaceAllStringFunc(name, func(m string) string {
+			// Keep one separator if the placeholder sat between two
+			if strings.HasPrefix(m, "{") || strings.HasSuffix(m, "}") {
+				return ""
+			}
+			return m[:1]
+		})
+	}
+
+	room := maxBranchNameLength - (len(name) - len("{slug}"))
+	return strings.Replace(name, "{slug}", fitSlug(slug, room), 1)
+}
+
+// fitSlug cuts slug to at most n bytes at a hyphen, or mid-word when the
+// first word alone is too long
+func fitSlug(slug string, n int) string {
+	if len(slug) <= n {
+		return slug
+	}
+	if n <= 0 {
+		return ""
+	}
+	if i := strings.LastIndex(slug[:n+1], "-"); i > 0 {
+		return slug[:i]
+	}
+	cut := slug[:n]
+	for !utf8.ValidString(cut) {
+		cut = cut[:len(cut)-1]
+	}
+	return cut
+}
+
+// slugify lowercases s and joins its letters and digits with hyphens, e.g.
+// "Add OAuth2 login!" becomes "add-oauth2-login"
+func slugify(s string) string {
+	var sb strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if hyphen && sb.Len() > 0 {
+				sb.WriteByte('-')
+			}
+			hyphen = false
+			sb.WriteRune(r)
+			continue
+		}
+		hyphen = true
+	}
+	return sb.String()
+}
@@ -0,0 +1,220 @@
+package app
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"ai-commit-message-generator/internal/ai"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: "add login form", expected: "add-login-form"},
+		{input: "Add OAuth2 login!", expected: "add-oauth2-login"},
+		{input: "  handle  expired--tokens  ", expected: "handle-expired-tokens"},
+		{input: "fix feat/login..form", expected: "fix-feat-login-form"},
+		{input: "Überprüfe Eingaben", expected: "überprüfe-eingaben"},
+		{input: "!?", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := slugify(tt.input); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestRenderBranchName(t *testing.T) {
+	tests := []struct {
+		name        string
+		pattern     string
+		commitType  string
+		ticket      string
+		description string
+		expected    string
+	}{
+		{
+			name:        "Default pattern with a ticket",
+			pattern:     DefaultBranchPattern,
+			commitType:  "feat",
+			ticket:      "PROJ-42",
+			description: "add login form",
+			expected:    "feat/PROJ-42-add-login-form",
+		},
+		{
+			name:        "Ticket is left out with its separator",
+			pattern:     DefaultBranchPattern,
+			commitType:  "fix",
+			description: "handle expired tokens",
+			expected:    "fix/handle-expired-tokens",
+		},
+		{
+			name:        "Ticket first",
+			pattern:     "{ticket}-{slug}",
+			description: "add login form",
+			expected:    "add-login-form",
+		},
+		{
+			name:        "Missing type",
+			pattern:     "{type}/{slug}",
+			description: "bump dependencies",
+			expected:    "chore/bump-dependencies",
+		},
+		{
+			name:        "Long descriptions are cut at a word",
+			pattern:     DefaultBranchPattern,
+			commitType:  "refactor",
+			ticket:      "PROJ-7",
+			description: "split the configuration loader into separate readers for rules and settings",
+			expected:    "refactor/PROJ-7-split-the-configuration-loader-into-separate",
+		},
+		{
+			name:        "Nothing to slug",
+			pattern:     DefaultBranchPattern,
+			commitType:  "feat",
+			description: "...",
+			expected:    "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := renderBranchName(tt.pattern, tt.commitType, tt.ticket, tt.description)
+			if got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+			if len(got) > maxBranchNameLength {
+				t.Errorf("expected at most %d characters, got %d", maxBranchNameLength, len(got))
+			}
+		})
+	}
+}
+
+func TestApp_Branch(t *testing.T) {
+	suggestions := []ai.BranchSuggestion{
+		{Type: "feat", Description: "add login form"},
+		{Type: "feat", Description: "Add login form!"},
+		{Type: "feat", Description: "support sso login"},
+		{Type: "fix", Description: "validate login input"},
+	}
+
+	tests := []struct {
+		name            string
+		opts            BranchOptions
+		staged          bool
+		existing        []string
+		terminal        string
+		expectedRequest ai.BranchRequest
+		expectedNames   []string
+		expectedCreated string
+		expectedError   string
+	}{
+		{
+			name:            "Staged diff",
+			staged:          true,
+			expectedRequest: ai.BranchRequest{Diff: "diff", Count: 3},
+			expectedNames:   []string{"feat/add-login-form", "feat/support-sso-login", "fix/validate-login-input"},
+		},
+		{
+			name:            "Description with a ticket",
+			opts:            BranchOptions{Description: " PROJ-42 login page "},
+			expectedRequest: ai.BranchRequest{Description: "PROJ-42 login page", Count: 3},
+			expectedNames:   []string{"feat/PROJ-42-add-login-form", "feat/PROJ-42-support-sso-login", "fix/PROJ-42-validate-login-input"},
+		},
+		{
+			name:            "Existing branches get a suffix",
+			opts:            BranchOptions{Description: "login page"},
+			existing:        []string{"feat/add-login-form", "feat/add-login-form-2", "main"},
+			expectedRequest: ai.BranchRequest{Description: "login page", Count: 3},
+			expectedNames:   []string{"feat/add-login-form-3", "feat/support-sso-login", "fix/validate-login-input"},
+		},
+		{
+			name:          "Nothing staged",
+			expectedError: "nothing is staged; stage changes or describe the work with --from",
+		},
+		{
+			name:            "Checkout of the picked branch",
+			opts:            BranchOptions{Description: "login page", Ticket: "WEB-9", Checkout: true, Pick: 2},
+			expectedRequest: ai.BranchRequest{Description: "login page", Count: 3},
+			expectedNames:   []string{"feat/WEB-9-add-login-form", "feat/WEB-9-support-sso-login", "fix/WEB-9-validate-login-input"},
+			expectedCreated: "feat/WEB-9-support-sso-login",
+		},
+		{
+			name:            "Checkout of the chosen branch",
+			opts:            BranchOptions{Description: "login page", Checkout: true},
+			terminal:        "7\n3\n",
+			expectedRequest: ai.BranchRequest{Description: "login page", Count: 3},
+			expectedNames:   []string{"feat/add-login-form", "feat/support-sso-login", "fix/validate-login-input"},
+			expectedCreated: "fix/validate-login-input",
+		},
+		{
+			name:            "Checkout without a terminal takes the first",
+			opts:            BranchOptions{Description: "login page", Checkout: true},
+			expectedRequest: ai.BranchRequest{Description: "login page", Count: 3},
+			expectedNames:   []string{"feat/add-login-form", "feat/support-sso-login", "fix/validate-login-input"},
+			expectedCreated: "feat/add-login-form",
+		},
+		{
+			name:            "Pick out of range",
+			opts:            BranchOptions{Description: "login page", Checkout: true, Pick: 4},
+			expectedRequest: ai.BranchRequest{Description: "login page", Count: 3},
+			expectedError:   "--pick must be between 1 and 3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var created string
+			mockGit := &MockGit{
+				IsInsideRepoFunc:     func() (bool, error) { return true, nil },
+				HasStagedChangesFunc: func() (bool, error) { return tt.staged, nil },
+				GetStagedDiffFunc:    func() (string, error) { return "diff", nil },
+				ListBranchesFunc:     func() ([]string, error) { return tt.existing, nil },
+				CreateBranchFunc: func(name string) error {
+					created = name
+					return nil
+				},
+			}
+			mockAI := &MockAI{
+				SuggestBranchesFunc: func(req ai.BranchRequest) ([]ai.BranchSuggestion, error) {
+					if !reflect.DeepEqual(req, tt.expectedRequest) {
+						t.Errorf("expected request %+v, got %+v", tt.expectedRequest, req)
+					}
+					return suggestions, nil
+				},
+			}
+			application := NewApp(mockGit, nil, nil, mockAI)
+			application.Quiet = true
+			if tt.terminal != "" {
+				fakeTTY(t)
+				application.Terminal = &Terminal{In: strings.NewReader(tt.terminal), Out: &strings.Builder{}}
+			}
+
+			var err error
+			output := captureStdout(t, func() {
+				err = application.Branch(tt.opts)
+			})
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("expected error containing %q, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Branch failed: %v", err)
+			}
+			if names := strings.Fields(output); !reflect.DeepEqual(names, tt.expectedNames) {
+				t.Errorf("expected names %q, got %q", tt.expectedNames, names)
+			}
+			if created != tt.expectedCreated {
+				t.Errorf("expected %q to be checked out, got %q", tt.expectedCreated, created)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"ai-commit-message-generator/internal/git"
+)
+
+// SemverOptions controls Semver
+type SemverOptions struct {
+	// Range selects the commits, e.g. v1.2.0..HEAD. When empty it runs from
+	// the highest semantic version tag to HEAD.
+	Range string
+	// Staged also asks the model whether the staged changes break
+	// compatibility without saying so, and bumps the major version if so
+	Staged bool
+	// Next prints the next version instead of the bump
+	Next bool
+	// JSON prints the bump, the versions and the reasons as JSON
+	JSON bool
+}
+
+// semverJSON is the --json output of Semver
+type semverJSON struct {
+	Range   string `json:"range"`
+	Bump    string `json:"bump"`
+	Current string `json:"current,omitempty"`
+	Next    string `json:"next,omitempty"`
+	// Breaking lists the commits that force a major bump
+	Breaking []string          `json:"breaking,omitempty"`
+	Staged   *stagedBumpResult `json:"staged,omitempty"`
+}
+
+// stagedBumpResult is the model's verdict on the staged changes
+type stagedBumpResult struct {
+	Breaking bool   `json:"breaking"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// Semver recommends the version bump for a range of commits from their
+// Conventional Commits headers: major for a breaking change (a "!" after
+// the type or a BREAKING CHANGE footer), minor for a feat, patch for a fix
+// or perf, and none otherwise. No model is involved unless Staged asks for
+// a second opinion on the staged diff. Only the result goes to stdout so
+// release scripts can read it; progress goes to stderr.
+func (a *App) Semver(opts SemverOptions) error {
+	if opts.Next && opts.JSON {
+		return errors.New("--next and --json cannot be used together")
+	}
+	isRepo, err := a.Git.IsInsideRepo()
+	if err != nil {
+		return fmt.Errorf("failed to check repository status: %w", err)
+	}
+	if !isRepo {
+		return ErrNotARepo
+	}
+
+	revRange := opts.Range
+	if revRange == "" {
+		tags, err := a.Git.ListTags()
+		if err != nil {
+			return err
+		}
+		since := previousTag(tags, "")
+		if since == "" {
+			return errors.New("no version tag was found; pass --range <from>..HEAD")
+		}
+		revRange = since + "..HEAD"
+	}
+	from, _, err := git.SplitRange(revRange)
+	if err != nil {
+		return err
+	}
+	current, hasCurrent := parseSemver(from)
+	if opts.Next && !hasCurrent {
+		return fmt.Errorf("%s is not a version, so there is no next one; start the range at a version tag", from)
+	}
+
+	defer a.Progress.Stop()
+	a.Progress.Phase(PhaseReadingDiff)
+	commits, err := a.Git.GetCommitRange(revRange)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", revRange, err)
+	}
+	bump, breaking := bumpOf(commits)
+	result := semverJSON{Range: revRange, Breaking: breaking}
+
+	if opts.Staged {
+		req, err := a.prepareRequest(RunOptions{})
+		if err != nil {
+			return err
+		}
+		if !a.Quiet && !a.Progress.Enabled() {
+			fmt.Fprintln(os.Stderr, "Checking the staged changes for breaking changes...")
+		}
+		check, err := a.AI.CheckBreakingChange(req)
+		a.Progress.Stop()
+		if err != nil {
+			return fmt.Errorf("failed to check the staged changes: %w", err)
+		}
+		result.Staged = &stagedBumpResult{Breaking: check.Breaking, Reason: check.Reason}
+		if check.Breaking {
+			bump = bumpMajor
+			if !a.Quiet {
+				fmt.Fprintf(os.Stderr, "Warning: the staged changes look breaking: %s\n", check.Reason)
+			}
+		}
+	}
+	a.Progress.Stop()
+
+	result.Bump = bump
+	if hasCurrent {
+		prefix := ""
+		if strings.HasPrefix(from, "v") {
+			prefix = "v"
+		}
+		result.Current = current.format(prefix)
+		result.Next = current.bump(bump).format(prefix)
+	}
+
+	switch {
+	case opts.Next:
+		fmt.Println(result.Next)
+	case opts.JSON:
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode the bump: %w", err)
+		}
+		fmt.Println(string(data))
+	default:
+		fmt.Println(bump)
+	}
+	return nil
+}
+
+// bumpOf returns the largest bump the commits call for and the short hash
+// and subject of each breaking commit. Merge commits are skipped.
+func bumpOf(commits []git.LogCommit) (string, []string) {
+	bump := bumpNone
+	var breaking []string
+	for _, commit := range commits {
+		if commit.Merge {
+			continue
+		}
+		kind := bumpNone
+		m := headerPattern.FindStringSubmatch(commit.Subject())
+		if m != nil {
+			switch strings.ToLower(m[1]) {
+			case "feat":
+				kind = bumpMinor
+			case "fix", "perf":
+				kind = bumpPatch
+			}
+		}
+		if m != nil && m[4] == "!" || hasBreakingFooter(commit.Message) {
+			kind = bumpMajor
+			breaking = append(breaking, shortHash(commit.Hash)+" "+commit.Subject())
+		}
+		if bumpRank[kind] > bumpRank[bump] {
+			bump = kind
+		}
+	}
+	return bump, breaking
+}
+
+// hasBreakingFooter reports whether a commit message has a BREAKING CHANGE
+// footer
+func hasBreakingFooter(message string) bool {
+	return strings.Contains(message, "\nBREAKING CHANGE:") || strings.Contains(message, "\nBREAKING-CHANGE:")
+}
@@ -0,0 +1,179 @@
+package app
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"ai-commit-message-generator/internal/ai"
+	"ai-commit-message-generator/internal/git"
+)
+
+func TestBumpOf(t *testing.T) {
+	tests := []struct {
+		name             string
+		messages         []string
+		expected         string
+		expectedBreaking []string
+	}{
+		{
+			name:     "Fixes only",
+			messages: []string{"fix(auth): handled expired tokens", "perf: cached the rules", "docs: updated the README"},
+			expected: bumpPatch,
+		},
+		{
+			name:     "Feature",
+			messages: []string{"fix(auth): handled expired tokens", "feat(cli): added the semver command"},
+			expected: bumpMinor,
+		},
+		{
+			name:             "Breaking header",
+			messages:         []string{"feat(config)!: renamed the model option", "fix: fixed a typo"},
+			expected:         bumpMajor,
+			expectedBreaking: []string{"commit0 feat(config)!: renamed the model option"},
+		},
+		{
+			name:             "Breaking footer",
+			messages:         []string{"refactor: moved the client\n\nBREAKING CHANGE: NewClient takes options"},
+			expected:         bumpMajor,
+			expectedBreaking: []string{"commit0 refactor: moved the client"},
+		},
+		{
+			name:     "Nothing to release",
+			messages: []string{"chore: bumped dependencies", "Update README.md"},
+			expected: bumpNone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var commits []git.LogCommit
+			for i, message := range tt.messages {
+				commits = append(commits, git.LogCommit{Hash: "commit" + string(rune('0'+i)) + "abc", Message: message})
+			}
+			commits = append(commits, git.LogCommit{Hash: "merge", Message: "feat!: Merge branch 'topic'", Merge: true})
+
+			bump, breaking := bumpOf(commits)
+			if bump != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, bump)
+			}
+			if !reflect.DeepEqual(breaking, tt.expectedBreaking) {
+				t.Errorf("expected breaking %q, got %q", tt.expectedBreaking, breaking)
+			}
+		})
+	}
+}
+
+func TestApp_Semver(t *testing.T) {
+	commits := []git.LogCommit{
+		{Hash: "bbbbbbbbbb", Message: "feat(cli): added the semver command"},
+		{Hash: "aaaaaaaaaa", Message: "fix(auth): handled expired tokens"},
+	}
+
+	tests := []struct {
+		name           string
+		opts           SemverOptions
+		tags           []string
+		check          *ai.BreakingCheck
+		expectedRange  string
+		expectedOutput string
+		expectedError  string
+	}{
+		{
+			name:           "Bump since the latest tag",
+			tags:           []string{"latest", "v1.2.0", "v1.10.0-rc.1", "v1.9.1"},
+			expectedRange:  "v1.10.0-rc.1..HEAD",
+			expectedOutput: "minor\n",
+		},
+		{
+			name:           "Next version of a range",
+			opts:           SemverOptions{Range: "1.2.0..HEAD", Next: true},
+			expectedRange:  "1.2.0..HEAD",
+			expectedOutput: "1.3.0\n",
+		},
+		{
+			name:          "JSON with a breaking staged change",
+			opts:          SemverOptions{Range: "v1.2.0..main", Staged: true, JSON: true},
+			check:         &ai.BreakingCheck{Breaking: true, Reason: "NewClient takes options now."},
+			expectedRange: "v1.2.0..main",
+			expectedOutput: `{
+  "range": "v1.2.0..main",
+  "bump": "major",
+  "current": "v1.2.0",
+  "next": "v2.0.0",
+  "staged": {
+    "breaking": true,
+    "reason": "NewClient takes options now."
+  }
+}
+`,
+		},
+		{
+			name:           "Staged change that breaks nothing",
+			opts:           SemverOptions{Range: "v1.2.0..HEAD", Staged: true},
+			check:          &ai.BreakingCheck{},
+			expectedRange:  "v1.2.0..HEAD",
+			expectedOutput: "minor\n",
+		},
+		{
+			name:          "No version tag",
+			tags:          []string{"latest"},
+			expectedError: "no version tag was found",
+		},
+		{
+			name:          "Next needs a version to start from",
+			opts:          SemverOptions{Range: "main..HEAD", Next: true},
+			expectedError: "main is not a version",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var readRange string
+			mockGit := &MockGit{
+				IsInsideRepoFunc:     func() (bool, error) { return true, nil },
+				ListTagsFunc:         func() ([]string, error) { return tt.tags, nil },
+				HasStagedChangesFunc: func() (bool, error) { return true, nil },
+				GetStagedDiffFunc:    func() (string, error) { return "diff --git a/client.go b/client.go", nil },
+				GetCommitRangeFunc: func(revRange string) ([]git.LogCommit, error) {
+					readRange = revRange
+					return commits, nil
+				},
+			}
+			mockConfig := &MockConfig{
+				LoadRulesFunc: func() (string, error) { return "", nil },
+			}
+			mockAI := &MockAI{
+				CheckBreakingChangeFunc: func(req ai.CommitRequest) (*ai.BreakingCheck, error) {
+					if tt.check == nil {
+						return nil, errors.New("unexpected breaking change check")
+					}
+					return tt.check, nil
+				},
+			}
+			application := NewApp(mockGit, mockConfig, nil, mockAI)
+			application.Quiet = true
+
+			var err error
+			output := captureStdout(t, func() {
+				err = application.Semver(tt.opts)
+			})
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("expected error containing %q, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Semver failed: %v", err)
+			}
+			if readRange != tt.expectedRange {
+				t.Errorf("expected range %q, got %q", tt.expectedRange, readRange)
+			}
+			if output != tt.expectedOutput {
+				t.Errorf("expected %q, got %q", tt.expectedOutput, output)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"ai-commit-message-generator/internal/ai"
+	"ai-commit-message-generator/internal/git"
+)
+
+// ChangelogOptions controls Changelog
+type ChangelogOptions struct {
+	// Range selects the commits, e.g. v1.2.0..HEAD
+	Range string
+	// Style is ai.ChangelogKeepAChangelog (the default) or
+	// ai.ChangelogConventional
+	Style string
+	// Write prepends the section to CHANGELOG.md in the repository root
+	// instead of printing it
+	Write bool
+}
+
+// changelogFile is the file Changelog updates with Write
+const changelogFile = "CHANGELOG.md"
+
+// Changelog writes a changelog section for the commits in a range. Commits
+// are grouped by their Conventional Commits type before the model polishes
+// them; merge commits are left out. Only the markdown goes to stdout so it
+// can be piped; progress goes to stderr.
+func (a *App) Changelog(opts ChangelogOptions) error {
+	style := opts.Style
+	switch style {
+	case "":
+		style = ai.ChangelogKeepAChangelog
+	case ai.ChangelogKeepAChangelog, ai.ChangelogConventional:
+	default:
+		return fmt.Errorf("unknown changelog style %q (expected %q or %q)", style, ai.ChangelogKeepAChangelog, ai.ChangelogConventional)
+	}
+	_, to, err := git.SplitRange(opts.Range)
+	if err != nil {
+		return err
+	}
+
+	isRepo, err := a.Git.IsInsideRepo()
+	if err != nil {
+		return fmt.Errorf("failed to check repository status: %w", err)
+	}
+	if !isRepo {
+		return ErrNotARepo
+	}
+
+	defer a.Progress.Stop()
+	a.Progress.Phase(PhaseReadingDiff)
+	commits, err := a.Git.GetCommitRange(opts.Range)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", opts.Range, err)
+	}
+	if len(commits) == 0 {
+		return fmt.Errorf("no commits in %s", opts.Range)
+	}
+	groups := groupCommits(commits)
+	if len(groups) == 0 {
+		return fmt.Errorf("%s only contains merge commits; there is nothing to describe", opts.Range)
+	}
+
+	version := to
+	if version == "HEAD" {
+		version = "Unreleased"
+	}
+	if !a.Quiet && !a.Progress.Enabled() {
+		fmt.Fprintf(os.Stderr, "Generating changelog for %s...\n", opts.Range)
+	}
+	section, err := a.AI.GenerateChangelog(ai.ChangelogRequest{
+		Version: version,
+		Date:    time.Now().Format("2006-01-02"),
+		Style:   style,
+		Groups:  groups,
+	})
+	a.Progress.Stop()
+	if err != nil {
+		return fmt.Errorf("failed to generate changelog: %w", err)
+	}
+
+	if !opts.Write {
+		fmt.Println(section)
+		return nil
+	}
+	root, err := a.Git.GetRepoRoot()
+	if err != nil {
+		return err
+	}
+	if err := prependChangelog(filepath.Join(root, changelogFile), section); err != nil {
+		return err
+	}
+	if !a.Quiet {
+		fmt.Fprintf(os.Stderr, "\033[32m✓ Updated %s\033[0m\n", changelogFile)
+	}
+	return nil
+}
+
+// groupCommits sorts commits into one group per Conventional Commits type,
+// in the order of conventionalTypes, followed by the commits that do not
+// follow the convention. Merge commits are skipped.
+func groupCommits(commits []git.LogCommit) []ai.ChangelogGroup {
+	byType := make(map[string][]ai.ChangelogCommit)
+	for _, commit := range commits {
+		if commit.Merge {
+			continue
+		}
+		entry := ai.ChangelogCommit{Description: commit.Subject(), Hash: shortHash(commit.Hash)}
+		commitType := ""
+		if m := headerPattern.FindStringSubmatch(commit.Subject()); m != nil && isConventionalType(strings.ToLower(m[1])) {
+			commitType = strings.ToLower(m[1])
+			entry.Scope = m[3]
+			entry.Description = m[5]
+			entry.Breaking = m[4] == "!"
+		}
+		if hasBreakingFooter(commit.Message) {
+			entry.Breaking = true
+		}
+		byType[commitType] = append(byType[commitType], entry)
+	}
+
+	var groups []ai.ChangelogGroup
+	for _, commitType := range conventionalTypes {
+		if entries := byType[commitType]; len(entries) > 0 {
+			groups = append(groups, ai.ChangelogGroup{Type: commitType, Commits: entries})
+		}
+	}
+	if other := byType[""]; len(other) > 0 {
+		groups = append(groups, ai.ChangelogGroup{Commits: other})
+	}
+	return groups
+}
+
+// prependChangelog puts section above the newest entry of the changelog at
+// path: before its first "## " heading, or after the existing text when it
+// has none. A missing file is created with a "# Changelog" title.
+func prependChangelog(path, section string) error {
+	content, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var updated string
+	existing := string(content)
+	switch {
+	case os.IsNotExist(err):
+		updated = "# Changelog\n\n" + section + "\n"
+	case strings.HasPrefix(existing, "## "):
+		updated = section + "\n\n" + existing
+	case strings.Contains(existing, "\n## "):
+		i := strings.Index(existing, "\n## ") + 1
+		updated = existing[:i] + section + "\n\n" + existing[i:]
+	default:
+		updated = strings.TrimRight(existing, "\n") + "\n\n" + section + "\n"
+	}
+
+	if err := os.WriteFile(path, []byte(updated), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,245 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"ai-commit-message-generator/internal/ai"
+	"ai-commit-message-generator/internal/git"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func TestGroupCommits(t *testing.T) {
+	commits := []git.LogCommit{
+		{Hash: "aaaaaaaaaa", Message: "fix(auth): handled expired tokens"},
+		{Hash: "bbbbbbbbbb", Message: "Merge branch 'feature'", Merge: true},
+		{Hash: "cccccccccc", Message: "feat!: dropped the v1 API"},
+		{Hash: "dddddddddd", Message: "Update README"},
+		{Hash: "eeeeeeeeee", Message: "Feat(ui): added dark mode\n\nBREAKING CHANGE: the theme option is gone"},
+		{Hash: "ffffffffff", Message: "wip: half done"},
+	}
+
+	expected := []ai.ChangelogGroup{
+		{Type: "feat", Commits: []ai.ChangelogCommit{
+			{Description: "dropped the v1 API", Breaking: true, Hash: "ccccccc"},
+			{Scope: "ui", Description: "added dark mode", Breaking: true, Hash: "eeeeeee"},
+		}},
+		{Type: "fix", Commits: []ai.ChangelogCommit{
+			{Scope: "auth", Description: "handled expired tokens", Hash: "aaaaaaa"},
+		}},
+		{Commits: []ai.ChangelogCommit{
+			{Description: "Update README", Hash: "ddddddd"},
+			{Description: "wip: half done", Hash: "fffffff"},
+		}},
+	}
+	if groups := groupCommits(commits); !reflect.DeepEqual(groups, expected) {
+		t.Errorf("expected %+v, got %+v", expected, groups)
+	}
+}
+
+func TestApp_Changelog(t *testing.T) {
+	commits := []git.LogCommit{
+		{Hash: "aaaaaaaaaa", Message: "feat(api): added users endpoint"},
+		{Hash: "bbbbbbbbbb", Message: "fix: handled empty body"},
+	}
+
+	tests := []struct {
+		name            string
+		opts            ChangelogOptions
+		commits         []git.LogCommit
+		existing        string
+		expectedStyle   string
+		expectedVersion string
+		expectedOutput  string
+		expectedFile    string
+		expectedError   string
+	}{
+		{
+			name:            "Prints the section",
+			opts:            ChangelogOptions{Range: "v1.2.0..HEAD"},
+			commits:         commits,
+			expectedStyle:   ai.ChangelogKeepAChangelog,
+			expectedVersion: "Unreleased",
+			expectedOutput:  "## [Unreleased]\n\n### Added\n\n- Users endpoint\n",
+		},
+		{
+			name:            "Range end names the version",
+			opts:            ChangelogOptions{Range: "v1.2.0..v1.3.0", Style: ai.ChangelogConventional},
+			commits:         commits,
+			expectedStyle:   ai.ChangelogConventional,
+			expectedVersion: "v1.3.0",
+			expectedOutput:  "## [Unreleased]\n\n### Added\n\n- Users endpoint\n",
+		},
+		{
+			name:          "Write prepends above the newest entry",
+			opts:          ChangelogOptions{Range: "v1.2.0..HEAD", Write: true},
+			commits:       commits,
+			existing:      "# Changelog\n\nAll notable changes.\n\n## [1.2.0] - 2026-01-01\n\n### Fixed\n\n- Crash\n",
+			expectedStyle: ai.ChangelogKeepAChangelog,
+			expectedFile:  "# Changelog\n\nAll notable changes.\n\n## [Unreleased]\n\n### Added\n\n- Users endpoint\n\n## [1.2.0] - 2026-01-01\n\n### Fixed\n\n- Crash\n",
+		},
+		{
+			name:          "Write creates the file",
+			opts:          ChangelogOptions{Range: "v1.2.0..HEAD", Write: true},
+			commits:       commits,
+			expectedStyle: ai.ChangelogKeepAChangelog,
+			expectedFile:  "# Changelog\n\n## [Unreleased]\n\n### Added\n\n- Users endpoint\n",
+		},
+		{
+			name:          "Empty range",
+			opts:          ChangelogOptions{Range: "HEAD..HEAD"},
+			expectedError: "no commits in HEAD..HEAD",
+		},
+		{
+			name:          "Only merge commits",
+			opts:          ChangelogOptions{Range: "v1.2.0..HEAD"},
+			commits:       []git.LogCommit{{Hash: "aaaaaaaaaa", Message: "Merge branch 'x'", Merge: true}},
+			expectedError: "only contains merge commits",
+		},
+		{
+			name:          "Unknown style",
+			opts:          ChangelogOptions{Range: "v1.2.0..HEAD", Style: "gnu"},
+			expectedError: `unknown changelog style "gnu"`,
+		},
+		{
+			name:          "Not a range",
+			opts:          ChangelogOptions{Range: "v1.2.0"},
+			expectedError: "expected <from>..<to>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			if tt.existing != "" {
+				if err := os.WriteFile(filepath.Join(root, changelogFile), []byte(tt.existing), 0644); err != nil {
+					t.Fatalf("failed to write changelog: %v", err)
+				}
+			}
+			mockGit := &MockGit{
+				IsInsideRepoFunc:   func() (bool, error) { return true, nil },
+				GetRepoRootFunc:    func() (string, error) { return root, nil },
+				GetCommitRangeFunc: func(revRange string) ([]git.LogCommit, error) { return tt.commits, nil },
+			}
+			var req ai.ChangelogRequest
+			mockAI := &MockAI{
+				GenerateChangelogFunc: func(r ai.ChangelogRequest) (string, error) {
+					req = r
+					return "## [Unreleased]\n\n### Added\n\n- Users endpoint", nil
+				},
+			}
+			application := NewApp(mockGit, &MockConfig{}, nil, mockAI)
+			application.Quiet = true
+
+			var err error
+			output := captureStdout(t, func() {
+				err = application.Changelog(tt.opts)
+			})
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("expected error %q, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if req.Style != tt.expectedStyle {
+				t.Errorf("expected style %q, got %q", tt.expectedStyle, req.Style)
+			}
+			if tt.expectedVersion != "" && req.Version != tt.expectedVersion {
+				t.Errorf("expected version %q, got %q", tt.expectedVersion, req.Version)
+			}
+			if _, err := time.Parse("2006-01-02", req.Date); err != nil {
+				t.Errorf("expected a YYYY-MM-DD date, got %q", req.Date)
+			}
+			if output != tt.expectedOutput {
+				t.Errorf("expected output %q, got %q", tt.expectedOutput, output)
+			}
+			if tt.expectedFile != "" {
+				content, _ := os.ReadFile(filepath.Join(root, changelogFile))
+				if string(content) != tt.expectedFile {
+					t.Errorf("expected CHANGELOG.md:\n%s\ngot:\n%s", tt.expectedFile, content)
+				}
+			}
+		})
+	}
+}
+
+func TestApp_Changelog_Integration(t *testing.T) {
+	tempDir := t.TempDir()
+	originalWd, _ := os.Getwd()
+	t.Cleanup(func() { _ = os.Chdir(originalWd) })
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatalf("failed to change to temp dir: %v", err)
+	}
+	repo, err := gogit.PlainInit(tempDir, false)
+	if err != nil {
+		t.Fatalf("failed to git init: %v", err)
+	}
+	worktree, _ := repo.Worktree()
+	for i, message := range []string{
+		"chore: initial commit",
+		"feat(api): added users endpoint",
+		"Update README",
+		"fix(api): handled empty body",
+		"docs: documented the endpoint",
+		"feat(ui)!: replaced the theme option",
+	} {
+		hash, err := worktree.Commit(message, &gogit.CommitOptions{
+			Author:            &object.Signature{Name: "Test User", Email: "test@example.com", When: time.Date(2026, 1, 1, 0, i, 0, 0, time.UTC)},
+			AllowEmptyCommits: true,
+		})
+		if err != nil {
+			t.Fatalf("failed to commit: %v", err)
+		}
+		if i == 0 {
+			if _, err := repo.CreateTag("v1.0.0", hash, nil); err != nil {
+				t.Fatalf("failed to tag: %v", err)
+			}
+		}
+	}
+
+	var groups []ai.ChangelogGroup
+	mockAI := &MockAI{
+		GenerateChangelogFunc: func(req ai.ChangelogRequest) (string, error) {
+			groups = req.Groups
+			return "## [Unreleased]", nil
+		},
+	}
+	application := NewApp(git.NewClient(), &MockConfig{}, nil, mockAI)
+	application.Quiet = true
+	captureStdout(t, func() {
+		if err := application.Changelog(ChangelogOptions{Range: "v1.0.0..HEAD"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	var got []string
+	for _, group := range groups {
+		for _, c := range group.Commits {
+			entry := group.Type + "|" + c.Scope + "|" + c.Description
+			if c.Breaking {
+				entry += "|breaking"
+			}
+			got = append(got, entry)
+		}
+	}
+	expected := []string{
+		"feat|ui|replaced the theme option|breaking",
+		"feat|api|added users endpoint",
+		"fix|api|handled empty body",
+		"docs||documented the endpoint",
+		"||Update README",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
@@ -0,0 +1,103 @@
+package app
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// clipboardCommands lists the clipboard utilities to try, per platform
+var clipboardCommands = map[string][][]string{
+	"darwin":  {{"pbcopy"}},
+	"windows": {{"clip.exe"}},
+	"linux": {
+		{"wl-copy"},
+		{"xclip", "-selection", "clipboard"},
+		{"xsel", "--clipboard", "--input"},
+	},
+}
+
+// osc52Mechanism names the terminal escape sequence in reports
+const osc52Mechanism = "OSC 52 (terminal escape sequence)"
+
+// clipboard copies text with a platform utility or, when there is none or
+// the session is remote, with an OSC 52 escape sequence the terminal turns
+// into a clipboard write. Its fields reach the system, so tests can fake it.
+type clipboard struct {
+	goos     string
+	getenv   func(key string) string
+	lookPath func(file string) (string, error)
+	run      func(path string, args []string, stdin string) error
+	// openTTY opens the controlling terminal for the escape sequence
+	openTTY func() (io.WriteCloser, error)
+}
+
+// systemClipboard is the clipboard of the machine the tool runs on
+var systemClipboard = clipboard{
+	goos:     runtime.GOOS,
+	getenv:   os.Getenv,
+	lookPath: exec.LookPath,
+	run: func(path string, args []string, stdin string) error {
+		cmd := exec.Command(path, args...)
+		cmd.Stdin = strings.NewReader(stdin)
+		return cmd.Run()
+	},
+	openTTY: func() (io.WriteCloser, error) {
+		return os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	},
+}
+
+// copyToClipboard places text on the system clipboard and returns the
+// mechanism used
+func copyToClipboard(text string) (string, error) {
+	return systemClipboard.copy(text)
+}
+
+// copy places text on the clipboard and returns the mechanism used. Over
+// SSH a platform utility would fill the remote machine's clipboard, so the
+// escape sequence is tried first there.
+func (c clipboard) copy(text string) (string, error) {
+	remote := c.getenv("SSH_TTY") != "" || c.getenv("SSH_CONNECTION") != ""
+	if remote {
+		if err := c.osc52(text); err == nil {
+			return osc52Mechanism, nil
+		}
+	}
+	for _, command := range clipboardCommands[c.goos] {
+		path, err := c.lookPath(command[0])
+		if err != nil {
+			continue
+		}
+		if err := c.run(path, command[1:], text); err != nil {
+			return "", fmt.Errorf("%s failed: %w", command[0], err)
+		}
+		return command[0], nil
+	}
+	if !remote {
+		if err := c.osc52(text); err == nil {
+			return osc52Mechanism, nil
+		}
+	}
+	return "", errors.New("no clipboard utility found and no terminal for OSC 52")
+}
+
+// osc52 writes text to the terminal as an OSC 52 clipboard sequence. Inside
+// tmux the sequence is wrapped so tmux passes it on to the outer terminal.
+func (c clipboard) osc52(text string) error {
+	tty, err := c.openTTY()
+	if err != nil {
+		return err
+	}
+	defer tty.Close()
+	sequence := "\033]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a"
+	if c.getenv("TMUX") != "" {
+		sequence = "\033Ptmux;\033" + sequence + "\033\\"
+	}
+	_, err = io.WriteString(tty, sequence)
+	return err
+}
@@ -0,0 +1,126 @@
+package app
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// nopWriteCloser is a terminal that records what is written to it
+type nopWriteCloser struct{ *bytes.Buffer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+func TestClipboard_Copy(t *testing.T) {
+	tests := []struct {
+		name              string
+		goos              string
+		env               map[string]string
+		tools             []string
+		noTTY             bool
+		expectedMechanism string
+		expectedRun       string
+		expectedTTY       string
+		expectedError     string
+	}{
+		{
+			name:              "Platform utility",
+			goos:              "darwin",
+			tools:             []string{"pbcopy"},
+			expectedMechanism: "pbcopy",
+			expectedRun:       "/usr/bin/pbcopy",
+		},
+		{
+			name:              "First Linux utility found",
+			goos:              "linux",
+			tools:             []string{"xclip", "xsel"},
+			expectedMechanism: "xclip",
+			expectedRun:       "/usr/bin/xclip -selection clipboard",
+		},
+		{
+			name:              "OSC 52 without a utility",
+			goos:              "linux",
+			expectedMechanism: osc52Mechanism,
+			expectedTTY:       "\033]52;c;ZmVhdDogYWRkZWQgbG9naW4=\a",
+		},
+		{
+			name:              "OSC 52 first over SSH",
+			goos:              "linux",
+			env:               map[string]string{"SSH_TTY": "/dev/pts/1", "TMUX": "/tmp/tmux-1000/default,1,0"},
+			tools:             []string{"xclip"},
+			expectedMechanism: osc52Mechanism,
+			expectedTTY:       "\033Ptmux;\033\033]52;c;ZmVhdDogYWRkZWQgbG9naW4=\a\033\\",
+		},
+		{
+			name:              "Utility over SSH without a terminal",
+			goos:              "linux",
+			env:               map[string]string{"SSH_CONNECTION": "10.0.0.1 22 10.0.0.2 22"},
+			tools:             []string{"wl-copy"},
+			noTTY:             true,
+			expectedMechanism: "wl-copy",
+			expectedRun:       "/usr/bin/wl-copy",
+		},
+		{
+			name:          "Nothing available",
+			goos:          "linux",
+			noTTY:         true,
+			expectedError: "no clipboard utility found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ran, stdin string
+			tty := &bytes.Buffer{}
+			c := clipboard{
+				goos:   tt.goos,
+				getenv: func(key string) string { return tt.env[key] },
+				lookPath: func(file string) (string, error) {
+					if containsString(tt.tools, file) {
+						return "/usr/bin/" + file, nil
+					}
+					return "", errors.New("not found")
+				},
+				run: func(path string, args []string, input string) error {
+					ran = path
+					for _, arg := range args {
+						ran += " " + arg
+					}
+					stdin = input
+					return nil
+				},
+				openTTY: func() (io.WriteCloser, error) {
+					if tt.noTTY {
+						return nil, errors.New("no terminal")
+					}
+					return nopWriteCloser{tty}, nil
+				},
+			}
+
+			mechanism, err := c.copy("feat: added login")
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("expected error containing %q, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("copy failed: %v", err)
+			}
+			if mechanism != tt.expectedMechanism {
+				t.Errorf("expected mechanism %q, got %q", tt.expectedMechanism, mechanism)
+			}
+			if ran != tt.expectedRun {
+				t.Errorf("expected to run %q, got %q", tt.expectedRun, ran)
+			}
+			if ran != "" && stdin != "feat: added login" {
+				t.Errorf("expected the message on stdin, got %q", stdin)
+			}
+			if tty.String() != tt.expectedTTY {
+				t.Errorf("expected %q on the terminal, got %q", tt.expectedTTY, tty.String())
+			}
+		})
+	}
+}
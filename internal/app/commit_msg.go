@@ -0,0 +1,226 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"ai-commit-message-generator/internal/ai"
+	"ai-commit-message-generator/internal/git"
+	"ai-commit-message-generator/internal/logging"
+)
+
+// ErrLintFailed is returned by CommitMsgHook when the message violates the
+// commit conventions and was not fixed, so git aborts the commit
+var ErrLintFailed = errors.New("commit message does not follow the commit conventions")
+
+// conventionalTypes are the commit types accepted by the linter when the
+// types key does not list others, the same ones the prompt offers
+var conventionalTypes = ai.DefaultTypes
+
+// maxHeaderLength is the longest header the linter accepts
+const maxHeaderLength = 72
+
+// headerPattern matches "type(scope)!: subject"
+var headerPattern = regexp.MustCompile(`^([A-Za-z]+)(\(([^()]*)\))?(!)?: (.*)$`)
+
+// scissors follows the comment character on the line that marks the start
+// of the diff appended by git commit --verbose; everything below it is
+// discarded by git
+const scissors = " ------------------------ >8 ------------------------"
+
+// CommitMsgOptions controls the commit-msg hook
+type CommitMsgOptions struct {
+	// Fix asks the model to rewrite a non-compliant message instead of
+	// rejecting it
+	Fix bool
+}
+
+// CommitMsgHook is the entrypoint of the installed commit-msg hook. It lints
+// the message in msgFile and, with Fix, rewrites it into compliance.
+func (a *App) CommitMsgHook(msgFile string, opts CommitMsgOptions) error {
+	content, err := os.ReadFile(msgFile)
+	if err != nil {
+		return fmt.Errorf("failed to read commit message file: %w", err)
+	}
+
+	message := cleanMessage(string(content), a.fileCommentChar(string(content)))
+	if message == "" || isExemptMessage(message) {
+		// git aborts empty messages itself; merges and reverts are exempt
+		return nil
+	}
+
+	// The message is checked in the style of the area the staged files
+	// are in. Listing them only picks that area, so a failure is not
+	// worth a warning.
+	files, _ := a.Git.GetStagedFiles()
+	override := a.pathOverride(files)
+	var style ai.CommitRequest
+	a.applyStyle(&style, override)
+	violations := lintMessage(message, style)
+	if len(violations) == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(os.Stderr, "\033[33mCommit message problems:\033[0m")
+	for _, v := range violations {
+		fmt.Fprintf(os.Stderr, "  - %s\n", v)
+	}
+
+	if !opts.Fix {
+		return ErrLintFailed
+	}
+
+	fixed, err := a.fixMessage(style, files, override, message, violations)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("\nBefore:")
+	fmt.Println("\033[31m" + message + "\033[0m")
+	fmt.Println("After:")
+	fmt.Println("\033[32m" + fixed + "\033[0m")
+
+	if err := os.WriteFile(msgFile, []byte(fixed+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write commit message file: %w", err)
+	}
+	return nil
+}
+
+// fixMessage asks the model to rewrite message so that it no longer has the
+// given violations, keeping its meaning. req holds the style the message
+// is checked in, and files and override pick the rules.
+func (a *App) fixMessage(req ai.CommitRequest, files []git.StagedFile, override *PathOverride, message string, violations []string) (string, error) {
+	rules, err := a.loadRules(files, override)
+	if err != nil {
+		a.log().Warn("failed to load rules, proceeding without them", logging.KeyStage, "rules", logging.KeyError, err)
+	}
+	diff, err := a.Git.GetStagedDiff()
+	if err != nil {
+		a.log().Warn("failed to get diff, fixing the message without it", logging.KeyStage, "diff", logging.KeyError, err)
+	}
+
+	var instruction strings.Builder
+	if req.HeaderFormat.IsDefault() {
+		instruction.WriteString("Rewrite this commit message so it follows Conventional Commits and the commit rules. ")
+	} else {
+		instruction.WriteString(fmt.Sprintf("Rewrite this commit message so its first line is laid out as %q and it follows the commit rules. ", req.HeaderFormat.Layout()))
+	}
+	instruction.WriteString("Keep its meaning; do not describe changes it does not mention. Fix these problems:\n")
+	for _, v := range violations {
+		instruction.WriteString("- ")
+		instruction.WriteString(v)
+		instruction.WriteString("\n")
+	}
+
+	fmt.Println("Fixing commit message...")
+	req.Diff = diff
+	req.Rules = rules
+	fixed, err := a.refine(req, message, instruction.String())
+	if err != nil {
+		return "", fmt.Errorf("failed to fix commit message: %w", err)
+	}
+	fixed = strings.TrimSpace(fixed)
+
+	if remaining := lintMessage(fixed, req); len(remaining) > 0 {
+		return "", fmt.Errorf("%w: the rewritten message still has problems: %s", ErrLintFailed, strings.Join(remaining, "; "))
+	}
+	return fixed, nil
+}
+
+// lintMessage checks message against the Conventional Commits format in the
+// house style of req, its HeaderFormat, ScopePolicy, SubjectStyle, Types
+// and Scopes, and returns a description of each violation. A nil
+// HeaderFormat is the usual "type(scope): subject". The subject ends with a
+// period only when the style asks for one.
+func lintMessage(message string, req ai.CommitRequest) []string {
+	format, scopePolicy, style := req.HeaderFormat, req.ScopePolicy, req.SubjectStyle
+	lines := strings.Split(message, "\n")
+	header := lines[0]
+
+	var violations []string
+	if length := utf8.RuneCountInString(header); length > maxHeaderLength {
+		violations = append(violations, fmt.Sprintf("header is %d characters, the limit is %d", length, maxHeaderLength))
+	}
+
+	parsed, ok := format.Parse(header)
+	if !ok && format.IsDefault() {
+		violations = append(violations, `header must look like "type(scope): subject", e.g. "fix(auth): handle expired tokens"`)
+	} else if !ok {
+		violations = append(violations, fmt.Sprintf("header must look like %q", format.Layout()))
+	} else {
+		commitType, hasScope, scope, subject := parsed.Type, parsed.HasScope, parsed.Scope, parsed.Description
+		if violation := typeViolation(commitType, req); violation != "" {
+			violations = append(violations, violation)
+		}
+		switch {
+		case scopePolicy == ai.ScopeForbidden && hasScope:
+			violations = append(violations, "header must not have a scope (scope_policy is forbidden)")
+		case scopePolicy == ai.ScopeRequired && strings.TrimSpace(scope) == "":
+			violations = append(violations, "header must have a scope (scope_policy is required)")
+		case hasScope && strings.TrimSpace(scope) == "":
+			violations = append(violations, "scope must not be empty; drop the parentheses instead")
+		case scopeViolation(scope, req) != "":
+			violations = append(violations, scopeViolation(scope, req))
+		}
+		if strings.TrimSpace(subject) == "" {
+			violations = append(violations, "subject must not be empty")
+		} else if !style.TrailingPeriod && strings.HasSuffix(subject, ".") {
+			violations = append(violations, "subject must not end with a period")
+		} else if style.TrailingPeriod && !strings.HasSuffix(subject, ".") {
+			violations = append(violations, "subject must end with a period (subject_trailing_period is set)")
+		}
+	}
+
+	if len(lines) > 1 && strings.TrimSpace(lines[1]) != "" {
+		violations = append(violations, "the header must be followed by a blank line before the body")
+	}
+	return violations
+}
+
+// isConventionalType reports whether t is one of conventionalTypes
+func isConventionalType(t string) bool {
+	for _, known := range conventionalTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// isExemptMessage reports whether message was written by git itself (merges,
+// reverts, autosquash markers) and so is not linted
+func isExemptMessage(message string) bool {
+	for _, prefix := range []string{"Merge ", "Revert \"", "fixup! ", "squash! ", "amend! "} {
+		if strings.HasPrefix(message, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// cleanMessage strips what git strips before committing: everything below
+// the scissors line and the lines starting with commentChar
+func cleanMessage(content, commentChar string) string {
+	if i := strings.Index(content, commentChar+scissors); i >= 0 {
+		content = content[:i]
+	}
+	return stripCommentLines(content, commentChar)
+}
+
+// fileCommentChar returns the comment character of a message file git
+// wrote, following core.commentChar
+func (a *App) fileCommentChar(content string) string {
+	setting := a.commentChar()
+	if setting == git.CommentCharAuto {
+		// The diff of git commit --verbose follows the comments; the
+		// scissors line is the last line git wrote itself
+		if i := strings.Index(content, scissors); i > 0 {
+			content = content[:i]
+		}
+	}
+	return git.FileCommentChar(setting, content)
+}
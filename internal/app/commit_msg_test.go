@@ -0,0 +1,301 @@
+package app
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ai-commit-message-generator/internal/ai"
+)
+
+func TestLintMessage(t *testing.T) {
+	scopeFirst := "[{{.Scope}}] {{.Type}}: {{.Description}}"
+
+	tests := []struct {
+		name    string
+		message string
+		// format is the header_format; empty is the default
+		format      string
+		scopePolicy string
+		style       ai.SubjectStyle
+		types       []string
+		scopes      []string
+		expected    []string
+	}{
+		{
+			name:    "Valid header",
+			message: "feat(auth): add OAuth2 login",
+		},
+		{
+			name:    "Valid header with body and breaking marker",
+			message: "refactor!: drop the v1 API\n\nClients must move to v2.",
+		},
+		{
+			name:     "Missing type",
+			message:  "added login",
+			expected: []string{"header must look like"},
+		},
+		{
+			name:     "Unknown type",
+			message:  "feature: add login",
+			expected: []string{`unknown type "feature"`},
+		},
+		{
+			name:     "Empty scope",
+			message:  "fix(): handle nil",
+			expected: []string{"scope must not be empty"},
+		},
+		{
+			name:     "Trailing period",
+			message:  "fix: handle nil.",
+			expected: []string{"must not end with a period"},
+		},
+		{
+			name:     "Header too long",
+			message:  "fix: " + strings.Repeat("a", 80),
+			expected: []string{"the limit is 72"},
+		},
+		{
+			name:     "Body without blank line",
+			message:  "fix: handle nil\nbody right away",
+			expected: []string{"followed by a blank line"},
+		},
+		{
+			name:    "Custom layout",
+			message: "[auth] feat: add OAuth2 login",
+			format:  scopeFirst,
+		},
+		{
+			name:    "Custom layout without scope",
+			message: "feat: add OAuth2 login",
+			format:  scopeFirst,
+		},
+		{
+			name:     "Custom layout rejects the default",
+			message:  "feat(auth): add OAuth2 login",
+			format:   scopeFirst,
+			expected: []string{`header must look like "[<scope>] <type>: <description>"`},
+		},
+		{
+			name:     "Custom layout still checks the type",
+			message:  "[auth] feature: add OAuth2 login",
+			format:   scopeFirst,
+			expected: []string{`unknown type "feature"`},
+		},
+		{
+			name:        "Required scope present",
+			message:     "feat(auth): add OAuth2 login",
+			scopePolicy: ai.ScopeRequired,
+		},
+		{
+			name:        "Required scope missing",
+			message:     "feat: add OAuth2 login",
+			scopePolicy: ai.ScopeRequired,
+			expected:    []string{"header must have a scope"},
+		},
+		{
+			name:        "Required scope empty",
+			message:     "feat(): add OAuth2 login",
+			scopePolicy: ai.ScopeRequired,
+			expected:    []string{"header must have a scope"},
+		},
+		{
+			name:        "Required scope in a custom layout",
+			message:     "feat: add OAuth2 login",
+			format:      scopeFirst,
+			scopePolicy: ai.ScopeRequired,
+			expected:    []string{"header must have a scope"},
+		},
+		{
+			name:        "Forbidden scope present",
+			message:     "feat(auth)!: drop the v1 API",
+			scopePolicy: ai.ScopeForbidden,
+			expected:    []string{"header must not have a scope"},
+		},
+		{
+			name:        "Forbidden scope absent",
+			message:     "feat!: drop the v1 API",
+			scopePolicy: ai.ScopeForbidden,
+		},
+		{
+			name:    "Trailing period asked for",
+			message: "feat(auth): Add OAuth2 login.",
+			style:   ai.SubjectStyle{Capitalize: true, TrailingPeriod: true},
+		},
+		{
+			name:    "Allowed scope",
+			message: "feat(ui): add OAuth2 login",
+			scopes:  []string{"ui", "docs"},
+		},
+		{
+			name:     "Scope not allowed",
+			message:  "feat(api): add OAuth2 login",
+			scopes:   []string{"ui", "docs"},
+			expected: []string{`scope "api" is not one of: ui, docs`},
+		},
+		{
+			name:    "Custom type",
+			message: "perf(git): cache the index",
+			types:   []string{"feat", "fix", "perf"},
+		},
+		{
+			name:     "Type left out of the custom list",
+			message:  "chore: bump deps",
+			types:    []string{"feat", "fix", "perf"},
+			expected: []string{`unknown type "chore", expected one of: feat, fix, perf`},
+		},
+		{
+			name:     "Trailing period missing",
+			message:  "feat(auth): add OAuth2 login",
+			style:    ai.SubjectStyle{TrailingPeriod: true},
+			expected: []string{"subject must end with a period"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			format, err := ai.ParseHeaderFormat(tt.format)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			violations := lintMessage(tt.message, ai.CommitRequest{HeaderFormat: format, ScopePolicy: tt.scopePolicy, SubjectStyle: tt.style, Types: tt.types, Scopes: tt.scopes})
+			if len(violations) != len(tt.expected) {
+				t.Fatalf("expected %d violations, got %q", len(tt.expected), violations)
+			}
+			for i, want := range tt.expected {
+				if !strings.Contains(violations[i], want) {
+					t.Errorf("expected violation containing %q, got %q", want, violations[i])
+				}
+			}
+		})
+	}
+}
+
+func TestApp_CommitMsgHook(t *testing.T) {
+	tests := []struct {
+		name            string
+		content         string
+		commentChar     string
+		fix             bool
+		response        string
+		expectedError   error
+		expectedContent string
+		expectAICall    bool
+	}{
+		{
+			name:            "Compliant message passes",
+			content:         "fix(auth): handle expired tokens\n# Please enter the commit message\n",
+			expectedContent: "fix(auth): handle expired tokens\n# Please enter the commit message\n",
+		},
+		{
+			name:            "Violation is reported without fix",
+			content:         "Fixed the login bug.\n",
+			expectedError:   ErrLintFailed,
+			expectedContent: "Fixed the login bug.\n",
+		},
+		{
+			name:            "Fix rewrites the message",
+			content:         "Fixed the login bug.\n",
+			fix:             true,
+			response:        "fix(auth): fix the login bug",
+			expectAICall:    true,
+			expectedContent: "fix(auth): fix the login bug\n",
+		},
+		{
+			name:            "Fix that is still invalid keeps the original",
+			content:         "Fixed the login bug.\n",
+			fix:             true,
+			response:        "Fixed the login bug",
+			expectAICall:    true,
+			expectedError:   ErrLintFailed,
+			expectedContent: "Fixed the login bug.\n",
+		},
+		{
+			name:            "Merge message is exempt",
+			content:         "Merge branch 'feature' into main\n",
+			expectedContent: "Merge branch 'feature' into main\n",
+		},
+		{
+			name:            "Revert message is exempt",
+			content:         "Revert \"feat: add login\"\n\nThis reverts commit abc123.\n",
+			expectedContent: "Revert \"feat: add login\"\n\nThis reverts commit abc123.\n",
+		},
+		{
+			name:            "Verbose diff below scissors is ignored",
+			content:         "docs: update readme\n#" + scissors + "\ndiff --git a/README.md b/README.md\n",
+			expectedContent: "docs: update readme\n#" + scissors + "\ndiff --git a/README.md b/README.md\n",
+		},
+		{
+			name:            "Hash lines are kept with a custom comment character",
+			content:         "#42 Fixed the login bug\n; Please enter the commit message\n",
+			commentChar:     ";",
+			expectedError:   ErrLintFailed,
+			expectedContent: "#42 Fixed the login bug\n; Please enter the commit message\n",
+		},
+		{
+			name:            "Auto comment character with a verbose diff",
+			content:         "fix(auth): handle expired tokens\n\n#42 was the report\n; Please enter the commit message\n;" + scissors + "\n@@ -1 +1 @@\n",
+			commentChar:     "auto",
+			expectedContent: "fix(auth): handle expired tokens\n\n#42 was the report\n; Please enter the commit message\n;" + scissors + "\n@@ -1 +1 @@\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msgFile := filepath.Join(t.TempDir(), "COMMIT_EDITMSG")
+			if err := os.WriteFile(msgFile, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("failed to write message file: %v", err)
+			}
+
+			var requests []ai.CommitRequest
+			mockGit := &MockGit{
+				GetStagedDiffFunc: func() (string, error) { return "diff", nil },
+			}
+			if tt.commentChar != "" {
+				mockGit.GetCommentCharFunc = func() (string, error) { return tt.commentChar, nil }
+			}
+			mockConfig := &MockConfig{
+				LoadRulesFunc: func() (string, error) { return "Use the auth scope for login", nil },
+			}
+			mockAI := &MockAI{
+				GenerateCommitMessageFunc: func(req ai.CommitRequest) (string, error) {
+					requests = append(requests, req)
+					return tt.response, nil
+				},
+			}
+			application := NewApp(mockGit, mockConfig, nil, mockAI)
+
+			err := application.CommitMsgHook(msgFile, CommitMsgOptions{Fix: tt.fix})
+			if tt.expectedError != nil {
+				if !errors.Is(err, tt.expectedError) {
+					t.Errorf("expected error %v, got %v", tt.expectedError, err)
+				}
+			} else if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			if tt.expectAICall != (len(requests) > 0) {
+				t.Errorf("expected AI call=%v, got %d calls", tt.expectAICall, len(requests))
+			}
+			if len(requests) > 0 {
+				req := requests[0]
+				if req.PreviousMessage != strings.TrimSpace(tt.content) || req.Rules == "" || req.Diff != "diff" {
+					t.Errorf("unexpected fix request: %+v", req)
+				}
+				if !strings.Contains(req.Feedback, "header must look like") {
+					t.Errorf("expected violations in feedback, got %q", req.Feedback)
+				}
+			}
+
+			content, err := os.ReadFile(msgFile)
+			if err != nil {
+				t.Fatalf("failed to read message file: %v", err)
+			}
+			if string(content) != tt.expectedContent {
+				t.Errorf("expected content %q, got %q", tt.expectedContent, string(content))
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"ai-commit-message-generator/internal/config"
+)
+
+// ErrInvalidConfig is returned by ConfigValidate when the file has problems
+var ErrInvalidConfig = errors.New("configuration is invalid")
+
+// ConfigScope selects the file the config command operates on
+type ConfigScope struct {
+	// Global selects the per-user config instead of the repository's
+	Global bool
+}
+
+// configPath resolves the file for scope
+func (a *App) configPath(scope ConfigScope) (string, error) {
+	if scope.Global {
+		return config.GlobalConfigPath()
+	}
+	if a.ConfigStore == nil {
+		return "", errors.New("no config loader")
+	}
+	return a.ConfigStore.Path()
+}
+
+// ConfigGet prints the value of key
+func (a *App) ConfigGet(scope ConfigScope, key string) error {
+	path, err := a.configPath(scope)
+	if err != nil {
+		return err
+	}
+	value, ok, err := config.GetValue(path, key)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%s is not set in %s", key, path)
+	}
+	fmt.Println(value)
+	return nil
+}
+
+// ConfigSet validates and stores key in the config file
+func (a *App) ConfigSet(scope ConfigScope, key, value string) error {
+	path, err := a.configPath(scope)
+	if err != nil {
+		return err
+	}
+	if err := config.SetValue(path, key, value); err != nil {
+		return err
+	}
+	fmt.Printf("✓ Set %s in %s\n", key, path)
+	return nil
+}
+
+// ConfigSetKey stores the API key in the OS keychain and sets
+// api_key_source to keychain in the config file. If the keychain is
+// unavailable nothing is stored unless fileFallback allows the credentials
+// file.
+func (a *App) ConfigSetKey(scope ConfigScope, key string, fileFallback bool) error {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return errors.New("the API key is empty")
+	}
+	path, err := a.configPath(scope)
+	if err != nil {
+		return err
+	}
+
+	where, err := a.ConfigStore.StoreAPIKey(key, fileFallback)
+	if errors.Is(err, config.ErrKeyringUnavailable) {
+		credentials, _ := config.CredentialsPath()
+		return fmt.Errorf("%w; the key was not saved.\n"+
+			"Run again with --file-fallback to keep it in %s (readable only by you, but not encrypted),\n"+
+			"or export OLLAMA_API_KEY instead", err, credentials)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✓ Stored the API key in %s\n", where)
+
+	if err := config.SetValue(path, "api_key_source", config.APIKeySourceKeychain); err != nil {
+		return err
+	}
+	fmt.Printf("✓ Set api_key_source in %s\n", path)
+
+	if old, ok, _ := config.GetValue(path, "api_key"); ok && old != "" {
+		fmt.Printf("! %s still contains a plain-text api_key; remove it with your editor\n", path)
+	}
+	return nil
+}
+
+// ConfigList prints the effective value of every key, secrets masked, with
+// the layer it came from. With scope.Global only the global file is listed.
+func (a *App) ConfigList(scope ConfigScope) error {
+	if scope.Global {
+		path, err := a.configPath(scope)
+		if err != nil {
+			return err
+		}
+		return listConfigFile(path)
+	}
+	if a.ConfigStore == nil {
+		return errors.New("no config loader")
+	}
+
+	_, values, err := a.ConfigStore.LoadEffective()
+	if err != nil {
+		return err
+	}
+	keyWidth, valueWidth := 0, 0
+	for i, v := range values {
+		if spec, _ := config.LookupKey(v.Key); spec.Secret {
+			values[i].Value = config.MaskSecret(v.Value)
+		}
+		if values[i].Value == "" {
+			values[i].Value = `""`
+		}
+		keyWidth = max(keyWidth, len(v.Key))
+		valueWidth = max(valueWidth, len(values[i].Value))
+	}
+	for _, v := range values {
+		source := v.Source
+		if v.Origin != "" {
+			source += " (" + v.Origin + ")"
+		}
+		fmt.Printf("%-*s  %-*s  %s\n", keyWidth, v.Key, valueWidth, v.Value, source)
+	}
+	return nil
+}
+
+// listConfigFile prints every key with its value in a single config file.
+// Keys the file does not set are listed as unset.
+func listConfigFile(path string) error {
+	values, err := config.ReadValues(path)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("# %s\n", path)
+	width := 0
+	for _, name := range config.ValidKeys() {
+		width = max(width, len(name))
+	}
+	for _, spec := range config.Schema {
+		value := "(not set)"
+		if _, ok := values[spec.Name]; ok {
+			value, _, _ = config.GetValue(path, spec.Name)
+			if spec.Secret {
+				value = config.MaskSecret(value)
+			}
+		}
+		fmt.Printf("%-*s  %s\n", width, spec.Name, value)
+	}
+	return nil
+}
+
+// ConfigValidate checks the config file and reports every problem
+func (a *App) ConfigValidate(scope ConfigScope) error {
+	path, err := a.configPath(scope)
+	if err != nil {
+		return err
+	}
+
+	problems := config.ValidateFile(path)
+	if len(problems) == 0 {
+		fmt.Printf("\033[32m✓ %s is valid\033[0m\n", path)
+		return nil
+	}
+	messages := make([]string, len(problems))
+	for i, problem := range problems {
+		messages[i] = problem.Error()
+		fmt.Printf("\033[31m✗ %s\033[0m\n", problem)
+	}
+	return fmt.Errorf("%w: %s", ErrInvalidConfig, strings.Join(messages, "; "))
+}
@@ -0,0 +1,290 @@
+package app
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"ai-commit-message-generator/internal/config"
+)
+
+// fakeKeyring is an in-memory OS keychain
+type fakeKeyring struct {
+	secrets     map[string]string
+	unavailable bool
+}
+
+func (f *fakeKeyring) Get(service, user string) (string, error) {
+	if f.unavailable {
+		return "", config.ErrKeyringUnavailable
+	}
+	secret, ok := f.secrets[service+"/"+user]
+	if !ok {
+		return "", config.ErrSecretNotFound
+	}
+	return secret, nil
+}
+
+func (f *fakeKeyring) Set(service, user, secret string) error {
+	if f.unavailable {
+		return config.ErrKeyringUnavailable
+	}
+	if f.secrets == nil {
+		f.secrets = make(map[string]string)
+	}
+	f.secrets[service+"/"+user] = secret
+	return nil
+}
+
+func newConfigApp(t *testing.T, content string) (*App, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), ".commit-generator-config")
+	if content != "" {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+	}
+	return NewApp(&MockGit{}, &MockConfig{}, config.NewConfigLoaderWithPath(path), nil), path
+}
+
+func TestApp_ConfigGet(t *testing.T) {
+	tests := []struct {
+		name           string
+		key            string
+		expectedOutput string
+		expectedError  string
+	}{
+		{name: "Set key", key: "model", expectedOutput: "llama3\n"},
+		{name: "Number key", key: "timeout_seconds", expectedOutput: "30\n"},
+		{name: "Unset key", key: "system_prompt", expectedError: "is not set"},
+		{name: "Unknown key", key: "provider", expectedError: "valid keys"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			application, _ := newConfigApp(t, `{"model": "llama3", "timeout_seconds": 30}`)
+
+			var err error
+			output := captureStdout(t, func() {
+				err = application.ConfigGet(ConfigScope{}, tt.key)
+			})
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("Expected error containing %q, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ConfigGet failed: %v", err)
+			}
+			if output != tt.expectedOutput {
+				t.Errorf("Expected output %q, got %q", tt.expectedOutput, output)
+			}
+		})
+	}
+}
+
+func TestApp_ConfigSet(t *testing.T) {
+	application, path := newConfigApp(t, "")
+
+	captureStdout(t, func() {
+		if err := application.ConfigSet(ConfigScope{}, "timeout_seconds", "90s"); err != nil {
+			t.Fatalf("ConfigSet failed: %v", err)
+		}
+	})
+	cfg, err := config.NewConfigLoaderWithPath(path).LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.TimeoutSeconds != 90 {
+		t.Errorf("Expected timeout 90, got %d", cfg.TimeoutSeconds)
+	}
+
+	err = application.ConfigSet(ConfigScope{}, "timeout_seconds", "later")
+	if err == nil || !strings.Contains(err.Error(), "invalid value for timeout_seconds") {
+		t.Errorf("Expected invalid value error, got %v", err)
+	}
+	err = application.ConfigSet(ConfigScope{}, "provider", "openai")
+	if err == nil || !strings.Contains(err.Error(), "valid keys: api_key") {
+		t.Errorf("Expected unknown key error listing valid keys, got %v", err)
+	}
+}
+
+func TestApp_ConfigSetKey(t *testing.T) {
+	tests := []struct {
+		name           string
+		unavailable    bool
+		fileFallback   bool
+		expectedError  string
+		expectedOutput []string
+	}{
+		{
+			name:           "Stored in the keychain",
+			expectedOutput: []string{"Stored the API key in the OS keychain", "Set api_key_source"},
+		},
+		{
+			name:          "Keychain unavailable",
+			unavailable:   true,
+			expectedError: "--file-fallback",
+		},
+		{
+			name:           "Keychain unavailable with file fallback",
+			unavailable:    true,
+			fileFallback:   true,
+			expectedOutput: []string{filepath.Join("ai-commit", "credentials")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+			application, path := newConfigApp(t, `{"model": "llama3"}`)
+			application.ConfigStore.(*config.ConfigLoader).SetKeyring(&fakeKeyring{unavailable: tt.unavailable})
+
+			var err error
+			output := captureStdout(t, func() {
+				err = application.ConfigSetKey(ConfigScope{}, "sk-secret\n", tt.fileFallback)
+			})
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("Expected error containing %q, got %v", tt.expectedError, err)
+				}
+				if _, ok, _ := config.GetValue(path, "api_key_source"); ok {
+					t.Errorf("Expected api_key_source to stay unset when nothing was stored")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ConfigSetKey failed: %v", err)
+			}
+			for _, want := range tt.expectedOutput {
+				if !strings.Contains(output, want) {
+					t.Errorf("Expected %q in output, got:\n%s", want, output)
+				}
+			}
+
+			cfg, err := application.ConfigStore.LoadConfig()
+			if err != nil {
+				t.Fatalf("LoadConfig failed: %v", err)
+			}
+			key, err := application.ConfigStore.ResolveAPIKey(cfg)
+			if err != nil || key != "sk-secret" {
+				t.Errorf("Expected the stored key to resolve, got %q, %v", key, err)
+			}
+		})
+	}
+}
+
+func TestApp_ConfigSet_Global(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("XDG_CONFIG_HOME only applies on Linux")
+	}
+	configHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	application, repoPath := newConfigApp(t, "")
+
+	captureStdout(t, func() {
+		if err := application.ConfigSet(ConfigScope{Global: true}, "model", "llama3"); err != nil {
+			t.Fatalf("ConfigSet failed: %v", err)
+		}
+	})
+
+	value, ok, err := config.GetValue(filepath.Join(configHome, "ai-commit", "config"), "model")
+	if err != nil || !ok || value != "llama3" {
+		t.Errorf("Expected model in the global config, got %q ok=%v err=%v", value, ok, err)
+	}
+	if _, err := os.Stat(repoPath); !os.IsNotExist(err) {
+		t.Errorf("Expected the repository config to be untouched, got %v", err)
+	}
+}
+
+func TestApp_ConfigList(t *testing.T) {
+	configHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	t.Setenv("OLLAMA_API_KEY", "")
+	t.Setenv("AI_COMMIT_BASE_URL", "http://ci:11434/api/generate")
+	application, _ := newConfigApp(t, `{"api_key": "sk-1234567890abcdef", "model": "llama3"}`)
+
+	var err error
+	output := captureStdout(t, func() {
+		err = application.ConfigList(ConfigScope{})
+	})
+	if err != nil {
+		t.Fatalf("ConfigList failed: %v", err)
+	}
+
+	if strings.Contains(output, "sk-1234567890abcdef") {
+		t.Errorf("Expected the api_key to be masked, got:\n%s", output)
+	}
+	for _, want := range []string{"sk-1********", "llama3", "repo (", "http://ci:11434/api/generate", "env (AI_COMMIT_BASE_URL)", "default"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected %q in output, got:\n%s", want, output)
+		}
+	}
+}
+
+func TestApp_ConfigList_Global(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("XDG_CONFIG_HOME only applies on Linux")
+	}
+	configHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	globalPath := filepath.Join(configHome, "ai-commit", "config")
+	if err := config.SetValue(globalPath, "api_key", "sk-1234567890abcdef"); err != nil {
+		t.Fatalf("SetValue failed: %v", err)
+	}
+	application, _ := newConfigApp(t, `{"model": "llama3"}`)
+
+	var err error
+	output := captureStdout(t, func() {
+		err = application.ConfigList(ConfigScope{Global: true})
+	})
+	if err != nil {
+		t.Fatalf("ConfigList failed: %v", err)
+	}
+	for _, want := range []string{globalPath, "sk-1********", "model                    (not set)"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected %q in output, got:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "sk-1234567890abcdef") {
+		t.Errorf("Expected the api_key to be masked, got:\n%s", output)
+	}
+}
+
+func TestApp_ConfigValidate(t *testing.T) {
+	tests := []struct {
+		name           string
+		content        string
+		expectedError  error
+		expectedOutput string
+	}{
+		{name: "Valid config", content: `{"model": "llama3"}`, expectedOutput: "is valid"},
+		{
+			name:           "Invalid config",
+			content:        `{"model": "llama3", "timeout_seconds": "soon", "temperature": 0.2}`,
+			expectedError:  ErrInvalidConfig,
+			expectedOutput: `unknown config key "temperature"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			application, _ := newConfigApp(t, tt.content)
+
+			var err error
+			output := captureStdout(t, func() {
+				err = application.ConfigValidate(ConfigScope{})
+			})
+			if !errors.Is(err, tt.expectedError) {
+				t.Errorf("Expected error %v, got %v", tt.expectedError, err)
+			}
+			if !strings.Contains(output, tt.expectedOutput) {
+				t.Errorf("Expected %q in output, got:\n%s", tt.expectedOutput, output)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"ai-commit-message-generator/internal/ai"
+)
+
+// conventionsFeedback asks the model to replace a type or scope the
+// repository does not allow
+const conventionsFeedback = "The first line of this message breaks the repository's conventions: %s. Rewrite the first line with an allowed type and scope. Keep the rest of the message as it is."
+
+// typeViolation describes why commitType is not allowed by req, or
+// returns "" when it is
+func typeViolation(commitType string, req ai.CommitRequest) string {
+	types := ai.AllowedTypes(req.Types)
+	if containsString(types, commitType) {
+		return ""
+	}
+	return fmt.Sprintf("unknown type %q, expected one of: %s", commitType, strings.Join(types, ", "))
+}
+
+// scopeViolation describes why scope is not allowed by req, or returns ""
+// when it is. Without Scopes any scope is.
+func scopeViolation(scope string, req ai.CommitRequest) string {
+	if scope == "" || len(req.Scopes) == 0 || containsString(req.Scopes, scope) {
+		return ""
+	}
+	return fmt.Sprintf("scope %q is not one of: %s", scope, strings.Join(req.Scopes, ", "))
+}
+
+// conventionViolations checks a generated message against the types and
+// scopes configured for req. Without either list nothing is checked, so
+// the model's choice stands as it did before the lists existed. With a
+// types list a header that does not follow the HeaderFormat of req is a
+// violation too, since its type cannot be checked.
+func conventionViolations(message string, req ai.CommitRequest) []string {
+	if len(req.Types) == 0 && len(req.Scopes) == 0 {
+		return nil
+	}
+	header, _, _ := strings.Cut(message, "\n")
+	h, ok := req.HeaderFormat.Parse(header)
+	if !ok {
+		if len(req.Types) == 0 {
+			return nil
+		}
+		return []string{fmt.Sprintf("header must look like %q", req.HeaderFormat.Layout())}
+	}
+	var violations []string
+	if len(req.Types) > 0 {
+		if violation := typeViolation(h.Type, req); violation != "" {
+			violations = append(violations, violation)
+		}
+	}
+	if violation := scopeViolation(strings.TrimSpace(h.Scope), req); violation != "" {
+		violations = append(violations, violation)
+	}
+	return violations
+}
+
+// enforceConventions keeps generated messages to the types and scopes
+// configured for req. A message that breaks them is asked for once more with the
+// problems spelled out, and a message that still breaks them is an error.
+// Split suggestions are left alone. It runs after applyScopePolicy, which
+// it applies again to the corrected message.
+func (a *App) enforceConventions(req ai.CommitRequest, message string) (string, error) {
+	if a.suggestsSplit(message) {
+		return message, nil
+	}
+	violations := conventionViolations(message, req)
+	if len(violations) == 0 {
+		return message, nil
+	}
+
+	a.status(fmt.Sprintf("The message breaks the repository's conventions (%s); asking for another...", strings.Join(violations, "; ")))
+	req.PreviousMessage = message
+	req.Feedback = fmt.Sprintf(conventionsFeedback, strings.Join(violations, "; "))
+	req.Explain = false
+	corrected, err := a.AI.GenerateCommitMessage(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to correct the message: %w", err)
+	}
+	corrected = req.HeaderFormat.Reformat(corrected)
+	if a.suggestsSplit(corrected) {
+		return "", fmt.Errorf("the model suggested a split instead of correcting the message: %q", corrected)
+	}
+	// The scope policy holds for the corrected message too
+	if corrected, err = a.applyScopePolicy(req, corrected); err != nil {
+		return "", err
+	}
+	if remaining := conventionViolations(corrected, req); len(remaining) > 0 {
+		return "", fmt.Errorf("the model's message still breaks the repository's conventions: %s", strings.Join(remaining, "; "))
+	}
+	return corrected, nil
+}
@@ -0,0 +1,109 @@
+package app
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"ai-commit-message-generator/internal/ai"
+)
+
+func TestApp_Run_Conventions(t *testing.T) {
+	tests := []struct {
+		name           string
+		types          []string
+		scopes         []string
+		responses      []string
+		expected       string
+		expectFeedback string
+		expectError    string
+	}{
+		{
+			name:      "Allowed type and scope",
+			types:     []string{"feat", "fix", "perf"},
+			scopes:    []string{"ai", "git"},
+			responses: []string{"perf(git): cached the index"},
+			expected:  "perf(git): cached the index\n",
+		},
+		{
+			name:           "Type outside the list is corrected",
+			types:          []string{"feat", "fix", "perf"},
+			responses:      []string{"refactor(git): cached the index", "perf(git): cached the index"},
+			expected:       "perf(git): cached the index\n",
+			expectFeedback: `unknown type "refactor", expected one of: feat, fix, perf`,
+		},
+		{
+			name:           "Scope outside the list is corrected",
+			scopes:         []string{"ai", "git"},
+			responses:      []string{"fix(server): handled timeouts", "fix(ai): handled timeouts"},
+			expected:       "fix(ai): handled timeouts\n",
+			expectFeedback: `scope "server" is not one of: ai, git`,
+		},
+		{
+			name:      "Any scope without a list",
+			responses: []string{"fix(server): handled timeouts"},
+			expected:  "fix(server): handled timeouts\n",
+		},
+		{
+			name:      "Nothing checked without lists",
+			responses: []string{"feature(app): added thing"},
+			expected:  "feature(app): added thing\n",
+		},
+		{
+			name:           "Header that does not parse is corrected when types are set",
+			types:          []string{"feat", "fix"},
+			responses:      []string{"Added the thing", "feat(app): added the thing"},
+			expected:       "feat(app): added the thing\n",
+			expectFeedback: `header must look like "<type>(<scope>): <description>"`,
+		},
+		{
+			name:        "Still outside the lists",
+			scopes:      []string{"ai", "git"},
+			responses:   []string{"fix(server): handled timeouts", "fix(api): handled timeouts"},
+			expectError: `still breaks the repository's conventions: scope "api" is not one of: ai, git`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests []ai.CommitRequest
+			mockAI := &MockAI{
+				GenerateCommitMessageFunc: func(req ai.CommitRequest) (string, error) {
+					requests = append(requests, req)
+					return tt.responses[min(len(requests), len(tt.responses))-1], nil
+				},
+			}
+			var committed []string
+			application, _ := newInteractiveApp(t, "", mockAI, &committed)
+			application.Quiet = true
+			application.Types = tt.types
+			application.Scopes = tt.scopes
+
+			var err error
+			stdout := captureStdout(t, func() {
+				err = application.Run(RunOptions{})
+			})
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Fatalf("expected error containing %q, got %v", tt.expectError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if !strings.Contains(stdout, tt.expected) {
+				t.Errorf("expected %q in stdout, got %q", tt.expected, stdout)
+			}
+			if len(requests) != len(tt.responses) {
+				t.Fatalf("expected %d requests, got %d", len(tt.responses), len(requests))
+			}
+			if !reflect.DeepEqual(tt.types, requests[0].Types) || !reflect.DeepEqual(tt.scopes, requests[0].Scopes) {
+				t.Errorf("expected the types %q and scopes %q in the request, got %q and %q", tt.types, tt.scopes, requests[0].Types, requests[0].Scopes)
+			}
+			if tt.expectFeedback != "" && !strings.Contains(requests[len(requests)-1].Feedback, tt.expectFeedback) {
+				t.Errorf("expected feedback containing %q, got %q", tt.expectFeedback, requests[len(requests)-1].Feedback)
+			}
+		})
+	}
+}
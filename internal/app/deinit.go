@@ -0,0 +1,126 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DeinitOptions controls removal of what init installed
+type DeinitOptions struct {
+	// Purge also deletes the config and rules files
+	Purge bool
+}
+
+// Deinit removes the hooks installed by init, restoring any hook that init
+// backed up or chained. Hooks without the generator marker are never
+// touched. It is safe to run repeatedly and on partial installs.
+func (a *App) Deinit(opts DeinitOptions) error {
+	isRepo, err := a.Git.IsInsideRepo()
+	if err != nil {
+		return fmt.Errorf("failed to check repository status: %w", err)
+	}
+	if !isRepo {
+		return fmt.Errorf("%w. Please run this command from within a git repository", ErrNotARepo)
+	}
+
+	repoRoot, err := a.Git.GetRepoRoot()
+	if err != nil {
+		return fmt.Errorf("failed to get repository root: %w", err)
+	}
+	hooksDir, err := a.Git.GetHooksDir()
+	if err != nil {
+		return fmt.Errorf("failed to get hooks directory: %w", err)
+	}
+
+	var actions, skipped []string
+	for _, hookName := range allHookNames {
+		r, k, err := removeHook(hooksDir, hookName)
+		if err != nil {
+			return err
+		}
+		actions = append(actions, r...)
+		skipped = append(skipped, k...)
+	}
+
+	if opts.Purge {
+		for _, name := range []string{".commit-generator-config", ".git-commit-rules-for-ai"} {
+			err := os.Remove(filepath.Join(repoRoot, name))
+			if err == nil {
+				actions = append(actions, "Deleted "+name)
+			} else if !os.IsNotExist(err) {
+				return fmt.Errorf("failed to delete %s: %w", name, err)
+			}
+		}
+	}
+
+	if len(actions) == 0 && len(skipped) == 0 {
+		fmt.Println("Nothing to remove.")
+		return nil
+	}
+	for _, line := range actions {
+		fmt.Printf("✓ %s\n", line)
+	}
+	for _, line := range skipped {
+		fmt.Printf("\033[33m! %s\033[0m\n", line)
+	}
+	return nil
+}
+
+// removeHook removes one installed hook, in whichever hook shell it was
+// written, and puts back the hook it replaced, returning summary lines for
+// what was done and what was left alone
+func removeHook(hooksDir, hookName string) (actions, skipped []string, err error) {
+	for _, shell := range hookShells {
+		a, k, err := removeHookAt(shellHookPath(hooksDir, hookName, shell), hookName)
+		if err != nil {
+			return nil, nil, err
+		}
+		actions = append(actions, a...)
+		skipped = append(skipped, k...)
+	}
+	return actions, skipped, nil
+}
+
+// removeHookAt removes the hook installed at path, and the PowerShell
+// script it starts, if init wrote them
+func removeHookAt(path, hookName string) (actions, skipped []string, err error) {
+	script := powerShellPath(path)
+	if content, err := os.ReadFile(script); err == nil && strings.Contains(string(content), hookMarker) {
+		if err := os.Remove(script); err != nil {
+			return nil, nil, fmt.Errorf("failed to remove %s hook: %w", hookName, err)
+		}
+	}
+
+	content, err := os.ReadFile(path)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return nil, nil, fmt.Errorf("failed to read %s hook: %w", hookName, err)
+	case !strings.Contains(string(content), hookMarker):
+		skipped = append(skipped, fmt.Sprintf("Left %s alone: it was not installed by generate-commit", path))
+		return nil, skipped, nil
+	default:
+		if err := os.Remove(path); err != nil {
+			return nil, nil, fmt.Errorf("failed to remove %s hook: %w", hookName, err)
+		}
+		actions = append(actions, fmt.Sprintf("Removed %s hook", hookName))
+	}
+
+	// The hook slot is free now; put back what init moved aside
+	for _, saved := range []string{chainedHookPath(path), path + ".backup"} {
+		if _, err := os.Stat(saved); err != nil {
+			continue
+		}
+		if _, err := os.Stat(path); err == nil {
+			skipped = append(skipped, fmt.Sprintf("Left %s in place: %s already exists", saved, path))
+			continue
+		}
+		if err := os.Rename(saved, path); err != nil {
+			return nil, nil, fmt.Errorf("failed to restore %s hook: %w", hookName, err)
+		}
+		actions = append(actions, fmt.Sprintf("Restored %s from %s", path, filepath.Base(saved)))
+	}
+	return actions, skipped, nil
+}
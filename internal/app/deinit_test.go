@@ -0,0 +1,158 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestApp_Deinit(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook files use the Unix names")
+	}
+	const ourHook = "#!/bin/bash\n# Pre-commit hook for AI commit message generator\nexec gc hook pre-commit\n"
+	const userHook = "#!/bin/sh\nnpx lint-staged\n"
+
+	tests := []struct {
+		name     string
+		purge    bool
+		files    map[string]string // relative to the repo root
+		expected map[string]string // content after deinit; "" means deleted
+	}{
+		{
+			name: "Partial install only removes what exists",
+			files: map[string]string{
+				"pre-commit": ourHook,
+			},
+			expected: map[string]string{
+				"pre-commit":               "",
+				"prepare-commit-msg":       "",
+				".commit-generator-config": "",
+			},
+		},
+		{
+			name: "Foreign hook is never deleted",
+			files: map[string]string{
+				"pre-commit": userHook,
+				"commit-msg": ourHook,
+			},
+			expected: map[string]string{
+				"pre-commit": userHook,
+				"commit-msg": "",
+			},
+		},
+		{
+			name: "Backup is restored",
+			files: map[string]string{
+				"pre-commit":        ourHook,
+				"pre-commit.backup": userHook,
+			},
+			expected: map[string]string{
+				"pre-commit":        userHook,
+				"pre-commit.backup": "",
+			},
+		},
+		{
+			name: "Chained hook is restored",
+			files: map[string]string{
+				"pre-commit":         ourHook,
+				"pre-commit.chained": userHook,
+			},
+			expected: map[string]string{
+				"pre-commit":         userHook,
+				"pre-commit.chained": "",
+			},
+		},
+		{
+			name: "PowerShell and batch hooks are removed",
+			files: map[string]string{
+				"pre-commit":         "#!/bin/sh\n# pre-commit hook for AI commit message generator\nexec pwsh -File \"$(dirname \"$0\")/pre-commit.ps1\"\n",
+				"pre-commit.ps1":     "# pre-commit hook for AI commit message generator (PowerShell)\n& \"gc\" hook pre-commit @args\n",
+				"commit-msg.bat":     "@echo off\nREM commit-msg hook for AI commit message generator (Windows)\n\"gc\" hook commit-msg %*\n",
+				"commit-msg.ps1.txt": "unrelated",
+			},
+			expected: map[string]string{
+				"pre-commit":         "",
+				"pre-commit.ps1":     "",
+				"commit-msg.bat":     "",
+				"commit-msg.ps1.txt": "unrelated",
+			},
+		},
+		{
+			name: "Backup does not replace a foreign hook",
+			files: map[string]string{
+				"pre-commit":        userHook,
+				"pre-commit.backup": "#!/bin/sh\nolder\n",
+			},
+			expected: map[string]string{
+				"pre-commit":        userHook,
+				"pre-commit.backup": "#!/bin/sh\nolder\n",
+			},
+		},
+		{
+			name:  "Purge deletes config and rules but keeps them otherwise",
+			purge: true,
+			files: map[string]string{
+				"../../.commit-generator-config": "{}",
+				"../../.git-commit-rules-for-ai": "rules",
+			},
+			expected: map[string]string{
+				"../../.commit-generator-config": "",
+				"../../.git-commit-rules-for-ai": "",
+			},
+		},
+		{
+			name: "Without purge config and rules stay",
+			files: map[string]string{
+				"../../.commit-generator-config": "{}",
+			},
+			expected: map[string]string{
+				"../../.commit-generator-config": "{}",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repoRoot := t.TempDir()
+			hooksDir := filepath.Join(repoRoot, ".git", "hooks")
+			if err := os.MkdirAll(hooksDir, 0755); err != nil {
+				t.Fatalf("failed to create hooks dir: %v", err)
+			}
+			for name, content := range tt.files {
+				if err := os.WriteFile(filepath.Join(hooksDir, name), []byte(content), 0755); err != nil {
+					t.Fatalf("failed to write %s: %v", name, err)
+				}
+			}
+
+			mockGit := &MockGit{
+				IsInsideRepoFunc: func() (bool, error) { return true, nil },
+				GetRepoRootFunc:  func() (string, error) { return repoRoot, nil },
+			}
+			application := NewApp(mockGit, &MockConfig{}, nil, nil)
+
+			// Running twice must be harmless
+			for run := 0; run < 2; run++ {
+				if err := application.Deinit(DeinitOptions{Purge: tt.purge}); err != nil {
+					t.Fatalf("run %d: unexpected error: %v", run+1, err)
+				}
+			}
+
+			for name, want := range tt.expected {
+				content, err := os.ReadFile(filepath.Join(hooksDir, name))
+				if want == "" {
+					if err == nil {
+						t.Errorf("expected %s to be removed, got:\n%s", name, content)
+					}
+					continue
+				}
+				if err != nil {
+					t.Errorf("expected %s to exist: %v", name, err)
+				} else if string(content) != want {
+					t.Errorf("expected %s to contain %q, got %q", name, want, content)
+				}
+			}
+		})
+	}
+}
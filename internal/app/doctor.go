@@ -0,0 +1,445 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"ai-commit-message-generator/internal/ai"
+	"ai-commit-message-generator/internal/config"
+)
+
+// ErrDoctorFailed is returned by Doctor when a hard check fails
+var ErrDoctorFailed = errors.New("doctor found problems that prevent generating commit messages")
+
+// Doctor check statuses
+const (
+	CheckOK   = "ok"
+	CheckWarn = "warn"
+	CheckFail = "fail"
+)
+
+// DoctorCheck is the result of one diagnostic
+type DoctorCheck struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Detail string `json:"detail"`
+	Hint   string `json:"hint,omitempty"`
+}
+
+// DoctorReport is the full doctor output
+type DoctorReport struct {
+	OK     bool          `json:"ok"`
+	Checks []DoctorCheck `json:"checks"`
+}
+
+// DoctorOptions controls the doctor command
+type DoctorOptions struct {
+	// JSON prints the report as JSON instead of ✓/✗ lines
+	JSON bool
+}
+
+// doctorHTTPTimeout bounds each request made to the provider
+const doctorHTTPTimeout = 5 * time.Second
+
+// hookExecPattern extracts the executable a hook script runs
+var hookExecPattern = regexp.MustCompile(`"([^"]+)" hook `)
+
+// Doctor checks the environment and configuration and prints a report.
+// It returns ErrDoctorFailed if any hard check fails.
+func (a *App) Doctor(opts DoctorOptions) error {
+	report := a.diagnose()
+
+	if opts.JSON {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal report: %w", err)
+		}
+		fmt.Println(string(data))
+	} else {
+		for _, check := range report.Checks {
+			switch check.Status {
+			case CheckOK:
+				fmt.Printf("\033[32m✓\033[0m %s: %s\n", check.Name, check.Detail)
+			case CheckWarn:
+				fmt.Printf("\033[33m!\033[0m %s: %s\n", check.Name, check.Detail)
+			default:
+				fmt.Printf("\033[31m✗\033[0m %s: %s\n", check.Name, check.Detail)
+			}
+			if check.Hint != "" && check.Status != CheckOK {
+				fmt.Printf("    → %s\n", check.Hint)
+			}
+		}
+	}
+
+	if !report.OK {
+		return ErrDoctorFailed
+	}
+	return nil
+}
+
+// diagnose runs every check. Checks that depend on an earlier failure
+// (e.g. hooks without a repository) are skipped.
+func (a *App) diagnose() DoctorReport {
+	var checks []DoctorCheck
+
+	repoCheck, inRepo := a.checkRepository()
+	checks = append(checks, repoCheck)
+	if inRepo {
+		checks = append(checks, a.checkHooks())
+	}
+
+	configCheck, cfg := a.checkConfig()
+	checks = append(checks, configCheck)
+	if cfg != nil {
+		keyCheck, apiKey := a.checkAPIKey(cfg)
+		checks = append(checks, keyCheck)
+		cfg.APIKey = apiKey
+		providerCheck, reachable := checkProvider(cfg)
+		checks = append(checks, providerCheck)
+		if reachable {
+			checks = append(checks, checkAuthentication(cfg), checkModel(cfg))
+		}
+	}
+
+	if inRepo {
+		checks = append(checks, a.checkRules(), a.checkStagedChanges())
+	}
+
+	report := DoctorReport{OK: true, Checks: checks}
+	for _, check := range checks {
+		if check.Status == CheckFail {
+			report.OK = false
+		}
+	}
+	return report
+}
+
+func (a *App) checkRepository() (DoctorCheck, bool) {
+	check := DoctorCheck{Name: "Git repository"}
+	isRepo, err := a.Git.IsInsideRepo()
+	if err != nil || !isRepo {
+		check.Status = CheckFail
+		check.Detail = "not inside a git repository"
+		check.Hint = "run generate-commit from inside a git repository"
+		return check, false
+	}
+
+	root, err := a.Git.GetRepoRoot()
+	if err != nil {
+		check.Status = CheckFail
+		check.Detail = fmt.Sprintf("failed to find the repository root: %v", err)
+		return check, false
+	}
+	check.Status = CheckOK
+	check.Detail = root
+	return check, true
+}
+
+func (a *App) checkHooks() DoctorCheck {
+	check := DoctorCheck{Name: "Git hooks"}
+	hooksDir, err := a.Git.GetHooksDir()
+	if err != nil {
+		check.Status = CheckFail
+		check.Detail = fmt.Sprintf("failed to find the hooks directory: %v", err)
+		return check
+	}
+
+	var installed, broken []string
+	for _, hookName := range allHookNames {
+		content := installedHook(hooksDir, hookName)
+		if content == "" {
+			continue
+		}
+		installed = append(installed, hookName)
+
+		match := hookExecPattern.FindStringSubmatch(string(content))
+		if match == nil {
+			broken = append(broken, fmt.Sprintf("%s (no generate-commit command found)", hookName))
+			continue
+		}
+		if _, err := os.Stat(match[1]); err != nil {
+			broken = append(broken, fmt.Sprintf("%s (%s does not exist)", hookName, match[1]))
+		}
+	}
+
+	switch {
+	case len(broken) > 0:
+		check.Status = CheckFail
+		check.Detail = "broken: " + strings.Join(broken, ", ")
+		check.Hint = "the binary moved; run 'generate-commit init --force' to reinstall the hooks"
+	case len(installed) == 0:
+		check.Status = CheckWarn
+		check.Detail = "no generate-commit hooks installed in " + hooksDir
+		check.Hint = "run 'generate-commit init' to install a hook, or run generate-commit manually"
+	default:
+		check.Status = CheckOK
+		check.Detail = strings.Join(installed, ", ") + " in " + hooksDir
+	}
+	return check
+}
+
+// installedHook returns the script of the hook init installed for
+// hookName, or "" if there is none. For a PowerShell hook it is the
+// PowerShell script, which names the binary.
+func installedHook(hooksDir, hookName string) string {
+	for _, shell := range hookShells {
+		path := shellHookPath(hooksDir, hookName, shell)
+		content, err := os.ReadFile(path)
+		if err != nil || !strings.Contains(string(content), hookMarker) {
+			continue
+		}
+		if script, err := os.ReadFile(powerShellPath(path)); err == nil && strings.Contains(string(content), ".ps1") {
+			return string(script)
+		}
+		return string(content)
+	}
+	return ""
+}
+
+func (a *App) checkConfig() (DoctorCheck, *config.Config) {
+	check := DoctorCheck{Name: "Config file"}
+	if a.ConfigStore == nil {
+		check.Status = CheckFail
+		check.Detail = "no config loader"
+		return check, nil
+	}
+
+	cfg, err := a.ConfigStore.LoadConfig()
+	if err != nil {
+		check.Status = CheckFail
+		check.Detail = err.Error()
+		check.Hint = "fix .commit-generator-config where the error points or re-create it with 'generate-commit init --force'"
+		return check, nil
+	}
+
+	exists, err := a.ConfigStore.ConfigExists()
+	if err != nil || !exists {
+		check.Status = CheckWarn
+		check.Detail = "no .commit-generator-config found, using the global config and defaults"
+		check.Hint = "run 'generate-commit init' to create one"
+		return check, cfg
+	}
+	check.Status = CheckOK
+	check.Detail = "parsed successfully"
+	return check, cfg
+}
+
+// checkAPIKey also returns the resolved key, which may come from the keychain
+func (a *App) checkAPIKey(cfg *config.Config) (DoctorCheck, string) {
+	check := DoctorCheck{Name: "API key"}
+	apiKey, err := a.ConfigStore.ResolveAPIKey(cfg)
+	if err != nil {
+		check.Status = CheckFail
+		check.Detail = err.Error()
+		check.Hint = "fix api_key_source, or run 'generate-commit config set-key' to use the keychain"
+		return check, ""
+	}
+	if apiKey == "" {
+		check.Status = CheckFail
+		check.Detail = "not set"
+		check.Hint = "run 'generate-commit config set-key', or export OLLAMA_API_KEY=..."
+		return check, ""
+	}
+	check.Status = CheckOK
+	check.Detail = "set"
+	if cfg.APIKeySource != "" {
+		check.Detail = "set (from " + cfg.APIKeySource + ")"
+	}
+	return check, apiKey
+}
+
+// providerRoot returns the scheme and host of the configured base URL
+func providerRoot(cfg *config.Config) (string, error) {
+	u, err := url.Parse(cfg.BaseURL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("invalid base_url %q", cfg.BaseURL)
+	}
+	return u.Scheme + "://" + u.Host, nil
+}
+
+func checkProvider(cfg *config.Config) (DoctorCheck, bool) {
+	check := DoctorCheck{Name: "Provider"}
+	root, err := providerRoot(cfg)
+	if err != nil {
+		check.Status = CheckFail
+		check.Detail = err.Error()
+		check.Hint = "set base_url to e.g. http://localhost:11434/api/generate"
+		return check, false
+	}
+
+	if err := pingProvider(root); err != nil {
+		check.Status = CheckFail
+		check.Detail = fmt.Sprintf("%s is not reachable: %v", root, err)
+		check.Hint = "start Ollama with 'ollama serve' or fix base_url"
+		return check, false
+	}
+
+	check.Status = CheckOK
+	check.Detail = root + " is reachable"
+	return check, true
+}
+
+// checkAuthentication asks the provider for a one-token generation, which
+// is the only way to know it accepts the API key
+func checkAuthentication(cfg *config.Config) DoctorCheck {
+	check := DoctorCheck{Name: "Authentication"}
+	status, err := testGeneration(cfg)
+	switch {
+	case err != nil:
+		check.Status = CheckWarn
+		check.Detail = fmt.Sprintf("the test generation failed: %v", err)
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		check.Status = CheckFail
+		check.Detail = fmt.Sprintf("the provider rejected the API key (%d %s)", status, http.StatusText(status))
+		check.Hint = "check the key with 'generate-commit config set-key', or export OLLAMA_API_KEY=..."
+	case status != http.StatusOK:
+		check.Status = CheckWarn
+		check.Detail = fmt.Sprintf("the test generation returned %d %s", status, http.StatusText(status))
+		check.Hint = "see the Model check below; a missing model fails the generation too"
+	default:
+		check.Status = CheckOK
+		check.Detail = "the API key was accepted"
+	}
+	return check
+}
+
+func checkModel(cfg *config.Config) DoctorCheck {
+	check := DoctorCheck{Name: "Model"}
+	root, _ := providerRoot(cfg)
+
+	models, err := listModels(root, cfg.APIKey, cfg.AuthHeader, cfg.AuthScheme)
+	if err != nil {
+		check.Status = CheckWarn
+		check.Detail = fmt.Sprintf("could not list models: %v", err)
+		return check
+	}
+
+	for _, name := range models {
+		if name == cfg.Model || name == cfg.Model+":latest" {
+			check.Status = CheckOK
+			check.Detail = cfg.Model + " is available"
+			return check
+		}
+	}
+	check.Status = CheckFail
+	check.Detail = cfg.Model + " is not available on the provider"
+	check.Hint = fmt.Sprintf("run 'ollama pull %s' or set model in .commit-generator-config", cfg.Model)
+	return check
+}
+
+// pingProvider checks that the provider at root answers HTTP requests
+func pingProvider(root string) error {
+	client := &http.Client{Timeout: doctorHTTPTimeout}
+	resp, err := client.Get(root + "/")
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// testGeneration sends base_url a generation of at most one token and
+// returns the HTTP status it answered with
+func testGeneration(cfg *config.Config) (int, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"model":   cfg.Model,
+		"prompt":  "Reply with OK.",
+		"stream":  false,
+		"options": map[string]int{"num_predict": 1},
+	})
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequest("POST", cfg.BaseURL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	ai.SetAuth(req.Header, cfg.AuthHeader, cfg.AuthScheme, cfg.APIKey)
+
+	client := &http.Client{Timeout: doctorHTTPTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// listModels returns the models the provider at root has available, sending
+// apiKey as authHeader and authScheme say
+func listModels(root, apiKey, authHeader, authScheme string) ([]string, error) {
+	client := &http.Client{Timeout: doctorHTTPTimeout}
+	req, err := http.NewRequest("GET", root+"/api/tags", nil)
+	if err != nil {
+		return nil, err
+	}
+	if apiKey != "" {
+		ai.SetAuth(req.Header, authHeader, authScheme, apiKey)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var tags struct {
+		Models []struct {
+			Name string `json:"name"`
+		} `json:"models"`
+	}
+	if resp.StatusCode != http.StatusOK || json.NewDecoder(resp.Body).Decode(&tags) != nil {
+		return nil, fmt.Errorf("unexpected response (%s)", resp.Status)
+	}
+
+	names := make([]string, len(tags.Models))
+	for i, m := range tags.Models {
+		names[i] = m.Name
+	}
+	return names, nil
+}
+
+func (a *App) checkRules() DoctorCheck {
+	check := DoctorCheck{Name: "Rules file"}
+	rules, err := a.RulesLoader.LoadRules(nil)
+	switch {
+	case err != nil:
+		check.Status = CheckWarn
+		check.Detail = fmt.Sprintf("failed to read: %v", err)
+	case rules == "":
+		check.Status = CheckWarn
+		check.Detail = "no .git-commit-rules-for-ai found"
+		check.Hint = "optional; run 'generate-commit init' to create a template"
+	default:
+		check.Status = CheckOK
+		check.Detail = ".git-commit-rules-for-ai found"
+	}
+	return check
+}
+
+func (a *App) checkStagedChanges() DoctorCheck {
+	check := DoctorCheck{Name: "Staged changes"}
+	hasChanges, err := a.Git.HasStagedChanges()
+	switch {
+	case err != nil:
+		check.Status = CheckWarn
+		check.Detail = fmt.Sprintf("failed to check: %v", err)
+	case !hasChanges:
+		check.Status = CheckWarn
+		check.Detail = "nothing staged right now"
+		check.Hint = "stage changes with 'git add' before generating a message"
+	default:
+		check.Status = CheckOK
+		check.Detail = "changes are staged"
+	}
+	return check
+}
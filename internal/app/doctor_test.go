@@ -0,0 +1,227 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ai-commit-message-generator/internal/config"
+)
+
+// doctorEnv describes a fake environment for the doctor checks
+type doctorEnv struct {
+	notInRepo      bool
+	hookExecutable string // "" installs no hook; "missing" points at a missing binary
+	config         string // config file content; "" means no file
+	providerDown   bool
+	rejectKey      bool
+	models         string
+	rules          string
+	staged         bool
+}
+
+func newDoctorApp(t *testing.T, env doctorEnv) *App {
+	t.Helper()
+	t.Setenv("OLLAMA_API_KEY", "")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/tags" {
+			w.Write([]byte(env.models))
+			return
+		}
+		if r.URL.Path == "/api/generate" {
+			if env.rejectKey || r.Header.Get("Authorization") != "Bearer secret-key" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`{"response": "OK", "done": true}`))
+			return
+		}
+		w.Write([]byte("Ollama is running"))
+	}))
+	t.Cleanup(server.Close)
+	baseURL := server.URL + "/api/generate"
+	if env.providerDown {
+		server.Close()
+	}
+
+	repoRoot := t.TempDir()
+	hooksDir := filepath.Join(repoRoot, ".git", "hooks")
+	if err := os.MkdirAll(hooksDir, 0755); err != nil {
+		t.Fatalf("failed to create hooks dir: %v", err)
+	}
+	if env.hookExecutable != "" {
+		exe := filepath.Join(repoRoot, "generate-commit")
+		if env.hookExecutable != "missing" {
+			if err := os.WriteFile(exe, []byte("binary"), 0755); err != nil {
+				t.Fatalf("failed to write fake binary: %v", err)
+			}
+		}
+		hook := "#!/bin/bash\n# Pre-commit hook for AI commit message generator\nexec \"" + exe + "\" hook pre-commit\n"
+		if err := os.WriteFile(hookPath(hooksDir, HookPreCommit), []byte(hook), 0755); err != nil {
+			t.Fatalf("failed to write hook: %v", err)
+		}
+	}
+
+	configPath := filepath.Join(repoRoot, ".commit-generator-config")
+	if env.config != "" {
+		content := strings.ReplaceAll(env.config, "BASE_URL", baseURL)
+		if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write config: %v", err)
+		}
+	}
+
+	mockGit := &MockGit{
+		IsInsideRepoFunc:     func() (bool, error) { return !env.notInRepo, nil },
+		GetRepoRootFunc:      func() (string, error) { return repoRoot, nil },
+		HasStagedChangesFunc: func() (bool, error) { return env.staged, nil },
+	}
+	mockConfig := &MockConfig{
+		LoadRulesFunc: func() (string, error) { return env.rules, nil },
+	}
+	configLoader := config.NewConfigLoaderWithPath(configPath)
+	configLoader.SetKeyring(&fakeKeyring{})
+	return NewApp(mockGit, mockConfig, configLoader, nil)
+}
+
+// healthyDoctorEnv passes every check
+func healthyDoctorEnv() doctorEnv {
+	return doctorEnv{
+		hookExecutable: "present",
+		config:         `{"api_key": "secret-key", "model": "llama3", "base_url": "BASE_URL"}`,
+		models:         `{"models": [{"name": "llama3:latest"}]}`,
+		rules:          "- Use past tense",
+		staged:         true,
+	}
+}
+
+func TestApp_Doctor_Checks(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(env *doctorEnv)
+		expectOK bool
+		expected map[string]string // check name -> status
+	}{
+		{
+			name:     "Healthy environment",
+			modify:   func(env *doctorEnv) {},
+			expectOK: true,
+			expected: map[string]string{
+				"Git repository": CheckOK, "Git hooks": CheckOK, "Config file": CheckOK, "API key": CheckOK,
+				"Provider": CheckOK, "Authentication": CheckOK, "Model": CheckOK, "Rules file": CheckOK, "Staged changes": CheckOK,
+			},
+		},
+		{
+			name:     "Not a repository",
+			modify:   func(env *doctorEnv) { env.notInRepo = true },
+			expected: map[string]string{"Git repository": CheckFail, "Git hooks": "", "Rules file": ""},
+		},
+		{
+			name:     "Hook points at a missing binary",
+			modify:   func(env *doctorEnv) { env.hookExecutable = "missing" },
+			expected: map[string]string{"Git hooks": CheckFail},
+		},
+		{
+			name:     "No hook installed is only a warning",
+			modify:   func(env *doctorEnv) { env.hookExecutable = "" },
+			expectOK: true,
+			expected: map[string]string{"Git hooks": CheckWarn},
+		},
+		{
+			name:     "Config does not parse",
+			modify:   func(env *doctorEnv) { env.config = `{"model": ` },
+			expected: map[string]string{"Config file": CheckFail, "API key": "", "Provider": ""},
+		},
+		{
+			name:     "Missing API key",
+			modify:   func(env *doctorEnv) { env.config = `{"model": "llama3", "base_url": "BASE_URL"}` },
+			expected: map[string]string{"API key": CheckFail},
+		},
+		{
+			name: "Keychain has no key",
+			modify: func(env *doctorEnv) {
+				env.config = `{"model": "llama3", "base_url": "BASE_URL", "api_key_source": "keychain"}`
+			},
+			expected: map[string]string{"API key": CheckFail},
+		},
+		{
+			name:     "Provider unreachable",
+			modify:   func(env *doctorEnv) { env.providerDown = true },
+			expected: map[string]string{"Provider": CheckFail, "Authentication": "", "Model": ""},
+		},
+		{
+			name:     "API key rejected",
+			modify:   func(env *doctorEnv) { env.rejectKey = true },
+			expected: map[string]string{"Authentication": CheckFail, "Model": CheckOK},
+		},
+		{
+			name:     "Model not pulled",
+			modify:   func(env *doctorEnv) { env.models = `{"models": [{"name": "mistral:latest"}]}` },
+			expected: map[string]string{"Model": CheckFail},
+		},
+		{
+			name: "No rules and nothing staged are warnings",
+			modify: func(env *doctorEnv) {
+				env.rules = ""
+				env.staged = false
+			},
+			expectOK: true,
+			expected: map[string]string{"Rules file": CheckWarn, "Staged changes": CheckWarn},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := healthyDoctorEnv()
+			tt.modify(&env)
+			report := newDoctorApp(t, env).diagnose()
+
+			if report.OK != tt.expectOK {
+				t.Errorf("expected OK=%v, got %+v", tt.expectOK, report.Checks)
+			}
+			statuses := make(map[string]string)
+			for _, check := range report.Checks {
+				statuses[check.Name] = check.Status
+				if check.Status == CheckFail && check.Hint == "" && check.Name != "Git repository" {
+					t.Errorf("expected a remediation hint for failed check %q", check.Name)
+				}
+			}
+			for name, want := range tt.expected {
+				if statuses[name] != want {
+					t.Errorf("expected %s status %q, got %q", name, want, statuses[name])
+				}
+			}
+		})
+	}
+}
+
+func TestApp_Doctor_JSON(t *testing.T) {
+	env := healthyDoctorEnv()
+	env.models = `{"models": []}`
+	application := newDoctorApp(t, env)
+
+	var err error
+	output := captureStdout(t, func() {
+		err = application.Doctor(DoctorOptions{JSON: true})
+	})
+	if !errors.Is(err, ErrDoctorFailed) {
+		t.Errorf("expected ErrDoctorFailed, got %v", err)
+	}
+
+	var report DoctorReport
+	if err := json.Unmarshal([]byte(output), &report); err != nil {
+		t.Fatalf("expected JSON output, got %v:\n%s", err, output)
+	}
+	if report.OK || len(report.Checks) != 9 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+	if strings.Contains(output, "secret-key") {
+		t.Error("the API key must never be printed")
+	}
+}
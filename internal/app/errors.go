@@ -0,0 +1,99 @@
+package app
+
+import (
+	"errors"
+	"net/url"
+
+	"ai-commit-message-generator/internal/ai"
+)
+
+// Errors the commands wrap their failures with, so callers can tell them
+// apart with errors.Is and scripts by the exit code ExitCode maps them to
+var (
+	// ErrNotARepo is returned outside a git repository
+	ErrNotARepo = errors.New("not a git repository")
+	// ErrNoStagedChanges is returned when there are no changes to describe
+	ErrNoStagedChanges = errors.New("no staged changes found")
+	// ErrAIUnavailable is returned when the provider cannot be reached, is
+	// rate limiting or fails to answer
+	ErrAIUnavailable = errors.New("the AI provider is unavailable")
+	// ErrAuth is returned when the provider rejects the API key
+	ErrAuth = errors.New("the AI provider rejected the API key")
+	// ErrCancelled is returned when the user aborts the interactive flow
+	ErrCancelled = errors.New("aborted by user")
+	// ErrValidationFailed is returned for options that cannot be combined
+	ErrValidationFailed = errors.New("invalid options")
+)
+
+// Exit codes for the errors above. Any other failure exits with ExitError.
+const (
+	ExitError           = 1
+	ExitNotARepo        = 2
+	ExitNoStagedChanges = 3
+	ExitAIUnavailable   = 4
+	ExitAuth            = 5
+	ExitValidation      = 6
+	ExitCancelled       = 130
+)
+
+// ExitCode returns the exit code for err: 0 for nil, the code of the first
+// of the errors above it wraps, or ExitError
+func ExitCode(err error) int {
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, ErrCancelled):
+		return ExitCancelled
+	case errors.Is(err, ErrNotARepo):
+		return ExitNotARepo
+	case errors.Is(err, ErrNoStagedChanges):
+		return ExitNoStagedChanges
+	case errors.Is(err, ErrAuth):
+		return ExitAuth
+	case errors.Is(err, ErrAIUnavailable):
+		return ExitAIUnavailable
+	case errors.Is(err, ErrValidationFailed):
+		return ExitValidation
+	}
+	return ExitError
+}
+
+// kindError gives err the kind of one of the errors above without changing
+// its message
+type kindError struct {
+	kind error
+	err  error
+}
+
+func (e *kindError) Error() string {
+	return e.err.Error()
+}
+
+func (e *kindError) Unwrap() []error {
+	return []error{e.kind, e.err}
+}
+
+// withKind marks err as kind, keeping its message
+func withKind(kind, err error) error {
+	return &kindError{kind: kind, err: err}
+}
+
+// classifyAIError marks an error from the AI client as ErrAuth when the
+// provider rejected the key, and as ErrAIUnavailable when it could not be
+// reached, was rate limiting, was still loading the model or failed on its
+// side
+func classifyAIError(err error) error {
+	var apiErr *ai.APIError
+	var rateErr *ai.RateLimitError
+	var loadingErr *ai.ModelLoadingError
+	var urlErr *url.Error
+	switch {
+	case errors.As(err, &apiErr) && apiErr.IsAuth():
+		return withKind(ErrAuth, err)
+	case errors.As(err, &apiErr) && apiErr.StatusCode >= 500:
+		return withKind(ErrAIUnavailable, err)
+	case errors.As(err, &rateErr), errors.As(err, &loadingErr), errors.As(err, &urlErr):
+		return withKind(ErrAIUnavailable, err)
+	}
+	return err
+}
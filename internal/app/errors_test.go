@@ -0,0 +1,153 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"testing"
+
+	"ai-commit-message-generator/internal/ai"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{name: "Success", err: nil, expected: 0},
+		{name: "Plain error", err: errors.New("boom"), expected: ExitError},
+		{name: "Not a repository", err: ErrNotARepo, expected: ExitNotARepo},
+		{name: "No staged changes", err: fmt.Errorf("%w. Stage them", ErrNoStagedChanges), expected: ExitNoStagedChanges},
+		{name: "AI unavailable", err: withKind(ErrAIUnavailable, errors.New("dial tcp: refused")), expected: ExitAIUnavailable},
+		{name: "Auth", err: fmt.Errorf("failed: %w", withKind(ErrAuth, errors.New("401"))), expected: ExitAuth},
+		{name: "Cancelled", err: ErrCancelled, expected: ExitCancelled},
+		{name: "Validation", err: withKind(ErrValidationFailed, errors.New("--a and --b")), expected: ExitValidation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := ExitCode(tt.err); code != tt.expected {
+				t.Errorf("expected exit code %d, got %d", tt.expected, code)
+			}
+		})
+	}
+}
+
+func TestApp_Run_ExitCodes(t *testing.T) {
+	stagedGit := func() *MockGit {
+		return &MockGit{
+			IsInsideRepoFunc:     func() (bool, error) { return true, nil },
+			HasStagedChangesFunc: func() (bool, error) { return true, nil },
+			GetStagedDiffFunc:    func() (string, error) { return "diff", nil },
+		}
+	}
+	failingAI := func(err error) *MockAI {
+		return &MockAI{GenerateCommitMessageFunc: func(req ai.CommitRequest) (string, error) { return "", err }}
+	}
+
+	tests := []struct {
+		name            string
+		mockGit         *MockGit
+		mockAI          *MockAI
+		opts            RunOptions
+		expected        int
+		expectedMessage string
+	}{
+		{
+			name:            "Not a repository",
+			mockGit:         &MockGit{IsInsideRepoFunc: func() (bool, error) { return false, nil }},
+			mockAI:          &MockAI{},
+			expected:        ExitNotARepo,
+			expectedMessage: "not a git repository",
+		},
+		{
+			name: "Nothing staged",
+			mockGit: &MockGit{
+				IsInsideRepoFunc:     func() (bool, error) { return true, nil },
+				HasStagedChangesFunc: func() (bool, error) { return false, nil },
+			},
+			mockAI:          &MockAI{},
+			expected:        ExitNoStagedChanges,
+			expectedMessage: "no staged changes found. Please stage your changes using 'git add', or describe unstaged changes with --all",
+		},
+		{
+			name:     "Provider unreachable",
+			mockGit:  stagedGit(),
+			mockAI:   failingAI(fmt.Errorf("API call failed: %w", &url.Error{Op: "Post", URL: "http://localhost:11434", Err: errors.New("connection refused")})),
+			expected: ExitAIUnavailable,
+		},
+		{
+			name:     "Provider failing",
+			mockGit:  stagedGit(),
+			mockAI:   failingAI(&ai.APIError{StatusCode: 503, Status: "503 Service Unavailable"}),
+			expected: ExitAIUnavailable,
+		},
+		{
+			name:     "Rate limited",
+			mockGit:  stagedGit(),
+			mockAI:   failingAI(&ai.RateLimitError{Attempts: 4}),
+			expected: ExitAIUnavailable,
+		},
+		{
+			name:     "Model still loading",
+			mockGit:  stagedGit(),
+			mockAI:   failingAI(&ai.ModelLoadingError{Attempts: 4}),
+			expected: ExitAIUnavailable,
+		},
+		{
+			name:     "API key rejected",
+			mockGit:  stagedGit(),
+			mockAI:   failingAI(&ai.APIError{StatusCode: 401, Status: "401 Unauthorized"}),
+			expected: ExitAuth,
+		},
+		{
+			name:     "Bad request is a plain error",
+			mockGit:  stagedGit(),
+			mockAI:   failingAI(&ai.APIError{StatusCode: 400, Status: "400 Bad Request"}),
+			expected: ExitError,
+		},
+		{
+			name:            "Options that cannot be combined",
+			mockGit:         stagedGit(),
+			mockAI:          &MockAI{},
+			opts:            RunOptions{Preview: true, Yes: true},
+			expected:        ExitValidation,
+			expectedMessage: "--preview cannot be combined with --yes",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockConfig := &MockConfig{LoadRulesFunc: func() (string, error) { return "", nil }}
+			application := NewApp(tt.mockGit, mockConfig, nil, tt.mockAI)
+			application.Quiet = true
+
+			var err error
+			captureStdout(t, func() {
+				err = application.Run(tt.opts)
+			})
+			if code := ExitCode(err); code != tt.expected {
+				t.Errorf("expected exit code %d, got %d for %v", tt.expected, code, err)
+			}
+			if tt.expectedMessage != "" && (err == nil || err.Error() != tt.expectedMessage) {
+				t.Errorf("expected error %q, got %v", tt.expectedMessage, err)
+			}
+		})
+	}
+
+	t.Run("Cancelled", func(t *testing.T) {
+		var committed []string
+		mockAI := &MockAI{GenerateCommitMessageFunc: func(req ai.CommitRequest) (string, error) { return "feat: added login", nil }}
+		application, _ := newInteractiveApp(t, "q\n", mockAI, &committed)
+		application.Quiet = true
+
+		var err error
+		captureStdout(t, func() {
+			err = application.Run(RunOptions{Interactive: true})
+		})
+		if code := ExitCode(err); code != ExitCancelled {
+			t.Errorf("expected exit code %d, got %d for %v", ExitCancelled, code, err)
+		}
+	})
+}
@@ -0,0 +1,69 @@
+package app
+
+import "strings"
+
+// mergeExistingMessage combines a generated message with the one the user
+// had started in the message file: the generated header replaces theirs,
+// the paragraphs of their body that the generated body leaves out follow
+// it, and their trailers join the generated trailer block
+func mergeExistingMessage(generated, existing string) string {
+	userParagraphs, userTrailers := splitStartedMessage(existing)
+	body, trailers := splitTrailerBlock(generated)
+	for _, paragraph := range userParagraphs {
+		if !strings.Contains(normalizeText(body), normalizeText(paragraph)) {
+			body += "\n\n" + paragraph
+		}
+	}
+	return appendTrailers(body, append(trailers, userTrailers...))
+}
+
+// normalizeText ignores letter case and line breaks when looking for the
+// user's lines in the generated message
+func normalizeText(text string) string {
+	return strings.ToLower(strings.Join(strings.Fields(text), " "))
+}
+
+// splitStartedMessage returns the body paragraphs and trailers of a message
+// the user started, leaving out its header. A message of trailers only,
+// such as a lone "Refs: PROJ-42", has no header.
+func splitStartedMessage(message string) (paragraphs, trailers []string) {
+	lines := strings.Split(strings.TrimSpace(message), "\n")
+	onlyTrailers := true
+	for _, line := range lines {
+		if strings.TrimSpace(line) != "" && !trailerPattern.MatchString(line) {
+			onlyTrailers = false
+			break
+		}
+	}
+	if onlyTrailers {
+		for _, line := range lines {
+			if strings.TrimSpace(line) != "" {
+				trailers = append(trailers, line)
+			}
+		}
+		return nil, trailers
+	}
+
+	body, trailers := splitTrailerBlock(message)
+	_, rest, _ := strings.Cut(body, "\n")
+	for _, paragraph := range strings.Split(strings.TrimSpace(rest), "\n\n") {
+		if paragraph = strings.TrimSpace(paragraph); paragraph != "" {
+			paragraphs = append(paragraphs, paragraph)
+		}
+	}
+	return paragraphs, trailers
+}
+
+// splitTrailerBlock separates the trailer block a message ends with, if
+// any, from the rest of it
+func splitTrailerBlock(message string) (string, []string) {
+	lines := strings.Split(strings.TrimRight(message, "\n "), "\n")
+	if !endsWithTrailerBlock(lines) {
+		return strings.Join(lines, "\n"), nil
+	}
+	start := len(lines)
+	for strings.TrimSpace(lines[start-1]) != "" {
+		start--
+	}
+	return strings.TrimRight(strings.Join(lines[:start], "\n"), "\n "), lines[start:]
+}
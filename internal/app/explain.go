@@ -0,0 +1,55 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// ExplainOptions controls Explain
+type ExplainOptions struct {
+	// File is the path the explanation is scoped to, when the git client
+	// was set up to diff only that path. It is reported in the JSON output.
+	File string
+	// JSON prints {"file": ..., "explanation": ...} instead of plain text
+	JSON bool
+}
+
+// explainJSON is the --json output of Explain
+type explainJSON struct {
+	File        string `json:"file,omitempty"`
+	Explanation string `json:"explanation"`
+}
+
+// Explain prints a plain-language explanation of the staged changes: what
+// they do and why they might have been made. The diff is read exactly as
+// for a commit message; only the prompt and the output differ. Only the
+// explanation goes to stdout so it can be piped; progress goes to stderr.
+func (a *App) Explain(opts ExplainOptions) error {
+	defer a.Progress.Stop()
+	a.Progress.Phase(PhaseReadingDiff)
+	req, err := a.prepareRequest(RunOptions{})
+	if err != nil {
+		return err
+	}
+
+	if !a.Quiet && !a.Progress.Enabled() {
+		fmt.Fprintln(os.Stderr, "Explaining the staged changes...")
+	}
+	explanation, err := a.AI.ExplainChanges(req)
+	a.Progress.Stop()
+	if err != nil {
+		return fmt.Errorf("failed to explain the changes: %w", classifyAIError(err))
+	}
+
+	if !opts.JSON {
+		fmt.Println(explanation)
+		return nil
+	}
+	data, err := json.MarshalIndent(explainJSON{File: opts.File, Explanation: explanation}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode explanation: %w", err)
+	}
+	fmt.Println(string(data))
+	return nil
+}
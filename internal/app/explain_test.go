@@ -0,0 +1,74 @@
+package app
+
+import (
+	"strings"
+	"testing"
+
+	"ai-commit-message-generator/internal/ai"
+)
+
+func TestApp_Explain(t *testing.T) {
+	tests := []struct {
+		name           string
+		opts           ExplainOptions
+		staged         bool
+		expectedOutput string
+		expectedError  string
+	}{
+		{
+			name:           "Plain text",
+			staged:         true,
+			expectedOutput: "The change adds a login handler.\n",
+		},
+		{
+			name:           "JSON for one file",
+			opts:           ExplainOptions{File: "login.go", JSON: true},
+			staged:         true,
+			expectedOutput: "{\n  \"file\": \"login.go\",\n  \"explanation\": \"The change adds a login handler.\"\n}\n",
+		},
+		{
+			name:          "Nothing staged",
+			expectedError: "no staged changes found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockGit := &MockGit{
+				IsInsideRepoFunc:     func() (bool, error) { return true, nil },
+				HasStagedChangesFunc: func() (bool, error) { return tt.staged, nil },
+				GetStagedDiffFunc:    func() (string, error) { return "diff --git a/login.go b/login.go", nil },
+			}
+			mockConfig := &MockConfig{
+				LoadRulesFunc: func() (string, error) { return "", nil },
+			}
+			mockAI := &MockAI{
+				ExplainChangesFunc: func(req ai.CommitRequest) (string, error) {
+					if req.Diff != "diff --git a/login.go b/login.go" {
+						t.Errorf("expected the staged diff, got %q", req.Diff)
+					}
+					return "The change adds a login handler.", nil
+				},
+			}
+			application := NewApp(mockGit, mockConfig, nil, mockAI)
+			application.Quiet = true
+
+			var err error
+			output := captureStdout(t, func() {
+				err = application.Explain(tt.opts)
+			})
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("expected error containing %q, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Explain failed: %v", err)
+			}
+			if output != tt.expectedOutput {
+				t.Errorf("expected %q, got %q", tt.expectedOutput, output)
+			}
+		})
+	}
+}
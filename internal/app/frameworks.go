@@ -0,0 +1,123 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// InitOptions.Framework values for the other hook managers. Like the
+// pre-commit framework they own the hooks directory, so init adds its hook
+// to their configuration instead.
+const (
+	// FrameworkHusky writes .husky/prepare-commit-msg for husky v9
+	FrameworkHusky = "husky"
+	// FrameworkLefthook adds a prepare-commit-msg command to lefthook.yml
+	FrameworkLefthook = "lefthook"
+	// FrameworkAuto picks the framework whose configuration the repository has
+	FrameworkAuto = "auto"
+)
+
+// huskyDir is where husky v9 keeps the hooks it runs
+const huskyDir = ".husky"
+
+// huskyHookLine is the line of .husky/prepare-commit-msg that runs us. Husky
+// runs the file with sh and git's arguments.
+const huskyHookLine = `generate-commit hook run "$@"`
+
+// validateFramework rejects an unknown init --framework value
+func validateFramework(framework string) error {
+	switch framework {
+	case "", FrameworkPreCommit, FrameworkHusky, FrameworkLefthook, FrameworkAuto:
+		return nil
+	}
+	return fmt.Errorf("unknown --framework %q (expected %s, %s, %s or %s)", framework, FrameworkPreCommit, FrameworkHusky, FrameworkLefthook, FrameworkAuto)
+}
+
+// detectFramework returns the hook framework the repository is set up for,
+// judged by its configuration files
+func detectFramework(repoRoot string) (string, error) {
+	var found []string
+	if info, err := os.Stat(filepath.Join(repoRoot, huskyDir)); err == nil && info.IsDir() {
+		found = append(found, FrameworkHusky)
+	}
+	if findLefthookConfig(repoRoot) != "" {
+		found = append(found, FrameworkLefthook)
+	}
+	if _, err := os.Stat(filepath.Join(repoRoot, preCommitConfigName)); err == nil {
+		found = append(found, FrameworkPreCommit)
+	}
+
+	switch len(found) {
+	case 0:
+		return "", fmt.Errorf("--framework auto found no %s directory, lefthook.yml or %s; pass the framework or leave --framework out to write .git/hooks", huskyDir, preCommitConfigName)
+	case 1:
+		return found[0], nil
+	default:
+		return "", fmt.Errorf("--framework auto found %s; pass the one that runs the hooks", strings.Join(found, " and "))
+	}
+}
+
+// installFramework adds our hook to the configuration of framework
+func installFramework(repoRoot, framework string) error {
+	switch framework {
+	case FrameworkHusky:
+		return installHusky(repoRoot)
+	case FrameworkLefthook:
+		return installLefthook(repoRoot)
+	default:
+		return installPreCommitFramework(repoRoot)
+	}
+}
+
+// frameworkNextStep is what is left to do after init so that framework runs
+// the hook
+func frameworkNextStep(framework string) string {
+	switch framework {
+	case FrameworkHusky:
+		return "Run 'npx husky' (or your package's prepare script) if husky is not set up yet"
+	case FrameworkLefthook:
+		return "Run 'lefthook install' to let lefthook run the hook"
+	default:
+		return "Run 'pre-commit install --hook-type prepare-commit-msg' to let pre-commit run the hook"
+	}
+}
+
+// installHusky writes .husky/prepare-commit-msg, or appends our line to the
+// one the repository has
+func installHusky(repoRoot string) error {
+	path := filepath.Join(repoRoot, huskyDir, "prepare-commit-msg")
+	content, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	updated, changed := addHuskyHook(string(content))
+	if !changed {
+		fmt.Println("✓ .husky/prepare-commit-msg already runs generate-commit")
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", huskyDir, err)
+	}
+	if err := os.WriteFile(path, []byte(updated), 0755); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	fmt.Println("✓ Added generate-commit to .husky/prepare-commit-msg")
+	return nil
+}
+
+// addHuskyHook appends our line to a husky hook file, keeping the commands
+// already there. It reports false when the file already runs generate-commit.
+func addHuskyHook(content string) (string, bool) {
+	for _, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "generate-commit ") {
+			return content, false
+		}
+	}
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	return content + huskyHookLine + "\n", true
+}
@@ -0,0 +1,170 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ai-commit-message-generator/internal/config"
+)
+
+func TestAddHuskyHook(t *testing.T) {
+	tests := []struct {
+		name          string
+		content       string
+		expected      string
+		expectChanged bool
+	}{
+		{name: "New file", content: "", expected: huskyHookLine + "\n", expectChanged: true},
+		{
+			name:          "Other commands kept",
+			content:       "npx --no -- commitlint --edit \"$1\"",
+			expected:      "npx --no -- commitlint --edit \"$1\"\n" + huskyHookLine + "\n",
+			expectChanged: true,
+		},
+		{
+			name:     "Already added",
+			content:  "npm test\ngenerate-commit hook prepare-commit-msg \"$@\"\n",
+			expected: "npm test\ngenerate-commit hook prepare-commit-msg \"$@\"\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, changed := addHuskyHook(tt.content)
+			if changed != tt.expectChanged {
+				t.Errorf("expected changed %v, got %v", tt.expectChanged, changed)
+			}
+			if got != tt.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestDetectFramework(t *testing.T) {
+	tests := []struct {
+		name        string
+		files       []string
+		expected    string
+		expectError string
+	}{
+		{name: "Husky", files: []string{".husky/pre-commit"}, expected: FrameworkHusky},
+		{name: "Lefthook", files: []string{".lefthook.yml"}, expected: FrameworkLefthook},
+		{name: "Pre-commit", files: []string{preCommitConfigName}, expected: FrameworkPreCommit},
+		{name: "None", expectError: "found no .husky directory"},
+		{name: "Several", files: []string{".husky/pre-commit", "lefthook.yml"}, expectError: "found husky and lefthook"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repoRoot := t.TempDir()
+			for _, file := range tt.files {
+				path := filepath.Join(repoRoot, file)
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, nil, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := detectFramework(repoRoot)
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Fatalf("expected error containing %q, got %v", tt.expectError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestApp_Init_Frameworks(t *testing.T) {
+	tests := []struct {
+		name      string
+		framework string
+		existing  map[string]string
+		file      string
+		expected  string
+	}{
+		{
+			name:      "Husky",
+			framework: FrameworkHusky,
+			file:      ".husky/prepare-commit-msg",
+			expected:  huskyHookLine + "\n",
+		},
+		{
+			name:      "Lefthook",
+			framework: FrameworkLefthook,
+			file:      "lefthook.yml",
+			expected:  "prepare-commit-msg:\n  commands:\n    generate-commit:\n      run: generate-commit hook run {0}\n",
+		},
+		{
+			name:      "Detected husky",
+			framework: FrameworkAuto,
+			existing:  map[string]string{".husky/prepare-commit-msg": "npm test\n"},
+			file:      ".husky/prepare-commit-msg",
+			expected:  "npm test\n" + huskyHookLine + "\n",
+		},
+		{
+			name:      "Detected lefthook",
+			framework: FrameworkAuto,
+			existing:  map[string]string{"lefthook.yaml": "pre-push:\n  commands:\n    test:\n      run: go test ./...\n"},
+			file:      "lefthook.yaml",
+			expected:  "pre-push:\n  commands:\n    test:\n      run: go test ./...\n\nprepare-commit-msg:\n  commands:\n    generate-commit:\n      run: generate-commit hook run {0}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repoRoot := t.TempDir()
+			if err := os.Mkdir(filepath.Join(repoRoot, ".git"), 0755); err != nil {
+				t.Fatalf("failed to create .git dir: %v", err)
+			}
+			for file, content := range tt.existing {
+				path := filepath.Join(repoRoot, file)
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			originalWd, _ := os.Getwd()
+			defer os.Chdir(originalWd)
+			os.Chdir(repoRoot)
+
+			mockGit := &MockGit{
+				IsInsideRepoFunc: func() (bool, error) { return true, nil },
+				GetRepoRootFunc:  func() (string, error) { return repoRoot, nil },
+				GetHooksDirFunc: func() (string, error) {
+					t.Error("the hooks directory is left to the framework")
+					return "", nil
+				},
+			}
+			application := NewApp(mockGit, &MockConfig{}, config.NewConfigLoader(), nil)
+
+			captureStdout(t, func() {
+				if err := application.Init(InitOptions{Framework: tt.framework}); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			})
+
+			content, err := os.ReadFile(filepath.Join(repoRoot, tt.file))
+			if err != nil {
+				t.Fatalf("expected %s: %v", tt.file, err)
+			}
+			if string(content) != tt.expected {
+				t.Errorf("expected %s:\n%s\ngot:\n%s", tt.file, tt.expected, content)
+			}
+		})
+	}
+}
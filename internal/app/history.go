@@ -0,0 +1,202 @@
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"ai-commit-message-generator/internal/logging"
+)
+
+// historyDirName is the directory in the git directory that keeps the
+// generated messages. Nothing in the git directory is ever committed.
+const historyDirName = "ai-commit-history"
+
+// maxHistoryEntries caps the messages kept; older ones are deleted
+const maxHistoryEntries = 50
+
+// HistoryEntry is one generated message
+type HistoryEntry struct {
+	Time time.Time `json:"time"`
+	// DiffHash identifies the diff the message was generated for
+	DiffHash string `json:"diff_hash"`
+	Message  string `json:"message"`
+}
+
+// HistoryOptions controls History
+type HistoryOptions struct {
+	// Use picks an entry to print or commit, 1 being the newest. 0 lists
+	// the entries instead.
+	Use int
+	// Commit commits the picked message instead of printing it
+	Commit bool
+}
+
+// History lists the recently generated messages, newest first, or with
+// Use prints or commits one of them, so a message is not lost when a hook
+// or a commit fails after it was generated. It warns when the staged
+// changes are not the ones the message was generated for.
+func (a *App) History(opts HistoryOptions) error {
+	if opts.Commit && opts.Use == 0 {
+		return errors.New("--commit needs the number of a message: generate-commit history use <n> --commit")
+	}
+	isRepo, err := a.Git.IsInsideRepo()
+	if err != nil {
+		return fmt.Errorf("failed to check repository status: %w", err)
+	}
+	if !isRepo {
+		return ErrNotARepo
+	}
+	dir, err := a.historyDir()
+	if err != nil {
+		return err
+	}
+	entries, err := readHistory(dir)
+	if err != nil {
+		return err
+	}
+
+	if opts.Use == 0 {
+		if len(entries) == 0 {
+			fmt.Println("No generated messages yet.")
+			return nil
+		}
+		for i, entry := range entries {
+			fmt.Printf("%2d  %s  %s  %s\n", i+1, entry.Time.Local().Format("2006-01-02 15:04"), shortHash(entry.DiffHash), strings.SplitN(entry.Message, "\n", 2)[0])
+		}
+		return nil
+	}
+	if opts.Use < 0 || opts.Use > len(entries) {
+		return fmt.Errorf("there is no message %d; the history has %s", opts.Use, countOf(len(entries), "message"))
+	}
+	entry := entries[opts.Use-1]
+
+	req, err := a.prepareRequest(RunOptions{})
+	switch {
+	case err != nil && opts.Commit:
+		return err
+	case err != nil:
+		a.log().Warn("could not compare with the staged changes", logging.KeyStage, "history", logging.KeyError, err)
+	case diffHash(req.Diff) != entry.DiffHash:
+		fmt.Fprintf(os.Stderr, "\033[33mWarning: the staged changes differ from the ones message %d was generated for.\033[0m\n", opts.Use)
+	}
+
+	if !opts.Commit {
+		fmt.Println(entry.Message)
+		return nil
+	}
+	if err := a.commit(entry.Message, RunOptions{}); err != nil {
+		return err
+	}
+	fmt.Println("\033[32m✓ Committed\033[0m")
+	return nil
+}
+
+// remember adds a generated message to the history. The history is a
+// convenience, so it is skipped outside a repository and a failure to
+// write it is only a warning.
+func (a *App) remember(diff, message string) {
+	if a.suggestsSplit(message) {
+		return
+	}
+	dir, err := a.historyDir()
+	if err != nil {
+		return
+	}
+	entry := HistoryEntry{Time: time.Now(), DiffHash: diffHash(diff), Message: message}
+	if err := writeHistory(dir, entry); err != nil {
+		a.log().Warn("failed to save the message to the history", logging.KeyStage, "history", logging.KeyError, err)
+	}
+}
+
+// historyDir returns the directory the history is kept in
+func (a *App) historyDir() (string, error) {
+	gitDir, err := a.Git.GetGitDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(gitDir, historyDirName), nil
+}
+
+// diffHash identifies a diff by its SHA-256
+func diffHash(diff string) string {
+	sum := sha256.Sum256([]byte(diff))
+	return hex.EncodeToString(sum[:])
+}
+
+// writeHistory stores entry in its own file, named after its time so the
+// names sort oldest first, and deletes the oldest entries beyond
+// maxHistoryEntries
+func writeHistory(dir string, entry HistoryEntry) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode history entry: %w", err)
+	}
+	path := filepath.Join(dir, fmt.Sprintf("%019d.json", entry.Time.UnixNano()))
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	names, err := historyFiles(dir)
+	if err != nil {
+		return err
+	}
+	for len(names) > maxHistoryEntries {
+		if err := os.Remove(filepath.Join(dir, names[0])); err != nil {
+			return fmt.Errorf("failed to remove old history entry: %w", err)
+		}
+		names = names[1:]
+	}
+	return nil
+}
+
+// readHistory returns the entries in dir, newest first. Files that cannot
+// be read are skipped, and a missing directory is an empty history.
+func readHistory(dir string) ([]HistoryEntry, error) {
+	names, err := historyFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]HistoryEntry, 0, len(names))
+	for i := len(names) - 1; i >= 0; i-- {
+		data, err := os.ReadFile(filepath.Join(dir, names[i]))
+		if err != nil {
+			continue
+		}
+		var entry HistoryEntry
+		if json.Unmarshal(data, &entry) != nil || entry.Message == "" {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// historyFiles lists the entry files in dir, oldest first
+func historyFiles(dir string) ([]string, error) {
+	files, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+	var names []string
+	for _, f := range files {
+		if !f.IsDir() && strings.HasSuffix(f.Name(), ".json") {
+			names = append(names, f.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
@@ -0,0 +1,164 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"ai-commit-message-generator/internal/ai"
+)
+
+func TestWriteHistory(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), historyDirName)
+	start := time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC)
+	for i := 0; i < maxHistoryEntries+2; i++ {
+		entry := HistoryEntry{Time: start.Add(time.Duration(i) * time.Minute), DiffHash: "hash", Message: fmt.Sprintf("fix: attempt %d", i)}
+		if err := writeHistory(dir, entry); err != nil {
+			t.Fatalf("writeHistory failed: %v", err)
+		}
+	}
+	// Not an entry; left alone
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("x"), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	entries, err := readHistory(dir)
+	if err != nil {
+		t.Fatalf("readHistory failed: %v", err)
+	}
+	if len(entries) != maxHistoryEntries {
+		t.Fatalf("expected %d entries, got %d", maxHistoryEntries, len(entries))
+	}
+	if entries[0].Message != fmt.Sprintf("fix: attempt %d", maxHistoryEntries+1) || entries[len(entries)-1].Message != "fix: attempt 2" {
+		t.Errorf("expected the newest entries, newest first, got %q ... %q", entries[0].Message, entries[len(entries)-1].Message)
+	}
+	if !entries[0].Time.Equal(start.Add(time.Duration(maxHistoryEntries+1) * time.Minute)) {
+		t.Errorf("expected the time to be kept, got %v", entries[0].Time)
+	}
+
+	if entries, err := readHistory(filepath.Join(t.TempDir(), "missing")); err != nil || len(entries) != 0 {
+		t.Errorf("expected an empty history for a missing directory, got %v, %v", entries, err)
+	}
+}
+
+func TestApp_History(t *testing.T) {
+	const diff = "diff --git a/login.go b/login.go"
+
+	tests := []struct {
+		name              string
+		opts              HistoryOptions
+		stagedDiff        string
+		expectedOutput    []string
+		expectedWarning   string
+		expectedCommitted string
+		expectedError     string
+	}{
+		{
+			name:           "List newest first",
+			expectedOutput: []string{" 1  ", "fix(auth): handled expired tokens\n", " 2  ", "feat(auth): added login\n"},
+		},
+		{
+			name:           "Use prints the message",
+			opts:           HistoryOptions{Use: 2},
+			stagedDiff:     diff,
+			expectedOutput: []string{"feat(auth): added login\n\nAdded a login handler.\n"},
+		},
+		{
+			name:              "Use commits the message",
+			opts:              HistoryOptions{Use: 2, Commit: true},
+			stagedDiff:        diff,
+			expectedOutput:    []string{"✓ Committed"},
+			expectedCommitted: "feat(auth): added login\n\nAdded a login handler.",
+		},
+		{
+			name:              "Stale diff warning",
+			opts:              HistoryOptions{Use: 2, Commit: true},
+			stagedDiff:        "diff --git a/logout.go b/logout.go",
+			expectedWarning:   "the staged changes differ from the ones message 2 was generated for",
+			expectedCommitted: "feat(auth): added login\n\nAdded a login handler.",
+		},
+		{
+			name:          "Out of range",
+			opts:          HistoryOptions{Use: 3},
+			expectedError: "there is no message 3; the history has 2 messages",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gitDir := t.TempDir()
+			staged := diff
+			var committed string
+			mockGit := &MockGit{
+				IsInsideRepoFunc:     func() (bool, error) { return true, nil },
+				GetGitDirFunc:        func() (string, error) { return gitDir, nil },
+				HasStagedChangesFunc: func() (bool, error) { return true, nil },
+				GetStagedDiffFunc:    func() (string, error) { return staged, nil },
+				CommitWithMessageFunc: func(message string) error {
+					committed = message
+					return nil
+				},
+			}
+			mockConfig := &MockConfig{
+				LoadRulesFunc: func() (string, error) { return "", nil },
+			}
+			responses := []string{"feat(auth): added login\n\nAdded a login handler.", "fix(auth): handled expired tokens"}
+			mockAI := &MockAI{
+				GenerateCommitMessageFunc: func(req ai.CommitRequest) (string, error) {
+					response := responses[0]
+					responses = responses[1:]
+					return response, nil
+				},
+			}
+			application := NewApp(mockGit, mockConfig, nil, mockAI)
+			application.Quiet = true
+
+			// Two messages generated for diff, neither committed
+			for range 2 {
+				captureStdout(t, func() {
+					if err := application.Run(RunOptions{}); err != nil {
+						t.Fatalf("Run failed: %v", err)
+					}
+				})
+			}
+			staged = tt.stagedDiff
+
+			var err error
+			var output string
+			warnings := captureStderr(t, func() {
+				output = captureStdout(t, func() {
+					err = application.History(tt.opts)
+				})
+			})
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("expected error containing %q, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("History failed: %v", err)
+			}
+			for _, want := range tt.expectedOutput {
+				if !strings.Contains(output, want) {
+					t.Errorf("expected output to contain %q, got %q", want, output)
+				}
+			}
+			if tt.opts.Use == 0 && strings.Index(output, "fix(auth)") > strings.Index(output, "feat(auth)") {
+				t.Errorf("expected the newest message first, got %q", output)
+			}
+			if tt.expectedWarning == "" && warnings != "" {
+				t.Errorf("expected no warning, got %q", warnings)
+			}
+			if !strings.Contains(warnings, tt.expectedWarning) {
+				t.Errorf("expected warning %q, got %q", tt.expectedWarning, warnings)
+			}
+			if committed != tt.expectedCommitted {
+				t.Errorf("expected %q to be committed, got %q", tt.expectedCommitted, committed)
+			}
+		})
+	}
+}
@@ -0,0 +1,530 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"ai-commit-message-generator/internal/git"
+)
+
+const (
+	// HookPreCommit is the legacy hook that commits from inside the hook
+	HookPreCommit = "pre-commit"
+	// HookPrepareCommitMsg writes the generated message into git's message file
+	HookPrepareCommitMsg = "prepare-commit-msg"
+	// HookCommitMsg lints the final message
+	HookCommitMsg = "commit-msg"
+	// HookTypeBoth installs the pre-commit and prepare-commit-msg hooks
+	HookTypeBoth = "both"
+)
+
+// allHookNames lists every hook init can install
+var allHookNames = []string{HookPreCommit, HookPrepareCommitMsg, HookCommitMsg}
+
+// hookNamesForType maps an init --hook-type value to the hooks to install.
+// The value may be a comma-separated list, e.g. "prepare-commit-msg,commit-msg".
+func hookNamesForType(hookType string) ([]string, error) {
+	if hookType == "" {
+		return []string{HookPreCommit}, nil
+	}
+
+	var names []string
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(hookType, ",") {
+		var expanded []string
+		switch part = strings.TrimSpace(part); part {
+		case HookPreCommit, HookPrepareCommitMsg, HookCommitMsg:
+			expanded = []string{part}
+		case HookTypeBoth:
+			expanded = []string{HookPreCommit, HookPrepareCommitMsg}
+		default:
+			return nil, fmt.Errorf("unknown hook type %q (expected %s, %s, %s or %s)", part, HookPreCommit, HookPrepareCommitMsg, HookCommitMsg, HookTypeBoth)
+		}
+		for _, name := range expanded {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	return names, nil
+}
+
+// Values for InitOptions.OnExisting
+const (
+	// OnExistingAbort refuses to touch a hook that was not written by us
+	OnExistingAbort = "abort"
+	// OnExistingBackup moves the existing hook to <hook>.backup
+	OnExistingBackup = "backup"
+	// OnExistingChain keeps the existing hook as <hook>.chained and runs it
+	// before ours
+	OnExistingChain = "chain"
+)
+
+// Values for InitOptions.HookShell
+const (
+	// HookShellSh writes POSIX sh hooks, which git runs on every platform,
+	// including Git for Windows
+	HookShellSh = "sh"
+	// HookShellPowerShell writes each hook as a PowerShell script started
+	// by a one-line sh hook
+	HookShellPowerShell = "powershell"
+	// HookShellBatch writes .bat hooks
+	HookShellBatch = "batch"
+)
+
+// hookShells lists the hook shells with a file layout of their own;
+// PowerShell hooks are installed where sh hooks are
+var hookShells = []string{HookShellSh, HookShellBatch}
+
+// defaultHookShell is the hook shell used when none is given: batch on
+// Windows and sh everywhere else
+func defaultHookShell() string {
+	if runtime.GOOS == "windows" {
+		return HookShellBatch
+	}
+	return HookShellSh
+}
+
+// resolveHookShell checks an init --hook-shell value; empty means
+// defaultHookShell
+func resolveHookShell(shell string) (string, error) {
+	switch shell {
+	case "":
+		return defaultHookShell(), nil
+	case HookShellSh, HookShellPowerShell, HookShellBatch:
+		return shell, nil
+	default:
+		return "", fmt.Errorf("unknown --hook-shell value %q (expected %s, %s or %s)", shell, HookShellSh, HookShellPowerShell, HookShellBatch)
+	}
+}
+
+// hookMarker identifies hook scripts written by init
+const hookMarker = "for AI commit message generator"
+
+// validateOnExisting checks an init --on-existing value
+func validateOnExisting(onExisting string) error {
+	switch onExisting {
+	case "", OnExistingAbort, OnExistingBackup, OnExistingChain:
+		return nil
+	default:
+		return fmt.Errorf("unknown --on-existing value %q (expected %s, %s or %s)", onExisting, OnExistingAbort, OnExistingBackup, OnExistingChain)
+	}
+}
+
+// hookPath returns the file a hook is installed to with the default hook
+// shell
+func hookPath(hooksDir, hookName string) string {
+	return shellHookPath(hooksDir, hookName, defaultHookShell())
+}
+
+// shellHookPath returns the file a hook written for shell is installed to
+func shellHookPath(hooksDir, hookName, shell string) string {
+	if shell == HookShellBatch {
+		return filepath.Join(hooksDir, hookName+".bat")
+	}
+	return filepath.Join(hooksDir, hookName)
+}
+
+// powerShellPath returns the PowerShell script started by the hook at path
+func powerShellPath(path string) string {
+	return path + ".ps1"
+}
+
+// chainedHookPath returns where a chained hook for the hook at path is kept
+func chainedHookPath(path string) string {
+	if strings.HasSuffix(path, ".bat") {
+		return strings.TrimSuffix(path, ".bat") + ".chained.bat"
+	}
+	return path + ".chained"
+}
+
+// foreignHook returns the path of an existing hook that was not written by
+// init, or "" if there is none
+func foreignHook(hooksDir, hookName, shell string) (string, error) {
+	path := shellHookPath(hooksDir, hookName, shell)
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read existing %s hook: %w", hookName, err)
+	}
+	if strings.Contains(string(content), hookMarker) {
+		return "", nil
+	}
+	return path, nil
+}
+
+// checkExistingHooks returns an error with instructions if any of the hooks
+// would overwrite a hook that was not written by init
+func checkExistingHooks(hooksDir string, hookNames []string, shell string) error {
+	for _, hookName := range hookNames {
+		existing, err := foreignHook(hooksDir, hookName, shell)
+		if err != nil {
+			return err
+		}
+		if existing != "" {
+			return fmt.Errorf("a %s hook already exists at %s. Rerun init with --on-existing backup to move it to %s.backup, or --on-existing chain to keep running it before the generator", hookName, existing, filepath.Base(existing))
+		}
+	}
+	return nil
+}
+
+// installHook writes the named hook script into hooksDir. An existing hook
+// that was not written by init is backed up or chained according to
+// opts.OnExisting; callers check for OnExistingAbort beforehand.
+func (a *App) installHook(hooksDir, hookName string, opts InitOptions) error {
+	if err := os.MkdirAll(hooksDir, 0755); err != nil {
+		return fmt.Errorf("failed to create hooks directory: %w", err)
+	}
+
+	if _, ok := hookDescriptions[hookName]; !ok {
+		return fmt.Errorf("unknown hook %q", hookName)
+	}
+	hookContent, powerShell := a.generateHook(hookName, opts.HookShell, opts.LintFix)
+
+	path := shellHookPath(hooksDir, hookName, opts.HookShell)
+	chainedPath := chainedHookPath(path)
+
+	existing, err := foreignHook(hooksDir, hookName, opts.HookShell)
+	if err != nil {
+		return err
+	}
+	if existing != "" {
+		switch opts.OnExisting {
+		case OnExistingBackup:
+			backupPath := existing + ".backup"
+			if _, err := os.Stat(backupPath); err == nil {
+				return fmt.Errorf("cannot back up %s hook: %s already exists", hookName, backupPath)
+			}
+			if err := os.Rename(existing, backupPath); err != nil {
+				return fmt.Errorf("failed to back up %s hook: %w", hookName, err)
+			}
+			fmt.Printf("✓ Backed up existing %s hook to %s\n", hookName, backupPath)
+		case OnExistingChain:
+			if _, err := os.Stat(chainedPath); err == nil {
+				return fmt.Errorf("cannot chain %s hook: %s already exists", hookName, chainedPath)
+			}
+			if err := os.Rename(existing, chainedPath); err != nil {
+				return fmt.Errorf("failed to chain %s hook: %w", hookName, err)
+			}
+			fmt.Printf("✓ Existing %s hook moved to %s and will run first\n", hookName, chainedPath)
+		default:
+			return checkExistingHooks(hooksDir, []string{hookName}, opts.HookShell)
+		}
+	}
+
+	// A hook chained by an earlier init keeps running on reinitialization
+	if _, err := os.Stat(chainedPath); err == nil {
+		hookContent = chainHook(hookContent, filepath.Base(chainedPath), opts.HookShell == HookShellBatch)
+	}
+
+	if powerShell != "" {
+		if err := os.WriteFile(powerShellPath(path), []byte(powerShell), 0644); err != nil {
+			return fmt.Errorf("failed to create %s hook: %w", hookName, err)
+		}
+	}
+	if err := os.WriteFile(path, []byte(hookContent), 0755); err != nil {
+		return fmt.Errorf("failed to create %s hook: %w", hookName, err)
+	}
+	return removeStaleHooks(hooksDir, hookName, opts.HookShell)
+}
+
+// removeStaleHooks deletes what an earlier init wrote for hookName in
+// another hook shell, so switching shells does not leave two hooks behind
+func removeStaleHooks(hooksDir, hookName, shell string) error {
+	current := shellHookPath(hooksDir, hookName, shell)
+	for _, other := range hookShells {
+		path := shellHookPath(hooksDir, hookName, other)
+		stale := []string{powerShellPath(path)}
+		if path != current {
+			stale = append(stale, path)
+		} else if shell == HookShellPowerShell {
+			continue
+		}
+		for _, file := range stale {
+			content, err := os.ReadFile(file)
+			if err != nil || !strings.Contains(string(content), hookMarker) {
+				continue
+			}
+			if err := os.Remove(file); err != nil {
+				return fmt.Errorf("failed to remove the old %s hook: %w", hookName, err)
+			}
+		}
+	}
+	return nil
+}
+
+// chainHook inserts a call to the chained hook after the script header, so
+// it runs first and a failure stops the commit before our hook runs. batch
+// selects batch file syntax instead of sh.
+func chainHook(content, chainedName string, batch bool) string {
+	var call string
+	if batch {
+		call = fmt.Sprintf("REM Run the hook that existed before init\ncall \"%%~dp0%s\" %%*\nif errorlevel 1 exit /b %%errorlevel%%\n", chainedName)
+	} else {
+		call = fmt.Sprintf("# Run the hook that existed before init\n\"$(dirname \"$0\")/%s\" \"$@\" || exit $?\n", chainedName)
+	}
+
+	lines := strings.SplitAfter(content, "\n")
+	i := 0
+	for i < len(lines) {
+		trimmed := strings.TrimSpace(lines[i])
+		if strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "@echo") || strings.HasPrefix(trimmed, "REM") {
+			i++
+			continue
+		}
+		break
+	}
+	return strings.Join(lines[:i], "") + call + strings.Join(lines[i:], "")
+}
+
+// PreCommitHook is the entrypoint of the installed pre-commit hook. It runs
+// the interactive loop and, once a commit has been created, returns
+// ErrHookCommitted so that git cancels the commit it was about to make.
+// Without a TTY (CI, GUI git clients) nobody can review the message, so the
+// commit goes ahead untouched.
+func (a *App) PreCommitHook() error {
+	if reason := a.hookSkipReason(); reason != "" {
+		printSkipped(reason)
+		return nil
+	}
+	hasChanges, err := a.Git.HasStagedChanges()
+	if err != nil {
+		return fmt.Errorf("failed to check for staged changes: %w", err)
+	}
+	if !hasChanges {
+		return nil
+	}
+	if !a.canPrompt() {
+		fmt.Fprintln(os.Stderr, "generate-commit: no terminal to review a message on; committing as usual")
+		return nil
+	}
+
+	if err := a.Run(RunOptions{Interactive: true}); err != nil {
+		return err
+	}
+	return ErrHookCommitted
+}
+
+// PrepareCommitMsgHook is the entrypoint of the installed prepare-commit-msg
+// hook. git passes the message file, the message source and, for amends, the
+// commit SHA. The generated message is written into the message file so the
+// user reviews it in their normal editor flow.
+//
+// For a squash (git merge --squash, or a squash during an interactive
+// rebase) the message file holds the messages of the squashed commits. They
+// are distilled into a single message and kept below it as comments.
+func (a *App) PrepareCommitMsgHook(msgFile, source, sha string) error {
+	if reason := a.hookSkipReason(); reason != "" {
+		printSkipped(reason)
+		return nil
+	}
+	existing, err := os.ReadFile(msgFile)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read commit message file: %w", err)
+	}
+
+	commentChar := a.fileCommentChar(string(existing))
+	squash := source == "squash" || isSquashCombination(string(existing), commentChar)
+	if !squash && (source == "message" || source == "commit") {
+		// A message was given with -m/-F/-c/-C or --amend; keep it
+		if source == "message" {
+			printSkipped("a message was given with -m or -F")
+		} else {
+			printSkipped("the commit reuses an existing message")
+		}
+		return nil
+	}
+
+	defer a.Progress.Stop()
+	a.Progress.Phase(PhaseReadingDiff)
+	req, err := a.prepareRequest(RunOptions{})
+	if err != nil {
+		return err
+	}
+
+	var squashed string
+	if squash {
+		squashed = stripCommentLines(string(existing), commentChar)
+		// The rebase headers between the messages leave extra blank lines
+		for strings.Contains(squashed, "\n\n\n") {
+			squashed = strings.ReplaceAll(squashed, "\n\n\n", "\n\n")
+		}
+		if squashed == "" && req.GitState.Type == git.StateSquash {
+			squashed = req.GitState.OriginalMessage
+		}
+		req.GitState = &git.GitState{Type: git.StateSquash, OriginalMessage: squashed}
+		// The squashed messages matter more than the kind of files changed
+		req.FastPath = nil
+		a.status("Combining the squashed commit messages...")
+	} else {
+		a.status("Generating commit message...")
+	}
+
+	// A ticket line or notes written by another hook or tool are built on
+	// rather than overwritten. The commit.template is filled in already,
+	// and merge messages are generated from the merge itself.
+	var started string
+	if a.MergeExistingMessage && !squash && source != "merge" && req.Template == nil {
+		started = stripCommentLines(string(existing), commentChar)
+		req.ExistingMessage = started
+	}
+	message, err := a.generateMessage(req)
+	if err != nil {
+		return fmt.Errorf("failed to generate commit message: %w", err)
+	}
+	split := a.suggestsSplit(message)
+	if started != "" && !split {
+		message = mergeExistingMessage(message, started)
+	}
+	a.remember(req.Diff, message)
+
+	comments := string(existing)
+	if squashed != "" {
+		comments = squashReference(squashed, commentChar) + comments
+	}
+	content := a.renderMessageFile(message, comments, commentChar)
+	if started != "" && split {
+		// The suggestion is only comments; keep what the user wrote
+		content = started + "\n" + content
+	}
+	if err := os.WriteFile(msgFile, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write commit message file: %w", err)
+	}
+	return nil
+}
+
+// squashCombinationHeader follows the comment character at the start of
+// the message file of a squash during an interactive rebase
+const squashCombinationHeader = " This is a combination of "
+
+// isSquashCombination reports whether a message file was written by an
+// interactive rebase squashing commits together
+func isSquashCombination(content, commentChar string) bool {
+	return strings.HasPrefix(strings.TrimLeft(content, "\n"), commentChar+squashCombinationHeader)
+}
+
+// squashReference renders the squashed messages as comment lines, so they
+// stay in the editor for reference without ending up in the commit
+func squashReference(squashed, commentChar string) string {
+	var sb strings.Builder
+	sb.WriteString(commentChar + " Squashed commit messages, for reference:\n" + commentChar + "\n")
+	for _, line := range strings.Split(squashed, "\n") {
+		if line = strings.TrimRight(line, " \t"); line == "" {
+			sb.WriteString(commentChar + "\n")
+			continue
+		}
+		sb.WriteString(commentChar + " ")
+		sb.WriteString(line)
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// renderMessageFile places message above the comment lines, which start
+// with commentChar, that git already wrote to the message file. A split
+// suggestion is written as comments so that saving the file unchanged
+// aborts the commit.
+func (a *App) renderMessageFile(message, existing, commentChar string) string {
+	var sb strings.Builder
+	if a.suggestsSplit(message) {
+		sb.WriteString("\n" + commentChar + " AI Suggestion (Split Changes):\n")
+		for _, line := range strings.Split(message, "\n") {
+			sb.WriteString(commentChar + " ")
+			sb.WriteString(line)
+			sb.WriteString("\n")
+		}
+	} else {
+		sb.WriteString(message)
+		sb.WriteString("\n")
+	}
+
+	var comments []string
+	for _, line := range strings.Split(existing, "\n") {
+		if strings.HasPrefix(line, commentChar) {
+			comments = append(comments, line)
+		}
+	}
+	if len(comments) > 0 {
+		sb.WriteString("\n")
+		sb.WriteString(strings.Join(comments, "\n"))
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// hookDescriptions is the second header line of each hook script
+var hookDescriptions = map[string]string{
+	HookPreCommit:        "The accept/edit/regenerate flow is handled by the binary itself.",
+	HookPrepareCommitMsg: "Writes the generated message into the commit message file.",
+	HookCommitMsg:        "Checks the final message against Conventional Commits and the team rules.",
+}
+
+// generateHook returns the script of the named hook in shell. For
+// HookShellPowerShell the hook is a POSIX sh script that starts the
+// returned PowerShell script, which is installed next to it with a .ps1
+// extension. With fix, the commit-msg hook rewrites non-compliant messages
+// instead of rejecting them.
+//
+// The scripts only pass git's arguments on to the binary: the messages
+// never go through the shell, so multi-line messages and quotes in them
+// need no escaping.
+func (a *App) generateHook(hookName, shell string, fix bool) (script, powerShell string) {
+	command := "hook " + hookName
+	if hookName == HookCommitMsg && fix {
+		command += " --fix"
+	}
+	exePath := hookExecutable()
+	description := hookDescriptions[hookName]
+
+	switch shell {
+	case HookShellBatch:
+		// %% is a literal % in a batch file, even inside quotes
+		return fmt.Sprintf(`@echo off
+REM %s hook %s (Windows)
+REM %s
+"%s" %s %%*
+exit /b %%errorlevel%%
+`, hookName, hookMarker, description, strings.ReplaceAll(exePath, "%", "%%"), command), ""
+	case HookShellPowerShell:
+		script = fmt.Sprintf(`#!/bin/sh
+# %s hook %s
+# Runs %s.ps1 next to it with PowerShell 7 if installed, else Windows PowerShell.
+ps=powershell.exe
+if command -v pwsh >/dev/null 2>&1; then
+	ps=pwsh
+fi
+exec "$ps" -NoProfile -NonInteractive -ExecutionPolicy Bypass -File "$(dirname "$0")/%s.ps1" "$@"
+`, hookName, hookMarker, hookName, hookName)
+		powerShell = fmt.Sprintf(`# %s hook %s (PowerShell)
+# %s
+& "%s" %s @args
+exit $LASTEXITCODE
+`, hookName, hookMarker, description, exePath, command)
+		return script, powerShell
+	default:
+		return fmt.Sprintf(`#!/bin/sh
+# %s hook %s
+# %s
+exec "%s" %s "$@"
+`, hookName, hookMarker, description, exePath, command), ""
+	}
+}
+
+// hookExecutable returns the absolute path of the running binary for use in hooks
+func hookExecutable() string {
+	exePath, err := os.Executable()
+	if err != nil {
+		return "generate-commit" // Fallback
+	}
+	// Ensure we have an absolute path
+	if absPath, err := filepath.Abs(exePath); err == nil {
+		exePath = absPath
+	}
+	return exePath
+}
@@ -0,0 +1,710 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ai-commit-message-generator/internal/ai"
+	"ai-commit-message-generator/internal/config"
+	"ai-commit-message-generator/internal/git"
+)
+
+func TestApp_PrepareCommitMsgHook(t *testing.T) {
+	gitComments := "# Please enter the commit message for your changes.\n# On branch main\n"
+	// squashMsg is what git merge --squash writes to SQUASH_MSG
+	squashMsg := "Squashed commit of the following:\n\ncommit 1a2b3c\nAuthor: Test User <test@example.com>\n\n    feat(auth): added login form\n\ncommit 4d5e6f\nAuthor: Test User <test@example.com>\n\n    wip\n"
+	squashComments := "# Squashed commit messages, for reference:\n#\n# Squashed commit of the following:\n#\n# commit 1a2b3c\n# Author: Test User <test@example.com>\n#\n#     feat(auth): added login form\n#\n# commit 4d5e6f\n# Author: Test User <test@example.com>\n#\n#     wip\n"
+	rebaseSquash := "# This is a combination of 2 commits.\n# This is the 1st commit message:\n\nfeat(auth): added login form\n\n# This is the commit message #2:\n\nfix typo\n"
+
+	tests := []struct {
+		name            string
+		source          string
+		existing        string
+		response        string
+		state           *git.GitState
+		commentChar     string
+		expectGenerated bool
+		// expectedSquashed is the OriginalMessage of a squash request
+		expectedSquashed string
+		expectedContent  string
+	}{
+		{
+			name:            "Plain commit writes the message",
+			source:          "",
+			existing:        "\n" + gitComments,
+			response:        "feat: added login",
+			expectGenerated: true,
+			expectedContent: "feat: added login\n\n" + gitComments,
+		},
+		{
+			name:            "Template source is replaced",
+			source:          "template",
+			existing:        "TICKET: \n" + gitComments,
+			response:        "feat: added login",
+			expectGenerated: true,
+			expectedContent: "feat: added login\n\n" + gitComments,
+		},
+		{
+			name:            "Merge source generates a message",
+			source:          "merge",
+			existing:        "Merge branch 'feature'\n" + gitComments,
+			response:        "feat(merge): Merged feature into main",
+			expectGenerated: true,
+			expectedContent: "feat(merge): merged feature into main\n\n" + gitComments,
+		},
+		{
+			name:            "Message from -m is kept",
+			source:          "message",
+			existing:        "fix: typed by hand\n",
+			expectGenerated: false,
+			expectedContent: "fix: typed by hand\n",
+		},
+		{
+			name:            "Amend or -c keeps the message",
+			source:          "commit",
+			existing:        "fix: earlier message\n",
+			expectGenerated: false,
+			expectedContent: "fix: earlier message\n",
+		},
+		{
+			name:             "Squash combines the squashed messages",
+			source:           "squash",
+			existing:         squashMsg + gitComments,
+			response:         "feat(auth): added a login form",
+			expectGenerated:  true,
+			expectedSquashed: strings.TrimSpace(squashMsg),
+			expectedContent:  "feat(auth): added a login form\n\n" + squashComments + gitComments,
+		},
+		{
+			name:             "Squash falls back to SQUASH_MSG",
+			source:           "squash",
+			existing:         gitComments,
+			state:            &git.GitState{Type: git.StateSquash, OriginalMessage: strings.TrimSpace(squashMsg)},
+			response:         "feat(auth): added a login form",
+			expectGenerated:  true,
+			expectedSquashed: strings.TrimSpace(squashMsg),
+			expectedContent:  "feat(auth): added a login form\n\n" + squashComments + gitComments,
+		},
+		{
+			name:             "Interactive rebase squash",
+			source:           "message",
+			existing:         rebaseSquash,
+			response:         "feat(auth): added a login form",
+			expectGenerated:  true,
+			expectedSquashed: "feat(auth): added login form\n\nfix typo",
+			expectedContent:  "feat(auth): added a login form\n\n# Squashed commit messages, for reference:\n#\n# feat(auth): added login form\n#\n# fix typo\n# This is a combination of 2 commits.\n# This is the 1st commit message:\n# This is the commit message #2:\n",
+		},
+		{
+			name:            "Split suggestion is written as comments",
+			source:          "",
+			existing:        gitComments,
+			response:        "This should be split into separate commits:\n1. auth\n2. ui",
+			expectGenerated: true,
+			expectedContent: "\n# AI Suggestion (Split Changes):\n# This should be split into separate commits:\n# 1. auth\n# 2. ui\n\n" + gitComments,
+		},
+		{
+			name:            "Custom comment character",
+			source:          "",
+			existing:        "\n#42 is not a comment\n; Please enter the commit message for your changes.\n",
+			commentChar:     ";",
+			response:        "feat: added login",
+			expectGenerated: true,
+			expectedContent: "feat: added login\n\n; Please enter the commit message for your changes.\n",
+		},
+		{
+			name:             "Interactive rebase squash with a custom comment character",
+			source:           "message",
+			existing:         "; This is a combination of 2 commits.\n; This is the 1st commit message:\n\nfeat(auth): added login form\n\n; This is the commit message #2:\n\n#42 fix typo\n",
+			commentChar:      ";",
+			response:         "feat(auth): added a login form",
+			expectGenerated:  true,
+			expectedSquashed: "feat(auth): added login form\n\n#42 fix typo",
+			expectedContent:  "feat(auth): added a login form\n\n; Squashed commit messages, for reference:\n;\n; feat(auth): added login form\n;\n; #42 fix typo\n; This is a combination of 2 commits.\n; This is the 1st commit message:\n; This is the commit message #2:\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msgFile := filepath.Join(t.TempDir(), "COMMIT_EDITMSG")
+			if err := os.WriteFile(msgFile, []byte(tt.existing), 0644); err != nil {
+				t.Fatalf("failed to write message file: %v", err)
+			}
+
+			generated := false
+			mockGit := &MockGit{
+				IsInsideRepoFunc:     func() (bool, error) { return true, nil },
+				HasStagedChangesFunc: func() (bool, error) { return true, nil },
+				GetStagedDiffFunc:    func() (string, error) { return "diff", nil },
+				DetectStateFunc: func() (*git.GitState, error) {
+					if tt.state != nil {
+						return tt.state, nil
+					}
+					return &git.GitState{Type: git.StateNormal}, nil
+				},
+			}
+			if tt.commentChar != "" {
+				mockGit.GetCommentCharFunc = func() (string, error) { return tt.commentChar, nil }
+			}
+			mockConfig := &MockConfig{
+				LoadRulesFunc: func() (string, error) { return "", nil },
+			}
+			mockAI := &MockAI{
+				GenerateCommitMessageFunc: func(req ai.CommitRequest) (string, error) {
+					generated = true
+					if tt.expectedSquashed != "" {
+						if req.GitState.Type != git.StateSquash || req.GitState.OriginalMessage != tt.expectedSquashed {
+							t.Errorf("expected the squashed messages %q, got %s state with %q", tt.expectedSquashed, req.GitState.Type, req.GitState.OriginalMessage)
+						}
+					}
+					return tt.response, nil
+				},
+			}
+
+			application := NewApp(mockGit, mockConfig, nil, mockAI)
+			if err := application.PrepareCommitMsgHook(msgFile, tt.source, ""); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if generated != tt.expectGenerated {
+				t.Errorf("expected generated=%v, got %v", tt.expectGenerated, generated)
+			}
+
+			content, err := os.ReadFile(msgFile)
+			if err != nil {
+				t.Fatalf("failed to read message file: %v", err)
+			}
+			if string(content) != tt.expectedContent {
+				t.Errorf("expected content %q, got %q", tt.expectedContent, string(content))
+			}
+		})
+	}
+}
+
+func TestApp_PrepareCommitMsgHook_MergeExistingMessage(t *testing.T) {
+	gitComments := "# Please enter the commit message for your changes.\n"
+	tests := []struct {
+		name     string
+		source   string
+		existing string
+		response string
+		disabled bool
+		// expectedStarted is the ExistingMessage of the request
+		expectedStarted string
+		expectedContent string
+	}{
+		{
+			name:            "Ticket line is replaced and the footer kept",
+			existing:        "PROJ-42\n\nRefs: #12\n" + gitComments,
+			response:        "feat(auth): added login\n\nAdds a login form.",
+			expectedStarted: "PROJ-42\n\nRefs: #12",
+			expectedContent: "feat(auth): added login\n\nAdds a login form.\n\nRefs: #12\n\n" + gitComments,
+		},
+		{
+			name:            "Body the model left out is kept",
+			existing:        "wip\n\nReviewed with the security team.\n",
+			response:        "feat(auth): added login\n\nAdds a login form.\n\nSigned-off-by: Jane <jane@example.com>",
+			expectedStarted: "wip\n\nReviewed with the security team.",
+			expectedContent: "feat(auth): added login\n\nAdds a login form.\n\nReviewed with the security team.\n\nSigned-off-by: Jane <jane@example.com>\n",
+		},
+		{
+			name:            "Lines the model kept are not repeated",
+			existing:        "wip\n\nKeeps the old token format.\n\nRefs: #12",
+			response:        "feat(auth): added login\n\nAdds a login form and\nkeeps the old token format.\n\nrefs: #12",
+			expectedStarted: "wip\n\nKeeps the old token format.\n\nRefs: #12",
+			expectedContent: "feat(auth): added login\n\nAdds a login form and\nkeeps the old token format.\n\nrefs: #12\n",
+		},
+		{
+			name:            "Trailers only",
+			source:          "template",
+			existing:        "Refs: PROJ-42\n",
+			response:        "feat(auth): added login\n\nSigned-off-by: Jane <jane@example.com>",
+			expectedStarted: "Refs: PROJ-42",
+			expectedContent: "feat(auth): added login\n\nSigned-off-by: Jane <jane@example.com>\nRefs: PROJ-42\n",
+		},
+		{
+			name:            "Split suggestion keeps the started message",
+			existing:        "PROJ-42\n" + gitComments,
+			response:        "This should be split into separate commits:\n1. auth\n2. ui",
+			expectedStarted: "PROJ-42",
+			expectedContent: "PROJ-42\n\n# AI Suggestion (Split Changes):\n# This should be split into separate commits:\n# 1. auth\n# 2. ui\n\n" + gitComments,
+		},
+		{
+			name:            "Only comments",
+			existing:        "\n" + gitComments,
+			response:        "feat(auth): added login",
+			expectedContent: "feat(auth): added login\n\n" + gitComments,
+		},
+		{
+			name:            "Merge message is replaced",
+			source:          "merge",
+			existing:        "Merge branch 'feature'\n" + gitComments,
+			response:        "feat(merge): merged feature into main",
+			expectedContent: "feat(merge): merged feature into main\n\n" + gitComments,
+		},
+		{
+			name:            "Disabled",
+			existing:        "PROJ-42\n\nRefs: #12\n" + gitComments,
+			response:        "feat(auth): added login",
+			disabled:        true,
+			expectedContent: "feat(auth): added login\n\n" + gitComments,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msgFile := filepath.Join(t.TempDir(), "COMMIT_EDITMSG")
+			if err := os.WriteFile(msgFile, []byte(tt.existing), 0644); err != nil {
+				t.Fatalf("failed to write message file: %v", err)
+			}
+
+			mockGit := &MockGit{
+				IsInsideRepoFunc:     func() (bool, error) { return true, nil },
+				HasStagedChangesFunc: func() (bool, error) { return true, nil },
+				GetStagedDiffFunc:    func() (string, error) { return "diff", nil },
+			}
+			mockAI := &MockAI{
+				GenerateCommitMessageFunc: func(req ai.CommitRequest) (string, error) {
+					if req.ExistingMessage != tt.expectedStarted {
+						t.Errorf("expected the started message %q, got %q", tt.expectedStarted, req.ExistingMessage)
+					}
+					return tt.response, nil
+				},
+			}
+			application := NewApp(mockGit, &MockConfig{LoadRulesFunc: func() (string, error) { return "", nil }}, nil, mockAI)
+			application.MergeExistingMessage = !tt.disabled
+			if err := application.PrepareCommitMsgHook(msgFile, tt.source, ""); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			content, err := os.ReadFile(msgFile)
+			if err != nil {
+				t.Fatalf("failed to read message file: %v", err)
+			}
+			if string(content) != tt.expectedContent {
+				t.Errorf("expected content %q, got %q", tt.expectedContent, string(content))
+			}
+		})
+	}
+}
+
+func TestApp_Init_HookType(t *testing.T) {
+	tests := []struct {
+		name          string
+		hookType      string
+		expectedHooks []string
+		absentHooks   []string
+		expectedError string
+	}{
+		{
+			name:          "Default installs pre-commit",
+			hookType:      "",
+			expectedHooks: []string{HookPreCommit},
+			absentHooks:   []string{HookPrepareCommitMsg},
+		},
+		{
+			name:          "prepare-commit-msg only",
+			hookType:      HookPrepareCommitMsg,
+			expectedHooks: []string{HookPrepareCommitMsg},
+			absentHooks:   []string{HookPreCommit},
+		},
+		{
+			name:          "Both hooks",
+			hookType:      HookTypeBoth,
+			expectedHooks: []string{HookPreCommit, HookPrepareCommitMsg},
+		},
+		{
+			name:          "Comma-separated list with commit-msg",
+			hookType:      "prepare-commit-msg, commit-msg",
+			expectedHooks: []string{HookPrepareCommitMsg, HookCommitMsg},
+			absentHooks:   []string{HookPreCommit},
+		},
+		{
+			name:          "Unknown hook type",
+			hookType:      "post-commit",
+			expectedError: "unknown hook type",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repoRoot := t.TempDir()
+			if err := os.Mkdir(filepath.Join(repoRoot, ".git"), 0755); err != nil {
+				t.Fatalf("failed to create .git dir: %v", err)
+			}
+			originalWd, _ := os.Getwd()
+			defer os.Chdir(originalWd)
+			os.Chdir(repoRoot)
+
+			mockGit := &MockGit{
+				IsInsideRepoFunc: func() (bool, error) { return true, nil },
+				GetRepoRootFunc:  func() (string, error) { return repoRoot, nil },
+			}
+			application := NewApp(mockGit, &MockConfig{}, config.NewConfigLoader(), nil)
+
+			err := application.Init(InitOptions{HookType: tt.hookType})
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("expected error containing %q, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			for _, hook := range tt.expectedHooks {
+				content, err := os.ReadFile(hookPath(filepath.Join(repoRoot, ".git", "hooks"), hook))
+				if err != nil {
+					t.Errorf("expected %s hook to be installed: %v", hook, err)
+					continue
+				}
+				if !strings.Contains(string(content), "hook "+hook) {
+					t.Errorf("expected %s hook to invoke 'hook %s', got:\n%s", hook, hook, content)
+				}
+			}
+			for _, hook := range tt.absentHooks {
+				if _, err := os.Stat(hookPath(filepath.Join(repoRoot, ".git", "hooks"), hook)); err == nil {
+					t.Errorf("expected %s hook not to be installed", hook)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateCommitMsgHook(t *testing.T) {
+	application := NewApp(&MockGit{}, &MockConfig{}, nil, nil)
+
+	if hook, _ := application.generateHook(HookCommitMsg, HookShellSh, false); !strings.Contains(hook, "hook commit-msg") || strings.Contains(hook, "--fix") {
+		t.Errorf("expected lint-only hook, got:\n%s", hook)
+	}
+	if hook, _ := application.generateHook(HookCommitMsg, HookShellSh, true); !strings.Contains(hook, "hook commit-msg --fix") {
+		t.Errorf("expected hook with --fix, got:\n%s", hook)
+	}
+}
+
+func TestGenerateHook(t *testing.T) {
+	application := NewApp(&MockGit{}, &MockConfig{}, nil, nil)
+	exe := hookExecutable()
+
+	tests := []struct {
+		shell string
+		// firstLine is the interpreter line of the hook git runs
+		firstLine string
+		// command is how the binary is called, in the hook or its
+		// PowerShell script
+		command    string
+		powerShell bool
+	}{
+		{shell: HookShellSh, firstLine: "#!/bin/sh", command: `exec "` + exe + `" hook %s "$@"`},
+		{shell: HookShellPowerShell, firstLine: "#!/bin/sh", command: `& "` + exe + `" hook %s @args`, powerShell: true},
+		{shell: HookShellBatch, firstLine: "@echo off", command: `"` + strings.ReplaceAll(exe, "%", "%%") + `" hook %s %%*`},
+	}
+
+	for _, tt := range tests {
+		for _, hookName := range allHookNames {
+			t.Run(tt.shell+"/"+hookName, func(t *testing.T) {
+				script, powerShell := application.generateHook(hookName, tt.shell, false)
+				if first := strings.SplitN(script, "\n", 2)[0]; first != tt.firstLine {
+					t.Errorf("expected interpreter line %q, got %q", tt.firstLine, first)
+				}
+				if !strings.Contains(script, hookMarker) {
+					t.Errorf("expected the marker in the hook, got:\n%s", script)
+				}
+				for _, bashism := range []string{"[[", "read -p", "perl", "sed ", "/dev/tty"} {
+					if strings.Contains(script, bashism) {
+						t.Errorf("expected no %q in the hook, got:\n%s", bashism, script)
+					}
+				}
+
+				commandScript := script
+				if tt.powerShell {
+					if powerShell == "" || !strings.Contains(powerShell, hookMarker) {
+						t.Fatalf("expected a PowerShell script with the marker, got %q", powerShell)
+					}
+					if !strings.Contains(script, hookName+".ps1") {
+						t.Errorf("expected the hook to start %s.ps1, got:\n%s", hookName, script)
+					}
+					commandScript = powerShell
+				} else if powerShell != "" {
+					t.Errorf("expected no PowerShell script, got:\n%s", powerShell)
+				}
+				if want := fmt.Sprintf(tt.command, hookName); !strings.Contains(commandScript, want) {
+					t.Errorf("expected %q in:\n%s", want, commandScript)
+				}
+				if m := hookExecPattern.FindStringSubmatch(commandScript); m == nil || m[1] != exe {
+					t.Errorf("expected doctor to find %s in the script, got %v", exe, m)
+				}
+
+				if strings.HasPrefix(script, "#!/bin/sh") {
+					lintShellScript(t, script)
+				}
+			})
+		}
+	}
+}
+
+func TestGenerateHook_BatchKeepsMultiLineMessages(t *testing.T) {
+	application := NewApp(&MockGit{}, &MockConfig{}, nil, nil)
+
+	for _, hookName := range allHookNames {
+		t.Run(hookName, func(t *testing.T) {
+			script, _ := application.generateHook(hookName, HookShellBatch, false)
+			// Reading output line by line into a variable keeps only one
+			// line of the message, and set /p <file only the first
+			for _, pattern := range []string{"for /f", "set /p", "set OUTPUT", "<"} {
+				if strings.Contains(strings.ToLower(script), pattern) {
+					t.Errorf("expected no %q in the batch hook, got:\n%s", pattern, script)
+				}
+			}
+			if strings.Contains(script, "git commit") {
+				t.Errorf("expected the binary to commit, not the batch hook:\n%s", script)
+			}
+			if !strings.Contains(script, " hook "+hookName+" %*\n") {
+				t.Errorf("expected the hook to pass git's arguments to the binary, got:\n%s", script)
+			}
+		})
+	}
+}
+
+// lintShellScript checks script with sh -n, where sh is available
+func lintShellScript(t *testing.T, script string) {
+	t.Helper()
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Log("sh not found; skipping the syntax check")
+		return
+	}
+	path := filepath.Join(t.TempDir(), "hook")
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write hook: %v", err)
+	}
+	if out, err := exec.Command(sh, "-n", path).CombinedOutput(); err != nil {
+		t.Errorf("sh -n rejected the hook: %v\n%s\n%s", err, out, script)
+	}
+}
+
+func TestApp_Init_HookShell(t *testing.T) {
+	repoRoot := t.TempDir()
+	hooksDir := filepath.Join(repoRoot, ".git", "hooks")
+	if err := os.MkdirAll(hooksDir, 0755); err != nil {
+		t.Fatalf("failed to create hooks dir: %v", err)
+	}
+	originalWd, _ := os.Getwd()
+	defer os.Chdir(originalWd)
+	os.Chdir(repoRoot)
+
+	mockGit := &MockGit{
+		IsInsideRepoFunc: func() (bool, error) { return true, nil },
+		GetRepoRootFunc:  func() (string, error) { return repoRoot, nil },
+	}
+	application := NewApp(mockGit, &MockConfig{}, config.NewConfigLoader(), nil)
+
+	hook := filepath.Join(hooksDir, HookPreCommit)
+	steps := []struct {
+		shell   string
+		present []string
+		absent  []string
+	}{
+		{shell: HookShellPowerShell, present: []string{hook, hook + ".ps1"}, absent: []string{hook + ".bat"}},
+		{shell: HookShellBatch, present: []string{hook + ".bat"}, absent: []string{hook, hook + ".ps1"}},
+		{shell: HookShellSh, present: []string{hook}, absent: []string{hook + ".bat", hook + ".ps1"}},
+	}
+	for _, step := range steps {
+		captureStdout(t, func() {
+			if err := application.Init(InitOptions{Force: true, HookShell: step.shell}); err != nil {
+				t.Fatalf("%s: unexpected error: %v", step.shell, err)
+			}
+		})
+		for _, path := range step.present {
+			if _, err := os.Stat(path); err != nil {
+				t.Errorf("%s: expected %s: %v", step.shell, filepath.Base(path), err)
+			}
+		}
+		for _, path := range step.absent {
+			if _, err := os.Stat(path); err == nil {
+				t.Errorf("%s: expected %s to be removed", step.shell, filepath.Base(path))
+			}
+		}
+	}
+
+	err := application.Init(InitOptions{Force: true, HookShell: "zsh"})
+	if err == nil || !strings.Contains(err.Error(), "unknown --hook-shell") {
+		t.Errorf("expected an unknown shell error, got %v", err)
+	}
+}
+
+func TestApp_Init_ExistingHook(t *testing.T) {
+	const userHook = "#!/bin/sh\nnpx lint-staged\n"
+
+	tests := []struct {
+		name            string
+		onExisting      string
+		existing        string
+		chainedBefore   bool
+		expectedError   string
+		expectedBackup  bool
+		expectedChained bool
+	}{
+		{
+			name:          "Default aborts with instructions",
+			existing:      userHook,
+			expectedError: "--on-existing backup",
+		},
+		{
+			name:          "Explicit abort",
+			onExisting:    OnExistingAbort,
+			existing:      userHook,
+			expectedError: "already exists",
+		},
+		{
+			name:           "Backup moves the existing hook aside",
+			onExisting:     OnExistingBackup,
+			existing:       userHook,
+			expectedBackup: true,
+		},
+		{
+			name:            "Chain runs the existing hook first",
+			onExisting:      OnExistingChain,
+			existing:        userHook,
+			expectedChained: true,
+		},
+		{
+			name:     "Our own hook is replaced without asking",
+			existing: "#!/bin/bash\n# Pre-commit hook for AI commit message generator\nexec old-binary\n",
+		},
+		{
+			name:            "Reinitialization keeps an earlier chain",
+			existing:        "#!/bin/bash\n# Pre-commit hook for AI commit message generator\nexec old-binary\n",
+			chainedBefore:   true,
+			expectedChained: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repoRoot := t.TempDir()
+			hooksDir := filepath.Join(repoRoot, ".git", "hooks")
+			if err := os.MkdirAll(hooksDir, 0755); err != nil {
+				t.Fatalf("failed to create hooks dir: %v", err)
+			}
+			originalWd, _ := os.Getwd()
+			defer os.Chdir(originalWd)
+			os.Chdir(repoRoot)
+
+			path := hookPath(hooksDir, HookPreCommit)
+			chainedPath := strings.TrimSuffix(path, ".bat") + ".chained"
+			if strings.HasSuffix(path, ".bat") {
+				chainedPath += ".bat"
+			}
+			if err := os.WriteFile(path, []byte(tt.existing), 0755); err != nil {
+				t.Fatalf("failed to write existing hook: %v", err)
+			}
+			if tt.chainedBefore {
+				if err := os.WriteFile(chainedPath, []byte(userHook), 0755); err != nil {
+					t.Fatalf("failed to write chained hook: %v", err)
+				}
+			}
+
+			mockGit := &MockGit{
+				IsInsideRepoFunc: func() (bool, error) { return true, nil },
+				GetRepoRootFunc:  func() (string, error) { return repoRoot, nil },
+			}
+			application := NewApp(mockGit, &MockConfig{}, config.NewConfigLoader(), nil)
+
+			err := application.Init(InitOptions{OnExisting: tt.onExisting})
+			content, _ := os.ReadFile(path)
+
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("expected error containing %q, got %v", tt.expectedError, err)
+				}
+				if string(content) != tt.existing {
+					t.Errorf("expected existing hook to be untouched, got:\n%s", content)
+				}
+				if _, err := os.Stat(filepath.Join(repoRoot, ".commit-generator-config")); err == nil {
+					t.Error("expected abort to happen before any file is written")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !strings.Contains(string(content), "hook pre-commit") {
+				t.Errorf("expected our hook to be installed, got:\n%s", content)
+			}
+
+			backup, err := os.ReadFile(path + ".backup")
+			if tt.expectedBackup != (err == nil) {
+				t.Errorf("expected backup=%v, got err %v", tt.expectedBackup, err)
+			}
+			if tt.expectedBackup && string(backup) != userHook {
+				t.Errorf("expected backup to hold the original hook, got:\n%s", backup)
+			}
+
+			chained, err := os.ReadFile(chainedPath)
+			if tt.expectedChained != (err == nil) {
+				t.Errorf("expected chained hook=%v, got err %v", tt.expectedChained, err)
+			}
+			if tt.expectedChained {
+				if string(chained) != userHook {
+					t.Errorf("expected chained file to hold the original hook, got:\n%s", chained)
+				}
+				call := strings.Index(string(content), filepath.Base(chainedPath))
+				ours := strings.Index(string(content), "hook pre-commit")
+				if call < 0 || call > ours {
+					t.Errorf("expected the chained hook to run before ours, got:\n%s", content)
+				}
+			}
+		})
+	}
+}
+
+func TestApp_Init_HooksPath(t *testing.T) {
+	repoRoot := t.TempDir()
+	if err := os.Mkdir(filepath.Join(repoRoot, ".git"), 0755); err != nil {
+		t.Fatalf("failed to create .git dir: %v", err)
+	}
+	originalWd, _ := os.Getwd()
+	defer os.Chdir(originalWd)
+	os.Chdir(repoRoot)
+
+	hooksDir := filepath.Join(repoRoot, ".husky")
+	mockGit := &MockGit{
+		IsInsideRepoFunc: func() (bool, error) { return true, nil },
+		GetRepoRootFunc:  func() (string, error) { return repoRoot, nil },
+		GetHooksDirFunc:  func() (string, error) { return hooksDir, nil },
+	}
+	application := NewApp(mockGit, &MockConfig{}, config.NewConfigLoader(), nil)
+
+	if err := application.Init(InitOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(hookPath(hooksDir, HookPreCommit)); err != nil {
+		t.Errorf("expected hook in core.hooksPath: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(repoRoot, ".git", "hooks")); err == nil {
+		t.Error("expected .git/hooks not to be used when core.hooksPath is set")
+	}
+}
+
+func TestChainHook(t *testing.T) {
+	content := "#!/bin/sh\n# header\nexec \"gc\" hook pre-commit \"$@\"\n"
+	chained := chainHook(content, "pre-commit.chained", false)
+	expected := "#!/bin/sh\n# header\n# Run the hook that existed before init\n\"$(dirname \"$0\")/pre-commit.chained\" \"$@\" || exit $?\nexec \"gc\" hook pre-commit \"$@\"\n"
+	if chained != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, chained)
+	}
+	lintShellScript(t, chained)
+
+	content = "@echo off\nREM header\n\"gc\" hook pre-commit %*\n"
+	chained = chainHook(content, "pre-commit.chained.bat", true)
+	expected = "@echo off\nREM header\nREM Run the hook that existed before init\ncall \"%~dp0pre-commit.chained.bat\" %*\nif errorlevel 1 exit /b %errorlevel%\n\"gc\" hook pre-commit %*\n"
+	if chained != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, chained)
+	}
+}
@@ -0,0 +1,115 @@
+package app
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+
+	"ai-commit-message-generator/internal/git"
+	"ai-commit-message-generator/internal/logging"
+)
+
+// lastCommitRange selects HEAD alone
+const lastCommitRange = "HEAD~1..HEAD"
+
+// ImproveLastOptions controls ImproveLast
+type ImproveLastOptions struct {
+	// Yes amends without asking
+	Yes bool
+	// Force amends even when HEAD is already on a protected branch or a
+	// remote
+	Force bool
+}
+
+// ImproveLast generates a better message for the last commit from its diff
+// and current message and offers to amend the commit with it. Only the
+// message changes: like reword, and unlike git commit --amend, staged
+// changes are not added to the commit. A commit that is already published
+// gets a warning, and with opts.Yes is only amended with opts.Force.
+func (a *App) ImproveLast(opts ImproveLastOptions) error {
+	isRepo, err := a.Git.IsInsideRepo()
+	if err != nil {
+		return fmt.Errorf("failed to check repository status: %w", err)
+	}
+	if !isRepo {
+		return ErrNotARepo
+	}
+
+	defer a.Progress.Stop()
+	a.Progress.Phase(PhaseReadingDiff)
+	commits, err := a.Git.GetCommitRange(lastCommitRange)
+	if err != nil {
+		return fmt.Errorf("failed to read the last commit (the first commit of a repository cannot be improved): %w", err)
+	}
+	if len(commits) == 0 {
+		return fmt.Errorf("there is no commit to improve yet")
+	}
+	if state, err := a.Git.DetectState(); err == nil && state.Type != git.StateNormal {
+		return fmt.Errorf("a %s is in progress; finish or abort it before amending", state.Type)
+	}
+	// Refuse before asking the model for anything
+	published, err := a.warnIfPublished(commits[0])
+	if err != nil {
+		return err
+	}
+	if opts.Yes && published && !opts.Force {
+		return fmt.Errorf("the last commit is already published; pass --force to amend it anyway")
+	}
+
+	rules, err := a.RulesLoader.LoadRules(nil)
+	if err != nil {
+		a.log().Warn("failed to load rules, proceeding without them", logging.KeyStage, "rules", logging.KeyError, err)
+	}
+	entry, err := a.rewordCommit(commits[0], rules)
+	if err != nil {
+		return err
+	}
+	a.Progress.Stop()
+
+	printRewordMapping([]rewordEntry{entry})
+	if entry.note != "" {
+		a.status("\nThe last commit keeps its message.")
+		return nil
+	}
+
+	switch {
+	case !opts.Yes && !a.canPrompt():
+		a.status("\nNothing was changed. Run with --yes to amend the last commit with this message.")
+		return nil
+	case !opts.Yes:
+		amend, err := a.confirm(bufio.NewReader(a.Terminal.In), "Amend the last commit with this message?")
+		if err != nil {
+			return err
+		}
+		if !amend {
+			return ErrCancelled
+		}
+	}
+
+	oldHead, newHead, err := a.Git.RewordCommits(lastCommitRange, map[string]string{commits[0].Hash: entry.message})
+	if err != nil {
+		return fmt.Errorf("failed to amend the last commit: %w", err)
+	}
+	fmt.Printf("\n\033[32m✓ Amended the last commit\033[0m\n")
+	fmt.Printf("HEAD moved from %s to %s. Undo with: git reset --soft %s\n", shortHash(oldHead), shortHash(newHead), oldHead)
+	return nil
+}
+
+// warnIfPublished warns when commit can be reached from a remote-tracking
+// or protected branch, where amending it would rewrite shared history
+func (a *App) warnIfPublished(commit git.LogCommit) (bool, error) {
+	protected := a.ProtectedBranches
+	if len(protected) == 0 {
+		protected = defaultProtectedBranches
+	}
+	published, err := a.Git.FindPublished([]string{commit.Hash}, protected)
+	if err != nil {
+		return false, fmt.Errorf("failed to check whether the last commit is published: %w", err)
+	}
+	branch, ok := published[commit.Hash]
+	if !ok {
+		return false, nil
+	}
+	fmt.Fprintf(os.Stderr, "\033[33mWarning: the last commit is already on %s; amending it rewrites shared history and needs a force push\033[0m\n", branch)
+	return true, nil
+}
@@ -0,0 +1,166 @@
+package app
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"ai-commit-message-generator/internal/ai"
+	"ai-commit-message-generator/internal/git"
+)
+
+func TestApp_ImproveLast(t *testing.T) {
+	last := git.LogCommit{Hash: "aaaaaaaaaa", Message: "fix stuff"}
+	tests := []struct {
+		name             string
+		opts             ImproveLastOptions
+		commits          []git.LogCommit
+		published        map[string]string
+		state            git.GitStateType
+		terminalInput    string
+		generated        string
+		expectedMessages map[string]string
+		expectedOutput   []string
+		expectedError    string
+		expectNoAI       bool
+	}{
+		{
+			name:             "Yes amends",
+			opts:             ImproveLastOptions{Yes: true},
+			generated:        "fix(auth): handled expired tokens",
+			expectedMessages: map[string]string{"aaaaaaaaaa": "fix(auth): handled expired tokens"},
+			expectedOutput:   []string{"aaaaaaa  fix stuff\n      →  \033[36mfix(auth): handled expired tokens\033[0m\n", "✓ Amended the last commit", "git reset --soft 0000000000"},
+		},
+		{
+			name:           "Without a terminal only prints",
+			generated:      "fix(auth): handled expired tokens",
+			expectedOutput: []string{"Run with --yes to amend"},
+		},
+		{
+			name:             "Confirmed on the terminal",
+			terminalInput:    "y\n",
+			generated:        "fix(auth): handled expired tokens",
+			expectedMessages: map[string]string{"aaaaaaaaaa": "fix(auth): handled expired tokens"},
+		},
+		{
+			name:          "Declined on the terminal",
+			terminalInput: "n\n",
+			generated:     "fix(auth): handled expired tokens",
+			expectedError: "aborted by user",
+		},
+		{
+			name:           "Unchanged message",
+			opts:           ImproveLastOptions{Yes: true},
+			generated:      "fix stuff",
+			expectedOutput: []string{"(unchanged)", "keeps its message"},
+		},
+		{
+			name:          "Published commit is refused with yes",
+			opts:          ImproveLastOptions{Yes: true},
+			published:     map[string]string{"aaaaaaaaaa": "origin/main"},
+			expectedError: "already published; pass --force",
+			expectNoAI:    true,
+		},
+		{
+			name:             "Force amends a published commit",
+			opts:             ImproveLastOptions{Yes: true, Force: true},
+			published:        map[string]string{"aaaaaaaaaa": "origin/main"},
+			generated:        "fix(auth): handled expired tokens",
+			expectedMessages: map[string]string{"aaaaaaaaaa": "fix(auth): handled expired tokens"},
+		},
+		{
+			name:             "Published commit can still be confirmed",
+			terminalInput:    "yes\n",
+			published:        map[string]string{"aaaaaaaaaa": "origin/main"},
+			generated:        "fix(auth): handled expired tokens",
+			expectedMessages: map[string]string{"aaaaaaaaaa": "fix(auth): handled expired tokens"},
+		},
+		{
+			name:          "No commits yet",
+			commits:       []git.LogCommit{},
+			expectedError: "no commit to improve",
+			expectNoAI:    true,
+		},
+		{
+			name:          "Not during a merge",
+			state:         git.StateMerge,
+			expectedError: "a merge is in progress",
+			expectNoAI:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commits := tt.commits
+			if commits == nil {
+				commits = []git.LogCommit{last}
+			}
+			var gotRange string
+			var gotMessages map[string]string
+			mockGit := &MockGit{
+				IsInsideRepoFunc: func() (bool, error) { return true, nil },
+				GetCommitRangeFunc: func(revRange string) ([]git.LogCommit, error) {
+					gotRange = revRange
+					return commits, nil
+				},
+				DetectStateFunc:   func() (*git.GitState, error) { return &git.GitState{Type: tt.state}, nil },
+				GetCommitDiffFunc: func(hash string) (string, error) { return "diff --git a/auth.go b/auth.go\n+refresh()\n", nil },
+				FindPublishedFunc: func(hashes, protected []string) (map[string]string, error) {
+					return tt.published, nil
+				},
+				RewordCommitsFunc: func(revRange string, messages map[string]string) (string, string, error) {
+					if revRange != "HEAD~1..HEAD" {
+						t.Errorf("expected only HEAD to be amended, got %s", revRange)
+					}
+					gotMessages = messages
+					return "0000000000", "1111111111", nil
+				},
+			}
+			mockAI := &MockAI{
+				GenerateCommitMessageFunc: func(req ai.CommitRequest) (string, error) {
+					if tt.expectNoAI {
+						t.Error("expected no generation")
+					}
+					if req.PreviousMessage != "fix stuff" || req.Feedback != rewordFeedback {
+						t.Errorf("expected the current message to be improved, got %+v", req)
+					}
+					return tt.generated, nil
+				},
+			}
+			application := NewApp(mockGit, &MockConfig{LoadRulesFunc: func() (string, error) { return "", nil }}, nil, mockAI)
+			if tt.terminalInput != "" {
+				fakeTTY(t)
+				application.Terminal = &Terminal{In: strings.NewReader(tt.terminalInput), Out: io.Discard}
+			}
+
+			var err error
+			output := captureStdout(t, func() {
+				err = application.ImproveLast(tt.opts)
+			})
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("expected error containing %q, got %v", tt.expectedError, err)
+				}
+				if gotMessages != nil {
+					t.Errorf("expected nothing to be amended, got %v", gotMessages)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ImproveLast failed: %v", err)
+			}
+			if gotRange != "HEAD~1..HEAD" {
+				t.Errorf("expected HEAD to be read, got %s", gotRange)
+			}
+			if !reflect.DeepEqual(gotMessages, tt.expectedMessages) {
+				t.Errorf("expected messages %v, got %v", tt.expectedMessages, gotMessages)
+			}
+			for _, want := range tt.expectedOutput {
+				if !strings.Contains(output, want) {
+					t.Errorf("expected %q in output:\n%s", want, output)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,283 @@
+package app
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"ai-commit-message-generator/internal/config"
+)
+
+// Providers offered by the init wizard. Both speak the Ollama API.
+const (
+	ProviderOllama           = "ollama"
+	ProviderOllamaCompatible = "ollama-compatible"
+)
+
+// Where the init wizard keeps the API key
+const (
+	KeyStorageEnv      = "env"
+	KeyStorageKeychain = "keychain"
+	KeyStorageConfig   = "config"
+	KeyStorageNone     = "none"
+)
+
+// wizardResult is everything the init wizard collected
+type wizardResult struct {
+	Config     *config.Config
+	Provider   string
+	KeyStorage string
+	HookType   string
+}
+
+// prompter asks questions on the terminal. Empty answers pick the default.
+type prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+func (p *prompter) ask(question, def string) (string, error) {
+	if def != "" {
+		fmt.Fprintf(p.out, "%s [%s]: ", question, def)
+	} else {
+		fmt.Fprintf(p.out, "%s: ", question)
+	}
+	answer, err := p.in.ReadString('\n')
+	if err != nil && answer == "" {
+		return "", fmt.Errorf("failed to read answer: %w", err)
+	}
+	if answer = strings.TrimSpace(answer); answer == "" {
+		return def, nil
+	}
+	return answer, nil
+}
+
+// choose lists options and returns the index picked; def is 0-based
+func (p *prompter) choose(question string, options []string, def int) (int, error) {
+	fmt.Fprintln(p.out, question)
+	for i, option := range options {
+		fmt.Fprintf(p.out, "  %d) %s\n", i+1, option)
+	}
+	for {
+		answer, err := p.ask("Choose", strconv.Itoa(def+1))
+		if err != nil {
+			return 0, err
+		}
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(options) {
+			return n - 1, nil
+		}
+		fmt.Fprintf(p.out, "Enter a number from 1 to %d.\n", len(options))
+	}
+}
+
+// askRequired repeats the question until the answer is not empty
+func (p *prompter) askRequired(question string) (string, error) {
+	for {
+		answer, err := p.ask(question, "")
+		if err != nil || answer != "" {
+			return answer, err
+		}
+	}
+}
+
+func (p *prompter) yesNo(question string, def bool) (bool, error) {
+	hint := "y/N"
+	if def {
+		hint = "Y/n"
+	}
+	answer, err := p.ask(question+" ("+hint+")", "")
+	if err != nil {
+		return false, err
+	}
+	switch strings.ToLower(answer) {
+	case "":
+		return def, nil
+	case "y", "yes":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// runInitWizard asks for the provider, base URL, API key storage, model and
+// hook type, then prints a summary
+func (a *App) runInitWizard() (*wizardResult, error) {
+	p := &prompter{in: bufio.NewReader(a.Terminal.In), out: a.Terminal.Out}
+	cfg := config.DefaultConfig()
+	cfg.APIKey = ""
+	result := &wizardResult{Config: cfg}
+	var apiKey string
+
+	fmt.Fprintln(p.out, "Let's set up the commit message generator. Press Enter to accept the [default].")
+	fmt.Fprintln(p.out)
+
+	// 1. Provider
+	choice, err := p.choose("Which provider do you use?", []string{
+		"Ollama on this machine",
+		"Hosted Ollama-compatible endpoint",
+	}, 0)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintln(p.out, "(OpenAI, Anthropic and Gemini APIs are not supported yet.)")
+	defaultURL := cfg.BaseURL
+	result.Provider = ProviderOllama
+	if choice == 1 {
+		result.Provider = ProviderOllamaCompatible
+		defaultURL = ""
+	}
+
+	// 2. Base URL, tested before moving on
+	for {
+		answer, err := p.ask("Base URL", defaultURL)
+		if err != nil {
+			return nil, err
+		}
+		baseURL, err := generateEndpoint(answer)
+		if err != nil {
+			fmt.Fprintf(p.out, "✗ %v\n", err)
+			continue
+		}
+		cfg.BaseURL = baseURL
+
+		root, _ := providerRoot(cfg)
+		if err := pingProvider(root); err == nil {
+			fmt.Fprintf(p.out, "✓ %s is reachable\n", root)
+			break
+		}
+		fmt.Fprintf(p.out, "✗ %s is not reachable\n", root)
+		keep, err := p.yesNo("Use it anyway?", false)
+		if err != nil {
+			return nil, err
+		}
+		if keep {
+			break
+		}
+	}
+
+	// 3. API key
+	keyOptions := []string{
+		"Environment variable OLLAMA_API_KEY",
+		"OS keychain (recommended)",
+		"Store it in .commit-generator-config (plain text)",
+		"No API key (local Ollama does not need one)",
+	}
+	defaultKey := 3
+	if result.Provider == ProviderOllamaCompatible {
+		defaultKey = 1
+	}
+	for result.KeyStorage == "" {
+		choice, err = p.choose("Where should the API key come from?", keyOptions, defaultKey)
+		if err != nil {
+			return nil, err
+		}
+		switch choice {
+		case 0:
+			result.KeyStorage = KeyStorageEnv
+			if os.Getenv("OLLAMA_API_KEY") == "" {
+				fmt.Fprintln(p.out, "! OLLAMA_API_KEY is not set; export it in your shell profile")
+			}
+		case 1:
+			key, err := p.askRequired("API key")
+			if err != nil {
+				return nil, err
+			}
+			if _, err := a.ConfigStore.StoreAPIKey(key, false); err != nil {
+				fmt.Fprintf(p.out, "✗ %v; choose another option\n", err)
+				continue
+			}
+			result.KeyStorage = KeyStorageKeychain
+			cfg.APIKeySource = config.APIKeySourceKeychain
+			apiKey = key
+		case 2:
+			result.KeyStorage = KeyStorageConfig
+			if cfg.APIKey, err = p.askRequired("API key"); err != nil {
+				return nil, err
+			}
+			fmt.Fprintln(p.out, "! The key is stored in plain text; add .commit-generator-config to .gitignore")
+		default:
+			result.KeyStorage = KeyStorageNone
+		}
+	}
+
+	// 4. Model, picked from the provider's list when it has one
+	switch result.KeyStorage {
+	case KeyStorageEnv:
+		apiKey = os.Getenv("OLLAMA_API_KEY")
+	case KeyStorageConfig:
+		apiKey = cfg.APIKey
+	}
+	root, _ := providerRoot(cfg)
+	models, err := listModels(root, apiKey, cfg.AuthHeader, cfg.AuthScheme)
+	if err == nil && len(models) > 0 {
+		fmt.Fprintln(p.out, "Available models:")
+		for i, name := range models {
+			fmt.Fprintf(p.out, "  %d) %s\n", i+1, name)
+		}
+		answer, err := p.ask("Model (number or name)", models[0])
+		if err != nil {
+			return nil, err
+		}
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(models) {
+			answer = models[n-1]
+		}
+		cfg.Model = answer
+	} else {
+		if cfg.Model, err = p.ask("Model", cfg.Model); err != nil {
+			return nil, err
+		}
+	}
+
+	// 5. Hooks
+	hookTypes := []string{HookPreCommit, HookPrepareCommitMsg, HookCommitMsg, HookTypeBoth}
+	choice, err = p.choose("Which git hook should generate messages?", []string{
+		"pre-commit: generate and commit when you run 'git commit'",
+		"prepare-commit-msg: prefill the message in your editor",
+		"commit-msg: only lint messages against Conventional Commits",
+		"both: pre-commit and prepare-commit-msg",
+	}, 0)
+	if err != nil {
+		return nil, err
+	}
+	result.HookType = hookTypes[choice]
+
+	printWizardSummary(p.out, result)
+	return result, nil
+}
+
+// generateEndpoint turns what the user typed into the generate endpoint. A
+// bare host such as https://ollama.example.com gets /api/generate appended.
+func generateEndpoint(answer string) (string, error) {
+	if answer == "" {
+		return "", errors.New("a base URL is required")
+	}
+	u, err := url.Parse(answer)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("%q is not an http(s) URL", answer)
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = "/api/generate"
+	}
+	return u.String(), nil
+}
+
+func printWizardSummary(out io.Writer, result *wizardResult) {
+	key := map[string]string{
+		KeyStorageEnv:      "from $OLLAMA_API_KEY",
+		KeyStorageKeychain: "stored in the OS keychain",
+		KeyStorageConfig:   "stored in .commit-generator-config",
+		KeyStorageNone:     "none",
+	}[result.KeyStorage]
+
+	fmt.Fprintln(out, "\nSummary:")
+	fmt.Fprintf(out, "  Provider: %s\n", result.Provider)
+	fmt.Fprintf(out, "  Base URL: %s\n", result.Config.BaseURL)
+	fmt.Fprintf(out, "  Model:    %s\n", result.Config.Model)
+	fmt.Fprintf(out, "  API key:  %s\n", key)
+	fmt.Fprintf(out, "  Hooks:    %s\n\n", result.HookType)
+}
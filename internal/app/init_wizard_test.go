@@ -0,0 +1,196 @@
+package app
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ai-commit-message-generator/internal/config"
+)
+
+func TestApp_Init_Wizard(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/tags" {
+			w.Write([]byte(`{"models": [{"name": "llama3:latest"}, {"name": "qwen2.5-coder:7b"}]}`))
+			return
+		}
+		w.Write([]byte("Ollama is running"))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name           string
+		script         []string
+		expectedConfig config.Config
+		expectedHooks  []string
+		expectedOutput []string
+		expectedError  string
+	}{
+		{
+			name: "Local Ollama with listed model",
+			script: []string{
+				"1",        // provider
+				server.URL, // base URL (bare host)
+				"",         // key storage: default none
+				"2",        // model by number
+				"",         // hook type: default pre-commit
+			},
+			expectedConfig: config.Config{BaseURL: server.URL + "/api/generate", Model: "qwen2.5-coder:7b"},
+			expectedHooks:  []string{HookPreCommit},
+			expectedOutput: []string{"is reachable", "2) qwen2.5-coder:7b", "API key:  none"},
+		},
+		{
+			name: "Hosted endpoint with key in config",
+			script: []string{
+				"2",
+				"", // base URL is required
+				server.URL + "/api/generate",
+				"3", // store in config
+				"sk-test",
+				"mistral", // model by name
+				"2",       // prepare-commit-msg
+			},
+			expectedConfig: config.Config{BaseURL: server.URL + "/api/generate", Model: "mistral", APIKey: "sk-test"},
+			expectedHooks:  []string{HookPrepareCommitMsg},
+			expectedOutput: []string{"a base URL is required", "stored in plain text", "Provider: ollama-compatible"},
+		},
+		{
+			name: "Hosted endpoint with key in the keychain",
+			script: []string{
+				"2",
+				server.URL,
+				"", // key storage: default keychain
+				"", // the key is required
+				"sk-test",
+				"1",
+				"",
+			},
+			expectedConfig: config.Config{BaseURL: server.URL + "/api/generate", Model: "llama3:latest", APIKeySource: config.APIKeySourceKeychain},
+			expectedHooks:  []string{HookPreCommit},
+			expectedOutput: []string{"API key:  stored in the OS keychain"},
+		},
+		{
+			name: "Unreachable URL kept, model typed",
+			script: []string{
+				"",
+				"http://127.0.0.1:1",
+				"y", // use it anyway
+				"1", // env var
+				"",  // model default
+				"4", // both hooks
+			},
+			expectedConfig: config.Config{BaseURL: "http://127.0.0.1:1/api/generate", Model: "gpt-oss:120b"},
+			expectedHooks:  []string{HookPreCommit, HookPrepareCommitMsg},
+			expectedOutput: []string{"is not reachable", "OLLAMA_API_KEY is not set", "from $OLLAMA_API_KEY"},
+		},
+		{
+			name:          "Input ends early",
+			script:        []string{"1"},
+			expectedError: "failed to read answer",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("OLLAMA_API_KEY", "")
+			repoRoot := t.TempDir()
+			if err := os.Mkdir(filepath.Join(repoRoot, ".git"), 0755); err != nil {
+				t.Fatalf("failed to create .git dir: %v", err)
+			}
+			originalWd, _ := os.Getwd()
+			defer os.Chdir(originalWd)
+			os.Chdir(repoRoot)
+
+			mockGit := &MockGit{
+				IsInsideRepoFunc: func() (bool, error) { return true, nil },
+				GetRepoRootFunc:  func() (string, error) { return repoRoot, nil },
+			}
+			keychain := &fakeKeyring{}
+			configLoader := config.NewConfigLoader()
+			configLoader.SetKeyring(keychain)
+			application := NewApp(mockGit, &MockConfig{}, configLoader, nil)
+			var out bytes.Buffer
+			application.Terminal = &Terminal{In: strings.NewReader(strings.Join(tt.script, "\n") + "\n"), Out: &out}
+
+			var err error
+			captureStdout(t, func() {
+				err = application.Init(InitOptions{Wizard: true})
+			})
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("expected error containing %q, got %v", tt.expectedError, err)
+				}
+				if _, statErr := os.Stat(filepath.Join(repoRoot, ".commit-generator-config")); !os.IsNotExist(statErr) {
+					t.Errorf("expected no config to be written when the wizard is abandoned")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Init failed: %v\n%s", err, out.String())
+			}
+
+			written, err := config.NewConfigLoaderWithPath(filepath.Join(repoRoot, ".commit-generator-config")).LoadConfig()
+			if err != nil {
+				t.Fatalf("failed to load config: %v", err)
+			}
+			if written.BaseURL != tt.expectedConfig.BaseURL || written.Model != tt.expectedConfig.Model ||
+				written.APIKey != tt.expectedConfig.APIKey || written.APIKeySource != tt.expectedConfig.APIKeySource {
+				t.Errorf("expected base_url=%q model=%q api_key=%q api_key_source=%q, got %+v",
+					tt.expectedConfig.BaseURL, tt.expectedConfig.Model, tt.expectedConfig.APIKey, tt.expectedConfig.APIKeySource, written)
+			}
+			if tt.expectedConfig.APIKeySource == config.APIKeySourceKeychain && keychain.secrets["ai-commit/api_key"] != "sk-test" {
+				t.Errorf("expected the key in the keychain, got %v", keychain.secrets)
+			}
+
+			for _, hookName := range tt.expectedHooks {
+				if _, err := os.Stat(hookPath(filepath.Join(repoRoot, ".git", "hooks"), hookName)); err != nil {
+					t.Errorf("expected %s hook to be installed: %v", hookName, err)
+				}
+			}
+			for _, want := range tt.expectedOutput {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("expected %q in wizard output, got:\n%s", want, out.String())
+				}
+			}
+		})
+	}
+}
+
+func TestApp_Init_WizardNeedsTerminal(t *testing.T) {
+	repoRoot := t.TempDir()
+	mockGit := &MockGit{
+		IsInsideRepoFunc: func() (bool, error) { return true, nil },
+		GetRepoRootFunc:  func() (string, error) { return repoRoot, nil },
+	}
+	application := NewApp(mockGit, &MockConfig{}, config.NewConfigLoaderWithPath(filepath.Join(repoRoot, "missing")), nil)
+
+	err := application.Init(InitOptions{Wizard: true})
+	if err == nil || !strings.Contains(err.Error(), "requires a terminal") {
+		t.Errorf("expected terminal error, got %v", err)
+	}
+}
+
+func TestGenerateEndpoint(t *testing.T) {
+	tests := []struct {
+		input       string
+		expected    string
+		expectedErr bool
+	}{
+		{"http://localhost:11434", "http://localhost:11434/api/generate", false},
+		{"https://ollama.example.com/", "https://ollama.example.com/api/generate", false},
+		{"https://gateway.example.com/v1/generate", "https://gateway.example.com/v1/generate", false},
+		{"localhost:11434", "", true},
+		{"", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := generateEndpoint(tt.input)
+		if (err != nil) != tt.expectedErr || got != tt.expected {
+			t.Errorf("generateEndpoint(%q) = %q, %v; expected %q (error %v)", tt.input, got, err, tt.expected, tt.expectedErr)
+		}
+	}
+}
@@ -0,0 +1,411 @@
+package app
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"ai-commit-message-generator/internal/ai"
+	"ai-commit-message-generator/internal/git"
+)
+
+// Terminal is the reader/writer pair the interactive loop talks through.
+// When invoked from a git hook stdin is not the user's terminal, so the
+// controlling terminal is opened explicitly by OpenTerminal.
+type Terminal struct {
+	In  io.Reader
+	Out io.Writer
+	// Timeout bounds how long a prompt waits for an answer. Zero waits
+	// forever.
+	Timeout time.Duration
+
+	closers []io.Closer
+}
+
+// DefaultPromptTimeout is how long a prompt waits for an answer before
+// giving up, so a run nobody is watching fails instead of hanging
+const DefaultPromptTimeout = 5 * time.Minute
+
+// ErrPromptTimeout is returned when nobody answers a prompt within the
+// terminal's Timeout
+var ErrPromptTimeout = errors.New("no answer to the prompt")
+
+// isTTY reports whether v is a character device such as a TTY. It is a
+// variable so tests can stand in for a real terminal.
+var isTTY = func(v any) bool {
+	f, ok := v.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// IsTTY reports whether both ends of the terminal are TTYs. Pipes, files
+// and buffers are not, e.g. when run from CI or a GUI git client.
+func (t *Terminal) IsTTY() bool {
+	return isTTY(t.In) && isTTY(t.Out)
+}
+
+// readLine reads one answer from reader, giving up after t.Timeout
+func (t *Terminal) readLine(reader *bufio.Reader) (string, error) {
+	if t.Timeout <= 0 {
+		return readLine(reader)
+	}
+
+	type answer struct {
+		line string
+		err  error
+	}
+	answers := make(chan answer, 1)
+	go func() {
+		line, err := readLine(reader)
+		answers <- answer{line, err}
+	}()
+
+	select {
+	case a := <-answers:
+		return a.line, a.err
+	case <-time.After(t.Timeout):
+		return "", fmt.Errorf("%w within %s; pass --yes or --non-interactive to run without prompts", ErrPromptTimeout, t.Timeout)
+	}
+}
+
+// OpenTerminal opens the controlling terminal: /dev/tty on Unix and
+// CONIN$/CONOUT$ on Windows
+func OpenTerminal() (*Terminal, error) {
+	if runtime.GOOS == "windows" {
+		in, err := os.OpenFile("CONIN$", os.O_RDWR, 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open console input: %w", err)
+		}
+		out, err := os.OpenFile("CONOUT$", os.O_RDWR, 0)
+		if err != nil {
+			in.Close()
+			return nil, fmt.Errorf("failed to open console output: %w", err)
+		}
+		return &Terminal{In: in, Out: out, closers: []io.Closer{in, out}}, nil
+	}
+
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open /dev/tty: %w", err)
+	}
+	return &Terminal{In: tty, Out: tty, closers: []io.Closer{tty}}, nil
+}
+
+// Close releases any files opened by OpenTerminal
+func (t *Terminal) Close() error {
+	var firstErr error
+	for _, c := range t.closers {
+		if err := c.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// canPrompt reports whether someone can answer prompts, i.e. a terminal is
+// attached and it is a TTY
+func (a *App) canPrompt() bool {
+	return a.Terminal != nil && a.Terminal.IsTTY()
+}
+
+// interact presents the latest attempt in history and loops until the user
+// accepts (commits) or quits. Every new candidate is appended to history so
+// the user can go back to an earlier attempt.
+func (a *App) interact(req ai.CommitRequest, history []string, opts RunOptions) error {
+	out := a.Terminal.Out
+	reader := bufio.NewReader(a.Terminal.In)
+	message := history[len(history)-1]
+
+	for {
+		a.showCandidate(out, message)
+		if len(history) > 1 {
+			fmt.Fprintf(out, "(%d attempts so far)\n", len(history))
+		}
+		fmt.Fprintln(out, "Options:")
+		fmt.Fprintln(out, "  [A]ccept and commit")
+		fmt.Fprintln(out, "  [E]dit message")
+		fmt.Fprintln(out, "  [R]egenerate (optionally with feedback, e.g. \"make it shorter\")")
+		fmt.Fprintln(out, "  [H]istory (go back to a previous attempt)")
+		fmt.Fprintln(out, "  [C]opy to clipboard")
+		// Splitting commits staged files, which --all has not staged yet
+		splittable := a.suggestsSplit(message) && !opts.All
+		if splittable {
+			fmt.Fprintln(out, "  [S]plit into the suggested commits")
+		}
+		fmt.Fprintln(out, "  [Q]uit without committing")
+		if splittable {
+			fmt.Fprint(out, "Your choice (A/E/R/H/C/S/Q): ")
+		} else {
+			fmt.Fprint(out, "Your choice (A/E/R/H/C/Q): ")
+		}
+
+		choice, err := a.Terminal.readLine(reader)
+		if err != nil {
+			fmt.Fprintln(out)
+			return promptError(err)
+		}
+
+		switch strings.ToLower(firstRune(choice)) {
+		case "a":
+			if a.suggestsSplit(message) {
+				fmt.Fprintln(out, "\033[33mThe model suggested splitting the changes. Edit or regenerate the message before committing.\033[0m")
+				continue
+			}
+			if err := a.commit(message, opts); err != nil {
+				return err
+			}
+			fmt.Fprintln(out, "\033[32m✓ Committed\033[0m")
+			return nil
+
+		case "e":
+			edited, err := a.editMessage(message)
+			if err != nil {
+				fmt.Fprintf(out, "Warning: failed to edit message: %v\n", err)
+				continue
+			}
+			if edited == "" {
+				fmt.Fprintln(out, "Edited message is empty, keeping the previous one.")
+				continue
+			}
+			message = edited
+			history = append(history, message)
+
+		case "r":
+			fmt.Fprint(out, "Feedback for the model (optional, press Enter to skip): ")
+			feedback, err := a.Terminal.readLine(reader)
+			if err != nil {
+				fmt.Fprintln(out)
+				return promptError(err)
+			}
+
+			var regenerated string
+			if feedback != "" {
+				fmt.Fprintln(out, "Revising commit message...")
+				regenerated, err = a.refine(req, message, feedback)
+			} else {
+				fmt.Fprintln(out, "Regenerating commit message...")
+				regenerated, err = a.generateMessage(req)
+			}
+			if err != nil {
+				fmt.Fprintf(out, "Warning: failed to regenerate commit message: %v\n", err)
+				continue
+			}
+			message = regenerated
+			a.remember(req.Diff, message)
+			history = append(history, message)
+
+		case "h":
+			restored, err := chooseAttempt(a.Terminal, reader, history)
+			if errors.Is(err, ErrPromptTimeout) {
+				return err
+			}
+			if err != nil {
+				fmt.Fprintf(out, "Warning: %v\n", err)
+				continue
+			}
+			message = restored
+
+		case "c":
+			if a.Clipboard == nil {
+				fmt.Fprintln(out, "Warning: no clipboard available")
+				continue
+			}
+			mechanism, err := a.Clipboard(message)
+			if err != nil {
+				fmt.Fprintf(out, "Warning: failed to copy to clipboard: %v\n", err)
+				continue
+			}
+			fmt.Fprintf(out, "\033[32m✓ Copied to clipboard with %s\033[0m\n", mechanism)
+
+		case "s":
+			if !splittable {
+				fmt.Fprintf(out, "Invalid choice %q\n", choice)
+				continue
+			}
+			return a.commitSplit(SplitOptions{}, reader)
+
+		case "q":
+			return ErrCancelled
+
+		default:
+			fmt.Fprintf(out, "Invalid choice %q\n", choice)
+		}
+	}
+}
+
+// promptError turns a failed read into ErrCancelled, except for a timeout
+// which is reported as is
+func promptError(err error) error {
+	if errors.Is(err, ErrPromptTimeout) {
+		return err
+	}
+	return ErrCancelled
+}
+
+// chooseAttempt lists previous attempts and returns the one the user picks
+func chooseAttempt(term *Terminal, reader *bufio.Reader, history []string) (string, error) {
+	out := term.Out
+	fmt.Fprintln(out)
+	for i, attempt := range history {
+		subject := strings.SplitN(attempt, "\n", 2)[0]
+		fmt.Fprintf(out, "  #%d  %s\n", i+1, subject)
+	}
+	fmt.Fprint(out, "Attempt to restore: ")
+
+	choice, err := term.readLine(reader)
+	if errors.Is(err, ErrPromptTimeout) {
+		return "", err
+	}
+	if err != nil {
+		return "", errors.New("no attempt selected")
+	}
+	n, err := strconv.Atoi(strings.TrimPrefix(choice, "#"))
+	if err != nil || n < 1 || n > len(history) {
+		return "", fmt.Errorf("invalid attempt %q", choice)
+	}
+	return history[n-1], nil
+}
+
+// showCandidate prints the current candidate message
+func (a *App) showCandidate(out io.Writer, message string) {
+	fmt.Fprintln(out)
+	if a.suggestsSplit(message) {
+		fmt.Fprintln(out, "\033[33mAI Suggestion (Split Changes):\033[0m")
+		fmt.Fprintln(out, message)
+	} else {
+		fmt.Fprintln(out, "Generated commit message:")
+		fmt.Fprintln(out, "==========================")
+		fmt.Fprintln(out, "\033[36m"+message+"\033[0m")
+		fmt.Fprintln(out, "==========================")
+	}
+	fmt.Fprintln(out)
+}
+
+// editMessage writes the message to a temp file, opens it in the editor and
+// returns the edited content with comment lines removed. Without a TTY no
+// editor is opened.
+func (a *App) editMessage(message string) (string, error) {
+	if !a.canPrompt() {
+		return "", errors.New("no terminal to open an editor on")
+	}
+
+	tmp, err := os.CreateTemp("", "COMMIT_EDITMSG-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	path := tmp.Name()
+	defer os.Remove(path)
+
+	commentChar := a.commentChar()
+	if commentChar == git.CommentCharAuto {
+		commentChar = git.PickCommentChar(message)
+	}
+	content := fmt.Sprintf("%s\n\n%s Edit the commit message above. Lines starting with '%s' will be ignored.\n", message, commentChar, commentChar)
+	if _, err := tmp.WriteString(content); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("failed to write temp file: %w", err)
+	}
+
+	edit := a.Editor
+	if edit == nil {
+		edit = a.launchEditor
+	}
+	if err := edit(path); err != nil {
+		return "", err
+	}
+
+	edited, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read edited message: %w", err)
+	}
+	return stripCommentLines(string(edited), commentChar), nil
+}
+
+// launchEditor opens path in the user's editor attached to the terminal
+func (a *App) launchEditor(path string) error {
+	editor := os.Getenv("GIT_EDITOR")
+	if editor == "" {
+		editor = os.Getenv("VISUAL")
+	}
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		if editor == "" {
+			editor = "notepad"
+		}
+		args := strings.Fields(editor)
+		cmd = exec.Command(args[0], append(args[1:], path)...)
+	} else {
+		if editor == "" {
+			editor = "vi"
+		}
+		// Run through the shell so editors configured with arguments
+		// (e.g. "code --wait") work
+		cmd = exec.Command("sh", "-c", editor+` "$@"`, editor, path)
+	}
+
+	cmd.Stdin = a.Terminal.In
+	cmd.Stdout = a.Terminal.Out
+	cmd.Stderr = a.Terminal.Out
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("editor %q failed: %w", editor, err)
+	}
+	return nil
+}
+
+// commentChar returns the core.commentChar setting, or "#" when it cannot
+// be read
+func (a *App) commentChar() string {
+	commentChar, err := a.Git.GetCommentChar()
+	if err != nil || commentChar == "" {
+		return git.DefaultCommentChar
+	}
+	return commentChar
+}
+
+// stripCommentLines removes the lines starting with commentChar and
+// surrounding whitespace while keeping blank lines inside the message
+func stripCommentLines(message, commentChar string) string {
+	lines := strings.Split(message, "\n")
+	kept := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if strings.HasPrefix(line, commentChar) {
+			continue
+		}
+		kept = append(kept, strings.TrimRight(line, " \t\r"))
+	}
+	return strings.TrimSpace(strings.Join(kept, "\n"))
+}
+
+// readLine reads a single line of user input without the line ending
+func readLine(reader *bufio.Reader) (string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil && !(errors.Is(err, io.EOF) && line != "") {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// firstRune returns the first character of s, or "" if s is empty
+func firstRune(s string) string {
+	for _, r := range s {
+		return string(r)
+	}
+	return ""
+}
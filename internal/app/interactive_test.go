@@ -0,0 +1,443 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"ai-commit-message-generator/internal/ai"
+)
+
+// fakeTTY makes every terminal count as a TTY for the rest of the test, so a
+// scripted reader can drive the prompts
+func fakeTTY(t *testing.T) {
+	original := isTTY
+	isTTY = func(any) bool { return true }
+	t.Cleanup(func() { isTTY = original })
+}
+
+// newInteractiveApp returns an App whose git mock records commits and whose
+// terminal is a TTY driven by the given scripted input
+func newInteractiveApp(t *testing.T, input string, mockAI *MockAI, committed *[]string) (*App, *bytes.Buffer) {
+	fakeTTY(t)
+	mockGit := &MockGit{
+		IsInsideRepoFunc:     func() (bool, error) { return true, nil },
+		HasStagedChangesFunc: func() (bool, error) { return true, nil },
+		GetStagedDiffFunc:    func() (string, error) { return "diff", nil },
+		CommitWithMessageFunc: func(message string) error {
+			*committed = append(*committed, message)
+			return nil
+		},
+	}
+	mockConfig := &MockConfig{
+		LoadRulesFunc: func() (string, error) { return "", nil },
+	}
+
+	out := &bytes.Buffer{}
+	application := NewApp(mockGit, mockConfig, nil, mockAI)
+	application.Terminal = &Terminal{In: strings.NewReader(input), Out: out}
+	return application, out
+}
+
+func TestApp_Run_Interactive(t *testing.T) {
+	tests := []struct {
+		name              string
+		input             string
+		responses         []string
+		editorContent     string
+		expectedCommits   []string
+		expectedFeedback  []string
+		expectedError     error
+		expectedOutput    string
+		expectedClipboard string
+	}{
+		{
+			name:            "Accept commits the message",
+			input:           "a\n",
+			responses:       []string{"feat: added login"},
+			expectedCommits: []string{"feat: added login"},
+			expectedOutput:  "Committed",
+		},
+		{
+			name:          "Quit aborts without committing",
+			input:         "q\n",
+			responses:     []string{"feat: added login"},
+			expectedError: ErrCancelled,
+		},
+		{
+			name:          "EOF aborts without committing",
+			input:         "",
+			responses:     []string{"feat: added login"},
+			expectedError: ErrCancelled,
+		},
+		{
+			name:             "Regenerate with feedback then accept",
+			input:            "r\nmention the migration\na\n",
+			responses:        []string{"feat: added login", "feat(db): added login migration"},
+			expectedCommits:  []string{"feat(db): added login migration"},
+			expectedFeedback: []string{"", "mention the migration"},
+		},
+		{
+			name:             "Regenerate without feedback",
+			input:            "r\n\nA\n",
+			responses:        []string{"feat: first", "feat: second"},
+			expectedCommits:  []string{"feat: second"},
+			expectedFeedback: []string{"", ""},
+		},
+		{
+			name:            "Edit replaces the message",
+			input:           "e\na\n",
+			responses:       []string{"feat: added login"},
+			editorContent:   "fix(auth): fixed login\n\nLonger body\n# a comment\n",
+			expectedCommits: []string{"fix(auth): fixed login\n\nLonger body"},
+		},
+		{
+			name:              "Copy then quit",
+			input:             "c\nq\n",
+			responses:         []string{"feat: added login"},
+			expectedError:     ErrCancelled,
+			expectedOutput:    "Copied to clipboard with pbcopy",
+			expectedClipboard: "feat: added login",
+		},
+		{
+			name:            "Invalid choice is reported",
+			input:           "x\na\n",
+			responses:       []string{"feat: added login"},
+			expectedCommits: []string{"feat: added login"},
+			expectedOutput:  `Invalid choice "x"`,
+		},
+		{
+			name:           "Split suggestion cannot be accepted",
+			input:          "a\nq\n",
+			responses:      []string{"This diff should be split into multiple commits"},
+			expectedError:  ErrCancelled,
+			expectedOutput: "suggested splitting",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var feedback []string
+			call := 0
+			mockAI := &MockAI{
+				GenerateCommitMessageFunc: func(req ai.CommitRequest) (string, error) {
+					feedback = append(feedback, req.Feedback)
+					response := tt.responses[call]
+					call++
+					return response, nil
+				},
+			}
+
+			var committed []string
+			application, out := newInteractiveApp(t, tt.input, mockAI, &committed)
+			application.Editor = func(path string) error {
+				return os.WriteFile(path, []byte(tt.editorContent), 0644)
+			}
+			var clipboard string
+			application.Clipboard = func(text string) (string, error) {
+				clipboard = text
+				return "pbcopy", nil
+			}
+
+			err := application.Run(RunOptions{Interactive: true})
+
+			if tt.expectedError != nil {
+				if !errors.Is(err, tt.expectedError) {
+					t.Errorf("expected error %v, got %v", tt.expectedError, err)
+				}
+			} else if err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+
+			if strings.Join(committed, "|") != strings.Join(tt.expectedCommits, "|") {
+				t.Errorf("expected commits %q, got %q", tt.expectedCommits, committed)
+			}
+			if tt.expectedFeedback != nil && strings.Join(feedback, "|") != strings.Join(tt.expectedFeedback, "|") {
+				t.Errorf("expected feedback %q, got %q", tt.expectedFeedback, feedback)
+			}
+			if tt.expectedOutput != "" && !strings.Contains(out.String(), tt.expectedOutput) {
+				t.Errorf("expected output to contain %q, got:\n%s", tt.expectedOutput, out.String())
+			}
+			if clipboard != tt.expectedClipboard {
+				t.Errorf("expected clipboard %q, got %q", tt.expectedClipboard, clipboard)
+			}
+		})
+	}
+}
+
+func TestApp_Run_InteractiveRefinementRounds(t *testing.T) {
+	var requests []ai.CommitRequest
+	responses := []string{
+		"feat(db): added user table migration and updated the repository layer",
+		"feat(db): added user table migration",
+		"feat(db): added users migration",
+	}
+	mockAI := &MockAI{
+		GenerateCommitMessageFunc: func(req ai.CommitRequest) (string, error) {
+			requests = append(requests, req)
+			return responses[len(requests)-1], nil
+		},
+	}
+
+	// Two refinement rounds, then go back to attempt #1 and accept it
+	input := "r\nmake it shorter\nr\nuse the plural table name\nh\n1\na\n"
+	var committed []string
+	application, out := newInteractiveApp(t, input, mockAI, &committed)
+
+	if err := application.Run(RunOptions{Interactive: true}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(requests) != 3 {
+		t.Fatalf("expected 3 AI calls, got %d", len(requests))
+	}
+	if requests[1].PreviousMessage != responses[0] || requests[1].Feedback != "make it shorter" {
+		t.Errorf("first refinement got previous %q and feedback %q", requests[1].PreviousMessage, requests[1].Feedback)
+	}
+	if requests[2].PreviousMessage != responses[1] || requests[2].Feedback != "use the plural table name" {
+		t.Errorf("second refinement got previous %q and feedback %q", requests[2].PreviousMessage, requests[2].Feedback)
+	}
+	if len(committed) != 1 || committed[0] != responses[0] {
+		t.Errorf("expected attempt #1 to be committed, got %q", committed)
+	}
+	if !strings.Contains(out.String(), "#3  feat(db): added users migration") {
+		t.Errorf("expected history listing in output, got:\n%s", out.String())
+	}
+}
+
+func TestApp_Run_NonInteractive(t *testing.T) {
+	tests := []struct {
+		name            string
+		terminal        *Terminal
+		opts            RunOptions
+		response        string
+		expectedCommits []string
+		expectedOutput  string
+		expectedError   string
+	}{
+		{
+			name:           "No terminal prints the message",
+			opts:           RunOptions{Interactive: true},
+			response:       "feat: added login",
+			expectedOutput: "feat: added login",
+		},
+		{
+			name:           "Piped terminal prints the message",
+			terminal:       &Terminal{In: strings.NewReader("a\n"), Out: &bytes.Buffer{}},
+			opts:           RunOptions{Interactive: true},
+			response:       "feat: added login",
+			expectedOutput: "feat: added login",
+		},
+		{
+			name:            "Yes commits without prompting",
+			terminal:        &Terminal{In: strings.NewReader(""), Out: &bytes.Buffer{}},
+			opts:            RunOptions{Interactive: true, Yes: true},
+			response:        "feat: added login",
+			expectedCommits: []string{"feat: added login"},
+			expectedOutput:  "Committed",
+		},
+		{
+			name:          "Yes never commits a split suggestion",
+			opts:          RunOptions{Yes: true},
+			response:      "This diff should be split into multiple commits",
+			expectedError: "suggested splitting",
+		},
+		{
+			name:          "Yes cannot be combined with preview",
+			opts:          RunOptions{Yes: true, Preview: true},
+			expectedError: "cannot be combined",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAI := &MockAI{
+				GenerateCommitMessageFunc: func(req ai.CommitRequest) (string, error) {
+					return tt.response, nil
+				},
+			}
+			var committed []string
+			application, _ := newInteractiveApp(t, "", mockAI, &committed)
+			isTTY = func(v any) bool { return false }
+			application.Terminal = tt.terminal
+			application.Editor = func(path string) error {
+				t.Error("expected no editor to be opened")
+				return nil
+			}
+
+			var err error
+			output := captureStdout(t, func() {
+				err = application.Run(tt.opts)
+			})
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("expected error containing %q, got %v", tt.expectedError, err)
+				}
+			} else if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if strings.Join(committed, "|") != strings.Join(tt.expectedCommits, "|") {
+				t.Errorf("expected commits %q, got %q", tt.expectedCommits, committed)
+			}
+			if !strings.Contains(output, tt.expectedOutput) {
+				t.Errorf("expected output to contain %q, got:\n%s", tt.expectedOutput, output)
+			}
+		})
+	}
+}
+
+func TestTerminal_ReadLineTimeout(t *testing.T) {
+	// Nothing is ever written to the pipe, like a prompt nobody answers
+	reader, writer := io.Pipe()
+	defer writer.Close()
+	terminal := &Terminal{In: reader, Out: io.Discard, Timeout: 10 * time.Millisecond}
+
+	_, err := terminal.readLine(bufio.NewReader(terminal.In))
+	if !errors.Is(err, ErrPromptTimeout) {
+		t.Fatalf("expected ErrPromptTimeout, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "--non-interactive") {
+		t.Errorf("expected the error to suggest --non-interactive, got %v", err)
+	}
+}
+
+func TestApp_Run_InteractivePromptTimeout(t *testing.T) {
+	mockAI := &MockAI{
+		GenerateCommitMessageFunc: func(req ai.CommitRequest) (string, error) {
+			return "feat: added login", nil
+		},
+	}
+	var committed []string
+	application, _ := newInteractiveApp(t, "", mockAI, &committed)
+	reader, writer := io.Pipe()
+	defer writer.Close()
+	application.Terminal.In = reader
+	application.Terminal.Timeout = 10 * time.Millisecond
+
+	err := application.Run(RunOptions{Interactive: true})
+	if !errors.Is(err, ErrPromptTimeout) {
+		t.Errorf("expected ErrPromptTimeout, got %v", err)
+	}
+	if len(committed) != 0 {
+		t.Errorf("expected no commits, got %q", committed)
+	}
+}
+
+func TestApp_PreCommitHook(t *testing.T) {
+	t.Run("No staged changes lets the commit proceed", func(t *testing.T) {
+		mockGit := &MockGit{
+			HasStagedChangesFunc: func() (bool, error) { return false, nil },
+		}
+		application := NewApp(mockGit, &MockConfig{}, nil, &MockAI{})
+		if err := application.PreCommitHook(); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	})
+
+	t.Run("Accepted commit cancels the original", func(t *testing.T) {
+		var committed []string
+		mockAI := &MockAI{
+			GenerateCommitMessageFunc: func(req ai.CommitRequest) (string, error) {
+				return "feat: added login", nil
+			},
+		}
+		application, _ := newInteractiveApp(t, "a\n", mockAI, &committed)
+
+		err := application.PreCommitHook()
+		if !errors.Is(err, ErrHookCommitted) {
+			t.Errorf("expected ErrHookCommitted, got %v", err)
+		}
+		if len(committed) != 1 {
+			t.Errorf("expected one commit, got %d", len(committed))
+		}
+	})
+
+	t.Run("No TTY lets the commit proceed", func(t *testing.T) {
+		var committed []string
+		mockAI := &MockAI{
+			GenerateCommitMessageFunc: func(req ai.CommitRequest) (string, error) {
+				t.Error("expected no message to be generated")
+				return "", nil
+			},
+		}
+		application, _ := newInteractiveApp(t, "a\n", mockAI, &committed)
+		isTTY = func(v any) bool { return false }
+
+		if err := application.PreCommitHook(); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+		if len(committed) != 0 {
+			t.Errorf("expected no commits, got %q", committed)
+		}
+	})
+}
+
+func TestStripCommentLines(t *testing.T) {
+	input := "feat: subject\n\nbody line\n# comment\n\n"
+	expected := "feat: subject\n\nbody line"
+	if got := stripCommentLines(input, "#"); got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
+func TestApp_EditMessage_CommentChar(t *testing.T) {
+	tests := []struct {
+		name            string
+		commentChar     string
+		message         string
+		expectedHint    string
+		expectedMessage string
+	}{
+		{
+			name:            "Default",
+			commentChar:     "#",
+			message:         "fix(auth): handled expired tokens",
+			expectedHint:    "# Edit the commit message above. Lines starting with '#' will be ignored.",
+			expectedMessage: "fix(auth): handled expired tokens",
+		},
+		{
+			name:            "Custom character keeps hash lines",
+			commentChar:     ";",
+			message:         "fix(auth): handled expired tokens\n\n#42 reported it",
+			expectedHint:    "; Edit the commit message above. Lines starting with ';' will be ignored.",
+			expectedMessage: "fix(auth): handled expired tokens\n\n#42 reported it",
+		},
+		{
+			name:            "Auto picks an unused character",
+			commentChar:     "auto",
+			message:         "#42 handled expired tokens",
+			expectedHint:    "; Edit the commit message above. Lines starting with ';' will be ignored.",
+			expectedMessage: "#42 handled expired tokens",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var committed []string
+			application, _ := newInteractiveApp(t, "", &MockAI{}, &committed)
+			application.Git.(*MockGit).GetCommentCharFunc = func() (string, error) { return tt.commentChar, nil }
+			var content string
+			application.Editor = func(path string) error {
+				data, err := os.ReadFile(path)
+				content = string(data)
+				return err
+			}
+
+			edited, err := application.editMessage(tt.message)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !strings.Contains(content, tt.expectedHint) {
+				t.Errorf("expected %q in the editor file, got %q", tt.expectedHint, content)
+			}
+			if edited != tt.expectedMessage {
+				t.Errorf("expected %q, got %q", tt.expectedMessage, edited)
+			}
+		})
+	}
+}
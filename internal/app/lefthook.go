@@ -0,0 +1,223 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// lefthookConfigNames are the names lefthook reads its config from, in the
+// order it looks for them
+var lefthookConfigNames = []string{"lefthook.yml", ".lefthook.yml", "lefthook.yaml", ".lefthook.yaml"}
+
+// lefthookCommandName is the name of our command in lefthook.yml
+const lefthookCommandName = "generate-commit"
+
+// lefthookRun runs us with git's arguments, which lefthook puts in {0}
+const lefthookRun = "generate-commit hook run {0}"
+
+// findLefthookConfig returns the name of the repository's lefthook config,
+// or "" when it has none
+func findLefthookConfig(repoRoot string) string {
+	for _, name := range lefthookConfigNames {
+		if _, err := os.Stat(filepath.Join(repoRoot, name)); err == nil {
+			return name
+		}
+	}
+	return ""
+}
+
+// installLefthook adds our command to the prepare-commit-msg hook of the
+// repository's lefthook config, creating lefthook.yml if needed
+func installLefthook(repoRoot string) error {
+	name := findLefthookConfig(repoRoot)
+	if name == "" {
+		name = lefthookConfigNames[0]
+	}
+	path := filepath.Join(repoRoot, name)
+	content, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+
+	updated, changed, err := addLefthookCommand(string(content))
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	if !changed {
+		fmt.Printf("✓ %s already runs generate-commit\n", name)
+		return nil
+	}
+	if err := os.WriteFile(path, []byte(updated), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	fmt.Printf("✓ Added the generate-commit command to %s\n", name)
+	return nil
+}
+
+// lefthookCommand is our command, indented by indent with unit per level
+func lefthookCommand(indent, unit string) []string {
+	return []string{
+		indent + lefthookCommandName + ":",
+		indent + unit + "run: " + lefthookRun,
+	}
+}
+
+// addLefthookCommand adds our command to the prepare-commit-msg hook of a
+// lefthook config, keeping the other hooks and commands as they are. It
+// reports false when the config already has a command with our name. Like
+// .pre-commit-config.yaml the file is edited as text, so anchors, comments
+// and layout survive; a hook or command list that is an alias, a merge or
+// written in flow style is left for the user to edit.
+func addLefthookCommand(content string) (string, bool, error) {
+	lines := strings.Split(content, "\n")
+	for _, line := range lines {
+		if strings.TrimSpace(line) == lefthookCommandName+":" {
+			return content, false, nil
+		}
+	}
+
+	hookLine := -1
+	for i, line := range lines {
+		if !strings.HasPrefix(line, "prepare-commit-msg:") {
+			continue
+		}
+		if !isYAMLBlockStart(strings.TrimPrefix(line, "prepare-commit-msg:")) {
+			return "", false, fmt.Errorf("prepare-commit-msg is an alias or written in flow style; add the generate-commit command by hand")
+		}
+		hookLine = i
+		break
+	}
+
+	if hookLine == -1 {
+		unit := lefthookIndentUnit(lines)
+		trimmed := strings.TrimRight(content, "\n")
+		if trimmed != "" {
+			trimmed += "\n\n"
+		}
+		block := append([]string{"prepare-commit-msg:", unit + "commands:"}, lefthookCommand(unit+unit, unit)...)
+		return trimmed + strings.Join(block, "\n") + "\n", true, nil
+	}
+
+	// The hook's keys are the lines after it indented like its first key
+	hookEnd := yamlBlockEnd(lines, hookLine, "")
+	keyIndent := ""
+	for i := hookLine + 1; i < hookEnd; i++ {
+		if !isYAMLComment(lines[i]) {
+			keyIndent = leadingSpaces(lines[i])
+			break
+		}
+	}
+	if keyIndent == "" {
+		// An empty hook gets its commands
+		unit := lefthookIndentUnit(lines)
+		block := append([]string{unit + "commands:"}, lefthookCommand(unit+unit, unit)...)
+		return insertLines(lines, lastYAMLLine(lines, hookLine, hookEnd), block), true, nil
+	}
+
+	commandsLine := -1
+	merges := false
+	for i := hookLine + 1; i < hookEnd; i++ {
+		line := lines[i]
+		if leadingSpaces(line) != keyIndent {
+			continue
+		}
+		key := strings.TrimSpace(line)
+		if strings.HasPrefix(key, "<<:") {
+			merges = true
+		}
+		if strings.HasPrefix(key, "commands:") {
+			if !isYAMLBlockStart(strings.TrimPrefix(key, "commands:")) {
+				return "", false, fmt.Errorf("the prepare-commit-msg commands are an alias or written in flow style; add the generate-commit command by hand")
+			}
+			commandsLine = i
+		}
+	}
+
+	unit := keyIndent
+	if commandsLine == -1 && merges {
+		// Merged commands would be overridden by a commands key of our own
+		return "", false, fmt.Errorf("prepare-commit-msg merges another hook (<<:); add the generate-commit command by hand")
+	}
+	if commandsLine == -1 {
+		block := append([]string{keyIndent + "commands:"}, lefthookCommand(keyIndent+unit, unit)...)
+		return insertLines(lines, lastYAMLLine(lines, hookLine, hookEnd), block), true, nil
+	}
+
+	commandsEnd := yamlBlockEnd(lines, commandsLine, keyIndent)
+	commandIndent := keyIndent + unit
+	for i := commandsLine + 1; i < commandsEnd; i++ {
+		if !isYAMLComment(lines[i]) {
+			commandIndent = leadingSpaces(lines[i])
+			break
+		}
+	}
+	return insertLines(lines, lastYAMLLine(lines, commandsLine, commandsEnd), lefthookCommand(commandIndent, unit)), true, nil
+}
+
+// isYAMLBlockStart reports whether the value after a key opens a block:
+// nothing, a comment or an anchor for the block
+func isYAMLBlockStart(value string) bool {
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, "&") {
+		value = strings.TrimSpace(strings.TrimLeft(value, "&"))
+		if i := strings.IndexAny(value, " \t"); i >= 0 {
+			value = strings.TrimSpace(value[i:])
+		} else {
+			value = ""
+		}
+	}
+	return isYAMLComment(value)
+}
+
+// yamlBlockEnd returns the index of the first line after start that is
+// neither blank, a comment nor indented further than indent
+func yamlBlockEnd(lines []string, start int, indent string) int {
+	for i := start + 1; i < len(lines); i++ {
+		if isYAMLComment(lines[i]) {
+			continue
+		}
+		if len(leadingSpaces(lines[i])) <= len(indent) {
+			return i
+		}
+	}
+	return len(lines)
+}
+
+// lastYAMLLine returns where to insert at the end of the block from start
+// to end: after its last line, not after trailing blank lines or comments
+// that belong to the next key
+func lastYAMLLine(lines []string, start, end int) int {
+	for end > start+1 && isYAMLComment(lines[end-1]) {
+		end--
+	}
+	return end
+}
+
+// insertLines inserts block before lines[at] and joins the result, ending
+// it with a newline
+func insertLines(lines []string, at int, block []string) string {
+	updated := append(append(append([]string{}, lines[:at]...), block...), lines[at:]...)
+	result := strings.Join(updated, "\n")
+	if !strings.HasSuffix(result, "\n") {
+		result += "\n"
+	}
+	return result
+}
+
+// lefthookIndentUnit returns the indentation of the first indented line, or
+// two spaces
+func lefthookIndentUnit(lines []string) string {
+	for _, line := range lines {
+		if indent := leadingSpaces(line); indent != "" && !isYAMLComment(line) {
+			return indent
+		}
+	}
+	return "  "
+}
+
+// leadingSpaces returns the spaces line starts with
+func leadingSpaces(line string) string {
+	return line[:len(line)-len(strings.TrimLeft(line, " "))]
+}
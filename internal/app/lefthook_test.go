@@ -0,0 +1,107 @@
+package app
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAddLefthookCommand(t *testing.T) {
+	command := "generate-commit:\n      run: generate-commit hook run {0}\n"
+	tests := []struct {
+		name          string
+		content       string
+		expected      string
+		expectChanged bool
+		expectError   string
+	}{
+		{
+			name:          "New file",
+			content:       "",
+			expected:      "prepare-commit-msg:\n  commands:\n    " + command,
+			expectChanged: true,
+		},
+		{
+			name:          "Other hooks kept",
+			content:       "pre-commit:\n  parallel: true\n  commands:\n    lint:\n      run: npm run lint\n",
+			expected:      "pre-commit:\n  parallel: true\n  commands:\n    lint:\n      run: npm run lint\n\nprepare-commit-msg:\n  commands:\n    " + command,
+			expectChanged: true,
+		},
+		{
+			name:          "Added to the existing commands",
+			content:       "prepare-commit-msg:\n  commands:\n    ticket:\n      run: ./add-ticket {1}\n\n# Runs before pushing\npre-push:\n  commands:\n    test:\n      run: npm test\n",
+			expected:      "prepare-commit-msg:\n  commands:\n    ticket:\n      run: ./add-ticket {1}\n    " + command + "\n# Runs before pushing\npre-push:\n  commands:\n    test:\n      run: npm test\n",
+			expectChanged: true,
+		},
+		{
+			name:          "Hook without commands",
+			content:       "prepare-commit-msg:\n  parallel: false\npre-push:\n  commands: {}\n",
+			expected:      "prepare-commit-msg:\n  parallel: false\n  commands:\n    " + command + "pre-push:\n  commands: {}\n",
+			expectChanged: true,
+		},
+		{
+			name:          "Empty hook",
+			content:       "prepare-commit-msg:\n",
+			expected:      "prepare-commit-msg:\n  commands:\n    " + command,
+			expectChanged: true,
+		},
+		{
+			name:          "Four space indentation",
+			content:       "prepare-commit-msg:\n    commands:\n        ticket:\n            run: ./add-ticket\n",
+			expected:      "prepare-commit-msg:\n    commands:\n        ticket:\n            run: ./add-ticket\n        generate-commit:\n            run: generate-commit hook run {0}\n",
+			expectChanged: true,
+		},
+		{
+			name:          "Anchors kept",
+			content:       "lint: &lint\n  run: npm run lint\npre-commit:\n  commands:\n    lint: *lint\nprepare-commit-msg: &prepare\n  commands:\n    lint: *lint\n",
+			expected:      "lint: &lint\n  run: npm run lint\npre-commit:\n  commands:\n    lint: *lint\nprepare-commit-msg: &prepare\n  commands:\n    lint: *lint\n    " + command,
+			expectChanged: true,
+		},
+		{
+			name:          "Merge with commands of its own",
+			content:       "prepare-commit-msg:\n  <<: *defaults\n  commands:\n    ticket:\n      run: ./add-ticket\n",
+			expected:      "prepare-commit-msg:\n  <<: *defaults\n  commands:\n    ticket:\n      run: ./add-ticket\n    " + command,
+			expectChanged: true,
+		},
+		{
+			name:     "Already added",
+			content:  "prepare-commit-msg:\n  commands:\n    generate-commit:\n      run: generate-commit hook run {0}\n",
+			expected: "prepare-commit-msg:\n  commands:\n    generate-commit:\n      run: generate-commit hook run {0}\n",
+		},
+		{
+			name:        "Hook is an alias",
+			content:     "pre-commit: &hook\n  commands: {}\nprepare-commit-msg: *hook\n",
+			expectError: "alias",
+		},
+		{
+			name:        "Commands are an alias",
+			content:     "prepare-commit-msg:\n  commands: *shared\n",
+			expectError: "alias",
+		},
+		{
+			name:        "Merge without commands",
+			content:     "prepare-commit-msg:\n  <<: *defaults\n",
+			expectError: "merges another hook",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, changed, err := addLefthookCommand(tt.content)
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Fatalf("expected error containing %q, got %v", tt.expectError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if changed != tt.expectChanged {
+				t.Errorf("expected changed %v, got %v", tt.expectChanged, changed)
+			}
+			if got != tt.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+
+	"ai-commit-message-generator/internal/config"
+)
+
+// ErrRulesHaveIssues is returned by LintRules in strict mode when the rules
+// file has issues
+var ErrRulesHaveIssues = errors.New("the rules file has issues")
+
+// LintRulesOptions controls the lint-rules command
+type LintRulesOptions struct {
+	// Strict returns ErrRulesHaveIssues when any issue is found, for CI
+	Strict bool
+}
+
+// LintRules loads the rules file and reports issues that make the model
+// likely to ignore it
+func (a *App) LintRules(opts LintRulesOptions) error {
+	rules, err := a.RulesLoader.LoadRules(nil)
+	if err != nil {
+		return fmt.Errorf("failed to load rules: %w", err)
+	}
+
+	report := config.LintRules(rules)
+	fmt.Printf("Rules: %d, about %d tokens per prompt\n", report.Rules, report.Tokens)
+
+	if len(report.Issues) == 0 {
+		fmt.Println("\033[32m✓ No issues found\033[0m")
+		return nil
+	}
+	for _, issue := range report.Issues {
+		if issue.Line > 0 {
+			fmt.Printf("\033[33m! line %d: %s\033[0m\n", issue.Line, issue.Message)
+		} else {
+			fmt.Printf("\033[33m! %s\033[0m\n", issue.Message)
+		}
+	}
+
+	if opts.Strict {
+		return ErrRulesHaveIssues
+	}
+	return nil
+}
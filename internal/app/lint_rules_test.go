@@ -0,0 +1,65 @@
+package app
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestApp_LintRules(t *testing.T) {
+	tests := []struct {
+		name           string
+		rules          string
+		loadErr        error
+		strict         bool
+		expectedError  error
+		expectedOutput string
+	}{
+		{
+			name:           "Clean rules",
+			rules:          "- Use past tense\n",
+			expectedOutput: "No issues found",
+		},
+		{
+			name:           "Issues are warnings by default",
+			rules:          "- Use past tense\n- Use the imperative mood\n",
+			expectedOutput: "line 2: conflicts with line 1 about tense",
+		},
+		{
+			name:           "Strict mode fails on issues",
+			rules:          "",
+			strict:         true,
+			expectedError:  ErrRulesHaveIssues,
+			expectedOutput: "has no rules",
+		},
+		{
+			name:          "Load error",
+			loadErr:       errors.New("permission denied"),
+			expectedError: errors.New("failed to load rules"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockConfig := &MockConfig{
+				LoadRulesFunc: func() (string, error) { return tt.rules, tt.loadErr },
+			}
+			application := NewApp(&MockGit{}, mockConfig, nil, nil)
+
+			var err error
+			output := captureStdout(t, func() {
+				err = application.LintRules(LintRulesOptions{Strict: tt.strict})
+			})
+
+			switch {
+			case tt.expectedError == nil && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tt.expectedError != nil && (err == nil || !strings.Contains(err.Error(), tt.expectedError.Error())):
+				t.Errorf("expected error %v, got %v", tt.expectedError, err)
+			}
+			if !strings.Contains(output, tt.expectedOutput) {
+				t.Errorf("expected output to contain %q, got:\n%s", tt.expectedOutput, output)
+			}
+		})
+	}
+}
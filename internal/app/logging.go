@@ -0,0 +1,26 @@
+package app
+
+import (
+	"log/slog"
+	"time"
+
+	"ai-commit-message-generator/internal/logging"
+)
+
+// log returns the logger diagnostics go to
+func (a *App) log() *slog.Logger {
+	if a.Logger == nil {
+		return logging.Default()
+	}
+	return a.Logger
+}
+
+// logStage logs at debug level how long stage took since start and whether
+// it failed
+func (a *App) logStage(stage string, start time.Time, err error) {
+	status := "ok"
+	if err != nil {
+		status = "failed"
+	}
+	a.log().Debug(stage+" finished", logging.KeyStage, stage, logging.KeyDuration, time.Since(start), logging.KeyStatus, status)
+}
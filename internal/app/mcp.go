@@ -0,0 +1,205 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"ai-commit-message-generator/internal/ai"
+	"ai-commit-message-generator/internal/mcp"
+)
+
+// maxMCPMessageBytes caps the message commit_with_message accepts
+const maxMCPMessageBytes = 64 * 1024
+
+// maxMCPDiffBytes caps the diff get_staged_diff returns. The staged diff is
+// cut to this size for the prompt already; max_bytes can ask for less.
+const maxMCPDiffBytes = 10000
+
+// MCPTools returns the tools the mcp command offers: reading the staged
+// diff and the git state, writing a message and committing with one
+func (a *App) MCPTools() []mcp.Tool {
+	return []mcp.Tool{
+		{
+			Name:        "get_staged_diff",
+			Description: "Returns the staged changes of the repository as a unified diff, cut to max_bytes.",
+			InputSchema: json.RawMessage(`{"type":"object","properties":{"max_bytes":{"type":"integer","minimum":1,"maximum":` + fmt.Sprint(maxMCPDiffBytes) + `,"description":"Most bytes of the diff to return"}},"additionalProperties":false}`),
+			Call:        a.mcpGetStagedDiff,
+		},
+		{
+			Name:        "generate_commit_message",
+			Description: "Writes a Conventional Commits message for the staged changes, or for the given unified diff, following the repository's rules. Nothing is committed. The result may instead suggest splitting the changes into several commits.",
+			InputSchema: json.RawMessage(`{"type":"object","properties":{"diff":{"type":"string","description":"Unified diff to describe instead of the staged changes"}},"additionalProperties":false}`),
+			Call:        a.mcpGenerateCommitMessage,
+		},
+		{
+			Name:        "commit_with_message",
+			Description: "Commits the staged changes with the given message, running the repository's git hooks.",
+			InputSchema: json.RawMessage(`{"type":"object","properties":{"message":{"type":"string","description":"The full commit message"}},"required":["message"],"additionalProperties":false}`),
+			Call:        a.mcpCommitWithMessage,
+		},
+		{
+			Name:        "get_git_state",
+			Description: "Returns whether the directory is a repository, its root, the current branch, whether anything is staged and the operation in progress (merge, rebase, cherry-pick, ...) as JSON.",
+			InputSchema: json.RawMessage(`{"type":"object","properties":{},"additionalProperties":false}`),
+			Call:        a.mcpGetGitState,
+		},
+	}
+}
+
+// decodeMCPArguments decodes the arguments of a tool call into v, rejecting
+// the ones the tool does not take
+func decodeMCPArguments(arguments json.RawMessage, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(arguments))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+	return nil
+}
+
+// requireStagedChanges returns an error unless there is something to commit
+func (a *App) requireStagedChanges() error {
+	isRepo, err := a.Git.IsInsideRepo()
+	if err != nil {
+		return fmt.Errorf("failed to check repository status: %w", err)
+	}
+	if !isRepo {
+		return ErrNotARepo
+	}
+	hasChanges, err := a.Git.HasStagedChanges()
+	if err != nil {
+		return fmt.Errorf("failed to check for staged changes: %w", err)
+	}
+	if !hasChanges {
+		return fmt.Errorf("%w; stage them with 'git add' first", ErrNoStagedChanges)
+	}
+	return nil
+}
+
+func (a *App) mcpGetStagedDiff(arguments json.RawMessage) (string, error) {
+	var args struct {
+		MaxBytes *int `json:"max_bytes"`
+	}
+	if err := decodeMCPArguments(arguments, &args); err != nil {
+		return "", err
+	}
+	limit := maxMCPDiffBytes
+	if args.MaxBytes != nil {
+		if *args.MaxBytes < 1 || *args.MaxBytes > maxMCPDiffBytes {
+			return "", fmt.Errorf("max_bytes must be between 1 and %d", maxMCPDiffBytes)
+		}
+		limit = *args.MaxBytes
+	}
+	if err := a.requireStagedChanges(); err != nil {
+		return "", err
+	}
+
+	diff, err := a.Git.GetStagedDiff()
+	if err != nil {
+		return "", fmt.Errorf("failed to get diff: %w", err)
+	}
+	if len(diff) > limit {
+		diff = diff[:limit] + "\n...[TRUNCATED]"
+	}
+	return diff, nil
+}
+
+func (a *App) mcpGenerateCommitMessage(arguments json.RawMessage) (string, error) {
+	var args struct {
+		Diff string `json:"diff"`
+	}
+	if err := decodeMCPArguments(arguments, &args); err != nil {
+		return "", err
+	}
+	if len(args.Diff) > maxStdinDiffBytes {
+		return "", fmt.Errorf("diff is larger than %d KiB", maxStdinDiffBytes/1024)
+	}
+	if args.Diff != "" && strings.TrimSpace(args.Diff) == "" {
+		return "", errors.New("diff is empty; leave it out to describe the staged changes")
+	}
+
+	generated, err := a.Generate(args.Diff)
+	if err != nil {
+		return "", err
+	}
+	if generated.Split {
+		return "The changes should be split into several commits:\n\n" + generated.Message, nil
+	}
+	return generated.Message, nil
+}
+
+func (a *App) mcpCommitWithMessage(arguments json.RawMessage) (string, error) {
+	var args struct {
+		Message string `json:"message"`
+	}
+	if err := decodeMCPArguments(arguments, &args); err != nil {
+		return "", err
+	}
+	message, _ := ai.SplitRationale(args.Message)
+	message = strings.TrimSpace(message)
+	switch {
+	case message == "":
+		return "", errors.New("message is required")
+	case len(message) > maxMCPMessageBytes:
+		return "", fmt.Errorf("message is larger than %d KiB", maxMCPMessageBytes/1024)
+	}
+	if err := a.requireStagedChanges(); err != nil {
+		return "", err
+	}
+
+	if err := a.Git.CommitWithMessage(message); err != nil {
+		return "", fmt.Errorf("failed to commit: %w", err)
+	}
+	return "Committed: " + strings.SplitN(message, "\n", 2)[0], nil
+}
+
+// mcpGitState is the result of get_git_state
+type mcpGitState struct {
+	InsideRepo    bool   `json:"inside_repo"`
+	RepoRoot      string `json:"repo_root,omitempty"`
+	Branch        string `json:"branch,omitempty"`
+	StagedChanges bool   `json:"staged_changes"`
+	// State is the operation in progress, e.g. merge or rebase
+	State         string `json:"state,omitempty"`
+	Conflicts     bool   `json:"conflicts,omitempty"`
+	InitialCommit bool   `json:"initial_commit,omitempty"`
+}
+
+func (a *App) mcpGetGitState(arguments json.RawMessage) (string, error) {
+	if err := decodeMCPArguments(arguments, &struct{}{}); err != nil {
+		return "", err
+	}
+
+	var state mcpGitState
+	inside, err := a.Git.IsInsideRepo()
+	if err != nil {
+		return "", fmt.Errorf("failed to check repository status: %w", err)
+	}
+	state.InsideRepo = inside
+	if inside {
+		if state.RepoRoot, err = a.Git.GetRepoRoot(); err != nil {
+			return "", err
+		}
+		if state.StagedChanges, err = a.Git.HasStagedChanges(); err != nil {
+			return "", fmt.Errorf("failed to check for staged changes: %w", err)
+		}
+		// A detached HEAD has no branch
+		state.Branch, _ = a.Git.GetCurrentBranch()
+		gitState, err := a.Git.DetectState()
+		if err != nil {
+			return "", fmt.Errorf("failed to detect git state: %w", err)
+		}
+		state.State = gitState.Type.String()
+		state.Conflicts = gitState.ConflictMode
+		state.InitialCommit = gitState.InitialCommit
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,194 @@
+package app
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"ai-commit-message-generator/internal/ai"
+	"ai-commit-message-generator/internal/git"
+)
+
+// callMCPTool runs the tool named name with arguments
+func callMCPTool(t *testing.T, application *App, name, arguments string) (string, error) {
+	t.Helper()
+	for _, tool := range application.MCPTools() {
+		if tool.Name == name {
+			if !json.Valid(tool.InputSchema) {
+				t.Fatalf("tool %s has an invalid input schema", name)
+			}
+			return tool.Call(json.RawMessage(arguments))
+		}
+	}
+	t.Fatalf("no tool named %s", name)
+	return "", nil
+}
+
+func TestApp_MCPTools(t *testing.T) {
+	diff := "diff --git a/login.go b/login.go\n+func Login() {}\n"
+	tests := []struct {
+		name         string
+		tool         string
+		arguments    string
+		staged       bool
+		response     string
+		expectedText string
+		expectCommit string
+		expectError  string
+	}{
+		{
+			name:         "Staged diff",
+			tool:         "get_staged_diff",
+			arguments:    `{}`,
+			staged:       true,
+			expectedText: diff,
+		},
+		{
+			name:         "Staged diff cut to max_bytes",
+			tool:         "get_staged_diff",
+			arguments:    `{"max_bytes": 10}`,
+			staged:       true,
+			expectedText: diff[:10] + "\n...[TRUNCATED]",
+		},
+		{
+			name:        "max_bytes out of range",
+			tool:        "get_staged_diff",
+			arguments:   `{"max_bytes": 0}`,
+			staged:      true,
+			expectError: "max_bytes must be between 1 and 10000",
+		},
+		{
+			name:        "Nothing staged",
+			tool:        "get_staged_diff",
+			arguments:   `{}`,
+			expectError: "no staged changes",
+		},
+		{
+			name:        "Unknown argument",
+			tool:        "get_staged_diff",
+			arguments:   `{"path": "login.go"}`,
+			staged:      true,
+			expectError: `invalid arguments: json: unknown field "path"`,
+		},
+		{
+			name:         "Message for the staged changes",
+			tool:         "generate_commit_message",
+			arguments:    `{}`,
+			staged:       true,
+			response:     "feat(auth): add login",
+			expectedText: "feat(auth): add login",
+		},
+		{
+			name:         "Message for a given diff",
+			tool:         "generate_commit_message",
+			arguments:    `{"diff": "diff --git a/a.go b/a.go\n+x\n"}`,
+			response:     "fix: handle x",
+			expectedText: "fix: handle x",
+		},
+		{
+			name:         "Split suggestion",
+			tool:         "generate_commit_message",
+			arguments:    `{}`,
+			staged:       true,
+			response:     "These changes should be split into multiple commits.",
+			expectedText: "The changes should be split into several commits:\n\nThese changes should be split into multiple commits.",
+		},
+		{
+			name:        "Blank diff",
+			tool:        "generate_commit_message",
+			arguments:   `{"diff": "  \n"}`,
+			expectError: "diff is empty",
+		},
+		{
+			name:         "Commit",
+			tool:         "commit_with_message",
+			arguments:    `{"message": "feat(auth): add login\n\nAdds a login form.\n"}`,
+			staged:       true,
+			expectedText: "Committed: feat(auth): add login",
+			expectCommit: "feat(auth): add login\n\nAdds a login form.",
+		},
+		{
+			name:        "Commit without a message",
+			tool:        "commit_with_message",
+			arguments:   `{"message": " "}`,
+			staged:      true,
+			expectError: "message is required",
+		},
+		{
+			name:        "Commit with nothing staged",
+			tool:        "commit_with_message",
+			arguments:   `{"message": "feat: x"}`,
+			expectError: "no staged changes",
+		},
+		{
+			name:         "Git state",
+			tool:         "get_git_state",
+			arguments:    `{}`,
+			staged:       true,
+			expectedText: "{\n  \"inside_repo\": true,\n  \"repo_root\": \"/tmp/test-repo\",\n  \"branch\": \"main\",\n  \"staged_changes\": true,\n  \"state\": \"normal\"\n}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var committed string
+			mockGit := &MockGit{
+				IsInsideRepoFunc:     func() (bool, error) { return true, nil },
+				HasStagedChangesFunc: func() (bool, error) { return tt.staged, nil },
+				GetStagedDiffFunc:    func() (string, error) { return diff, nil },
+				GetGitDirFunc:        func() (string, error) { return t.TempDir(), nil },
+				CommitWithMessageFunc: func(message string) error {
+					committed = message
+					return nil
+				},
+			}
+			mockAI := &MockAI{
+				GenerateCommitMessageFunc: func(req ai.CommitRequest) (string, error) {
+					return tt.response, nil
+				},
+			}
+			application := NewApp(mockGit, &MockConfig{LoadRulesFunc: func() (string, error) { return "", nil }}, nil, mockAI)
+			application.Quiet = true
+
+			var text string
+			var err error
+			captureStdout(t, func() {
+				text, err = callMCPTool(t, application, tt.tool, tt.arguments)
+			})
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Fatalf("expected error containing %q, got %v", tt.expectError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if text != tt.expectedText {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.expectedText, text)
+			}
+			if committed != tt.expectCommit {
+				t.Errorf("expected commit %q, got %q", tt.expectCommit, committed)
+			}
+		})
+	}
+}
+
+func TestApp_MCPTools_GitStateOutsideRepo(t *testing.T) {
+	mockGit := &MockGit{
+		IsInsideRepoFunc: func() (bool, error) { return false, nil },
+		DetectStateFunc: func() (*git.GitState, error) {
+			t.Error("the state is not detected outside a repository")
+			return nil, nil
+		},
+	}
+	application := NewApp(mockGit, nil, nil, nil)
+
+	text, err := callMCPTool(t, application, "get_git_state", `{}`)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if text != "{\n  \"inside_repo\": false,\n  \"staged_changes\": false\n}" {
+		t.Errorf("unexpected state:\n%s", text)
+	}
+}
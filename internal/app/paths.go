@@ -0,0 +1,140 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"ai-commit-message-generator/internal/ai"
+	"ai-commit-message-generator/internal/git"
+)
+
+// PathOverride replaces the message style for changes that all fall under
+// Pattern, one area of a monorepo such as its frontend. Nil and empty
+// fields keep the setting of the App.
+type PathOverride struct {
+	// Pattern is a glob matched against repository-relative paths
+	Pattern string
+	// RulesFile is read, relative to the repository root, instead of the
+	// rules the RulesLoader finds
+	RulesFile      string
+	HeaderFormat   *ai.HeaderFormat
+	ScopePolicy    string
+	Capitalize     *bool
+	TrailingPeriod *bool
+	// Scopes are the only scopes a message for the area may use
+	Scopes []string
+	// Language is the language messages for the area are written in
+	Language string
+}
+
+// pathOverride returns the override of the area every described file is
+// in. Files outside every pattern get the App's settings, and so do files
+// spread over several areas, with a warning: no one area's style fits them.
+func (a *App) pathOverride(files []git.StagedFile) *PathOverride {
+	if len(a.PathOverrides) == 0 {
+		return nil
+	}
+	areas := make(map[string]*PathOverride)
+	for _, file := range files {
+		if file.Excluded {
+			continue
+		}
+		override := a.matchPathOverride(file.Path)
+		if override == nil {
+			areas[""] = nil
+		} else {
+			areas[override.Pattern] = override
+		}
+	}
+	if len(areas) == 1 {
+		for _, override := range areas {
+			return override
+		}
+	}
+	if len(areas) > 1 {
+		a.warnMixedAreas(areas)
+	}
+	return nil
+}
+
+// matchPathOverride returns the override whose pattern matches p, the
+// longest one when several do, or nil
+func (a *App) matchPathOverride(p string) *PathOverride {
+	var match *PathOverride
+	for i := range a.PathOverrides {
+		override := &a.PathOverrides[i]
+		if git.MatchGlob(override.Pattern, p) && (match == nil || len(override.Pattern) > len(match.Pattern)) {
+			match = override
+		}
+	}
+	return match
+}
+
+// warnMixedAreas reports that the changes span the areas of paths, keyed
+// by pattern with "" for the files outside every area
+func (a *App) warnMixedAreas(areas map[string]*PathOverride) {
+	var names []string
+	for pattern := range areas {
+		if pattern != "" {
+			names = append(names, pattern)
+		}
+	}
+	sort.Strings(names)
+	if _, outside := areas[""]; outside {
+		names = append(names, "files outside them")
+	}
+	message := fmt.Sprintf("the changes span several areas of paths (%s); using the settings for the whole repository", strings.Join(names, ", "))
+	if !a.NoSplit {
+		message += ". Commit each area on its own, or run 'generate-commit split'"
+	}
+	a.log().Warn(message)
+}
+
+// applyStyle sets the house style of req: the App's settings, with those
+// override sets in their place
+func (a *App) applyStyle(req *ai.CommitRequest, override *PathOverride) {
+	req.HeaderFormat = a.HeaderFormat
+	req.ScopePolicy = a.ScopePolicy
+	req.SubjectStyle = a.SubjectStyle
+	req.Types = a.Types
+	req.Scopes = a.Scopes
+	if override == nil {
+		return
+	}
+	if override.HeaderFormat != nil {
+		req.HeaderFormat = override.HeaderFormat
+	}
+	if override.ScopePolicy != "" {
+		req.ScopePolicy = override.ScopePolicy
+	}
+	if override.Capitalize != nil {
+		req.SubjectStyle.Capitalize = *override.Capitalize
+	}
+	if override.TrailingPeriod != nil {
+		req.SubjectStyle.TrailingPeriod = *override.TrailingPeriod
+	}
+	if len(override.Scopes) > 0 {
+		req.Scopes = override.Scopes
+	}
+	req.Language = override.Language
+}
+
+// loadRules returns the rules for changes to files: the rules file of
+// override when it names one, or those the RulesLoader finds
+func (a *App) loadRules(files []git.StagedFile, override *PathOverride) (string, error) {
+	if override == nil || override.RulesFile == "" {
+		return a.RulesLoader.LoadRules(filePaths(files))
+	}
+	repoRoot, err := a.Git.GetRepoRoot()
+	if err != nil {
+		return "", err
+	}
+	content, err := os.ReadFile(filepath.Join(repoRoot, filepath.FromSlash(override.RulesFile)))
+	if err != nil {
+		return "", fmt.Errorf("failed to read the rules file of %s: %w", override.Pattern, err)
+	}
+	return string(content), nil
+}
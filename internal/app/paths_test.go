@@ -0,0 +1,163 @@
+package app
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"ai-commit-message-generator/internal/ai"
+	"ai-commit-message-generator/internal/git"
+	"ai-commit-message-generator/internal/logging"
+)
+
+func TestApp_Run_PathOverrides(t *testing.T) {
+	noScope, err := ai.ParseHeaderFormat("{{.Type}}: {{.Description}}")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	capitalize := true
+	repoRoot := t.TempDir()
+	if err := os.WriteFile(filepath.Join(repoRoot, "web-rules.md"), []byte("Start the message with a gitmoji."), 0644); err != nil {
+		t.Fatalf("failed to write rules: %v", err)
+	}
+	overrides := []PathOverride{
+		{Pattern: "services/", ScopePolicy: ai.ScopeRequired, Scopes: []string{"api", "db"}},
+		{Pattern: "web/", RulesFile: "web-rules.md", HeaderFormat: noScope, Capitalize: &capitalize, Language: "German"},
+		{Pattern: "web/admin/", Scopes: []string{"admin"}},
+	}
+
+	tests := []struct {
+		name            string
+		files           []string
+		response        string
+		expectedRules   string
+		expectedFormat  *ai.HeaderFormat
+		expectedPolicy  string
+		expectedStyle   ai.SubjectStyle
+		expectedScopes  []string
+		expectedLang    string
+		expectedMessage string
+		expectedWarning string
+	}{
+		{
+			name:            "Single area",
+			files:           []string{"web/index.html", "web/app.js"},
+			response:        "feat: add a dark theme",
+			expectedRules:   "Start the message with a gitmoji.",
+			expectedFormat:  noScope,
+			expectedStyle:   ai.SubjectStyle{Capitalize: true},
+			expectedLang:    "German",
+			expectedMessage: "feat: Add a dark theme",
+		},
+		{
+			name:            "Nested area wins over its parent",
+			files:           []string{"web/admin/users.js"},
+			response:        "feat(admin): list users",
+			expectedRules:   "root rules",
+			expectedScopes:  []string{"admin"},
+			expectedMessage: "feat(admin): list users",
+		},
+		{
+			name:            "Area keeps the root settings it does not override",
+			files:           []string{"services/api/handler.go"},
+			response:        "fix(api): handle timeouts",
+			expectedRules:   "root rules",
+			expectedPolicy:  ai.ScopeRequired,
+			expectedScopes:  []string{"api", "db"},
+			expectedMessage: "fix(api): handle timeouts",
+		},
+		{
+			name:            "Cross-area uses the root settings",
+			files:           []string{"web/app.js", "services/api/handler.go"},
+			response:        "feat(ui): Show errors.",
+			expectedRules:   "root rules",
+			expectedMessage: "feat(ui): show errors",
+			expectedWarning: "the changes span several areas of paths (services/, web/); using the settings for the whole repository. Commit each area on its own, or run 'generate-commit split'",
+		},
+		{
+			name:            "Area and files outside it",
+			files:           []string{"web/app.js", "README.md"},
+			response:        "docs: describe the theme",
+			expectedRules:   "root rules",
+			expectedMessage: "docs: describe the theme",
+			expectedWarning: "(web/, files outside them)",
+		},
+		{
+			name:            "No match",
+			files:           []string{"README.md", "go.mod"},
+			response:        "chore: tidy",
+			expectedRules:   "root rules",
+			expectedMessage: "chore: tidy",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockGit := &MockGit{
+				IsInsideRepoFunc:     func() (bool, error) { return true, nil },
+				HasStagedChangesFunc: func() (bool, error) { return true, nil },
+				GetStagedDiffFunc:    func() (string, error) { return "diff", nil },
+				GetRepoRootFunc:      func() (string, error) { return repoRoot, nil },
+				GetStagedFilesFunc: func() ([]git.StagedFile, error) {
+					var files []git.StagedFile
+					for _, p := range tt.files {
+						files = append(files, git.StagedFile{Path: p, Change: git.ChangeModified})
+					}
+					return files, nil
+				},
+				DetectStateFunc: func() (*git.GitState, error) {
+					return &git.GitState{Type: git.StateNormal}, nil
+				},
+			}
+			var requests []ai.CommitRequest
+			mockAI := &MockAI{
+				GenerateCommitMessageFunc: func(req ai.CommitRequest) (string, error) {
+					requests = append(requests, req)
+					return tt.response, nil
+				},
+			}
+			mockConfig := &MockConfig{
+				LoadRulesFunc: func() (string, error) { return "root rules", nil },
+			}
+			application := NewApp(mockGit, mockConfig, nil, mockAI)
+			application.PathOverrides = overrides
+			application.Quiet = true
+			application.FastPath = ai.FastPathOptions{Disabled: true}
+			var warnings bytes.Buffer
+			application.Logger = logging.New(&warnings, logging.FormatText, false)
+
+			var runErr error
+			stdout := captureStdout(t, func() {
+				runErr = application.Run(RunOptions{})
+			})
+			if runErr != nil {
+				t.Fatalf("expected no error, got %v", runErr)
+			}
+			if len(requests) != 1 {
+				t.Fatalf("expected one generation, got %d", len(requests))
+			}
+			req := requests[0]
+			if req.Rules != tt.expectedRules {
+				t.Errorf("expected rules %q, got %q", tt.expectedRules, req.Rules)
+			}
+			if req.HeaderFormat != tt.expectedFormat || req.ScopePolicy != tt.expectedPolicy || req.SubjectStyle != tt.expectedStyle {
+				t.Errorf("expected format %v, policy %q and style %+v, got %v, %q and %+v", tt.expectedFormat, tt.expectedPolicy, tt.expectedStyle, req.HeaderFormat, req.ScopePolicy, req.SubjectStyle)
+			}
+			if !reflect.DeepEqual(req.Scopes, tt.expectedScopes) || req.Language != tt.expectedLang {
+				t.Errorf("expected scopes %v and language %q, got %v and %q", tt.expectedScopes, tt.expectedLang, req.Scopes, req.Language)
+			}
+			if !strings.Contains(stdout, tt.expectedMessage+"\n") {
+				t.Errorf("expected the message %q, got %q", tt.expectedMessage, stdout)
+			}
+			if tt.expectedWarning == "" && warnings.Len() != 0 {
+				t.Errorf("expected no warning, got %q", warnings.String())
+			}
+			if tt.expectedWarning != "" && !strings.Contains(warnings.String(), tt.expectedWarning) {
+				t.Errorf("expected a warning containing %q, got %q", tt.expectedWarning, warnings.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"ai-commit-message-generator/internal/ai"
+	"ai-commit-message-generator/internal/logging"
+)
+
+// PRDescriptionOptions controls PRDescription
+type PRDescriptionOptions struct {
+	// Base is the branch the pull request targets. Empty means the
+	// branch's upstream, or main, master or origin/HEAD, whichever exists
+	// first.
+	Base string
+	// JSON prints {"title": ..., "body": ...} instead of markdown, for
+	// gh pr create --title --body
+	JSON bool
+}
+
+// prJSON is the --json output of PRDescription
+type prJSON struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+// PRDescription writes a pull request title and markdown body for the
+// commits on the current branch since it left the base branch. Only the
+// markdown goes to stdout so it can be piped; progress goes to stderr.
+func (a *App) PRDescription(opts PRDescriptionOptions) error {
+	isRepo, err := a.Git.IsInsideRepo()
+	if err != nil {
+		return fmt.Errorf("failed to check repository status: %w", err)
+	}
+	if !isRepo {
+		return ErrNotARepo
+	}
+
+	base := opts.Base
+	if base == "" {
+		if base, err = a.Git.DetectBaseBranch(); err != nil {
+			return err
+		}
+	}
+
+	defer a.Progress.Stop()
+	a.Progress.Phase(PhaseReadingDiff)
+	diff, err := a.Git.GetBranchDiff(base)
+	if err != nil {
+		return fmt.Errorf("failed to get branch diff: %w", err)
+	}
+	if diff == "" {
+		return fmt.Errorf("no committed changes between %s and HEAD", base)
+	}
+
+	// The subjects only help the model, so a branch whose log cannot be
+	// walked is still described from its diff
+	var subjects []string
+	commits, err := a.Git.GetCommitRange(base + "..HEAD")
+	if err != nil {
+		a.log().Warn("failed to read the branch's commits", logging.KeyStage, "diff", logging.KeyError, err)
+	}
+	for i := len(commits) - 1; i >= 0; i-- {
+		if !commits[i].Merge {
+			subjects = append(subjects, commits[i].Subject())
+		}
+	}
+
+	if !a.Quiet && !a.Progress.Enabled() {
+		fmt.Fprintf(os.Stderr, "Generating pull request description against %s...\n", base)
+	}
+	description, err := a.AI.GeneratePRDescription(ai.PRRequest{Diff: diff, Base: base, Commits: subjects})
+	a.Progress.Stop()
+	if err != nil {
+		return fmt.Errorf("failed to generate pull request description: %w", classifyAIError(err))
+	}
+
+	if !opts.JSON {
+		fmt.Println(description)
+		return nil
+	}
+	title, body := splitPRDescription(description)
+	data, err := json.MarshalIndent(prJSON{Title: title, Body: body}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode pull request description: %w", err)
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+// splitPRDescription splits the model's markdown into the title on its
+// first line, without the "# ", and the body below it
+func splitPRDescription(description string) (string, string) {
+	title, body, _ := strings.Cut(description, "\n")
+	title = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(title), "#"))
+	return title, strings.TrimSpace(body)
+}
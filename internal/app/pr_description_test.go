@@ -0,0 +1,214 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"ai-commit-message-generator/internal/ai"
+	"ai-commit-message-generator/internal/git"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func TestApp_PRDescription(t *testing.T) {
+	tests := []struct {
+		name            string
+		base            string
+		json            bool
+		diff            string
+		diffErr         error
+		rangeErr        error
+		expectedBase    string
+		expectedCommits []string
+		expectedOutput  string
+		expectedError   string
+	}{
+		{
+			name:            "Detected base",
+			diff:            "diff --git a/login.go b/login.go",
+			expectedBase:    "master",
+			expectedCommits: []string{"feat: add login", "test: cover login"},
+			expectedOutput:  "# Add login\n\n## Summary\nAdds login.\n",
+		},
+		{
+			name:            "JSON for gh pr create",
+			base:            "main",
+			json:            true,
+			diff:            "diff --git a/login.go b/login.go",
+			expectedBase:    "main",
+			expectedCommits: []string{"feat: add login", "test: cover login"},
+			expectedOutput:  "{\n  \"title\": \"Add login\",\n  \"body\": \"## Summary\\nAdds login.\"\n}\n",
+		},
+		{
+			name:           "Unreadable log still describes the diff",
+			base:           "main",
+			diff:           "diff --git a/login.go b/login.go",
+			rangeErr:       errors.New("object not found"),
+			expectedBase:   "main",
+			expectedOutput: "# Add login\n\n## Summary\nAdds login.\n",
+		},
+		{
+			name:            "Explicit base",
+			base:            "develop",
+			diff:            "diff --git a/login.go b/login.go",
+			expectedBase:    "develop",
+			expectedCommits: []string{"feat: add login", "test: cover login"},
+			expectedOutput:  "# Add login\n\n## Summary\nAdds login.\n",
+		},
+		{
+			name:          "Nothing committed on the branch",
+			base:          "main",
+			expectedBase:  "main",
+			expectedError: "no committed changes between main and HEAD",
+		},
+		{
+			name:          "Unknown base",
+			base:          "nope",
+			diffErr:       errors.New(`failed to resolve base "nope"`),
+			expectedBase:  "nope",
+			expectedError: "failed to get branch diff",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotBase string
+			mockGit := &MockGit{
+				IsInsideRepoFunc:     func() (bool, error) { return true, nil },
+				DetectBaseBranchFunc: func() (string, error) { return "master", nil },
+				GetBranchDiffFunc: func(base string) (string, error) {
+					gotBase = base
+					return tt.diff, tt.diffErr
+				},
+				GetCommitRangeFunc: func(revRange string) ([]git.LogCommit, error) {
+					if revRange != tt.expectedBase+"..HEAD" {
+						t.Errorf("expected range %s..HEAD, got %q", tt.expectedBase, revRange)
+					}
+					if tt.rangeErr != nil {
+						return nil, tt.rangeErr
+					}
+					return []git.LogCommit{
+						{Hash: "cccccccccc", Message: "Merge branch 'main' into login", Merge: true},
+						{Hash: "bbbbbbbbbb", Message: "test: cover login\n\nAdds table tests."},
+						{Hash: "aaaaaaaaaa", Message: "feat: add login"},
+					}, nil
+				},
+			}
+			mockAI := &MockAI{
+				GeneratePRDescriptionFunc: func(req ai.PRRequest) (string, error) {
+					if req.Diff != tt.diff || req.Base != tt.expectedBase || !reflect.DeepEqual(req.Commits, tt.expectedCommits) {
+						t.Errorf("unexpected request %+v", req)
+					}
+					return "# Add login\n\n## Summary\nAdds login.", nil
+				},
+			}
+			application := NewApp(mockGit, &MockConfig{}, nil, mockAI)
+			application.Quiet = true
+
+			var err error
+			output := captureStdout(t, func() {
+				err = application.PRDescription(PRDescriptionOptions{Base: tt.base, JSON: tt.json})
+			})
+			if gotBase != tt.expectedBase {
+				t.Errorf("expected base %q, got %q", tt.expectedBase, gotBase)
+			}
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("expected error containing %q, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("PRDescription failed: %v", err)
+			}
+			if output != tt.expectedOutput {
+				t.Errorf("expected stdout %q, got %q", tt.expectedOutput, output)
+			}
+		})
+	}
+}
+
+func TestApp_PRDescription_Integration(t *testing.T) {
+	tempDir := t.TempDir()
+	originalWd, _ := os.Getwd()
+	t.Cleanup(func() { _ = os.Chdir(originalWd) })
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatalf("failed to change to temp dir: %v", err)
+	}
+	repo, err := gogit.PlainInit(tempDir, false)
+	if err != nil {
+		t.Fatalf("failed to git init: %v", err)
+	}
+	worktree, _ := repo.Worktree()
+	commit := func(file, content, message string, minute int) plumbing.Hash {
+		if err := os.WriteFile(filepath.Join(tempDir, file), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", file, err)
+		}
+		if _, err := worktree.Add(file); err != nil {
+			t.Fatalf("failed to stage %s: %v", file, err)
+		}
+		hash, err := worktree.Commit(message, &gogit.CommitOptions{
+			Author: &object.Signature{Name: "Test User", Email: "test@example.com", When: time.Date(2026, 1, 1, 0, minute, 0, 0, time.UTC)},
+		})
+		if err != nil {
+			t.Fatalf("failed to commit: %v", err)
+		}
+		return hash
+	}
+
+	base := commit("README.md", "# Demo\n", "chore: initial commit", 0)
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName("main"), base)); err != nil {
+		t.Fatalf("failed to create main: %v", err)
+	}
+	if err := worktree.Checkout(&gogit.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("login"), Create: true}); err != nil {
+		t.Fatalf("failed to create the branch: %v", err)
+	}
+	commit("login.go", "package demo\n\nfunc Login() {}\n", "feat: add login", 1)
+	commit("login_test.go", "package demo\n", "test: cover login", 2)
+
+	var req ai.PRRequest
+	mockAI := &MockAI{
+		GeneratePRDescriptionFunc: func(r ai.PRRequest) (string, error) {
+			req = r
+			return "# Add login\n\n## Summary\nAdds login.\n\n## Breaking Changes\nNone.", nil
+		},
+	}
+	application := NewApp(git.NewClient(), &MockConfig{}, nil, mockAI)
+	application.Quiet = true
+	output := captureStdout(t, func() {
+		if err := application.PRDescription(PRDescriptionOptions{Base: "main", JSON: true}); err != nil {
+			t.Fatalf("PRDescription failed: %v", err)
+		}
+	})
+
+	if req.Base != "main" {
+		t.Errorf("expected base main, got %q", req.Base)
+	}
+	if expected := []string{"feat: add login", "test: cover login"}; !reflect.DeepEqual(req.Commits, expected) {
+		t.Errorf("expected commits %q, got %q", expected, req.Commits)
+	}
+	for _, want := range []string{"+++ b/login.go", "+++ b/login_test.go"} {
+		if !strings.Contains(req.Diff, want) {
+			t.Errorf("expected the diff to contain %q, got:\n%s", want, req.Diff)
+		}
+	}
+	if strings.Contains(req.Diff, "README.md") {
+		t.Errorf("expected the diff to leave out main's commits, got:\n%s", req.Diff)
+	}
+
+	var got prJSON
+	if err := json.Unmarshal([]byte(output), &got); err != nil {
+		t.Fatalf("expected JSON output, got %q: %v", output, err)
+	}
+	if expected := (prJSON{Title: "Add login", Body: "## Summary\nAdds login.\n\n## Breaking Changes\nNone."}); got != expected {
+		t.Errorf("expected %+v, got %+v", expected, got)
+	}
+}
@@ -0,0 +1,267 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"ai-commit-message-generator/internal/git"
+)
+
+// FrameworkPreCommit is the InitOptions.Framework value that registers the
+// hook with the pre-commit framework (pre-commit.com) instead of writing
+// .git/hooks scripts, which 'pre-commit install' would replace
+const FrameworkPreCommit = "pre-commit"
+
+// preCommitConfigName is the pre-commit framework's config file
+const preCommitConfigName = ".pre-commit-config.yaml"
+
+// preCommitHookID identifies our hook in .pre-commit-config.yaml
+const preCommitHookID = "generate-commit"
+
+// preCommitRepo is the local repository entry init appends to the repos of
+// .pre-commit-config.yaml, indented as a list item of repos by two spaces.
+// The message file is not passed; hook run finds it in the git directory.
+const preCommitRepo = `- repo: local
+  hooks:
+    - id: generate-commit
+      name: generate-commit
+      entry: generate-commit hook run
+      language: system
+      stages: [prepare-commit-msg]
+      pass_filenames: false
+      always_run: true
+`
+
+// Environment variables the pre-commit framework passes the
+// prepare-commit-msg arguments after the message file in
+const (
+	preCommitSourceEnv = "PRE_COMMIT_COMMIT_MSG_SOURCE"
+	preCommitSHAEnv    = "PRE_COMMIT_COMMIT_OBJECT_NAME"
+)
+
+// hookFilesEnv lists the staged files for a hook manager that can set
+// environment variables but not add arguments, one per line or comma
+// separated
+const hookFilesEnv = "AI_COMMIT_FILES"
+
+// HookRunArgs are the arguments of 'hook run', the entrypoint for the
+// pre-commit framework
+type HookRunArgs struct {
+	// MsgFile is the commit message file; empty means COMMIT_EDITMSG in
+	// the git directory
+	MsgFile string
+	// Source and SHA are the prepare-commit-msg arguments that follow it
+	Source string
+	SHA    string
+	// Files are the staged files the hook manager already knows, given
+	// with --files or AI_COMMIT_FILES; empty means git is asked
+	Files []string
+}
+
+// ParseHookRunArgs reads the arguments of 'hook run': an optional message
+// file, given as --msg-file or as the first argument the way git and
+// pass_filenames: true give it, then the optional source and commit. The
+// pre-commit framework passes the source and commit in environment
+// variables instead, which getenv reads when they are not arguments.
+// --files, which may be repeated, or AI_COMMIT_FILES name the staged files
+// so they are not looked for in the whole working tree.
+func ParseHookRunArgs(args []string, getenv func(string) string) (HookRunArgs, error) {
+	var positional []string
+	var msgFile string
+	var files []string
+	var filesGiven bool
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--msg-file":
+			if i+1 == len(args) {
+				return HookRunArgs{}, errors.New("--msg-file needs a path")
+			}
+			i++
+			msgFile = args[i]
+		case strings.HasPrefix(arg, "--msg-file="):
+			msgFile = strings.TrimPrefix(arg, "--msg-file=")
+		case arg == "--files":
+			if i+1 == len(args) {
+				return HookRunArgs{}, errors.New("--files needs a comma-separated list of paths")
+			}
+			i++
+			files, filesGiven = append(files, splitFileList(args[i])...), true
+		case strings.HasPrefix(arg, "--files="):
+			files, filesGiven = append(files, splitFileList(strings.TrimPrefix(arg, "--files="))...), true
+		case strings.HasPrefix(arg, "-") && arg != "-":
+			return HookRunArgs{}, fmt.Errorf("unknown flag %s (expected --msg-file or --files)", arg)
+		default:
+			positional = append(positional, arg)
+		}
+	}
+	if msgFile != "" {
+		positional = append([]string{msgFile}, positional...)
+	}
+	if len(positional) > 3 {
+		return HookRunArgs{}, fmt.Errorf("too many arguments %q (expected [msg-file] [source] [sha])", positional)
+	}
+
+	parsed := HookRunArgs{MsgFile: hookArg(positional, 0), Source: hookArg(positional, 1), SHA: hookArg(positional, 2)}
+	if parsed.Source == "" {
+		parsed.Source = getenv(preCommitSourceEnv)
+	}
+	if parsed.SHA == "" {
+		parsed.SHA = getenv(preCommitSHAEnv)
+	}
+	if !filesGiven {
+		files = splitFileList(getenv(hookFilesEnv))
+	}
+	cleaned, err := git.CleanFiles(files)
+	if err != nil {
+		return HookRunArgs{}, err
+	}
+	parsed.Files = cleaned
+	return parsed, nil
+}
+
+// splitFileList splits a list of paths on commas and newlines
+func splitFileList(list string) []string {
+	return strings.FieldsFunc(list, func(r rune) bool {
+		return r == ',' || r == '\n' || r == '\r'
+	})
+}
+
+// hookArg returns args[i], or "" when there are fewer arguments
+func hookArg(args []string, i int) string {
+	if i < len(args) {
+		return args[i]
+	}
+	return ""
+}
+
+// HookRun is the prepare-commit-msg hook run by the pre-commit framework
+func (a *App) HookRun(args HookRunArgs) error {
+	if args.MsgFile == "" {
+		gitDir, err := a.Git.GetGitDir()
+		if err != nil {
+			return fmt.Errorf("failed to find the commit message file: %w", err)
+		}
+		args.MsgFile = filepath.Join(gitDir, "COMMIT_EDITMSG")
+	}
+	return a.PrepareCommitMsgHook(args.MsgFile, args.Source, args.SHA)
+}
+
+// installPreCommitFramework adds our hook to the repository's
+// .pre-commit-config.yaml, creating it if needed
+func installPreCommitFramework(repoRoot string) error {
+	path := filepath.Join(repoRoot, preCommitConfigName)
+	content, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", preCommitConfigName, err)
+	}
+
+	updated, changed, err := addPreCommitHook(string(content))
+	if err != nil {
+		return err
+	}
+	if !changed {
+		fmt.Printf("✓ %s already runs generate-commit\n", preCommitConfigName)
+		return nil
+	}
+	if err := os.WriteFile(path, []byte(updated), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", preCommitConfigName, err)
+	}
+	fmt.Printf("✓ Added the generate-commit hook to %s\n", preCommitConfigName)
+	return nil
+}
+
+// addPreCommitHook appends our local repository entry to the repos of a
+// .pre-commit-config.yaml, keeping the rest of the file as it is. It
+// reports false when the file already has a hook with our id. The file is
+// edited as text: the entry goes at the end of the block style repos list,
+// indented like the items already there.
+func addPreCommitHook(content string) (string, bool, error) {
+	lines := strings.Split(content, "\n")
+	for _, line := range lines {
+		if strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "-")) == "id: "+preCommitHookID {
+			return content, false, nil
+		}
+	}
+
+	reposLine := -1
+	for i, line := range lines {
+		if !strings.HasPrefix(line, "repos:") {
+			continue
+		}
+		value := strings.TrimPrefix(line, "repos:")
+		switch {
+		case isYAMLComment(value):
+		case strings.TrimSpace(value) == "[]":
+			// An empty flow list becomes a block list
+			lines[i] = "repos:"
+		default:
+			return "", false, fmt.Errorf("%s lists its repos in flow style; add the generate-commit hook by hand", preCommitConfigName)
+		}
+		reposLine = i
+		break
+	}
+
+	if reposLine == -1 {
+		trimmed := strings.TrimRight(content, "\n")
+		if trimmed != "" {
+			trimmed += "\n"
+		}
+		return trimmed + "repos:\n" + indentYAML(preCommitRepo, "  "), true, nil
+	}
+
+	// The repos list runs until the next line that is neither blank, a
+	// comment nor indented
+	end := len(lines)
+	indent := "  "
+	foundItem := false
+	for i := reposLine + 1; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "-") {
+			end = i
+			break
+		}
+		if !foundItem && strings.HasPrefix(trimmed, "- ") {
+			indent = line[:len(line)-len(strings.TrimLeft(line, " "))]
+			foundItem = true
+		}
+	}
+	// Insert after the last line of the list, not after trailing blank
+	// lines or comments that belong to the next key
+	insert := end
+	for insert > reposLine+1 && isYAMLComment(lines[insert-1]) {
+		insert--
+	}
+
+	entry := strings.Split(strings.TrimRight(indentYAML(preCommitRepo, indent), "\n"), "\n")
+	updated := append(append(append([]string{}, lines[:insert]...), entry...), lines[insert:]...)
+	result := strings.Join(updated, "\n")
+	if !strings.HasSuffix(result, "\n") {
+		result += "\n"
+	}
+	return result, true, nil
+}
+
+// isYAMLComment reports whether line is blank or only a comment
+func isYAMLComment(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return trimmed == "" || strings.HasPrefix(trimmed, "#")
+}
+
+// indentYAML indents every non-empty line of block by indent
+func indentYAML(block, indent string) string {
+	lines := strings.Split(block, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = indent + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
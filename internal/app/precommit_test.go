@@ -0,0 +1,262 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"ai-commit-message-generator/internal/ai"
+	"ai-commit-message-generator/internal/config"
+)
+
+func TestParseHookRunArgs(t *testing.T) {
+	env := map[string]string{
+		preCommitSourceEnv: "template",
+		preCommitSHAEnv:    "abc123",
+	}
+	tests := []struct {
+		name        string
+		args        []string
+		env         map[string]string
+		expected    HookRunArgs
+		expectError string
+	}{
+		{
+			name:     "No arguments",
+			expected: HookRunArgs{},
+		},
+		{
+			name:     "Source and commit from pre-commit's environment",
+			env:      env,
+			expected: HookRunArgs{Source: "template", SHA: "abc123"},
+		},
+		{
+			name:     "Message file passed as a filename",
+			args:     []string{".git/COMMIT_EDITMSG"},
+			env:      env,
+			expected: HookRunArgs{MsgFile: ".git/COMMIT_EDITMSG", Source: "template", SHA: "abc123"},
+		},
+		{
+			name:     "Arguments as git passes them",
+			args:     []string{".git/COMMIT_EDITMSG", "message"},
+			env:      env,
+			expected: HookRunArgs{MsgFile: ".git/COMMIT_EDITMSG", Source: "message", SHA: "abc123"},
+		},
+		{
+			name:     "--msg-file",
+			args:     []string{"--msg-file", "msg.txt"},
+			expected: HookRunArgs{MsgFile: "msg.txt"},
+		},
+		{
+			name:     "--msg-file= with the source",
+			args:     []string{"--msg-file=msg.txt", "merge"},
+			expected: HookRunArgs{MsgFile: "msg.txt", Source: "merge"},
+		},
+		{
+			name:        "--msg-file without a path",
+			args:        []string{"--msg-file"},
+			expectError: "--msg-file needs a path",
+		},
+		{
+			name:     "--files, repeated",
+			args:     []string{"--files", "a.go,./pkg/b.go", "--files=c.go", ".git/COMMIT_EDITMSG"},
+			env:      map[string]string{hookFilesEnv: "ignored.go"},
+			expected: HookRunArgs{MsgFile: ".git/COMMIT_EDITMSG", Files: []string{"a.go", "pkg/b.go", "c.go"}},
+		},
+		{
+			name:     "Files from the environment",
+			env:      map[string]string{hookFilesEnv: "a.go\nb.go\n"},
+			expected: HookRunArgs{Files: []string{"a.go", "b.go"}},
+		},
+		{
+			name:        "--files outside the repository",
+			args:        []string{"--files", "../a.go"},
+			expectError: `invalid file "../a.go"`,
+		},
+		{
+			name:        "Unknown flag",
+			args:        []string{"--verbose"},
+			expectError: "unknown flag --verbose",
+		},
+		{
+			name:        "Too many arguments",
+			args:        []string{"a", "b", "c", "d"},
+			expectError: "too many arguments",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseHookRunArgs(tt.args, func(key string) string { return tt.env[key] })
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Fatalf("expected error containing %q, got %v", tt.expectError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("expected %+v, got %+v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestAddPreCommitHook(t *testing.T) {
+	entry := func(indent string) string {
+		return indentYAML(preCommitRepo, indent)
+	}
+	tests := []struct {
+		name          string
+		content       string
+		expected      string
+		expectChanged bool
+		expectError   string
+	}{
+		{
+			name:          "New file",
+			content:       "",
+			expected:      "repos:\n" + entry("  "),
+			expectChanged: true,
+		},
+		{
+			name:          "Appended to the repos list",
+			content:       "repos:\n  - repo: https://github.com/pre-commit/pre-commit-hooks\n    rev: v4.6.0\n    hooks:\n      - id: trailing-whitespace\n",
+			expected:      "repos:\n  - repo: https://github.com/pre-commit/pre-commit-hooks\n    rev: v4.6.0\n    hooks:\n      - id: trailing-whitespace\n" + entry("  "),
+			expectChanged: true,
+		},
+		{
+			name:          "List items at the indentation of repos",
+			content:       "repos:\n- repo: local\n  hooks:\n  - id: lint\n",
+			expected:      "repos:\n- repo: local\n  hooks:\n  - id: lint\n" + entry(""),
+			expectChanged: true,
+		},
+		{
+			name:          "Keys after the repos list",
+			content:       "default_stages: [pre-commit]\nrepos:\n  - repo: local\n    hooks:\n      - id: lint\n\n# CI settings\nci:\n  autofix_prs: false\n",
+			expected:      "default_stages: [pre-commit]\nrepos:\n  - repo: local\n    hooks:\n      - id: lint\n" + entry("  ") + "\n# CI settings\nci:\n  autofix_prs: false\n",
+			expectChanged: true,
+		},
+		{
+			name:          "Empty flow list",
+			content:       "repos: []\n",
+			expected:      "repos:\n" + entry("  "),
+			expectChanged: true,
+		},
+		{
+			name:          "No repos key",
+			content:       "fail_fast: true",
+			expected:      "fail_fast: true\nrepos:\n" + entry("  "),
+			expectChanged: true,
+		},
+		{
+			name:     "Already added",
+			content:  "repos:\n  - repo: local\n    hooks:\n      - id: generate-commit\n",
+			expected: "repos:\n  - repo: local\n    hooks:\n      - id: generate-commit\n",
+		},
+		{
+			name:        "Flow style list",
+			content:     "repos: [{repo: local, hooks: []}]\n",
+			expectError: "flow style",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, changed, err := addPreCommitHook(tt.content)
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Fatalf("expected error containing %q, got %v", tt.expectError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if changed != tt.expectChanged {
+				t.Errorf("expected changed %v, got %v", tt.expectChanged, changed)
+			}
+			if got != tt.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestApp_Init_PreCommitFramework(t *testing.T) {
+	repoRoot := t.TempDir()
+	if err := os.Mkdir(filepath.Join(repoRoot, ".git"), 0755); err != nil {
+		t.Fatalf("failed to create .git dir: %v", err)
+	}
+	originalWd, _ := os.Getwd()
+	defer os.Chdir(originalWd)
+	os.Chdir(repoRoot)
+
+	mockGit := &MockGit{
+		IsInsideRepoFunc: func() (bool, error) { return true, nil },
+		GetRepoRootFunc:  func() (string, error) { return repoRoot, nil },
+		GetHooksDirFunc: func() (string, error) {
+			t.Error("the hooks directory is left to the pre-commit framework")
+			return "", nil
+		},
+	}
+	application := NewApp(mockGit, &MockConfig{}, config.NewConfigLoader(), nil)
+
+	captureStdout(t, func() {
+		if err := application.Init(InitOptions{Framework: FrameworkPreCommit}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	content, err := os.ReadFile(filepath.Join(repoRoot, preCommitConfigName))
+	if err != nil {
+		t.Fatalf("expected %s: %v", preCommitConfigName, err)
+	}
+	if !strings.Contains(string(content), "entry: generate-commit hook run") {
+		t.Errorf("expected the hook entry, got:\n%s", content)
+	}
+	if _, err := os.Stat(filepath.Join(repoRoot, ".git", "hooks")); err == nil {
+		t.Error("expected no hooks to be written")
+	}
+
+	err = application.Init(InitOptions{Force: true, Framework: "npm"})
+	if err == nil || !strings.Contains(err.Error(), `unknown --framework "npm"`) {
+		t.Errorf("expected an unknown framework error, got %v", err)
+	}
+}
+
+func TestApp_HookRun(t *testing.T) {
+	gitDir := t.TempDir()
+	msgFile := filepath.Join(gitDir, "COMMIT_EDITMSG")
+	if err := os.WriteFile(msgFile, []byte("\n# Please enter the commit message\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mockGit := &MockGit{
+		IsInsideRepoFunc:     func() (bool, error) { return true, nil },
+		HasStagedChangesFunc: func() (bool, error) { return true, nil },
+		GetStagedDiffFunc:    func() (string, error) { return "diff --git a/login.go b/login.go", nil },
+		GetGitDirFunc:        func() (string, error) { return gitDir, nil },
+	}
+	mockAI := &MockAI{
+		GenerateCommitMessageFunc: func(req ai.CommitRequest) (string, error) {
+			return "feat(auth): add login", nil
+		},
+	}
+	application := NewApp(mockGit, &MockConfig{LoadRulesFunc: func() (string, error) { return "", nil }}, nil, mockAI)
+	application.Quiet = true
+
+	if err := application.HookRun(HookRunArgs{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, err := os.ReadFile(msgFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(content), "feat(auth): add login\n") {
+		t.Errorf("expected the message in COMMIT_EDITMSG, got:\n%s", content)
+	}
+}
@@ -0,0 +1,85 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"ai-commit-message-generator/internal/ai"
+	"ai-commit-message-generator/internal/git"
+)
+
+// finalizeMessage applies the formatting pipeline a message goes through
+// before it is committed. Preview and commit both use it so the preview shows
+// exactly what git will record. A rationale asked for with --explain is
+// never part of it.
+func (a *App) finalizeMessage(message string) string {
+	message, _ = ai.SplitRationale(message)
+	return message
+}
+
+// preview prints the commit that would be created from message without
+// creating it: the final message, the author/committer and the file list.
+// With opts.Add the list holds the files Add would stage.
+func (a *App) preview(message string, opts RunOptions) error {
+	if a.suggestsSplit(message) {
+		fmt.Println("\n\033[33mAI Suggestion (Split Changes):\033[0m")
+		fmt.Println(message)
+		fmt.Println("\nNo commit would be created from a split suggestion.")
+		return nil
+	}
+
+	files, err := a.changedFiles(opts)
+	if err != nil {
+		return fmt.Errorf("failed to list changed files: %w", err)
+	}
+
+	fmt.Println("\nCommit preview (nothing has been committed):")
+	fmt.Println("==========================")
+
+	// go-git records the author as the committer too
+	identity, err := a.Git.GetUserIdentity()
+	if err != nil {
+		fmt.Printf("\033[31mAuthor:    unavailable (%v)\033[0m\n", err)
+	} else {
+		fmt.Printf("Author:    %s\n", identity)
+		fmt.Printf("Committer: %s\n", identity)
+	}
+
+	fmt.Println()
+	for _, line := range strings.Split(a.finalizeMessage(message), "\n") {
+		fmt.Println(strings.TrimRight("    "+line, " "))
+	}
+
+	fmt.Println()
+	writeFiles(os.Stdout, files)
+	fmt.Println("==========================")
+	return nil
+}
+
+// showFiles prints the files the commit will contain so accidentally staged
+// ones are caught before committing. Listing them is a courtesy, so a
+// failure is only a warning.
+func (a *App) showFiles(w io.Writer, opts RunOptions) {
+	files, err := a.changedFiles(opts)
+	if err != nil {
+		fmt.Fprintf(w, "Warning: failed to list changed files: %v\n", err)
+		return
+	}
+	fmt.Fprintln(w)
+	writeFiles(w, files)
+}
+
+// writeFiles prints files, sorted by path, one per line with their change
+// type
+func writeFiles(w io.Writer, files []git.StagedFile) {
+	fmt.Fprintf(w, "Files (%d):\n", len(files))
+	for _, file := range files {
+		note := ""
+		if file.Excluded {
+			note = "  (not described, excluded by path filters)"
+		}
+		fmt.Fprintf(w, "  %s  %s%s\n", file.Change, file.Path, note)
+	}
+}
@@ -0,0 +1,224 @@
+package app
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"ai-commit-message-generator/internal/ai"
+	"ai-commit-message-generator/internal/git"
+)
+
+// captureStdout returns everything fn writes to os.Stdout
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	return captureFile(t, &os.Stdout, fn)
+}
+
+// captureStderr returns everything fn writes to os.Stderr
+func captureStderr(t *testing.T, fn func()) string {
+	t.Helper()
+	return captureFile(t, &os.Stderr, fn)
+}
+
+// captureFile returns everything fn writes to *f
+func captureFile(t *testing.T, f **os.File, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("failed to create pipe: %v", err)
+	}
+	original := *f
+	*f = w
+	defer func() { *f = original }()
+
+	done := make(chan string)
+	go func() {
+		var buf bytes.Buffer
+		io.Copy(&buf, r)
+		done <- buf.String()
+	}()
+
+	fn()
+	w.Close()
+	return <-done
+}
+
+func TestApp_Run_Preview(t *testing.T) {
+	tests := []struct {
+		name             string
+		response         string
+		identityErr      error
+		expectedOutput   []string
+		unexpectedOutput []string
+	}{
+		{
+			name:     "Shows message, identity and files",
+			response: "feat(auth): add login\n\nAdds the login form.\n",
+			expectedOutput: []string{
+				"nothing has been committed",
+				"Author:    Test User <test@example.com>",
+				"Committer: Test User <test@example.com>",
+				"    feat(auth): add login\n\n    Adds the login form.\n",
+				"Files (2):",
+				"  A  internal/auth/login.go\n",
+				"  M  go.sum  (not described, excluded by path filters)",
+			},
+		},
+		{
+			name:           "Missing identity is reported",
+			response:       "feat(auth): add login",
+			identityErr:    errors.New("git user name is not configured"),
+			expectedOutput: []string{"Author:    unavailable (git user name is not configured)", "    feat(auth): add login"},
+		},
+		{
+			name:             "Split suggestion creates no commit",
+			response:         "This should be split into separate commits",
+			expectedOutput:   []string{"No commit would be created"},
+			unexpectedOutput: []string{"Author:"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			committed := false
+			mockGit := &MockGit{
+				IsInsideRepoFunc:     func() (bool, error) { return true, nil },
+				HasStagedChangesFunc: func() (bool, error) { return true, nil },
+				GetStagedDiffFunc:    func() (string, error) { return "diff", nil },
+				GetStagedFilesFunc: func() ([]git.StagedFile, error) {
+					return []git.StagedFile{
+						{Path: "go.sum", Change: git.ChangeModified, Excluded: true},
+						{Path: "internal/auth/login.go", Change: git.ChangeAdded},
+					}, nil
+				},
+				CommitWithMessageFunc: func(message string) error {
+					committed = true
+					return nil
+				},
+			}
+			if tt.identityErr != nil {
+				mockGit.GetUserIdentityFunc = func() (*git.Identity, error) { return nil, tt.identityErr }
+			}
+			mockConfig := &MockConfig{
+				LoadRulesFunc: func() (string, error) { return "", nil },
+			}
+			mockAI := &MockAI{
+				GenerateCommitMessageFunc: func(req ai.CommitRequest) (string, error) {
+					return tt.response, nil
+				},
+			}
+			application := NewApp(mockGit, mockConfig, nil, mockAI)
+
+			var err error
+			output := captureStdout(t, func() {
+				err = application.Run(RunOptions{Preview: true})
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if committed {
+				t.Error("preview must not create a commit")
+			}
+			for _, want := range tt.expectedOutput {
+				if !strings.Contains(output, want) {
+					t.Errorf("expected output to contain %q, got:\n%s", want, output)
+				}
+			}
+			for _, unwanted := range tt.unexpectedOutput {
+				if strings.Contains(output, unwanted) {
+					t.Errorf("expected output not to contain %q, got:\n%s", unwanted, output)
+				}
+			}
+		})
+	}
+}
+
+func TestApp_Run_PreviewRejectsInteractive(t *testing.T) {
+	application := NewApp(&MockGit{}, &MockConfig{}, nil, &MockAI{})
+	application.Terminal = &Terminal{In: strings.NewReader(""), Out: io.Discard}
+
+	err := application.Run(RunOptions{Interactive: true, Preview: true})
+	if err == nil || !strings.Contains(err.Error(), "cannot be combined") {
+		t.Errorf("expected combination error, got %v", err)
+	}
+}
+
+func TestApp_Run_ShowFiles(t *testing.T) {
+	tests := []struct {
+		name          string
+		opts          RunOptions
+		expectedFiles bool
+	}{
+		{name: "Hidden by default", opts: RunOptions{}},
+		{name: "Shown with ShowFiles", opts: RunOptions{ShowFiles: true}, expectedFiles: true},
+		{name: "Shown before committing with Yes", opts: RunOptions{ShowFiles: true, Yes: true}, expectedFiles: true},
+		{name: "Always shown when interactive", opts: RunOptions{Interactive: true}, expectedFiles: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeTTY(t)
+			mockGit := &MockGit{
+				IsInsideRepoFunc:     func() (bool, error) { return true, nil },
+				HasStagedChangesFunc: func() (bool, error) { return true, nil },
+				GetStagedDiffFunc:    func() (string, error) { return "diff", nil },
+				GetStagedFilesFunc: func() ([]git.StagedFile, error) {
+					return []git.StagedFile{
+						{Path: ".env", Change: git.ChangeAdded},
+						{Path: "internal/auth/login.go", Change: git.ChangeModified},
+						{Path: "old.go", Change: git.ChangeDeleted},
+					}, nil
+				},
+				CommitWithMessageFunc: func(message string) error { return nil },
+			}
+			mockConfig := &MockConfig{
+				LoadRulesFunc: func() (string, error) { return "", nil },
+			}
+			mockAI := &MockAI{
+				GenerateCommitMessageFunc: func(req ai.CommitRequest) (string, error) {
+					return "feat(auth): added login", nil
+				},
+			}
+			application := NewApp(mockGit, mockConfig, nil, mockAI)
+			terminal := &bytes.Buffer{}
+			application.Terminal = &Terminal{In: strings.NewReader("q\n"), Out: terminal}
+
+			var err error
+			output := captureStdout(t, func() {
+				err = application.Run(tt.opts)
+			})
+			if err != nil && !errors.Is(err, ErrCancelled) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			output += terminal.String()
+
+			list := "Files (3):\n  A  .env\n  M  internal/auth/login.go\n  D  old.go\n"
+			index := strings.Index(output, list)
+			if !tt.expectedFiles {
+				if strings.Contains(output, "Files (") {
+					t.Errorf("expected no file list, got:\n%s", output)
+				}
+				return
+			}
+			if index < 0 {
+				t.Fatalf("expected file list %q, got:\n%s", list, output)
+			}
+			if message := strings.Index(output, "feat(auth): added login"); message < index {
+				t.Errorf("expected the file list above the message, got:\n%s", output)
+			}
+		})
+	}
+}
+
+func TestApp_Run_ShowFilesRejectsStdin(t *testing.T) {
+	application := NewApp(&MockGit{}, &MockConfig{}, nil, &MockAI{})
+
+	err := application.Run(RunOptions{ShowFiles: true, Stdin: strings.NewReader("diff")})
+	if err == nil || !strings.Contains(err.Error(), "cannot be combined") {
+		t.Errorf("expected combination error, got %v", err)
+	}
+}
@@ -0,0 +1,144 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"ai-commit-message-generator/internal/ai"
+)
+
+// PhaseReadingDiff is reported while the staged changes are gathered; the
+// later phases come from the AI client
+const PhaseReadingDiff = "reading diff"
+
+// spinnerFrames are drawn in turn on the progress line
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// spinnerInterval is how often the progress line is redrawn
+const spinnerInterval = 100 * time.Millisecond
+
+// Progress draws a single self-updating line with a spinner, the current
+// phase and the elapsed time. It is meant for stderr on a TTY; a disabled
+// or nil Progress writes nothing, so hooks and pipes never see its frames.
+type Progress struct {
+	out     io.Writer
+	enabled bool
+	timeout time.Duration
+
+	mu         sync.Mutex
+	phase      string
+	phaseStart time.Time
+	tokens     int
+	start      time.Time
+	frame      int
+	stop       chan struct{}
+	stopped    chan struct{}
+}
+
+// NewProgress returns a Progress drawing on out when enabled
+func NewProgress(out io.Writer, enabled bool) *Progress {
+	return &Progress{out: out, enabled: enabled}
+}
+
+// SetTimeout shows how long the model has left to answer while waiting for
+// it, so a slow request is not mistaken for a hang
+func (p *Progress) SetTimeout(timeout time.Duration) {
+	if p != nil {
+		p.timeout = timeout
+	}
+}
+
+// Enabled reports whether the progress line is drawn at all
+func (p *Progress) Enabled() bool {
+	return p != nil && p.enabled
+}
+
+// Phase shows name as the current phase, starting the line if it is not
+// running yet
+func (p *Progress) Phase(name string) {
+	if !p.Enabled() {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if name != p.phase {
+		p.phaseStart = time.Now()
+	}
+	p.phase = name
+	if p.stop == nil {
+		p.start = time.Now()
+		p.tokens = 0
+		p.stop = make(chan struct{})
+		p.stopped = make(chan struct{})
+		go p.run(p.stop, p.stopped)
+	}
+	p.draw()
+}
+
+// Tokens shows the number of tokens streamed so far instead of the spinner
+func (p *Progress) Tokens(count int) {
+	if !p.Enabled() {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.tokens = count
+}
+
+// Stop clears the line. The next Phase starts it again with a fresh clock.
+func (p *Progress) Stop() {
+	if !p.Enabled() {
+		return
+	}
+	p.mu.Lock()
+	stop, stopped := p.stop, p.stopped
+	p.stop = nil
+	p.phase = ""
+	p.mu.Unlock()
+	if stop == nil {
+		return
+	}
+
+	close(stop)
+	<-stopped
+	fmt.Fprint(p.out, "\r\033[K")
+}
+
+// run redraws the line until stop is closed
+func (p *Progress) run(stop, stopped chan struct{}) {
+	defer close(stopped)
+	ticker := time.NewTicker(spinnerInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			p.mu.Lock()
+			p.frame++
+			p.draw()
+			p.mu.Unlock()
+		}
+	}
+}
+
+// draw renders the line; p.mu must be held
+func (p *Progress) draw() {
+	elapsed := time.Since(p.start).Truncate(time.Second).String()
+	if p.phase == ai.PhaseWaiting && p.timeout > 0 {
+		left := p.timeout - time.Since(p.phaseStart)
+		if left < 0 {
+			left = 0
+		}
+		elapsed += fmt.Sprintf(", times out in %s", left.Round(time.Second))
+	}
+	if p.tokens > 0 {
+		fmt.Fprintf(p.out, "\r\033[K%s: %d tokens (%s)", p.phase, p.tokens, elapsed)
+		return
+	}
+	frame := spinnerFrames[p.frame%len(spinnerFrames)]
+	fmt.Fprintf(p.out, "\r\033[K%s %s (%s)", frame, p.phase, elapsed)
+}
@@ -0,0 +1,141 @@
+package app
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"ai-commit-message-generator/internal/ai"
+)
+
+func TestProgress(t *testing.T) {
+	tests := []struct {
+		name             string
+		enabled          bool
+		tokens           int
+		timeout          time.Duration
+		expectedContains []string
+	}{
+		{
+			name:    "Disabled writes nothing",
+			enabled: false,
+		},
+		{
+			name:             "Spinner shows the phase and elapsed time",
+			enabled:          true,
+			expectedContains: []string{spinnerFrames[0] + " " + ai.PhaseWaiting + " (0s)"},
+		},
+		{
+			name:             "Waiting shows the time left before the timeout",
+			enabled:          true,
+			timeout:          time.Minute,
+			expectedContains: []string{ai.PhaseWaiting + " (0s, times out in 1m0s)"},
+		},
+		{
+			name:             "Streaming shows a token counter",
+			enabled:          true,
+			tokens:           42,
+			expectedContains: []string{ai.PhasePostProcessing + ": 42 tokens"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			progress := NewProgress(&out, tt.enabled)
+			progress.SetTimeout(tt.timeout)
+			progress.Phase(ai.PhaseWaiting)
+			if tt.tokens > 0 {
+				progress.Tokens(tt.tokens)
+			}
+			progress.Phase(ai.PhasePostProcessing)
+			progress.Stop()
+			progress.Stop()
+
+			if !tt.enabled {
+				if out.Len() != 0 {
+					t.Errorf("expected no output, got %q", out.String())
+				}
+				return
+			}
+			for _, want := range tt.expectedContains {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("expected %q in %q", want, out.String())
+				}
+			}
+			if !strings.HasSuffix(out.String(), "\r\033[K") {
+				t.Errorf("expected the line to be cleared last, got %q", out.String())
+			}
+		})
+	}
+}
+
+func TestProgress_Nil(t *testing.T) {
+	var progress *Progress
+	progress.Phase(ai.PhaseWaiting)
+	progress.Tokens(1)
+	progress.Stop()
+	if progress.Enabled() {
+		t.Error("expected a nil progress to be disabled")
+	}
+}
+
+func TestApp_Run_Progress(t *testing.T) {
+	tests := []struct {
+		name           string
+		progress       bool
+		quiet          bool
+		expectedStdout string
+	}{
+		{
+			name:           "Without a spinner the status line is printed",
+			expectedStdout: "Generating commit message...\n\n\033[36mfeat: added login\033[0m\n",
+		},
+		{
+			name:           "The spinner replaces the status line",
+			progress:       true,
+			expectedStdout: "\n\033[36mfeat: added login\033[0m\n",
+		},
+		{
+			name:           "Quiet prints only the message",
+			quiet:          true,
+			expectedStdout: "feat: added login\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stderr bytes.Buffer
+			mockAI := &MockAI{
+				GenerateCommitMessageFunc: func(req ai.CommitRequest) (string, error) {
+					return "feat: added login", nil
+				},
+			}
+			var committed []string
+			application, _ := newInteractiveApp(t, "", mockAI, &committed)
+			application.Progress = NewProgress(&stderr, tt.progress)
+			application.Quiet = tt.quiet
+
+			var err error
+			stdout := captureStdout(t, func() {
+				err = application.Run(RunOptions{})
+			})
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if stdout != tt.expectedStdout {
+				t.Errorf("expected stdout %q, got %q", tt.expectedStdout, stdout)
+			}
+			if strings.Contains(stdout, "\r") || strings.Contains(stdout, PhaseReadingDiff) {
+				t.Errorf("expected no spinner bytes on stdout, got %q", stdout)
+			}
+			if tt.progress && !strings.Contains(stderr.String(), PhaseReadingDiff) {
+				t.Errorf("expected the spinner on stderr, got %q", stderr.String())
+			}
+			if !tt.progress && stderr.Len() != 0 {
+				t.Errorf("expected a disabled spinner to be silent, got %q", stderr.String())
+			}
+		})
+	}
+}
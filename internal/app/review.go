@@ -0,0 +1,102 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"ai-commit-message-generator/internal/ai"
+)
+
+// ReviewOptions controls Review
+type ReviewOptions struct {
+	// FailOn makes Review fail when a finding is at least this severe:
+	// bug, issue or nit (plurals are accepted). Empty never fails.
+	FailOn string
+}
+
+// severityColors color the severity labels of review findings
+var severityColors = map[string]string{
+	ai.SeverityBug:   "\033[31m",
+	ai.SeverityIssue: "\033[33m",
+	ai.SeverityNit:   "\033[2m",
+}
+
+// Review asks the model for a short review of the staged changes and prints
+// its findings, most severe first. The diff is read exactly as for a commit
+// message. With FailOn it returns an error when a finding is that severe or
+// worse, so it can gate a pre-commit hook.
+func (a *App) Review(opts ReviewOptions) error {
+	failOn, err := parseSeverity(opts.FailOn)
+	if err != nil {
+		return err
+	}
+
+	defer a.Progress.Stop()
+	a.Progress.Phase(PhaseReadingDiff)
+	req, err := a.prepareRequest(RunOptions{})
+	if err != nil {
+		return err
+	}
+
+	if !a.Quiet && !a.Progress.Enabled() {
+		fmt.Fprintln(os.Stderr, "Reviewing the staged changes...")
+	}
+	findings, err := a.AI.ReviewChanges(req)
+	a.Progress.Stop()
+	if err != nil {
+		return fmt.Errorf("failed to review the changes: %w", classifyAIError(err))
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		return ai.SeverityRank(findings[i].Severity) < ai.SeverityRank(findings[j].Severity)
+	})
+
+	if len(findings) == 0 {
+		fmt.Println("AI review: no findings.")
+	} else {
+		fmt.Println("AI review suggestions (generated by a model, they may be wrong):")
+		for _, f := range findings {
+			fmt.Printf("- %s[%s]\033[0m %s\n", severityColors[f.Severity], f.Severity, findingText(f))
+		}
+	}
+
+	if failOn == "" {
+		return nil
+	}
+	failing := 0
+	for _, f := range findings {
+		if ai.SeverityRank(f.Severity) <= ai.SeverityRank(failOn) {
+			failing++
+		}
+	}
+	if failing > 0 {
+		return fmt.Errorf("the AI review reported %s of severity %s or worse", countOf(failing, "finding"), failOn)
+	}
+	return nil
+}
+
+// parseSeverity checks a --fail-on value and returns its severity. "issues"
+// reads better on a command line than "issue", so plurals are accepted.
+func parseSeverity(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	severity := strings.TrimSuffix(strings.ToLower(value), "s")
+	if ai.SeverityRank(severity) < 0 {
+		return "", fmt.Errorf("--fail-on must be one of %s, got %q", strings.Join(ai.Severities, ", "), value)
+	}
+	return severity, nil
+}
+
+// findingText is a finding's message prefixed with its file:line, or its
+// file when the line is unknown
+func findingText(f ai.ReviewFinding) string {
+	switch {
+	case f.File != "" && f.Line > 0:
+		return fmt.Sprintf("%s:%d: %s", f.File, f.Line, f.Message)
+	case f.File != "":
+		return f.File + ": " + f.Message
+	}
+	return f.Message
+}
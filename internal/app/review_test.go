@@ -0,0 +1,101 @@
+package app
+
+import (
+	"strings"
+	"testing"
+
+	"ai-commit-message-generator/internal/ai"
+)
+
+func TestApp_Review(t *testing.T) {
+	findings := []ai.ReviewFinding{
+		{Severity: ai.SeverityNit, Message: "The name could be clearer."},
+		{Severity: ai.SeverityIssue, File: "login.go", Line: 12, Message: "Debug print left in."},
+		{Severity: ai.SeverityIssue, File: "login.go", Message: "No test covers the new error path."},
+	}
+
+	tests := []struct {
+		name           string
+		failOn         string
+		findings       []ai.ReviewFinding
+		expectedOutput string
+		expectedError  string
+	}{
+		{
+			name:     "Findings are labeled and sorted",
+			findings: findings,
+			expectedOutput: "AI review suggestions (generated by a model, they may be wrong):\n" +
+				"- \033[33m[issue]\033[0m login.go:12: Debug print left in.\n" +
+				"- \033[33m[issue]\033[0m login.go: No test covers the new error path.\n" +
+				"- \033[2m[nit]\033[0m The name could be clearer.\n",
+		},
+		{
+			name:          "Fails on issues",
+			failOn:        "issues",
+			findings:      findings,
+			expectedError: "the AI review reported 2 findings of severity issue or worse",
+		},
+		{
+			name:          "Fails on nits",
+			failOn:        "nit",
+			findings:      findings,
+			expectedError: "the AI review reported 3 findings of severity nit or worse",
+		},
+		{
+			name:           "Passes when nothing is severe enough",
+			failOn:         "bugs",
+			findings:       findings,
+			expectedOutput: "[nit]",
+		},
+		{
+			name:           "No findings",
+			failOn:         "nit",
+			expectedOutput: "AI review: no findings.\n",
+		},
+		{
+			name:          "Unknown severity",
+			failOn:        "blocker",
+			expectedError: `--fail-on must be one of bug, issue, nit, got "blocker"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockGit := &MockGit{
+				IsInsideRepoFunc:     func() (bool, error) { return true, nil },
+				HasStagedChangesFunc: func() (bool, error) { return true, nil },
+				GetStagedDiffFunc:    func() (string, error) { return "diff --git a/login.go b/login.go", nil },
+			}
+			mockConfig := &MockConfig{
+				LoadRulesFunc: func() (string, error) { return "", nil },
+			}
+			mockAI := &MockAI{
+				ReviewChangesFunc: func(req ai.CommitRequest) ([]ai.ReviewFinding, error) {
+					if req.Diff != "diff --git a/login.go b/login.go" {
+						t.Errorf("expected the staged diff, got %q", req.Diff)
+					}
+					return append([]ai.ReviewFinding(nil), tt.findings...), nil
+				},
+			}
+			application := NewApp(mockGit, mockConfig, nil, mockAI)
+			application.Quiet = true
+
+			var err error
+			output := captureStdout(t, func() {
+				err = application.Review(ReviewOptions{FailOn: tt.failOn})
+			})
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("expected error containing %q, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Review failed: %v", err)
+			}
+			if !strings.Contains(output, tt.expectedOutput) {
+				t.Errorf("expected output to contain %q, got %q", tt.expectedOutput, output)
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"ai-commit-message-generator/internal/ai"
+	"ai-commit-message-generator/internal/git"
+	"ai-commit-message-generator/internal/logging"
+)
+
+// RewordOptions controls Reword
+type RewordOptions struct {
+	// Range selects the commits, e.g. main..HEAD. With Apply it must end
+	// at HEAD.
+	Range string
+	// Apply rewrites the commits with the new messages instead of only
+	// printing them
+	Apply bool
+	// Force applies the messages even to commits that are already on a
+	// protected branch or a remote
+	Force bool
+}
+
+// defaultProtectedBranches are used when App.ProtectedBranches is empty
+var defaultProtectedBranches = []string{"main", "master"}
+
+// rewordFeedback turns the commit prompt into a request to improve the
+// message of a commit that already exists
+const rewordFeedback = "This is the current message of an existing commit, which cannot be split. Rewrite it as a single Conventional Commits message for this diff, keeping any intent it states that the diff does not show."
+
+// rewordEntry is one commit of a Reword run and the message it gets
+type rewordEntry struct {
+	commit  git.LogCommit
+	message string
+	// note explains why the message was kept, if it was
+	note string
+}
+
+// Reword generates a new message for every commit in a range and prints
+// the old and new messages side by side. With opts.Apply the commits are
+// rewritten; commits that are already published are refused unless
+// opts.Force is set.
+func (a *App) Reword(opts RewordOptions) error {
+	if _, _, err := git.SplitRange(opts.Range); err != nil {
+		return err
+	}
+	isRepo, err := a.Git.IsInsideRepo()
+	if err != nil {
+		return fmt.Errorf("failed to check repository status: %w", err)
+	}
+	if !isRepo {
+		return ErrNotARepo
+	}
+
+	defer a.Progress.Stop()
+	a.Progress.Phase(PhaseReadingDiff)
+	commits, err := a.Git.GetCommitRange(opts.Range)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", opts.Range, err)
+	}
+	if len(commits) == 0 {
+		return fmt.Errorf("no commits in %s", opts.Range)
+	}
+
+	// Refuse before asking the model for anything
+	if opts.Apply {
+		if state, err := a.Git.DetectState(); err == nil && state.Type != git.StateNormal {
+			return fmt.Errorf("a %s is in progress; finish or abort it before rewording", state.Type)
+		}
+		if err := a.checkPublished(commits, opts); err != nil {
+			return err
+		}
+	}
+
+	rules, err := a.RulesLoader.LoadRules(nil)
+	if err != nil {
+		a.log().Warn("failed to load rules, proceeding without them", logging.KeyStage, "rules", logging.KeyError, err)
+	}
+
+	// Oldest first, the order the commits were made in
+	var entries []rewordEntry
+	for i := len(commits) - 1; i >= 0; i-- {
+		entry, err := a.rewordCommit(commits[i], rules)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, entry)
+	}
+	a.Progress.Stop()
+
+	printRewordMapping(entries)
+	messages := make(map[string]string)
+	for _, entry := range entries {
+		if entry.note == "" {
+			messages[entry.commit.Hash] = entry.message
+		}
+	}
+	if !opts.Apply {
+		a.status("\nNothing was changed. Run with --apply to rewrite these commits.")
+		return nil
+	}
+	if len(messages) == 0 {
+		a.status("\nEvery message is unchanged; nothing to rewrite.")
+		return nil
+	}
+
+	oldHead, newHead, err := a.Git.RewordCommits(opts.Range, messages)
+	if err != nil {
+		return fmt.Errorf("failed to rewrite %s: %w", opts.Range, err)
+	}
+	fmt.Printf("\n\033[32m✓ Reworded %d of %d commits\033[0m\n", len(messages), len(entries))
+	fmt.Printf("HEAD moved from %s to %s. Undo with: git reset --soft %s\n", shortHash(oldHead), shortHash(newHead), oldHead)
+	return nil
+}
+
+// rewordCommit generates the new message of one commit. Merge commits,
+// empty commits and commits the model wants to split keep their message.
+func (a *App) rewordCommit(commit git.LogCommit, rules string) (rewordEntry, error) {
+	entry := rewordEntry{commit: commit, message: a.finalizeMessage(commit.Message)}
+	if commit.Merge {
+		entry.note = "merge commit, kept"
+		return entry, nil
+	}
+
+	a.Progress.Phase(PhaseReadingDiff)
+	diff, err := a.Git.GetCommitDiff(commit.Hash)
+	if err != nil {
+		return entry, fmt.Errorf("failed to get the diff of %s: %w", shortHash(commit.Hash), err)
+	}
+	if strings.TrimSpace(diff) == "" {
+		entry.note = "no changes, kept"
+		return entry, nil
+	}
+
+	a.status(fmt.Sprintf("Generating message for %s...", shortHash(commit.Hash)))
+	req := ai.CommitRequest{
+		Diff:            diff,
+		Rules:           rules,
+		PreviousMessage: commit.Message,
+		Feedback:        rewordFeedback,
+	}
+	// Each commit is reworded in the style of the area it changed
+	_, files := git.ParseDiff(diff, git.DiffOptions{})
+	a.applyStyle(&req, a.pathOverride(files))
+	message, err := a.generateMessage(req)
+	if err != nil {
+		return entry, fmt.Errorf("failed to generate a message for %s: %w", shortHash(commit.Hash), err)
+	}
+	switch message = a.finalizeMessage(message); {
+	case a.suggestsSplit(message):
+		entry.note = "the model suggested a split, kept"
+	case message == entry.message:
+		entry.note = "unchanged"
+	default:
+		entry.message = message
+	}
+	return entry, nil
+}
+
+// checkPublished refuses to rewrite commits that can be reached from a
+// remote-tracking branch or a protected branch, unless opts.Force is set
+func (a *App) checkPublished(commits []git.LogCommit, opts RewordOptions) error {
+	protected := a.ProtectedBranches
+	if len(protected) == 0 {
+		protected = defaultProtectedBranches
+	}
+	hashes := make([]string, len(commits))
+	for i, commit := range commits {
+		hashes[i] = commit.Hash
+	}
+	published, err := a.Git.FindPublished(hashes, protected)
+	if err != nil {
+		return fmt.Errorf("failed to check which commits are published: %w", err)
+	}
+	if len(published) == 0 {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	var branches []string
+	for _, branch := range published {
+		if !seen[branch] {
+			seen[branch] = true
+			branches = append(branches, branch)
+		}
+	}
+	sort.Strings(branches)
+	if opts.Force {
+		fmt.Fprintf(os.Stderr, "\033[33mWarning: rewriting %d commits that are already on %s (--force)\033[0m\n", len(published), strings.Join(branches, ", "))
+		return nil
+	}
+	return fmt.Errorf("%d of the commits in %s are already on %s; rewording them would rewrite shared history. Pass --force to do it anyway", len(published), opts.Range, strings.Join(branches, ", "))
+}
+
+// printRewordMapping prints each commit's current subject and the message
+// it gets
+func printRewordMapping(entries []rewordEntry) {
+	fmt.Println()
+	for _, entry := range entries {
+		hash := shortHash(entry.commit.Hash)
+		if entry.note != "" {
+			fmt.Printf("%s  %s  (%s)\n", hash, entry.commit.Subject(), entry.note)
+			continue
+		}
+		fmt.Printf("%s  %s\n", hash, entry.commit.Subject())
+		lines := strings.Split(entry.message, "\n")
+		fmt.Printf("%s→  \033[36m%s\033[0m\n", strings.Repeat(" ", len(hash)-1), lines[0])
+		for _, line := range lines[1:] {
+			fmt.Printf("%s%s\n", strings.Repeat(" ", len(hash)+2), line)
+		}
+	}
+}
+
+// shortHash abbreviates a commit hash to seven characters
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
@@ -0,0 +1,259 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"ai-commit-message-generator/internal/ai"
+	"ai-commit-message-generator/internal/git"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func TestApp_Reword(t *testing.T) {
+	// Newest first, as GetCommitRange returns them
+	commits := []git.LogCommit{
+		{Hash: "dddddddddd", Message: "Merge branch 'main' into topic", Merge: true},
+		{Hash: "cccccccccc", Message: "docs: documented login"},
+		{Hash: "bbbbbbbbbb", Message: "fix stuff"},
+		{Hash: "aaaaaaaaaa", Message: "wip"},
+	}
+	generated := map[string]string{
+		"aaaaaaaaaa": "feat(auth): added login",
+		"bbbbbbbbbb": "fix(auth): handled expired tokens",
+		"cccccccccc": "docs: documented login",
+	}
+
+	tests := []struct {
+		name              string
+		opts              RewordOptions
+		protected         []string
+		published         map[string]string
+		state             git.GitStateType
+		expectedProtected []string
+		expectedMessages  map[string]string
+		expectedOutput    []string
+		expectedError     string
+	}{
+		{
+			name: "Dry run prints the mapping",
+			opts: RewordOptions{Range: "main..HEAD"},
+			expectedOutput: []string{
+				"aaaaaaa  wip\n      →  \033[36mfeat(auth): added login\033[0m\n",
+				"bbbbbbb  fix stuff\n      →  \033[36mfix(auth): handled expired tokens\033[0m\n",
+				"ccccccc  docs: documented login  (unchanged)\n",
+				"ddddddd  Merge branch 'main' into topic  (merge commit, kept)\n",
+			},
+		},
+		{
+			name:              "Apply rewrites the changed messages",
+			opts:              RewordOptions{Range: "main..HEAD", Apply: true},
+			expectedProtected: []string{"main", "master"},
+			expectedMessages: map[string]string{
+				"aaaaaaaaaa": "feat(auth): added login",
+				"bbbbbbbbbb": "fix(auth): handled expired tokens",
+			},
+			expectedOutput: []string{"✓ Reworded 2 of 4 commits", "git reset --soft 0000000000"},
+		},
+		{
+			name:              "Published commits are refused",
+			opts:              RewordOptions{Range: "main..HEAD", Apply: true},
+			published:         map[string]string{"aaaaaaaaaa": "origin/topic", "bbbbbbbbbb": "origin/topic"},
+			expectedProtected: []string{"main", "master"},
+			expectedError:     "2 of the commits in main..HEAD are already on origin/topic",
+		},
+		{
+			name:              "Configured protected branches",
+			opts:              RewordOptions{Range: "v1.0.0..HEAD", Apply: true},
+			protected:         []string{"release/*"},
+			published:         map[string]string{"aaaaaaaaaa": "release/1.0"},
+			expectedProtected: []string{"release/*"},
+			expectedError:     "already on release/1.0",
+		},
+		{
+			name:              "Force rewrites published commits",
+			opts:              RewordOptions{Range: "main..HEAD", Apply: true, Force: true},
+			published:         map[string]string{"aaaaaaaaaa": "main"},
+			expectedProtected: []string{"main", "master"},
+			expectedMessages: map[string]string{
+				"aaaaaaaaaa": "feat(auth): added login",
+				"bbbbbbbbbb": "fix(auth): handled expired tokens",
+			},
+		},
+		{
+			name:          "Not during a rebase",
+			opts:          RewordOptions{Range: "main..HEAD", Apply: true},
+			state:         git.StateRebase,
+			expectedError: "a rebase is in progress",
+		},
+		{
+			name:          "Not a range",
+			opts:          RewordOptions{Range: "HEAD~3"},
+			expectedError: "expected <from>..<to>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotProtected []string
+			var gotMessages map[string]string
+			mockGit := &MockGit{
+				IsInsideRepoFunc:   func() (bool, error) { return true, nil },
+				GetCommitRangeFunc: func(revRange string) ([]git.LogCommit, error) { return commits, nil },
+				DetectStateFunc:    func() (*git.GitState, error) { return &git.GitState{Type: tt.state}, nil },
+				GetCommitDiffFunc: func(hash string) (string, error) {
+					return "diff --git a/" + hash + " b/" + hash, nil
+				},
+				FindPublishedFunc: func(hashes, protected []string) (map[string]string, error) {
+					gotProtected = protected
+					if len(hashes) != len(commits) {
+						t.Errorf("expected every commit to be checked, got %v", hashes)
+					}
+					return tt.published, nil
+				},
+				RewordCommitsFunc: func(revRange string, messages map[string]string) (string, string, error) {
+					gotMessages = messages
+					return "0000000000", "1111111111", nil
+				},
+			}
+			var previous []string
+			mockAI := &MockAI{
+				GenerateCommitMessageFunc: func(req ai.CommitRequest) (string, error) {
+					if tt.expectedError != "" {
+						t.Errorf("expected no generation before the refusal")
+					}
+					if req.Feedback != rewordFeedback {
+						t.Errorf("expected the reword feedback, got %q", req.Feedback)
+					}
+					previous = append(previous, req.PreviousMessage)
+					hash := strings.TrimPrefix(strings.Fields(req.Diff)[2], "a/")
+					return generated[hash], nil
+				},
+			}
+			application := NewApp(mockGit, &MockConfig{LoadRulesFunc: func() (string, error) { return "", nil }}, nil, mockAI)
+			application.ProtectedBranches = tt.protected
+			application.Quiet = true
+
+			var err error
+			output := captureStdout(t, func() {
+				err = application.Reword(tt.opts)
+			})
+			if !reflect.DeepEqual(gotProtected, tt.expectedProtected) {
+				t.Errorf("expected protected branches %q, got %q", tt.expectedProtected, gotProtected)
+			}
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("expected error containing %q, got %v", tt.expectedError, err)
+				}
+				if gotMessages != nil {
+					t.Errorf("expected nothing to be rewritten, got %v", gotMessages)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Reword failed: %v", err)
+			}
+
+			if expected := []string{"wip", "fix stuff", "docs: documented login"}; !reflect.DeepEqual(previous, expected) {
+				t.Errorf("expected the commits oldest first with their messages %q, got %q", expected, previous)
+			}
+			if !reflect.DeepEqual(gotMessages, tt.expectedMessages) {
+				t.Errorf("expected messages %v, got %v", tt.expectedMessages, gotMessages)
+			}
+			for _, want := range tt.expectedOutput {
+				if !strings.Contains(output, want) {
+					t.Errorf("expected output to contain %q, got:\n%s", want, output)
+				}
+			}
+		})
+	}
+}
+
+func TestApp_Reword_Integration(t *testing.T) {
+	client := newRewordTestRepo(t)
+	mockAI := &MockAI{
+		GenerateCommitMessageFunc: func(req ai.CommitRequest) (string, error) {
+			return "feat: improved " + req.PreviousMessage, nil
+		},
+	}
+	application := NewApp(client, &MockConfig{LoadRulesFunc: func() (string, error) { return "", nil }}, nil, mockAI)
+	application.Quiet = true
+
+	var err error
+	captureStdout(t, func() {
+		err = application.Reword(RewordOptions{Range: "main..HEAD", Apply: true})
+	})
+	if err == nil || !strings.Contains(err.Error(), "already on origin/topic") {
+		t.Fatalf("expected the pushed commit to be refused, got %v", err)
+	}
+
+	captureStdout(t, func() {
+		err = application.Reword(RewordOptions{Range: "main..HEAD", Apply: true, Force: true})
+	})
+	if err != nil {
+		t.Fatalf("Reword failed: %v", err)
+	}
+	commits, err := client.GetCommitRange("main..HEAD")
+	if err != nil {
+		t.Fatalf("failed to read the branch: %v", err)
+	}
+	var subjects []string
+	for _, commit := range commits {
+		subjects = append(subjects, commit.Subject())
+	}
+	if expected := []string{"feat: improved fix stuff", "feat: improved wip"}; !reflect.DeepEqual(subjects, expected) {
+		t.Errorf("expected %q, got %q", expected, subjects)
+	}
+}
+
+// newRewordTestRepo creates a repository with a topic branch two commits
+// ahead of main, the first of which was pushed to origin/topic
+func newRewordTestRepo(t *testing.T) git.Client {
+	t.Helper()
+	tempDir := t.TempDir()
+	originalWd, _ := os.Getwd()
+	t.Cleanup(func() { _ = os.Chdir(originalWd) })
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatalf("failed to change to temp dir: %v", err)
+	}
+	repo, err := gogit.PlainInitWithOptions(tempDir, &gogit.PlainInitOptions{
+		InitOptions: gogit.InitOptions{DefaultBranch: plumbing.NewBranchReferenceName("topic")},
+	})
+	if err != nil {
+		t.Fatalf("failed to git init: %v", err)
+	}
+	worktree, _ := repo.Worktree()
+	var hashes []plumbing.Hash
+	for i, message := range []string{"chore: initial commit", "wip", "fix stuff"} {
+		file := fmt.Sprintf("file%d.txt", i)
+		if err := os.WriteFile(filepath.Join(tempDir, file), []byte(message+"\n"), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", file, err)
+		}
+		if _, err := worktree.Add(file); err != nil {
+			t.Fatalf("failed to stage %s: %v", file, err)
+		}
+		hash, err := worktree.Commit(message, &gogit.CommitOptions{
+			Author: &object.Signature{Name: "Test User", Email: "test@example.com", When: time.Date(2026, 1, 1, 0, i, 0, 0, time.UTC)},
+		})
+		if err != nil {
+			t.Fatalf("failed to commit: %v", err)
+		}
+		hashes = append(hashes, hash)
+	}
+	for name, hash := range map[plumbing.ReferenceName]plumbing.Hash{
+		plumbing.NewBranchReferenceName("main"):            hashes[0],
+		plumbing.NewRemoteReferenceName("origin", "topic"): hashes[1],
+	} {
+		if err := repo.Storer.SetReference(plumbing.NewHashReference(name, hash)); err != nil {
+			t.Fatalf("failed to set %s: %v", name, err)
+		}
+	}
+	return git.NewClient()
+}
@@ -0,0 +1,45 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"ai-commit-message-generator/internal/ai"
+)
+
+// scopeRequiredFeedback asks the model to add the scope it left out
+const scopeRequiredFeedback = "The first line of this message has no scope, but this repository requires one. Rewrite the first line with a scope naming the module, package or area the change touches, laid out as %q. Keep the rest of the message as it is."
+
+// applyScopePolicy enforces the ScopePolicy of req on a generated message.
+// A forbidden scope is dropped from the header. A missing required scope is
+// asked for once more, and a message still without one is an error. Split
+// suggestions and headers that do not follow the HeaderFormat of req are
+// left alone; the commit-msg hook reports those.
+func (a *App) applyScopePolicy(req ai.CommitRequest, message string) (string, error) {
+	if a.suggestsSplit(message) {
+		return message, nil
+	}
+	switch req.ScopePolicy {
+	case ai.ScopeForbidden:
+		return req.HeaderFormat.WithoutScope(message), nil
+	case ai.ScopeRequired:
+		header, _, _ := strings.Cut(message, "\n")
+		if _, ok := req.HeaderFormat.Parse(header); !ok || req.HeaderFormat.HasScope(message) {
+			return message, nil
+		}
+		a.status("The message has no scope (scope_policy is required); asking for one...")
+		req.PreviousMessage = message
+		req.Feedback = fmt.Sprintf(scopeRequiredFeedback, req.HeaderFormat.Layout())
+		req.Explain = false
+		scoped, err := a.AI.GenerateCommitMessage(req)
+		if err != nil {
+			return "", fmt.Errorf("failed to add a scope to the message: %w", err)
+		}
+		scoped = req.HeaderFormat.Reformat(scoped)
+		if a.suggestsSplit(scoped) || !req.HeaderFormat.HasScope(scoped) {
+			return "", fmt.Errorf("the model wrote no scope although scope_policy is required: %q", header)
+		}
+		return scoped, nil
+	}
+	return message, nil
+}
@@ -0,0 +1,165 @@
+package app
+
+import (
+	"strings"
+	"testing"
+
+	"ai-commit-message-generator/internal/ai"
+	"ai-commit-message-generator/internal/git"
+)
+
+func TestApp_Run_ScopePolicy(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    string
+		files     []string
+		responses []string
+		// expectedFastPath is the header of the fast path sent, if any
+		expectedFastPath string
+		expectedMessage  string
+		expectedCalls    int
+		expectedError    string
+	}{
+		{
+			name:            "Optional keeps the message",
+			policy:          ai.ScopeOptional,
+			files:           []string{"main.go"},
+			responses:       []string{"feat: added login"},
+			expectedMessage: "feat: added login",
+			expectedCalls:   1,
+		},
+		{
+			name:            "Required with a scope",
+			policy:          ai.ScopeRequired,
+			files:           []string{"main.go"},
+			responses:       []string{"feat(auth): added login"},
+			expectedMessage: "feat(auth): added login",
+			expectedCalls:   1,
+		},
+		{
+			name:            "Required regenerates a message without a scope",
+			policy:          ai.ScopeRequired,
+			files:           []string{"main.go"},
+			responses:       []string{"feat: added login\n\nAdded a form.", "feat(auth): added login\n\nAdded a form."},
+			expectedMessage: "feat(auth): added login\n\nAdded a form.",
+			expectedCalls:   2,
+		},
+		{
+			name:          "Required rejects a second message without a scope",
+			policy:        ai.ScopeRequired,
+			files:         []string{"main.go"},
+			responses:     []string{"feat: added login", "feat: added a login"},
+			expectedCalls: 2,
+			expectedError: "the model wrote no scope although scope_policy is required",
+		},
+		{
+			name:            "Required skips the unscoped docs fast path",
+			policy:          ai.ScopeRequired,
+			files:           []string{"README.md"},
+			responses:       []string{"docs(readme): documented flags"},
+			expectedMessage: "docs(readme): documented flags",
+			expectedCalls:   1,
+		},
+		{
+			name:             "Required keeps a scoped fast path",
+			policy:           ai.ScopeRequired,
+			files:            []string{"go.sum"},
+			responses:        []string{"chore(deps): bumped go-git"},
+			expectedFastPath: "chore(deps)",
+			expectedMessage:  "chore(deps): bumped go-git",
+			expectedCalls:    1,
+		},
+		{
+			name:            "Forbidden strips the scope",
+			policy:          ai.ScopeForbidden,
+			files:           []string{"main.go"},
+			responses:       []string{"feat(auth)!: dropped v1 login\n\nBREAKING CHANGE: v1 is gone."},
+			expectedMessage: "feat!: dropped v1 login\n\nBREAKING CHANGE: v1 is gone.",
+			expectedCalls:   1,
+		},
+		{
+			name:             "Forbidden drops the fast path scope",
+			policy:           ai.ScopeForbidden,
+			files:            []string{"go.sum"},
+			responses:        []string{"chore: bumped go-git"},
+			expectedFastPath: "chore",
+			expectedMessage:  "chore: bumped go-git",
+			expectedCalls:    1,
+		},
+		{
+			name:            "Split suggestions are left alone",
+			policy:          ai.ScopeRequired,
+			files:           []string{"main.go"},
+			responses:       []string{"This should be split into separate commits:\n1. auth\n2. ui"},
+			expectedMessage: "This should be split into separate commits:\n1. auth\n2. ui",
+			expectedCalls:   1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockGit := &MockGit{
+				IsInsideRepoFunc:     func() (bool, error) { return true, nil },
+				HasStagedChangesFunc: func() (bool, error) { return true, nil },
+				GetStagedDiffFunc:    func() (string, error) { return "diff", nil },
+				GetStagedFilesFunc: func() ([]git.StagedFile, error) {
+					var files []git.StagedFile
+					for _, p := range tt.files {
+						files = append(files, git.StagedFile{Path: p, Change: git.ChangeModified})
+					}
+					return files, nil
+				},
+				DetectStateFunc: func() (*git.GitState, error) {
+					return &git.GitState{Type: git.StateNormal}, nil
+				},
+			}
+			var requests []ai.CommitRequest
+			mockAI := &MockAI{
+				GenerateCommitMessageFunc: func(req ai.CommitRequest) (string, error) {
+					requests = append(requests, req)
+					return tt.responses[len(requests)-1], nil
+				},
+			}
+			mockConfig := &MockConfig{
+				LoadRulesFunc: func() (string, error) { return "", nil },
+			}
+			application := NewApp(mockGit, mockConfig, nil, mockAI)
+			application.ScopePolicy = tt.policy
+			application.Quiet = true
+
+			var err error
+			stdout := captureStdout(t, func() {
+				err = application.Run(RunOptions{})
+			})
+			if len(requests) != tt.expectedCalls {
+				t.Fatalf("expected %d generations, got %d", tt.expectedCalls, len(requests))
+			}
+			if requests[0].ScopePolicy != tt.policy {
+				t.Errorf("expected scope policy %q in the request, got %q", tt.policy, requests[0].ScopePolicy)
+			}
+			fastPath := ""
+			if requests[0].FastPath != nil {
+				fastPath = requests[0].FastPath.Header()
+			}
+			if fastPath != tt.expectedFastPath {
+				t.Errorf("expected fast path %q, got %q", tt.expectedFastPath, fastPath)
+			}
+			if tt.expectedCalls == 2 && (requests[1].PreviousMessage != tt.responses[0] || !strings.Contains(requests[1].Feedback, "requires one")) {
+				t.Errorf("expected the second request to revise the first message, got %+v", requests[1])
+			}
+
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("expected error containing %q, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if !strings.Contains(stdout, tt.expectedMessage+"\n") {
+				t.Errorf("expected the message %q, got %q", tt.expectedMessage, stdout)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package app
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// semver is a semantic version such as v1.2.3 or 1.3.0-rc.1. Build
+// metadata is ignored, as it is for precedence.
+type semver struct {
+	Major, Minor, Patch int
+	// Pre is the pre-release, e.g. "rc.1", or empty for a release
+	Pre string
+}
+
+// semverPattern matches a version with an optional v prefix
+var semverPattern = regexp.MustCompile(`^v?(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)(?:-([0-9A-Za-z.-]+))?(?:\+[0-9A-Za-z.-]+)?$`)
+
+// parseSemver parses a version, reporting false for anything else
+func parseSemver(s string) (semver, bool) {
+	m := semverPattern.FindStringSubmatch(s)
+	if m == nil {
+		return semver{}, false
+	}
+	v := semver{Pre: m[4]}
+	v.Major, _ = strconv.Atoi(m[1])
+	v.Minor, _ = strconv.Atoi(m[2])
+	v.Patch, _ = strconv.Atoi(m[3])
+	return v, true
+}
+
+// compare returns -1, 0 or 1 as v is lower than, equal to or higher than o,
+// following semver precedence: a pre-release is lower than its release
+func (v semver) compare(o semver) int {
+	for _, d := range []int{v.Major - o.Major, v.Minor - o.Minor, v.Patch - o.Patch} {
+		if d != 0 {
+			return sign(d)
+		}
+	}
+	switch {
+	case v.Pre == o.Pre:
+		return 0
+	case v.Pre == "":
+		return 1
+	case o.Pre == "":
+		return -1
+	}
+
+	a, b := strings.Split(v.Pre, "."), strings.Split(o.Pre, ".")
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if c := comparePreField(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+	return sign(len(a) - len(b))
+}
+
+// comparePreField compares one dot-separated pre-release field. Numeric
+// fields compare numerically and are lower than alphanumeric ones.
+func comparePreField(a, b string) int {
+	x, errA := strconv.Atoi(a)
+	y, errB := strconv.Atoi(b)
+	switch {
+	case errA == nil && errB == nil:
+		return sign(x - y)
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// Version bumps, from none to major
+const (
+	bumpNone  = "none"
+	bumpPatch = "patch"
+	bumpMinor = "minor"
+	bumpMajor = "major"
+)
+
+// bumpRank orders the bumps so the larger of two can be picked
+var bumpRank = map[string]int{bumpNone: 0, bumpPatch: 1, bumpMinor: 2, bumpMajor: 3}
+
+// bump returns the version after a release with the given bump. A
+// pre-release of the version the bump leads to is released as is, so
+// v1.3.0-rc.1 with a minor bump becomes v1.3.0 rather than v1.4.0.
+func (v semver) bump(kind string) semver {
+	pre := v.Pre != ""
+	switch kind {
+	case bumpMajor:
+		if pre && v.Minor == 0 && v.Patch == 0 {
+			return semver{Major: v.Major}
+		}
+		return semver{Major: v.Major + 1}
+	case bumpMinor:
+		if pre && v.Patch == 0 {
+			return semver{Major: v.Major, Minor: v.Minor}
+		}
+		return semver{Major: v.Major, Minor: v.Minor + 1}
+	case bumpPatch:
+		if pre {
+			return semver{Major: v.Major, Minor: v.Minor, Patch: v.Patch}
+		}
+		return semver{Major: v.Major, Minor: v.Minor, Patch: v.Patch + 1}
+	}
+	return v
+}
+
+// format writes v with prefix, e.g. "v" to match the tags it came from
+func (v semver) format(prefix string) string {
+	s := fmt.Sprintf("%s%d.%d.%d", prefix, v.Major, v.Minor, v.Patch)
+	if v.Pre != "" {
+		s += "-" + v.Pre
+	}
+	return s
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
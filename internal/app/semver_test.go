@@ -0,0 +1,73 @@
+package app
+
+import "testing"
+
+func TestSemver_Compare(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"v1.2.3", "1.2.3", 0},
+		{"v1.10.0", "v1.9.0", 1},
+		{"v1.2.3", "v1.2.4", -1},
+		{"v2.0.0", "v1.99.99", 1},
+		{"v1.3.0-rc.1", "v1.3.0", -1},
+		{"v1.3.0-rc.2", "v1.3.0-rc.10", -1},
+		{"v1.3.0-alpha", "v1.3.0-alpha.1", -1},
+		{"v1.3.0-1", "v1.3.0-alpha", -1},
+		{"v1.3.0-beta", "v1.3.0-alpha", 1},
+		{"v1.3.0+build.5", "v1.3.0", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+" vs "+tt.b, func(t *testing.T) {
+			a, okA := parseSemver(tt.a)
+			b, okB := parseSemver(tt.b)
+			if !okA || !okB {
+				t.Fatalf("expected both versions to parse")
+			}
+			if got := a.compare(b); got != tt.expected {
+				t.Errorf("expected %d, got %d", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestParseSemver_Invalid(t *testing.T) {
+	for _, s := range []string{"release-1", "v1.2", "v01.2.3", "1.2.3.4", "latest"} {
+		if _, ok := parseSemver(s); ok {
+			t.Errorf("expected %q not to parse", s)
+		}
+	}
+}
+
+func TestSemver_Bump(t *testing.T) {
+	tests := []struct {
+		version  string
+		bump     string
+		expected string
+	}{
+		{"v1.2.3", bumpMajor, "v2.0.0"},
+		{"v1.2.3", bumpMinor, "v1.3.0"},
+		{"v1.2.3", bumpPatch, "v1.2.4"},
+		{"v1.2.3", bumpNone, "v1.2.3"},
+		{"v1.3.0-rc.1", bumpMinor, "v1.3.0"},
+		{"v1.3.0-rc.1", bumpMajor, "v2.0.0"},
+		{"v2.0.0-rc.1", bumpMajor, "v2.0.0"},
+		{"v1.2.4-rc.1", bumpPatch, "v1.2.4"},
+		{"v1.2.4-rc.1", bumpMinor, "v1.3.0"},
+		{"v1.2.3+build.5", bumpPatch, "v1.2.4"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version+" "+tt.bump, func(t *testing.T) {
+			v, ok := parseSemver(tt.version)
+			if !ok {
+				t.Fatalf("expected %q to parse", tt.version)
+			}
+			if got := v.bump(tt.bump).format("v"); got != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
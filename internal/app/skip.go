@@ -0,0 +1,94 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SkipEnv is the environment variable that bypasses generation for one
+// command, e.g. AI_COMMIT_SKIP=1 git commit
+const SkipEnv = "AI_COMMIT_SKIP"
+
+// offFlagName is the file in the git directory that 'generate-commit off'
+// creates. While it exists the installed hooks do nothing.
+const offFlagName = "ai-commit-off"
+
+// skipRequested reports whether SkipEnv is set to anything but "", "0" or
+// "false"
+func skipRequested() bool {
+	value := strings.TrimSpace(os.Getenv(SkipEnv))
+	return value != "" && value != "0" && !strings.EqualFold(value, "false")
+}
+
+// hookSkipReason returns why the installed hooks should not generate a
+// message, or "" when they should
+func (a *App) hookSkipReason() string {
+	if skipRequested() {
+		return SkipEnv + " is set"
+	}
+	if off, err := a.isOff(); err == nil && off {
+		return "turned off for this repository; run 'generate-commit on' to turn it back on"
+	}
+	return ""
+}
+
+// printSkipped prints the one line that tells the user why nothing was
+// generated
+func printSkipped(reason string) {
+	fmt.Fprintf(os.Stderr, "generate-commit: skipped, %s\n", reason)
+}
+
+// offFlagPath returns the path of the flag file that 'generate-commit off'
+// creates
+func (a *App) offFlagPath() (string, error) {
+	gitDir, err := a.Git.GetGitDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get git directory: %w", err)
+	}
+	return filepath.Join(gitDir, offFlagName), nil
+}
+
+// isOff reports whether 'generate-commit off' was run in this repository
+func (a *App) isOff() (bool, error) {
+	path, err := a.offFlagPath()
+	if err != nil {
+		return false, err
+	}
+	_, err = os.Stat(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// SetEnabled turns the installed hooks on or off for this repository. Off
+// records a flag in the git directory, so it is never committed and applies
+// to this clone only. Running generate-commit directly still works.
+func (a *App) SetEnabled(enabled bool) error {
+	isRepo, err := a.Git.IsInsideRepo()
+	if err != nil {
+		return fmt.Errorf("failed to check repository status: %w", err)
+	}
+	if !isRepo {
+		return fmt.Errorf("%w. Please run this command from within a git repository", ErrNotARepo)
+	}
+
+	path, err := a.offFlagPath()
+	if err != nil {
+		return err
+	}
+	if enabled {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to turn generation back on: %w", err)
+		}
+		fmt.Println("The hooks generate commit messages again in this repository.")
+		return nil
+	}
+	if err := os.WriteFile(path, []byte("Created by 'generate-commit off'. Run 'generate-commit on' to remove it.\n"), 0644); err != nil {
+		return fmt.Errorf("failed to turn generation off: %w", err)
+	}
+	fmt.Println("The hooks no longer generate commit messages in this repository. Run 'generate-commit on' to turn them back on.")
+	return nil
+}
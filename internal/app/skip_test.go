@@ -0,0 +1,202 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ai-commit-message-generator/internal/ai"
+	"ai-commit-message-generator/internal/git"
+)
+
+// newSkipTestApp returns an App over a repository whose git directory is
+// gitDir, and a pointer that records whether the model was asked
+func newSkipTestApp(gitDir string) (*App, *bool) {
+	generated := false
+	mockGit := &MockGit{
+		IsInsideRepoFunc:     func() (bool, error) { return true, nil },
+		HasStagedChangesFunc: func() (bool, error) { return true, nil },
+		GetStagedDiffFunc:    func() (string, error) { return "diff", nil },
+		GetGitDirFunc:        func() (string, error) { return gitDir, nil },
+		DetectStateFunc: func() (*git.GitState, error) {
+			return &git.GitState{Type: git.StateNormal}, nil
+		},
+	}
+	mockConfig := &MockConfig{
+		LoadRulesFunc: func() (string, error) { return "", nil },
+	}
+	mockAI := &MockAI{
+		GenerateCommitMessageFunc: func(req ai.CommitRequest) (string, error) {
+			generated = true
+			return "feat: added login", nil
+		},
+	}
+	return NewApp(mockGit, mockConfig, nil, mockAI), &generated
+}
+
+func TestSkipRequested(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected bool
+	}{
+		{"", false},
+		{"0", false},
+		{"false", false},
+		{"FALSE", false},
+		{"1", true},
+		{"yes", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Setenv(SkipEnv, tt.value)
+			if got := skipRequested(); got != tt.expected {
+				t.Errorf("expected %v for %q, got %v", tt.expected, tt.value, got)
+			}
+		})
+	}
+}
+
+func TestApp_SkipEnv(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(a *App, msgFile string) error
+	}{
+		{
+			name: "Run",
+			run:  func(a *App, msgFile string) error { return a.Run(RunOptions{}) },
+		},
+		{
+			name: "pre-commit hook",
+			run:  func(a *App, msgFile string) error { return a.PreCommitHook() },
+		},
+		{
+			name: "prepare-commit-msg hook",
+			run:  func(a *App, msgFile string) error { return a.PrepareCommitMsgHook(msgFile, "", "") },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(SkipEnv, "1")
+			dir := t.TempDir()
+			msgFile := filepath.Join(dir, "COMMIT_EDITMSG")
+			if err := os.WriteFile(msgFile, []byte("\n# comments\n"), 0644); err != nil {
+				t.Fatalf("failed to write message file: %v", err)
+			}
+			application, generated := newSkipTestApp(dir)
+
+			var err error
+			stderr := captureStderr(t, func() {
+				captureStdout(t, func() {
+					err = tt.run(application, msgFile)
+				})
+			})
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if *generated {
+				t.Error("expected no message to be generated")
+			}
+			if stderr != "generate-commit: skipped, AI_COMMIT_SKIP is set\n" {
+				t.Errorf("expected one skip line, got %q", stderr)
+			}
+			content, _ := os.ReadFile(msgFile)
+			if string(content) != "\n# comments\n" {
+				t.Errorf("expected the message file untouched, got %q", string(content))
+			}
+		})
+	}
+}
+
+func TestApp_SetEnabled(t *testing.T) {
+	gitDir := t.TempDir()
+	msgFile := filepath.Join(t.TempDir(), "COMMIT_EDITMSG")
+	application, generated := newSkipTestApp(gitDir)
+
+	captureStdout(t, func() {
+		if err := application.SetEnabled(false); err != nil {
+			t.Fatalf("failed to turn off: %v", err)
+		}
+	})
+	if _, err := os.Stat(filepath.Join(gitDir, offFlagName)); err != nil {
+		t.Fatalf("expected the flag file in the git directory: %v", err)
+	}
+
+	stderr := captureStderr(t, func() {
+		if err := application.PrepareCommitMsgHook(msgFile, "", ""); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := application.PreCommitHook(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	if *generated {
+		t.Error("expected the hooks to do nothing while off")
+	}
+	if strings.Count(stderr, "generate-commit: skipped, turned off for this repository") != 2 {
+		t.Errorf("expected a skip line per hook, got %q", stderr)
+	}
+
+	// Running the command directly still works while off
+	captureStdout(t, func() {
+		if err := application.Run(RunOptions{}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	if !*generated {
+		t.Error("expected a direct run to generate a message while off")
+	}
+
+	*generated = false
+	captureStdout(t, func() {
+		if err := application.SetEnabled(true); err != nil {
+			t.Fatalf("failed to turn on: %v", err)
+		}
+		// Turning on twice is harmless
+		if err := application.SetEnabled(true); err != nil {
+			t.Fatalf("failed to turn on again: %v", err)
+		}
+	})
+	captureStderr(t, func() {
+		if err := application.PrepareCommitMsgHook(msgFile, "", ""); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	if !*generated {
+		t.Error("expected the hook to generate a message once back on")
+	}
+}
+
+func TestApp_PrepareCommitMsgHook_GivenMessage(t *testing.T) {
+	tests := []struct {
+		source         string
+		expectedStderr string
+	}{
+		{"message", "generate-commit: skipped, a message was given with -m or -F\n"},
+		{"commit", "generate-commit: skipped, the commit reuses an existing message\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			msgFile := filepath.Join(t.TempDir(), "COMMIT_EDITMSG")
+			if err := os.WriteFile(msgFile, []byte("fix: typed by hand\n"), 0644); err != nil {
+				t.Fatalf("failed to write message file: %v", err)
+			}
+			application, generated := newSkipTestApp(t.TempDir())
+
+			stderr := captureStderr(t, func() {
+				if err := application.PrepareCommitMsgHook(msgFile, tt.source, ""); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			})
+			if *generated {
+				t.Error("expected no message to be generated")
+			}
+			if stderr != tt.expectedStderr {
+				t.Errorf("expected %q, got %q", tt.expectedStderr, stderr)
+			}
+		})
+	}
+}
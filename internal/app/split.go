@@ -0,0 +1,456 @@
+package app
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"ai-commit-message-generator/internal/ai"
+	"ai-commit-message-generator/internal/git"
+)
+
+// SplitGroup is a set of staged files committed together
+type SplitGroup struct {
+	// Name describes the group in the plan, e.g. the globs that selected it
+	Name  string
+	Files []string
+	// Subject is the model's proposed subject for a planned group, checked
+	// against the group's diff when its message is generated
+	Subject string
+	// Message is the generated commit message for the group
+	Message string
+}
+
+// leftoverGroup names the group of files a split plan did not assign
+const leftoverGroup = "leftovers"
+
+// SplitOptions controls CommitSplit
+type SplitOptions struct {
+	// Groups holds one comma-separated glob list per group. A staged file
+	// belongs to the first group it matches. Without groups the model plans
+	// them, and files it does not assign are committed last as leftovers.
+	Groups []string
+	// ByDir groups the staged files by package directory instead, see
+	// groupByDir. It cannot be combined with Groups.
+	ByDir bool
+	// Yes skips the confirmation prompt, and with ByDir the offer to merge
+	// single-file groups
+	Yes bool
+}
+
+// CommitSplit commits the staged changes as one commit per group. Messages
+// for every group are generated first and shown for confirmation; nothing is
+// committed until the user agrees. If a commit fails, the commits already
+// made are undone and the original index is restored. Staged files that
+// match no --group stay staged.
+func (a *App) CommitSplit(opts SplitOptions) error {
+	return a.commitSplit(opts, nil)
+}
+
+// commitSplit is CommitSplit reading the confirmation from reader, so a
+// caller that already reads the terminal does not lose buffered input. A nil
+// reader reads the terminal directly.
+func (a *App) commitSplit(opts SplitOptions, reader *bufio.Reader) error {
+	if opts.ByDir && len(opts.Groups) > 0 {
+		return errors.New("--by-dir cannot be combined with --group")
+	}
+	if !opts.Yes && !a.canPrompt() {
+		return errors.New("splitting commits requires confirmation; run it from a terminal or pass --yes")
+	}
+	if !opts.Yes && reader == nil {
+		reader = bufio.NewReader(a.Terminal.In)
+	}
+
+	state, err := a.Git.DetectState()
+	if err == nil && state.Type != git.StateNormal {
+		return fmt.Errorf("cannot split commits during a %s", state.Type)
+	}
+
+	files, err := a.Git.GetStagedFiles()
+	if err != nil {
+		return fmt.Errorf("failed to list staged files: %w", err)
+	}
+	if len(files) == 0 {
+		return withKind(ErrNoStagedChanges, errors.New("no staged changes to split"))
+	}
+	var groups []SplitGroup
+	var leftover []string
+	switch {
+	case len(opts.Groups) > 0:
+		groups, leftover, err = groupFiles(files, opts.Groups)
+	case opts.ByDir:
+		groups = groupByDir(files)
+		if !opts.Yes {
+			groups, err = a.mergeSingleFileGroups(reader, groups)
+		}
+	default:
+		groups, err = a.planGroups(files)
+	}
+	if err != nil {
+		return err
+	}
+
+	snapshot, err := a.Git.SnapshotIndex()
+	if err != nil {
+		return fmt.Errorf("failed to record the index: %w", err)
+	}
+	if err := a.checkSplitBase(snapshot, files); err != nil {
+		return err
+	}
+
+	// Generate every message before committing anything
+	for i := range groups {
+		fmt.Printf("Generating commit message for %s...\n", groups[i].Name)
+		groups[i].Message, err = a.generateGroupMessage(snapshot, groups[i])
+		if err != nil {
+			if restoreErr := a.Git.RestoreIndex(snapshot); restoreErr != nil {
+				return fmt.Errorf("%w (and failed to restore the index: %v)", err, restoreErr)
+			}
+			return err
+		}
+	}
+	if err := a.Git.RestoreIndex(snapshot); err != nil {
+		return fmt.Errorf("failed to restore the index: %w", err)
+	}
+
+	printSplitPlan(groups, leftover)
+	if !opts.Yes {
+		confirmed, err := a.confirm(reader, fmt.Sprintf("Create these %d commits?", len(groups)))
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			return ErrCancelled
+		}
+	}
+
+	for i, group := range groups {
+		err := a.Git.StageOnly(snapshot, group.Files)
+		if err == nil {
+			err = a.Git.CommitWithMessage(group.Message)
+		}
+		if err == nil {
+			err = a.checkGroupCommit(group)
+		}
+		if err != nil {
+			if resetErr := a.Git.ResetToSnapshot(snapshot); resetErr != nil {
+				return fmt.Errorf("failed to commit %s: %w (and failed to roll back: %v; check 'git log' and 'git status')", group.Name, err, resetErr)
+			}
+			return fmt.Errorf("failed to commit %s: %w (%d earlier commits rolled back, index restored)", group.Name, err, i)
+		}
+		fmt.Printf("\033[32m✓ Committed %s\033[0m\n", group.Name)
+	}
+
+	if err := a.Git.RestoreIndex(snapshot); err != nil {
+		return fmt.Errorf("failed to restore the remaining staged files: %w", err)
+	}
+	return nil
+}
+
+// checkSplitBase stops a split that would start from an empty tree
+// although the branch has commits. Each group is staged on top of HEAD, so
+// a HEAD that did not resolve would make every commit delete the rest of
+// the tree. On a branch that really has no commits yet, everything in the
+// index is staged.
+func (a *App) checkSplitBase(snapshot *git.IndexSnapshot, files []git.StagedFile) error {
+	if !snapshot.Unborn() {
+		return nil
+	}
+	hasCommits, err := a.Git.HasCommits()
+	if err != nil {
+		return fmt.Errorf("failed to check for commits: %w", err)
+	}
+	if hasCommits {
+		return errors.New("HEAD did not resolve although the branch has commits; nothing was split")
+	}
+	staged := make(map[string]bool, len(files))
+	for _, f := range files {
+		staged[f.Path] = true
+	}
+	for _, path := range snapshot.Paths() {
+		if !staged[path] {
+			return fmt.Errorf("HEAD did not resolve although the index tracks %s, which is not staged; nothing was split", path)
+		}
+	}
+	return nil
+}
+
+// checkGroupCommit makes sure the commit just made for group changed none
+// of the paths outside it
+func (a *App) checkGroupCommit(group SplitGroup) error {
+	changed, err := a.Git.HeadChangedPaths()
+	if err != nil {
+		return fmt.Errorf("failed to check the commit: %w", err)
+	}
+	inGroup := make(map[string]bool, len(group.Files))
+	for _, path := range group.Files {
+		inGroup[path] = true
+	}
+	var outside []string
+	for _, path := range changed {
+		if !inGroup[path] {
+			outside = append(outside, path)
+		}
+	}
+	if len(outside) > 0 {
+		return fmt.Errorf("the commit changed paths outside the group: %s", strings.Join(outside, ", "))
+	}
+	return nil
+}
+
+// generateGroupMessage stages only the group's files and generates a message
+// from their diff
+func (a *App) generateGroupMessage(snapshot *git.IndexSnapshot, group SplitGroup) (string, error) {
+	if err := a.Git.StageOnly(snapshot, group.Files); err != nil {
+		return "", fmt.Errorf("failed to stage %s: %w", group.Name, err)
+	}
+	a.Progress.Phase(PhaseReadingDiff)
+	req, err := a.prepareRequest(RunOptions{})
+	if err != nil {
+		a.Progress.Stop()
+		return "", err
+	}
+	if group.Subject != "" {
+		req.PreviousMessage = group.Subject
+		req.Feedback = "This subject was proposed when the staged changes were split into commits. Keep it if it describes this diff; otherwise correct it."
+	}
+	message, err := a.generateMessage(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate commit message for %s: %w", group.Name, err)
+	}
+	if a.suggestsSplit(message) {
+		return "", fmt.Errorf("the model suggested splitting %s further; use narrower groups", group.Name)
+	}
+	return a.finalizeMessage(message), nil
+}
+
+// planGroups asks the model how to split the staged files into commits.
+// The plan is trusted only as far as the staged files go: paths that are
+// not staged are dropped, a path listed twice stays in the first group
+// that lists it, and groups left without files are dropped. Files the plan
+// leaves out form a final leftovers group.
+func (a *App) planGroups(files []git.StagedFile) ([]SplitGroup, error) {
+	a.Progress.Phase(PhaseReadingDiff)
+	req, err := a.prepareRequest(RunOptions{})
+	if err != nil {
+		a.Progress.Stop()
+		return nil, err
+	}
+
+	paths := make([]string, len(files))
+	for i, f := range files {
+		paths[i] = f.Path
+	}
+	a.status("Planning the split...")
+	plan, err := a.planSplit(ai.SplitRequest{Diff: req.Diff, Files: paths, Rules: req.Rules})
+	if err != nil {
+		return nil, fmt.Errorf("failed to plan the split: %w", err)
+	}
+
+	staged := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		staged[p] = true
+	}
+	assigned := make(map[string]bool, len(paths))
+	groups := make([]SplitGroup, 0, len(plan.Groups)+1)
+	for i, planned := range plan.Groups {
+		var groupFiles []string
+		for _, f := range planned.Files {
+			if !staged[f] || assigned[f] {
+				continue
+			}
+			assigned[f] = true
+			groupFiles = append(groupFiles, f)
+		}
+		if len(groupFiles) == 0 {
+			continue
+		}
+		name := planned.Subject
+		if name == "" {
+			name = fmt.Sprintf("group %d", i+1)
+		}
+		groups = append(groups, SplitGroup{Name: name, Files: groupFiles, Subject: planned.Subject})
+	}
+
+	var leftover []string
+	for _, p := range paths {
+		if !assigned[p] {
+			leftover = append(leftover, p)
+		}
+	}
+	if len(leftover) > 0 {
+		groups = append(groups, SplitGroup{Name: leftoverGroup, Files: leftover})
+	}
+	return groups, nil
+}
+
+// planSplit asks the model for a split plan. The progress line is cleared
+// before returning.
+func (a *App) planSplit(req ai.SplitRequest) (*ai.SplitPlan, error) {
+	defer a.Progress.Stop()
+	return a.AI.PlanSplit(req)
+}
+
+// groupFiles assigns each staged file to the first group whose globs match
+// it. Files matching no group are returned as leftover.
+func groupFiles(files []git.StagedFile, specs []string) ([]SplitGroup, []string, error) {
+	groups := make([]SplitGroup, len(specs))
+	patterns := make([][]string, len(specs))
+	for i, spec := range specs {
+		for _, p := range strings.Split(spec, ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				patterns[i] = append(patterns[i], p)
+			}
+		}
+		if err := git.ValidateGlobs(patterns[i]); err != nil {
+			return nil, nil, err
+		}
+		groups[i].Name = strings.Join(patterns[i], ", ")
+	}
+
+	var leftover []string
+	for _, f := range files {
+		assigned := false
+		for i := range groups {
+			if matchesAnyGlob(patterns[i], f.Path) {
+				groups[i].Files = append(groups[i].Files, f.Path)
+				assigned = true
+				break
+			}
+		}
+		if !assigned {
+			leftover = append(leftover, f.Path)
+		}
+	}
+
+	for _, group := range groups {
+		if len(group.Files) == 0 {
+			return nil, nil, fmt.Errorf("group %q matches no staged files", group.Name)
+		}
+	}
+	return groups, leftover, nil
+}
+
+// monorepoContainers are top-level directories that hold packages rather
+// than being one, e.g. packages/web and packages/api
+var monorepoContainers = map[string]bool{
+	"packages": true, "apps": true, "services": true, "libs": true,
+	"modules": true, "crates": true, "cmd": true, "internal": true, "pkg": true,
+}
+
+// rootGroup names the group of files at the top of the repository
+const rootGroup = "(root)"
+
+// packageDir returns the package directory p belongs to: its top-level
+// directory, or the second level inside a monorepo container. Files at the
+// top of the repository belong to rootGroup.
+func packageDir(p string) string {
+	parts := strings.SplitN(p, "/", 3)
+	switch {
+	case len(parts) == 1:
+		return rootGroup
+	case len(parts) == 3 && monorepoContainers[parts[0]]:
+		return parts[0] + "/" + parts[1] + "/"
+	default:
+		return parts[0] + "/"
+	}
+}
+
+// groupByDir puts each staged file into the group of its package directory.
+// Groups are ordered by directory, with files at the top of the repository
+// last.
+func groupByDir(files []git.StagedFile) []SplitGroup {
+	index := make(map[string]int)
+	var groups []SplitGroup
+	for _, f := range files {
+		dir := packageDir(f.Path)
+		i, ok := index[dir]
+		if !ok {
+			i = len(groups)
+			index[dir] = i
+			groups = append(groups, SplitGroup{Name: dir})
+		}
+		groups[i].Files = append(groups[i].Files, f.Path)
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		if (groups[i].Name == rootGroup) != (groups[j].Name == rootGroup) {
+			return groups[j].Name == rootGroup
+		}
+		return groups[i].Name < groups[j].Name
+	})
+	return groups
+}
+
+// mergeSingleFileGroups offers to fold each group holding a single file into
+// its neighbor, the group before it or, for the first group, the one after
+func (a *App) mergeSingleFileGroups(reader *bufio.Reader, groups []SplitGroup) ([]SplitGroup, error) {
+	for i := 0; i < len(groups) && len(groups) > 1; i++ {
+		if len(groups[i].Files) != 1 {
+			continue
+		}
+		neighbor := i - 1
+		if neighbor < 0 {
+			neighbor = i + 1
+		}
+		merge, err := a.confirm(reader, fmt.Sprintf("%s only holds %s. Merge it into %s?", groups[i].Name, groups[i].Files[0], groups[neighbor].Name))
+		if err != nil {
+			return nil, err
+		}
+		if !merge {
+			continue
+		}
+		groups[neighbor].Files = append(groups[neighbor].Files, groups[i].Files...)
+		sort.Strings(groups[neighbor].Files)
+		groups = append(groups[:i], groups[i+1:]...)
+		i--
+	}
+	return groups, nil
+}
+
+func matchesAnyGlob(patterns []string, p string) bool {
+	for _, pattern := range patterns {
+		if git.MatchGlob(pattern, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// printSplitPlan shows the commits CommitSplit is about to create
+func printSplitPlan(groups []SplitGroup, leftover []string) {
+	fmt.Println("\nSplit plan:")
+	fmt.Println("==========================")
+	for i, group := range groups {
+		fmt.Printf("\n%d. %s (%d files)\n", i+1, group.Name, len(group.Files))
+		for _, line := range strings.Split(group.Message, "\n") {
+			fmt.Println("   \033[36m" + line + "\033[0m")
+		}
+		for _, f := range group.Files {
+			fmt.Printf("   - %s\n", f)
+		}
+	}
+	if len(leftover) > 0 {
+		fmt.Printf("\nLeft staged (no group matches):\n")
+		for _, f := range leftover {
+			fmt.Printf("   - %s\n", f)
+		}
+	}
+	fmt.Println()
+}
+
+// confirm asks a yes/no question on the terminal. Anything but y/yes is no.
+func (a *App) confirm(reader *bufio.Reader, question string) (bool, error) {
+	fmt.Fprintf(a.Terminal.Out, "%s [y/N] ", question)
+	answer, err := a.Terminal.readLine(reader)
+	if errors.Is(err, ErrPromptTimeout) {
+		return false, err
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read answer: %w", err)
+	}
+	answer = strings.ToLower(answer)
+	return answer == "y" || answer == "yes", nil
+}
@@ -0,0 +1,588 @@
+package app
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"ai-commit-message-generator/internal/ai"
+	"ai-commit-message-generator/internal/git"
+
+	gogit "github.com/go-git/go-git/v5"
+)
+
+func TestGroupFiles(t *testing.T) {
+	files := []git.StagedFile{
+		{Path: "README.md"},
+		{Path: "cmd/generate-commit/main.go"},
+		{Path: "internal/ai/client.go"},
+		{Path: "internal/ai/client_test.go"},
+	}
+
+	tests := []struct {
+		name          string
+		specs         []string
+		expected      [][]string
+		leftover      []string
+		expectedError string
+	}{
+		{
+			name:     "Groups and leftover",
+			specs:    []string{"internal/ai/", "cmd/**"},
+			expected: [][]string{{"internal/ai/client.go", "internal/ai/client_test.go"}, {"cmd/generate-commit/main.go"}},
+			leftover: []string{"README.md"},
+		},
+		{
+			name:     "First matching group wins",
+			specs:    []string{"*_test.go", "internal/**, README.md"},
+			expected: [][]string{{"internal/ai/client_test.go"}, {"README.md", "internal/ai/client.go"}},
+			leftover: []string{"cmd/generate-commit/main.go"},
+		},
+		{
+			name:          "Empty group",
+			specs:         []string{"internal/**", "docs/"},
+			expectedError: `group "docs/" matches no staged files`,
+		},
+		{
+			name:          "Invalid glob",
+			specs:         []string{"internal/[ai"},
+			expectedError: "invalid glob",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			groups, leftover, err := groupFiles(files, tt.specs)
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("expected error %q, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var got [][]string
+			for _, g := range groups {
+				got = append(got, g.Files)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("expected groups %v, got %v", tt.expected, got)
+			}
+			if !reflect.DeepEqual(leftover, tt.leftover) {
+				t.Errorf("expected leftover %v, got %v", tt.leftover, leftover)
+			}
+		})
+	}
+}
+
+// splitEnv simulates the index: StageOnly sets the staged paths and
+// RestoreIndex/ResetToSnapshot put back the original ones
+type splitEnv struct {
+	original  []string
+	staged    []string
+	commits   []string
+	restored  int
+	reset     bool
+	commitErr error
+	aiErr     error
+	// plan is returned by PlanSplit, or planErr if set
+	plan    *ai.SplitPlan
+	planErr error
+	// firstResponse replaces the first generated message, e.g. with a
+	// split suggestion
+	firstResponse string
+	generated     int
+	// hasCommits is what HasCommits reports for the unborn snapshot
+	hasCommits bool
+	// committedExtra are paths every commit changes besides the group's
+	committedExtra []string
+}
+
+func (e *splitEnv) app() *App {
+	mockGit := &MockGit{
+		IsInsideRepoFunc:     func() (bool, error) { return true, nil },
+		HasStagedChangesFunc: func() (bool, error) { return len(e.staged) > 0, nil },
+		GetStagedDiffFunc:    func() (string, error) { return strings.Join(e.staged, " "), nil },
+		GetStagedFilesFunc: func() ([]git.StagedFile, error) {
+			var files []git.StagedFile
+			for _, p := range e.staged {
+				files = append(files, git.StagedFile{Path: p, Change: git.ChangeModified})
+			}
+			return files, nil
+		},
+		StageOnlyFunc: func(paths []string) error {
+			e.staged = paths
+			return nil
+		},
+		RestoreIndexFunc: func() error {
+			e.staged = e.original
+			e.restored++
+			return nil
+		},
+		ResetToSnapshotFunc: func() error {
+			e.staged = e.original
+			e.commits = nil
+			e.reset = true
+			return nil
+		},
+		HasCommitsFunc: func() (bool, error) { return e.hasCommits, nil },
+		HeadChangedPathsFunc: func() ([]string, error) {
+			return append(append([]string(nil), e.staged...), e.committedExtra...), nil
+		},
+		CommitWithMessageFunc: func(message string) error {
+			if e.commitErr != nil && len(e.commits) == 1 {
+				return e.commitErr
+			}
+			e.commits = append(e.commits, message)
+			return nil
+		},
+	}
+	mockAI := &MockAI{
+		GenerateCommitMessageFunc: func(req ai.CommitRequest) (string, error) {
+			if e.aiErr != nil {
+				return "", e.aiErr
+			}
+			e.generated++
+			if e.generated == 1 && e.firstResponse != "" {
+				return e.firstResponse, nil
+			}
+			return "chore: update " + req.Diff, nil
+		},
+		PlanSplitFunc: func(req ai.SplitRequest) (*ai.SplitPlan, error) {
+			if !reflect.DeepEqual(req.Files, e.original) {
+				return nil, errors.New("unexpected files to plan")
+			}
+			return e.plan, e.planErr
+		},
+	}
+	mockConfig := &MockConfig{LoadRulesFunc: func() (string, error) { return "", nil }}
+	return NewApp(mockGit, mockConfig, nil, mockAI)
+}
+
+func TestApp_CommitSplit(t *testing.T) {
+	staged := []string{"README.md", "cmd/main.go", "internal/ai/client.go"}
+
+	tests := []struct {
+		name            string
+		opts            SplitOptions
+		input           string
+		noTerminal      bool
+		notTTY          bool
+		commitErr       error
+		aiErr           error
+		plan            *ai.SplitPlan
+		planErr         error
+		expectedError   string
+		hasCommits      bool
+		committedExtra  []string
+		expectedCommits []string
+		expectReset     bool
+	}{
+		{
+			name:            "Confirmed split",
+			opts:            SplitOptions{Groups: []string{"internal/", "cmd/"}},
+			input:           "y\n",
+			expectedCommits: []string{"chore: update internal/ai/client.go", "chore: update cmd/main.go"},
+		},
+		{
+			name:            "Yes skips confirmation",
+			opts:            SplitOptions{Groups: []string{"*.md", "*.go"}, Yes: true},
+			noTerminal:      true,
+			expectedCommits: []string{"chore: update README.md", "chore: update cmd/main.go internal/ai/client.go"},
+		},
+		{
+			name:          "Declined",
+			opts:          SplitOptions{Groups: []string{"internal/", "cmd/"}},
+			input:         "n\n",
+			expectedError: ErrCancelled.Error(),
+		},
+		{
+			name:          "No terminal without yes",
+			opts:          SplitOptions{Groups: []string{"internal/"}},
+			noTerminal:    true,
+			expectedError: "pass --yes",
+		},
+		{
+			name:          "Piped input without yes",
+			opts:          SplitOptions{Groups: []string{"internal/"}},
+			input:         "y\n",
+			notTTY:        true,
+			expectedError: "pass --yes",
+		},
+		{
+			name:          "Generation failure commits nothing",
+			opts:          SplitOptions{Groups: []string{"internal/"}, Yes: true},
+			aiErr:         errors.New("timeout"),
+			expectedError: "failed to generate commit message for internal/",
+		},
+		{
+			name: "Model plan with leftovers",
+			opts: SplitOptions{Yes: true},
+			plan: &ai.SplitPlan{Groups: []ai.PlannedGroup{
+				{Files: []string{"internal/ai/client.go"}, Subject: "feat(ai): added client"},
+				{Files: []string{"cmd/main.go"}, Subject: "feat(cmd): added flag"},
+			}},
+			noTerminal:      true,
+			expectedCommits: []string{"chore: update internal/ai/client.go", "chore: update cmd/main.go", "chore: update README.md"},
+		},
+		{
+			name: "Model plan with made-up and repeated paths",
+			opts: SplitOptions{Yes: true},
+			plan: &ai.SplitPlan{Groups: []ai.PlannedGroup{
+				{Files: []string{"internal/ai/client.go", "internal/ai/client.go", "internal/ai/made_up.go"}, Subject: "feat(ai): added client"},
+				{Files: []string{"docs/made_up.md"}, Subject: "docs: added guide"},
+				{Files: []string{"internal/ai/client.go", "cmd/main.go"}, Subject: "feat(cmd): added flag"},
+			}},
+			noTerminal:      true,
+			expectedCommits: []string{"chore: update internal/ai/client.go", "chore: update cmd/main.go", "chore: update README.md"},
+		},
+		{
+			name:          "Plan failure commits nothing",
+			opts:          SplitOptions{Yes: true},
+			planErr:       errors.New("timeout"),
+			noTerminal:    true,
+			expectedError: "failed to plan the split",
+		},
+		{
+			name:          "Commit failure rolls back",
+			opts:          SplitOptions{Groups: []string{"internal/", "cmd/"}, Yes: true},
+			commitErr:     errors.New("disk full"),
+			expectedError: "1 earlier commits rolled back",
+			expectReset:   true,
+		},
+		{
+			name:          "Unresolved HEAD with commits stages nothing",
+			opts:          SplitOptions{Groups: []string{"internal/", "cmd/"}, Yes: true},
+			hasCommits:    true,
+			expectedError: "HEAD did not resolve although the branch has commits",
+		},
+		{
+			name:           "Commit changing paths outside the group rolls back",
+			opts:           SplitOptions{Groups: []string{"internal/", "cmd/"}, Yes: true},
+			committedExtra: []string{"go.mod"},
+			expectedError:  "the commit changed paths outside the group: go.mod",
+			expectReset:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := &splitEnv{original: staged, staged: staged, commitErr: tt.commitErr, aiErr: tt.aiErr, plan: tt.plan, planErr: tt.planErr, hasCommits: tt.hasCommits, committedExtra: tt.committedExtra}
+			application := env.app()
+			if !tt.notTTY {
+				fakeTTY(t)
+			}
+			if !tt.noTerminal {
+				application.Terminal = &Terminal{In: strings.NewReader(tt.input), Out: io.Discard}
+			}
+
+			var err error
+			captureStdout(t, func() {
+				err = application.CommitSplit(tt.opts)
+			})
+
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("expected error %q, got %v", tt.expectedError, err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(env.commits, tt.expectedCommits) {
+				t.Errorf("expected commits %q, got %q", tt.expectedCommits, env.commits)
+			}
+			if env.reset != tt.expectReset {
+				t.Errorf("expected reset=%v, got %v", tt.expectReset, env.reset)
+			}
+			if !reflect.DeepEqual(env.staged, staged) && env.restored > 0 {
+				t.Errorf("expected the original index back, got %v", env.staged)
+			}
+		})
+	}
+}
+
+func TestApp_CommitSplit_PlanSubjects(t *testing.T) {
+	staged := []string{"README.md", "cmd/main.go"}
+	env := &splitEnv{original: staged, staged: staged, plan: &ai.SplitPlan{Groups: []ai.PlannedGroup{
+		{Files: []string{"cmd/main.go"}, Subject: "feat(cmd): added flag"},
+		{Files: []string{"README.md"}, Subject: "docs: documented flag"},
+	}}}
+	application := env.app()
+	var previous []string
+	application.AI.(*MockAI).GenerateCommitMessageFunc = func(req ai.CommitRequest) (string, error) {
+		previous = append(previous, req.PreviousMessage)
+		return req.PreviousMessage, nil
+	}
+
+	captureStdout(t, func() {
+		if err := application.CommitSplit(SplitOptions{Yes: true}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	expected := []string{"feat(cmd): added flag", "docs: documented flag"}
+	if !reflect.DeepEqual(previous, expected) {
+		t.Errorf("expected the proposed subjects to be revised, got %q", previous)
+	}
+	if !reflect.DeepEqual(env.commits, expected) {
+		t.Errorf("expected commits %q, got %q", expected, env.commits)
+	}
+}
+
+func TestApp_Run_InteractiveSplit(t *testing.T) {
+	staged := []string{"README.md", "cmd/main.go"}
+	env := &splitEnv{
+		original:      staged,
+		staged:        staged,
+		firstResponse: "These changes should be split into separate commits: docs and cmd.",
+		plan: &ai.SplitPlan{Groups: []ai.PlannedGroup{
+			{Files: []string{"README.md"}, Subject: "docs: documented flag"},
+			{Files: []string{"cmd/main.go"}, Subject: "feat(cmd): added flag"},
+		}},
+	}
+	application := env.app()
+	fakeTTY(t)
+	out := &strings.Builder{}
+	application.Terminal = &Terminal{In: strings.NewReader("s\ny\n"), Out: out}
+
+	captureStdout(t, func() {
+		if err := application.Run(RunOptions{Interactive: true}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	if !strings.Contains(out.String(), "[S]plit into the suggested commits") {
+		t.Errorf("expected the split option to be offered, got:\n%s", out.String())
+	}
+	expected := []string{"chore: update README.md", "chore: update cmd/main.go"}
+	if !reflect.DeepEqual(env.commits, expected) {
+		t.Errorf("expected commits %q, got %q", expected, env.commits)
+	}
+}
+
+func TestApp_CommitSplit_Integration(t *testing.T) {
+	tempDir := t.TempDir()
+	originalWd, _ := os.Getwd()
+	t.Cleanup(func() { _ = os.Chdir(originalWd) })
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatalf("failed to change to temp dir: %v", err)
+	}
+	t.Setenv("GIT_AUTHOR_NAME", "Test User")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+
+	repo, err := gogit.PlainInit(tempDir, false)
+	if err != nil {
+		t.Fatalf("failed to git init: %v", err)
+	}
+	worktree, _ := repo.Worktree()
+	write := func(name, content string) {
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+		if _, err := worktree.Add(name); err != nil {
+			t.Fatalf("failed to add %s: %v", name, err)
+		}
+	}
+	gitClient := git.NewClient()
+	write("README.md", "# Project\n")
+	if err := gitClient.CommitWithMessage("initial"); err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+	write("auth/login.go", "package auth\n")
+	write("auth/login_test.go", "package auth\n")
+	write("README.md", "# Project\n\nNow with login.\n")
+	write("db/migrate.go", "package db\n")
+
+	mockAI := &MockAI{
+		GenerateCommitMessageFunc: func(req ai.CommitRequest) (string, error) {
+			return req.PreviousMessage, nil
+		},
+		PlanSplitFunc: func(req ai.SplitRequest) (*ai.SplitPlan, error) {
+			return &ai.SplitPlan{Groups: []ai.PlannedGroup{
+				{Files: []string{"auth/login.go", "auth/login_test.go", "README.md"}, Subject: "feat(auth): added login"},
+			}}, nil
+		},
+	}
+	mockConfig := &MockConfig{LoadRulesFunc: func() (string, error) { return "", nil }}
+	application := NewApp(gitClient, mockConfig, nil, mockAI)
+
+	captureStdout(t, func() {
+		if err := application.CommitSplit(SplitOptions{Yes: true}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	// Newest first: the leftovers, then the planned group
+	expected := []struct {
+		message string
+		files   []string
+	}{
+		{"", []string{"db/migrate.go"}},
+		{"feat(auth): added login", []string{"README.md", "auth/login.go", "auth/login_test.go"}},
+	}
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatalf("failed to resolve HEAD: %v", err)
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		t.Fatalf("failed to read HEAD: %v", err)
+	}
+	for _, want := range expected {
+		parent, err := commit.Parent(0)
+		if err != nil {
+			t.Fatalf("failed to read parent of %q: %v", commit.Message, err)
+		}
+		tree, _ := commit.Tree()
+		parentTree, _ := parent.Tree()
+		changes, err := parentTree.Diff(tree)
+		if err != nil {
+			t.Fatalf("failed to diff %q: %v", commit.Message, err)
+		}
+		var files []string
+		for _, change := range changes {
+			name := change.To.Name
+			if name == "" {
+				name = change.From.Name
+			}
+			files = append(files, name)
+		}
+		sort.Strings(files)
+		if !reflect.DeepEqual(files, want.files) {
+			t.Errorf("commit %q: expected files %v, got %v", commit.Message, want.files, files)
+		}
+		if want.message != "" && commit.Message != want.message {
+			t.Errorf("expected message %q, got %q", want.message, commit.Message)
+		}
+		commit = parent
+	}
+	if commit.Message != "initial" {
+		t.Errorf("expected exactly two new commits, found %q before the initial one", commit.Message)
+	}
+
+	status, err := worktree.Status()
+	if err != nil {
+		t.Fatalf("failed to get status: %v", err)
+	}
+	if !status.IsClean() {
+		t.Errorf("expected everything committed, got:\n%s", status)
+	}
+}
+
+func TestGroupByDir(t *testing.T) {
+	tests := []struct {
+		name     string
+		paths    []string
+		expected []SplitGroup
+	}{
+		{
+			name:  "Top-level directories with root files last",
+			paths: []string{"README.md", "api/handler.go", "api/handler_test.go", "web/app.ts"},
+			expected: []SplitGroup{
+				{Name: "api/", Files: []string{"api/handler.go", "api/handler_test.go"}},
+				{Name: "web/", Files: []string{"web/app.ts"}},
+				{Name: rootGroup, Files: []string{"README.md"}},
+			},
+		},
+		{
+			name:  "Monorepo containers split one level deeper",
+			paths: []string{"packages/api/index.ts", "packages/api/src/db.ts", "packages/README.md", "packages/web/index.ts"},
+			expected: []SplitGroup{
+				{Name: "packages/", Files: []string{"packages/README.md"}},
+				{Name: "packages/api/", Files: []string{"packages/api/index.ts", "packages/api/src/db.ts"}},
+				{Name: "packages/web/", Files: []string{"packages/web/index.ts"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var files []git.StagedFile
+			for _, p := range tt.paths {
+				files = append(files, git.StagedFile{Path: p, Change: git.ChangeModified})
+			}
+			if groups := groupByDir(files); !reflect.DeepEqual(groups, tt.expected) {
+				t.Errorf("expected %+v, got %+v", tt.expected, groups)
+			}
+		})
+	}
+}
+
+func TestApp_CommitSplit_ByDir(t *testing.T) {
+	staged := []string{"api/handler.go", "api/handler_test.go", "docs/api.md", "web/app.ts", "web/app_test.ts"}
+
+	tests := []struct {
+		name            string
+		opts            SplitOptions
+		input           string
+		expectedError   string
+		expectedCommits []string
+	}{
+		{
+			name: "One commit per directory",
+			opts: SplitOptions{ByDir: true, Yes: true},
+			expectedCommits: []string{
+				"chore: update api/handler.go api/handler_test.go",
+				"chore: update docs/api.md",
+				"chore: update web/app.ts web/app_test.ts",
+			},
+		},
+		{
+			name:  "Single-file group merged into its neighbor",
+			opts:  SplitOptions{ByDir: true},
+			input: "y\ny\n",
+			expectedCommits: []string{
+				"chore: update api/handler.go api/handler_test.go docs/api.md",
+				"chore: update web/app.ts web/app_test.ts",
+			},
+		},
+		{
+			name:  "Single-file group kept",
+			opts:  SplitOptions{ByDir: true},
+			input: "n\ny\n",
+			expectedCommits: []string{
+				"chore: update api/handler.go api/handler_test.go",
+				"chore: update docs/api.md",
+				"chore: update web/app.ts web/app_test.ts",
+			},
+		},
+		{
+			name:          "Cannot be combined with groups",
+			opts:          SplitOptions{ByDir: true, Groups: []string{"api/"}, Yes: true},
+			expectedError: "cannot be combined",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := &splitEnv{original: staged, staged: staged}
+			application := env.app()
+			fakeTTY(t)
+			application.Terminal = &Terminal{In: strings.NewReader(tt.input), Out: io.Discard}
+
+			var err error
+			captureStdout(t, func() {
+				err = application.CommitSplit(tt.opts)
+			})
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("expected error %q, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(env.commits, tt.expectedCommits) {
+				t.Errorf("expected commits %q, got %q", tt.expectedCommits, env.commits)
+			}
+		})
+	}
+}
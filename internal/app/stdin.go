@@ -0,0 +1,66 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"ai-commit-message-generator/internal/ai"
+	"ai-commit-message-generator/internal/git"
+	"ai-commit-message-generator/internal/logging"
+)
+
+// maxStdinDiffBytes caps how much of stdin is read. The diff sent to the
+// model is cut much shorter, so the rest would only cost memory.
+const maxStdinDiffBytes = 1 << 20
+
+// prepareStdinRequest builds the commit message request from a diff read
+// from r. Nothing is asked of git: the repository, staged changes and git
+// state are not checked, and the state is normal.
+func (a *App) prepareStdinRequest(r io.Reader) (ai.CommitRequest, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxStdinDiffBytes+1))
+	if err != nil {
+		return ai.CommitRequest{}, fmt.Errorf("failed to read the diff from stdin: %w", err)
+	}
+	if len(data) > maxStdinDiffBytes {
+		a.log().Warn(fmt.Sprintf("the diff on stdin is larger than %d KiB; only the start is used", maxStdinDiffBytes/1024), logging.KeyStage, "diff")
+		data = data[:maxStdinDiffBytes]
+	}
+	if strings.TrimSpace(string(data)) == "" {
+		return ai.CommitRequest{}, withKind(ErrNoStagedChanges, errors.New("no diff on stdin; pipe one in, e.g. git diff main...feature | generate-commit --stdin"))
+	}
+
+	diff, files := git.ParseDiff(string(data), a.StdinFilter)
+	if len(files) > 0 && allExcluded(files) {
+		return ai.CommitRequest{}, withKind(ErrNoStagedChanges, errors.New("no files in the diff on stdin match the path filters"))
+	}
+
+	override := a.pathOverride(files)
+	rules, err := a.loadRules(files, override)
+	if err != nil {
+		a.log().Warn("failed to load rules, proceeding without them", logging.KeyStage, "rules", logging.KeyError, err)
+	}
+
+	req := ai.CommitRequest{
+		Diff:     a.withDiffStat(diff),
+		Rules:    rules,
+		GitState: &git.GitState{Type: git.StateNormal},
+	}
+	a.applyStyle(&req, override)
+	if len(files) > 0 {
+		req.Meta = ai.NewDiffMeta(files, a.TestFiles)
+		req.FastPath = a.classifyChangeset(files, req)
+	}
+	return req, nil
+}
+
+// allExcluded reports whether the path filters left out every file
+func allExcluded(files []git.StagedFile) bool {
+	for _, file := range files {
+		if !file.Excluded {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,162 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"ai-commit-message-generator/internal/ai"
+	"ai-commit-message-generator/internal/git"
+)
+
+// unusedGit is a git client for runs that must not touch git. Its nil
+// embedded Client panics on any call.
+type unusedGit struct {
+	git.Client
+}
+
+const stdinDiff = `diff --git a/internal/auth/login.go b/internal/auth/login.go
+--- a/internal/auth/login.go
++++ b/internal/auth/login.go
+@@ -1 +1 @@
+-func Login() {}
++func Login(user string) {}
+diff --git a/internal/auth/login_test.go b/internal/auth/login_test.go
+--- a/internal/auth/login_test.go
++++ b/internal/auth/login_test.go
+@@ -1 +1 @@
+-func TestLogin() {}
++func TestLogin(t *testing.T) {}
+`
+
+func TestApp_Run_Stdin(t *testing.T) {
+	tests := []struct {
+		name             string
+		input            string
+		opts             RunOptions
+		filter           git.DiffOptions
+		expectedContains []string
+		expectedAbsent   []string
+		expectedError    string
+	}{
+		{
+			name:             "Diff from stdin",
+			input:            stdinDiff,
+			expectedContains: []string{"+func Login(user string) {}", "+func TestLogin(t *testing.T) {}"},
+		},
+		{
+			name:             "Path filters apply",
+			input:            stdinDiff,
+			filter:           git.DiffOptions{Ignore: []string{"*_test.go"}},
+			expectedContains: []string{"+func Login(user string) {}"},
+			expectedAbsent:   []string{"TestLogin"},
+		},
+		{
+			name:          "Empty stdin",
+			input:         " \n",
+			expectedError: "no diff on stdin",
+		},
+		{
+			name:          "Everything filtered out",
+			input:         stdinDiff,
+			filter:        git.DiffOptions{Only: []string{"docs/"}},
+			expectedError: "match the path filters",
+		},
+		{
+			name:          "Nothing to commit",
+			input:         stdinDiff,
+			opts:          RunOptions{Yes: true},
+			expectedError: "--stdin cannot be combined",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got ai.CommitRequest
+			mockAI := &MockAI{
+				GenerateCommitMessageFunc: func(req ai.CommitRequest) (string, error) {
+					got = req
+					return "feat(auth): added the user to login", nil
+				},
+			}
+			mockConfig := &MockConfig{
+				LoadRulesFunc: func() (string, error) { return "Use the auth scope.", nil },
+			}
+			application := NewApp(unusedGit{}, mockConfig, nil, mockAI)
+			application.StdinFilter = tt.filter
+
+			opts := tt.opts
+			opts.Stdin = strings.NewReader(tt.input)
+			var err error
+			output := captureStdout(t, func() {
+				err = application.Run(opts)
+			})
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("expected error containing %q, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			for _, want := range tt.expectedContains {
+				if !strings.Contains(got.Diff, want) {
+					t.Errorf("expected the diff to contain %q, got:\n%s", want, got.Diff)
+				}
+			}
+			for _, unwanted := range tt.expectedAbsent {
+				if strings.Contains(got.Diff, unwanted) {
+					t.Errorf("expected the diff not to contain %q, got:\n%s", unwanted, got.Diff)
+				}
+			}
+			if got.Rules != "Use the auth scope." {
+				t.Errorf("expected the rules in the request, got %q", got.Rules)
+			}
+			if got.GitState == nil || got.GitState.Type != git.StateNormal {
+				t.Errorf("expected a normal git state, got %+v", got.GitState)
+			}
+			if got.Meta == nil || got.Meta.FileCount == 0 {
+				t.Errorf("expected file metadata from the diff, got %+v", got.Meta)
+			}
+			if !strings.Contains(output, "feat(auth): added the user to login") {
+				t.Errorf("expected the message on stdout, got %q", output)
+			}
+		})
+	}
+}
+
+func TestApp_Run_StdinPrompt(t *testing.T) {
+	var body struct {
+		Prompt string `json:"prompt"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("failed to decode request body: %v", err)
+		}
+		w.Write([]byte(`{"response": "feat(auth): added the user to login", "done": true}`))
+	}))
+	defer server.Close()
+
+	aiClient := ai.NewClient("test-api-key", server.URL+"/api/generate", "", time.Second)
+	mockConfig := &MockConfig{
+		LoadRulesFunc: func() (string, error) { return "", nil },
+	}
+	application := NewApp(unusedGit{}, mockConfig, nil, aiClient)
+
+	var err error
+	captureStdout(t, func() {
+		err = application.Run(RunOptions{Stdin: strings.NewReader(stdinDiff)})
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	// Every line of the piped diff is in the prompt, below the language tags
+	if !strings.Contains(strings.ReplaceAll(body.Prompt, "Language: Go\n", ""), stdinDiff) {
+		t.Errorf("expected the prompt to contain the piped diff, got:\n%s", body.Prompt)
+	}
+}
@@ -0,0 +1,157 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"ai-commit-message-generator/internal/ai"
+	"ai-commit-message-generator/internal/git"
+)
+
+// TagOptions controls Tag
+type TagOptions struct {
+	// Version is the name of the new tag, e.g. v1.3.0
+	Version string
+	// Since is the tag the release starts from. When empty it is the
+	// highest semantic version tag below Version.
+	Since string
+	// Create creates the annotated tag at HEAD with the message
+	Create bool
+	// File writes the message to a file, for git tag -a -F, instead of
+	// printing it
+	File string
+}
+
+// Tag writes an annotated tag message for the commits between the previous
+// tag and HEAD: a title, the highlights and breaking changes, and how many
+// commits and contributors went into the release. Only the message goes to
+// stdout so it can be piped; progress goes to stderr.
+func (a *App) Tag(opts TagOptions) error {
+	if opts.Version == "" {
+		return errors.New("a tag name is required, e.g. generate-commit tag v1.3.0")
+	}
+	isRepo, err := a.Git.IsInsideRepo()
+	if err != nil {
+		return fmt.Errorf("failed to check repository status: %w", err)
+	}
+	if !isRepo {
+		return ErrNotARepo
+	}
+
+	tags, err := a.Git.ListTags()
+	if err != nil {
+		return err
+	}
+	if opts.Create && containsString(tags, opts.Version) {
+		return fmt.Errorf("tag %s already exists", opts.Version)
+	}
+	since := opts.Since
+	if since == "" {
+		if since = previousTag(tags, opts.Version); since == "" {
+			return fmt.Errorf("no version tag below %s was found; pass --since <tag>", opts.Version)
+		}
+	}
+
+	defer a.Progress.Stop()
+	a.Progress.Phase(PhaseReadingDiff)
+	revRange := since + "..HEAD"
+	commits, err := a.Git.GetCommitRange(revRange)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", revRange, err)
+	}
+	groups := groupCommits(commits)
+	if len(groups) == 0 {
+		return fmt.Errorf("no commits since %s besides merges; there is nothing to tag", since)
+	}
+
+	if !a.Quiet && !a.Progress.Enabled() {
+		fmt.Fprintf(os.Stderr, "Generating tag message for %s (since %s)...\n", opts.Version, since)
+	}
+	body, err := a.AI.GenerateTagMessage(ai.TagRequest{
+		Version:  opts.Version,
+		Previous: since,
+		Groups:   groups,
+	})
+	a.Progress.Stop()
+	if err != nil {
+		return fmt.Errorf("failed to generate tag message: %w", err)
+	}
+	message := tagMessage(opts.Version, since, body, commits)
+
+	if opts.File != "" {
+		if err := os.WriteFile(opts.File, []byte(message), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", opts.File, err)
+		}
+		if !a.Quiet && !opts.Create {
+			fmt.Fprintf(os.Stderr, "\033[32m✓ Wrote the message to %s\033[0m\nCreate the tag with: git tag -a %s -F %s\n", opts.File, opts.Version, opts.File)
+		}
+	} else {
+		fmt.Print(message)
+	}
+
+	if !opts.Create {
+		return nil
+	}
+	if err := a.Git.CreateTag(opts.Version, message); err != nil {
+		return err
+	}
+	if !a.Quiet {
+		fmt.Fprintf(os.Stderr, "\033[32m✓ Created annotated tag %s\033[0m\n", opts.Version)
+	}
+	return nil
+}
+
+// previousTag returns the highest semantic version tag below version, or
+// the highest one of all when version is not a semantic version itself
+func previousTag(tags []string, version string) string {
+	current, isSemver := parseSemver(version)
+	var best string
+	var bestVersion semver
+	for _, tag := range tags {
+		v, ok := parseSemver(tag)
+		if !ok || tag == version || isSemver && v.compare(current) >= 0 {
+			continue
+		}
+		if best == "" || v.compare(bestVersion) > 0 {
+			best, bestVersion = tag, v
+		}
+	}
+	return best
+}
+
+// tagMessage puts the generated body under the tag name and ends it with
+// the number of commits and contributors, counted from the non-merge
+// commits by author email
+func tagMessage(version, since, body string, commits []git.LogCommit) string {
+	count := 0
+	authors := make(map[string]bool)
+	for _, commit := range commits {
+		if commit.Merge {
+			continue
+		}
+		count++
+		authors[strings.ToLower(commit.AuthorEmail)] = true
+	}
+	return fmt.Sprintf("%s\n\n%s\n\n%s from %s since %s\n",
+		version, strings.TrimSpace(body), countOf(count, "commit"), countOf(len(authors), "contributor"), since)
+}
+
+// countOf formats n with noun, adding an s unless n is one
+func countOf(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// containsString reports whether list contains s
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
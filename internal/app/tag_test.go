@@ -0,0 +1,219 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"ai-commit-message-generator/internal/ai"
+	"ai-commit-message-generator/internal/git"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func TestPreviousTag(t *testing.T) {
+	tags := []string{"latest", "v1.10.0", "v1.2.0", "v1.9.0", "v2.0.0-rc.1"}
+
+	tests := []struct {
+		name     string
+		version  string
+		expected string
+	}{
+		{name: "Highest below the version", version: "v1.11.0", expected: "v1.10.0"},
+		{name: "Sorted as versions, not text", version: "v1.10.0", expected: "v1.9.0"},
+		{name: "Pre-release below its release", version: "v2.0.0", expected: "v2.0.0-rc.1"},
+		{name: "Not a version", version: "nightly", expected: "v2.0.0-rc.1"},
+		{name: "Nothing below", version: "v1.0.0", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := previousTag(tags, tt.version); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestApp_Tag(t *testing.T) {
+	// Newest first, as GetCommitRange returns them
+	commits := []git.LogCommit{
+		{Hash: "cccccccccc", Message: "Merge branch 'topic'", AuthorEmail: "bot@example.com", Merge: true},
+		{Hash: "bbbbbbbbbb", Message: "feat(config)!: renamed the model option", AuthorEmail: "Ann@example.com"},
+		{Hash: "aaaaaaaaaa", Message: "fix(auth): handled expired tokens", AuthorEmail: "ann@example.com"},
+	}
+
+	tests := []struct {
+		name            string
+		opts            TagOptions
+		tags            []string
+		expectedRange   string
+		expectedCreated bool
+		expectedOutput  string
+		expectedError   string
+	}{
+		{
+			name:           "Previous tag is detected",
+			opts:           TagOptions{Version: "v1.3.0"},
+			tags:           []string{"v1.10.0-rc.1", "v1.2.0"},
+			expectedRange:  "v1.2.0..HEAD",
+			expectedOutput: "v1.3.0\n\nHighlights:\n- Expired tokens are handled\n\n2 commits from 1 contributor since v1.2.0\n",
+		},
+		{
+			name:            "Since and create",
+			opts:            TagOptions{Version: "v1.3.0", Since: "v1.1.0", Create: true},
+			tags:            []string{"v1.2.0"},
+			expectedRange:   "v1.1.0..HEAD",
+			expectedCreated: true,
+			expectedOutput:  "2 commits from 1 contributor since v1.1.0\n",
+		},
+		{
+			name:          "Existing tag is refused",
+			opts:          TagOptions{Version: "v1.2.0", Since: "v1.1.0", Create: true},
+			tags:          []string{"v1.2.0"},
+			expectedError: "tag v1.2.0 already exists",
+		},
+		{
+			name:          "No previous tag",
+			opts:          TagOptions{Version: "v1.0.0"},
+			tags:          []string{"v1.2.0"},
+			expectedError: "no version tag below v1.0.0 was found; pass --since <tag>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotRange, created string
+			mockGit := &MockGit{
+				IsInsideRepoFunc: func() (bool, error) { return true, nil },
+				ListTagsFunc:     func() ([]string, error) { return tt.tags, nil },
+				GetCommitRangeFunc: func(revRange string) ([]git.LogCommit, error) {
+					gotRange = revRange
+					return commits, nil
+				},
+				CreateTagFunc: func(name, message string) error {
+					created = message
+					return nil
+				},
+			}
+			mockAI := &MockAI{
+				GenerateTagMessageFunc: func(req ai.TagRequest) (string, error) {
+					if len(req.Groups) != 2 || !req.Groups[0].Commits[0].Breaking {
+						t.Errorf("expected the feat and fix groups, breaking first, got %+v", req.Groups)
+					}
+					return "Highlights:\n- Expired tokens are handled\n", nil
+				},
+			}
+			application := NewApp(mockGit, nil, nil, mockAI)
+			application.Quiet = true
+
+			var err error
+			output := captureStdout(t, func() {
+				err = application.Tag(tt.opts)
+			})
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("expected error containing %q, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Tag failed: %v", err)
+			}
+			if gotRange != tt.expectedRange {
+				t.Errorf("expected range %q, got %q", tt.expectedRange, gotRange)
+			}
+			if !strings.Contains(output, tt.expectedOutput) {
+				t.Errorf("expected output to contain %q, got %q", tt.expectedOutput, output)
+			}
+			if tt.expectedCreated != (created != "") || tt.expectedCreated && created != output {
+				t.Errorf("expected created=%v with the printed message, got %q", tt.expectedCreated, created)
+			}
+		})
+	}
+}
+
+func TestApp_Tag_Integration(t *testing.T) {
+	tempDir := t.TempDir()
+	originalWd, _ := os.Getwd()
+	t.Cleanup(func() { _ = os.Chdir(originalWd) })
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatalf("failed to change to temp dir: %v", err)
+	}
+	t.Setenv("GIT_AUTHOR_NAME", "Test User")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	repo, err := gogit.PlainInit(tempDir, false)
+	if err != nil {
+		t.Fatalf("failed to git init: %v", err)
+	}
+	worktree, _ := repo.Worktree()
+
+	// v1.9.0 and v1.10.0 are the prior tags; v1.10.0 only sorts last as a
+	// version
+	authors := []string{"ann@example.com", "ann@example.com", "bob@example.com", "ann@example.com", "cid@example.com"}
+	for i, message := range []string{"feat: first", "fix: tagged v1.9.0", "feat: tagged v1.10.0", "feat(ui): added dark mode", "fix(api): fixed pagination"} {
+		hash, err := worktree.Commit(message, &gogit.CommitOptions{
+			Author:            &object.Signature{Name: "Author", Email: authors[i], When: time.Date(2026, 1, 1, 0, i, 0, 0, time.UTC)},
+			AllowEmptyCommits: true,
+		})
+		if err != nil {
+			t.Fatalf("failed to commit: %v", err)
+		}
+		switch i {
+		case 1:
+			_, err = repo.CreateTag("v1.9.0", hash, nil)
+		case 2:
+			_, err = repo.CreateTag("v1.10.0", hash, &gogit.CreateTagOptions{Message: "v1.10.0", Tagger: &object.Signature{Name: "Author", Email: authors[i]}})
+		}
+		if err != nil {
+			t.Fatalf("failed to tag: %v", err)
+		}
+	}
+
+	var subjects []string
+	mockAI := &MockAI{
+		GenerateTagMessageFunc: func(req ai.TagRequest) (string, error) {
+			if req.Previous != "v1.10.0" {
+				t.Errorf("expected v1.10.0 as the previous tag, got %q", req.Previous)
+			}
+			for _, group := range req.Groups {
+				for _, commit := range group.Commits {
+					subjects = append(subjects, fmt.Sprintf("%s: %s", group.Type, commit.Description))
+				}
+			}
+			return "Highlights:\n- Dark mode\n- Pagination fix", nil
+		},
+	}
+	application := NewApp(git.NewClient(), nil, nil, mockAI)
+	application.Quiet = true
+
+	file := filepath.Join(tempDir, "TAG_MSG")
+	var tagErr error
+	captureStdout(t, func() {
+		tagErr = application.Tag(TagOptions{Version: "v1.11.0", Create: true, File: file})
+	})
+	if tagErr != nil {
+		t.Fatalf("Tag failed: %v", tagErr)
+	}
+	if expected := "feat: added dark mode, fix: fixed pagination"; strings.Join(subjects, ", ") != expected {
+		t.Errorf("expected the commits since v1.10.0 (%s), got %q", expected, subjects)
+	}
+
+	expected := "v1.11.0\n\nHighlights:\n- Dark mode\n- Pagination fix\n\n2 commits from 2 contributors since v1.10.0\n"
+	content, err := os.ReadFile(file)
+	if err != nil || string(content) != expected {
+		t.Errorf("expected the file to hold %q, got %q (%v)", expected, content, err)
+	}
+	ref, err := repo.Tag("v1.11.0")
+	if err != nil {
+		t.Fatalf("expected the tag to be created: %v", err)
+	}
+	tag, err := repo.TagObject(ref.Hash())
+	if err != nil || tag.Message != expected {
+		t.Errorf("expected an annotated tag with the message, got %v (%v)", tag, err)
+	}
+}
@@ -0,0 +1,153 @@
+package app
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// trailerPattern matches a git trailer line such as "Signed-off-by: Jane
+// <jane@example.com>". BREAKING CHANGE is the one key with a space.
+var trailerPattern = regexp.MustCompile(`^(BREAKING CHANGE|[A-Za-z0-9][A-Za-z0-9-]*): \S`)
+
+// AddCoAuthors adds --co-author values to CoAuthors, looking up the ones
+// without an address in AddressBook
+func (a *App) AddCoAuthors(values []string) error {
+	identities, err := resolveCoAuthors(values, a.AddressBook)
+	if err != nil {
+		return err
+	}
+	a.CoAuthors = append(a.CoAuthors, identities...)
+	return nil
+}
+
+// resolveCoAuthors turns --co-author values into "Name <email>" identities.
+// A value with an address is used as is; anything else is looked up in the
+// address book.
+func resolveCoAuthors(values []string, book map[string]string) ([]string, error) {
+	var identities []string
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if strings.Contains(value, "<") {
+			if !strings.HasSuffix(value, ">") || strings.HasPrefix(value, "<") {
+				return nil, fmt.Errorf("co-author %q is not in the form \"Name <email>\"", value)
+			}
+			identities = append(identities, value)
+			continue
+		}
+		identity, ok := book[value]
+		if !ok {
+			known := make([]string, 0, len(book))
+			for name := range book {
+				known = append(known, name)
+			}
+			sort.Strings(known)
+			if len(known) == 0 {
+				return nil, fmt.Errorf("unknown co-author %q: pass \"Name <email>\" or add it to co_authors in the config", value)
+			}
+			return nil, fmt.Errorf("unknown co-author %q (address book: %s)", value, strings.Join(known, ", "))
+		}
+		identities = append(identities, identity)
+	}
+	return identities, nil
+}
+
+// diffDigestKey is the trailer IncludeDiffDigest records the diff's
+// SHA-256 in
+const diffDigestKey = "X-Diff-SHA256"
+
+// trailers returns the trailer lines every generated message gets, in a
+// fixed order: the configured trailers, then the digest of diff, then
+// Co-authored-by, then Signed-off-by last as git commit --signoff writes it
+func (a *App) trailers(diff string) ([]string, error) {
+	lines := append([]string{}, a.Trailers...)
+	if a.IncludeDiffDigest {
+		lines = append(lines, diffDigestKey+": "+diffHash(diff))
+	}
+	for _, coAuthor := range a.CoAuthors {
+		lines = append(lines, "Co-authored-by: "+coAuthor)
+	}
+	if a.Signoff {
+		identity, err := a.Git.GetUserIdentity()
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign off: %w", err)
+		}
+		lines = append(lines, "Signed-off-by: "+identity.String())
+	}
+	return lines, nil
+}
+
+// withTrailers appends the configured trailers to a message generated from
+// diff, the diff as it was put in the prompt. Split suggestions are not
+// commit messages and are returned unchanged.
+func (a *App) withTrailers(message, diff string) (string, error) {
+	if a.suggestsSplit(message) {
+		return message, nil
+	}
+	lines, err := a.trailers(diff)
+	if err != nil {
+		return "", err
+	}
+	return appendTrailers(message, lines), nil
+}
+
+// appendTrailers adds trailers to the end of message the way git
+// interpret-trailers does: into the trailer block the message already ends
+// with, or after a blank line. A trailer the message already has, in any
+// letter case, is not repeated. The header is never taken for a trailer
+// block, so a single-line "feat: x" message gets a blank line too.
+func appendTrailers(message string, trailers []string) string {
+	trimmed := strings.TrimRight(message, "\n ")
+	lines := strings.Split(trimmed, "\n")
+
+	seen := make(map[string]bool, len(lines))
+	for _, line := range lines {
+		seen[trailerKey(line)] = true
+	}
+	var added []string
+	for _, trailer := range trailers {
+		trailer = strings.TrimSpace(trailer)
+		if trailer == "" || seen[trailerKey(trailer)] {
+			continue
+		}
+		seen[trailerKey(trailer)] = true
+		added = append(added, trailer)
+	}
+	if len(added) == 0 {
+		return message
+	}
+
+	if !endsWithTrailerBlock(lines) {
+		trimmed += "\n"
+	}
+	return trimmed + "\n" + strings.Join(added, "\n")
+}
+
+// endsWithTrailerBlock reports whether the last paragraph of a message,
+// not counting the header, consists of trailers only
+func endsWithTrailerBlock(lines []string) bool {
+	start := len(lines)
+	for start > 1 && strings.TrimSpace(lines[start-1]) != "" {
+		start--
+	}
+	if start <= 1 || start == len(lines) {
+		return false
+	}
+	for _, line := range lines[start:] {
+		if !trailerPattern.MatchString(line) {
+			return false
+		}
+	}
+	return true
+}
+
+// trailerKey normalizes a line for deduplication: letter case and the
+// spacing around the separator do not make a trailer different
+func trailerKey(line string) string {
+	key, value, ok := strings.Cut(line, ":")
+	if !ok {
+		return strings.ToLower(strings.TrimSpace(line))
+	}
+	return strings.ToLower(strings.TrimSpace(key)) + ": " + strings.ToLower(strings.Join(strings.Fields(value), " "))
+}
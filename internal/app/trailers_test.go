@@ -0,0 +1,232 @@
+package app
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"ai-commit-message-generator/internal/ai"
+	"ai-commit-message-generator/internal/git"
+)
+
+func TestAppendTrailers(t *testing.T) {
+	signoff := "Signed-off-by: Jane Doe <jane@example.com>"
+
+	tests := []struct {
+		name     string
+		message  string
+		trailers []string
+		expected string
+	}{
+		{
+			name:     "Single-line message",
+			message:  "feat(auth): add login",
+			trailers: []string{signoff},
+			expected: "feat(auth): add login\n\n" + signoff,
+		},
+		{
+			name:     "Body",
+			message:  "feat(auth): add login\n\nUsers can sign in with a password.\n",
+			trailers: []string{"Co-authored-by: Sam Roe <sam@example.com>", signoff},
+			expected: "feat(auth): add login\n\nUsers can sign in with a password.\n\nCo-authored-by: Sam Roe <sam@example.com>\n" + signoff,
+		},
+		{
+			name:     "Joins the existing trailer block",
+			message:  "fix: handle empty input\n\nRefs: #12",
+			trailers: []string{signoff},
+			expected: "fix: handle empty input\n\nRefs: #12\n" + signoff,
+		},
+		{
+			name:     "Body with a colon is not a trailer block",
+			message:  "fix: handle empty input\n\nNote: the parser returned nil.\nIt now returns an error.",
+			trailers: []string{signoff},
+			expected: "fix: handle empty input\n\nNote: the parser returned nil.\nIt now returns an error.\n\n" + signoff,
+		},
+		{
+			name:     "Skips a trailer the model already wrote",
+			message:  "feat: add login\n\nCo-Authored-By: Sam Roe <SAM@example.com>",
+			trailers: []string{"Co-authored-by: Sam Roe <sam@example.com>", signoff},
+			expected: "feat: add login\n\nCo-Authored-By: Sam Roe <SAM@example.com>\n" + signoff,
+		},
+		{
+			name:     "Skips duplicates among the trailers",
+			message:  "feat: add login",
+			trailers: []string{signoff, signoff},
+			expected: "feat: add login\n\n" + signoff,
+		},
+		{
+			name:     "Nothing to add",
+			message:  "feat: add login\n\n" + signoff + "\n",
+			trailers: []string{signoff},
+			expected: "feat: add login\n\n" + signoff + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := appendTrailers(tt.message, tt.trailers)
+			if got != tt.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestApp_AddCoAuthors(t *testing.T) {
+	book := map[string]string{"jane": "Jane Doe <jane@example.com>"}
+
+	tests := []struct {
+		name        string
+		book        map[string]string
+		values      []string
+		expected    []string
+		expectError string
+	}{
+		{
+			name:     "Shortcut and address",
+			book:     book,
+			values:   []string{"jane", "Sam Roe <sam@example.com>"},
+			expected: []string{"Jane Doe <jane@example.com>", "Sam Roe <sam@example.com>"},
+		},
+		{name: "Unknown shortcut", book: book, values: []string{"sam"}, expectError: `unknown co-author "sam" (address book: jane)`},
+		{name: "No address book", values: []string{"sam"}, expectError: "add it to co_authors in the config"},
+		{name: "Malformed address", values: []string{"Sam <sam@example.com"}, expectError: `not in the form "Name <email>"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			application := NewApp(&MockGit{}, nil, nil, &MockAI{})
+			application.AddressBook = tt.book
+
+			err := application.AddCoAuthors(tt.values)
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Fatalf("expected error containing %q, got %v", tt.expectError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if strings.Join(application.CoAuthors, "|") != strings.Join(tt.expected, "|") {
+				t.Errorf("expected %v, got %v", tt.expected, application.CoAuthors)
+			}
+		})
+	}
+}
+
+func TestApp_Run_Trailers(t *testing.T) {
+	tests := []struct {
+		name            string
+		response        string
+		signoff         bool
+		identityErr     error
+		expectedMessage string
+		expectError     string
+	}{
+		{
+			name:            "Configured trailers, co-author and sign-off",
+			response:        "feat(auth): add login",
+			signoff:         true,
+			expectedMessage: "feat(auth): add login\n\nRefs: #12\nCo-authored-by: Sam Roe <sam@example.com>\nSigned-off-by: Jane Doe <jane@example.com>",
+		},
+		{
+			name:            "Without sign-off",
+			response:        "feat(auth): add login\n\nCo-authored-by: Sam Roe <sam@example.com>",
+			expectedMessage: "feat(auth): add login\n\nCo-authored-by: Sam Roe <sam@example.com>\nRefs: #12",
+		},
+		{
+			name:        "No identity to sign off with",
+			response:    "feat(auth): add login",
+			signoff:     true,
+			identityErr: errors.New("git user name is not configured"),
+			expectError: "failed to sign off: git user name is not configured",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var committed string
+			mockGit := &MockGit{
+				IsInsideRepoFunc:     func() (bool, error) { return true, nil },
+				HasStagedChangesFunc: func() (bool, error) { return true, nil },
+				GetStagedDiffFunc:    func() (string, error) { return "diff --git a/login.go b/login.go", nil },
+				GetUserIdentityFunc: func() (*git.Identity, error) {
+					if tt.identityErr != nil {
+						return nil, tt.identityErr
+					}
+					return &git.Identity{Name: "Jane Doe", Email: "jane@example.com"}, nil
+				},
+				CommitWithMessageFunc: func(message string) error {
+					committed = message
+					return nil
+				},
+			}
+			mockConfig := &MockConfig{
+				LoadRulesFunc: func() (string, error) { return "", nil },
+			}
+			mockAI := &MockAI{
+				GenerateCommitMessageFunc: func(req ai.CommitRequest) (string, error) {
+					return tt.response, nil
+				},
+			}
+			application := NewApp(mockGit, mockConfig, nil, mockAI)
+			application.Quiet = true
+			application.Signoff = tt.signoff
+			application.Trailers = []string{"Refs: #12"}
+			application.CoAuthors = []string{"Sam Roe <sam@example.com>"}
+
+			var err error
+			captureStdout(t, func() {
+				err = application.Run(RunOptions{Yes: true})
+			})
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Fatalf("expected error containing %q, got %v", tt.expectError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if committed != tt.expectedMessage {
+				t.Errorf("expected commit message:\n%s\ngot:\n%s", tt.expectedMessage, committed)
+			}
+		})
+	}
+}
+
+func TestApp_Run_DiffDigest(t *testing.T) {
+	diff := "diff --git a/login.go b/login.go\n+func Login() {}"
+	mockGit := &MockGit{
+		IsInsideRepoFunc:     func() (bool, error) { return true, nil },
+		HasStagedChangesFunc: func() (bool, error) { return true, nil },
+		GetStagedDiffFunc:    func() (string, error) { return diff, nil },
+	}
+	var prompted string
+	mockAI := &MockAI{
+		GenerateCommitMessageFunc: func(req ai.CommitRequest) (string, error) {
+			prompted = req.Diff
+			return "feat(auth): add login", nil
+		},
+	}
+	application := NewApp(mockGit, &MockConfig{LoadRulesFunc: func() (string, error) { return "", nil }}, nil, mockAI)
+	application.Quiet = true
+	application.IncludeDiffDigest = true
+	application.Trailers = []string{"Refs: #12"}
+
+	output := captureStdout(t, func() {
+		if err := application.Run(RunOptions{}); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	})
+
+	expected := "feat(auth): add login\n\nRefs: #12\nX-Diff-SHA256: " + diffHash(prompted)
+	if strings.TrimSpace(output) != expected {
+		t.Errorf("expected message:\n%s\ngot:\n%s", expected, output)
+	}
+	if !regexp.MustCompile(`\nX-Diff-SHA256: [0-9a-f]{64}$`).MatchString(strings.TrimSpace(output)) {
+		t.Errorf("expected a 64 digit hex digest, got:\n%s", output)
+	}
+}
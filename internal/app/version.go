@@ -0,0 +1,206 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"time"
+
+	"ai-commit-message-generator/internal/logging"
+)
+
+// Build metadata, set when building a release with
+//
+//	-ldflags "-X ai-commit-message-generator/internal/app.Version=v1.2.3
+//	  -X ai-commit-message-generator/internal/app.Commit=abc1234
+//	  -X ai-commit-message-generator/internal/app.BuildDate=2024-01-02T15:04:05Z"
+var (
+	Version   string
+	Commit    string
+	BuildDate string
+)
+
+// DevelVersion is the version of a build without the ldflags above
+const DevelVersion = "devel"
+
+// releasesURL is the GitHub API endpoint of the latest release
+var releasesURL = "https://api.github.com/repos/AllaySahoo222/AI-Commit-Message-Generator/releases/latest"
+
+// releasesPage is where a newer release can be downloaded
+const releasesPage = "https://github.com/AllaySahoo222/AI-Commit-Message-Generator/releases/latest"
+
+// updateCheckTimeout caps the request for the latest release
+const updateCheckTimeout = 3 * time.Second
+
+// BuildInfo describes the running binary
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildDate string `json:"build_date"`
+	GoVersion string `json:"go_version"`
+	Platform  string `json:"platform"`
+	// Latest is the newest release, set when the update check ran
+	Latest string `json:"latest,omitempty"`
+}
+
+// CurrentBuild returns the build metadata of the running binary. Without
+// ldflags the version is DevelVersion, and the commit and date come from
+// the VCS information go build embeds, if any.
+func CurrentBuild() BuildInfo {
+	info := BuildInfo{
+		Version:   Version,
+		Commit:    Commit,
+		BuildDate: BuildDate,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	if info.Version == "" {
+		info.Version = DevelVersion
+	}
+	if build, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range build.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = setting.Value
+			case setting.Key == "vcs.time" && info.BuildDate == "":
+				info.BuildDate = setting.Value
+			}
+		}
+	}
+	if len(info.Commit) > 12 {
+		info.Commit = info.Commit[:12]
+	}
+	if info.Commit == "" {
+		info.Commit = "unknown"
+	}
+	if info.BuildDate == "" {
+		info.BuildDate = "unknown"
+	}
+	return info
+}
+
+// VersionOptions controls the version command
+type VersionOptions struct {
+	// JSON prints the build metadata as a JSON object
+	JSON bool
+}
+
+// PrintVersion prints the build metadata. With CheckUpdates it also asks
+// GitHub for the latest release and notes a newer one on stderr.
+func (a *App) PrintVersion(opts VersionOptions) error {
+	info := CurrentBuild()
+	notice := ""
+	if a.CheckUpdates {
+		latest, err := latestRelease(releasesURL)
+		if err != nil {
+			a.log().Warn("could not check for updates", logging.KeyStage, "update", logging.KeyError, err)
+		} else {
+			info.Latest = latest
+			notice = updateNotice(info.Version, latest)
+		}
+	}
+
+	if opts.JSON {
+		data, err := json.MarshalIndent(info, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode version: %w", err)
+		}
+		fmt.Println(string(data))
+	} else {
+		fmt.Printf("generate-commit %s\n", info.Version)
+		fmt.Printf("  commit:   %s\n", info.Commit)
+		fmt.Printf("  built:    %s\n", info.BuildDate)
+		fmt.Printf("  go:       %s\n", info.GoVersion)
+		fmt.Printf("  platform: %s\n", info.Platform)
+	}
+	if notice != "" {
+		fmt.Fprintln(os.Stderr, notice)
+	}
+	return nil
+}
+
+// UpdateCheck is a request for the latest release running in the
+// background. A nil UpdateCheck never reports anything.
+type UpdateCheck struct {
+	done   chan struct{}
+	notice string
+}
+
+// StartUpdateCheck asks GitHub for the latest release in the background
+// when CheckUpdates is set, and returns nil otherwise
+func (a *App) StartUpdateCheck() *UpdateCheck {
+	if !a.CheckUpdates {
+		return nil
+	}
+	check := &UpdateCheck{done: make(chan struct{})}
+	current, url := CurrentBuild().Version, releasesURL
+	go func() {
+		defer close(check.done)
+		if latest, err := latestRelease(url); err == nil {
+			check.notice = updateNotice(current, latest)
+		}
+	}()
+	return check
+}
+
+// Notice returns the one-line notice of a newer release, or "" when there
+// is none or the check has not finished. It never waits for the request.
+func (c *UpdateCheck) Notice() string {
+	if c == nil {
+		return ""
+	}
+	select {
+	case <-c.done:
+		return c.notice
+	default:
+		return ""
+	}
+}
+
+// latestRelease returns the tag of the latest release at url
+func latestRelease(url string) (string, error) {
+	client := &http.Client{Timeout: updateCheckTimeout}
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch the latest release: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to fetch the latest release: %s", resp.Status)
+	}
+
+	var release struct {
+		TagName string `json:"tag_name"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&release); err != nil {
+		return "", fmt.Errorf("failed to parse the latest release: %w", err)
+	}
+	if release.TagName == "" {
+		return "", fmt.Errorf("the latest release has no tag")
+	}
+	return release.TagName, nil
+}
+
+// updateNotice returns a line announcing latest when it is newer than
+// current, or "". A devel build or a version that is not semantic is never
+// told to update.
+func updateNotice(current, latest string) string {
+	have, ok := parseSemver(current)
+	if !ok {
+		return ""
+	}
+	newest, ok := parseSemver(latest)
+	if !ok || newest.compare(have) <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("A newer version of generate-commit is available: %s (you have %s). Download it from %s", latest, current, releasesPage)
+}
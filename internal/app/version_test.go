@@ -0,0 +1,175 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// setBuild sets the ldflags variables for the duration of a test
+func setBuild(t *testing.T, version, commit, date string) {
+	t.Helper()
+	origVersion, origCommit, origDate := Version, Commit, BuildDate
+	t.Cleanup(func() { Version, Commit, BuildDate = origVersion, origCommit, origDate })
+	Version, Commit, BuildDate = version, commit, date
+}
+
+// serveLatestRelease points the update check at a server answering with
+// tag, or with status when it is not 200
+func serveLatestRelease(t *testing.T, tag string, status int) {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"tag_name": tag, "name": "Release " + tag})
+	}))
+	t.Cleanup(server.Close)
+	original := releasesURL
+	t.Cleanup(func() { releasesURL = original })
+	releasesURL = server.URL
+}
+
+func TestCurrentBuild(t *testing.T) {
+	t.Run("Set with ldflags", func(t *testing.T) {
+		setBuild(t, "v1.4.0", "0123456789abcdef", "2024-05-01T10:00:00Z")
+		info := CurrentBuild()
+		expected := BuildInfo{
+			Version:   "v1.4.0",
+			Commit:    "0123456789ab",
+			BuildDate: "2024-05-01T10:00:00Z",
+			GoVersion: runtime.Version(),
+			Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+		}
+		if info != expected {
+			t.Errorf("expected %+v, got %+v", expected, info)
+		}
+	})
+
+	t.Run("Fallback without ldflags", func(t *testing.T) {
+		setBuild(t, "", "", "")
+		info := CurrentBuild()
+		if info.Version != DevelVersion {
+			t.Errorf("expected version %q, got %q", DevelVersion, info.Version)
+		}
+		if info.Commit == "" || info.BuildDate == "" {
+			t.Errorf("expected a commit and build date, even if unknown, got %+v", info)
+		}
+		if info.GoVersion != runtime.Version() {
+			t.Errorf("expected Go version %q, got %q", runtime.Version(), info.GoVersion)
+		}
+	})
+}
+
+func TestUpdateNotice(t *testing.T) {
+	tests := []struct {
+		name    string
+		current string
+		latest  string
+		notice  bool
+	}{
+		{name: "Newer release", current: "v1.2.0", latest: "v1.3.0", notice: true},
+		{name: "Release of a pre-release", current: "v1.3.0-rc.1", latest: "v1.3.0", notice: true},
+		{name: "Same version", current: "v1.3.0", latest: "v1.3.0"},
+		{name: "Ahead of the release", current: "v1.4.0", latest: "v1.3.0"},
+		{name: "Devel build", current: DevelVersion, latest: "v1.3.0"},
+		{name: "Odd tag", current: "v1.2.0", latest: "nightly"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notice := updateNotice(tt.current, tt.latest)
+			if tt.notice && !strings.Contains(notice, tt.latest) {
+				t.Errorf("expected a notice naming %s, got %q", tt.latest, notice)
+			}
+			if !tt.notice && notice != "" {
+				t.Errorf("expected no notice, got %q", notice)
+			}
+		})
+	}
+}
+
+func TestApp_PrintVersion(t *testing.T) {
+	t.Run("Text", func(t *testing.T) {
+		setBuild(t, "v1.2.0", "abc1234", "2024-05-01")
+		application := NewApp(&MockGit{}, &MockConfig{}, nil, &MockAI{})
+
+		output := captureStdout(t, func() {
+			if err := application.PrintVersion(VersionOptions{}); err != nil {
+				t.Fatalf("PrintVersion failed: %v", err)
+			}
+		})
+		for _, want := range []string{"generate-commit v1.2.0", "commit:   abc1234", "built:    2024-05-01", runtime.Version(), runtime.GOOS + "/" + runtime.GOARCH} {
+			if !strings.Contains(output, want) {
+				t.Errorf("expected %q in output:\n%s", want, output)
+			}
+		}
+	})
+
+	t.Run("JSON with an update check", func(t *testing.T) {
+		setBuild(t, "v1.2.0", "abc1234", "2024-05-01")
+		serveLatestRelease(t, "v1.3.0", http.StatusOK)
+		application := NewApp(&MockGit{}, &MockConfig{}, nil, &MockAI{})
+		application.CheckUpdates = true
+
+		var output string
+		stderr := captureStderr(t, func() {
+			output = captureStdout(t, func() {
+				if err := application.PrintVersion(VersionOptions{JSON: true}); err != nil {
+					t.Fatalf("PrintVersion failed: %v", err)
+				}
+			})
+		})
+		var info BuildInfo
+		if err := json.Unmarshal([]byte(output), &info); err != nil {
+			t.Fatalf("expected JSON output, got %q: %v", output, err)
+		}
+		if info.Version != "v1.2.0" || info.Commit != "abc1234" || info.Latest != "v1.3.0" {
+			t.Errorf("unexpected build info %+v", info)
+		}
+		if !strings.Contains(stderr, "A newer version of generate-commit is available: v1.3.0") {
+			t.Errorf("expected an update notice on stderr, got %q", stderr)
+		}
+	})
+
+	t.Run("Failed update check", func(t *testing.T) {
+		setBuild(t, "v1.2.0", "", "")
+		serveLatestRelease(t, "", http.StatusForbidden)
+		application := NewApp(&MockGit{}, &MockConfig{}, nil, &MockAI{})
+		application.CheckUpdates = true
+
+		var err error
+		stderr := captureStderr(t, func() {
+			captureStdout(t, func() {
+				err = application.PrintVersion(VersionOptions{})
+			})
+		})
+		if err != nil {
+			t.Fatalf("expected the version to be printed anyway, got %v", err)
+		}
+		if !strings.Contains(stderr, "could not check for updates") || !strings.Contains(stderr, "403") {
+			t.Errorf("expected a warning about the failed check, got %q", stderr)
+		}
+	})
+}
+
+func TestApp_StartUpdateCheck(t *testing.T) {
+	setBuild(t, "v1.2.0", "", "")
+	serveLatestRelease(t, "v2.0.0", http.StatusOK)
+
+	application := NewApp(&MockGit{}, &MockConfig{}, nil, &MockAI{})
+	if check := application.StartUpdateCheck(); check != nil || check.Notice() != "" {
+		t.Fatal("expected no update check without CheckUpdates")
+	}
+
+	application.CheckUpdates = true
+	check := application.StartUpdateCheck()
+	<-check.done
+	if notice := check.Notice(); !strings.Contains(notice, "v2.0.0") {
+		t.Errorf("expected a notice for v2.0.0, got %q", notice)
+	}
+}
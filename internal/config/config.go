@@ -5,7 +5,12 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
+
+	"ai-commit-message-generator/internal/ai"
+	"ai-commit-message-generator/internal/git"
+	"ai-commit-message-generator/internal/logging"
 )
 
 // Config represents the application configuration
@@ -14,41 +19,493 @@ type Config struct {
 	Model          string `json:"model"`
 	BaseURL        string `json:"base_url"`
 	TimeoutSeconds int    `json:"timeout_seconds"`
+	// DiffContextLines is the number of unchanged lines around each hunk
+	// of the staged diff
+	DiffContextLines int `json:"diff_context_lines"`
+	// MaxFileBytes is the size above which a staged file's content is left
+	// out of the diff and its change summarized in a line. Zero means no
+	// limit.
+	MaxFileBytes int64 `json:"max_file_bytes"`
+	// SystemPrompt replaces (or with SystemPromptMode "prepend", goes
+	// before) the built-in persona line of the prompt
+	SystemPrompt     string `json:"system_prompt,omitempty"`
+	SystemPromptMode string `json:"system_prompt_mode,omitempty"`
+	// APIKeySource names where to read the API key instead of APIKey:
+	// keychain, env:VAR, file:PATH or git-config:KEY
+	APIKeySource string `json:"api_key_source,omitempty"`
+	// TestPathPatterns are globs that mark staged paths as test files,
+	// replacing the built-in heuristics
+	TestPathPatterns []string `json:"test_path_patterns,omitempty"`
+	// TestFilePolicy is how test files affect the commit type:
+	// prefer_test_type_when_only_tests (default) or fold_into_main
+	TestFilePolicy string `json:"test_file_policy,omitempty"`
+	// Stream asks the server to stream the response, so progress can show
+	// a token counter
+	Stream bool `json:"stream,omitempty"`
+	// FastPath gives docs-, config- and dependency-only changes a shorter
+	// prompt with a fixed type. Unset means enabled.
+	FastPath *bool `json:"fast_path,omitempty"`
+	// FastPathDocs, FastPathConfig and FastPathDeps replace the built-in
+	// globs of the fast path changesets
+	FastPathDocs   []string `json:"fast_path_docs,omitempty"`
+	FastPathConfig []string `json:"fast_path_config,omitempty"`
+	FastPathDeps   []string `json:"fast_path_deps,omitempty"`
+	// GitBackend selects how the repository is read: auto (default),
+	// go-git or exec
+	GitBackend string `json:"git_backend,omitempty"`
+	// CommitBackend selects how commits are made: go-git (default) or exec,
+	// which runs git commit with the repository's hooks
+	CommitBackend string `json:"commit_backend,omitempty"`
+	// ProtectedBranches are globs of local branches whose commits reword
+	// leaves alone unless forced. Empty means main and master.
+	ProtectedBranches []string `json:"protected_branches,omitempty"`
+	// MaxBodyLength caps the characters of a generated message body. Zero
+	// means no limit.
+	MaxBodyLength int `json:"max_body_length,omitempty"`
+	// BodyOverflow is what happens to a longer body: truncate (default) or
+	// regenerate
+	BodyOverflow string `json:"body_overflow,omitempty"`
+	// BranchPattern lays out suggested branch names from {type}, {ticket}
+	// and {slug}. Empty means {type}/{ticket}-{slug}.
+	BranchPattern string `json:"branch_pattern,omitempty"`
+	// AnalyzeGo parses the staged Go files and names the top-level
+	// declarations they add or change in the prompt
+	AnalyzeGo bool `json:"analyze_go,omitempty"`
+	// PrependDiffStat puts a git diff --stat style summary above the diff
+	// in the prompt
+	PrependDiffStat bool `json:"prepend_diff_stat,omitempty"`
+	// Signoff appends a Signed-off-by trailer with user.name and
+	// user.email to every generated message
+	Signoff bool `json:"signoff,omitempty"`
+	// IncludeDiffDigest appends an X-Diff-SHA256 trailer with the hash of
+	// the diff the message was generated from
+	IncludeDiffDigest bool `json:"include_diff_digest,omitempty"`
+	// CoAuthors is an address book of --co-author shortcuts, e.g.
+	// "jane": "Jane Doe <jane@example.com>"
+	CoAuthors map[string]string `json:"co_authors,omitempty"`
+	// Trailers are "Key: value" lines appended to every generated message
+	Trailers []string `json:"trailers,omitempty"`
+	// Examples are few-shot demonstrations of the team's message style. Like
+	// co_authors they are edited by hand.
+	Examples []ai.Example `json:"examples,omitempty"`
+	// ScopeMap maps path prefixes to scopes, e.g. "internal/ai": "ai". The
+	// scope of the most-changed mapped paths is given to the model.
+	ScopeMap map[string]string `json:"scope_map,omitempty"`
+	// SuggestSplits lets the model suggest splitting a change into several
+	// commits instead of writing a message. Unset means enabled.
+	SuggestSplits *bool `json:"suggest_splits,omitempty"`
+	// MergeExistingMessage has the prepare-commit-msg hook extend a message
+	// already in the message file, such as a ticket line, instead of
+	// replacing it. Unset means enabled.
+	MergeExistingMessage *bool `json:"merge_existing_message,omitempty"`
+	// HeaderFormat is a text/template layout of the first line from
+	// {{.Type}}, {{.Scope}} and {{.Description}}. Empty means
+	// {{.Type}}({{.Scope}}): {{.Description}}.
+	HeaderFormat string `json:"header_format,omitempty"`
+	// Types are the commit types messages may use. Empty means the
+	// Conventional Commits types.
+	Types []string `json:"types,omitempty"`
+	// Scopes are the only scopes messages may use. Empty means any scope.
+	Scopes []string `json:"scopes,omitempty"`
+	// ScopePolicy is optional (default), required or forbidden: whether
+	// every message must have a scope, or none may
+	ScopePolicy string `json:"scope_policy,omitempty"`
+	// SubjectCapitalize starts the description in the first line with a
+	// capital letter instead of a lower-case one
+	SubjectCapitalize bool `json:"subject_capitalize,omitempty"`
+	// SubjectTrailingPeriod ends the description in the first line with a
+	// period instead of none
+	SubjectTrailingPeriod bool `json:"subject_trailing_period,omitempty"`
+	// KeepAlive is how long Ollama keeps the model loaded after a request:
+	// seconds (-1 for always) or a duration such as 5m. Empty leaves it to
+	// the server.
+	KeepAlive string `json:"keep_alive,omitempty"`
+	// RequestsPerMinute caps the API calls made per minute, retries
+	// included. Zero means no limit.
+	RequestsPerMinute int `json:"requests_per_minute,omitempty"`
+	// Cache lets identical generations running at the same time share one
+	// API call
+	Cache bool `json:"cache,omitempty"`
+	// CheckUpdates asks GitHub for the latest release and prints a notice
+	// when a newer one is out
+	CheckUpdates bool `json:"check_updates,omitempty"`
+	// AuthHeader is the header the API key is sent in. Empty means
+	// Authorization.
+	AuthHeader string `json:"auth_header,omitempty"`
+	// AuthScheme is the prefix of the key in AuthHeader, e.g. Bearer. Empty
+	// means Bearer for Authorization and no prefix for other headers.
+	AuthScheme string `json:"auth_scheme,omitempty"`
+	// LogFormat is text (default) or json: how warnings and the --verbose
+	// diagnostics are written to stderr
+	LogFormat string `json:"log_format,omitempty"`
+	// Profiles are named sets of keys that --profile overlays on the rest
+	// of the configuration
+	Profiles map[string]map[string]json.RawMessage `json:"profiles,omitempty"`
+	// Paths maps globs to the overrides for changes that all fall under
+	// them. Like profiles it is edited by hand.
+	Paths map[string]PathConfig `json:"paths,omitempty"`
+	// Profile names the profile to overlay when --profile does not
+	Profile string `json:"profile,omitempty"`
+}
+
+// Store reads and writes the configuration and the API key.
+// ConfigLoader implements it; tests substitute their own.
+type Store interface {
+	// ConfigExists reports whether the repository has a config file
+	ConfigExists() (bool, error)
+	LoadConfig() (*Config, error)
+	// LoadEffective is LoadConfig along with where each value came from
+	LoadEffective() (*Config, []Value, error)
+	SaveDefaultConfig(repoRoot string) error
+	SaveConfig(repoRoot string, config *Config) error
+	// Path is the config file that is read and written
+	Path() (string, error)
+	ResolveAPIKey(cfg *Config) (string, error)
+	StoreAPIKey(key string, fileFallback bool) (string, error)
 }
 
 // ConfigLoader handles loading configuration from file, env, or defaults
-type ConfigLoader struct{}
+type ConfigLoader struct {
+	// path is an explicit config file that overrides the repo root lookup
+	path string
+	// keyring replaces the OS keychain when set
+	keyring Keyring
+	// profile names the profile to overlay, if any
+	profile string
+}
 
 // NewConfigLoader creates a new config loader
 func NewConfigLoader() *ConfigLoader {
 	return &ConfigLoader{}
 }
 
-// LoadConfig loads configuration with priority: file > env > defaults
+// NewConfigLoaderWithPath creates a config loader that reads the given file
+// instead of looking for .commit-generator-config in the repo root
+func NewConfigLoaderWithPath(path string) *ConfigLoader {
+	return &ConfigLoader{path: path}
+}
+
+// Config layers, from lowest to highest precedence
+const (
+	SourceDefault = "default"
+	SourceGlobal  = "global"
+	SourceRepo    = "repo"
+	SourceProfile = "profile"
+	SourceEnv     = "env"
+)
+
+// envPrefix starts the environment variables that override config keys
+const envPrefix = "AI_COMMIT_"
+
+// EnvVar returns the environment variable that overrides key: the key in
+// upper case after AI_COMMIT_, e.g. AI_COMMIT_MAX_FILE_BYTES. These take
+// precedence over every config file.
+func EnvVar(key string) string {
+	return envPrefix + strings.ToUpper(key)
+}
+
+// Value is the effective value of a configuration key and where it came from
+type Value struct {
+	Key   string
+	Value string
+	// Source is the layer that set the value: SourceDefault, SourceGlobal,
+	// SourceRepo, SourceProfile or SourceEnv
+	Source string
+	// Origin is the file or environment variable behind Source
+	Origin string
+}
+
+// LoadConfig loads the effective configuration. Layers are applied in this
+// order, each overriding the keys set by the ones before it:
+//
+//  1. Defaults
+//  2. The global config (GlobalConfigPath), if it exists
+//  3. The selected profile, when the global config defines it
+//  4. The repository's .commit-generator-config, or the explicit path
+//  5. The selected profile, when the repository config defines it
+//  6. Environment variables named by EnvVar, e.g. AI_COMMIT_MODEL, one for
+//     every key of the Schema
+//
+// The profile is the one named with SetProfile, else by AI_COMMIT_PROFILE,
+// else by the profile key of the config files.
+//
+// OLLAMA_API_KEY is used when no layer sets an API key.
 func (c *ConfigLoader) LoadConfig() (*Config, error) {
+	config, _, err := c.LoadEffective()
+	return config, err
+}
+
+// LoadEffective loads the configuration like LoadConfig and also reports the
+// source of every key, in schema order
+func (c *ConfigLoader) LoadEffective() (*Config, []Value, error) {
 	config := &Config{
-		Model:          "gpt-oss:120b",
-		BaseURL:        "http://localhost:11434/api/generate",
-		TimeoutSeconds: 60,
+		Model:            "gpt-oss:120b",
+		BaseURL:          "http://localhost:11434/api/generate",
+		TimeoutSeconds:   60,
+		DiffContextLines: 3,
+		MaxFileBytes:     git.DefaultMaxFileBytes,
 	}
+	sources := make(map[string]Value)
 
-	// Try to load from config file
-	repoRoot, err := findRepoRoot()
-	if err == nil {
-		configPath := filepath.Join(repoRoot, ".commit-generator-config")
-		if fileData, err := os.ReadFile(configPath); err == nil {
-			if err := json.Unmarshal(fileData, config); err != nil {
-				return nil, fmt.Errorf("failed to parse config file: %w", err)
-			}
+	// The global config is optional, as is finding where it lives
+	var global, repo *configFile
+	if globalPath, err := GlobalConfigPath(); err == nil {
+		if global, err = readConfigFile(globalPath, false); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if c.path != "" {
+		// An explicitly requested file must exist; never fall back to defaults
+		var err error
+		if repo, err = readConfigFile(c.path, true); err != nil {
+			return nil, nil, err
 		}
+	} else if repoRoot, err := findRepoRoot(); err == nil {
+		if repo, err = readConfigFile(filepath.Join(repoRoot, ".commit-generator-config"), false); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	profile, err := c.selectProfile(global, repo)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	// Override with environment variable if config file doesn't have it
-	if config.APIKey == "" {
+	// A profile from the global config sits between the global and the
+	// repository config, so the repository's settings still win over
+	// personal presets. One the repository config defines overlays it.
+	if err := global.apply(config, sources, SourceGlobal); err != nil {
+		return nil, nil, err
+	}
+	if profile != nil && profile.definedIn == global {
+		if err := applyProfile(config, sources, profile); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := repo.apply(config, sources, SourceRepo); err != nil {
+		return nil, nil, err
+	}
+	if profile != nil && profile.definedIn == repo {
+		if err := applyProfile(config, sources, profile); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// Environment values are parsed like config set values, so
+	// AI_COMMIT_TIMEOUT_SECONDS=2m and AI_COMMIT_TRAILERS="Refs: #1, Refs: #2"
+	// work
+	for _, spec := range Schema {
+		env := EnvVar(spec.Name)
+		value := os.Getenv(env)
+		if value == "" {
+			continue
+		}
+		parsed, err := spec.Parse(value)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", env, err)
+		}
+		encoded, _ := json.Marshal(parsed)
+		if err := json.Unmarshal([]byte(fmt.Sprintf("{%q: %s}", spec.Name, encoded)), config); err != nil {
+			return nil, nil, fmt.Errorf("failed to apply %s: %w", env, err)
+		}
+		sources[spec.Name] = Value{Source: SourceEnv, Origin: env}
+	}
+
+	if c.profile != "" {
+		config.Profile = c.profile
+		sources[profileKey] = Value{Source: SourceProfile, Origin: "--profile"}
+	}
+
+	// Legacy fallback for the API key
+	if config.APIKey == "" && os.Getenv("OLLAMA_API_KEY") != "" {
 		config.APIKey = os.Getenv("OLLAMA_API_KEY")
+		sources["api_key"] = Value{Source: SourceEnv, Origin: "OLLAMA_API_KEY"}
+	}
+
+	if config.DiffContextLines < 0 {
+		return nil, nil, fmt.Errorf("invalid diff_context_lines %d: must not be negative", config.DiffContextLines)
+	}
+
+	if config.MaxFileBytes < 0 {
+		return nil, nil, fmt.Errorf("invalid max_file_bytes %d: must not be negative", config.MaxFileBytes)
+	}
+
+	if _, err := parseAPIKeySource(config.APIKeySource); err != nil {
+		return nil, nil, fmt.Errorf("invalid api_key_source: %w", err)
 	}
 
-	return config, nil
+	if err := git.ValidateGlobs(config.TestPathPatterns); err != nil {
+		return nil, nil, fmt.Errorf("invalid test_path_patterns: %w", err)
+	}
+	if _, err := parseTestFilePolicy(config.TestFilePolicy); err != nil {
+		return nil, nil, fmt.Errorf("invalid test_file_policy: %w", err)
+	}
+	for key, patterns := range map[string][]string{
+		"fast_path_docs":     config.FastPathDocs,
+		"fast_path_config":   config.FastPathConfig,
+		"fast_path_deps":     config.FastPathDeps,
+		"protected_branches": config.ProtectedBranches,
+	} {
+		if err := git.ValidateGlobs(patterns); err != nil {
+			return nil, nil, fmt.Errorf("invalid %s: %w", key, err)
+		}
+	}
+
+	if config.MaxBodyLength < 0 {
+		return nil, nil, fmt.Errorf("invalid max_body_length %d: must not be negative", config.MaxBodyLength)
+	}
+	switch config.BodyOverflow {
+	case "", "truncate", "regenerate":
+	default:
+		return nil, nil, fmt.Errorf("invalid body_overflow %q (expected \"truncate\" or \"regenerate\")", config.BodyOverflow)
+	}
+	switch config.LogFormat {
+	case "", logging.FormatText, logging.FormatJSON:
+	default:
+		return nil, nil, fmt.Errorf("invalid log_format %q (expected %q or %q)", config.LogFormat, logging.FormatText, logging.FormatJSON)
+	}
+
+	if _, err := parseBranchPattern(config.BranchPattern); err != nil {
+		return nil, nil, fmt.Errorf("invalid branch_pattern: %w", err)
+	}
+
+	if _, err := parseHeaderFormat(config.HeaderFormat); err != nil {
+		return nil, nil, fmt.Errorf("invalid header_format: %w", err)
+	}
+	if err := validateTypes(config.Types); err != nil {
+		return nil, nil, fmt.Errorf("invalid types: %w", err)
+	}
+	if err := validateScopes(config.Scopes); err != nil {
+		return nil, nil, fmt.Errorf("invalid scopes: %w", err)
+	}
+	switch config.ScopePolicy {
+	case "", "optional", "required", "forbidden":
+	default:
+		return nil, nil, fmt.Errorf("invalid scope_policy %q (expected \"optional\", \"required\" or \"forbidden\")", config.ScopePolicy)
+	}
+	for _, pattern := range sortedPatterns(config.Paths) {
+		if err := validatePathConfig(pattern, config.Paths[pattern]); err != nil {
+			return nil, nil, err
+		}
+	}
+	if _, err := parseKeepAlive(config.KeepAlive); err != nil {
+		return nil, nil, fmt.Errorf("invalid keep_alive: %w", err)
+	}
+	if config.RequestsPerMinute < 0 {
+		return nil, nil, fmt.Errorf("invalid requests_per_minute %d: must not be negative", config.RequestsPerMinute)
+	}
+	if _, err := parseAuthHeader(config.AuthHeader); err != nil {
+		return nil, nil, fmt.Errorf("invalid auth_header: %w", err)
+	}
+
+	if _, err := git.ParseBackendKind(config.GitBackend); err != nil {
+		return nil, nil, fmt.Errorf("invalid git_backend: %w", err)
+	}
+	if _, err := git.ParseCommitBackendKind(config.CommitBackend); err != nil {
+		return nil, nil, fmt.Errorf("invalid commit_backend: %w", err)
+	}
+
+	switch config.SystemPromptMode {
+	case "", "replace", "prepend":
+	default:
+		return nil, nil, fmt.Errorf("invalid system_prompt_mode %q (expected \"replace\" or \"prepend\")", config.SystemPromptMode)
+	}
+
+	values, err := effectiveValues(config, sources)
+	if err != nil {
+		return nil, nil, err
+	}
+	return config, values, nil
+}
+
+// configFile is a config file read for one of the layers
+type configFile struct {
+	path   string
+	values map[string]json.RawMessage
+	lines  map[string]int
+}
+
+// readConfigFile reads and validates the config file at path. A missing
+// file is nil unless required.
+func readConfigFile(path string, required bool) (*configFile, error) {
+	fileData, err := os.ReadFile(path)
+	if os.IsNotExist(err) && !required {
+		return nil, nil
+	}
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("config file %s does not exist", path)
+		}
+		return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+
+	values, lines, err := decodeValues(path, fileData)
+	if err != nil {
+		return nil, err
+	}
+	if err := normalizeValues(path, values, lines); err != nil {
+		return nil, err
+	}
+	return &configFile{path: path, values: values, lines: lines}, nil
+}
+
+// apply sets the keys of the file in config and records them as coming
+// from source
+func (f *configFile) apply(config *Config, sources map[string]Value, source string) error {
+	if f == nil {
+		return nil
+	}
+	for _, key := range sortedKeys(f.values) {
+		if err := json.Unmarshal([]byte(fmt.Sprintf("{%q: %s}", key, f.values[key])), config); err != nil {
+			return lineError(f.path, f.lines[key], fmt.Errorf("invalid value for %s: %w", key, err))
+		}
+		sources[key] = Value{Source: source, Origin: f.path}
+	}
+	return nil
+}
+
+// effectiveValues lists every schema key with its value in config and the
+// source recorded for it
+func effectiveValues(config *Config, sources map[string]Value) ([]Value, error) {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode config: %w", err)
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to encode config: %w", err)
+	}
+
+	values := make([]Value, 0, len(Schema))
+	for _, spec := range Schema {
+		value, ok := sources[spec.Name]
+		if !ok {
+			value.Source = SourceDefault
+		}
+		value.Key = spec.Name
+		if r, set := raw[spec.Name]; set {
+			value.Value = rawString(r)
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// FastPathEnabled reports whether trivial changesets get the fast path
+func (c *Config) FastPathEnabled() bool {
+	return c.FastPath == nil || *c.FastPath
+}
+
+// SuggestSplitsEnabled reports whether the model may suggest a split
+func (c *Config) SuggestSplitsEnabled() bool {
+	return c.SuggestSplits == nil || *c.SuggestSplits
+}
+
+// MergeExistingMessageEnabled reports whether the hook extends a message
+// already in the message file
+func (c *Config) MergeExistingMessageEnabled() bool {
+	return c.MergeExistingMessage == nil || *c.MergeExistingMessage
 }
 
 // GetTimeout returns the timeout as a time.Duration
@@ -56,17 +513,28 @@ func (c *Config) GetTimeout() time.Duration {
 	return time.Duration(c.TimeoutSeconds) * time.Second
 }
 
+// DefaultConfig returns the configuration init writes when not asked
+func DefaultConfig() *Config {
+	return &Config{
+		APIKey:           os.Getenv("OLLAMA_API_KEY"), // Pre-fill from env if available
+		Model:            "gpt-oss:120b",
+		BaseURL:          "http://localhost:11434/api/generate",
+		TimeoutSeconds:   60,
+		DiffContextLines: 3,
+		MaxFileBytes:     git.DefaultMaxFileBytes,
+	}
+}
+
 // SaveDefaultConfig saves a default config file to the repo root
 func (c *ConfigLoader) SaveDefaultConfig(repoRoot string) error {
-	config := &Config{
-		APIKey:         os.Getenv("OLLAMA_API_KEY"), // Pre-fill from env if available
-		Model:          "gpt-oss:120b",
-		BaseURL:        "http://localhost:11434/api/generate",
-		TimeoutSeconds: 60,
-	}
+	return c.SaveConfig(repoRoot, DefaultConfig())
+}
 
+// SaveConfig writes config to .commit-generator-config in the repo root as
+// commented YAML, listing the keys it leaves unset commented out
+func (c *ConfigLoader) SaveConfig(repoRoot string, config *Config) error {
 	configPath := filepath.Join(repoRoot, ".commit-generator-config")
-	data, err := json.MarshalIndent(config, "", "  ")
+	data, err := renderConfig(config)
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
@@ -80,12 +548,15 @@ func (c *ConfigLoader) SaveDefaultConfig(repoRoot string) error {
 
 // ConfigExists checks if a config file already exists
 func (c *ConfigLoader) ConfigExists() (bool, error) {
-	repoRoot, err := findRepoRoot()
-	if err != nil {
-		return false, err
+	configPath := c.path
+	if configPath == "" {
+		repoRoot, err := findRepoRoot()
+		if err != nil {
+			return false, err
+		}
+		configPath = filepath.Join(repoRoot, ".commit-generator-config")
 	}
-	configPath := filepath.Join(repoRoot, ".commit-generator-config")
-	_, err = os.Stat(configPath)
+	_, err := os.Stat(configPath)
 	if err == nil {
 		return true, nil
 	}
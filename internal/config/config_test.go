@@ -1,11 +1,30 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
+// TestMain keeps the developer's global config and environment out of the
+// tests
+func TestMain(m *testing.M) {
+	configHome, err := os.MkdirTemp("", "ai-commit-config-home")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("XDG_CONFIG_HOME", configHome)
+	for _, spec := range Schema {
+		os.Unsetenv(EnvVar(spec.Name))
+	}
+
+	code := m.Run()
+	os.RemoveAll(configHome)
+	os.Exit(code)
+}
+
 func TestLoadConfig(t *testing.T) {
 	// Create a temporary directory
 	tmpDir, err := os.MkdirTemp("", "test-repo")
@@ -44,6 +63,10 @@ func TestLoadConfig(t *testing.T) {
 	if config.TimeoutSeconds != 60 {
 		t.Errorf("Expected default timeout 60, got %d", config.TimeoutSeconds)
 	}
+
+	if config.MaxFileBytes != 256*1024 {
+		t.Errorf("Expected default max file bytes 262144, got %d", config.MaxFileBytes)
+	}
 }
 
 func TestSaveAndLoadConfig(t *testing.T) {
@@ -127,3 +150,408 @@ func TestConfigExists(t *testing.T) {
 		t.Error("Config should exist after saving")
 	}
 }
+
+func TestLoadConfig_ExplicitPath(t *testing.T) {
+	// Run outside any repository to prove the repo lookup is bypassed
+	tmpDir := t.TempDir()
+	oldDir, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldDir)
+
+	configPath := filepath.Join(tmpDir, "ci-config.json")
+	content := `{"model": "llama3", "base_url": "http://ci:11434/api/generate", "timeout_seconds": 5}`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	t.Run("Existing file is loaded", func(t *testing.T) {
+		config, err := NewConfigLoaderWithPath(configPath).LoadConfig()
+		if err != nil {
+			t.Fatalf("Failed to load config: %v", err)
+		}
+		if config.Model != "llama3" {
+			t.Errorf("Expected model 'llama3', got '%s'", config.Model)
+		}
+		if config.BaseURL != "http://ci:11434/api/generate" {
+			t.Errorf("Expected base URL from file, got '%s'", config.BaseURL)
+		}
+		if config.TimeoutSeconds != 5 {
+			t.Errorf("Expected timeout 5, got %d", config.TimeoutSeconds)
+		}
+	})
+
+	t.Run("Missing file is an error", func(t *testing.T) {
+		missing := filepath.Join(tmpDir, "missing.json")
+		_, err := NewConfigLoaderWithPath(missing).LoadConfig()
+		if err == nil {
+			t.Fatal("Expected error for missing config file")
+		}
+		if !strings.Contains(err.Error(), "does not exist") {
+			t.Errorf("Expected 'does not exist' error, got %v", err)
+		}
+	})
+
+	t.Run("ConfigExists checks the explicit file", func(t *testing.T) {
+		exists, err := NewConfigLoaderWithPath(configPath).ConfigExists()
+		if err != nil || !exists {
+			t.Errorf("Expected config to exist, got %v (err %v)", exists, err)
+		}
+	})
+}
+
+func TestLoadConfig_SystemPrompt(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	tests := []struct {
+		name         string
+		content      string
+		expectedErr  string
+		expectedMode string
+	}{
+		{
+			name:    "Prompt without mode",
+			content: `{"system_prompt": "You are terse."}`,
+		},
+		{
+			name:         "Prepend mode",
+			content:      `{"system_prompt": "You are terse.", "system_prompt_mode": "prepend"}`,
+			expectedMode: "prepend",
+		},
+		{
+			name:        "Unknown mode",
+			content:     `{"system_prompt": "You are terse.", "system_prompt_mode": "append"}`,
+			expectedErr: ":1: invalid value for system_prompt_mode",
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(tmpDir, fmt.Sprintf("config-%d.json", i))
+			if err := os.WriteFile(configPath, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to write config: %v", err)
+			}
+
+			config, err := NewConfigLoaderWithPath(configPath).LoadConfig()
+			if tt.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+					t.Fatalf("Expected error containing %q, got %v", tt.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to load config: %v", err)
+			}
+			if config.SystemPrompt != "You are terse." {
+				t.Errorf("Expected system prompt from file, got %q", config.SystemPrompt)
+			}
+			if config.SystemPromptMode != tt.expectedMode {
+				t.Errorf("Expected mode %q, got %q", tt.expectedMode, config.SystemPromptMode)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_DiffContextLines(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	tests := []struct {
+		name        string
+		content     string
+		expected    int
+		expectedErr string
+	}{
+		{name: "Defaults to git's 3", content: `{}`, expected: 3},
+		{name: "Zero is kept", content: `{"diff_context_lines": 0}`, expected: 0},
+		{name: "Larger value", content: `{"diff_context_lines": 10}`, expected: 10},
+		{name: "Negative is rejected", content: `{"diff_context_lines": -1}`, expectedErr: ":1: invalid value for diff_context_lines"},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(tmpDir, fmt.Sprintf("config-%d.json", i))
+			if err := os.WriteFile(configPath, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to write config: %v", err)
+			}
+
+			config, err := NewConfigLoaderWithPath(configPath).LoadConfig()
+			if tt.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+					t.Fatalf("Expected error containing %q, got %v", tt.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to load config: %v", err)
+			}
+			if config.DiffContextLines != tt.expected {
+				t.Errorf("Expected %d context lines, got %d", tt.expected, config.DiffContextLines)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_BodyLimit(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	tests := []struct {
+		name             string
+		content          string
+		expectedLength   int
+		expectedOverflow string
+		expectedErr      string
+	}{
+		{name: "No limit by default", content: `{}`},
+		{name: "Limit and policy", content: `{"max_body_length": 300, "body_overflow": "regenerate"}`, expectedLength: 300, expectedOverflow: "regenerate"},
+		{name: "Negative is rejected", content: `{"max_body_length": -5}`, expectedErr: "invalid value for max_body_length"},
+		{name: "Negative requests per minute are rejected", content: `{"requests_per_minute": -1}`, expectedErr: "invalid value for requests_per_minute"},
+		{name: "Unknown policy", content: `{"body_overflow": "wrap"}`, expectedErr: "invalid value for body_overflow"},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(tmpDir, fmt.Sprintf("config-%d.json", i))
+			if err := os.WriteFile(configPath, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to write config: %v", err)
+			}
+
+			config, err := NewConfigLoaderWithPath(configPath).LoadConfig()
+			if tt.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+					t.Fatalf("Expected error containing %q, got %v", tt.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to load config: %v", err)
+			}
+			if config.MaxBodyLength != tt.expectedLength || config.BodyOverflow != tt.expectedOverflow {
+				t.Errorf("Expected %d/%q, got %d/%q", tt.expectedLength, tt.expectedOverflow, config.MaxBodyLength, config.BodyOverflow)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_ScopePolicy(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	tests := []struct {
+		name        string
+		content     string
+		expected    string
+		expectedErr string
+	}{
+		{name: "Optional by default", content: `{}`},
+		{name: "Required", content: `{"scope_policy": "required"}`, expected: "required"},
+		{name: "Forbidden", content: `{"scope_policy": "forbidden"}`, expected: "forbidden"},
+		{name: "Unknown policy", content: `{"scope_policy": "sometimes"}`, expectedErr: "invalid value for scope_policy"},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(tmpDir, fmt.Sprintf("config-%d.json", i))
+			if err := os.WriteFile(configPath, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to write config: %v", err)
+			}
+
+			config, err := NewConfigLoaderWithPath(configPath).LoadConfig()
+			if tt.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+					t.Fatalf("Expected error containing %q, got %v", tt.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to load config: %v", err)
+			}
+			if config.ScopePolicy != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, config.ScopePolicy)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_Layers(t *testing.T) {
+	configHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	globalPath, err := GlobalConfigPath()
+	if err != nil {
+		t.Fatalf("GlobalConfigPath failed: %v", err)
+	}
+	repoPath := filepath.Join(t.TempDir(), ".commit-generator-config")
+
+	tests := []struct {
+		name     string
+		global   string
+		repo     string
+		env      map[string]string
+		expected map[string]string // key -> value@source
+	}{
+		{
+			name: "No files",
+			expected: map[string]string{
+				"model":           "gpt-oss:120b@default",
+				"timeout_seconds": "60@default",
+				"api_key":         "@default",
+			},
+		},
+		{
+			name:   "Global only",
+			global: `{"model": "llama3", "api_key": "global-key"}`,
+			expected: map[string]string{
+				"model":   "llama3@global",
+				"api_key": "global-key@global",
+			},
+		},
+		{
+			name:   "Repo overrides global key by key",
+			global: `{"model": "llama3", "api_key": "global-key", "timeout_seconds": 30}`,
+			repo:   `{"model": "qwen2.5-coder"}`,
+			expected: map[string]string{
+				"model":           "qwen2.5-coder@repo",
+				"api_key":         "global-key@global",
+				"timeout_seconds": "30@global",
+				"base_url":        "http://localhost:11434/api/generate@default",
+			},
+		},
+		{
+			name:   "Environment overrides every file",
+			global: `{"model": "llama3", "base_url": "http://global:11434/api/generate"}`,
+			repo:   `{"model": "qwen2.5-coder", "api_key": "repo-key"}`,
+			env: map[string]string{
+				"AI_COMMIT_MODEL":   "mistral",
+				"AI_COMMIT_API_KEY": "env-key",
+				"OLLAMA_API_KEY":    "legacy-key",
+			},
+			expected: map[string]string{
+				"model":    "mistral@env",
+				"api_key":  "env-key@env",
+				"base_url": "http://global:11434/api/generate@global",
+			},
+		},
+		{
+			name:   "Environment values are parsed like config set values",
+			global: `{"stream": false}`,
+			repo:   `{"timeout_seconds": 30, "max_file_bytes": 4096}`,
+			env: map[string]string{
+				"AI_COMMIT_TIMEOUT_SECONDS": "2m",
+				"AI_COMMIT_MAX_FILE_BYTES":  "0",
+				"AI_COMMIT_STREAM":          "true",
+				"AI_COMMIT_TRAILERS":        "Refs: #1, Refs: #2",
+				"AI_COMMIT_SCOPE_MAP":       "internal/ai=ai",
+			},
+			expected: map[string]string{
+				"timeout_seconds": "120@env",
+				"max_file_bytes":  "0@env",
+				"stream":          "true@env",
+				"trailers":        `["Refs: #1","Refs: #2"]@env`,
+				"scope_map":       `{"internal/ai":"ai"}@env`,
+				"model":           "gpt-oss:120b@default",
+			},
+		},
+		{
+			name:   "OLLAMA_API_KEY only fills a missing key",
+			global: `{"model": "llama3"}`,
+			env:    map[string]string{"OLLAMA_API_KEY": "legacy-key"},
+			expected: map[string]string{
+				"api_key": "legacy-key@env",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("OLLAMA_API_KEY", tt.env["OLLAMA_API_KEY"])
+			for _, spec := range Schema {
+				t.Setenv(EnvVar(spec.Name), tt.env[EnvVar(spec.Name)])
+			}
+			writeOrRemove(t, globalPath, tt.global)
+			writeOrRemove(t, repoPath, tt.repo)
+
+			loader := NewConfigLoaderWithPath(repoPath)
+			if tt.repo == "" {
+				// The explicit path must exist, so use the repo lookup instead
+				dir := t.TempDir()
+				os.Mkdir(filepath.Join(dir, ".git"), 0755)
+				oldDir, _ := os.Getwd()
+				os.Chdir(dir)
+				defer os.Chdir(oldDir)
+				loader = NewConfigLoader()
+			}
+
+			_, values, err := loader.LoadEffective()
+			if err != nil {
+				t.Fatalf("LoadEffective failed: %v", err)
+			}
+			got := make(map[string]string)
+			for _, v := range values {
+				got[v.Key] = v.Value + "@" + v.Source
+			}
+			for key, want := range tt.expected {
+				if got[key] != want {
+					t.Errorf("%s: expected %q, got %q", key, want, got[key])
+				}
+			}
+		})
+	}
+}
+
+func TestLoadConfig_InvalidEnv(t *testing.T) {
+	tests := []struct {
+		env         string
+		value       string
+		expectedErr string
+	}{
+		{env: "AI_COMMIT_BASE_URL", value: "localhost:11434", expectedErr: "is not an http(s) URL"},
+		{env: "AI_COMMIT_TIMEOUT_SECONDS", value: "soon", expectedErr: "invalid value for timeout_seconds"},
+		{env: "AI_COMMIT_SCOPE_POLICY", value: "sometimes", expectedErr: "optional, required, forbidden"},
+		{env: "AI_COMMIT_STREAM", value: "yes please", expectedErr: "is not true or false"},
+		{env: "AI_COMMIT_MAX_FILE_BYTES", value: "-1", expectedErr: "not a non-negative integer"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.env, func(t *testing.T) {
+			t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+			t.Setenv(tt.env, tt.value)
+			configPath := filepath.Join(t.TempDir(), "config.json")
+			if err := os.WriteFile(configPath, []byte(`{}`), 0644); err != nil {
+				t.Fatalf("Failed to write config: %v", err)
+			}
+
+			_, err := NewConfigLoaderWithPath(configPath).LoadConfig()
+			if err == nil || !strings.Contains(err.Error(), tt.env+": ") || !strings.Contains(err.Error(), tt.expectedErr) {
+				t.Errorf("Expected error naming %s and containing %q, got %v", tt.env, tt.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_InvalidGlobal(t *testing.T) {
+	configHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	globalPath, _ := GlobalConfigPath()
+	writeOrRemove(t, globalPath, `{"model": `)
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	writeOrRemove(t, configPath, `{}`)
+
+	_, err := NewConfigLoaderWithPath(configPath).LoadConfig()
+	if err == nil || !strings.Contains(err.Error(), globalPath) {
+		t.Errorf("Expected parse error naming the global config, got %v", err)
+	}
+}
+
+// writeOrRemove writes content to path, or removes path if content is empty
+func writeOrRemove(t *testing.T, path, content string) {
+	t.Helper()
+	if content == "" {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			t.Fatalf("Failed to remove %s: %v", path, err)
+		}
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create dir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
@@ -0,0 +1,155 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	git "github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	format "github.com/go-git/go-git/v5/plumbing/format/config"
+)
+
+// api_key_source values. The prefixed forms carry their argument after the
+// colon, e.g. env:MY_OLLAMA_KEY.
+const (
+	// APIKeySourceKeychain reads the API key from the OS keychain
+	APIKeySourceKeychain = "keychain"
+	// APIKeySourceEnv reads the API key from an environment variable
+	APIKeySourceEnv = "env:"
+	// APIKeySourceFile reads the API key from a file
+	APIKeySourceFile = "file:"
+	// APIKeySourceGitConfig reads the API key from a git config key
+	APIKeySourceGitConfig = "git-config:"
+)
+
+// parseAPIKeySource accepts "", keychain, env:VAR, file:PATH and
+// git-config:KEY
+func parseAPIKeySource(value string) (interface{}, error) {
+	if value == "" || value == APIKeySourceKeychain {
+		return value, nil
+	}
+	for _, prefix := range []string{APIKeySourceEnv, APIKeySourceFile, APIKeySourceGitConfig} {
+		if strings.HasPrefix(value, prefix) {
+			if strings.TrimPrefix(value, prefix) == "" {
+				return nil, fmt.Errorf("%q needs a value after the colon", value)
+			}
+			if prefix == APIKeySourceGitConfig && !strings.Contains(value[len(prefix):], ".") {
+				return nil, fmt.Errorf("%q is not a git config key such as ai-commit.apikey", value[len(prefix):])
+			}
+			return value, nil
+		}
+	}
+	return nil, fmt.Errorf("%q is not one of: keychain, env:VAR, file:PATH, git-config:KEY", value)
+}
+
+// ResolveAPIKey returns the API key to send. It is cfg.APIKey unless
+// api_key_source names somewhere else to read it from:
+//
+//   - keychain: the OS keychain, then the CredentialsPath fallback
+//   - env:VAR: the environment variable VAR
+//   - file:PATH: the contents of PATH, without surrounding whitespace
+//   - git-config:KEY: KEY from the repository, global or system git config,
+//     in that order
+//
+// Errors name the source that was tried.
+func (c *ConfigLoader) ResolveAPIKey(cfg *Config) (string, error) {
+	source := cfg.APIKeySource
+	var key string
+	var err error
+	switch {
+	case source == "":
+		return cfg.APIKey, nil
+	case source == APIKeySourceKeychain:
+		key, err = c.keychainAPIKey()
+	case strings.HasPrefix(source, APIKeySourceEnv):
+		key, err = envAPIKey(strings.TrimPrefix(source, APIKeySourceEnv))
+	case strings.HasPrefix(source, APIKeySourceFile):
+		key, err = fileAPIKey(strings.TrimPrefix(source, APIKeySourceFile))
+	case strings.HasPrefix(source, APIKeySourceGitConfig):
+		key, err = gitConfigValue(strings.TrimPrefix(source, APIKeySourceGitConfig))
+	default:
+		_, err = parseAPIKeySource(source)
+	}
+	if err != nil {
+		return "", fmt.Errorf("api_key_source %q: %w", source, err)
+	}
+	return key, nil
+}
+
+func envAPIKey(name string) (string, error) {
+	key := strings.TrimSpace(os.Getenv(name))
+	if key == "" {
+		return "", fmt.Errorf("%s is not set", name)
+	}
+	return key, nil
+}
+
+// fileAPIKey reads a key file. A leading ~/ is the home directory; relative
+// paths are relative to the working directory, which is the repository
+// root when run from a hook.
+func fileAPIKey(path string) (string, error) {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to find the home directory: %w", err)
+		}
+		path = filepath.Join(home, rest)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read key file: %w", err)
+	}
+	key := strings.TrimSpace(string(data))
+	if key == "" {
+		return "", fmt.Errorf("key file %s is empty", path)
+	}
+	return key, nil
+}
+
+// gitConfigValue looks up key (section.name or section.subsection.name) in
+// the repository's git config, then the global and then the system one
+func gitConfigValue(key string) (string, error) {
+	dot, lastDot := strings.Index(key, "."), strings.LastIndex(key, ".")
+	section, name := key[:dot], key[lastDot+1:]
+	subsection := ""
+	if dot != lastDot {
+		subsection = key[dot+1 : lastDot]
+	}
+
+	var scopes []*format.Config
+	if repo, err := git.PlainOpenWithOptions(".", &git.PlainOpenOptions{DetectDotGit: true}); err == nil {
+		cfg, err := repo.Config()
+		if err != nil {
+			return "", fmt.Errorf("failed to read the repository git config: %w", err)
+		}
+		scopes = append(scopes, cfg.Raw)
+	}
+	for _, scope := range []gitconfig.Scope{gitconfig.GlobalScope, gitconfig.SystemScope} {
+		cfg, err := gitconfig.LoadConfig(scope)
+		if err != nil {
+			return "", fmt.Errorf("failed to read git config: %w", err)
+		}
+		scopes = append(scopes, cfg.Raw)
+	}
+
+	for _, raw := range scopes {
+		if !raw.HasSection(section) {
+			continue
+		}
+		options := raw.Section(section).Options
+		if subsection != "" {
+			if !raw.Section(section).HasSubsection(subsection) {
+				continue
+			}
+			options = raw.Section(section).Subsection(subsection).Options
+		}
+		if options.Has(name) {
+			if value := strings.TrimSpace(options.Get(name)); value != "" {
+				return value, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("%s is not set in the repository, global or system git config", key)
+}
@@ -0,0 +1,150 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	git "github.com/go-git/go-git/v5"
+)
+
+func TestConfigLoader_ResolveAPIKey_Sources(t *testing.T) {
+	tests := []struct {
+		name          string
+		source        string
+		env           map[string]string
+		files         map[string]string // relative to the repository; ~ is the home dir
+		expectedKey   string
+		expectedError string
+	}{
+		{
+			name:        "Environment variable",
+			source:      "env:MY_OLLAMA_KEY",
+			env:         map[string]string{"MY_OLLAMA_KEY": "sk-env"},
+			expectedKey: "sk-env",
+		},
+		{
+			name:          "Environment variable not set",
+			source:        "env:MY_OLLAMA_KEY",
+			expectedError: `api_key_source "env:MY_OLLAMA_KEY": MY_OLLAMA_KEY is not set`,
+		},
+		{
+			name:        "Key file with a trailing newline",
+			source:      "file:key.txt",
+			files:       map[string]string{"key.txt": "sk-file\n"},
+			expectedKey: "sk-file",
+		},
+		{
+			name:        "Key file in the home directory",
+			source:      "file:~/.ollama-key",
+			files:       map[string]string{"~/.ollama-key": "sk-home\r\n"},
+			expectedKey: "sk-home",
+		},
+		{
+			name:          "Missing key file",
+			source:        "file:missing.txt",
+			expectedError: `api_key_source "file:missing.txt": failed to read key file`,
+		},
+		{
+			name:          "Empty key file",
+			source:        "file:key.txt",
+			files:         map[string]string{"key.txt": "\n"},
+			expectedError: "is empty",
+		},
+		{
+			name:        "Git config from the repository",
+			source:      "git-config:ai-commit.apikey",
+			files:       map[string]string{".git/config": "[core]\n\tbare = false\n[ai-commit]\n\tapikey = sk-local\n", "~/.gitconfig": "[ai-commit]\n\tapikey = sk-global\n"},
+			expectedKey: "sk-local",
+		},
+		{
+			name:        "Git config falls back to global",
+			source:      "git-config:ai-commit.apikey",
+			files:       map[string]string{"~/.gitconfig": "[ai-commit]\n\tapikey = sk-global\n"},
+			expectedKey: "sk-global",
+		},
+		{
+			name:        "Git config with a subsection",
+			source:      "git-config:ai-commit.ollama.apikey",
+			files:       map[string]string{"~/.gitconfig": "[ai-commit \"ollama\"]\n\tapikey = sk-sub\n"},
+			expectedKey: "sk-sub",
+		},
+		{
+			name:          "Git config key not set",
+			source:        "git-config:ai-commit.apikey",
+			expectedError: `api_key_source "git-config:ai-commit.apikey": ai-commit.apikey is not set`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			home := filepath.Join(dir, "home")
+			t.Setenv("HOME", home)
+			t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+			t.Setenv("MY_OLLAMA_KEY", "")
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+
+			repo := filepath.Join(dir, "repo")
+			if _, err := git.PlainInit(repo, false); err != nil {
+				t.Fatalf("failed to create repo: %v", err)
+			}
+			for name, content := range tt.files {
+				path := filepath.Join(repo, name)
+				if rest, ok := strings.CutPrefix(name, "~/"); ok {
+					path = filepath.Join(home, rest)
+				}
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatalf("failed to create dir: %v", err)
+				}
+				if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+					t.Fatalf("failed to write %s: %v", name, err)
+				}
+			}
+			originalWd, _ := os.Getwd()
+			defer os.Chdir(originalWd)
+			if err := os.Chdir(repo); err != nil {
+				t.Fatalf("failed to change dir: %v", err)
+			}
+
+			key, err := NewConfigLoader().ResolveAPIKey(&Config{APIKey: "sk-ignored", APIKeySource: tt.source})
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("expected error containing %q, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResolveAPIKey failed: %v", err)
+			}
+			if key != tt.expectedKey {
+				t.Errorf("expected key %q, got %q", tt.expectedKey, key)
+			}
+		})
+	}
+}
+
+func TestParseAPIKeySource(t *testing.T) {
+	tests := []struct {
+		value   string
+		isValid bool
+	}{
+		{"", true},
+		{"keychain", true},
+		{"env:OLLAMA_KEY", true},
+		{"file:/run/secrets/ollama", true},
+		{"git-config:ai-commit.apikey", true},
+		{"env:", false},
+		{"git-config:apikey", false},
+		{"vault:secret/ollama", false},
+	}
+
+	for _, tt := range tests {
+		if _, err := parseAPIKeySource(tt.value); (err == nil) != tt.isValid {
+			t.Errorf("parseAPIKeySource(%q) error = %v, expected valid %v", tt.value, err, tt.isValid)
+		}
+	}
+}
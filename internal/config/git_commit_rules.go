@@ -1,21 +1,35 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
 )
 
+// rulesFileName is the name of the rules files in a repository: one at the
+// root and, optionally, one in any directory below it
+const rulesFileName = ".git-commit-rules-for-ai"
+
+// includeDirective starts a line of a rules file that is replaced by the
+// contents of another file
+const includeDirective = "@include"
+
 // Loader defines the interface for loading configuration
 type Loader interface {
-	LoadRules() (string, error)
+	// LoadRules returns the rules that apply to changes to paths, given
+	// relative to the repository root
+	LoadRules(paths []string) (string, error)
 }
 
 // FileLoader implements the Loader interface
 type FileLoader struct {
-	cachedRepoRoot string
-	cachedRules    string
-	mu             sync.Mutex
+	cachedKey   string
+	cachedRules string
+	mu          sync.Mutex
 }
 
 // NewLoader creates a new Config loader
@@ -23,51 +37,178 @@ func NewLoader() Loader {
 	return &FileLoader{}
 }
 
-// LoadRules reads the .git-commit-rules-for-ai file from the repo root.
-// It assumes the current working directory is the repo root (or we could look it up).
-// For simplicity and per requirements ("in the root of the git repository"),
-// we will try to find it in the current directory or traverse up?
-// The requirement says: "Look for a file named .git-commit-rules-for-ai in the root of the git repository."
-// Since the tool is likely run from the root, we check current dir.
-// We can also double check by finding the .git dir if needed, but 'internal/git' handles repo check.
-// We'll trust the user invokes it from within the repo.
-func (c *FileLoader) LoadRules() (string, error) {
+// rulesBlock is the contents of one rules file, with its includes resolved
+type rulesBlock struct {
+	// source is the path shown in the header of the block
+	source  string
+	content string
+}
+
+// GlobalRulesPath returns the per-user rules file, next to the global
+// config: $XDG_CONFIG_HOME/ai-commit/rules on Linux
+func GlobalRulesPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find the user config directory: %w", err)
+	}
+	return filepath.Join(dir, "ai-commit", "rules"), nil
+}
+
+// LoadRules reads the rules for changes to paths, in this order:
+//
+//  1. The global rules file (GlobalRulesPath), if it exists
+//  2. The .git-commit-rules-for-ai file in the repository root
+//  3. For each changed path, the .git-commit-rules-for-ai file in the
+//     nearest directory above it, below the root; each file once, in the
+//     order of their directories
+//
+// Later blocks come later in the prompt, so the more specific rules have
+// the last word. When more than one file applies, each block is headed by
+// a comment naming the file it came from. A line '@include path' in any of
+// them is replaced by the file at path, relative to the including file.
+// Outside a repository only the global rules apply.
+func (c *FileLoader) LoadRules(paths []string) (string, error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	// 1. Try to find the root of the git repo.
 	repoRoot, err := findRepoRoot()
 	if err != nil {
-		// If we can't find repo root, we can't find the rules file there.
-		// Return empty, but maybe this isn't an error for the rules loader itself?
-		// The App will verify we are in a repo first.
-		// If we are, findRepoRoot should succeed.
-		return "", nil
+		// The App verifies we are in a repo first
+		repoRoot = ""
 	}
 
-	// Return cached rules if repo root hasn't changed
-	if c.cachedRepoRoot == repoRoot && c.cachedRules != "" {
+	var dirs []string
+	if repoRoot != "" {
+		dirs = ruleDirs(repoRoot, paths)
+	}
+	key := repoRoot + "\x00" + strings.Join(dirs, "\x00")
+	if c.cachedKey == key && c.cachedRules != "" {
 		return c.cachedRules, nil
 	}
 
-	rulesPath := filepath.Join(repoRoot, ".git-commit-rules-for-ai")
+	var blocks []rulesBlock
+	if globalPath, err := GlobalRulesPath(); err == nil {
+		block, err := readRulesFile(globalPath, globalPath)
+		if err != nil {
+			return "", err
+		}
+		blocks = appendBlock(blocks, block)
+	}
+	if repoRoot != "" {
+		for _, dir := range append([]string{"."}, dirs...) {
+			source := filepath.ToSlash(filepath.Join(dir, rulesFileName))
+			block, err := readRulesFile(filepath.Join(repoRoot, dir, rulesFileName), source)
+			if err != nil {
+				return "", err
+			}
+			blocks = appendBlock(blocks, block)
+		}
+	}
+
+	c.cachedKey = key
+	c.cachedRules = joinRulesBlocks(blocks)
+	return c.cachedRules, nil
+}
+
+// ruleDirs returns the directories below repoRoot, relative to it, whose
+// rules file is the nearest one above a changed path
+func ruleDirs(repoRoot string, paths []string) []string {
+	seen := make(map[string]bool)
+	var dirs []string
+	for _, path := range paths {
+		dir := filepath.Dir(filepath.FromSlash(path))
+		for dir != "." && dir != string(filepath.Separator) && !strings.HasPrefix(dir, "..") {
+			if _, err := os.Stat(filepath.Join(repoRoot, dir, rulesFileName)); err == nil {
+				if !seen[dir] {
+					seen[dir] = true
+					dirs = append(dirs, dir)
+				}
+				break
+			}
+			dir = filepath.Dir(dir)
+		}
+	}
+	sort.Strings(dirs)
+	return dirs
+}
 
-	content, err := os.ReadFile(rulesPath)
+// appendBlock adds block unless its file is missing or blank
+func appendBlock(blocks []rulesBlock, block *rulesBlock) []rulesBlock {
+	if block == nil || strings.TrimSpace(block.content) == "" {
+		return blocks
+	}
+	return append(blocks, *block)
+}
+
+// joinRulesBlocks concatenates blocks. A single block is returned as it is;
+// several get a header each saying where they came from.
+func joinRulesBlocks(blocks []rulesBlock) string {
+	if len(blocks) == 1 {
+		return blocks[0].content
+	}
+	var parts []string
+	for _, block := range blocks {
+		parts = append(parts, "# Rules from "+block.source+"\n"+strings.TrimRight(block.content, "\n"))
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// readRulesFile reads the rules file at path with its includes resolved,
+// or returns nil when it does not exist
+func readRulesFile(path, source string) (*rulesBlock, error) {
+	content, err := os.ReadFile(path)
 	if err != nil {
 		if os.IsNotExist(err) {
-			// Cache empty result
-			c.cachedRepoRoot = repoRoot
-			c.cachedRules = ""
-			return "", nil // Optional file
+			return nil, nil // Optional file
 		}
-		return "", err
+		return nil, err
+	}
+	resolved, err := resolveIncludes(path, string(content), []string{filepath.Clean(path)})
+	if err != nil {
+		return nil, err
 	}
+	return &rulesBlock{source: source, content: resolved}, nil
+}
 
-	// Cache the result
-	c.cachedRepoRoot = repoRoot
-	c.cachedRules = string(content)
+// resolveIncludes replaces the '@include path' lines of content, read from
+// path, with the contents of the files they name. stack holds the files
+// being included, to report an include cycle instead of following it.
+func resolveIncludes(path, content string, stack []string) (string, error) {
+	if !strings.Contains(content, includeDirective) {
+		return content, nil
+	}
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		target, ok := strings.CutPrefix(strings.TrimSpace(line), includeDirective+" ")
+		if !ok {
+			continue
+		}
+		target = strings.TrimSpace(target)
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(path), target)
+		}
+		target = filepath.Clean(target)
+		for j, including := range stack {
+			if including == target {
+				cycle := append(append([]string{}, stack[j:]...), target)
+				return "", lineError(path, i+1, fmt.Errorf("include cycle: %s", strings.Join(cycle, " -> ")))
+			}
+		}
 
-	return c.cachedRules, nil
+		included, err := os.ReadFile(target)
+		if err != nil {
+			if os.IsNotExist(err) {
+				err = errors.New("no such file")
+			}
+			return "", lineError(path, i+1, fmt.Errorf("failed to include %s: %w", target, err))
+		}
+		resolved, err := resolveIncludes(target, string(included), append(stack, target))
+		if err != nil {
+			return "", err
+		}
+		lines[i] = strings.TrimRight(resolved, "\n")
+	}
+	return strings.Join(lines, "\n"), nil
 }
 
 func findRepoRoot() (string, error) {
@@ -38,7 +38,7 @@ func BenchmarkLoadRules_WithFile(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		_, _ = loader.LoadRules()
+		_, _ = loader.LoadRules(nil)
 	}
 }
 
@@ -50,7 +50,7 @@ func BenchmarkLoadRules_WithoutFile(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		_, _ = loader.LoadRules()
+		_, _ = loader.LoadRules(nil)
 	}
 }
 
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -49,7 +50,7 @@ func TestFileLoader_LoadRules(t *testing.T) {
 		}
 
 		loader := NewLoader()
-		rules, err := loader.LoadRules()
+		rules, err := loader.LoadRules(nil)
 		if err != nil {
 			t.Errorf("unexpected error: %v", err)
 		}
@@ -67,7 +68,7 @@ func TestFileLoader_LoadRules(t *testing.T) {
 		}
 
 		loader := NewLoader()
-		rules, err := loader.LoadRules()
+		rules, err := loader.LoadRules(nil)
 		if err != nil {
 			t.Errorf("unexpected error: %v", err)
 		}
@@ -85,7 +86,7 @@ func TestFileLoader_LoadRules(t *testing.T) {
 		}
 
 		loader := NewLoader()
-		rules, err := loader.LoadRules()
+		rules, err := loader.LoadRules(nil)
 		// Expect no error, just empty rules as per implementation
 		if err != nil {
 			t.Errorf("unexpected error: %v", err)
@@ -95,3 +96,180 @@ func TestFileLoader_LoadRules(t *testing.T) {
 		}
 	})
 }
+
+// setupRulesRepo creates a repository with the given files, relative to
+// its root, makes it the working directory and points the global config
+// directory at configHome
+func setupRulesRepo(t *testing.T, files map[string]string) (repoRoot, configHome string) {
+	t.Helper()
+	repoRoot = t.TempDir()
+	configHome = t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	if err := os.Mkdir(filepath.Join(repoRoot, ".git"), 0755); err != nil {
+		t.Fatalf("failed to create .git dir: %v", err)
+	}
+	for name, content := range files {
+		path := filepath.Join(repoRoot, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create %s: %v", filepath.Dir(path), err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	originalWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get current working directory: %v", err)
+	}
+	if err := os.Chdir(repoRoot); err != nil {
+		t.Fatalf("failed to chdir: %v", err)
+	}
+	t.Cleanup(func() { os.Chdir(originalWd) })
+	return repoRoot, configHome
+}
+
+func TestFileLoader_LoadRules_Layers(t *testing.T) {
+	_, configHome := setupRulesRepo(t, map[string]string{
+		".git-commit-rules-for-ai":              "Root rule\n",
+		"services/.git-commit-rules-for-ai":     "Services rule\n",
+		"services/api/.git-commit-rules-for-ai": "API rule\n",
+		"services/api/v2/handlers/users.go":     "package handlers\n",
+		"web/.git-commit-rules-for-ai":          "Web rule\n",
+	})
+	globalPath := filepath.Join(configHome, "ai-commit", "rules")
+	if err := os.MkdirAll(filepath.Dir(globalPath), 0755); err != nil {
+		t.Fatalf("failed to create the global config dir: %v", err)
+	}
+	if err := os.WriteFile(globalPath, []byte("Global rule\n"), 0644); err != nil {
+		t.Fatalf("failed to write global rules: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		paths    []string
+		expected string
+	}{
+		{
+			name:     "No changed paths",
+			paths:    nil,
+			expected: "# Rules from " + globalPath + "\nGlobal rule\n\n# Rules from .git-commit-rules-for-ai\nRoot rule",
+		},
+		{
+			name:     "Nearest file of a nested path",
+			paths:    []string{"services/api/v2/handlers/users.go"},
+			expected: "# Rules from " + globalPath + "\nGlobal rule\n\n# Rules from .git-commit-rules-for-ai\nRoot rule\n\n# Rules from services/api/.git-commit-rules-for-ai\nAPI rule",
+		},
+		{
+			name:     "Each directory once, in order",
+			paths:    []string{"web/index.html", "services/db.go", "web/app.js", "services/cache.go"},
+			expected: "# Rules from " + globalPath + "\nGlobal rule\n\n# Rules from .git-commit-rules-for-ai\nRoot rule\n\n# Rules from services/.git-commit-rules-for-ai\nServices rule\n\n# Rules from web/.git-commit-rules-for-ai\nWeb rule",
+		},
+		{
+			name:     "Path without a directory file",
+			paths:    []string{"README.md", "docs/guide.md"},
+			expected: "# Rules from " + globalPath + "\nGlobal rule\n\n# Rules from .git-commit-rules-for-ai\nRoot rule",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := NewLoader().LoadRules(tt.paths)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if rules != tt.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.expected, rules)
+			}
+		})
+	}
+}
+
+func TestFileLoader_LoadRules_SingleFileUnchanged(t *testing.T) {
+	setupRulesRepo(t, map[string]string{
+		"services/.git-commit-rules-for-ai": "Services rule\n",
+	})
+
+	rules, err := NewLoader().LoadRules([]string{"services/db.go"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rules != "Services rule\n" {
+		t.Errorf("expected the only file without a header, got %q", rules)
+	}
+}
+
+func TestFileLoader_LoadRules_Includes(t *testing.T) {
+	tests := []struct {
+		name        string
+		files       map[string]string
+		expected    string
+		expectedErr string
+	}{
+		{
+			name: "Nested includes",
+			files: map[string]string{
+				".git-commit-rules-for-ai": "Root rule\n@include rules/shared.md\nLast rule\n",
+				"rules/shared.md":          "Shared rule\n  @include scopes.md\n",
+				"rules/scopes.md":          "Scope rule\n",
+			},
+			expected: "Root rule\nShared rule\nScope rule\nLast rule\n",
+		},
+		{
+			name: "Missing include",
+			files: map[string]string{
+				".git-commit-rules-for-ai": "Root rule\n@include missing.md\n",
+			},
+			expectedErr: ".git-commit-rules-for-ai:2: failed to include",
+		},
+		{
+			name: "Include cycle",
+			files: map[string]string{
+				".git-commit-rules-for-ai": "@include a.md\n",
+				"a.md":                     "A rule\n@include b.md\n",
+				"b.md":                     "B rule\n@include a.md\n",
+			},
+			expectedErr: "include cycle: ",
+		},
+		{
+			name: "File including itself",
+			files: map[string]string{
+				".git-commit-rules-for-ai": "Root rule\n@include .git-commit-rules-for-ai\n",
+			},
+			expectedErr: "include cycle: ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupRulesRepo(t, tt.files)
+			rules, err := NewLoader().LoadRules(nil)
+			if tt.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if rules != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, rules)
+			}
+		})
+	}
+}
+
+func TestFileLoader_LoadRules_CycleNamesFiles(t *testing.T) {
+	repoRoot, _ := setupRulesRepo(t, map[string]string{
+		".git-commit-rules-for-ai": "@include a.md\n",
+		"a.md":                     "@include b.md\n",
+		"b.md":                     "@include a.md\n",
+	})
+
+	_, err := NewLoader().LoadRules(nil)
+	a, b := filepath.Join(repoRoot, "a.md"), filepath.Join(repoRoot, "b.md")
+	if want := "include cycle: " + a + " -> " + b + " -> " + a; err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("expected error containing %q, got %v", want, err)
+	}
+}
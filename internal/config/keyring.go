@@ -0,0 +1,182 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	gokeyring "github.com/zalando/go-keyring"
+)
+
+// KeyringService is the service name secrets are stored under
+const KeyringService = "ai-commit"
+
+// keyringUser is the account name of the API key within KeyringService
+const keyringUser = "api_key"
+
+// ErrSecretNotFound is returned by a Keyring that has no secret for the user
+var ErrSecretNotFound = errors.New("secret not found")
+
+// ErrKeyringUnavailable is returned when the OS credential store cannot be
+// used, e.g. no Secret Service is running on a headless Linux machine
+var ErrKeyringUnavailable = errors.New("the OS keychain is not available")
+
+// Keyring stores secrets by service and user
+type Keyring interface {
+	Get(service, user string) (string, error)
+	Set(service, user, secret string) error
+}
+
+// SystemKeyring returns the OS credential store: Keychain on macOS, the
+// Windows Credential Manager, or the Secret Service on Linux
+func SystemKeyring() Keyring {
+	return systemKeyring{}
+}
+
+type systemKeyring struct{}
+
+func (systemKeyring) Get(service, user string) (string, error) {
+	secret, err := gokeyring.Get(service, user)
+	if errors.Is(err, gokeyring.ErrNotFound) {
+		return "", ErrSecretNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrKeyringUnavailable, err)
+	}
+	return secret, nil
+}
+
+func (systemKeyring) Set(service, user, secret string) error {
+	if err := gokeyring.Set(service, user, secret); err != nil {
+		return fmt.Errorf("%w: %v", ErrKeyringUnavailable, err)
+	}
+	return nil
+}
+
+// CredentialsPath returns the file used when the OS keychain is unavailable.
+// It lives next to the global config and is readable only by its owner.
+func CredentialsPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find the user config directory: %w", err)
+	}
+	return filepath.Join(dir, "ai-commit", "credentials"), nil
+}
+
+// fileKeyring keeps secrets in a JSON file with 0600 permissions. It is not
+// encrypted and is only used when asked for explicitly.
+type fileKeyring struct {
+	path string
+}
+
+func (f fileKeyring) read() (map[string]string, error) {
+	secrets := make(map[string]string)
+	data, err := os.ReadFile(f.path)
+	if os.IsNotExist(err) {
+		return secrets, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", f.path, err)
+	}
+	if err := json.Unmarshal(data, &secrets); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", f.path, err)
+	}
+	return secrets, nil
+}
+
+func (f fileKeyring) Get(service, user string) (string, error) {
+	secrets, err := f.read()
+	if err != nil {
+		return "", err
+	}
+	secret, ok := secrets[service+"/"+user]
+	if !ok {
+		return "", ErrSecretNotFound
+	}
+	return secret, nil
+}
+
+func (f fileKeyring) Set(service, user, secret string) error {
+	secrets, err := f.read()
+	if err != nil {
+		return err
+	}
+	secrets[service+"/"+user] = secret
+	data, err := json.MarshalIndent(secrets, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode credentials: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(f.path), 0700); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(f.path), err)
+	}
+	if err := os.WriteFile(f.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", f.path, err)
+	}
+	// WriteFile keeps the mode of an existing file
+	return os.Chmod(f.path, 0600)
+}
+
+// SetKeyring replaces the OS keychain, for tests
+func (c *ConfigLoader) SetKeyring(k Keyring) {
+	c.keyring = k
+}
+
+func (c *ConfigLoader) systemKeyring() Keyring {
+	if c.keyring == nil {
+		return SystemKeyring()
+	}
+	return c.keyring
+}
+
+// StoreAPIKey saves key in the OS keychain and returns a description of
+// where it went. When the keychain is unavailable it returns an error
+// wrapping ErrKeyringUnavailable, unless fileFallback allows writing the
+// key to CredentialsPath instead.
+func (c *ConfigLoader) StoreAPIKey(key string, fileFallback bool) (string, error) {
+	err := c.systemKeyring().Set(KeyringService, keyringUser, key)
+	if err == nil {
+		return "the OS keychain", nil
+	}
+	if !errors.Is(err, ErrKeyringUnavailable) || !fileFallback {
+		return "", err
+	}
+
+	path, pathErr := CredentialsPath()
+	if pathErr != nil {
+		return "", pathErr
+	}
+	if err := (fileKeyring{path: path}).Set(KeyringService, keyringUser, key); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// keychainAPIKey reads the API key from the OS keychain, then from the
+// CredentialsPath fallback
+func (c *ConfigLoader) keychainAPIKey() (string, error) {
+	key, keychainErr := c.systemKeyring().Get(KeyringService, keyringUser)
+	if keychainErr == nil {
+		return key, nil
+	}
+	if !errors.Is(keychainErr, ErrSecretNotFound) && !errors.Is(keychainErr, ErrKeyringUnavailable) {
+		return "", keychainErr
+	}
+
+	path, err := CredentialsPath()
+	if err != nil {
+		return "", err
+	}
+	key, err = (fileKeyring{path: path}).Get(KeyringService, keyringUser)
+	if err == nil {
+		return key, nil
+	}
+	if !errors.Is(err, ErrSecretNotFound) {
+		return "", err
+	}
+	if errors.Is(keychainErr, ErrKeyringUnavailable) {
+		return "", fmt.Errorf("%v and %s has no key; run 'generate-commit config set-key --file-fallback'", keychainErr, path)
+	}
+	return "", errors.New("no API key is stored; run 'generate-commit config set-key'")
+}
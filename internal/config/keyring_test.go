@@ -0,0 +1,150 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// fakeKeyring is an in-memory Keyring. With unavailable set every call
+// fails like a machine without a credential store.
+type fakeKeyring struct {
+	secrets     map[string]string
+	unavailable bool
+}
+
+func (f *fakeKeyring) Get(service, user string) (string, error) {
+	if f.unavailable {
+		return "", ErrKeyringUnavailable
+	}
+	secret, ok := f.secrets[service+"/"+user]
+	if !ok {
+		return "", ErrSecretNotFound
+	}
+	return secret, nil
+}
+
+func (f *fakeKeyring) Set(service, user, secret string) error {
+	if f.unavailable {
+		return ErrKeyringUnavailable
+	}
+	if f.secrets == nil {
+		f.secrets = make(map[string]string)
+	}
+	f.secrets[service+"/"+user] = secret
+	return nil
+}
+
+func TestConfigLoader_ResolveAPIKey(t *testing.T) {
+	tests := []struct {
+		name          string
+		config        Config
+		keyring       *fakeKeyring
+		fileKey       string
+		expectedKey   string
+		expectedError string
+	}{
+		{
+			name:        "Plain api_key",
+			config:      Config{APIKey: "sk-config"},
+			keyring:     &fakeKeyring{},
+			expectedKey: "sk-config",
+		},
+		{
+			name:        "Key from the keychain",
+			config:      Config{APIKey: "sk-ignored", APIKeySource: APIKeySourceKeychain},
+			keyring:     &fakeKeyring{secrets: map[string]string{"ai-commit/api_key": "sk-keychain"}},
+			expectedKey: "sk-keychain",
+		},
+		{
+			name:          "Nothing stored",
+			config:        Config{APIKeySource: APIKeySourceKeychain},
+			keyring:       &fakeKeyring{},
+			expectedError: "run 'generate-commit config set-key'",
+		},
+		{
+			name:        "Keychain unavailable, file fallback",
+			config:      Config{APIKeySource: APIKeySourceKeychain},
+			keyring:     &fakeKeyring{unavailable: true},
+			fileKey:     "sk-file",
+			expectedKey: "sk-file",
+		},
+		{
+			name:          "Keychain unavailable, no fallback",
+			config:        Config{APIKeySource: APIKeySourceKeychain},
+			keyring:       &fakeKeyring{unavailable: true},
+			expectedError: "--file-fallback",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+			if tt.fileKey != "" {
+				path, _ := CredentialsPath()
+				if err := (fileKeyring{path: path}).Set(KeyringService, keyringUser, tt.fileKey); err != nil {
+					t.Fatalf("failed to write credentials: %v", err)
+				}
+			}
+			loader := NewConfigLoader()
+			loader.SetKeyring(tt.keyring)
+
+			key, err := loader.ResolveAPIKey(&tt.config)
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("expected error containing %q, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResolveAPIKey failed: %v", err)
+			}
+			if key != tt.expectedKey {
+				t.Errorf("expected key %q, got %q", tt.expectedKey, key)
+			}
+		})
+	}
+}
+
+func TestConfigLoader_StoreAPIKey(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	keychain := &fakeKeyring{}
+	loader := NewConfigLoader()
+	loader.SetKeyring(keychain)
+
+	if _, err := loader.StoreAPIKey("sk-keychain", false); err != nil {
+		t.Fatalf("StoreAPIKey failed: %v", err)
+	}
+	if keychain.secrets["ai-commit/api_key"] != "sk-keychain" {
+		t.Errorf("expected the key in the keychain, got %v", keychain.secrets)
+	}
+
+	keychain.unavailable = true
+	if _, err := loader.StoreAPIKey("sk-file", false); !errors.Is(err, ErrKeyringUnavailable) {
+		t.Fatalf("expected ErrKeyringUnavailable without the fallback, got %v", err)
+	}
+	path, _ := CredentialsPath()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("expected no credentials file without the fallback, got %v", err)
+	}
+
+	where, err := loader.StoreAPIKey("sk-file", true)
+	if err != nil {
+		t.Fatalf("StoreAPIKey with fallback failed: %v", err)
+	}
+	if where != path {
+		t.Errorf("expected the key in %s, got %s", path, where)
+	}
+	if info, err := os.Stat(path); err != nil {
+		t.Fatalf("expected credentials file: %v", err)
+	} else if runtime.GOOS != "windows" && info.Mode().Perm() != 0600 {
+		t.Errorf("expected mode 0600, got %v", info.Mode().Perm())
+	}
+
+	key, err := loader.ResolveAPIKey(&Config{APIKeySource: APIKeySourceKeychain})
+	if err != nil || key != "sk-file" {
+		t.Errorf("expected the fallback key, got %q, %v", key, err)
+	}
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"ai-commit-message-generator/internal/git"
+)
+
+// pathsKey holds the overrides for areas of a monorepo. Like profiles it is
+// edited by hand, so it is not part of the Schema.
+const pathsKey = "paths"
+
+// PathConfig overrides the message style for changes under one area of a
+// repository, such as a frontend kept next to a backend. Unset fields keep
+// the value of the rest of the configuration.
+type PathConfig struct {
+	// RulesFile is a rules file, relative to the repository root, read
+	// instead of the .git-commit-rules-for-ai files
+	RulesFile string `json:"rules_file,omitempty"`
+	// HeaderFormat and ScopePolicy are as the top-level keys
+	HeaderFormat string `json:"header_format,omitempty"`
+	ScopePolicy  string `json:"scope_policy,omitempty"`
+	// SubjectCapitalize and SubjectTrailingPeriod are as the top-level
+	// keys; nil keeps their value
+	SubjectCapitalize     *bool `json:"subject_capitalize,omitempty"`
+	SubjectTrailingPeriod *bool `json:"subject_trailing_period,omitempty"`
+	// Scopes are the only scopes messages for the area may use
+	Scopes []string `json:"scopes,omitempty"`
+	// Language is the language messages for the area are written in,
+	// e.g. German
+	Language string `json:"language,omitempty"`
+}
+
+// validatePaths checks the raw "paths" value of a config file and returns
+// one error per problem
+func validatePaths(raw json.RawMessage) []error {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	var paths map[string]PathConfig
+	if err := decoder.Decode(&paths); err != nil {
+		return []error{fmt.Errorf(`invalid paths: expected an object of globs, each an object of overrides such as {"web/": {"scopes": ["ui"]}}: %w`, err)}
+	}
+	var problems []error
+	for _, pattern := range sortedPatterns(paths) {
+		if err := validatePathConfig(pattern, paths[pattern]); err != nil {
+			problems = append(problems, err)
+		}
+	}
+	return problems
+}
+
+// validatePathConfig checks the glob and the values of one entry of paths
+// the way the top-level keys are checked
+func validatePathConfig(pattern string, override PathConfig) error {
+	if err := git.ValidateGlobs([]string{pattern}); err != nil {
+		return fmt.Errorf("paths: %w", err)
+	}
+	if _, err := parseHeaderFormat(override.HeaderFormat); err != nil {
+		return fmt.Errorf("paths %q: invalid header_format: %w", pattern, err)
+	}
+	if _, err := parseEnum("", "optional", "required", "forbidden")(override.ScopePolicy); err != nil {
+		return fmt.Errorf("paths %q: invalid scope_policy: %w", pattern, err)
+	}
+	if err := validateScopes(override.Scopes); err != nil {
+		return fmt.Errorf("paths %q: %w", pattern, err)
+	}
+	return nil
+}
+
+// sortedPatterns returns the globs of paths in order
+func sortedPatterns(paths map[string]PathConfig) []string {
+	patterns := make([]string, 0, len(paths))
+	for pattern := range paths {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+	return patterns
+}
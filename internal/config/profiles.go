@@ -0,0 +1,155 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// profilesKey holds the named profiles in a config file. It is not part of
+// the Schema because profiles are edited by hand, not with config set.
+const profilesKey = "profiles"
+
+// profileKey names the profile to use when --profile does not
+const profileKey = "profile"
+
+// SetProfile selects a named profile from the "profiles" key, whose fields
+// are overlaid on the file configuration. An empty name leaves the choice
+// to AI_COMMIT_PROFILE and the profile key.
+func (c *ConfigLoader) SetProfile(name string) {
+	c.profile = name
+}
+
+// ProfileNames returns the names of the profiles defined in config, sorted
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// selectedProfile is the profile a run overlays
+type selectedProfile struct {
+	name   string
+	fields map[string]json.RawMessage
+	// definedIn is the config file whose profiles key has it
+	definedIn *configFile
+}
+
+// selectProfile finds the profile to overlay: the one named with
+// SetProfile, else by AI_COMMIT_PROFILE, else by the profile key of the
+// files. Files are given from the lowest layer up, and a later file's
+// profile key and profile of the same name win. It returns nil when no
+// profile is named.
+func (c *ConfigLoader) selectProfile(files ...*configFile) (*selectedProfile, error) {
+	name := c.profile
+	if name == "" {
+		name = os.Getenv(EnvVar(profileKey))
+	}
+	for i := len(files) - 1; i >= 0 && name == ""; i-- {
+		if raw, ok := files[i].value(profileKey); ok {
+			name = rawString(raw)
+		}
+	}
+	if name == "" {
+		return nil, nil
+	}
+
+	var selected *selectedProfile
+	defined := make(map[string]bool)
+	for _, f := range files {
+		raw, ok := f.value(profilesKey)
+		if !ok {
+			continue
+		}
+		var profiles map[string]map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &profiles); err != nil {
+			return nil, lineError(f.path, f.lines[profilesKey], fmt.Errorf("invalid value for %s: %w", profilesKey, err))
+		}
+		for profileName, fields := range profiles {
+			defined[profileName] = true
+			if profileName == name {
+				selected = &selectedProfile{name: name, fields: fields, definedIn: f}
+			}
+		}
+	}
+	if selected == nil {
+		if len(defined) == 0 {
+			return nil, fmt.Errorf("profile %q not found: the config defines no profiles", name)
+		}
+		names := make([]string, 0, len(defined))
+		for profileName := range defined {
+			names = append(names, profileName)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("profile %q not found (available: %s)", name, strings.Join(names, ", "))
+	}
+	return selected, nil
+}
+
+// value returns the raw value of key in the file, which may be nil
+func (f *configFile) value(key string) (json.RawMessage, bool) {
+	if f == nil {
+		return nil, false
+	}
+	raw, ok := f.values[key]
+	return raw, ok
+}
+
+// applyProfile overlays the fields of profile onto config and records them
+// as coming from SourceProfile
+func applyProfile(config *Config, sources map[string]Value, profile *selectedProfile) error {
+	for _, key := range sortedKeys(profile.fields) {
+		parsed, err := parseProfileValue(key, profile.fields[key])
+		if err != nil {
+			return fmt.Errorf("profile %q: %w", profile.name, err)
+		}
+		encoded, _ := json.Marshal(parsed)
+		if err := json.Unmarshal([]byte(fmt.Sprintf("{%q: %s}", key, encoded)), config); err != nil {
+			return fmt.Errorf("failed to apply profile %q: %w", profile.name, err)
+		}
+		sources[key] = Value{Source: SourceProfile, Origin: "profiles." + profile.name}
+	}
+	return nil
+}
+
+// parseProfileValue validates one field of a profile like a top-level key
+func parseProfileValue(key string, raw json.RawMessage) (interface{}, error) {
+	if key == profileKey {
+		return nil, fmt.Errorf("%s cannot be set in a profile", profileKey)
+	}
+	spec, ok := LookupKey(key)
+	if !ok {
+		return nil, unknownKeyError(key)
+	}
+	return spec.Parse(rawString(raw))
+}
+
+// validateProfiles checks the raw "profiles" value of a config file and
+// returns one error per problem
+func validateProfiles(raw json.RawMessage) []error {
+	var profiles map[string]map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &profiles); err != nil {
+		return []error{fmt.Errorf("invalid profiles: expected an object of profiles, each an object of config keys")}
+	}
+
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var problems []error
+	for _, name := range names {
+		for _, key := range sortedKeys(profiles[name]) {
+			if _, err := parseProfileValue(key, profiles[name][key]); err != nil {
+				problems = append(problems, fmt.Errorf("profile %q: %w", name, err))
+			}
+		}
+	}
+	return problems
+}
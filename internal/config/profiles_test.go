@@ -0,0 +1,259 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfig_Profile(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	content := `{
+  "model": "llama3",
+  "timeout_seconds": 60,
+  "profiles": {
+    "fast": {"model": "qwen2.5:3b", "timeout_seconds": "20s"},
+    "thorough": {"model": "gpt-oss:120b", "stream": true},
+    "broken": {"modle": "typo"}
+  }
+}`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	tests := []struct {
+		name            string
+		profile         string
+		env             string
+		expectedModel   string
+		expectedTimeout int
+		expectedSource  string
+		expectedError   string
+	}{
+		{
+			name:            "No profile uses the top-level config",
+			expectedModel:   "llama3",
+			expectedTimeout: 60,
+			expectedSource:  SourceRepo,
+		},
+		{
+			name:            "Profile overlays its fields",
+			profile:         "fast",
+			expectedModel:   "qwen2.5:3b",
+			expectedTimeout: 20,
+			expectedSource:  SourceProfile,
+		},
+		{
+			name:            "Fields the profile does not set are kept",
+			profile:         "thorough",
+			expectedModel:   "gpt-oss:120b",
+			expectedTimeout: 60,
+			expectedSource:  SourceProfile,
+		},
+		{
+			name:            "Environment overrides the profile",
+			profile:         "fast",
+			env:             "mistral",
+			expectedModel:   "mistral",
+			expectedTimeout: 20,
+			expectedSource:  SourceEnv,
+		},
+		{
+			name:          "Unknown profile",
+			profile:       "fastest",
+			expectedError: `profile "fastest" not found (available: broken, fast, thorough)`,
+		},
+		{
+			name:          "Invalid profile key",
+			profile:       "broken",
+			expectedError: `profile "broken": unknown config key "modle"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.env != "" {
+				t.Setenv("AI_COMMIT_MODEL", tt.env)
+			}
+			loader := NewConfigLoaderWithPath(configPath)
+			loader.SetProfile(tt.profile)
+
+			config, values, err := loader.LoadEffective()
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("Expected error %q, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to load config: %v", err)
+			}
+			if config.Model != tt.expectedModel {
+				t.Errorf("Expected model %q, got %q", tt.expectedModel, config.Model)
+			}
+			if config.TimeoutSeconds != tt.expectedTimeout {
+				t.Errorf("Expected timeout %d, got %d", tt.expectedTimeout, config.TimeoutSeconds)
+			}
+			for _, value := range values {
+				if value.Key == "model" && value.Source != tt.expectedSource {
+					t.Errorf("Expected model from %s, got %s", tt.expectedSource, value.Source)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadConfig_ProfileWithoutProfiles(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(configPath, []byte(`{"model": "llama3"}`), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	loader := NewConfigLoaderWithPath(configPath)
+	loader.SetProfile("fast")
+	_, err := loader.LoadConfig()
+	if err == nil || !strings.Contains(err.Error(), "defines no profiles") {
+		t.Fatalf("Expected a missing profiles error, got %v", err)
+	}
+}
+
+func TestLoadConfig_ProfileLayers(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	globalPath, err := GlobalConfigPath()
+	if err != nil {
+		t.Fatalf("GlobalConfigPath failed: %v", err)
+	}
+	repoPath := filepath.Join(t.TempDir(), ".commit-generator-config")
+	global := `{
+  "model": "llama3",
+  "base_url": "http://localhost:11434/api/generate",
+  "profiles": {
+    "work": {"model": "work-model", "base_url": "https://ollama.example.com/api/generate", "timeout_seconds": "2m"},
+    "personal": {"model": "llama3.2"}
+  }
+}`
+
+	tests := []struct {
+		name          string
+		global        string
+		repo          string
+		flag          string
+		env           string
+		expected      map[string]string // key -> value@source
+		expectedError string
+	}{
+		{
+			name:   "No profile",
+			global: global,
+			repo:   `{"model": "qwen2.5-coder"}`,
+			expected: map[string]string{
+				"model":    "qwen2.5-coder@repo",
+				"base_url": "http://localhost:11434/api/generate@global",
+				"profile":  "@default",
+			},
+		},
+		{
+			name:   "Global profile sits under the repository config",
+			global: global,
+			repo:   `{"model": "qwen2.5-coder"}`,
+			flag:   "work",
+			expected: map[string]string{
+				"model":           "qwen2.5-coder@repo",
+				"base_url":        "https://ollama.example.com/api/generate@profile",
+				"timeout_seconds": "120@profile",
+				"profile":         "work@profile",
+			},
+		},
+		{
+			name:   "Selected by AI_COMMIT_PROFILE",
+			global: global,
+			repo:   `{}`,
+			env:    "work",
+			expected: map[string]string{
+				"model":   "work-model@profile",
+				"profile": "work@env",
+			},
+		},
+		{
+			name:   "Selected by the profile key",
+			global: global,
+			repo:   `{"profile": "personal"}`,
+			expected: map[string]string{
+				"model":   "llama3.2@profile",
+				"profile": "personal@repo",
+			},
+		},
+		{
+			name:   "--profile wins over the environment and the key",
+			global: global,
+			repo:   `{"profile": "personal"}`,
+			flag:   "work",
+			env:    "personal",
+			expected: map[string]string{
+				"model":   "work-model@profile",
+				"profile": "work@profile",
+			},
+		},
+		{
+			name:   "Repository profile overlays the repository config",
+			global: global,
+			repo:   `{"model": "qwen2.5-coder", "profiles": {"work": {"model": "repo-work-model"}}}`,
+			flag:   "work",
+			expected: map[string]string{
+				"model":           "repo-work-model@profile",
+				"base_url":        "http://localhost:11434/api/generate@global",
+				"timeout_seconds": "60@default",
+			},
+		},
+		{
+			name:          "Unknown profile lists the profiles of every file",
+			global:        global,
+			repo:          `{"profiles": {"fast": {"model": "qwen2.5:3b"}}}`,
+			env:           "home",
+			expectedError: `profile "home" not found (available: fast, personal, work)`,
+		},
+		{
+			name:          "Unknown profile without profiles",
+			repo:          `{"profile": "work"}`,
+			expectedError: `profile "work" not found: the config defines no profiles`,
+		},
+		{
+			name:          "Profile naming a profile",
+			global:        `{"profiles": {"work": {"profile": "personal"}}}`,
+			repo:          `{}`,
+			flag:          "work",
+			expectedError: `profile "work": profile cannot be set in a profile`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("AI_COMMIT_PROFILE", tt.env)
+			writeOrRemove(t, globalPath, tt.global)
+			writeOrRemove(t, repoPath, tt.repo)
+
+			loader := NewConfigLoaderWithPath(repoPath)
+			loader.SetProfile(tt.flag)
+			_, values, err := loader.LoadEffective()
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("Expected error %q, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadEffective failed: %v", err)
+			}
+			got := make(map[string]string)
+			for _, v := range values {
+				got[v.Key] = v.Value + "@" + v.Source
+			}
+			for key, want := range tt.expected {
+				if got[key] != want {
+					t.Errorf("%s: expected %q, got %q", key, want, got[key])
+				}
+			}
+		})
+	}
+}
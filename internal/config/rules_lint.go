@@ -0,0 +1,163 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	// maxRulesTokens is the estimated size above which rules start crowding
+	// the diff out of the model's context
+	maxRulesTokens = 1000
+	// maxRuleLineLength flags single rules long enough to be several rules
+	maxRuleLineLength = 300
+)
+
+// RuleIssue is a problem found in the rules file
+type RuleIssue struct {
+	// Line is the 1-based line the issue refers to, or 0 for the whole file
+	Line    int
+	Message string
+}
+
+// RulesReport is the result of linting a rules file
+type RulesReport struct {
+	// Rules is the number of non-comment, non-blank lines
+	Rules int
+	// Tokens is a rough estimate of the tokens the rules add to each prompt
+	Tokens int
+	Issues []RuleIssue
+}
+
+// EstimateTokens roughly estimates the token count of text (~4 characters
+// per token for English prose and code)
+func EstimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+var (
+	subjectLimitPattern = regexp.MustCompile(`(?i)(\d+)\s*(?:characters|chars)`)
+	alwaysNeverPattern  = regexp.MustCompile(`(?i)\b(always|never|do not|don't)\s+(.+)`)
+	wordPattern         = regexp.MustCompile(`[a-z0-9]+`)
+)
+
+// conflictingTerms pairs instructions that cannot both be followed
+var conflictingTerms = []struct {
+	a, b   []string
+	reason string
+}{
+	{[]string{"past tense"}, []string{"imperative", "present tense"}, "tense"},
+	{[]string{"capitalize", "uppercase", "capital letter"}, []string{"lowercase", "lower case", "lower-case"}, "subject casing"},
+	{[]string{"single line", "one line", "single-line", "one-line"}, []string{"include a body", "add a body", "detailed description"}, "message length"},
+}
+
+// LintRules checks rules file content for issues that make the model likely
+// to ignore it: empty files, excessive length and contradictory instructions
+func LintRules(content string) RulesReport {
+	report := RulesReport{Tokens: EstimateTokens(content)}
+
+	type rule struct {
+		line int
+		text string
+	}
+	var rules []rule
+	seen := make(map[string]int)
+	for i, line := range strings.Split(content, "\n") {
+		text := strings.TrimSpace(line)
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		rules = append(rules, rule{line: i + 1, text: text})
+
+		key := strings.ToLower(strings.TrimLeft(text, "-*• "))
+		if first, ok := seen[key]; ok {
+			report.Issues = append(report.Issues, RuleIssue{Line: i + 1, Message: fmt.Sprintf("duplicates line %d", first)})
+		} else {
+			seen[key] = i + 1
+		}
+		if len(text) > maxRuleLineLength {
+			report.Issues = append(report.Issues, RuleIssue{Line: i + 1, Message: fmt.Sprintf("rule is %d characters long; split it into shorter rules", len(text))})
+		}
+	}
+	report.Rules = len(rules)
+
+	if len(rules) == 0 {
+		report.Issues = append(report.Issues, RuleIssue{Message: "the rules file has no rules (only blank lines or comments)"})
+		return report
+	}
+	if report.Tokens > maxRulesTokens {
+		report.Issues = append(report.Issues, RuleIssue{Message: fmt.Sprintf("rules use about %d tokens of every prompt (more than %d); trim them so the diff keeps enough context", report.Tokens, maxRulesTokens)})
+	}
+
+	// Opposing terms, e.g. "past tense" and "imperative"
+	for _, pair := range conflictingTerms {
+		lineA, lineB := 0, 0
+		for _, r := range rules {
+			lower := strings.ToLower(r.text)
+			if lineA == 0 && containsAny(lower, pair.a) {
+				lineA = r.line
+			}
+			if lineB == 0 && containsAny(lower, pair.b) {
+				lineB = r.line
+			}
+		}
+		if lineA != 0 && lineB != 0 && lineA != lineB {
+			report.Issues = append(report.Issues, RuleIssue{Line: lineB, Message: fmt.Sprintf("conflicts with line %d about %s", lineA, pair.reason)})
+		}
+	}
+
+	// Different character limits, e.g. "Max 50 characters" and "72 chars"
+	limits := make(map[string]int)
+	for _, r := range rules {
+		for _, m := range subjectLimitPattern.FindAllStringSubmatch(r.text, -1) {
+			if _, ok := limits[m[1]]; !ok {
+				limits[m[1]] = r.line
+			}
+		}
+	}
+	if len(limits) > 1 {
+		var values []string
+		for v := range limits {
+			values = append(values, v)
+		}
+		sort.Strings(values)
+		report.Issues = append(report.Issues, RuleIssue{Message: fmt.Sprintf("different character limits are given (%s); keep one", strings.Join(values, ", "))})
+	}
+
+	// "Always X" next to "Never X"
+	always := make(map[string]int)
+	never := make(map[string]int)
+	for _, r := range rules {
+		m := alwaysNeverPattern.FindStringSubmatch(r.text)
+		if m == nil {
+			continue
+		}
+		key := strings.Join(wordPattern.FindAllString(strings.ToLower(m[2]), -1), " ")
+		if strings.EqualFold(m[1], "always") {
+			always[key] = r.line
+		} else {
+			never[key] = r.line
+		}
+	}
+	for key, lineA := range always {
+		if lineB, ok := never[key]; ok {
+			report.Issues = append(report.Issues, RuleIssue{Line: lineB, Message: fmt.Sprintf("contradicts line %d (always vs never %q)", lineA, key)})
+		}
+	}
+
+	sort.SliceStable(report.Issues, func(i, j int) bool {
+		return report.Issues[i].Line < report.Issues[j].Line
+	})
+	return report
+}
+
+func containsAny(s string, terms []string) bool {
+	for _, term := range terms {
+		if strings.Contains(s, term) {
+			return true
+		}
+	}
+	return false
+}
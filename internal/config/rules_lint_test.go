@@ -0,0 +1,119 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestLintRules(t *testing.T) {
+	tests := []struct {
+		name           string
+		content        string
+		expectedRules  int
+		expectedIssues []string
+	}{
+		{
+			name:          "Clean rules",
+			content:       "# Team rules\n- Use past tense\n- Max 50 characters for the subject line\n- Mention the UI if it changes\n",
+			expectedRules: 3,
+		},
+		{
+			name:           "Only comments",
+			content:        "# Git Commit Rules for AI Generator\n# - Always start with a verb\n\n",
+			expectedIssues: []string{"has no rules"},
+		},
+		{
+			name:           "Empty file",
+			content:        "",
+			expectedIssues: []string{"has no rules"},
+		},
+		{
+			name:           "Tense conflict",
+			content:        "- Use past tense\n- Write the subject in the imperative mood\n",
+			expectedRules:  2,
+			expectedIssues: []string{"line 2: conflicts with line 1 about tense"},
+		},
+		{
+			name:           "Casing conflict",
+			content:        "- Capitalize the subject\n- Keep everything lowercase\n",
+			expectedRules:  2,
+			expectedIssues: []string{"line 2: conflicts with line 1 about subject casing"},
+		},
+		{
+			name:           "Different limits",
+			content:        "- Max 50 characters for the subject\n- Keep the subject under 72 chars\n",
+			expectedRules:  2,
+			expectedIssues: []string{"different character limits are given (50, 72)"},
+		},
+		{
+			name:           "Always and never",
+			content:        "- Always include the ticket ID\n- Never include the ticket ID.\n",
+			expectedRules:  2,
+			expectedIssues: []string{`line 2: contradicts line 1 (always vs never "include the ticket id")`},
+		},
+		{
+			name:           "Duplicate rule",
+			content:        "- Use past tense\n* use past tense\n",
+			expectedRules:  2,
+			expectedIssues: []string{"line 2: duplicates line 1"},
+		},
+		{
+			name:           "Long rule",
+			content:        "- " + strings.Repeat("word ", 70),
+			expectedRules:  1,
+			expectedIssues: []string{"line 1: rule is"},
+		},
+		{
+			name:           "Too many tokens",
+			content:        manyRules(120),
+			expectedRules:  120,
+			expectedIssues: []string{"rules use about"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := LintRules(tt.content)
+			if report.Rules != tt.expectedRules {
+				t.Errorf("expected %d rules, got %d", tt.expectedRules, report.Rules)
+			}
+
+			var issues []string
+			for _, issue := range report.Issues {
+				if issue.Line > 0 {
+					issues = append(issues, fmt.Sprintf("line %d: %s", issue.Line, issue.Message))
+				} else {
+					issues = append(issues, issue.Message)
+				}
+			}
+			joined := strings.Join(issues, "\n")
+			for _, want := range tt.expectedIssues {
+				if !strings.Contains(joined, want) {
+					t.Errorf("expected issue containing %q, got:\n%s", want, joined)
+				}
+			}
+			if len(tt.expectedIssues) != len(issues) {
+				t.Errorf("expected %d issues, got:\n%s", len(tt.expectedIssues), joined)
+			}
+		})
+	}
+}
+
+// manyRules returns n distinct rules
+func manyRules(n int) string {
+	var sb strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&sb, "- Rule number %d: describe the change in detail\n", i)
+	}
+	return sb.String()
+}
+
+func TestEstimateTokens(t *testing.T) {
+	if got := EstimateTokens(""); got != 0 {
+		t.Errorf("expected 0 tokens for empty text, got %d", got)
+	}
+	if got := EstimateTokens("12345678"); got != 2 {
+		t.Errorf("expected 2 tokens, got %d", got)
+	}
+}
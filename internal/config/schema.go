@@ -0,0 +1,630 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"ai-commit-message-generator/internal/ai"
+	"ai-commit-message-generator/internal/git"
+	"ai-commit-message-generator/internal/logging"
+)
+
+// KeySpec describes one configuration key
+type KeySpec struct {
+	// Name is the JSON key
+	Name        string
+	Description string
+	// Secret keys are masked when listed
+	Secret bool
+	// parse converts a command-line value into the JSON value to store
+	parse func(value string) (interface{}, error)
+}
+
+// Schema lists every configuration key in the order they are written
+var Schema = []KeySpec{
+	{Name: "api_key", Description: "Ollama API key (overrides OLLAMA_API_KEY)", Secret: true, parse: parseString},
+	{Name: "model", Description: "Model to generate messages with", parse: parseNonEmpty},
+	{Name: "base_url", Description: "Ollama generate endpoint", parse: parseURL},
+	{Name: "timeout_seconds", Description: "Request timeout in seconds, or a duration such as 90s or 2m", parse: parseTimeout},
+	{Name: "diff_context_lines", Description: "Unchanged lines around each diff hunk", parse: parseNonNegativeInt},
+	{Name: "max_file_bytes", Description: "Size in bytes above which a staged file is summarized in a line instead of diffed (0 for no limit)", parse: parseNonNegativeInt},
+	{Name: "system_prompt", Description: "Custom system prompt", parse: parseString},
+	{Name: "system_prompt_mode", Description: "replace or prepend", parse: parseEnum("", "replace", "prepend")},
+	{Name: "api_key_source", Description: "Where to read the API key: keychain, env:VAR, file:PATH or git-config:KEY", parse: parseAPIKeySource},
+	{Name: "test_path_patterns", Description: "Comma-separated globs marking test files (replaces the built-in detection)", parse: parseGlobList},
+	{Name: "test_file_policy", Description: "prefer_test_type_when_only_tests or fold_into_main", parse: parseTestFilePolicy},
+	{Name: "stream", Description: "Stream the response and count tokens while waiting (true or false)", parse: parseBool},
+	{Name: "fast_path", Description: "Give docs-, config- and dependency-only changes a fixed type and a shorter prompt (true or false)", parse: parseBool},
+	{Name: "fast_path_docs", Description: "Comma-separated globs of documentation files for the fast path", parse: parseGlobList},
+	{Name: "fast_path_config", Description: "Comma-separated globs of configuration files for the fast path", parse: parseGlobList},
+	{Name: "fast_path_deps", Description: "Comma-separated globs of dependency manifests and lockfiles for the fast path", parse: parseGlobList},
+	{Name: "git_backend", Description: "auto, go-git or exec (run the git binary)", parse: parseEnum("", string(git.BackendAuto), string(git.BackendGoGit), string(git.BackendExec))},
+	{Name: "commit_backend", Description: "go-git (default) or exec (git commit, running the repository's hooks)", parse: parseEnum("", string(git.BackendGoGit), string(git.BackendExec))},
+	{Name: "protected_branches", Description: "Comma-separated branch globs whose commits reword refuses to rewrite (default: main, master)", parse: parseGlobList},
+	{Name: "max_body_length", Description: "Most characters a generated message body may have (0 for no limit)", parse: parseNonNegativeInt},
+	{Name: "body_overflow", Description: "What to do with a longer body: truncate (at a sentence, with an ellipsis) or regenerate", parse: parseEnum("", "truncate", "regenerate")},
+	{Name: "branch_pattern", Description: "Layout of suggested branch names from {type}, {ticket} and {slug} (default: {type}/{ticket}-{slug})", parse: parseBranchPattern},
+	{Name: "analyze_go", Description: "Name the changed Go functions, types and methods in the prompt (true or false)", parse: parseBool},
+	{Name: "prepend_diff_stat", Description: "Put a per-file count of added and removed lines above the diff (true or false)", parse: parseBool},
+	{Name: "signoff", Description: "Append a Signed-off-by trailer from user.name and user.email (true or false)", parse: parseBool},
+	{Name: "include_diff_digest", Description: "Append an X-Diff-SHA256 trailer with the hash of the diff in the prompt (true or false)", parse: parseBool},
+	{Name: "trailers", Description: "Comma-separated \"Key: value\" trailers appended to every message", parse: parseTrailers},
+	{Name: "scope_map", Description: "Comma-separated path=scope pairs giving the scope of changes under a path, e.g. internal/ai=ai,cmd/=cli", parse: parseScopeMap},
+	{Name: "suggest_splits", Description: "Let the model suggest splitting a change into several commits (true or false)", parse: parseBool},
+	{Name: "merge_existing_message", Description: "Have the prepare-commit-msg hook extend a message already in the file, such as a ticket line, instead of replacing it (true or false)", parse: parseBool},
+	{Name: "header_format", Description: "Layout of the first line from {{.Type}}, {{.Scope}} and {{.Description}} (default: {{.Type}}({{.Scope}}): {{.Description}})", parse: parseHeaderFormat},
+	{Name: "types", Description: "Comma-separated commit types the model may use and the commit-msg hook accepts (default: the Conventional Commits types)", parse: parseTypes},
+	{Name: "scopes", Description: "Comma-separated scopes the model must choose from and the commit-msg hook accepts (default: any scope)", parse: parseScopes},
+	{Name: "scope_policy", Description: "Whether messages need a scope: optional, required (asked for again, then an error) or forbidden (removed)", parse: parseEnum("", "optional", "required", "forbidden")},
+	{Name: "subject_capitalize", Description: "Start the description after the colon with a capital letter (true or false)", parse: parseBool},
+	{Name: "subject_trailing_period", Description: "End the description after the colon with a period (true or false)", parse: parseBool},
+	{Name: "keep_alive", Description: "How long Ollama keeps the model loaded after a request: seconds (-1 for always) or a duration such as 5m", parse: parseKeepAlive},
+	{Name: "requests_per_minute", Description: "Most API calls per minute, retries included (0 for no limit)", parse: parseNonNegativeInt},
+	{Name: "cache", Description: "Let identical generations running at the same time share one API call (true or false)", parse: parseBool},
+	{Name: "check_updates", Description: "Check GitHub for a newer release and print a notice (true or false)", parse: parseBool},
+	{Name: "auth_header", Description: "Header the API key is sent in, e.g. api-key (default: Authorization)", parse: parseAuthHeader},
+	{Name: "auth_scheme", Description: "Prefix of the API key in auth_header (default: Bearer for Authorization, none otherwise)", parse: parseString},
+	{Name: "log_format", Description: "Format of warnings and --verbose diagnostics on stderr: text (default) or json", parse: parseEnum("", logging.FormatText, logging.FormatJSON)},
+	{Name: "profile", Description: "Profile from profiles to use when --profile is not given", parse: parseString},
+}
+
+// LookupKey returns the spec for a configuration key
+func LookupKey(name string) (KeySpec, bool) {
+	for _, spec := range Schema {
+		if spec.Name == name {
+			return spec, true
+		}
+	}
+	return KeySpec{}, false
+}
+
+// ValidKeys returns the names of every configuration key
+func ValidKeys() []string {
+	names := make([]string, len(Schema))
+	for i, spec := range Schema {
+		names[i] = spec.Name
+	}
+	return names
+}
+
+// unknownKeyError lists the valid keys so typos are easy to fix
+func unknownKeyError(key string) error {
+	return fmt.Errorf("unknown config key %q (valid keys: %s)", key, strings.Join(ValidKeys(), ", "))
+}
+
+// Parse validates value for this key and returns the JSON value to store
+func (s KeySpec) Parse(value string) (interface{}, error) {
+	v, err := s.parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for %s: %w", s.Name, err)
+	}
+	return v, nil
+}
+
+func parseString(value string) (interface{}, error) {
+	return value, nil
+}
+
+func parseNonEmpty(value string) (interface{}, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, fmt.Errorf("must not be empty")
+	}
+	return value, nil
+}
+
+func parseURL(value string) (interface{}, error) {
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("%q is not an http(s) URL", value)
+	}
+	return value, nil
+}
+
+func parseTimeout(value string) (interface{}, error) {
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds <= 0 {
+			return nil, fmt.Errorf("must be positive")
+		}
+		return seconds, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return nil, fmt.Errorf("%q is not a number of seconds or a duration like 90s", value)
+	}
+	if d < time.Second {
+		return nil, fmt.Errorf("must be at least 1s")
+	}
+	return int(d / time.Second), nil
+}
+
+func parseAuthHeader(value string) (interface{}, error) {
+	if err := ai.ValidateAuthHeader(value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func parseBool(value string) (interface{}, error) {
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, fmt.Errorf("%q is not true or false", value)
+	}
+	return b, nil
+}
+
+func parseNonNegativeInt(value string) (interface{}, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("%q is not a non-negative integer", value)
+	}
+	return n, nil
+}
+
+// parseGlobList accepts comma-separated globs, or a JSON array of them as
+// stored in the config file
+func parseGlobList(value string) (interface{}, error) {
+	var patterns []string
+	if strings.HasPrefix(strings.TrimSpace(value), "[") {
+		if err := json.Unmarshal([]byte(value), &patterns); err != nil {
+			return nil, fmt.Errorf("%q is not a list of globs", value)
+		}
+	} else {
+		for _, pattern := range strings.Split(value, ",") {
+			if pattern = strings.TrimSpace(pattern); pattern != "" {
+				patterns = append(patterns, pattern)
+			}
+		}
+	}
+	if err := git.ValidateGlobs(patterns); err != nil {
+		return nil, err
+	}
+	return patterns, nil
+}
+
+// parseNameList accepts comma-separated names, or a JSON array of them as
+// stored in the config file
+func parseNameList(value, what string) ([]string, error) {
+	var names []string
+	if strings.HasPrefix(strings.TrimSpace(value), "[") {
+		if err := json.Unmarshal([]byte(value), &names); err != nil {
+			return nil, fmt.Errorf("%q is not a list of %s", value, what)
+		}
+		return names, nil
+	}
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// parseTypes accepts the commit types of the types key
+func parseTypes(value string) (interface{}, error) {
+	types, err := parseNameList(value, "commit types")
+	if err != nil {
+		return nil, err
+	}
+	return types, validateTypes(types)
+}
+
+// parseScopes accepts the scopes of the scopes key
+func parseScopes(value string) (interface{}, error) {
+	scopes, err := parseNameList(value, "scopes")
+	if err != nil {
+		return nil, err
+	}
+	return scopes, validateScopes(scopes)
+}
+
+// typeName matches a commit type: the linter reads the type of a header
+// as a run of letters
+var typeName = regexp.MustCompile(`^[A-Za-z]+$`)
+
+// validateTypes checks that every type can be written in a header
+func validateTypes(types []string) error {
+	for _, commitType := range types {
+		if !typeName.MatchString(commitType) {
+			return fmt.Errorf("type %q must be letters only", commitType)
+		}
+	}
+	return nil
+}
+
+// validateScopes checks that every scope can be written in a header
+func validateScopes(scopes []string) error {
+	for _, scope := range scopes {
+		if scope == "" || strings.ContainsAny(scope, "() :") {
+			return fmt.Errorf("scope %q must not be empty or contain spaces, colons or parentheses", scope)
+		}
+	}
+	return nil
+}
+
+// trailerLine matches a "Key: value" trailer
+var trailerLine = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9-]*: \S`)
+
+// parseTrailers accepts comma-separated "Key: value" trailers, or a JSON
+// array of them as stored in the config file
+func parseTrailers(value string) (interface{}, error) {
+	var trailers []string
+	if strings.HasPrefix(strings.TrimSpace(value), "[") {
+		if err := json.Unmarshal([]byte(value), &trailers); err != nil {
+			return nil, fmt.Errorf("%q is not a list of trailers", value)
+		}
+	} else {
+		for _, trailer := range strings.Split(value, ",") {
+			if trailer = strings.TrimSpace(trailer); trailer != "" {
+				trailers = append(trailers, trailer)
+			}
+		}
+	}
+	for _, trailer := range trailers {
+		if !trailerLine.MatchString(trailer) {
+			return nil, fmt.Errorf("%q is not a trailer of the form \"Key: value\"", trailer)
+		}
+	}
+	return trailers, nil
+}
+
+// parseScopeMap accepts comma-separated prefix=scope pairs, or a JSON object
+// of them as stored in the config file
+func parseScopeMap(value string) (interface{}, error) {
+	scopes := make(map[string]string)
+	if strings.HasPrefix(strings.TrimSpace(value), "{") {
+		if err := json.Unmarshal([]byte(value), &scopes); err != nil {
+			return nil, fmt.Errorf("%q is not an object of path prefixes and scopes", value)
+		}
+	} else {
+		for _, pair := range strings.Split(value, ",") {
+			if pair = strings.TrimSpace(pair); pair == "" {
+				continue
+			}
+			prefix, scope, ok := strings.Cut(pair, "=")
+			if !ok {
+				return nil, fmt.Errorf("%q is not of the form path=scope", pair)
+			}
+			scopes[strings.TrimSpace(prefix)] = strings.TrimSpace(scope)
+		}
+	}
+	for prefix, scope := range scopes {
+		if prefix == "" || scope == "" {
+			return nil, fmt.Errorf("scope_map entry %q=%q needs both a path and a scope", prefix, scope)
+		}
+		if strings.ContainsAny(scope, "() :") {
+			return nil, fmt.Errorf("scope %q cannot contain spaces, colons or parentheses", scope)
+		}
+	}
+	return scopes, nil
+}
+
+// coAuthorsKey holds the --co-author address book. Like profiles it is
+// edited by hand, so it is not part of the Schema.
+const coAuthorsKey = "co_authors"
+
+// coAuthorIdentity matches "Name <email>"
+var coAuthorIdentity = regexp.MustCompile(`^[^<>]+ <[^<>\s]+>$`)
+
+// validateCoAuthors checks that the address book maps shortcuts to
+// "Name <email>" identities
+func validateCoAuthors(raw json.RawMessage) []error {
+	var book map[string]string
+	if err := json.Unmarshal(raw, &book); err != nil {
+		return []error{fmt.Errorf(`invalid co_authors: expected an object of shortcuts, e.g. {"jane": "Jane Doe <jane@example.com>"}`)}
+	}
+	names := make([]string, 0, len(book))
+	for name := range book {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var problems []error
+	for _, name := range names {
+		if !coAuthorIdentity.MatchString(book[name]) {
+			problems = append(problems, fmt.Errorf("co_authors %q: %q is not in the form \"Name <email>\"", name, book[name]))
+		}
+	}
+	return problems
+}
+
+// examplesKey holds the few-shot message examples. Like co_authors it is
+// edited by hand, so it is not part of the Schema.
+const examplesKey = "examples"
+
+// validateExamples checks that every example has a message and warns about
+// the ones that will never reach the prompt
+func validateExamples(raw json.RawMessage) []error {
+	var examples []ai.Example
+	if err := json.Unmarshal(raw, &examples); err != nil {
+		return []error{fmt.Errorf(`invalid examples: expected a list such as [{"diff_summary": "add login form", "message": "feat(auth): add login form"}]`)}
+	}
+	var problems []error
+	for i, example := range examples {
+		if strings.TrimSpace(example.Message) == "" {
+			problems = append(problems, fmt.Errorf("examples[%d]: message is required", i))
+		}
+	}
+	if len(examples) > ai.MaxExamples {
+		problems = append(problems, fmt.Errorf("examples: only the first %d of %d examples are used", ai.MaxExamples, len(examples)))
+	}
+	return problems
+}
+
+// parseTestFilePolicy accepts the test file policies; empty means the default
+var parseTestFilePolicy = parseEnum("", "prefer_test_type_when_only_tests", "fold_into_main")
+
+// branchPlaceholder matches a {placeholder} of branch_pattern
+var branchPlaceholder = regexp.MustCompile(`\{[^{}]*\}`)
+
+// parseBranchPattern accepts a branch name layout that contains {slug} and
+// no placeholders other than {type}, {ticket} and {slug}
+func parseBranchPattern(value string) (interface{}, error) {
+	if value == "" {
+		return value, nil
+	}
+	for _, placeholder := range branchPlaceholder.FindAllString(value, -1) {
+		switch placeholder {
+		case "{type}", "{ticket}", "{slug}":
+		default:
+			return nil, fmt.Errorf("unknown placeholder %s (expected {type}, {ticket} or {slug})", placeholder)
+		}
+	}
+	if !strings.Contains(value, "{slug}") {
+		return nil, fmt.Errorf("%q has no {slug}", value)
+	}
+	return value, nil
+}
+
+// parseKeepAlive accepts what ai.ParseKeepAlive does and stores it as a
+// string
+func parseKeepAlive(value string) (interface{}, error) {
+	if _, err := ai.ParseKeepAlive(value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// parseHeaderFormat accepts a header layout that ai.ParseHeaderFormat can
+// render and recognize
+func parseHeaderFormat(value string) (interface{}, error) {
+	if _, err := ai.ParseHeaderFormat(value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// parseEnum accepts one of values; "" among them means the key may be empty
+func parseEnum(values ...string) func(string) (interface{}, error) {
+	return func(value string) (interface{}, error) {
+		var named []string
+		for _, v := range values {
+			if value == v {
+				return value, nil
+			}
+			if v != "" {
+				named = append(named, v)
+			}
+		}
+		return nil, fmt.Errorf("%q is not one of: %s", value, strings.Join(named, ", "))
+	}
+}
+
+// GlobalConfigPath returns the per-user config file:
+// $XDG_CONFIG_HOME/ai-commit/config on Linux, and the platform equivalent
+// (~/Library/Application Support, %AppData%) elsewhere
+func GlobalConfigPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find the user config directory: %w", err)
+	}
+	return filepath.Join(dir, "ai-commit", "config"), nil
+}
+
+// Path returns the file this loader reads: the explicit path, or
+// .commit-generator-config in the repository root
+func (c *ConfigLoader) Path() (string, error) {
+	if c.path != "" {
+		return c.path, nil
+	}
+	repoRoot, err := findRepoRoot()
+	if err != nil {
+		return "", fmt.Errorf("failed to find repository root: %w", err)
+	}
+	return filepath.Join(repoRoot, ".commit-generator-config"), nil
+}
+
+// ReadValues returns the raw key/value pairs of a config file, YAML or
+// JSON, as JSON values. A missing file has no values.
+func ReadValues(path string) (map[string]json.RawMessage, error) {
+	values, _, err := readValues(path)
+	return values, err
+}
+
+// readValues is ReadValues along with the line each key is on
+func readValues(path string) (map[string]json.RawMessage, map[string]int, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return make(map[string]json.RawMessage), make(map[string]int), nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+	return decodeValues(path, data)
+}
+
+// GetValue returns the value of key in the config file as a string, and
+// whether it is set
+func GetValue(path, key string) (string, bool, error) {
+	if _, ok := LookupKey(key); !ok {
+		return "", false, unknownKeyError(key)
+	}
+	values, err := ReadValues(path)
+	if err != nil {
+		return "", false, err
+	}
+	raw, ok := values[key]
+	if !ok {
+		return "", false, nil
+	}
+	return rawString(raw), true, nil
+}
+
+// SetValue validates value and writes key to the config file, creating the
+// file if needed. Other keys, including ones this version does not know,
+// are kept, as are the comments of a YAML file. A JSON file stays JSON; a
+// new file is YAML.
+func SetValue(path, key, value string) error {
+	spec, ok := LookupKey(key)
+	if !ok {
+		return unknownKeyError(key)
+	}
+	parsed, err := spec.Parse(value)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+	if !isJSONConfig(data) {
+		updated, err := setYAMLValue(data, key, parsed)
+		if err != nil {
+			return fmt.Errorf("failed to update config file %s: %w", path, err)
+		}
+		return writeConfigFile(path, updated)
+	}
+
+	values, err := ReadValues(path)
+	if err != nil {
+		return err
+	}
+	encoded, err := encodeJSON(parsed)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", key, err)
+	}
+	values[key] = encoded
+	return writeValues(path, values)
+}
+
+// ValidateFile checks every key and value in a config file and returns one
+// error per problem
+func ValidateFile(path string) []error {
+	values, lines, err := readValues(path)
+	if err != nil {
+		return []error{err}
+	}
+
+	var problems []error
+	for _, key := range sortedKeys(values) {
+		var keyProblems []error
+		switch key {
+		case profilesKey:
+			keyProblems = validateProfiles(values[key])
+		case coAuthorsKey:
+			keyProblems = validateCoAuthors(values[key])
+		case examplesKey:
+			keyProblems = validateExamples(values[key])
+		case pathsKey:
+			keyProblems = validatePaths(values[key])
+		default:
+			spec, ok := LookupKey(key)
+			if !ok {
+				keyProblems = []error{unknownKeyError(key)}
+			} else if _, err := spec.Parse(rawString(values[key])); err != nil {
+				keyProblems = []error{err}
+			}
+		}
+		for _, problem := range keyProblems {
+			problems = append(problems, lineError(path, lines[key], problem))
+		}
+	}
+	return problems
+}
+
+// writeValues writes values as indented JSON, schema keys first in schema
+// order followed by any unknown keys. It is only used for the JSON files of
+// earlier versions.
+func writeValues(path string, values map[string]json.RawMessage) error {
+	var buf bytes.Buffer
+	buf.WriteString("{\n")
+	var keys []string
+	for _, name := range ValidKeys() {
+		if _, ok := values[name]; ok {
+			keys = append(keys, name)
+		}
+	}
+	for _, name := range sortedKeys(values) {
+		if _, known := LookupKey(name); !known {
+			keys = append(keys, name)
+		}
+	}
+	for i, key := range keys {
+		name, _ := json.Marshal(key)
+		var value bytes.Buffer
+		if err := json.Indent(&value, values[key], "  ", "  "); err != nil {
+			return fmt.Errorf("failed to encode %s: %w", key, err)
+		}
+		fmt.Fprintf(&buf, "  %s: %s", name, value.String())
+		if i < len(keys)-1 {
+			buf.WriteString(",")
+		}
+		buf.WriteString("\n")
+	}
+	buf.WriteString("}\n")
+	return writeConfigFile(path, buf.Bytes())
+}
+
+// writeConfigFile writes data to path, creating its directory if needed
+func writeConfigFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
+
+// rawString renders a raw JSON value as a plain string: strings unquoted,
+// everything else as compact JSON
+func rawString(raw json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, raw); err == nil {
+		return compact.String()
+	}
+	return string(raw)
+}
+
+func sortedKeys(values map[string]json.RawMessage) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// MaskSecret hides all but the first four characters of a secret
+func MaskSecret(value string) string {
+	if value == "" {
+		return ""
+	}
+	if len(value) <= 8 {
+		return "********"
+	}
+	return value[:4] + strings.Repeat("*", 8)
+}
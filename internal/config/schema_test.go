@@ -0,0 +1,234 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSetValue(t *testing.T) {
+	tests := []struct {
+		name        string
+		key         string
+		value       string
+		want        string
+		expectError string
+	}{
+		{name: "String value", key: "model", value: "llama3", want: "llama3"},
+		{name: "Timeout in seconds", key: "timeout_seconds", value: "90", want: "90"},
+		{name: "Timeout as duration", key: "timeout_seconds", value: "2m", want: "120"},
+		{name: "Bad duration", key: "timeout_seconds", value: "soon", expectError: "timeout_seconds"},
+		{name: "Zero timeout", key: "timeout_seconds", value: "0", expectError: "must be positive"},
+		{name: "Negative context lines", key: "diff_context_lines", value: "-1", expectError: "non-negative"},
+		{name: "Max file bytes", key: "max_file_bytes", value: "1048576", want: "1048576"},
+		{name: "Bad max file bytes", key: "max_file_bytes", value: "1MB", expectError: "not a non-negative integer"},
+		{name: "Bad URL", key: "base_url", value: "localhost:11434", expectError: "http(s) URL"},
+		{name: "Empty model", key: "model", value: " ", expectError: "must not be empty"},
+		{name: "Enum value", key: "system_prompt_mode", value: "prepend", want: "prepend"},
+		{name: "Bad enum value", key: "system_prompt_mode", value: "append", expectError: "replace, prepend"},
+		{name: "Glob list", key: "test_path_patterns", value: "e2e/, *Check.java", want: `["e2e/","*Check.java"]`},
+		{name: "Bad glob", key: "test_path_patterns", value: "[a-", expectError: "invalid"},
+		{name: "Test file policy", key: "test_file_policy", value: "fold_into_main", want: "fold_into_main"},
+		{name: "Bad test file policy", key: "test_file_policy", value: "skip", expectError: "prefer_test_type_when_only_tests, fold_into_main"},
+		{name: "Git backend", key: "git_backend", value: "exec", want: "exec"},
+		{name: "Bad git backend", key: "git_backend", value: "libgit2", expectError: "auto, go-git, exec"},
+		{name: "Protected branches", key: "protected_branches", value: "main, release/*", want: `["main","release/*"]`},
+		{name: "Max body length", key: "max_body_length", value: "400", want: "400"},
+		{name: "Bad max body length", key: "max_body_length", value: "-1", expectError: "not a non-negative integer"},
+		{name: "Requests per minute", key: "requests_per_minute", value: "30", want: "30"},
+		{name: "Bad requests per minute", key: "requests_per_minute", value: "fast", expectError: "not a non-negative integer"},
+		{name: "Cache", key: "cache", value: "true", want: "true"},
+		{name: "Body overflow", key: "body_overflow", value: "regenerate", want: "regenerate"},
+		{name: "Bad body overflow", key: "body_overflow", value: "wrap", expectError: "truncate, regenerate"},
+		{name: "Branch pattern", key: "branch_pattern", value: "{ticket}/{type}-{slug}", want: "{ticket}/{type}-{slug}"},
+		{name: "Branch pattern without slug", key: "branch_pattern", value: "{type}/{ticket}", expectError: "has no {slug}"},
+		{name: "Unknown branch placeholder", key: "branch_pattern", value: "{user}/{slug}", expectError: "unknown placeholder {user}"},
+		{name: "Analyze Go", key: "analyze_go", value: "true", want: "true"},
+		{name: "Prepend diff stat", key: "prepend_diff_stat", value: "false", want: "false"},
+		{name: "Signoff", key: "signoff", value: "true", want: "true"},
+		{name: "Subject trailing period", key: "subject_trailing_period", value: "true", want: "true"},
+		{name: "Trailers", key: "trailers", value: "Reviewed-by: Team <team@example.com>, Refs: #12", want: `["Reviewed-by: Team <team@example.com>","Refs: #12"]`},
+		{name: "Scope map", key: "scope_map", value: "internal/ai=ai, cmd/=cli", want: `{"cmd/":"cli","internal/ai":"ai"}`},
+		{name: "Scope map without a scope", key: "scope_map", value: "internal/ai", expectError: "not of the form path=scope"},
+		{name: "Bad scope", key: "scope_map", value: "cmd/=command line", expectError: "cannot contain spaces"},
+		{name: "Suggest splits", key: "suggest_splits", value: "false", want: "false"},
+		{name: "Merge existing message", key: "merge_existing_message", value: "false", want: "false"},
+		{name: "Header format", key: "header_format", value: "[{{.Scope}}] {{.Type}}: {{.Description}}", want: "[{{.Scope}}] {{.Type}}: {{.Description}}"},
+		{name: "Header format without a description", key: "header_format", value: "{{.Type}}({{.Scope}})", expectError: "{{.Description}} exactly once"},
+		{name: "Types", key: "types", value: "feat, fix, perf, build, ci", want: `["feat","fix","perf","build","ci"]`},
+		{name: "Bad type", key: "types", value: "feat, bug-fix", expectError: `type "bug-fix" must be letters only`},
+		{name: "Scopes", key: "scopes", value: "ai, git, app, config", want: `["ai","git","app","config"]`},
+		{name: "Bad scope in scopes", key: "scopes", value: "ai, front end", expectError: `scope "front end" must not be empty`},
+		{name: "Keep alive duration", key: "keep_alive", value: "5m", want: "5m"},
+		{name: "Keep alive forever", key: "keep_alive", value: "-1", want: "-1"},
+		{name: "Bad keep alive", key: "keep_alive", value: "forever", expectError: "not a number of seconds"},
+		{name: "Check updates", key: "check_updates", value: "true", want: "true"},
+		{name: "Auth header", key: "auth_header", value: "api-key", want: "api-key"},
+		{name: "Bad auth header", key: "auth_header", value: "api key", expectError: "not a valid HTTP header name"},
+		{name: "Auth scheme", key: "auth_scheme", value: "Token", want: "Token"},
+		{name: "Log format", key: "log_format", value: "json", want: "json"},
+		{name: "Bad log format", key: "log_format", value: "xml", expectError: "text, json"},
+		{name: "Bad trailer", key: "trailers", value: "reviewed by team", expectError: `not a trailer of the form "Key: value"`},
+		{name: "Unknown key", key: "provider", value: "openai", expectError: "valid keys: api_key, model"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "nested", "config")
+
+			err := SetValue(path, tt.key, tt.value)
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Fatalf("Expected error containing %q, got %v", tt.expectError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SetValue failed: %v", err)
+			}
+
+			got, ok, err := GetValue(path, tt.key)
+			if err != nil || !ok {
+				t.Fatalf("GetValue failed: ok=%v err=%v", ok, err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSetValue_PreservesOtherKeys(t *testing.T) {
+	warnings := captureWarnings(t)
+	path := filepath.Join(t.TempDir(), "config")
+	content := `{"team_note": {"owner": "platform"}, "model": "llama3", "timeout_seconds": 30}`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	if err := SetValue(path, "timeout_seconds", "45"); err != nil {
+		t.Fatalf("SetValue failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read config: %v", err)
+	}
+	written := string(data)
+	for _, want := range []string{`"model": "llama3"`, `"timeout_seconds": 45`, `"owner": "platform"`} {
+		if !strings.Contains(written, want) {
+			t.Errorf("Expected %s in config, got:\n%s", want, written)
+		}
+	}
+	// Schema keys come first, unknown keys last
+	if strings.Index(written, "model") > strings.Index(written, "team_note") {
+		t.Errorf("Expected schema keys before unknown keys, got:\n%s", written)
+	}
+
+	// The rewritten file still loads
+	cfg, err := NewConfigLoaderWithPath(path).LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.TimeoutSeconds != 45 || cfg.Model != "llama3" {
+		t.Errorf("Unexpected config after SetValue: %+v", cfg)
+	}
+	if !strings.Contains(warnings.String(), `unknown config key "team_note"`) {
+		t.Errorf("Expected a warning about team_note, got %q", warnings)
+	}
+}
+
+func TestGetValue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+
+	if _, ok, err := GetValue(path, "model"); err != nil || ok {
+		t.Errorf("Expected unset key in missing file, got ok=%v err=%v", ok, err)
+	}
+	if _, _, err := GetValue(path, "modle"); err == nil || !strings.Contains(err.Error(), "valid keys") {
+		t.Errorf("Expected unknown key error, got %v", err)
+	}
+}
+
+func TestValidateFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected []string
+	}{
+		{name: "Valid", content: `{"model": "llama3", "timeout_seconds": 60, "diff_context_lines": 0}`},
+		{name: "Valid test file settings", content: `{"test_path_patterns": ["e2e/", "*_spec.rb"], "test_file_policy": "fold_into_main"}`},
+		{name: "Valid profiles", content: `{"model": "llama3", "profiles": {"fast": {"model": "qwen2.5:3b", "timeout_seconds": 20}}}`},
+		{
+			name:     "Invalid profiles",
+			content:  `{"profiles": {"fast": {"modle": "qwen2.5:3b"}, "slow": {"timeout_seconds": "forever"}}}`,
+			expected: []string{`profile "fast": unknown config key "modle"`, `profile "slow": invalid value for timeout_seconds`},
+		},
+		{name: "Profiles not an object", content: `{"profiles": ["fast"]}`, expected: []string{"invalid profiles"}},
+		{name: "Valid co-authors", content: `{"signoff": true, "co_authors": {"jane": "Jane Doe <jane@example.com>"}, "trailers": ["Refs: #12"]}`},
+		{
+			name:     "Invalid co-authors",
+			content:  `{"co_authors": {"jane": "jane@example.com", "sam": "Sam Roe <sam@example.com>"}}`,
+			expected: []string{`co_authors "jane": "jane@example.com" is not in the form "Name <email>"`},
+		},
+		{name: "Valid examples", content: `{"examples": [{"diff_summary": "add login form", "message": "feat(auth): add login form"}]}`},
+		{name: "Example without a message", content: `{"examples": [{"diff_summary": "add login form"}]}`, expected: []string{"examples[0]: message is required"}},
+		{name: "Examples not a list", content: `{"examples": {"message": "feat: x"}}`, expected: []string{"invalid examples"}},
+		{name: "Valid paths", content: `{"paths": {"web/": {"rules_file": "web/rules.md", "header_format": "{{.Type}}: {{.Description}}", "subject_capitalize": true, "scopes": ["ui"], "language": "German"}}}`},
+		{
+			name:     "Invalid paths",
+			content:  `{"paths": {"services/": {"scope_policy": "sometimes"}, "web/": {"scopes": ["front end"]}}}`,
+			expected: []string{`paths "services/": invalid scope_policy`, `paths "web/": scope "front end" must not be empty`},
+		},
+		{name: "Unknown path override", content: `{"paths": {"web/": {"model": "llama3"}}}`, expected: []string{`invalid paths`}},
+		{name: "Valid scope map", content: `{"scope_map": {"internal/ai": "ai", "cmd/": "cli"}}`},
+		{name: "Invalid keep alive", content: `{"keep_alive": "a while"}`, expected: []string{"invalid value for keep_alive"}},
+		{name: "Invalid auth header", content: `{"auth_header": "api-key:"}`, expected: []string{"invalid value for auth_header"}},
+		{name: "Co-authors not an object", content: `{"co_authors": ["jane"]}`, expected: []string{"invalid co_authors"}},
+		{name: "Missing file", content: ""},
+		{name: "Invalid JSON", content: `{"model": `, expected: []string{"failed to parse"}},
+		{
+			name:     "Unknown key and bad value",
+			content:  `{"modle": "llama3", "system_prompt_mode": "append"}`,
+			expected: []string{`unknown config key "modle"`, "invalid value for system_prompt_mode"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config")
+			if tt.content != "" {
+				if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+					t.Fatalf("Failed to write config: %v", err)
+				}
+			}
+
+			problems := ValidateFile(path)
+			if len(problems) != len(tt.expected) {
+				t.Fatalf("Expected %d problems, got %v", len(tt.expected), problems)
+			}
+			for i, want := range tt.expected {
+				if !strings.Contains(problems[i].Error(), want) {
+					t.Errorf("Problem %d: expected %q, got %q", i, want, problems[i])
+				}
+			}
+		})
+	}
+}
+
+func TestMaskSecret(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{"", ""},
+		{"short", "********"},
+		{"sk-1234567890abcdef", "sk-1********"},
+	}
+
+	for _, tt := range tests {
+		if got := MaskSecret(tt.value); got != tt.expected {
+			t.Errorf("MaskSecret(%q) = %q, expected %q", tt.value, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,282 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config files are YAML. Files written by earlier versions are JSON, which
+// is recognized by its opening brace and kept as JSON when edited.
+
+// warningOutput receives the warnings about config files, such as unknown
+// keys, that do not stop the configuration from loading
+var warningOutput io.Writer = os.Stderr
+
+// isJSONConfig reports whether a config file is in the JSON format of
+// earlier versions: its first character other than whitespace is {
+func isJSONConfig(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte("{"))
+}
+
+// isHandEditedKey reports whether key is one of the keys that are valid in
+// a config file but not part of the Schema
+func isHandEditedKey(key string) bool {
+	return key == profilesKey || key == coAuthorsKey || key == examplesKey || key == pathsKey
+}
+
+// lineError prefixes err with the file and line it is about
+func lineError(path string, line int, err error) error {
+	if line <= 0 {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return fmt.Errorf("%s:%d: %w", path, line, err)
+}
+
+// decodeValues returns the top-level keys of a config file as raw JSON
+// values along with the line each key is on. Keys set to null are left
+// out, as if they were not set.
+func decodeValues(path string, data []byte) (map[string]json.RawMessage, map[string]int, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return make(map[string]json.RawMessage), make(map[string]int), nil
+	}
+	if isJSONConfig(data) {
+		return decodeJSONValues(path, data)
+	}
+	return decodeYAMLValues(path, data)
+}
+
+func decodeJSONValues(path string, data []byte) (map[string]json.RawMessage, map[string]int, error) {
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(data, &values); err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			return nil, nil, fmt.Errorf("failed to parse config file %w", lineError(path, lineAt(data, syntaxErr.Offset-1), err))
+		}
+		return nil, nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	for key, raw := range values {
+		if string(bytes.TrimSpace(raw)) == "null" {
+			delete(values, key)
+		}
+	}
+	return values, jsonKeyLines(data), nil
+}
+
+// jsonKeyLines finds the line of every top-level key of a JSON object
+func jsonKeyLines(data []byte) map[string]int {
+	lines := make(map[string]int)
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return lines
+	}
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return lines
+		}
+		if key, ok := token.(string); ok {
+			lines[key] = lineAt(data, decoder.InputOffset())
+		}
+		var skip json.RawMessage
+		if err := decoder.Decode(&skip); err != nil {
+			return lines
+		}
+	}
+	return lines
+}
+
+// lineAt returns the line of data that offset falls on
+func lineAt(data []byte, offset int64) int {
+	offset = max(0, min(offset, int64(len(data))))
+	return bytes.Count(data[:offset], []byte("\n")) + 1
+}
+
+func decodeYAMLValues(path string, data []byte) (map[string]json.RawMessage, map[string]int, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	values := make(map[string]json.RawMessage)
+	lines := make(map[string]int)
+	if len(doc.Content) == 0 {
+		// Only comments
+		return values, lines, nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, nil, lineError(path, root.Line, errors.New("expected the config to be key: value pairs"))
+	}
+
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		keyNode, valueNode := root.Content[i], root.Content[i+1]
+		key := keyNode.Value
+		if line, seen := lines[key]; seen {
+			return nil, nil, lineError(path, keyNode.Line, fmt.Errorf("%s is already set on line %d", key, line))
+		}
+		lines[key] = keyNode.Line
+		if valueNode.Tag == "!!null" {
+			continue
+		}
+		var value interface{}
+		if err := valueNode.Decode(&value); err != nil {
+			return nil, nil, lineError(path, keyNode.Line, fmt.Errorf("invalid value for %s: %w", key, err))
+		}
+		raw, err := encodeJSON(value)
+		if err != nil {
+			return nil, nil, lineError(path, keyNode.Line, fmt.Errorf("invalid value for %s: %w", key, err))
+		}
+		values[key] = raw
+	}
+	return values, lines, nil
+}
+
+// encodeJSON encodes v compactly, keeping the <> of trailers and addresses
+// readable
+func encodeJSON(v interface{}) (json.RawMessage, error) {
+	var encoded bytes.Buffer
+	encoder := json.NewEncoder(&encoded)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSpace(encoded.Bytes()), nil
+}
+
+// normalizeValues validates the schema keys of a config file the way
+// config set does and replaces their values with the parsed ones, so a
+// timeout such as 2m is read as seconds. Invalid values are errors; unknown
+// keys are reported to warningOutput and skipped.
+func normalizeValues(path string, values map[string]json.RawMessage, lines map[string]int) error {
+	for _, key := range sortedKeys(values) {
+		if isHandEditedKey(key) {
+			continue
+		}
+		spec, ok := LookupKey(key)
+		if !ok {
+			fmt.Fprintf(warningOutput, "Warning: %v\n", lineError(path, lines[key], unknownKeyError(key)))
+			delete(values, key)
+			continue
+		}
+		parsed, err := spec.Parse(rawString(values[key]))
+		if err != nil {
+			return lineError(path, lines[key], err)
+		}
+		if values[key], err = encodeJSON(parsed); err != nil {
+			return lineError(path, lines[key], fmt.Errorf("failed to encode %s: %w", key, err))
+		}
+	}
+	return nil
+}
+
+// setYAMLValue sets key to value in a YAML config file, keeping its comments
+// and the order of its keys. A new key is added at the end.
+func setYAMLValue(data []byte, key string, value interface{}) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Kind == 0 {
+		doc.Kind = yaml.DocumentNode
+	}
+	if len(doc.Content) == 0 {
+		doc.Content = []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, errors.New("expected the config to be key: value pairs")
+	}
+
+	var valueNode yaml.Node
+	if err := valueNode.Encode(value); err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", key, err)
+	}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == key {
+			valueNode.LineComment = root.Content[i+1].LineComment
+			root.Content[i+1] = &valueNode
+			return encodeYAML(&doc)
+		}
+	}
+	root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, &valueNode)
+	return encodeYAML(&doc)
+}
+
+func encodeYAML(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// configTemplateHeader opens the config files init writes
+const configTemplateHeader = `# generate-commit configuration. Uncomment a key to set it; every key is
+# described in the README, and 'generate-commit config list' shows the
+# effective value of each and where it comes from.
+`
+
+// renderConfig writes config as a commented YAML file: every schema key
+// with its description, the unset ones commented out, followed by the
+// hand-edited keys that are set
+func renderConfig(config *Config) ([]byte, error) {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	var values map[string]interface{}
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(configTemplateHeader)
+	for _, spec := range Schema {
+		fmt.Fprintf(&buf, "\n# %s\n", spec.Description)
+		value, set := values[spec.Name]
+		if !set || value == "" {
+			fmt.Fprintf(&buf, "# %s:\n", spec.Name)
+			continue
+		}
+		if err := writeYAMLKey(&buf, spec.Name, value); err != nil {
+			return nil, err
+		}
+	}
+
+	var extra []string
+	for key := range values {
+		if _, known := LookupKey(key); !known {
+			extra = append(extra, key)
+		}
+	}
+	sort.Strings(extra)
+	for _, key := range extra {
+		buf.WriteString("\n")
+		if err := writeYAMLKey(&buf, key, values[key]); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// writeYAMLKey writes key: value as YAML
+func writeYAMLKey(buf *bytes.Buffer, key string, value interface{}) error {
+	encoded, err := encodeYAML(map[string]interface{}{key: value})
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", key, err)
+	}
+	buf.WriteString(strings.TrimRight(string(encoded), "\n") + "\n")
+	return nil
+}
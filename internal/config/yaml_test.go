@@ -0,0 +1,303 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"ai-commit-message-generator/internal/ai"
+)
+
+// fullConfig sets every key of the Schema and the hand-edited ones
+func fullConfig() *Config {
+	disabled := false
+	return &Config{
+		APIKey:                "sk-test",
+		Model:                 "llama3",
+		BaseURL:               "https://ollama.example.com/api/generate",
+		TimeoutSeconds:        120,
+		DiffContextLines:      5,
+		MaxFileBytes:          1 << 20,
+		SystemPrompt:          "You are terse.\nNo emoji.",
+		SystemPromptMode:      "prepend",
+		APIKeySource:          "env:OLLAMA_TOKEN",
+		TestPathPatterns:      []string{"e2e/", "*_spec.rb"},
+		TestFilePolicy:        "fold_into_main",
+		Stream:                true,
+		FastPath:              &disabled,
+		FastPathDocs:          []string{"*.md"},
+		FastPathConfig:        []string{"*.yaml"},
+		FastPathDeps:          []string{"go.sum"},
+		GitBackend:            "exec",
+		CommitBackend:         "exec",
+		ProtectedBranches:     []string{"main", "release/*"},
+		MaxBodyLength:         400,
+		BodyOverflow:          "regenerate",
+		BranchPattern:         "{ticket}/{type}-{slug}",
+		AnalyzeGo:             true,
+		PrependDiffStat:       true,
+		Signoff:               true,
+		IncludeDiffDigest:     true,
+		CoAuthors:             map[string]string{"jane": "Jane Doe <jane@example.com>"},
+		Trailers:              []string{"Refs: #12"},
+		Examples:              []ai.Example{{DiffSummary: "add login form", Message: "feat(auth): add login form"}},
+		ScopeMap:              map[string]string{"internal/ai": "ai"},
+		SuggestSplits:         &disabled,
+		MergeExistingMessage:  &disabled,
+		HeaderFormat:          "[{{.Scope}}] {{.Type}}: {{.Description}}",
+		Types:                 []string{"feat", "fix", "perf"},
+		Scopes:                []string{"ai", "git"},
+		ScopePolicy:           "required",
+		SubjectCapitalize:     true,
+		SubjectTrailingPeriod: true,
+		KeepAlive:             "5m",
+		RequestsPerMinute:     30,
+		Cache:                 true,
+		CheckUpdates:          true,
+		AuthHeader:            "api-key",
+		AuthScheme:            "Token",
+		LogFormat:             "json",
+		Profiles:              map[string]map[string]json.RawMessage{"fast": {"timeout_seconds": json.RawMessage(`120`)}},
+		Paths:                 map[string]PathConfig{"web/": {RulesFile: "web/rules.md", SubjectCapitalize: &disabled, Scopes: []string{"ui"}, Language: "German"}},
+		Profile:               "fast",
+	}
+}
+
+// captureWarnings collects what is written to warningOutput during the test
+func captureWarnings(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	previous := warningOutput
+	warningOutput = &buf
+	t.Cleanup(func() { warningOutput = previous })
+	return &buf
+}
+
+func TestSaveConfig_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *Config
+	}{
+		{name: "Every key set", config: fullConfig()},
+		{name: "Defaults", config: &Config{Model: "gpt-oss:120b", BaseURL: "http://localhost:11434/api/generate", TimeoutSeconds: 60, DiffContextLines: 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repoRoot := t.TempDir()
+			warnings := captureWarnings(t)
+			if err := NewConfigLoader().SaveConfig(repoRoot, tt.config); err != nil {
+				t.Fatalf("SaveConfig failed: %v", err)
+			}
+			path := filepath.Join(repoRoot, ".commit-generator-config")
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("Failed to read config: %v", err)
+			}
+			if isJSONConfig(data) {
+				t.Fatalf("Expected a YAML config, got:\n%s", data)
+			}
+
+			loaded, err := NewConfigLoaderWithPath(path).LoadConfig()
+			if err != nil {
+				t.Fatalf("LoadConfig failed: %v\n%s", err, data)
+			}
+			if !reflect.DeepEqual(loaded, tt.config) {
+				t.Errorf("Expected %+v, got %+v\n%s", tt.config, loaded, data)
+			}
+			if problems := ValidateFile(path); len(problems) != 0 {
+				t.Errorf("Expected a valid file, got %v", problems)
+			}
+			if warnings.Len() != 0 {
+				t.Errorf("Expected no warnings, got %s", warnings)
+			}
+		})
+	}
+}
+
+func TestSaveDefaultConfig_Template(t *testing.T) {
+	repoRoot := t.TempDir()
+	if err := NewConfigLoader().SaveDefaultConfig(repoRoot); err != nil {
+		t.Fatalf("SaveDefaultConfig failed: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(repoRoot, ".commit-generator-config"))
+	if err != nil {
+		t.Fatalf("Failed to read config: %v", err)
+	}
+	written := string(data)
+	for _, want := range []string{
+		"# generate-commit configuration.",
+		"# Model to generate messages with\nmodel: gpt-oss:120b\n",
+		"timeout_seconds: 60\n",
+		"\n# scope_policy:\n",
+		"\n# auth_scheme:\n",
+	} {
+		if !strings.Contains(written, want) {
+			t.Errorf("Expected %q in the template, got:\n%s", want, written)
+		}
+	}
+}
+
+func TestLoadConfig_YAML(t *testing.T) {
+	content := `# Team settings
+model: llama3
+timeout_seconds: 2m   # read as 120 seconds
+keep_alive: 10m
+trailers:
+  - "Refs: #12"
+scope_map:
+  internal/ai: ai
+system_prompt: |
+  You are terse.
+api_key:
+profiles:
+  fast:
+    model: qwen2.5:3b
+    timeout_seconds: 20s
+`
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	loader := NewConfigLoaderWithPath(path)
+	cfg, err := loader.LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.Model != "llama3" || cfg.TimeoutSeconds != 120 || cfg.KeepAlive != "10m" || cfg.SystemPrompt != "You are terse.\n" {
+		t.Errorf("Unexpected config %+v", cfg)
+	}
+	if !reflect.DeepEqual(cfg.Trailers, []string{"Refs: #12"}) || cfg.ScopeMap["internal/ai"] != "ai" {
+		t.Errorf("Unexpected trailers %v or scope map %v", cfg.Trailers, cfg.ScopeMap)
+	}
+	if cfg.APIKey != "" {
+		t.Errorf("Expected a key without a value to be unset, got %q", cfg.APIKey)
+	}
+
+	loader.SetProfile("fast")
+	cfg, err = loader.LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig with profile failed: %v", err)
+	}
+	if cfg.Model != "qwen2.5:3b" || cfg.TimeoutSeconds != 20 {
+		t.Errorf("Expected the profile's model and timeout, got %+v", cfg)
+	}
+}
+
+func TestLoadConfig_FileErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		expectedErr string
+	}{
+		{name: "Bad enum", content: "model: llama3\nscope_policy: sometimes\n", expectedErr: "config:2: invalid value for scope_policy"},
+		{name: "Bad backend", content: "\n\ngit_backend: libgit2\n", expectedErr: "config:3: invalid value for git_backend"},
+		{name: "Malformed timeout", content: "timeout_seconds: soon\n", expectedErr: "config:1: invalid value for timeout_seconds"},
+		{name: "Malformed keep alive", content: "model: llama3\nkeep_alive: a while\n", expectedErr: "config:2: invalid value for keep_alive"},
+		{name: "Wrong type", content: "co_authors:\n  - jane\n", expectedErr: "config:1: invalid value for co_authors"},
+		{name: "Key set twice", content: "model: a\nmodel: b\n", expectedErr: "config:2: model is already set on line 1"},
+		{name: "Not a mapping", content: "- model\n", expectedErr: "expected the config to be key: value pairs"},
+		{name: "Bad YAML", content: "model: [llama3\n", expectedErr: "failed to parse config file"},
+		{name: "Bad JSON value", content: "{\n  \"model\": \"llama3\",\n  \"body_overflow\": \"wrap\"\n}", expectedErr: "config:3: invalid value for body_overflow"},
+		{name: "Bad JSON", content: "{\n  \"model\": \"llama3\",\n  \"stream\": tru\n}", expectedErr: "config:3: invalid character"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to write config: %v", err)
+			}
+			_, err := NewConfigLoaderWithPath(path).LoadConfig()
+			if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+				t.Fatalf("Expected error containing %q, got %v", tt.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_UnknownKeyWarns(t *testing.T) {
+	warnings := captureWarnings(t)
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte("model: llama3\nmodle: qwen\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := NewConfigLoaderWithPath(path).LoadConfig()
+	if err != nil {
+		t.Fatalf("Expected unknown keys not to stop loading, got %v", err)
+	}
+	if cfg.Model != "llama3" {
+		t.Errorf("Expected model llama3, got %q", cfg.Model)
+	}
+	if want := `config:2: unknown config key "modle"`; !strings.Contains(warnings.String(), want) {
+		t.Errorf("Expected a warning containing %q, got %q", want, warnings)
+	}
+}
+
+func TestSetValue_YAML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	content := "# Team settings\nmodel: llama3 # the shared model\n\n# Slow network\ntimeout_seconds: 30\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	if err := SetValue(path, "model", "qwen2.5:3b"); err != nil {
+		t.Fatalf("SetValue failed: %v", err)
+	}
+	if err := SetValue(path, "trailers", "Refs: #12"); err != nil {
+		t.Fatalf("SetValue failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read config: %v", err)
+	}
+	written := string(data)
+	for _, want := range []string{"# Team settings\n", "model: qwen2.5:3b # the shared model\n", "# Slow network\ntimeout_seconds: 30\n", "trailers:\n  - 'Refs: #12'\n"} {
+		if !strings.Contains(written, want) {
+			t.Errorf("Expected %q in config, got:\n%s", want, written)
+		}
+	}
+	if problems := ValidateFile(path); len(problems) != 0 {
+		t.Errorf("Expected a valid file, got %v", problems)
+	}
+}
+
+func TestSetValue_NewFileIsYAML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	if err := SetValue(path, "timeout_seconds", "2m"); err != nil {
+		t.Fatalf("SetValue failed: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read config: %v", err)
+	}
+	if string(data) != "timeout_seconds: 120\n" {
+		t.Errorf("Expected a YAML file, got:\n%s", data)
+	}
+}
+
+func TestValidateFile_Lines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	content := "model: llama3\n\nsystem_prompt_mode: append\nmodle: qwen\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	problems := ValidateFile(path)
+	expected := []string{`config:4: unknown config key "modle"`, "config:3: invalid value for system_prompt_mode"}
+	if len(problems) != len(expected) {
+		t.Fatalf("Expected %d problems, got %v", len(expected), problems)
+	}
+	for i, want := range expected {
+		if !strings.Contains(problems[i].Error(), want) {
+			t.Errorf("Problem %d: expected %q, got %q", i, want, problems[i])
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// baseCandidates are tried in order by DetectBaseBranch
+var baseCandidates = []string{"main", "master", "origin/HEAD"}
+
+// DetectBaseBranch returns the branch the current branch was created from
+// when its upstream is another branch (git switch -c feature origin/main),
+// and otherwise the first of main, master and origin/HEAD that exists.
+// origin/HEAD is reported as the branch it points at, e.g. origin/develop.
+func (c *ClientImpl) DetectBaseBranch() (string, error) {
+	repo, err := c.openRepo()
+	if err != nil {
+		return "", fmt.Errorf("failed to open repository: %w", err)
+	}
+	if unborn, err := c.headUnborn(repo); err != nil {
+		return "", err
+	} else if unborn {
+		return "", fmt.Errorf("could not detect the base branch: %w", errUnbornHead)
+	}
+
+	if upstream := upstreamBase(repo); upstream != "" {
+		return upstream, nil
+	}
+	for _, candidate := range baseCandidates {
+		name := plumbing.NewBranchReferenceName(candidate)
+		if strings.HasPrefix(candidate, "origin/") {
+			name = plumbing.NewRemoteReferenceName("origin", strings.TrimPrefix(candidate, "origin/"))
+		}
+		ref, err := repo.Reference(name, false)
+		if err != nil {
+			continue
+		}
+		if ref.Type() == plumbing.SymbolicReference {
+			return ref.Target().Short(), nil
+		}
+		return candidate, nil
+	}
+	return "", fmt.Errorf("could not detect the base branch (tried %s); pass one explicitly", strings.Join(baseCandidates, ", "))
+}
+
+// upstreamBase returns the upstream of the current branch, e.g. origin/main,
+// unless it is the branch's own remote counterpart, which is not a base
+func upstreamBase(repo *git.Repository) string {
+	head, err := repo.Head()
+	if err != nil || !head.Name().IsBranch() {
+		return ""
+	}
+	cfg, err := repo.Config()
+	if err != nil {
+		return ""
+	}
+	branch, ok := cfg.Branches[head.Name().Short()]
+	if !ok || branch.Merge == "" || branch.Merge.Short() == head.Name().Short() {
+		return ""
+	}
+	if branch.Remote == "" || branch.Remote == "." {
+		return branch.Merge.Short()
+	}
+	return branch.Remote + "/" + branch.Merge.Short()
+}
+
+// GetBranchDiff returns the diff of everything committed on the current
+// branch since it diverged from base: the merge-base of base and HEAD
+// against HEAD. An empty base is detected with DetectBaseBranch. Staged and
+// unstaged changes are not included, so an unborn HEAD has an empty diff.
+func (c *ClientImpl) GetBranchDiff(base string) (string, error) {
+	repo, err := c.openRepo()
+	if err != nil {
+		return "", fmt.Errorf("failed to open repository: %w", err)
+	}
+	if unborn, err := c.headUnborn(repo); err != nil || unborn {
+		return "", err
+	}
+	if base == "" {
+		if base, err = c.DetectBaseBranch(); err != nil {
+			return "", err
+		}
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return "", fmt.Errorf("failed to get HEAD: %w", err)
+	}
+	headCommit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return "", fmt.Errorf("failed to get HEAD commit: %w", err)
+	}
+
+	baseHash, err := repo.ResolveRevision(plumbing.Revision(base))
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve base %q: %w", base, err)
+	}
+	baseCommit, err := repo.CommitObject(*baseHash)
+	if err != nil {
+		return "", fmt.Errorf("failed to get commit for %q: %w", base, err)
+	}
+
+	mergeBases, err := headCommit.MergeBase(baseCommit)
+	if err != nil {
+		return "", fmt.Errorf("failed to find the merge-base of %s and HEAD: %w", base, err)
+	}
+	if len(mergeBases) == 0 {
+		return "", fmt.Errorf("%s and HEAD have no common history", base)
+	}
+
+	fromTree, err := mergeBases[0].Tree()
+	if err != nil {
+		return "", fmt.Errorf("failed to get merge-base tree: %w", err)
+	}
+	toTree, err := headCommit.Tree()
+	if err != nil {
+		return "", fmt.Errorf("failed to get HEAD tree: %w", err)
+	}
+
+	changes, err := object.DiffTreeWithOptions(context.Background(), fromTree, toTree, object.DefaultDiffTreeOptions)
+	if err != nil {
+		return "", fmt.Errorf("failed to diff %s..HEAD: %w", base, err)
+	}
+
+	var diffBuilder strings.Builder
+	for _, change := range changes {
+		if err := c.writeTreeChange(&diffBuilder, change); err != nil {
+			return "", err
+		}
+	}
+	return truncateDiff(diffBuilder.String()), nil
+}
+
+// writeTreeChange renders one file of a tree diff in the same format as the
+// staged diff
+func (c *ClientImpl) writeTreeChange(sb *strings.Builder, change *object.Change) error {
+	if !c.options.includes(changePath(change)) {
+		return nil
+	}
+	from, to, err := change.Files()
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", changePath(change), err)
+	}
+	fromPath, toPath := change.From.Name, change.To.Name
+
+	switch {
+	case from == nil:
+		fmt.Fprintf(sb, "diff --git a/%s b/%s\nnew file mode 100644\n--- /dev/null\n+++ b/%s\n", toPath, toPath, toPath)
+	case to == nil:
+		fmt.Fprintf(sb, "diff --git a/%s b/%s\ndeleted file mode 100644\n--- a/%s\n+++ /dev/null\n", fromPath, fromPath, fromPath)
+	case fromPath != toPath:
+		fmt.Fprintf(sb, "diff --git a/%s b/%s\nrename from %s\nrename to %s\n", fromPath, toPath, fromPath, toPath)
+		if from.Hash == to.Hash {
+			return nil
+		}
+		fmt.Fprintf(sb, "--- a/%s\n+++ b/%s\n", fromPath, toPath)
+	default:
+		fmt.Fprintf(sb, "diff --git a/%s b/%s\n--- a/%s\n+++ b/%s\n", fromPath, toPath, fromPath, toPath)
+	}
+
+	oldContent, binary, err := fileContents(from)
+	if err != nil {
+		return err
+	}
+	newContent, newBinary, err := fileContents(to)
+	if err != nil {
+		return err
+	}
+	if binary || newBinary {
+		sb.WriteString("Binary files differ\n")
+		return nil
+	}
+	sb.WriteString(unifiedHunks(oldContent, newContent, c.options.ContextLines))
+	return nil
+}
+
+// changePath is the path a change is reported under: the new path, or the
+// old one for deletions
+func changePath(change *object.Change) string {
+	if change.To.Name != "" {
+		return change.To.Name
+	}
+	return change.From.Name
+}
+
+// fileContents returns the text of f, or "" for a missing file
+func fileContents(f *object.File) (string, bool, error) {
+	if f == nil {
+		return "", false, nil
+	}
+	binary, err := f.IsBinary()
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read %s: %w", f.Name, err)
+	}
+	if binary {
+		return "", true, nil
+	}
+	content, err := f.Contents()
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read %s: %w", f.Name, err)
+	}
+	return content, false, nil
+}
@@ -0,0 +1,225 @@
+package git
+
+import (
+	"strings"
+	"testing"
+
+	git "github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// checkoutNewBranch creates name at HEAD and switches to it
+func checkoutNewBranch(t *testing.T, repo *git.Repository, name string) {
+	t.Helper()
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("failed to get worktree: %v", err)
+	}
+	if err := worktree.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName(name), Create: true, Keep: true}); err != nil {
+		t.Fatalf("failed to check out %s: %v", name, err)
+	}
+}
+
+func TestClientImpl_GetBranchDiff(t *testing.T) {
+	repo, client := newIndexTestRepo(t, map[string]string{"a.txt": "one\n", "old.txt": "legacy\nconfig\nloader\n"})
+	head, _ := repo.Head()
+	base := head.Name().Short()
+
+	checkoutNewBranch(t, repo, "feature")
+	stageFiles(t, repo, map[string]string{"a.txt": "two\n"})
+	if err := client.CommitWithMessage("change a"); err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+	stageFiles(t, repo, map[string]string{"new.txt": "hello\nworld\n"})
+	worktree, _ := repo.Worktree()
+	if _, err := worktree.Remove("old.txt"); err != nil {
+		t.Fatalf("failed to remove old.txt: %v", err)
+	}
+	if err := client.CommitWithMessage("add new, drop old"); err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+
+	diff, err := client.GetBranchDiff(base)
+	if err != nil {
+		t.Fatalf("GetBranchDiff failed: %v", err)
+	}
+	for _, want := range []string{
+		"diff --git a/a.txt b/a.txt", "-one", "+two",
+		"new file mode 100644\n--- /dev/null\n+++ b/new.txt", "+hello",
+		"deleted file mode 100644\n--- a/old.txt", "-legacy",
+	} {
+		if !strings.Contains(diff, want) {
+			t.Errorf("expected %q in branch diff, got:\n%s", want, diff)
+		}
+	}
+
+	// Staged changes are not part of the branch diff
+	stageFiles(t, repo, map[string]string{"staged.txt": "not committed\n"})
+	diff, err = client.GetBranchDiff(base)
+	if err != nil {
+		t.Fatalf("GetBranchDiff failed: %v", err)
+	}
+	if strings.Contains(diff, "staged.txt") {
+		t.Errorf("expected staged files to be left out, got:\n%s", diff)
+	}
+
+	if _, err := client.GetBranchDiff("no-such-branch"); err == nil || !strings.Contains(err.Error(), "failed to resolve base") {
+		t.Errorf("expected an unknown base to fail, got %v", err)
+	}
+}
+
+func TestClientImpl_GetBranchDiff_Diverged(t *testing.T) {
+	repo, client := newIndexTestRepo(t, map[string]string{"shared.txt": "base\n", "lib.txt": "v1\n"})
+	checkoutNewBranch(t, repo, "main")
+
+	checkoutNewBranch(t, repo, "feature")
+	stageFiles(t, repo, map[string]string{"shared.txt": "feature\n", "feature.txt": "new feature\n"})
+	if err := client.CommitWithMessage("feature work"); err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+
+	// main moves on after the branch point
+	worktree, _ := repo.Worktree()
+	if err := worktree.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("main")}); err != nil {
+		t.Fatalf("failed to check out main: %v", err)
+	}
+	stageFiles(t, repo, map[string]string{"lib.txt": "v2\n"})
+	if err := client.CommitWithMessage("main work"); err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+	if err := worktree.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("feature")}); err != nil {
+		t.Fatalf("failed to check out feature: %v", err)
+	}
+
+	// An empty base is detected as main
+	diff, err := client.GetBranchDiff("")
+	if err != nil {
+		t.Fatalf("GetBranchDiff failed: %v", err)
+	}
+	for _, want := range []string{"+++ b/feature.txt", "-base", "+feature"} {
+		if !strings.Contains(diff, want) {
+			t.Errorf("expected %q in branch diff, got:\n%s", want, diff)
+		}
+	}
+	// Changes made on main after the branch point are not the feature's
+	if strings.Contains(diff, "lib.txt") {
+		t.Errorf("expected main's own changes to be left out, got:\n%s", diff)
+	}
+}
+
+func TestClientImpl_DetectBaseBranch(t *testing.T) {
+	tests := []struct {
+		name          string
+		setup         func(t *testing.T, repo *git.Repository)
+		expected      string
+		expectedError string
+	}{
+		{
+			name:     "Only master",
+			setup:    func(t *testing.T, repo *git.Repository) {},
+			expected: "master",
+		},
+		{
+			name: "main is preferred over master",
+			setup: func(t *testing.T, repo *git.Repository) {
+				setRef(t, repo, plumbing.NewBranchReferenceName("main"))
+			},
+			expected: "main",
+		},
+		{
+			name: "origin/HEAD names the remote default branch",
+			setup: func(t *testing.T, repo *git.Repository) {
+				moveToBranch(t, repo, "trunk")
+				setRef(t, repo, plumbing.NewRemoteReferenceName("origin", "trunk"))
+				if err := repo.Storer.SetReference(plumbing.NewSymbolicReference(
+					plumbing.NewRemoteHEADReferenceName("origin"), plumbing.NewRemoteReferenceName("origin", "trunk"))); err != nil {
+					t.Fatalf("failed to set origin/HEAD: %v", err)
+				}
+			},
+			expected: "origin/trunk",
+		},
+		{
+			name: "Upstream names the base",
+			setup: func(t *testing.T, repo *git.Repository) {
+				setRef(t, repo, plumbing.NewRemoteReferenceName("origin", "develop"))
+				checkoutNewBranch(t, repo, "feature")
+				setUpstream(t, repo, "feature", "origin", "develop")
+			},
+			expected: "origin/develop",
+		},
+		{
+			name: "Upstream of the same name is not a base",
+			setup: func(t *testing.T, repo *git.Repository) {
+				checkoutNewBranch(t, repo, "feature")
+				setUpstream(t, repo, "feature", "origin", "feature")
+			},
+			expected: "master",
+		},
+		{
+			name: "Nothing to detect",
+			setup: func(t *testing.T, repo *git.Repository) {
+				moveToBranch(t, repo, "trunk")
+			},
+			expectedError: "could not detect the base branch",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, client := newIndexTestRepo(t, map[string]string{"a.txt": "a\n"})
+			tt.setup(t, repo)
+
+			base, err := client.DetectBaseBranch()
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("expected error containing %q, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DetectBaseBranch failed: %v", err)
+			}
+			if base != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, base)
+			}
+		})
+	}
+}
+
+// setRef points name at HEAD's commit
+func setRef(t *testing.T, repo *git.Repository, name plumbing.ReferenceName) {
+	t.Helper()
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatalf("failed to resolve HEAD: %v", err)
+	}
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(name, head.Hash())); err != nil {
+		t.Fatalf("failed to set %s: %v", name, err)
+	}
+}
+
+// setUpstream makes branch track merge on remote
+func setUpstream(t *testing.T, repo *git.Repository, branch, remote, merge string) {
+	t.Helper()
+	cfg, err := repo.Config()
+	if err != nil {
+		t.Fatalf("failed to read config: %v", err)
+	}
+	cfg.Branches[branch] = &gitconfig.Branch{Name: branch, Remote: remote, Merge: plumbing.NewBranchReferenceName(merge)}
+	if err := repo.SetConfig(cfg); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+}
+
+// moveToBranch renames the current branch (master) to name
+func moveToBranch(t *testing.T, repo *git.Repository, name string) {
+	t.Helper()
+	setRef(t, repo, plumbing.NewBranchReferenceName(name))
+	if err := repo.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, plumbing.NewBranchReferenceName(name))); err != nil {
+		t.Fatalf("failed to move HEAD: %v", err)
+	}
+	if err := repo.Storer.RemoveReference(plumbing.NewBranchReferenceName("master")); err != nil {
+		t.Fatalf("failed to remove master: %v", err)
+	}
+}
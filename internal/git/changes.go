@@ -0,0 +1,375 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// binaryCheckBytes is how much of a file is searched for a NUL byte to
+// tell binary files apart, as git does
+const binaryCheckBytes = 8000
+
+// Hunk is one "@@" section of a file's diff
+type Hunk struct {
+	// OldStart and NewStart are the first lines of the hunk on each side,
+	// as written in its header
+	OldStart int
+	OldLines int
+	NewStart int
+	NewLines int
+	// Lines are the lines of the hunk, each prefixed with ' ' for context,
+	// '-' for a removed line or '+' for an added line
+	Lines []string
+}
+
+// Header returns the "@@ -a,b +c,d @@" line of the hunk
+func (h Hunk) Header() string {
+	return fmt.Sprintf("@@ -%s +%s @@", hunkRange(h.OldStart, h.OldLines), hunkRange(h.NewStart, h.NewLines))
+}
+
+// writeTo writes the hunk with its header
+func (h Hunk) writeTo(sb *strings.Builder) {
+	sb.WriteString(h.Header())
+	sb.WriteByte('\n')
+	for _, line := range h.Lines {
+		sb.WriteString(line)
+		sb.WriteByte('\n')
+	}
+}
+
+// FileChange is the change of a single path between HEAD and the index
+type FileChange struct {
+	// Path is the path relative to the repository root
+	Path string
+	// OldPath is the path before a rename, and empty otherwise
+	OldPath string
+	// Change is the kind of change
+	Change ChangeType
+	// OldMode and NewMode are the file modes on each side. A side the file
+	// does not exist on has filemode.Empty.
+	OldMode filemode.FileMode
+	NewMode filemode.FileMode
+	// Hunks are the changed lines; binary files have none
+	Hunks      []Hunk
+	Insertions int
+	Deletions  int
+	IsBinary   bool
+	// Omitted is set for a file larger than DiffOptions.MaxFileBytes,
+	// whose content was not read; Size is its larger side in bytes
+	Omitted bool
+	Size    int64
+}
+
+// String renders the change as one file of a unified diff, the way git
+// diff writes it
+func (f FileChange) String() string {
+	var sb strings.Builder
+	f.writeTo(&sb)
+	return sb.String()
+}
+
+func (f FileChange) writeTo(sb *strings.Builder) {
+	oldPath := f.Path
+	if f.OldPath != "" {
+		oldPath = f.OldPath
+	}
+	fmt.Fprintf(sb, "diff --git a/%s b/%s\n", oldPath, f.Path)
+
+	oldName, newName := "a/"+oldPath, "b/"+f.Path
+	switch {
+	case f.Change == ChangeAdded:
+		fmt.Fprintf(sb, "new file mode %o\n", uint32(f.NewMode))
+		oldName = "/dev/null"
+	case f.Change == ChangeDeleted:
+		fmt.Fprintf(sb, "deleted file mode %o\n", uint32(f.OldMode))
+		newName = "/dev/null"
+	case f.OldMode != f.NewMode && f.OldMode != filemode.Empty && f.NewMode != filemode.Empty:
+		fmt.Fprintf(sb, "old mode %o\nnew mode %o\n", uint32(f.OldMode), uint32(f.NewMode))
+	}
+	if f.OldPath != "" {
+		fmt.Fprintf(sb, "rename from %s\nrename to %s\n", f.OldPath, f.Path)
+	}
+
+	if f.Omitted {
+		fmt.Fprintf(sb, "Large file %s (%d bytes) changed, content omitted\n", f.Path, f.Size)
+		return
+	}
+	if f.IsBinary {
+		fmt.Fprintf(sb, "Binary files %s and %s differ\n", oldName, newName)
+		return
+	}
+	if len(f.Hunks) == 0 {
+		return
+	}
+	fmt.Fprintf(sb, "--- %s\n+++ %s\n", oldName, newName)
+	for _, hunk := range f.Hunks {
+		hunk.writeTo(sb)
+	}
+}
+
+// RenderChanges joins changes into a unified diff. It is not truncated.
+func RenderChanges(changes []FileChange) string {
+	var sb strings.Builder
+	for _, change := range changes {
+		change.writeTo(&sb)
+	}
+	return sb.String()
+}
+
+// GetStagedChanges returns the staged changes of the paths the path
+// filters include, one per file and sorted by path. GetStagedDiff is these
+// changes rendered as text.
+func (c *ClientImpl) GetStagedChanges() ([]FileChange, error) {
+	if b := c.backend(); b != nil {
+		return b.GetStagedChanges()
+	}
+	return c.fileChanges(stagedChange)
+}
+
+// fileChanges builds the change of every path changeOf reports as changed
+// and the path filters include, comparing HEAD with the working tree
+func (c *ClientImpl) fileChanges(changeOf func(*git.FileStatus) git.StatusCode) ([]FileChange, error) {
+	repo, err := c.openRepo()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get worktree: %w", err)
+	}
+
+	status, err := c.status(repo, worktree)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status: %w", err)
+	}
+
+	// Cache working directory
+	wd, _ := c.workDir()
+
+	// Get HEAD commit for comparison
+	head, err := repo.Head()
+	if err != nil && err != plumbing.ErrReferenceNotFound {
+		return nil, fmt.Errorf("failed to get HEAD: %w", err)
+	}
+
+	var headTree *object.Tree
+	if err == nil {
+		headCommit, err := repo.CommitObject(head.Hash())
+		if err == nil {
+			headTree, err = headCommit.Tree()
+			if err != nil {
+				return nil, fmt.Errorf("failed to get HEAD tree: %w", err)
+			}
+		}
+	}
+
+	var changes []FileChange
+	for filePath, fileStatus := range status {
+		change := changeOf(fileStatus)
+		if change == git.Unmodified || !c.options.includes(filePath) {
+			continue
+		}
+
+		fileChange := FileChange{Path: filePath, Change: ChangeType(string(rune(change)))}
+		var oldContent, newContent []byte
+		var oldSize, newSize int64
+		if change == git.Deleted || change == git.Modified {
+			oldContent, fileChange.OldMode, oldSize = readHeadFile(repo, headTree, filePath, c.options.MaxFileBytes)
+		}
+		if change == git.Added || change == git.Modified {
+			newContent, fileChange.NewMode, newSize = readWorktreeFile(filepath.Join(wd, filePath), c.options.MaxFileBytes)
+		}
+		if change == git.Renamed {
+			// go-git reports the old path of a rename in Extra
+			fileChange.OldPath = fileStatus.Extra
+		}
+
+		if size := max(oldSize, newSize); c.options.tooLarge(size) {
+			fileChange.Omitted, fileChange.Size = true, size
+			changes = append(changes, fileChange)
+			continue
+		}
+		fileChange.IsBinary = isBinary(oldContent) || isBinary(newContent)
+		if !fileChange.IsBinary && change != git.Renamed {
+			fileChange.setHunks(diffHunks(string(oldContent), string(newContent), c.options.ContextLines))
+		}
+		changes = append(changes, fileChange)
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes, nil
+}
+
+// setHunks sets the hunks of f and counts their changed lines
+func (f *FileChange) setHunks(hunks []Hunk) {
+	f.Hunks = hunks
+	f.Insertions, f.Deletions = 0, 0
+	for _, hunk := range hunks {
+		for _, line := range hunk.Lines {
+			switch {
+			case strings.HasPrefix(line, "+"):
+				f.Insertions++
+			case strings.HasPrefix(line, "-"):
+				f.Deletions++
+			}
+		}
+	}
+}
+
+// readHeadFile returns the content, mode and size of path in the HEAD tree,
+// or nothing when it is not there. Content larger than limit, when limit is
+// positive, is not read.
+func readHeadFile(repo *git.Repository, headTree *object.Tree, path string, limit int64) ([]byte, filemode.FileMode, int64) {
+	if headTree == nil {
+		return nil, filemode.Empty, 0
+	}
+	entry, err := headTree.FindEntry(path)
+	if err != nil {
+		return nil, filemode.Empty, 0
+	}
+	blob, err := repo.BlobObject(entry.Hash)
+	if err != nil {
+		return nil, entry.Mode, 0
+	}
+	if limit > 0 && blob.Size > limit {
+		return nil, entry.Mode, blob.Size
+	}
+	reader, err := blob.Reader()
+	if err != nil {
+		return nil, entry.Mode, blob.Size
+	}
+	defer reader.Close()
+	content, _ := io.ReadAll(reader)
+	return content, entry.Mode, blob.Size
+}
+
+// readWorktreeFile returns the content, mode and size of a file in the
+// working tree, or nothing when it cannot be read. Content larger than
+// limit, when limit is positive, is not read.
+func readWorktreeFile(fullPath string, limit int64) ([]byte, filemode.FileMode, int64) {
+	info, err := os.Lstat(fullPath)
+	if err != nil {
+		return nil, filemode.Empty, 0
+	}
+	mode, err := filemode.NewFromOSFileMode(info.Mode())
+	if err != nil {
+		mode = filemode.Regular
+	}
+	if limit > 0 && info.Size() > limit {
+		return nil, mode, info.Size()
+	}
+	content, err := os.ReadFile(fullPath)
+	if err != nil {
+		return nil, mode, info.Size()
+	}
+	return content, mode, info.Size()
+}
+
+// isBinary reports whether content has a NUL byte near its start
+func isBinary(content []byte) bool {
+	return bytes.IndexByte(content[:min(len(content), binaryCheckBytes)], 0) >= 0
+}
+
+// ParseChanges reads a unified diff, e.g. from "git diff", into one change
+// per file. Text before the first file is ignored.
+func ParseChanges(diff string) []FileChange {
+	_, sections := splitDiff(diff)
+	changes := make([]FileChange, 0, len(sections))
+	for _, section := range sections {
+		change := FileChange{Path: section.file.Path, Change: section.file.Change}
+		var hunks []Hunk
+		var current *Hunk
+		inHeader := true
+		for _, line := range strings.Split(strings.TrimSuffix(section.text, "\n"), "\n") {
+			line = strings.TrimSuffix(line, "\r")
+			if strings.HasPrefix(line, "@@") {
+				inHeader = false
+				hunks = append(hunks, parseHunkHeader(line))
+				current = &hunks[len(hunks)-1]
+				continue
+			}
+			if inHeader {
+				change.applyHeaderLine(line)
+				continue
+			}
+			if current != nil && line != "" && strings.ContainsRune(" +-", rune(line[0])) {
+				current.Lines = append(current.Lines, line)
+			}
+		}
+		change.setHunks(hunks)
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// applyHeaderLine reads the modes, rename source and binary note from one
+// line of a file header. The path and change type come from splitDiff.
+func (f *FileChange) applyHeaderLine(line string) {
+	switch {
+	case strings.HasPrefix(line, "new file mode "):
+		f.NewMode = parseFileMode(strings.TrimPrefix(line, "new file mode "))
+	case strings.HasPrefix(line, "deleted file mode "):
+		f.OldMode = parseFileMode(strings.TrimPrefix(line, "deleted file mode "))
+	case strings.HasPrefix(line, "old mode "):
+		f.OldMode = parseFileMode(strings.TrimPrefix(line, "old mode "))
+	case strings.HasPrefix(line, "new mode "):
+		f.NewMode = parseFileMode(strings.TrimPrefix(line, "new mode "))
+	case strings.HasPrefix(line, "index "):
+		// "index abc..def 100644" carries the mode when it did not change
+		if fields := strings.Fields(line); len(fields) == 3 {
+			f.OldMode = parseFileMode(fields[2])
+			f.NewMode = f.OldMode
+		}
+	case strings.HasPrefix(line, "rename from "):
+		f.OldPath = strings.TrimPrefix(line, "rename from ")
+	case strings.HasPrefix(line, "Binary files "), strings.HasPrefix(line, "GIT binary patch"):
+		f.IsBinary = true
+	}
+}
+
+// parseFileMode reads an octal mode such as 100644, or returns
+// filemode.Empty
+func parseFileMode(s string) filemode.FileMode {
+	mode, err := filemode.New(strings.TrimSpace(s))
+	if err != nil {
+		return filemode.Empty
+	}
+	return mode
+}
+
+// parseHunkHeader reads the ranges of a "@@ -a,b +c,d @@" line. A range
+// without a count has one line.
+func parseHunkHeader(line string) Hunk {
+	var hunk Hunk
+	// Text after the closing @@, such as the enclosing function, is skipped
+	fields := strings.Fields(line)
+	if len(fields) >= 3 {
+		hunk.OldStart, hunk.OldLines = parseHunkRange(strings.TrimPrefix(fields[1], "-"))
+		hunk.NewStart, hunk.NewLines = parseHunkRange(strings.TrimPrefix(fields[2], "+"))
+	}
+	return hunk
+}
+
+func parseHunkRange(s string) (int, int) {
+	start, count, found := strings.Cut(s, ",")
+	first, _ := strconv.Atoi(start)
+	if !found {
+		return first, 1
+	}
+	n, _ := strconv.Atoi(count)
+	return first, n
+}
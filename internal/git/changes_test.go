@@ -0,0 +1,213 @@
+package git
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+)
+
+// stageChangeSet commits a small tree and stages one change of every kind
+func stageChangeSet(t *testing.T) Client {
+	t.Helper()
+	repo, client := newIndexTestRepo(t, map[string]string{
+		"main.go": "package main\n\nfunc main() {}\n",
+		"old.txt": "bye\n",
+	})
+	stageFiles(t, repo, map[string]string{
+		"main.go":  "package main\n\nimport \"fmt\"\n\nfunc main() { fmt.Println() }\n",
+		"new.txt":  "a\nb\n",
+		"logo.png": "\x89PNG\x00\x01",
+	})
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("failed to get worktree: %v", err)
+	}
+	if _, err := worktree.Remove("old.txt"); err != nil {
+		t.Fatalf("failed to remove old.txt: %v", err)
+	}
+	return client
+}
+
+func TestClientImpl_GetStagedChanges(t *testing.T) {
+	client := stageChangeSet(t)
+
+	changes, err := client.GetStagedChanges()
+	if err != nil {
+		t.Fatalf("GetStagedChanges failed: %v", err)
+	}
+
+	expected := []FileChange{
+		{Path: "logo.png", Change: ChangeAdded, NewMode: filemode.Regular, IsBinary: true},
+		{
+			Path: "main.go", Change: ChangeModified, OldMode: filemode.Regular, NewMode: filemode.Regular,
+			Hunks: []Hunk{{OldStart: 1, OldLines: 3, NewStart: 1, NewLines: 5, Lines: []string{
+				" package main", " ", "-func main() {}", "+import \"fmt\"", "+", "+func main() { fmt.Println() }",
+			}}},
+			Insertions: 3, Deletions: 1,
+		},
+		{
+			Path: "new.txt", Change: ChangeAdded, NewMode: filemode.Regular,
+			Hunks:      []Hunk{{OldStart: 0, OldLines: 0, NewStart: 1, NewLines: 2, Lines: []string{"+a", "+b"}}},
+			Insertions: 2,
+		},
+		{
+			Path: "old.txt", Change: ChangeDeleted, OldMode: filemode.Regular,
+			Hunks:     []Hunk{{OldStart: 1, OldLines: 1, NewStart: 0, NewLines: 0, Lines: []string{"-bye"}}},
+			Deletions: 1,
+		},
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("expected changes:\n%+v\ngot:\n%+v", expected, changes)
+	}
+
+	diff, err := client.GetStagedDiff()
+	if err != nil {
+		t.Fatalf("GetStagedDiff failed: %v", err)
+	}
+	if diff != RenderChanges(changes) {
+		t.Errorf("expected the staged diff to be the rendered changes, got:\n%s", diff)
+	}
+	for _, want := range []string{
+		"Binary files /dev/null and b/logo.png differ\n",
+		"new file mode 100644\n--- /dev/null\n+++ b/new.txt\n@@ -0,0 +1,2 @@\n+a\n+b\n",
+		"deleted file mode 100644\n--- a/old.txt\n+++ /dev/null\n@@ -1 +0,0 @@\n-bye\n",
+	} {
+		if !strings.Contains(diff, want) {
+			t.Errorf("expected %q in diff:\n%s", want, diff)
+		}
+	}
+}
+
+func TestClientImpl_GetStagedChanges_ExecBackend(t *testing.T) {
+	requireGit(t)
+	stageChangeSet(t)
+
+	opts := DiffOptions{ContextLines: DefaultContextLines}
+	changes, err := NewClientWithBackend(opts, BackendExec).GetStagedChanges()
+	if err != nil {
+		t.Fatalf("GetStagedChanges failed: %v", err)
+	}
+	expected, err := NewClientWithBackend(opts, BackendGoGit).GetStagedChanges()
+	if err != nil {
+		t.Fatalf("GetStagedChanges failed: %v", err)
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("expected the same changes as go-git:\n%+v\ngot:\n%+v", expected, changes)
+	}
+}
+
+func TestParseChanges(t *testing.T) {
+	tests := []struct {
+		name     string
+		diff     string
+		expected []FileChange
+	}{
+		{
+			name: "Modified with two hunks",
+			diff: "diff --git a/app.go b/app.go\nindex 1a2b3c4..5d6e7f8 100644\n--- a/app.go\n+++ b/app.go\n" +
+				"@@ -1,2 +1,2 @@ package app\n-a\n+b\n c\n@@ -10 +10,2 @@\n x\n+y\n",
+			expected: []FileChange{{
+				Path: "app.go", Change: ChangeModified, OldMode: filemode.Regular, NewMode: filemode.Regular,
+				Hunks: []Hunk{
+					{OldStart: 1, OldLines: 2, NewStart: 1, NewLines: 2, Lines: []string{"-a", "+b", " c"}},
+					{OldStart: 10, OldLines: 1, NewStart: 10, NewLines: 2, Lines: []string{" x", "+y"}},
+				},
+				Insertions: 2, Deletions: 1,
+			}},
+		},
+		{
+			name: "Mode change and new executable",
+			diff: "diff --git a/run.sh b/run.sh\nold mode 100644\nnew mode 100755\n" +
+				"diff --git a/build.sh b/build.sh\nnew file mode 100755\nindex 0000000..1a2b3c4\n--- /dev/null\n+++ b/build.sh\n@@ -0,0 +1 @@\n+make\n",
+			expected: []FileChange{
+				{Path: "run.sh", Change: ChangeModified, OldMode: filemode.Regular, NewMode: filemode.Executable},
+				{
+					Path: "build.sh", Change: ChangeAdded, NewMode: filemode.Executable,
+					Hunks:      []Hunk{{OldStart: 0, OldLines: 0, NewStart: 1, NewLines: 1, Lines: []string{"+make"}}},
+					Insertions: 1,
+				},
+			},
+		},
+		{
+			name: "Rename and binary",
+			diff: "diff --git a/a.txt b/b.txt\nsimilarity index 100%\nrename from a.txt\nrename to b.txt\n" +
+				"diff --git a/logo.png b/logo.png\nindex 1a2b3c4..5d6e7f8 100644\nBinary files a/logo.png and b/logo.png differ\n",
+			expected: []FileChange{
+				{Path: "b.txt", OldPath: "a.txt", Change: ChangeRenamed},
+				{Path: "logo.png", Change: ChangeModified, OldMode: filemode.Regular, NewMode: filemode.Regular, IsBinary: true},
+			},
+		},
+		{
+			name:     "Empty",
+			diff:     "",
+			expected: []FileChange{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseChanges(tt.diff); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("expected:\n%+v\ngot:\n%+v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestClientImpl_GetStagedDiff_LargeFiles(t *testing.T) {
+	requireGit(t)
+	large := strings.Repeat("generated line\n", 1000)
+	repo, _ := newIndexTestRepo(t, map[string]string{
+		"dump.sql": large,
+		"main.go":  "package main\n",
+	})
+	stageFiles(t, repo, map[string]string{
+		"dump.sql":        "-- emptied\n",
+		"main.go":         "package main\n\nfunc main() {}\n",
+		"gen/schema.json": large + "{}\n",
+	})
+
+	for _, backend := range []BackendKind{BackendGoGit, BackendExec} {
+		t.Run(string(backend), func(t *testing.T) {
+			client := NewClientWithBackend(DiffOptions{ContextLines: DefaultContextLines, MaxFileBytes: 4096}, backend)
+			diff, err := client.GetStagedDiff()
+			if err != nil {
+				t.Fatalf("GetStagedDiff failed: %v", err)
+			}
+			for _, want := range []string{
+				"diff --git a/dump.sql b/dump.sql\nLarge file dump.sql (15000 bytes) changed, content omitted\n",
+				"diff --git a/gen/schema.json b/gen/schema.json\nnew file mode 100644\nLarge file gen/schema.json (15003 bytes) changed, content omitted\n",
+				"+func main() {}\n",
+			} {
+				if !strings.Contains(diff, want) {
+					t.Errorf("expected %q in diff:\n%s", want, diff)
+				}
+			}
+			if strings.Contains(diff, "generated line") || strings.Contains(diff, "emptied") {
+				t.Errorf("expected the large files' content to be left out:\n%s", diff)
+			}
+			if strings.Index(diff, "dump.sql") > strings.Index(diff, "gen/schema.json") || strings.Index(diff, "gen/schema.json") > strings.Index(diff, "main.go") {
+				t.Errorf("expected the files in path order:\n%s", diff)
+			}
+
+			changes, err := client.GetStagedChanges()
+			if err != nil {
+				t.Fatalf("GetStagedChanges failed: %v", err)
+			}
+			if len(changes) != 3 || !changes[0].Omitted || !changes[1].Omitted || changes[2].Omitted || changes[2].Insertions != 2 {
+				t.Errorf("expected dump.sql and gen/schema.json to be omitted, got %+v", changes)
+			}
+		})
+	}
+
+	t.Run("No limit", func(t *testing.T) {
+		diff, err := NewClientWithBackend(DiffOptions{ContextLines: DefaultContextLines}, BackendGoGit).GetStagedDiff()
+		if err != nil {
+			t.Fatalf("GetStagedDiff failed: %v", err)
+		}
+		if strings.Contains(diff, "content omitted") || !strings.Contains(diff, "-generated line") {
+			t.Errorf("expected every file to be diffed without a limit:\n%s", diff)
+		}
+	})
+}
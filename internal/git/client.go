@@ -2,16 +2,20 @@ package git
 
 import (
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/go-git/go-billy/v5/osfs"
 	git "github.com/go-git/go-git/v5"
-	"github.com/go-git/go-git/v5/plumbing"
+	gitconfig "github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing/object"
+
+	"ai-commit-message-generator/internal/logging"
 )
 
 // Client defines the interface for git operations
@@ -19,30 +23,159 @@ type Client interface {
 	IsInsideRepo() (bool, error)
 	HasStagedChanges() (bool, error)
 	GetStagedDiff() (string, error)
+	GetStagedChanges() ([]FileChange, error)
+	GetStagedFiles() ([]StagedFile, error)
+	GetUserIdentity() (*Identity, error)
 	CommitWithMessage(message string) error
 	GetRepoRoot() (string, error)
+	GetHooksDir() (string, error)
 	DetectState() (*GitState, error)
+	SnapshotIndex() (*IndexSnapshot, error)
+	StageOnly(snapshot *IndexSnapshot, paths []string) error
+	RestoreIndex(snapshot *IndexSnapshot) error
+	ResetToSnapshot(snapshot *IndexSnapshot) error
+	HasCommits() (bool, error)
+	HeadChangedPaths() ([]string, error)
+	GetBranchDiff(base string) (string, error)
+	DetectBaseBranch() (string, error)
+	GetWorktreeDiff(includeUntracked bool) (string, error)
+	GetWorktreeFiles(includeUntracked bool) ([]StagedFile, error)
+	StageAll(includeUntracked bool) error
+	GetCommitRange(revRange string) ([]LogCommit, error)
+	GetCommitDiff(hash string) (string, error)
+	FindPublished(hashes []string, protected []string) (map[string]string, error)
+	RewordCommits(revRange string, messages map[string]string) (string, string, error)
+	GetCommitTemplate() (string, error)
+	GetCurrentBranch() (string, error)
+	ListTags() ([]string, error)
+	CreateTag(name, message string) error
+	ListBranches() ([]string, error)
+	CreateBranch(name string) error
+	GetFileVersions(path string) (string, string, error)
+	GetGitDir() (string, error)
+	GetNewFiles() (*NewFiles, error)
+	GetCommentChar() (string, error)
+}
+
+// ChangeType is the single-letter status git uses for a staged path
+type ChangeType string
+
+const (
+	// ChangeAdded indicates a new file
+	ChangeAdded ChangeType = "A"
+	// ChangeModified indicates a modified file
+	ChangeModified ChangeType = "M"
+	// ChangeDeleted indicates a deleted file
+	ChangeDeleted ChangeType = "D"
+	// ChangeRenamed indicates a renamed file
+	ChangeRenamed ChangeType = "R"
+	// ChangeCopied indicates a copied file
+	ChangeCopied ChangeType = "C"
+)
+
+// StagedFile describes a single staged path
+type StagedFile struct {
+	// Path is the path relative to the repository root
+	Path string
+	// Change is the kind of change staged for the path
+	Change ChangeType
+	// Excluded is set when the path filters leave the file out of the diff.
+	// It is still committed.
+	Excluded bool
+}
+
+// Identity is the name and email git records on a commit
+type Identity struct {
+	Name  string
+	Email string
+}
+
+// String formats the identity the way git does: "Name <email>"
+func (i Identity) String() string {
+	return fmt.Sprintf("%s <%s>", i.Name, i.Email)
 }
 
 // ClientImpl implements the Client interface using go-git
 type ClientImpl struct {
 	repo     *git.Repository
 	repoPath string
-	mu       sync.Mutex
+	options  DiffOptions
+	kind     BackendKind
+	// commitKind is BackendExec to commit with git commit and its hooks
+	commitKind BackendKind
+	mu         sync.Mutex
+	// logger receives the client's decisions, such as how a commit is
+	// signed
+	logger *slog.Logger
+	// dir is the directory the client works in; empty means the current one
+	dir string
+}
+
+// ClientOption configures optional ClientImpl behavior
+type ClientOption func(*ClientImpl)
+
+// WithLogger logs the client's decisions, such as whether go-git or the
+// git binary signs a commit, at debug level. Nil logs nothing.
+func WithLogger(logger *slog.Logger) ClientOption {
+	return func(c *ClientImpl) {
+		c.logger = logger
+	}
+}
+
+// WithDir makes the client work in dir instead of the current directory,
+// as git -C would
+func WithDir(dir string) ClientOption {
+	return func(c *ClientImpl) {
+		c.dir = dir
+	}
 }
 
 // NewClient creates a new Git client
 func NewClient() Client {
-	return &ClientImpl{}
+	return &ClientImpl{options: DiffOptions{ContextLines: DefaultContextLines}, kind: BackendAuto}
+}
+
+// NewClientWithOptions creates a Git client whose staged diff and file list
+// are restricted by opts. Commits still include everything that is staged.
+func NewClientWithOptions(opts DiffOptions) Client {
+	return NewClientWithBackend(opts, BackendAuto)
+}
+
+// NewClientWithBackend creates a Git client like NewClientWithOptions that
+// reads the repository state with the given backend
+func NewClientWithBackend(opts DiffOptions, kind BackendKind, options ...ClientOption) Client {
+	c := &ClientImpl{options: opts, kind: kind}
+	for _, option := range options {
+		option(c)
+	}
+	return c
 }
 
-// openRepo opens a git repository from the current working directory
-// Uses caching to avoid repeated opens
+// logf logs a decision about the commit, if there is a logger
+func (c *ClientImpl) logf(format string, args ...interface{}) {
+	if c.logger != nil {
+		c.logger.Debug(fmt.Sprintf(format, args...), logging.KeyStage, "commit")
+	}
+}
+
+// workDir returns the directory the client works in: the one WithDir
+// gave, made absolute, or the current working directory
+func (c *ClientImpl) workDir() (string, error) {
+	if c.dir != "" {
+		return filepath.Abs(c.dir)
+	}
+	return os.Getwd()
+}
+
+// openRepo opens a git repository from the client's working directory
+// Uses caching to avoid repeated opens. The common dir of a linked worktree
+// is resolved, so HEAD, branches and objects shared with the main
+// repository are found.
 func (c *ClientImpl) openRepo() (*git.Repository, error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	wd, err := os.Getwd()
+	wd, err := c.workDir()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get working directory: %w", err)
 	}
@@ -53,7 +186,8 @@ func (c *ClientImpl) openRepo() (*git.Repository, error) {
 	}
 
 	repo, err := git.PlainOpenWithOptions(wd, &git.PlainOpenOptions{
-		DetectDotGit: true,
+		DetectDotGit:          true,
+		EnableDotGitCommonDir: true,
 	})
 	if err != nil {
 		return nil, err
@@ -68,6 +202,9 @@ func (c *ClientImpl) openRepo() (*git.Repository, error) {
 
 // IsInsideRepo checks if the current directory is inside a git repository
 func (c *ClientImpl) IsInsideRepo() (bool, error) {
+	if b := c.backend(); b != nil {
+		return b.IsInsideRepo()
+	}
 	_, err := c.openRepo()
 	if err == git.ErrRepositoryNotExists {
 		return false, nil
@@ -80,6 +217,9 @@ func (c *ClientImpl) IsInsideRepo() (bool, error) {
 
 // HasStagedChanges checks if there are staged changes
 func (c *ClientImpl) HasStagedChanges() (bool, error) {
+	if b := c.backend(); b != nil {
+		return b.HasStagedChanges()
+	}
 	repo, err := c.openRepo()
 	if err != nil {
 		return false, fmt.Errorf("failed to open repository: %w", err)
@@ -90,7 +230,7 @@ func (c *ClientImpl) HasStagedChanges() (bool, error) {
 		return false, fmt.Errorf("failed to get worktree: %w", err)
 	}
 
-	status, err := worktree.Status()
+	status, err := c.status(repo, worktree)
 	if err != nil {
 		return false, fmt.Errorf("failed to get status: %w", err)
 	}
@@ -113,195 +253,136 @@ func (c *ClientImpl) HasStagedChanges() (bool, error) {
 	return false, nil
 }
 
-// GetStagedDiff returns the diff of staged changes
+// GetStagedDiff returns the diff of staged changes, rendered from
+// GetStagedChanges
 func (c *ClientImpl) GetStagedDiff() (string, error) {
-	repo, err := c.openRepo()
-	if err != nil {
-		return "", fmt.Errorf("failed to open repository: %w", err)
+	if b := c.backend(); b != nil {
+		return b.GetStagedDiff()
 	}
+	return c.diffChanges(stagedChange)
+}
 
-	worktree, err := repo.Worktree()
-	if err != nil {
-		return "", fmt.Errorf("failed to get worktree: %w", err)
+// stagedChange returns the staged change of a path, or git.Unmodified when
+// nothing is staged for it
+func stagedChange(fileStatus *git.FileStatus) git.StatusCode {
+	if fileStatus.Staging == git.Untracked {
+		return git.Unmodified
 	}
+	return fileStatus.Staging
+}
 
-	status, err := worktree.Status()
+// diffChanges renders the changes of every path changeOf reports as
+// changed, comparing HEAD with the working tree
+func (c *ClientImpl) diffChanges(changeOf func(*git.FileStatus) git.StatusCode) (string, error) {
+	changes, err := c.fileChanges(changeOf)
 	if err != nil {
-		return "", fmt.Errorf("failed to get status: %w", err)
+		return "", err
 	}
+	return truncateDiff(RenderChanges(changes)), nil
+}
 
-	// Pre-allocate builder capacity based on estimated diff size
-	// Estimate: ~100 bytes per file header + ~50 bytes per line
-	estimatedSize := len(status) * 500
-	var diffBuilder strings.Builder
-	diffBuilder.Grow(estimatedSize)
+// maxDiffBytes caps the diff sent to the model
+const maxDiffBytes = 10000
 
-	// Cache working directory
-	wd, _ := os.Getwd()
+// truncateDiff cuts diff to maxDiffBytes and marks that it was cut
+func truncateDiff(diff string) string {
+	if len(diff) > maxDiffBytes {
+		return diff[:maxDiffBytes] + "\n...[TRUNCATED]"
+	}
+	return diff
+}
 
-	// Get HEAD commit for comparison
-	head, err := repo.Head()
-	if err != nil && err != plumbing.ErrReferenceNotFound {
-		return "", fmt.Errorf("failed to get HEAD: %w", err)
+// GetStagedFiles returns the staged paths sorted by name. Paths left out by
+// the path filters are included with Excluded set.
+func (c *ClientImpl) GetStagedFiles() ([]StagedFile, error) {
+	if b := c.backend(); b != nil {
+		return b.GetStagedFiles()
 	}
+	return c.changedFiles(stagedChange)
+}
 
-	var headTree *object.Tree
-	if err == nil {
-		headCommit, err := repo.CommitObject(head.Hash())
-		if err == nil {
-			headTree, err = headCommit.Tree()
-			if err != nil {
-				return "", fmt.Errorf("failed to get HEAD tree: %w", err)
-			}
-		}
+// changedFiles lists the paths changeOf reports as changed, sorted by name
+func (c *ClientImpl) changedFiles(changeOf func(*git.FileStatus) git.StatusCode) ([]StagedFile, error) {
+	repo, err := c.openRepo()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
 	}
 
-	// Process each staged file
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get worktree: %w", err)
+	}
+
+	status, err := c.status(repo, worktree)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status: %w", err)
+	}
+
+	files := make([]StagedFile, 0, len(status))
 	for filePath, fileStatus := range status {
-		// Only process staged changes
-		if fileStatus.Staging == git.Unmodified || fileStatus.Staging == git.Untracked {
+		change := changeOf(fileStatus)
+		if change == git.Unmodified {
 			continue
 		}
+		files = append(files, StagedFile{
+			Path:     filePath,
+			Change:   ChangeType(string(rune(change))),
+			Excluded: !c.options.includes(filePath),
+		})
+	}
 
-		switch fileStatus.Staging {
-		case git.Added:
-			// New file - show all lines as additions
-			diffBuilder.WriteString("diff --git a/")
-			diffBuilder.WriteString(filePath)
-			diffBuilder.WriteString(" b/")
-			diffBuilder.WriteString(filePath)
-			diffBuilder.WriteString("\nnew file mode 100644\nindex 0000000..")
-			diffBuilder.WriteString(fileStatus.Extra)
-			diffBuilder.WriteString("\n--- /dev/null\n+++ b/")
-			diffBuilder.WriteString(filePath)
-			diffBuilder.WriteString("\n")
-
-			// Read file content
-			fullPath := filepath.Join(wd, filePath)
-			content, err := os.ReadFile(fullPath)
-			if err == nil {
-				lines := strings.Split(string(content), "\n")
-				for _, line := range lines {
-					diffBuilder.WriteString("+")
-					diffBuilder.WriteString(line)
-					diffBuilder.WriteString("\n")
-				}
-			}
-
-		case git.Deleted:
-			// Deleted file
-			diffBuilder.WriteString("diff --git a/")
-			diffBuilder.WriteString(filePath)
-			diffBuilder.WriteString(" b/")
-			diffBuilder.WriteString(filePath)
-			diffBuilder.WriteString("\ndeleted file mode 100644\nindex ")
-			diffBuilder.WriteString(fileStatus.Extra)
-			diffBuilder.WriteString("..0000000\n--- a/")
-			diffBuilder.WriteString(filePath)
-			diffBuilder.WriteString("\n+++ /dev/null\n")
-
-			// Try to get content from HEAD
-			if headTree != nil {
-				entry, err := headTree.FindEntry(filePath)
-				if err == nil {
-					blob, err := repo.BlobObject(entry.Hash)
-					if err == nil {
-						reader, err := blob.Reader()
-						if err == nil {
-							content := make([]byte, blob.Size)
-							reader.Read(content)
-							reader.Close()
-							lines := strings.Split(string(content), "\n")
-							for _, line := range lines {
-								diffBuilder.WriteString("-")
-								diffBuilder.WriteString(line)
-								diffBuilder.WriteString("\n")
-							}
-						}
-					}
-				}
-			}
-
-		case git.Modified:
-			// Modified file - get diff between HEAD and staged version
-			diffBuilder.WriteString("diff --git a/")
-			diffBuilder.WriteString(filePath)
-			diffBuilder.WriteString(" b/")
-			diffBuilder.WriteString(filePath)
-			diffBuilder.WriteString("\nindex ")
-			diffBuilder.WriteString(fileStatus.Extra)
-			diffBuilder.WriteString("..")
-			diffBuilder.WriteString(fileStatus.Extra)
-			diffBuilder.WriteString(" 100644\n--- a/")
-			diffBuilder.WriteString(filePath)
-			diffBuilder.WriteString("\n+++ b/")
-			diffBuilder.WriteString(filePath)
-			diffBuilder.WriteString("\n")
-
-			// Get old content from HEAD
-			var oldContent []byte
-			if headTree != nil {
-				entry, err := headTree.FindEntry(filePath)
-				if err == nil {
-					blob, err := repo.BlobObject(entry.Hash)
-					if err == nil {
-						reader, err := blob.Reader()
-						if err == nil {
-							oldContent = make([]byte, blob.Size)
-							reader.Read(oldContent)
-							reader.Close()
-						}
-					}
-				}
-			}
-
-			// Get new content from working directory
-			fullPath := filepath.Join(wd, filePath)
-			newContent, err := os.ReadFile(fullPath)
-			if err != nil {
-				newContent = []byte{}
-			}
-
-			// Simple line-by-line diff
-			oldLines := strings.Split(string(oldContent), "\n")
-			newLines := strings.Split(string(newContent), "\n")
-
-			// For simplicity, show old lines as removed and new lines as added
-			// A more sophisticated diff algorithm could be used here
-			for _, line := range oldLines {
-				diffBuilder.WriteString("-")
-				diffBuilder.WriteString(line)
-				diffBuilder.WriteString("\n")
-			}
-			for _, line := range newLines {
-				diffBuilder.WriteString("+")
-				diffBuilder.WriteString(line)
-				diffBuilder.WriteString("\n")
-			}
-
-		case git.Renamed:
-			// Renamed file
-			diffBuilder.WriteString("diff --git a/")
-			diffBuilder.WriteString(fileStatus.Extra)
-			diffBuilder.WriteString(" b/")
-			diffBuilder.WriteString(filePath)
-			diffBuilder.WriteString("\nrename from ")
-			diffBuilder.WriteString(fileStatus.Extra)
-			diffBuilder.WriteString("\nrename to ")
-			diffBuilder.WriteString(filePath)
-			diffBuilder.WriteString("\n")
-		}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Path < files[j].Path
+	})
+
+	return files, nil
+}
+
+// GetUserIdentity returns the identity commits are recorded with: user.name
+// and user.email from the merged system, global and repository config,
+// overridden by GIT_AUTHOR_NAME and GIT_AUTHOR_EMAIL
+func (c *ClientImpl) GetUserIdentity() (*Identity, error) {
+	repo, err := c.openRepo()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
 	}
 
-	diff := diffBuilder.String()
-	if len(diff) > 10000 {
-		return diff[:10000] + "\n...[TRUNCATED]", nil
+	cfg, err := repo.ConfigScoped(gitconfig.SystemScope)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get git config: %w", err)
+	}
+
+	identity := &Identity{Name: cfg.User.Name, Email: cfg.User.Email}
+	if name := os.Getenv("GIT_AUTHOR_NAME"); name != "" {
+		identity.Name = name
+	}
+	if email := os.Getenv("GIT_AUTHOR_EMAIL"); email != "" {
+		identity.Email = email
+	}
+
+	// Validate that git user name and email are configured
+	if identity.Name == "" {
+		return nil, fmt.Errorf("git user name is not configured. Please set it with: git config user.name \"Your Name\"")
+	}
+	if identity.Email == "" {
+		return nil, fmt.Errorf("git user email is not configured. Please set it with: git config user.email \"your.email@example.com\"")
 	}
-	return diff, nil
+	return identity, nil
 }
 
 // CommitWithMessage executes git commit with the given message
 func (c *ClientImpl) CommitWithMessage(message string) error {
+	if c.commitKind == BackendExec {
+		if !insideGitCommit() {
+			c.logf("Committing with git commit, running the repository's hooks")
+			return commitWithHooks(c.dir, message)
+		}
+		// A nested git commit would find the index locked
+		c.logf("Committing without git commit: running inside one, which holds the index")
+	}
+	if b := c.backend(); b != nil {
+		return b.CommitWithMessage(message)
+	}
 	repo, err := c.openRepo()
 	if err != nil {
 		return fmt.Errorf("failed to open repository: %w", err)
@@ -312,33 +393,50 @@ func (c *ClientImpl) CommitWithMessage(message string) error {
 		return fmt.Errorf("failed to get worktree: %w", err)
 	}
 
-	// Get git config for author information
-	config, err := repo.Config()
+	identity, err := c.GetUserIdentity()
 	if err != nil {
-		return fmt.Errorf("failed to get git config: %w", err)
-	}
-
-	// Validate that git user name and email are configured
-	if config.User.Name == "" {
-		return fmt.Errorf("git user name is not configured. Please set it with: git config user.name \"Your Name\"")
-	}
-	if config.User.Email == "" {
-		return fmt.Errorf("git user email is not configured. Please set it with: git config user.email \"your.email@example.com\"")
+		return err
 	}
 
 	// Create author signature from config
 	author := &object.Signature{
-		Name:  config.User.Name,
-		Email: config.User.Email,
+		Name:  identity.Name,
+		Email: identity.Email,
 		When:  time.Now(),
 	}
 
-	// Commit the staged changes
-	_, err = worktree.Commit(message, &git.CommitOptions{
+	commitOptions := &git.CommitOptions{
 		Author: author,
-	})
+	}
+	signing, err := readSigningConfig(repo)
 	if err != nil {
-		return fmt.Errorf("failed to commit: %w", err)
+		return err
+	}
+	if signing.Sign {
+		entity, reason := signingEntity(signing, identity.Email)
+		switch {
+		case entity != nil:
+			c.logf("Signing the commit with OpenPGP key %X through go-git", entity.PrimaryKey.KeyId)
+			commitOptions.SignKey = entity
+		case c.kind == BackendGoGit:
+			gitDir, _ := c.GetGitDir()
+			return keepMessage(gitDir, message, fmt.Errorf("commit.gpgsign is set but %v; set git_backend to auto or exec to let git sign the commit", reason))
+		default:
+			// git signs with gpg, gpg-agent and ssh-keygen natively
+			c.logf("Signing the commit with the git binary: %v", reason)
+			return (&execBackend{options: c.options, dir: c.dir}).CommitWithMessage(message)
+		}
+	}
+
+	// Commit the staged changes
+	_, err = worktree.Commit(message, commitOptions)
+	if err != nil {
+		err = fmt.Errorf("failed to commit: %w", err)
+		if signing.Sign {
+			gitDir, _ := c.GetGitDir()
+			return keepMessage(gitDir, message, err)
+		}
+		return err
 	}
 
 	return nil
@@ -363,7 +461,7 @@ func (c *ClientImpl) GetRepoRoot() (string, error) {
 
 	// Fallback: traverse up from current directory to find .git directory
 	// This works regardless of filesystem type
-	wd, err := os.Getwd()
+	wd, err := c.workDir()
 	if err != nil {
 		return "", fmt.Errorf("failed to get working directory: %w", err)
 	}
@@ -386,6 +484,58 @@ func (c *ClientImpl) GetRepoRoot() (string, error) {
 	return "", fmt.Errorf("failed to determine repository root: .git directory not found")
 }
 
+// GetHooksDir returns the directory git runs hooks from: core.hooksPath
+// when configured (relative paths are resolved against the repository root),
+// otherwise the hooks directory of the git dir. Linked worktrees share the
+// hooks of the main repository, and a submodule has its own under the
+// directory its .git file points to.
+func (c *ClientImpl) GetHooksDir() (string, error) {
+	repo, err := c.openRepo()
+	if err != nil {
+		return "", fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	repoRoot, err := c.GetRepoRoot()
+	if err != nil {
+		return "", err
+	}
+
+	cfg, err := repo.ConfigScoped(gitconfig.SystemScope)
+	if err != nil {
+		return "", fmt.Errorf("failed to get git config: %w", err)
+	}
+
+	hooksPath := cfg.Raw.Section("core").Option("hooksPath")
+	if hooksPath == "" {
+		gitDir, err := resolveGitDir(repoRoot)
+		if err != nil {
+			return "", err
+		}
+		commonDir, err := resolveCommonDir(gitDir)
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(commonDir, "hooks"), nil
+	}
+	return resolveConfigPath(repoRoot, "core.hooksPath", hooksPath)
+}
+
+// resolveConfigPath expands a leading ~/ in a path from the git config of
+// key and resolves a relative path against the repository root
+func resolveConfigPath(repoRoot, key, p string) (string, error) {
+	if strings.HasPrefix(p, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to expand %s: %w", key, err)
+		}
+		p = filepath.Join(home, p[2:])
+	}
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(repoRoot, p)
+	}
+	return filepath.Clean(p), nil
+}
+
 // DetectState detects the current git state (merge, rebase, cherry-pick, or normal)
 func (c *ClientImpl) DetectState() (*GitState, error) {
 	repoRoot, err := c.GetRepoRoot()
@@ -393,6 +543,22 @@ func (c *ClientImpl) DetectState() (*GitState, error) {
 		return nil, fmt.Errorf("failed to get repository root: %w", err)
 	}
 
-	return DetectGitState(repoRoot)
+	commentChar, err := c.GetCommentChar()
+	if err != nil {
+		return nil, err
+	}
+	state, err := DetectGitState(repoRoot, commentChar)
+	if err != nil {
+		return nil, err
+	}
+
+	repo, err := c.openRepo()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+	if state.InitialCommit, err = c.headUnborn(repo); err != nil {
+		return nil, err
+	}
+	return state, nil
 }
 
@@ -2,6 +2,7 @@ package git
 
 import (
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -114,4 +115,87 @@ func TestClientImpl_Integration(t *testing.T) {
 	if !strings.Contains(diff, "test.txt") {
 		t.Errorf("expected diff to contain 'test.txt', got: %s", diff)
 	}
+
+	// 7. Test GetStagedFiles
+	files, err := client.GetStagedFiles()
+	if err != nil {
+		t.Errorf("unexpected error listing staged files: %v", err)
+	}
+	if len(files) != 1 || files[0].Path != "test.txt" || files[0].Change != ChangeAdded {
+		t.Errorf("expected a single added test.txt, got %+v", files)
+	}
+
+	// 8. Test GetUserIdentity, including the author environment override
+	identity, err := client.GetUserIdentity()
+	if err != nil {
+		t.Errorf("unexpected error getting identity: %v", err)
+	} else if identity.String() != "Test User <test@example.com>" {
+		t.Errorf("expected configured identity, got %q", identity)
+	}
+	t.Setenv("GIT_AUTHOR_NAME", "Env Author")
+	identity, err = client.GetUserIdentity()
+	if err != nil || identity.Name != "Env Author" || identity.Email != "test@example.com" {
+		t.Errorf("expected GIT_AUTHOR_NAME override, got %+v (err %v)", identity, err)
+	}
+
+	// 9. Test path filters: excluded files are listed but not diffed
+	filtered := NewClientWithOptions(DiffOptions{Ignore: []string{"*.txt"}})
+	diff, err = filtered.GetStagedDiff()
+	if err != nil {
+		t.Errorf("unexpected error getting filtered diff: %v", err)
+	}
+	if strings.Contains(diff, "test.txt") {
+		t.Errorf("expected test.txt to be filtered out, got: %s", diff)
+	}
+	files, err = filtered.GetStagedFiles()
+	if err != nil {
+		t.Errorf("unexpected error listing filtered files: %v", err)
+	}
+	if len(files) != 1 || !files[0].Excluded {
+		t.Errorf("expected test.txt to be listed as excluded, got %+v", files)
+	}
+
+	// 10. Test GetHooksDir, defaulting to .git/hooks and honoring core.hooksPath
+	repoRoot, err := client.GetRepoRoot()
+	if err != nil {
+		t.Fatalf("unexpected error getting repo root: %v", err)
+	}
+	hooksDir, err := client.GetHooksDir()
+	if err != nil || hooksDir != filepath.Join(repoRoot, ".git", "hooks") {
+		t.Errorf("expected default hooks dir, got %q (err %v)", hooksDir, err)
+	}
+	config, err = repo.Config()
+	if err != nil {
+		t.Fatalf("failed to get config: %v", err)
+	}
+	config.Raw.Section("core").SetOption("hooksPath", ".husky")
+	if err := repo.SetConfig(config); err != nil {
+		t.Fatalf("failed to set core.hooksPath: %v", err)
+	}
+	hooksDir, err = client.GetHooksDir()
+	if err != nil || hooksDir != filepath.Join(repoRoot, ".husky") {
+		t.Errorf("expected core.hooksPath to be honored, got %q (err %v)", hooksDir, err)
+	}
+}
+
+func TestClientImpl_GetHooksDir_LinkedWorktree(t *testing.T) {
+	requireGit(t)
+	newIndexTestRepo(t, map[string]string{"README.md": "# demo\n"})
+	linked := addLinkedWorktree(t)
+	client := NewClient()
+
+	// git runs the hooks of a linked worktree from the main repository
+	hooksDir, err := client.GetHooksDir()
+	if err != nil {
+		t.Fatalf("GetHooksDir failed: %v", err)
+	}
+	if want := gitOutput(t, "rev-parse", "--path-format=absolute", "--git-path", "hooks"); hooksDir != want {
+		t.Errorf("expected %q, got %q", want, hooksDir)
+	}
+
+	gitOutput(t, "config", "core.hooksPath", ".husky")
+	hooksDir, err = client.GetHooksDir()
+	if err != nil || hooksDir != filepath.Join(linked, ".husky") {
+		t.Errorf("expected core.hooksPath to be honored, got %q (err %v)", hooksDir, err)
+	}
 }
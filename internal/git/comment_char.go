@@ -0,0 +1,77 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+
+	gitconfig "github.com/go-git/go-git/v5/config"
+)
+
+// DefaultCommentChar starts comment lines in commit messages when
+// core.commentChar is not set
+const DefaultCommentChar = "#"
+
+// CommentCharAuto is the core.commentChar value that has git pick, for each
+// commit, a character no line of the message starts with
+const CommentCharAuto = "auto"
+
+// autoCommentChars are the characters core.commentChar=auto picks from, in
+// git's order
+const autoCommentChars = "#;@!$%^&|:"
+
+// GetCommentChar returns the core.commentChar setting: DefaultCommentChar
+// when it is not set, and CommentCharAuto as is
+func (c *ClientImpl) GetCommentChar() (string, error) {
+	repo, err := c.openRepo()
+	if err != nil {
+		return "", fmt.Errorf("failed to open repository: %w", err)
+	}
+	cfg, err := repo.ConfigScoped(gitconfig.SystemScope)
+	if err != nil {
+		return "", fmt.Errorf("failed to get git config: %w", err)
+	}
+	commentChar := strings.TrimSpace(cfg.Raw.Section("core").Option("commentChar"))
+	if commentChar == "" {
+		return DefaultCommentChar, nil
+	}
+	if strings.EqualFold(commentChar, CommentCharAuto) {
+		return CommentCharAuto, nil
+	}
+	return commentChar, nil
+}
+
+// PickCommentChar returns the character core.commentChar=auto picks for
+// text that has no comments yet: the first of #;@!$%^&|: that starts none of
+// its lines, or DefaultCommentChar when every one does
+func PickCommentChar(text string) string {
+	used := make(map[byte]bool)
+	for _, line := range strings.Split(text, "\n") {
+		if line != "" {
+			used[line[0]] = true
+		}
+	}
+	for i := 0; i < len(autoCommentChars); i++ {
+		if !used[autoCommentChars[i]] {
+			return autoCommentChars[i : i+1]
+		}
+	}
+	return DefaultCommentChar
+}
+
+// FileCommentChar returns the comment character of a message file git
+// wrote with the core.commentChar setting. With auto, git writes its
+// comments below the message, so the last line tells which character it
+// picked.
+func FileCommentChar(setting, content string) string {
+	if setting != CommentCharAuto {
+		if setting == "" {
+			return DefaultCommentChar
+		}
+		return setting
+	}
+	lines := strings.Split(strings.TrimRight(content, " \t\r\n"), "\n")
+	if last := lines[len(lines)-1]; last != "" && strings.IndexByte(autoCommentChars, last[0]) >= 0 {
+		return last[:1]
+	}
+	return DefaultCommentChar
+}
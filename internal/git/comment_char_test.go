@@ -0,0 +1,126 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestClientImpl_GetCommentChar(t *testing.T) {
+	tests := []struct {
+		name     string
+		setting  string
+		expected string
+	}{
+		{"Not configured", "", "#"},
+		{"Custom character", ";", ";"},
+		{"Auto", "auto", CommentCharAuto},
+		{"Auto in capitals", "AUTO", CommentCharAuto},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Keep a setting in the user's global config out of the test
+			t.Setenv("HOME", t.TempDir())
+			t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+			repo, client := newIndexTestRepo(t, nil)
+			if tt.setting != "" {
+				cfg, _ := repo.Config()
+				cfg.Raw.Section("core").SetOption("commentChar", tt.setting)
+				if err := repo.SetConfig(cfg); err != nil {
+					t.Fatalf("failed to set core.commentChar: %v", err)
+				}
+			}
+
+			commentChar, err := client.GetCommentChar()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if commentChar != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, commentChar)
+			}
+		})
+	}
+}
+
+func TestPickCommentChar(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{"Unused hash", "feat: added login\n\nRefs PROJ-1\n", "#"},
+		{"Issue reference at a line start", "#42 fixed the login\n", ";"},
+		{"Several used", "#42\n;x\n@here\n", "!"},
+		{"All used", "#\n;\n@\n!\n$\n%\n^\n&\n|\n:\n", "#"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PickCommentChar(tt.text); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestFileCommentChar(t *testing.T) {
+	tests := []struct {
+		name     string
+		setting  string
+		content  string
+		expected string
+	}{
+		{"Default", "#", "fix: x\n; not a comment\n# comment\n", "#"},
+		{"Custom", ";", "#42 fix: x\n; comment\n", ";"},
+		{"Unset", "", "fix: x\n", "#"},
+		{"Auto picks from the last line", "auto", "#42 fix: x\n\n; Please enter the commit message\n;\n", ";"},
+		{"Auto without comments", "auto", "fix: x\n", "#"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FileCommentChar(tt.setting, tt.content); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestDetectGitState_CommentChar(t *testing.T) {
+	mergeMsg := "Merge branch 'feature'\n\n#123 was the bug this fixes\n; Conflicts:\n;\tmain.go\n"
+
+	tests := []struct {
+		name        string
+		commentChar string
+		expected    string
+	}{
+		{"Custom character", ";", "Merge branch 'feature'\n#123 was the bug this fixes"},
+		{"Auto", CommentCharAuto, "Merge branch 'feature'\n#123 was the bug this fixes"},
+		{"Default", DefaultCommentChar, "Merge branch 'feature'\n; Conflicts:\n;\tmain.go"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repoRoot := t.TempDir()
+			gitDir := filepath.Join(repoRoot, ".git")
+			if err := os.Mkdir(gitDir, 0755); err != nil {
+				t.Fatalf("failed to create .git dir: %v", err)
+			}
+			files := map[string]string{"MERGE_HEAD": "abc123\n", "MERGE_MSG": mergeMsg}
+			for name, content := range files {
+				if err := os.WriteFile(filepath.Join(gitDir, name), []byte(content), 0644); err != nil {
+					t.Fatalf("failed to write %s: %v", name, err)
+				}
+			}
+
+			state, err := DetectGitState(repoRoot, tt.commentChar)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if state.OriginalMessage != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, state.OriginalMessage)
+			}
+		})
+	}
+}
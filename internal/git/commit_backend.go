@@ -0,0 +1,90 @@
+package git
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// skipGenerationEnv is set for the git commit commitWithHooks runs, so our
+// own installed hooks (see app.SkipEnv) do not generate another message
+// for the commit they are part of
+const skipGenerationEnv = "AI_COMMIT_SKIP=1"
+
+// maxCommitOutputLines caps how much of a failed commit's output is put in
+// the error; hooks can be chatty
+const maxCommitOutputLines = 20
+
+// ParseCommitBackendKind accepts the commit_backend names: BackendGoGit or
+// BackendExec. Empty means BackendGoGit.
+func ParseCommitBackendKind(name string) (BackendKind, error) {
+	switch kind := BackendKind(name); kind {
+	case "":
+		return BackendGoGit, nil
+	case BackendGoGit, BackendExec:
+		return kind, nil
+	default:
+		return "", fmt.Errorf("unknown commit backend %q (expected %q or %q)", name, BackendGoGit, BackendExec)
+	}
+}
+
+// WithCommitBackend selects how CommitWithMessage commits. BackendExec runs
+// git commit, hooks, filters and all, whatever backend reads the
+// repository; BackendGoGit leaves it to that backend.
+func WithCommitBackend(kind BackendKind) ClientOption {
+	return func(c *ClientImpl) {
+		c.commitKind = kind
+	}
+}
+
+// insideGitCommit reports whether we run in a hook of a git commit, which
+// holds the index lock until it is done. The hook's GIT_INDEX_FILE is then
+// the lock file.
+func insideGitCommit() bool {
+	return strings.HasSuffix(os.Getenv("GIT_INDEX_FILE"), ".lock")
+}
+
+// commitWithHooks commits the index with git commit -F -, the message on
+// stdin, as a commit made by hand would be: the repository's pre-commit,
+// commit-msg and post-commit hooks run and git's filters, such as LFS,
+// apply. A failure, such as a hook rejecting the commit, carries git's exit
+// status and the end of its output, and the message is saved so it is not
+// lost. It runs in dir, or in the current directory when dir is empty.
+func commitWithHooks(dir, message string) error {
+	cmd := exec.Command("git", "commit", "--cleanup=verbatim", "-F", "-")
+	cmd.Dir = dir
+	cmd.Stdin = strings.NewReader(message)
+	cmd.Env = append(os.Environ(), skipGenerationEnv)
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	err := cmd.Run()
+	if err == nil {
+		return nil
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		err = fmt.Errorf("git commit exited with status %d", exitErr.ExitCode())
+		if tail := lastLines(output.String(), maxCommitOutputLines); tail != "" {
+			err = fmt.Errorf("%w:\n%s", err, tail)
+		}
+	} else {
+		err = fmt.Errorf("failed to run git commit: %w", err)
+	}
+	gitDir, _ := (&execBackend{dir: dir}).run("rev-parse", "--absolute-git-dir")
+	return keepMessage(strings.TrimSpace(gitDir), message, err)
+}
+
+// lastLines returns the last n lines of text, without surrounding blank
+// space
+func lastLines(text string, n int) string {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
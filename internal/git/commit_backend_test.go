@@ -0,0 +1,124 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseCommitBackendKind(t *testing.T) {
+	tests := []struct {
+		name        string
+		value       string
+		expected    BackendKind
+		expectError bool
+	}{
+		{name: "Empty means go-git", value: "", expected: BackendGoGit},
+		{name: "go-git", value: "go-git", expected: BackendGoGit},
+		{name: "exec", value: "exec", expected: BackendExec},
+		{name: "auto is for reading", value: "auto", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kind, err := ParseCommitBackendKind(tt.value)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected an error, got %q", kind)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if kind != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, kind)
+			}
+		})
+	}
+}
+
+// writeHook installs an sh hook in the repository in the current directory
+func writeHook(t *testing.T, name, script string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(".git", "hooks"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(".git", "hooks", name), []byte("#!/bin/sh\n"+script), 0755); err != nil {
+		t.Fatalf("failed to write the %s hook: %v", name, err)
+	}
+}
+
+func TestClientImpl_CommitBackendExec(t *testing.T) {
+	requireGit(t)
+	repo, _ := newIndexTestRepo(t, map[string]string{"main.go": "package main\n"})
+	stageFiles(t, repo, map[string]string{"main.go": "package main\n\nfunc main() {}\n"})
+	// The hook records that it ran and whether our hooks were told to skip
+	writeHook(t, "commit-msg", `echo "$AI_COMMIT_SKIP" > commit-msg-ran
+echo "Refs: #12" >> "$1"
+`)
+
+	client := NewClientWithBackend(DiffOptions{}, BackendGoGit, WithCommitBackend(BackendExec))
+	if err := client.CommitWithMessage("feat: add main\n"); err != nil {
+		t.Fatalf("CommitWithMessage failed: %v", err)
+	}
+
+	ran, err := os.ReadFile("commit-msg-ran")
+	if err != nil {
+		t.Fatalf("expected the commit-msg hook to run: %v", err)
+	}
+	if strings.TrimSpace(string(ran)) != "1" {
+		t.Errorf("expected AI_COMMIT_SKIP=1 for the hooks, got %q", ran)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatalf("failed to resolve HEAD: %v", err)
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		t.Fatalf("failed to read commit: %v", err)
+	}
+	if commit.Message != "feat: add main\nRefs: #12\n" {
+		t.Errorf("expected the message the hook amended, got %q", commit.Message)
+	}
+}
+
+func TestClientImpl_CommitBackendExec_HookRejects(t *testing.T) {
+	requireGit(t)
+	repo, _ := newIndexTestRepo(t, map[string]string{"main.go": "package main\n"})
+	stageFiles(t, repo, map[string]string{"main.go": "package main\n\nfunc main() {}\n"})
+	writeHook(t, "pre-commit", "echo 'lint failed: main.go' >&2\nexit 3\n")
+
+	client := NewClientWithBackend(DiffOptions{}, BackendGoGit, WithCommitBackend(BackendExec))
+	err := client.CommitWithMessage("feat: add main\n")
+	if err == nil {
+		t.Fatal("expected the hook to reject the commit")
+	}
+	for _, want := range []string{"git commit exited with status 1", "lint failed: main.go", uncommittedMessageFile} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in the error, got: %v", want, err)
+		}
+	}
+	saved, readErr := os.ReadFile(filepath.Join(".git", uncommittedMessageFile))
+	if readErr != nil || string(saved) != "feat: add main\n" {
+		t.Errorf("expected the message to be saved, got %q, %v", saved, readErr)
+	}
+}
+
+func TestClientImpl_CommitBackendExec_InsideGitCommit(t *testing.T) {
+	requireGit(t)
+	repo, _ := newIndexTestRepo(t, map[string]string{"main.go": "package main\n"})
+	stageFiles(t, repo, map[string]string{"main.go": "package main\n\nfunc main() {}\n"})
+	writeHook(t, "pre-commit", "touch pre-commit-ran\n")
+	// As in a hook of a git commit, which holds the index
+	t.Setenv("GIT_INDEX_FILE", filepath.Join(".git", "index.lock"))
+
+	client := NewClientWithBackend(DiffOptions{}, BackendGoGit, WithCommitBackend(BackendExec))
+	if err := client.CommitWithMessage("feat: add main\n"); err != nil {
+		t.Fatalf("CommitWithMessage failed: %v", err)
+	}
+	if _, err := os.Stat("pre-commit-ran"); err == nil {
+		t.Error("expected go-git to commit inside a git commit, without hooks")
+	}
+}
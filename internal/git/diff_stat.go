@@ -0,0 +1,109 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxStatGraphWidth caps the +/- graph of a FormatDiffStat line
+const maxStatGraphWidth = 40
+
+// FileStat counts the changed lines of one file in a diff
+type FileStat struct {
+	Path       string
+	Insertions int
+	Deletions  int
+	// Binary is set for files the diff has no lines for, only a
+	// "Binary files differ" note or a binary patch
+	Binary bool
+}
+
+// DiffStat counts the added and removed lines of every file in a unified
+// diff, like git diff --stat. A truncated diff is counted as far as it goes.
+func DiffStat(diff string) []FileStat {
+	_, sections := splitDiff(diff)
+	stats := make([]FileStat, 0, len(sections))
+	for _, section := range sections {
+		stat := FileStat{Path: section.file.Path}
+		inHeader := true
+		for _, line := range strings.Split(section.text, "\n") {
+			switch {
+			case strings.HasPrefix(line, "@@"):
+				inHeader = false
+			case inHeader:
+				// Diffs of new files written by this package have no
+				// hunk header, so the +++ line ends the file header too
+				if strings.HasPrefix(line, "+++ ") {
+					inHeader = false
+				}
+				if strings.HasPrefix(line, "Binary files ") || strings.HasPrefix(line, "GIT binary patch") {
+					stat.Binary = true
+				}
+			case strings.HasPrefix(line, "+"):
+				stat.Insertions++
+			case strings.HasPrefix(line, "-"):
+				stat.Deletions++
+			}
+		}
+		stats = append(stats, stat)
+	}
+	return stats
+}
+
+// FormatDiffStat writes stats the way git diff --stat does: one
+// "path | 12 +++---" line per file and a closing summary line. It returns
+// "" when there are no files.
+func FormatDiffStat(stats []FileStat) string {
+	if len(stats) == 0 {
+		return ""
+	}
+	pathWidth, countWidth, most := 0, 1, 0
+	insertions, deletions := 0, 0
+	for _, s := range stats {
+		pathWidth = max(pathWidth, len(s.Path))
+		countWidth = max(countWidth, len(fmt.Sprint(s.Insertions+s.Deletions)))
+		most = max(most, s.Insertions+s.Deletions)
+		insertions += s.Insertions
+		deletions += s.Deletions
+	}
+
+	var sb strings.Builder
+	for _, s := range stats {
+		if s.Binary {
+			fmt.Fprintf(&sb, " %-*s | Bin\n", pathWidth, s.Path)
+			continue
+		}
+		plus, minus := s.Insertions, s.Deletions
+		if most > maxStatGraphWidth {
+			plus, minus = scaleStat(plus, most), scaleStat(minus, most)
+		}
+		fmt.Fprintf(&sb, " %-*s | %*d %s%s\n", pathWidth, s.Path, countWidth, s.Insertions+s.Deletions,
+			strings.Repeat("+", plus), strings.Repeat("-", minus))
+	}
+
+	summary := fmt.Sprintf(" %d file%s changed", len(stats), plural(len(stats)))
+	if insertions > 0 {
+		summary += fmt.Sprintf(", %d insertion%s(+)", insertions, plural(insertions))
+	}
+	if deletions > 0 {
+		summary += fmt.Sprintf(", %d deletion%s(-)", deletions, plural(deletions))
+	}
+	sb.WriteString(summary + "\n")
+	return sb.String()
+}
+
+// scaleStat fits n into the graph width when the largest count is most,
+// keeping at least one character for any change
+func scaleStat(n, most int) int {
+	if n == 0 {
+		return 0
+	}
+	return max(1, n*maxStatGraphWidth/most)
+}
+
+func plural(n int) string {
+	if n == 1 {
+		return ""
+	}
+	return "s"
+}
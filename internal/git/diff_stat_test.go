@@ -0,0 +1,91 @@
+package git
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiffStat(t *testing.T) {
+	tests := []struct {
+		name     string
+		diff     string
+		expected []FileStat
+	}{
+		{
+			name: "Git format",
+			diff: gitFormatDiff,
+			expected: []FileStat{
+				{Path: "internal/app/app.go", Insertions: 1, Deletions: 1},
+				{Path: "docs/new.md", Insertions: 1},
+				{Path: "old.txt", Deletions: 1},
+				{Path: "b.go"},
+				{Path: "go.sum", Insertions: 1, Deletions: 1},
+			},
+		},
+		{
+			name: "New file without a hunk header",
+			diff: "diff --git a/main.go b/main.go\nnew file mode 100644\nindex 0000000..abc\n--- /dev/null\n+++ b/main.go\n+package main\n+\n",
+			expected: []FileStat{
+				{Path: "main.go", Insertions: 2},
+			},
+		},
+		{
+			name: "Binary file",
+			diff: "diff --git a/logo.png b/logo.png\nindex 1111111..2222222 100644\nBinary files a/logo.png and b/logo.png differ\n",
+			expected: []FileStat{
+				{Path: "logo.png", Binary: true},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DiffStat(tt.diff); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("expected %+v, got %+v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestFormatDiffStat(t *testing.T) {
+	tests := []struct {
+		name     string
+		stats    []FileStat
+		expected string
+	}{
+		{
+			name: "Aligned like git",
+			stats: []FileStat{
+				{Path: "internal/app/app.go", Insertions: 8, Deletions: 4},
+				{Path: "README.md", Insertions: 1},
+				{Path: "logo.png", Binary: true},
+			},
+			expected: " internal/app/app.go | 12 ++++++++----\n" +
+				" README.md           |  1 +\n" +
+				" logo.png            | Bin\n" +
+				" 3 files changed, 9 insertions(+), 4 deletions(-)\n",
+		},
+		{
+			name:     "Graph is scaled to fit",
+			stats:    []FileStat{{Path: "big.go", Insertions: 300, Deletions: 100}, {Path: "small.go", Deletions: 1}},
+			expected: " big.go   | 400 " + strings.Repeat("+", 30) + strings.Repeat("-", 10) + "\n small.go |   1 -\n 2 files changed, 300 insertions(+), 101 deletions(-)\n",
+		},
+		{
+			name:     "One file",
+			stats:    []FileStat{{Path: "main.go", Deletions: 1}},
+			expected: " main.go | 1 -\n 1 file changed, 1 deletion(-)\n",
+		},
+		{
+			name: "Nothing",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatDiffStat(tt.stats); got != tt.expected {
+				t.Errorf("expected:\n%q\ngot:\n%q", tt.expected, got)
+			}
+		})
+	}
+}
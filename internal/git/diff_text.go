@@ -0,0 +1,124 @@
+package git
+
+import (
+	"strings"
+)
+
+// diffSection is the part of a unified diff that describes one file
+type diffSection struct {
+	file StagedFile
+	text string
+}
+
+// ParseDiff reads a unified diff produced outside this package, e.g. by
+// "git diff" or another VCS, and returns it with the files the path filters
+// exclude removed, along with every file it mentions. Excluded files are
+// listed with Excluded set. Text before the first file is kept. The result
+// is truncated like the staged diff.
+func ParseDiff(diff string, opts DiffOptions) (string, []StagedFile) {
+	preamble, sections := splitDiff(diff)
+
+	var sb strings.Builder
+	sb.WriteString(preamble)
+	files := make([]StagedFile, 0, len(sections))
+	for _, section := range sections {
+		section.file.Excluded = !opts.includes(section.file.Path)
+		files = append(files, section.file)
+		if !section.file.Excluded {
+			sb.WriteString(section.text)
+		}
+	}
+	return truncateDiff(sb.String()), files
+}
+
+// splitDiff cuts diff into per-file sections. A section starts at a
+// "diff --git" header or, for plain unified diffs without such headers, at
+// a "--- " line followed by a "+++ " line.
+func splitDiff(diff string) (string, []diffSection) {
+	lines := strings.SplitAfter(diff, "\n")
+	gitFormat := strings.HasPrefix(diff, "diff --git ") || strings.Contains(diff, "\ndiff --git ")
+	var preamble strings.Builder
+	var sections []diffSection
+	var current *diffSection
+	inHeader := false
+
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			sections = append(sections, diffSection{file: StagedFile{Path: gitHeaderPath(line), Change: ChangeModified}})
+			current = &sections[len(sections)-1]
+			inHeader = true
+		case !gitFormat && strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ "):
+			sections = append(sections, diffSection{file: StagedFile{Change: ChangeModified}})
+			current = &sections[len(sections)-1]
+			inHeader = true
+		case strings.HasPrefix(line, "@@"):
+			inHeader = false
+		}
+
+		if current == nil {
+			preamble.WriteString(line)
+			continue
+		}
+		current.text += line
+		if inHeader {
+			applyHeaderLine(&current.file, line)
+		}
+	}
+	return preamble.String(), sections
+}
+
+// applyHeaderLine updates file from one line of its section header
+func applyHeaderLine(file *StagedFile, line string) {
+	line = strings.TrimRight(line, "\r\n")
+	switch {
+	case strings.HasPrefix(line, "new file mode"):
+		file.Change = ChangeAdded
+	case strings.HasPrefix(line, "deleted file mode"):
+		file.Change = ChangeDeleted
+	case strings.HasPrefix(line, "rename to "):
+		file.Change = ChangeRenamed
+		file.Path = strings.TrimPrefix(line, "rename to ")
+	case strings.HasPrefix(line, "copy to "):
+		file.Change = ChangeCopied
+		file.Path = strings.TrimPrefix(line, "copy to ")
+	case strings.HasPrefix(line, "--- "):
+		if path := patchPath(line[4:]); path == "" {
+			file.Change = ChangeAdded
+		} else if file.Path == "" {
+			file.Path = path
+		}
+	case strings.HasPrefix(line, "+++ "):
+		if path := patchPath(line[4:]); path == "" {
+			file.Change = ChangeDeleted
+		} else if file.Change != ChangeRenamed && file.Change != ChangeCopied {
+			file.Path = path
+		}
+	}
+}
+
+// gitHeaderPath returns the new path of a "diff --git a/x b/y" header
+func gitHeaderPath(line string) string {
+	line = strings.TrimRight(line, "\r\n")
+	if i := strings.LastIndex(line, " b/"); i >= 0 {
+		return line[i+3:]
+	}
+	fields := strings.Fields(line)
+	return fields[len(fields)-1]
+}
+
+// patchPath returns the path of a ---/+++ line without the a/ or b/ prefix
+// and any timestamp, or "" for /dev/null
+func patchPath(name string) string {
+	if i := strings.Index(name, "\t"); i >= 0 {
+		name = name[:i]
+	}
+	name = strings.TrimSpace(name)
+	if name == "/dev/null" {
+		return ""
+	}
+	if strings.HasPrefix(name, "a/") || strings.HasPrefix(name, "b/") {
+		return name[2:]
+	}
+	return name
+}
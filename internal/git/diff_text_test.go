@@ -0,0 +1,132 @@
+package git
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const gitFormatDiff = `commit message preamble
+diff --git a/internal/app/app.go b/internal/app/app.go
+index 1111111..2222222 100644
+--- a/internal/app/app.go
++++ b/internal/app/app.go
+@@ -1,3 +1,3 @@
+ package app
+--- a removed line that looks like a header
++++ an added line that looks like a header
+diff --git a/docs/new.md b/docs/new.md
+new file mode 100644
+--- /dev/null
++++ b/docs/new.md
+@@ -0,0 +1 @@
++# New
+diff --git a/old.txt b/old.txt
+deleted file mode 100644
+--- a/old.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-old
+diff --git a/a.go b/b.go
+similarity index 90%
+rename from a.go
+rename to b.go
+diff --git a/go.sum b/go.sum
+--- a/go.sum
++++ b/go.sum
+@@ -1 +1 @@
+-x v1
++x v2
+`
+
+const plainDiff = `Index: src/main.c
+--- src/main.c	2024-01-01 10:00:00
++++ src/main.c	2024-01-02 10:00:00
+@@ -1 +1 @@
+-int x;
++int y;
+--- /dev/null
++++ src/util.h
+@@ -0,0 +1 @@
++#pragma once
+`
+
+func TestParseDiff(t *testing.T) {
+	tests := []struct {
+		name             string
+		diff             string
+		opts             DiffOptions
+		expectedFiles    []StagedFile
+		expectedContains []string
+		expectedAbsent   []string
+	}{
+		{
+			name: "Git format",
+			diff: gitFormatDiff,
+			expectedFiles: []StagedFile{
+				{Path: "internal/app/app.go", Change: ChangeModified},
+				{Path: "docs/new.md", Change: ChangeAdded},
+				{Path: "old.txt", Change: ChangeDeleted},
+				{Path: "b.go", Change: ChangeRenamed},
+				{Path: "go.sum", Change: ChangeModified},
+			},
+			expectedContains: []string{"commit message preamble", "+x v2", "+++ an added line"},
+		},
+		{
+			name: "Filters drop file sections",
+			diff: gitFormatDiff,
+			opts: DiffOptions{Ignore: []string{"go.sum", "docs/"}},
+			expectedFiles: []StagedFile{
+				{Path: "internal/app/app.go", Change: ChangeModified},
+				{Path: "docs/new.md", Change: ChangeAdded, Excluded: true},
+				{Path: "old.txt", Change: ChangeDeleted},
+				{Path: "b.go", Change: ChangeRenamed},
+				{Path: "go.sum", Change: ChangeModified, Excluded: true},
+			},
+			expectedContains: []string{"package app", "-old"},
+			expectedAbsent:   []string{"# New", "x v2"},
+		},
+		{
+			name: "Plain unified diff",
+			diff: plainDiff,
+			expectedFiles: []StagedFile{
+				{Path: "src/main.c", Change: ChangeModified},
+				{Path: "src/util.h", Change: ChangeAdded},
+			},
+			expectedContains: []string{"Index: src/main.c", "+int y;", "+#pragma once"},
+		},
+		{
+			name:             "Not a diff",
+			diff:             "just some text\n",
+			expectedFiles:    []StagedFile{},
+			expectedContains: []string{"just some text"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff, files := ParseDiff(tt.diff, tt.opts)
+
+			if !reflect.DeepEqual(files, tt.expectedFiles) {
+				t.Errorf("expected files %+v, got %+v", tt.expectedFiles, files)
+			}
+			for _, want := range tt.expectedContains {
+				if !strings.Contains(diff, want) {
+					t.Errorf("expected diff to contain %q, got:\n%s", want, diff)
+				}
+			}
+			for _, unwanted := range tt.expectedAbsent {
+				if strings.Contains(diff, unwanted) {
+					t.Errorf("expected diff not to contain %q, got:\n%s", unwanted, diff)
+				}
+			}
+		})
+	}
+}
+
+func TestParseDiff_Truncates(t *testing.T) {
+	diff, _ := ParseDiff("diff --git a/big.txt b/big.txt\n"+strings.Repeat("+line\n", maxDiffBytes), DiffOptions{})
+	if !strings.HasSuffix(diff, "[TRUNCATED]") {
+		t.Errorf("expected a truncated diff, got %d bytes", len(diff))
+	}
+}
@@ -0,0 +1,379 @@
+package git
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// BackendKind selects how the client reads the repository
+type BackendKind string
+
+const (
+	// BackendAuto uses go-git, and the git binary for layouts go-git
+	// cannot read: linked worktrees, submodule checkouts and repositories
+	// it fails to open although a .git exists
+	BackendAuto BackendKind = "auto"
+	// BackendGoGit always uses go-git
+	BackendGoGit BackendKind = "go-git"
+	// BackendExec reads the repository state and commits with the git
+	// binary
+	BackendExec BackendKind = "exec"
+)
+
+// ParseBackendKind accepts the backend names; empty means BackendAuto
+func ParseBackendKind(name string) (BackendKind, error) {
+	switch kind := BackendKind(name); kind {
+	case "":
+		return BackendAuto, nil
+	case BackendAuto, BackendGoGit, BackendExec:
+		return kind, nil
+	default:
+		return "", fmt.Errorf("unknown git backend %q (expected %q, %q or %q)", name, BackendAuto, BackendGoGit, BackendExec)
+	}
+}
+
+// GitBackend reads what a commit message is generated from and commits
+// it. ClientImpl implements it with go-git; execBackend runs the git binary.
+type GitBackend interface {
+	IsInsideRepo() (bool, error)
+	HasStagedChanges() (bool, error)
+	GetStagedDiff() (string, error)
+	GetStagedChanges() ([]FileChange, error)
+	GetStagedFiles() ([]StagedFile, error)
+	GetFileVersions(path string) (string, string, error)
+	CommitWithMessage(message string) error
+}
+
+// execBackend implements GitBackend by running git in dir, or in the
+// current directory when dir is empty
+type execBackend struct {
+	options DiffOptions
+	dir     string
+}
+
+// run runs git with args and returns its stdout. A failure carries git's
+// own error message.
+func (b *execBackend) run(args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = b.dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s failed: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s failed: %w", args[0], err)
+	}
+	return string(out), nil
+}
+
+// IsInsideRepo asks git whether the current directory is in a work tree
+func (b *execBackend) IsInsideRepo() (bool, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return false, fmt.Errorf("failed to find the git binary: %w", err)
+	}
+	out, err := b.run("rev-parse", "--is-inside-work-tree")
+	if err != nil {
+		// git exits non-zero outside a repository
+		return false, nil
+	}
+	return strings.TrimSpace(out) == "true", nil
+}
+
+// HasStagedChanges reports whether the index differs from HEAD
+func (b *execBackend) HasStagedChanges() (bool, error) {
+	cmd := exec.Command("git", b.withFiles("diff", "--cached", "--quiet")...)
+	cmd.Dir = b.dir
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return false, nil
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == 1:
+		return true, nil
+	default:
+		return false, fmt.Errorf("failed to check for staged changes: %w", err)
+	}
+}
+
+// GetStagedDiff returns the staged diff with the path filters applied. git
+// writes the text itself, so it is kept as is rather than rendered from
+// GetStagedChanges.
+func (b *execBackend) GetStagedDiff() (string, error) {
+	omitted, err := b.omittedChanges()
+	if err != nil {
+		return "", err
+	}
+	out, err := b.stagedDiff(omitted)
+	if err != nil {
+		return "", err
+	}
+	diff, _ := ParseDiff(withOmitted(out, omitted), b.options)
+	return truncateDiff(diff), nil
+}
+
+// GetStagedChanges parses the staged diff into one change per file the
+// path filters include, sorted by path
+func (b *execBackend) GetStagedChanges() ([]FileChange, error) {
+	omitted, err := b.omittedChanges()
+	if err != nil {
+		return nil, err
+	}
+	out, err := b.stagedDiff(omitted)
+	if err != nil {
+		return nil, err
+	}
+	changes := omitted
+	for _, change := range ParseChanges(out) {
+		if b.options.includes(change.Path) {
+			changes = append(changes, change)
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes, nil
+}
+
+// stagedDiff runs git diff on the index, leaving out the omitted files
+func (b *execBackend) stagedDiff(omitted []FileChange) (string, error) {
+	args := []string{"diff", "--cached", "--no-color", "--no-ext-diff", "--no-renames",
+		"-U" + strconv.Itoa(b.options.ContextLines)}
+	specs := b.options.filePathspecs()
+	if len(omitted) > 0 && specs == nil {
+		specs = []string{":/"}
+	}
+	if specs != nil {
+		args = append(append(args, "--"), specs...)
+		for _, change := range omitted {
+			args = append(args, ":(top,literal,exclude)"+change.Path)
+		}
+	}
+	return b.run(args...)
+}
+
+// withFiles limits a git diff command to the Files of the options
+func (b *execBackend) withFiles(args ...string) []string {
+	if specs := b.options.filePathspecs(); specs != nil {
+		return append(append(args, "--"), specs...)
+	}
+	return args
+}
+
+// nullBlob is the object id git lists for the missing side of an added or
+// deleted file
+const nullBlob = "0000000000000000000000000000000000000000"
+
+// omittedChanges returns the staged files the path filters include that
+// are larger than MaxFileBytes in HEAD or the index, as summaries. Their
+// size is looked up from the blobs, so git never reads them for a diff.
+func (b *execBackend) omittedChanges() ([]FileChange, error) {
+	if b.options.MaxFileBytes <= 0 {
+		return nil, nil
+	}
+	out, err := b.run(b.withFiles("diff", "--cached", "--raw", "--no-renames", "--no-abbrev", "-z")...)
+	if err != nil {
+		return nil, err
+	}
+
+	// -z output alternates ":oldmode newmode oldblob newblob status" and
+	// paths, NUL separated
+	type rawChange struct {
+		change FileChange
+		blobs  []string
+	}
+	var raw []rawChange
+	var blobs []string
+	fields := strings.Split(strings.TrimSuffix(out, "\x00"), "\x00")
+	for i := 0; i+1 < len(fields); i += 2 {
+		meta := strings.Fields(strings.TrimPrefix(fields[i], ":"))
+		filePath := fields[i+1]
+		if len(meta) < 5 || !b.options.includes(filePath) {
+			continue
+		}
+		entry := rawChange{change: FileChange{
+			Path:    filePath,
+			Change:  ChangeType(meta[4][:1]),
+			OldMode: parseFileMode(meta[0]),
+			NewMode: parseFileMode(meta[1]),
+		}}
+		for _, blob := range meta[2:4] {
+			if blob != nullBlob {
+				entry.blobs = append(entry.blobs, blob)
+				blobs = append(blobs, blob)
+			}
+		}
+		raw = append(raw, entry)
+	}
+	if len(blobs) == 0 {
+		return nil, nil
+	}
+
+	sizes, err := b.blobSizes(blobs)
+	if err != nil {
+		return nil, err
+	}
+	var omitted []FileChange
+	for _, entry := range raw {
+		var size int64
+		for _, blob := range entry.blobs {
+			size = max(size, sizes[blob])
+		}
+		if b.options.tooLarge(size) {
+			entry.change.Omitted, entry.change.Size = true, size
+			omitted = append(omitted, entry.change)
+		}
+	}
+	return omitted, nil
+}
+
+// blobSizes asks git cat-file for the size of every blob
+func (b *execBackend) blobSizes(blobs []string) (map[string]int64, error) {
+	cmd := exec.Command("git", "cat-file", "--batch-check")
+	cmd.Dir = b.dir
+	cmd.Stdin = strings.NewReader(strings.Join(blobs, "\n") + "\n")
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git cat-file failed: %w", err)
+	}
+
+	// Each line is "<blob> blob <size>", or "<blob> missing"
+	sizes := make(map[string]int64, len(blobs))
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			continue
+		}
+		if size, err := strconv.ParseInt(fields[2], 10, 64); err == nil {
+			sizes[fields[0]] = size
+		}
+	}
+	return sizes, nil
+}
+
+// withOmitted adds the summaries of omitted files to a diff git wrote,
+// keeping the files in path order
+func withOmitted(diff string, omitted []FileChange) string {
+	if len(omitted) == 0 {
+		return diff
+	}
+	preamble, sections := splitDiff(diff)
+	for _, change := range omitted {
+		sections = append(sections, diffSection{file: StagedFile{Path: change.Path, Change: change.Change}, text: change.String()})
+	}
+	sort.SliceStable(sections, func(i, j int) bool {
+		return sections[i].file.Path < sections[j].file.Path
+	})
+
+	var sb strings.Builder
+	sb.WriteString(preamble)
+	for _, section := range sections {
+		sb.WriteString(section.text)
+	}
+	return sb.String()
+}
+
+// GetStagedFiles returns the staged paths sorted by name. Paths left out by
+// the path filters are included with Excluded set.
+func (b *execBackend) GetStagedFiles() ([]StagedFile, error) {
+	out, err := b.run(b.withFiles("diff", "--cached", "--name-status", "--no-renames", "-z")...)
+	if err != nil {
+		return nil, err
+	}
+
+	// -z output alternates status letters and paths, NUL separated
+	fields := strings.Split(strings.TrimSuffix(out, "\x00"), "\x00")
+	var files []StagedFile
+	for i := 0; i+1 < len(fields); i += 2 {
+		filePath := fields[i+1]
+		files = append(files, StagedFile{
+			Path:     filePath,
+			Change:   ChangeType(fields[i][:1]),
+			Excluded: !b.options.includes(filePath),
+		})
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Path < files[j].Path
+	})
+	return files, nil
+}
+
+// CommitWithMessage commits the index with message as given. --no-verify
+// matches go-git, which runs no hooks; prepare-commit-msg still runs but
+// keeps a message passed with -F. git signs the commit as commit.gpgsign,
+// gpg.format and user.signingkey say. When the commit fails, for example
+// because signing did, the message is saved so it is not lost.
+func (b *execBackend) CommitWithMessage(message string) error {
+	cmd := exec.Command("git", "commit", "--no-verify", "--cleanup=verbatim", "-F", "-")
+	cmd.Dir = b.dir
+	cmd.Stdin = strings.NewReader(message)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = fmt.Errorf("failed to commit: %s", msg)
+		} else {
+			err = fmt.Errorf("failed to commit: %w", err)
+		}
+		gitDir, _ := b.run("rev-parse", "--absolute-git-dir")
+		return keepMessage(strings.TrimSpace(gitDir), message, err)
+	}
+	return nil
+}
+
+// backend returns the git binary backend when the kind asks for it or
+// go-git cannot be trusted with the layout, and nil when go-git reads the
+// repository itself
+func (c *ClientImpl) backend() GitBackend {
+	switch c.kind {
+	case BackendExec:
+		return &execBackend{options: c.options, dir: c.dir}
+	case BackendGoGit:
+		return nil
+	}
+
+	wd, err := c.workDir()
+	if err != nil {
+		return nil
+	}
+	dotGit, isFile := findDotGit(wd)
+	if dotGit == "" && os.Getenv("GIT_DIR") == "" {
+		return nil
+	}
+	// A .git file points to a linked worktree or a submodule's git dir.
+	// openRepo resolves their common dir, but the staged state and commits
+	// of those layouts are left to the git binary, which reads them exactly
+	// as git does.
+	if isFile {
+		return &execBackend{options: c.options, dir: c.dir}
+	}
+	if _, err := c.openRepo(); err != nil {
+		return &execBackend{options: c.options, dir: c.dir}
+	}
+	return nil
+}
+
+// findDotGit returns the nearest .git above dir and whether it is a file
+// rather than a directory
+func findDotGit(dir string) (string, bool) {
+	for {
+		candidate := filepath.Join(dir, ".git")
+		if info, err := os.Stat(candidate); err == nil {
+			return candidate, !info.IsDir()
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,238 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// requireGit skips tests that run the git binary when it is not installed
+func requireGit(t *testing.T) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git binary not found")
+	}
+	t.Setenv("GIT_COMMITTER_NAME", "Test User")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+}
+
+func TestParseBackendKind(t *testing.T) {
+	tests := []struct {
+		name        string
+		value       string
+		expected    BackendKind
+		expectError bool
+	}{
+		{name: "Empty means auto", value: "", expected: BackendAuto},
+		{name: "go-git", value: "go-git", expected: BackendGoGit},
+		{name: "exec", value: "exec", expected: BackendExec},
+		{name: "Unknown", value: "libgit2", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kind, err := ParseBackendKind(tt.value)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected an error, got %q", kind)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if kind != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, kind)
+			}
+		})
+	}
+}
+
+func TestClientImpl_ExecBackend(t *testing.T) {
+	requireGit(t)
+	repo, goGitClient := newIndexTestRepo(t, map[string]string{
+		"main.go": "package main\n",
+		"go.sum":  "a v1\n",
+	})
+	stageFiles(t, repo, map[string]string{
+		"main.go":        "package main\n\nfunc main() {}\n",
+		"go.sum":         "a v2\n",
+		"auth/login.go":  "package auth\n",
+		"docs/README.md": "# Docs\n",
+	})
+	opts := DiffOptions{ContextLines: DefaultContextLines, Ignore: []string{"go.sum"}}
+	execClient := NewClientWithBackend(opts, BackendExec)
+
+	inside, err := execClient.IsInsideRepo()
+	if err != nil || !inside {
+		t.Fatalf("expected to be inside the repository, got %v, %v", inside, err)
+	}
+	hasChanges, err := execClient.HasStagedChanges()
+	if err != nil || !hasChanges {
+		t.Fatalf("expected staged changes, got %v, %v", hasChanges, err)
+	}
+
+	files, err := execClient.GetStagedFiles()
+	if err != nil {
+		t.Fatalf("GetStagedFiles failed: %v", err)
+	}
+	expectedFiles, _ := NewClientWithBackend(opts, BackendGoGit).GetStagedFiles()
+	if !reflect.DeepEqual(files, expectedFiles) {
+		t.Errorf("expected the same files as go-git %+v, got %+v", expectedFiles, files)
+	}
+
+	diff, err := execClient.GetStagedDiff()
+	if err != nil {
+		t.Fatalf("GetStagedDiff failed: %v", err)
+	}
+	for _, want := range []string{"+func main() {}", "+package auth", "+# Docs"} {
+		if !strings.Contains(diff, want) {
+			t.Errorf("expected %q in diff:\n%s", want, diff)
+		}
+	}
+	if strings.Contains(diff, "a v2") {
+		t.Errorf("expected go.sum to be filtered out of the diff:\n%s", diff)
+	}
+
+	if err := execClient.CommitWithMessage("feat: added login\n\n# not a comment\n"); err != nil {
+		t.Fatalf("CommitWithMessage failed: %v", err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatalf("failed to resolve HEAD: %v", err)
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		t.Fatalf("failed to read commit: %v", err)
+	}
+	if commit.Message != "feat: added login\n\n# not a comment\n" {
+		t.Errorf("expected the message verbatim, got %q", commit.Message)
+	}
+	if hasChanges, _ := goGitClient.HasStagedChanges(); hasChanges {
+		t.Error("expected nothing staged after the commit")
+	}
+}
+
+func TestClientImpl_ExecBackend_OutsideRepo(t *testing.T) {
+	requireGit(t)
+	originalWd, _ := os.Getwd()
+	t.Cleanup(func() { _ = os.Chdir(originalWd) })
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatalf("failed to change to temp dir: %v", err)
+	}
+	t.Setenv("GIT_CEILING_DIRECTORIES", filepath.Dir(originalWd))
+
+	inside, err := NewClientWithBackend(DiffOptions{}, BackendExec).IsInsideRepo()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if inside {
+		t.Error("expected a temp dir not to be a repository")
+	}
+}
+
+func TestClientImpl_AutoBackend_LinkedWorktree(t *testing.T) {
+	requireGit(t)
+	_, client := newIndexTestRepo(t, map[string]string{"main.go": "package main\n"})
+	mainDir, _ := os.Getwd()
+	linked := addLinkedWorktree(t)
+	if err := os.WriteFile("main.go", []byte("package main\n\nfunc main() {}\n"), 0644); err != nil {
+		t.Fatalf("failed to write main.go: %v", err)
+	}
+	if out, err := exec.Command("git", "add", "main.go").CombinedOutput(); err != nil {
+		t.Fatalf("failed to stage: %v: %s", err, out)
+	}
+
+	// The linked worktree's HEAD is the shared branch, so main.go is
+	// modified rather than added
+	if staged := stagedPaths(t, client); !reflect.DeepEqual(staged, []string{"main.go:M"}) {
+		t.Errorf("expected main.go to be modified, got %v", staged)
+	}
+	diff, err := client.GetStagedDiff()
+	if err != nil {
+		t.Fatalf("GetStagedDiff failed: %v", err)
+	}
+	if !strings.Contains(diff, "+func main() {}") || strings.Contains(diff, "new file") {
+		t.Errorf("expected a modification of main.go, got:\n%s", diff)
+	}
+
+	if err := client.CommitWithMessage("feat: added main"); err != nil {
+		t.Fatalf("CommitWithMessage failed: %v", err)
+	}
+	out, err := exec.Command("git", "-C", mainDir, "log", "--format=%s", "-n", "1", strings.TrimSpace(branchOf(t, linked))).Output()
+	if err != nil {
+		t.Fatalf("failed to read the log: %v", err)
+	}
+	if strings.TrimSpace(string(out)) != "feat: added main" {
+		t.Errorf("expected the commit on the worktree's branch, got %q", out)
+	}
+}
+
+// addLinkedWorktree adds a linked worktree of the repository in the current
+// directory with git worktree add, changes into it and returns its path
+func addLinkedWorktree(t *testing.T) string {
+	t.Helper()
+	linked := filepath.Join(t.TempDir(), "linked")
+	if out, err := exec.Command("git", "worktree", "add", "-q", linked).CombinedOutput(); err != nil {
+		t.Fatalf("failed to add worktree: %v: %s", err, out)
+	}
+	if err := os.Chdir(linked); err != nil {
+		t.Fatalf("failed to change to worktree: %v", err)
+	}
+	return linked
+}
+
+// gitOutput runs git with args in the current directory and returns its
+// trimmed output
+func gitOutput(t *testing.T, args ...string) string {
+	t.Helper()
+	out, err := exec.Command("git", args...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s failed: %v: %s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// branchOf returns the branch checked out in dir
+func branchOf(t *testing.T, dir string) string {
+	t.Helper()
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "--abbrev-ref", "HEAD").Output()
+	if err != nil {
+		t.Fatalf("failed to read the branch: %v", err)
+	}
+	return string(out)
+}
+
+func TestClientImpl_WithDir(t *testing.T) {
+	requireGit(t)
+	repo, _ := newIndexTestRepo(t, map[string]string{"main.go": "package main\n"})
+	stageFiles(t, repo, map[string]string{"main.go": "package main\n\nfunc main() {}\n"})
+	repoDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get WD: %v", err)
+	}
+	// The clients must read repoDir, not the directory the process is in
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatalf("failed to change dir: %v", err)
+	}
+
+	for _, kind := range []BackendKind{BackendGoGit, BackendExec} {
+		t.Run(string(kind), func(t *testing.T) {
+			client := NewClientWithBackend(DiffOptions{ContextLines: DefaultContextLines}, kind, WithDir(repoDir))
+			inside, err := client.IsInsideRepo()
+			if err != nil || !inside {
+				t.Fatalf("expected to be inside the repository, got %v, %v", inside, err)
+			}
+			diff, err := client.GetStagedDiff()
+			if err != nil {
+				t.Fatalf("GetStagedDiff failed: %v", err)
+			}
+			if !strings.Contains(diff, "+func main() {}") {
+				t.Errorf("expected the staged change in diff:\n%s", diff)
+			}
+		})
+	}
+}
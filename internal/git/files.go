@@ -0,0 +1,163 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/format/index"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// CleanFiles turns the file list a hook passes into repository-relative,
+// slash-separated paths, dropping blanks and repeats. Paths are relative
+// to the repository root, where git runs hooks.
+func CleanFiles(files []string) ([]string, error) {
+	var cleaned []string
+	seen := make(map[string]bool)
+	for _, file := range files {
+		file = strings.TrimSpace(file)
+		if file == "" {
+			continue
+		}
+		file = path.Clean(filepath.ToSlash(file))
+		if path.IsAbs(file) || file == ".." || strings.HasPrefix(file, "../") || file == "." {
+			return nil, fmt.Errorf("invalid file %q: expected a path inside the repository", file)
+		}
+		if !seen[file] {
+			seen[file] = true
+			cleaned = append(cleaned, file)
+		}
+	}
+	return cleaned, nil
+}
+
+// status returns the status of the working tree. With Files set, only
+// those paths are looked up in HEAD, the index and the working tree
+// instead of scanning the whole tree, which is what makes a hook that
+// already knows the staged files fast on a large repository.
+func (c *ClientImpl) status(repo *git.Repository, worktree *git.Worktree) (git.Status, error) {
+	if len(c.options.Files) == 0 {
+		return worktree.Status()
+	}
+
+	headTree, err := headTreeOf(repo)
+	if err != nil {
+		return nil, err
+	}
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the index: %w", err)
+	}
+	root := worktree.Filesystem.Root()
+
+	status := make(git.Status)
+	for _, file := range c.options.Files {
+		headHash, headMode, inHead := treeEntry(headTree, file)
+		entry, err := idx.Entry(file)
+		inIndex := err == nil
+		if err != nil && err != index.ErrEntryNotFound {
+			return nil, fmt.Errorf("failed to read the index: %w", err)
+		}
+		worktreeHash, worktreeMode, inWorktree := worktreeEntry(filepath.Join(root, filepath.FromSlash(file)))
+
+		fileStatus := &git.FileStatus{Staging: git.Unmodified, Worktree: git.Unmodified}
+		switch {
+		case !inIndex && !inHead && !inWorktree:
+			continue
+		case !inIndex && !inHead:
+			fileStatus.Staging, fileStatus.Worktree = git.Untracked, git.Untracked
+		case !inIndex:
+			fileStatus.Staging = git.Deleted
+			if inWorktree {
+				fileStatus.Worktree = git.Untracked
+			}
+		default:
+			switch {
+			case !inHead:
+				fileStatus.Staging = git.Added
+			case entry.Hash != headHash || entry.Mode != headMode:
+				fileStatus.Staging = git.Modified
+			}
+			switch {
+			case !inWorktree:
+				fileStatus.Worktree = git.Deleted
+			case entry.Hash != worktreeHash || entry.Mode != worktreeMode:
+				fileStatus.Worktree = git.Modified
+			}
+		}
+		status[file] = fileStatus
+	}
+	return status, nil
+}
+
+// headTreeOf returns the tree of HEAD, or nil before the first commit
+func headTreeOf(repo *git.Repository) (*object.Tree, error) {
+	head, err := repo.Head()
+	if err == plumbing.ErrReferenceNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get HEAD: %w", err)
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get HEAD commit: %w", err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get HEAD tree: %w", err)
+	}
+	return tree, nil
+}
+
+// treeEntry looks a file up in tree, which is nil before the first commit
+func treeEntry(tree *object.Tree, file string) (plumbing.Hash, filemode.FileMode, bool) {
+	if tree == nil {
+		return plumbing.ZeroHash, filemode.Empty, false
+	}
+	entry, err := tree.FindEntry(file)
+	if err != nil || entry.Mode == filemode.Dir {
+		return plumbing.ZeroHash, filemode.Empty, false
+	}
+	return entry.Hash, entry.Mode, true
+}
+
+// worktreeEntry hashes a file of the working tree the way git add would.
+// A directory or a file that cannot be read counts as missing.
+func worktreeEntry(fullPath string) (plumbing.Hash, filemode.FileMode, bool) {
+	info, err := os.Lstat(fullPath)
+	if err != nil || info.IsDir() {
+		return plumbing.ZeroHash, filemode.Empty, false
+	}
+	mode, err := filemode.NewFromOSFileMode(info.Mode())
+	if err != nil {
+		return plumbing.ZeroHash, filemode.Empty, false
+	}
+	var content []byte
+	if mode == filemode.Symlink {
+		target, err := os.Readlink(fullPath)
+		if err != nil {
+			return plumbing.ZeroHash, filemode.Empty, false
+		}
+		content = []byte(filepath.ToSlash(target))
+	} else if content, err = os.ReadFile(fullPath); err != nil {
+		return plumbing.ZeroHash, filemode.Empty, false
+	}
+	return plumbing.ComputeHash(plumbing.BlobObject, content), mode, true
+}
+
+// filePathspecs returns the pathspecs of the exec backend for Files, or
+// nil when every path is diffed
+func (o DiffOptions) filePathspecs() []string {
+	var specs []string
+	for _, file := range o.Files {
+		specs = append(specs, ":(top,literal)"+file)
+	}
+	return specs
+}
@@ -0,0 +1,94 @@
+package git
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCleanFiles(t *testing.T) {
+	tests := []struct {
+		name        string
+		files       []string
+		expected    []string
+		expectError string
+	}{
+		{name: "None", files: nil, expected: nil},
+		{name: "Cleaned and deduplicated", files: []string{" ./a.go", "pkg//b.go", "", "a.go"}, expected: []string{"a.go", "pkg/b.go"}},
+		{name: "Outside the repository", files: []string{"../a.go"}, expectError: `invalid file "../a.go"`},
+		{name: "Absolute", files: []string{"/etc/passwd"}, expectError: `invalid file "/etc/passwd"`},
+		{name: "The root", files: []string{"."}, expectError: `invalid file "."`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := CleanFiles(tt.files)
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Fatalf("expected error containing %q, got %v", tt.expectError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(files, tt.expected) {
+				t.Errorf("expected %q, got %q", tt.expected, files)
+			}
+		})
+	}
+}
+
+func TestClientImpl_Files(t *testing.T) {
+	requireGit(t)
+	repo, _ := newIndexTestRepo(t, map[string]string{
+		"main.go":  "package main\n",
+		"old.go":   "package old\n",
+		"other.go": "package other\n",
+	})
+	stageFiles(t, repo, map[string]string{
+		"main.go":        "package main\n\nfunc main() {}\n",
+		"auth/login.go":  "package auth\n",
+		"docs/README.md": "# Docs\n",
+	})
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("failed to get worktree: %v", err)
+	}
+	if _, err := worktree.Remove("old.go"); err != nil {
+		t.Fatalf("failed to remove old.go: %v", err)
+	}
+	if err := os.WriteFile("other.go", []byte("package other // edited\n"), 0644); err != nil {
+		t.Fatalf("failed to write other.go: %v", err)
+	}
+
+	files := []string{"main.go", "auth/login.go", "old.go", "other.go", "missing.go"}
+	for _, kind := range []BackendKind{BackendGoGit, BackendExec} {
+		t.Run(string(kind), func(t *testing.T) {
+			client := NewClientWithBackend(DiffOptions{ContextLines: DefaultContextLines, Files: files}, kind)
+
+			expected := []string{"auth/login.go:A", "main.go:M", "old.go:D"}
+			if paths := stagedPaths(t, client); !reflect.DeepEqual(paths, expected) {
+				t.Errorf("expected %q, got %q", expected, paths)
+			}
+			diff, err := client.GetStagedDiff()
+			if err != nil {
+				t.Fatalf("GetStagedDiff failed: %v", err)
+			}
+			for _, want := range []string{"+func main() {}", "+package auth", "-package old"} {
+				if !strings.Contains(diff, want) {
+					t.Errorf("expected %q in diff:\n%s", want, diff)
+				}
+			}
+			if strings.Contains(diff, "# Docs") || strings.Contains(diff, "edited") {
+				t.Errorf("expected only the listed staged files in the diff:\n%s", diff)
+			}
+
+			unstagedOnly := NewClientWithBackend(DiffOptions{Files: []string{"other.go", "missing.go"}}, kind)
+			if hasChanges, err := unstagedOnly.HasStagedChanges(); err != nil || hasChanges {
+				t.Errorf("expected no staged changes among unstaged files, got %v, %v", hasChanges, err)
+			}
+		})
+	}
+}
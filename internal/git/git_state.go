@@ -19,6 +19,10 @@ const (
 	StateRebase
 	// StateCherryPick indicates a cherry-pick is in progress
 	StateCherryPick
+	// StateSquash indicates a squash merge is waiting to be committed
+	StateSquash
+	// StateBisect indicates a git bisect is in progress
+	StateBisect
 )
 
 // String returns the string representation of GitStateType
@@ -32,6 +36,10 @@ func (s GitStateType) String() string {
 		return "rebase"
 	case StateCherryPick:
 		return "cherry-pick"
+	case StateSquash:
+		return "squash"
+	case StateBisect:
+		return "bisect"
 	default:
 		return "unknown"
 	}
@@ -45,15 +53,18 @@ type GitState struct {
 	OriginalMessage string
 	// ConflictMode indicates if there are conflicts to resolve
 	ConflictMode bool
+	// InitialCommit indicates HEAD is an unborn branch, so the commit to be
+	// made is the first of the repository
+	InitialCommit bool
 }
 
-// DetectGitState detects the current git state by inspecting the .git directory
-func DetectGitState(repoRoot string) (*GitState, error) {
-	gitDir := filepath.Join(repoRoot, ".git")
-
-	// Check if .git exists
-	if _, err := os.Stat(gitDir); os.IsNotExist(err) {
-		return nil, fmt.Errorf("not a git repository: %s", repoRoot)
+// DetectGitState detects the current git state by inspecting the .git
+// directory. commentChar is the core.commentChar setting, which marks the
+// comment lines of the messages git left there.
+func DetectGitState(repoRoot, commentChar string) (*GitState, error) {
+	gitDir, err := resolveGitDir(repoRoot)
+	if err != nil {
+		return nil, err
 	}
 
 	state := &GitState{
@@ -70,7 +81,7 @@ func DetectGitState(repoRoot string) (*GitState, error) {
 		// Read merge message
 		mergeMsgPath := filepath.Join(gitDir, "MERGE_MSG")
 		if content, err := os.ReadFile(mergeMsgPath); err == nil {
-			state.OriginalMessage = filterCommentLines(strings.TrimSpace(string(content)))
+			state.OriginalMessage = filterCommentLines(strings.TrimSpace(string(content)), FileCommentChar(commentChar, string(content)))
 		}
 		return state, nil
 	}
@@ -84,7 +95,7 @@ func DetectGitState(repoRoot string) (*GitState, error) {
 		// Read cherry-pick message from COMMIT_EDITMSG
 		commitEditMsgPath := filepath.Join(gitDir, "COMMIT_EDITMSG")
 		if content, err := os.ReadFile(commitEditMsgPath); err == nil {
-			state.OriginalMessage = filterCommentLines(strings.TrimSpace(string(content)))
+			state.OriginalMessage = filterCommentLines(strings.TrimSpace(string(content)), FileCommentChar(commentChar, string(content)))
 		}
 		return state, nil
 	}
@@ -117,17 +128,106 @@ func DetectGitState(repoRoot string) (*GitState, error) {
 		return state, nil
 	}
 
+	// Check for a squash merge. git merge --squash leaves no MERGE_HEAD,
+	// only SQUASH_MSG with the messages of the squashed commits.
+	squashMsgPath := filepath.Join(gitDir, "SQUASH_MSG")
+	if content, err := os.ReadFile(squashMsgPath); err == nil {
+		state.Type = StateSquash
+		state.OriginalMessage = strings.TrimSpace(string(content))
+		return state, nil
+	}
+
+	// Check for a bisect. It is checked last: a merge or cherry-pick made
+	// while bisecting is what the commit is about.
+	for _, name := range []string{"BISECT_LOG", "BISECT_START"} {
+		if _, err := os.Stat(filepath.Join(gitDir, name)); err != nil {
+			continue
+		}
+		state.Type = StateBisect
+		// BISECT_START holds the branch or commit the bisect started from
+		if content, err := os.ReadFile(filepath.Join(gitDir, "BISECT_START")); err == nil {
+			if start := strings.TrimSpace(string(content)); start != "" {
+				state.OriginalMessage = fmt.Sprintf("Bisect started from: %s", start)
+			}
+		}
+		return state, nil
+	}
+
 	// Normal state
 	return state, nil
 }
 
-// filterCommentLines removes git comment lines (starting with #) from a message
-func filterCommentLines(message string) string {
+// GetGitDir returns the git directory of the current worktree, which is not
+// <root>/.git in a linked worktree or a submodule
+func (c *ClientImpl) GetGitDir() (string, error) {
+	repoRoot, err := c.GetRepoRoot()
+	if err != nil {
+		return "", fmt.Errorf("failed to get repository root: %w", err)
+	}
+	return resolveGitDir(repoRoot)
+}
+
+// resolveGitDir returns the git directory of the worktree at repoRoot. In a
+// linked worktree or a submodule .git is a file holding "gitdir: <path>",
+// and the merge and rebase state lives in the directory it points to.
+func resolveGitDir(repoRoot string) (string, error) {
+	dotGit := filepath.Join(repoRoot, ".git")
+	info, err := os.Stat(dotGit)
+	if os.IsNotExist(err) {
+		return "", fmt.Errorf("not a git repository: %s", repoRoot)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to stat %s: %w", dotGit, err)
+	}
+	if info.IsDir() {
+		return dotGit, nil
+	}
+
+	content, err := os.ReadFile(dotGit)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", dotGit, err)
+	}
+	line := strings.TrimSpace(strings.SplitN(string(content), "\n", 2)[0])
+	gitDir, ok := strings.CutPrefix(line, "gitdir:")
+	if !ok || strings.TrimSpace(gitDir) == "" {
+		return "", fmt.Errorf("invalid .git file %s: expected \"gitdir: <path>\"", dotGit)
+	}
+	gitDir = strings.TrimSpace(gitDir)
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(repoRoot, gitDir)
+	}
+	if _, err := os.Stat(gitDir); err != nil {
+		return "", fmt.Errorf("git directory %s from %s: %w", gitDir, dotGit, err)
+	}
+	return gitDir, nil
+}
+
+// resolveCommonDir returns the directory shared by every worktree of the
+// repository whose git directory is gitDir: the one its commondir file
+// names in a linked worktree, and gitDir itself otherwise
+func resolveCommonDir(gitDir string) (string, error) {
+	content, err := os.ReadFile(filepath.Join(gitDir, "commondir"))
+	if os.IsNotExist(err) {
+		return gitDir, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read the commondir of %s: %w", gitDir, err)
+	}
+	commonDir := strings.TrimSpace(string(content))
+	if !filepath.IsAbs(commonDir) {
+		commonDir = filepath.Join(gitDir, commonDir)
+	}
+	return filepath.Clean(commonDir), nil
+}
+
+// filterCommentLines removes git comment lines (starting with commentChar)
+// and blank lines from a message
+func filterCommentLines(message, commentChar string) string {
 	lines := strings.Split(message, "\n")
 	var filtered []string
 	for _, line := range lines {
 		trimmed := strings.TrimSpace(line)
-		if !strings.HasPrefix(trimmed, "#") && trimmed != "" {
+		if !strings.HasPrefix(trimmed, commentChar) && trimmed != "" {
 			filtered = append(filtered, line)
 		}
 	}
@@ -87,6 +87,88 @@ func TestDetectGitState(t *testing.T) {
 			expectedMsgContains: "added new endpoint",
 			wantErr:             false,
 		},
+		{
+			name: "Squash state - SQUASH_MSG exists",
+			setupFunc: func(t *testing.T) string {
+				tmpDir := t.TempDir()
+				gitDir := filepath.Join(tmpDir, ".git")
+				if err := os.Mkdir(gitDir, 0755); err != nil {
+					t.Fatalf("failed to create .git dir: %v", err)
+				}
+
+				// Create SQUASH_MSG as git merge --squash writes it
+				squashMsg := "Squashed commit of the following:\n\ncommit 1a2b3c\nAuthor: Test User <test@example.com>\nDate:   Thu Jan 1 00:00:00 2026 +0000\n\n    feat(auth): added login form\n"
+				if err := os.WriteFile(filepath.Join(gitDir, "SQUASH_MSG"), []byte(squashMsg), 0644); err != nil {
+					t.Fatalf("failed to create SQUASH_MSG: %v", err)
+				}
+
+				return tmpDir
+			},
+			expectedType:        StateSquash,
+			expectedConflict:    false,
+			expectedMsgContains: "    feat(auth): added login form",
+			wantErr:             false,
+		},
+		{
+			name: "Bisect state - BISECT_START exists",
+			setupFunc: func(t *testing.T) string {
+				tmpDir := t.TempDir()
+				gitDir := filepath.Join(tmpDir, ".git")
+				if err := os.Mkdir(gitDir, 0755); err != nil {
+					t.Fatalf("failed to create .git dir: %v", err)
+				}
+
+				// git bisect start writes the branch it started from
+				if err := os.WriteFile(filepath.Join(gitDir, "BISECT_START"), []byte("main\n"), 0644); err != nil {
+					t.Fatalf("failed to create BISECT_START: %v", err)
+				}
+				if err := os.WriteFile(filepath.Join(gitDir, "BISECT_LOG"), []byte("git bisect start\n"), 0644); err != nil {
+					t.Fatalf("failed to create BISECT_LOG: %v", err)
+				}
+
+				return tmpDir
+			},
+			expectedType:        StateBisect,
+			expectedConflict:    false,
+			expectedMsgContains: "Bisect started from: main",
+			wantErr:             false,
+		},
+		{
+			name: "Bisect state - only BISECT_LOG exists",
+			setupFunc: func(t *testing.T) string {
+				tmpDir := t.TempDir()
+				gitDir := filepath.Join(tmpDir, ".git")
+				if err := os.Mkdir(gitDir, 0755); err != nil {
+					t.Fatalf("failed to create .git dir: %v", err)
+				}
+				if err := os.WriteFile(filepath.Join(gitDir, "BISECT_LOG"), []byte("git bisect start\n"), 0644); err != nil {
+					t.Fatalf("failed to create BISECT_LOG: %v", err)
+				}
+				return tmpDir
+			},
+			expectedType:     StateBisect,
+			expectedConflict: false,
+			wantErr:          false,
+		},
+		{
+			name: "Cherry-pick during a bisect is a cherry-pick",
+			setupFunc: func(t *testing.T) string {
+				tmpDir := t.TempDir()
+				gitDir := filepath.Join(tmpDir, ".git")
+				if err := os.Mkdir(gitDir, 0755); err != nil {
+					t.Fatalf("failed to create .git dir: %v", err)
+				}
+				for _, name := range []string{"BISECT_START", "CHERRY_PICK_HEAD"} {
+					if err := os.WriteFile(filepath.Join(gitDir, name), []byte("main\n"), 0644); err != nil {
+						t.Fatalf("failed to create %s: %v", name, err)
+					}
+				}
+				return tmpDir
+			},
+			expectedType:     StateCherryPick,
+			expectedConflict: true,
+			wantErr:          false,
+		},
 		{
 			name: "Rebase state - rebase-merge exists",
 			setupFunc: func(t *testing.T) string {
@@ -143,6 +225,64 @@ func TestDetectGitState(t *testing.T) {
 			expectedMsgContains: "develop",
 			wantErr:             false,
 		},
+		{
+			name: "Merge state - linked worktree .git file",
+			setupFunc: func(t *testing.T) string {
+				tmpDir := t.TempDir()
+				gitDir := filepath.Join(t.TempDir(), "worktrees", "feature")
+				if err := os.MkdirAll(gitDir, 0755); err != nil {
+					t.Fatalf("failed to create git dir: %v", err)
+				}
+				if err := os.WriteFile(filepath.Join(tmpDir, ".git"), []byte("gitdir: "+gitDir+"\n"), 0644); err != nil {
+					t.Fatalf("failed to create .git file: %v", err)
+				}
+
+				if err := os.WriteFile(filepath.Join(gitDir, "MERGE_HEAD"), []byte("abc123\n"), 0644); err != nil {
+					t.Fatalf("failed to create MERGE_HEAD: %v", err)
+				}
+				if err := os.WriteFile(filepath.Join(gitDir, "MERGE_MSG"), []byte("Merge branch 'feature-y'"), 0644); err != nil {
+					t.Fatalf("failed to create MERGE_MSG: %v", err)
+				}
+
+				return tmpDir
+			},
+			expectedType:        StateMerge,
+			expectedConflict:    true,
+			expectedMsgContains: "feature-y",
+			wantErr:             false,
+		},
+		{
+			name: "Rebase state - relative gitdir pointer",
+			setupFunc: func(t *testing.T) string {
+				tmpDir := t.TempDir()
+				repoDir := filepath.Join(tmpDir, "sub")
+				gitDir := filepath.Join(tmpDir, "modules", "sub")
+				if err := os.MkdirAll(filepath.Join(gitDir, "rebase-merge"), 0755); err != nil {
+					t.Fatalf("failed to create rebase-merge dir: %v", err)
+				}
+				if err := os.Mkdir(repoDir, 0755); err != nil {
+					t.Fatalf("failed to create worktree dir: %v", err)
+				}
+				if err := os.WriteFile(filepath.Join(repoDir, ".git"), []byte("gitdir: ../modules/sub\n"), 0644); err != nil {
+					t.Fatalf("failed to create .git file: %v", err)
+				}
+				return repoDir
+			},
+			expectedType:     StateRebase,
+			expectedConflict: true,
+			wantErr:          false,
+		},
+		{
+			name: "Error - .git file without a gitdir",
+			setupFunc: func(t *testing.T) string {
+				tmpDir := t.TempDir()
+				if err := os.WriteFile(filepath.Join(tmpDir, ".git"), []byte("not a pointer\n"), 0644); err != nil {
+					t.Fatalf("failed to create .git file: %v", err)
+				}
+				return tmpDir
+			},
+			wantErr: true,
+		},
 		{
 			name: "Error - .git does not exist",
 			setupFunc: func(t *testing.T) string {
@@ -156,7 +296,7 @@ func TestDetectGitState(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			repoPath := tt.setupFunc(t)
 
-			state, err := DetectGitState(repoPath)
+			state, err := DetectGitState(repoPath, DefaultCommentChar)
 
 			if tt.wantErr {
 				if err == nil {
@@ -197,6 +337,8 @@ func TestGitStateType_String(t *testing.T) {
 		{StateMerge, "merge"},
 		{StateRebase, "rebase"},
 		{StateCherryPick, "cherry-pick"},
+		{StateSquash, "squash"},
+		{StateBisect, "bisect"},
 		{GitStateType(999), "unknown"},
 	}
 
@@ -0,0 +1,134 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-git/go-git/v5/utils/diff"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// DefaultContextLines is the number of unchanged lines shown around each
+// hunk, matching git's default
+const DefaultContextLines = 3
+
+// lineOp is a single line of a line-level diff: ' ' unchanged, '-' removed
+// or '+' added
+type lineOp struct {
+	kind byte
+	text string
+}
+
+// unifiedHunks renders the changes from oldContent to newContent as unified
+// diff hunks with the given number of context lines around each change
+func unifiedHunks(oldContent, newContent string, context int) string {
+	var sb strings.Builder
+	for _, hunk := range diffHunks(oldContent, newContent, context) {
+		hunk.writeTo(&sb)
+	}
+	return sb.String()
+}
+
+// diffHunks returns the changes from oldContent to newContent as hunks with
+// the given number of context lines around each change
+func diffHunks(oldContent, newContent string, context int) []Hunk {
+	var ops []lineOp
+	for _, d := range diff.Do(oldContent, newContent) {
+		kind := byte(' ')
+		switch d.Type {
+		case diffmatchpatch.DiffDelete:
+			kind = '-'
+		case diffmatchpatch.DiffInsert:
+			kind = '+'
+		}
+		for _, line := range splitLines(d.Text) {
+			ops = append(ops, lineOp{kind: kind, text: line})
+		}
+	}
+
+	var hunks []Hunk
+	oldLine, newLine := 0, 0 // lines consumed before ops[i]
+	i := 0
+	for i < len(ops) {
+		// Find the next change
+		start := i
+		for start < len(ops) && ops[start].kind == ' ' {
+			start++
+		}
+		if start == len(ops) {
+			break
+		}
+
+		// Extend the hunk while the next change is close enough that the
+		// context of both would touch
+		end := start
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			next := end
+			for next < len(ops) && ops[next].kind == ' ' {
+				next++
+			}
+			if next == len(ops) || next-end > 2*context {
+				break
+			}
+			end = next
+		}
+
+		from := max(start-context, i)
+		to := min(end+context, len(ops))
+
+		// Advance the line counters over the unchanged lines skipped so far
+		for ; i < from; i++ {
+			oldLine++
+			newLine++
+		}
+
+		hunk := Hunk{Lines: make([]string, 0, to-from)}
+		for _, op := range ops[from:to] {
+			if op.kind != '+' {
+				hunk.OldLines++
+			}
+			if op.kind != '-' {
+				hunk.NewLines++
+			}
+			hunk.Lines = append(hunk.Lines, string(op.kind)+op.text)
+		}
+		hunk.OldStart = hunkStart(oldLine, hunk.OldLines)
+		hunk.NewStart = hunkStart(newLine, hunk.NewLines)
+		hunks = append(hunks, hunk)
+
+		oldLine += hunk.OldLines
+		newLine += hunk.NewLines
+		i = to
+	}
+	return hunks
+}
+
+// hunkStart returns the first line of one side of a hunk. before is the
+// number of lines preceding the hunk; git numbers an empty range by the
+// line before it.
+func hunkStart(before, count int) int {
+	if count == 0 {
+		return before
+	}
+	return before + 1
+}
+
+// hunkRange formats one side of a hunk header from its start and count
+func hunkRange(start, count int) string {
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// splitLines splits text into lines without their line endings
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
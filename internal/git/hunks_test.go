@@ -0,0 +1,96 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// numberedLines returns "line 1\n" ... "line n\n"
+func numberedLines(n int) []string {
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line %d", i+1)
+	}
+	return lines
+}
+
+func TestUnifiedHunks(t *testing.T) {
+	old := numberedLines(20)
+	changed := append([]string{}, old...)
+	changed[9] = "line 10 changed"
+
+	twoChanges := append([]string{}, changed...)
+	twoChanges[15] = "line 16 changed"
+
+	join := func(lines []string) string { return strings.Join(lines, "\n") + "\n" }
+
+	tests := []struct {
+		name     string
+		old, new string
+		context  int
+		expected string
+	}{
+		{
+			name:     "Zero context shows only the change",
+			old:      join(old),
+			new:      join(changed),
+			context:  0,
+			expected: "@@ -10 +10 @@\n-line 10\n+line 10 changed\n",
+		},
+		{
+			name:    "Three context lines like git",
+			old:     join(old),
+			new:     join(changed),
+			context: 3,
+			expected: "@@ -7,7 +7,7 @@\n line 7\n line 8\n line 9\n" +
+				"-line 10\n+line 10 changed\n line 11\n line 12\n line 13\n",
+		},
+		{
+			name:    "Distant changes make separate hunks at zero context",
+			old:     join(old),
+			new:     join(twoChanges),
+			context: 0,
+			expected: "@@ -10 +10 @@\n-line 10\n+line 10 changed\n" +
+				"@@ -16 +16 @@\n-line 16\n+line 16 changed\n",
+		},
+		{
+			name:    "Nearby changes share a hunk at three context lines",
+			old:     join(old),
+			new:     join(twoChanges),
+			context: 3,
+			expected: "@@ -7,13 +7,13 @@\n line 7\n line 8\n line 9\n" +
+				"-line 10\n+line 10 changed\n line 11\n line 12\n line 13\n line 14\n line 15\n" +
+				"-line 16\n+line 16 changed\n line 17\n line 18\n line 19\n",
+		},
+		{
+			name:     "Insertion at the start",
+			old:      "b\nc\n",
+			new:      "a\nb\nc\n",
+			context:  0,
+			expected: "@@ -0,0 +1 @@\n+a\n",
+		},
+		{
+			name:     "Context is clipped at the file edges",
+			old:      "a\nb\n",
+			new:      "a\nB\n",
+			context:  3,
+			expected: "@@ -1,2 +1,2 @@\n a\n-b\n+B\n",
+		},
+		{
+			name:     "No changes",
+			old:      "a\n",
+			new:      "a\n",
+			context:  3,
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := unifiedHunks(tt.old, tt.new, tt.context); got != tt.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.expected, got)
+			}
+		})
+	}
+}
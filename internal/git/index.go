@@ -0,0 +1,259 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/format/index"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// IndexSnapshot records the index and HEAD so they can be restored after
+// committing staged changes in several steps
+type IndexSnapshot struct {
+	head    plumbing.Hash
+	entries []index.Entry
+	version uint32
+}
+
+// Unborn reports whether HEAD had no commit when the snapshot was taken
+func (s *IndexSnapshot) Unborn() bool {
+	return s.head.IsZero()
+}
+
+// Paths returns the paths in the snapshot's index, sorted
+func (s *IndexSnapshot) Paths() []string {
+	paths := make([]string, 0, len(s.entries))
+	for _, e := range s.entries {
+		paths = append(paths, e.Name)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// SnapshotIndex records the current index and HEAD commit
+func (c *ClientImpl) SnapshotIndex() (*IndexSnapshot, error) {
+	repo, err := c.openRepo()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index: %w", err)
+	}
+	for _, e := range idx.Entries {
+		// Resolved entries are stage 0; conflicts have one entry per side
+		if e.Stage != 0 {
+			return nil, errors.New("the index has unresolved conflicts")
+		}
+	}
+
+	snapshot := &IndexSnapshot{version: idx.Version}
+	for _, e := range idx.Entries {
+		snapshot.entries = append(snapshot.entries, *e)
+	}
+
+	head, err := repo.Head()
+	switch {
+	case errors.Is(err, plumbing.ErrReferenceNotFound):
+		// Unborn branch: no commits yet, if git agrees
+		if err := c.confirmUnborn(err); err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, fmt.Errorf("failed to resolve HEAD: %w", err)
+	default:
+		snapshot.head = head.Hash()
+	}
+	return snapshot, nil
+}
+
+// StageOnly replaces the index with the HEAD tree plus the snapshot's staged
+// state of paths, so the next commit contains exactly those paths. A path
+// missing from the snapshot is staged as deleted.
+func (c *ClientImpl) StageOnly(snapshot *IndexSnapshot, paths []string) error {
+	repo, err := c.openRepo()
+	if err != nil {
+		return fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	entries, err := c.headEntries(repo)
+	if err != nil {
+		return err
+	}
+	staged := make(map[string]index.Entry, len(snapshot.entries))
+	for _, e := range snapshot.entries {
+		staged[e.Name] = e
+	}
+	for _, p := range paths {
+		if e, ok := staged[p]; ok {
+			entries[p] = e
+		} else {
+			delete(entries, p)
+		}
+	}
+
+	return writeIndex(repo, snapshot.version, entries)
+}
+
+// RestoreIndex puts the snapshot's index back. Paths committed since the
+// snapshot match HEAD again, and any others are staged as before.
+func (c *ClientImpl) RestoreIndex(snapshot *IndexSnapshot) error {
+	repo, err := c.openRepo()
+	if err != nil {
+		return fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	entries := make(map[string]index.Entry, len(snapshot.entries))
+	for _, e := range snapshot.entries {
+		entries[e.Name] = e
+	}
+	return writeIndex(repo, snapshot.version, entries)
+}
+
+// ResetToSnapshot moves the current branch (or a detached HEAD) back to the
+// snapshot's commit and restores its index, undoing commits made since.
+// The working tree is not touched.
+func (c *ClientImpl) ResetToSnapshot(snapshot *IndexSnapshot) error {
+	repo, err := c.openRepo()
+	if err != nil {
+		return fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	head, err := repo.Storer.Reference(plumbing.HEAD)
+	if err != nil {
+		return fmt.Errorf("failed to read HEAD: %w", err)
+	}
+	name := plumbing.HEAD
+	if head.Type() == plumbing.SymbolicReference {
+		name = head.Target()
+	}
+
+	if snapshot.head.IsZero() {
+		// The branch did not exist yet
+		if err := repo.Storer.RemoveReference(name); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", name, err)
+		}
+	} else if err := repo.Storer.SetReference(plumbing.NewHashReference(name, snapshot.head)); err != nil {
+		return fmt.Errorf("failed to reset %s: %w", name, err)
+	}
+
+	return c.RestoreIndex(snapshot)
+}
+
+// HeadChangedPaths returns the paths the HEAD commit changed against its
+// first parent, sorted. For a root commit that is every path in its tree.
+func (c *ClientImpl) HeadChangedPaths() ([]string, error) {
+	repo, err := c.openRepo()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve HEAD: %w", err)
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read HEAD commit: %w", err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read HEAD tree: %w", err)
+	}
+	var parentTree *object.Tree
+	if commit.NumParents() > 0 {
+		parent, err := commit.Parent(0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the parent of HEAD: %w", err)
+		}
+		if parentTree, err = parent.Tree(); err != nil {
+			return nil, fmt.Errorf("failed to read the parent tree of HEAD: %w", err)
+		}
+	}
+
+	changes, err := object.DiffTree(parentTree, tree)
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff HEAD: %w", err)
+	}
+	seen := make(map[string]bool)
+	var paths []string
+	for _, change := range changes {
+		for _, name := range []string{change.From.Name, change.To.Name} {
+			if name != "" && !seen[name] {
+				seen[name] = true
+				paths = append(paths, name)
+			}
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// headEntries returns index entries for every file in the HEAD tree. An
+// unborn branch has none, but only when the git binary agrees it has no
+// commits.
+func (c *ClientImpl) headEntries(repo *git.Repository) (map[string]index.Entry, error) {
+	entries := make(map[string]index.Entry)
+
+	head, err := repo.Head()
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		if err := c.confirmUnborn(err); err != nil {
+			return nil, err
+		}
+		return entries, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve HEAD: %w", err)
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read HEAD commit: %w", err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read HEAD tree: %w", err)
+	}
+
+	walker := object.NewTreeWalker(tree, true, nil)
+	defer walker.Close()
+	for {
+		name, entry, err := walker.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to walk HEAD tree: %w", err)
+		}
+		if entry.Mode == filemode.Dir {
+			continue
+		}
+		entries[name] = index.Entry{Name: name, Hash: entry.Hash, Mode: entry.Mode}
+	}
+	return entries, nil
+}
+
+// writeIndex stores entries as the repository index, sorted by path
+func writeIndex(repo *git.Repository, version uint32, entries map[string]index.Entry) error {
+	idx := &index.Index{Version: version}
+	if idx.Version == 0 {
+		idx.Version = 2
+	}
+	for _, e := range entries {
+		e := e
+		idx.Entries = append(idx.Entries, &e)
+	}
+	sort.Slice(idx.Entries, func(i, j int) bool {
+		return idx.Entries[i].Name < idx.Entries[j].Name
+	})
+
+	if err := repo.Storer.SetIndex(idx); err != nil {
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	return nil
+}
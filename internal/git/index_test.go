@@ -0,0 +1,265 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// newIndexTestRepo creates a repository in a temp dir, changes into it and
+// returns it with a client. If initial is set it is committed first.
+func newIndexTestRepo(t *testing.T, initial map[string]string) (*git.Repository, Client) {
+	t.Helper()
+	tempDir := t.TempDir()
+	originalWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get WD: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(originalWd) })
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatalf("failed to change to temp dir: %v", err)
+	}
+	t.Setenv("GIT_AUTHOR_NAME", "Test User")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+
+	repo, err := git.PlainInit(tempDir, false)
+	if err != nil {
+		t.Fatalf("failed to git init: %v", err)
+	}
+	client := NewClient()
+	if len(initial) > 0 {
+		stageFiles(t, repo, initial)
+		if err := client.CommitWithMessage("initial"); err != nil {
+			t.Fatalf("failed to commit: %v", err)
+		}
+	}
+	return repo, client
+}
+
+func stageFiles(t *testing.T, repo *git.Repository, files map[string]string) {
+	t.Helper()
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("failed to get worktree: %v", err)
+	}
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+		if _, err := worktree.Add(name); err != nil {
+			t.Fatalf("failed to add %s: %v", name, err)
+		}
+	}
+}
+
+func stagedPaths(t *testing.T, client Client) []string {
+	t.Helper()
+	files, err := client.GetStagedFiles()
+	if err != nil {
+		t.Fatalf("GetStagedFiles failed: %v", err)
+	}
+	var paths []string
+	for _, f := range files {
+		paths = append(paths, f.Path+":"+string(f.Change))
+	}
+	return paths
+}
+
+func headFiles(t *testing.T, repo *git.Repository) map[string]bool {
+	t.Helper()
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatalf("failed to resolve HEAD: %v", err)
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		t.Fatalf("failed to read commit: %v", err)
+	}
+	files, err := commit.Files()
+	if err != nil {
+		t.Fatalf("failed to list files: %v", err)
+	}
+	names := make(map[string]bool)
+	files.ForEach(func(f *object.File) error {
+		names[f.Name] = true
+		return nil
+	})
+	return names
+}
+
+func TestClientImpl_StageOnly(t *testing.T) {
+	repo, client := newIndexTestRepo(t, map[string]string{"a.txt": "one\n", "old.txt": "old\n"})
+	stageFiles(t, repo, map[string]string{"a.txt": "two\n", "b.txt": "new\n", "pkg/c.go": "package pkg\n"})
+	worktree, _ := repo.Worktree()
+	if _, err := worktree.Remove("old.txt"); err != nil {
+		t.Fatalf("failed to remove old.txt: %v", err)
+	}
+
+	snapshot, err := client.SnapshotIndex()
+	if err != nil {
+		t.Fatalf("SnapshotIndex failed: %v", err)
+	}
+
+	// First group: a modification and a deletion
+	if err := client.StageOnly(snapshot, []string{"a.txt", "old.txt"}); err != nil {
+		t.Fatalf("StageOnly failed: %v", err)
+	}
+	if got, want := stagedPaths(t, client), []string{"a.txt:M", "old.txt:D"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected staged %v, got %v", want, got)
+	}
+	if err := client.CommitWithMessage("first group"); err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+
+	// Second group
+	if err := client.StageOnly(snapshot, []string{"b.txt"}); err != nil {
+		t.Fatalf("StageOnly failed: %v", err)
+	}
+	if got, want := stagedPaths(t, client), []string{"b.txt:A"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected staged %v, got %v", want, got)
+	}
+	if err := client.CommitWithMessage("second group"); err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+	if files := headFiles(t, repo); !files["a.txt"] || !files["b.txt"] || files["old.txt"] || files["pkg/c.go"] {
+		t.Errorf("unexpected files in HEAD: %v", files)
+	}
+
+	// Restoring keeps the ungrouped file staged
+	if err := client.RestoreIndex(snapshot); err != nil {
+		t.Fatalf("RestoreIndex failed: %v", err)
+	}
+	if got, want := stagedPaths(t, client), []string{"pkg/c.go:A"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected staged %v after restore, got %v", want, got)
+	}
+}
+
+func TestClientImpl_StageOnly_LinkedWorktree(t *testing.T) {
+	requireGit(t)
+	newIndexTestRepo(t, map[string]string{"a.txt": "one\n", "b.txt": "one\n", "d/c.txt": "one\n"})
+	addLinkedWorktree(t)
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if err := os.WriteFile(name, []byte("two\n"), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+	gitOutput(t, "add", "a.txt", "b.txt")
+	client := NewClient()
+
+	snapshot, err := client.SnapshotIndex()
+	if err != nil {
+		t.Fatalf("SnapshotIndex failed: %v", err)
+	}
+	if err := client.StageOnly(snapshot, []string{"a.txt"}); err != nil {
+		t.Fatalf("StageOnly failed: %v", err)
+	}
+	if err := client.CommitWithMessage("first group"); err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+
+	// Only the group is committed; the rest of the tree stays as it was
+	if changed := gitOutput(t, "show", "--name-status", "--format=", "HEAD"); changed != "M\ta.txt" {
+		t.Errorf("expected the commit to modify only a.txt, got %q", changed)
+	}
+	if err := client.RestoreIndex(snapshot); err != nil {
+		t.Fatalf("RestoreIndex failed: %v", err)
+	}
+	if staged := gitOutput(t, "diff", "--cached", "--name-status"); staged != "M\tb.txt" {
+		t.Errorf("expected only b.txt staged after restore, got %q", staged)
+	}
+}
+
+func TestClientImpl_StageOnly_UnresolvedHead(t *testing.T) {
+	requireGit(t)
+	newIndexTestRepo(t, map[string]string{"a.txt": "one\n", "b.txt": "one\n"})
+	linked := addLinkedWorktree(t)
+	if err := os.WriteFile("a.txt", []byte("two\n"), 0644); err != nil {
+		t.Fatalf("failed to write a.txt: %v", err)
+	}
+	gitOutput(t, "add", "a.txt")
+
+	// Opened without its common dir, the linked worktree has no HEAD as far
+	// as go-git can tell, although the branch has commits
+	repo, err := git.PlainOpenWithOptions(linked, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		t.Fatalf("failed to open the worktree: %v", err)
+	}
+	client := &ClientImpl{repo: repo, repoPath: linked, kind: BackendGoGit}
+
+	if _, err := client.SnapshotIndex(); err == nil || !strings.Contains(err.Error(), "repository has commits") {
+		t.Errorf("expected SnapshotIndex to refuse an unresolved HEAD, got %v", err)
+	}
+	if err := client.StageOnly(&IndexSnapshot{}, []string{"a.txt"}); err == nil || !strings.Contains(err.Error(), "repository has commits") {
+		t.Errorf("expected StageOnly to refuse an unresolved HEAD, got %v", err)
+	}
+	if staged := gitOutput(t, "diff", "--cached", "--name-status"); staged != "M\ta.txt" {
+		t.Errorf("expected the index untouched, got %q", staged)
+	}
+}
+
+func TestClientImpl_ResetToSnapshot(t *testing.T) {
+	repo, client := newIndexTestRepo(t, map[string]string{"a.txt": "one\n"})
+	stageFiles(t, repo, map[string]string{"a.txt": "two\n", "b.txt": "new\n"})
+	before, _ := repo.Head()
+
+	snapshot, err := client.SnapshotIndex()
+	if err != nil {
+		t.Fatalf("SnapshotIndex failed: %v", err)
+	}
+	if err := client.StageOnly(snapshot, []string{"a.txt"}); err != nil {
+		t.Fatalf("StageOnly failed: %v", err)
+	}
+	if err := client.CommitWithMessage("partial"); err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+
+	if err := client.ResetToSnapshot(snapshot); err != nil {
+		t.Fatalf("ResetToSnapshot failed: %v", err)
+	}
+	after, _ := repo.Head()
+	if after.Hash() != before.Hash() || after.Name() != before.Name() {
+		t.Errorf("expected HEAD %s back on %s, got %s on %s", before.Hash(), before.Name(), after.Hash(), after.Name())
+	}
+	if got, want := stagedPaths(t, client), []string{"a.txt:M", "b.txt:A"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected staged %v after reset, got %v", want, got)
+	}
+}
+
+func TestClientImpl_ResetToSnapshot_UnbornBranch(t *testing.T) {
+	repo, client := newIndexTestRepo(t, nil)
+	stageFiles(t, repo, map[string]string{"a.txt": "one\n", "b.txt": "two\n"})
+
+	snapshot, err := client.SnapshotIndex()
+	if err != nil {
+		t.Fatalf("SnapshotIndex failed: %v", err)
+	}
+	if err := client.StageOnly(snapshot, []string{"a.txt"}); err != nil {
+		t.Fatalf("StageOnly failed: %v", err)
+	}
+	if got, want := stagedPaths(t, client), []string{"a.txt:A"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected staged %v, got %v", want, got)
+	}
+	if err := client.CommitWithMessage("first"); err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+
+	if err := client.ResetToSnapshot(snapshot); err != nil {
+		t.Fatalf("ResetToSnapshot failed: %v", err)
+	}
+	if _, err := repo.Head(); !errors.Is(err, plumbing.ErrReferenceNotFound) {
+		t.Errorf("expected the branch to be unborn again, got %v", err)
+	}
+	if got, want := stagedPaths(t, client), []string{"a.txt:A", "b.txt:A"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected staged %v after reset, got %v", want, got)
+	}
+}
@@ -0,0 +1,116 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+)
+
+// LogCommit is one commit of a range
+type LogCommit struct {
+	Hash    string
+	Message string
+	When    time.Time
+	// AuthorEmail identifies the author when counting contributors
+	AuthorEmail string
+	// Merge is set for commits with more than one parent
+	Merge bool
+}
+
+// Subject returns the first line of the message
+func (c LogCommit) Subject() string {
+	return strings.TrimSpace(strings.SplitN(c.Message, "\n", 2)[0])
+}
+
+// SplitRange splits "from..to" into its ends. An empty end means HEAD.
+func SplitRange(revRange string) (string, string, error) {
+	from, to, ok := strings.Cut(revRange, "..")
+	if !ok || strings.HasPrefix(to, ".") {
+		return "", "", fmt.Errorf("invalid range %q: expected <from>..<to>, e.g. v1.2.0..HEAD", revRange)
+	}
+	if from == "" {
+		return "", "", fmt.Errorf("invalid range %q: the start is required", revRange)
+	}
+	if to == "" {
+		to = "HEAD"
+	}
+	return from, to, nil
+}
+
+// GetCommitRange returns the commits reachable from the end of revRange but
+// not from its start, like git log from..to, newest first. A range ending at
+// an unborn HEAD has no commits.
+func (c *ClientImpl) GetCommitRange(revRange string) ([]LogCommit, error) {
+	from, to, err := SplitRange(revRange)
+	if err != nil {
+		return nil, err
+	}
+	repo, err := c.openRepo()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+	if to == "HEAD" {
+		unborn, err := c.headUnborn(repo)
+		if err != nil {
+			return nil, err
+		}
+		if unborn {
+			return nil, nil
+		}
+	}
+
+	resolve := func(rev string) (*object.Commit, error) {
+		hash, err := repo.ResolveRevision(plumbing.Revision(rev))
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %q: %w", rev, err)
+		}
+		commit, err := repo.CommitObject(*hash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get commit for %q: %w", rev, err)
+		}
+		return commit, nil
+	}
+	fromCommit, err := resolve(from)
+	if err != nil {
+		return nil, err
+	}
+	toCommit, err := resolve(to)
+	if err != nil {
+		return nil, err
+	}
+
+	excluded := make(map[plumbing.Hash]bool)
+	err = object.NewCommitPreorderIter(fromCommit, nil, nil).ForEach(func(commit *object.Commit) error {
+		excluded[commit.Hash] = true
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk %s: %w", from, err)
+	}
+
+	var commits []LogCommit
+	err = object.NewCommitPreorderIter(toCommit, excluded, nil).ForEach(func(commit *object.Commit) error {
+		commits = append(commits, LogCommit{
+			Hash:        commit.Hash.String(),
+			Message:     commit.Message,
+			When:        commit.Committer.When,
+			AuthorEmail: commit.Author.Email,
+			Merge:       commit.NumParents() > 1,
+		})
+		return nil
+	})
+	if err != nil && !errors.Is(err, storer.ErrStop) {
+		return nil, fmt.Errorf("failed to walk %s: %w", revRange, err)
+	}
+
+	sort.SliceStable(commits, func(i, j int) bool {
+		return commits[i].When.After(commits[j].When)
+	})
+	return commits, nil
+}
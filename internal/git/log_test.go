@@ -0,0 +1,105 @@
+package git
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// commitAt commits the index with message at a fixed time, so the order of
+// commits does not depend on how fast the test runs
+func commitAt(t *testing.T, repo *git.Repository, message string, minute int, parents ...plumbing.Hash) plumbing.Hash {
+	t.Helper()
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("failed to get worktree: %v", err)
+	}
+	hash, err := worktree.Commit(message, &git.CommitOptions{
+		Author:            &object.Signature{Name: "Test User", Email: "test@example.com", When: time.Date(2026, 1, 1, 0, minute, 0, 0, time.UTC)},
+		Parents:           parents,
+		AllowEmptyCommits: true,
+	})
+	if err != nil {
+		t.Fatalf("failed to commit %q: %v", message, err)
+	}
+	return hash
+}
+
+func TestClientImpl_GetCommitRange(t *testing.T) {
+	repo, client := newIndexTestRepo(t, nil)
+	base := commitAt(t, repo, "chore: initial commit", 0)
+	if _, err := repo.CreateTag("v1.0.0", base, nil); err != nil {
+		t.Fatalf("failed to tag: %v", err)
+	}
+	commitAt(t, repo, "feat(api): added users endpoint", 1)
+	fix := commitAt(t, repo, "fix: handled empty body\n\nBREAKING CHANGE: errors are JSON now", 2)
+	commitAt(t, repo, "Merge branch 'hotfix'", 3, fix, base)
+
+	tests := []struct {
+		name          string
+		revRange      string
+		expected      []string
+		expectedError string
+	}{
+		{
+			name:     "Tag to HEAD, newest first",
+			revRange: "v1.0.0..HEAD",
+			expected: []string{"Merge branch 'hotfix' (merge)", "fix: handled empty body", "feat(api): added users endpoint"},
+		},
+		{
+			name:     "Open end means HEAD",
+			revRange: "v1.0.0..",
+			expected: []string{"Merge branch 'hotfix' (merge)", "fix: handled empty body", "feat(api): added users endpoint"},
+		},
+		{
+			name:     "Only a merge commit",
+			revRange: fix.String() + "..HEAD",
+			expected: []string{"Merge branch 'hotfix' (merge)"},
+		},
+		{
+			name:     "Empty range",
+			revRange: "HEAD..HEAD",
+		},
+		{
+			name:          "Not a range",
+			revRange:      "v1.0.0",
+			expectedError: "expected <from>..<to>",
+		},
+		{
+			name:          "Unknown revision",
+			revRange:      "v9.9.9..HEAD",
+			expectedError: `failed to resolve "v9.9.9"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commits, err := client.GetCommitRange(tt.revRange)
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("expected error %q, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetCommitRange failed: %v", err)
+			}
+			var got []string
+			for _, c := range commits {
+				subject := c.Subject()
+				if c.Merge {
+					subject += " (merge)"
+				}
+				got = append(got, subject)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
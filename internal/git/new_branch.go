@@ -0,0 +1,76 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// ValidateBranchName checks name against git's reference name rules: no
+// spaces, "..", "@{", control characters or any of ~^:?*[\, and no
+// component that starts with a dot or ends with .lock
+func ValidateBranchName(name string) error {
+	if err := plumbing.NewBranchReferenceName(name).Validate(); err != nil {
+		return fmt.Errorf("%q is not a valid branch name", name)
+	}
+	return nil
+}
+
+// ListBranches returns the names of the local branches, sorted
+func (c *ClientImpl) ListBranches() ([]string, error) {
+	repo, err := c.openRepo()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+	refs, err := repo.Branches()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list branches: %w", err)
+	}
+
+	var branches []string
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		branches = append(branches, ref.Name().Short())
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list branches: %w", err)
+	}
+	sort.Strings(branches)
+	return branches, nil
+}
+
+// CreateBranch creates a branch at HEAD and switches to it, like git switch
+// -c. Since both point at the same commit, the index and working tree are
+// left as they are, staged changes included. On an unborn HEAD only HEAD is
+// moved; the first commit creates the branch.
+func (c *ClientImpl) CreateBranch(name string) error {
+	if err := ValidateBranchName(name); err != nil {
+		return err
+	}
+	repo, err := c.openRepo()
+	if err != nil {
+		return fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	ref := plumbing.NewBranchReferenceName(name)
+	if _, err := repo.Reference(ref, false); err == nil {
+		return fmt.Errorf("branch %s already exists", name)
+	}
+	head, err := repo.Head()
+	switch {
+	case errors.Is(err, plumbing.ErrReferenceNotFound):
+	case err != nil:
+		return fmt.Errorf("failed to get HEAD: %w", err)
+	default:
+		if err := repo.Storer.CheckAndSetReference(plumbing.NewHashReference(ref, head.Hash()), nil); err != nil {
+			return fmt.Errorf("failed to create branch %s: %w", name, err)
+		}
+	}
+
+	if err := repo.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, ref)); err != nil {
+		return fmt.Errorf("failed to switch to %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package git
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+func TestValidateBranchName(t *testing.T) {
+	tests := []struct {
+		name  string
+		valid bool
+	}{
+		{name: "feat/PROJ-42-add-login", valid: true},
+		{name: "fix/handle-expired-tokens", valid: true},
+		{name: "feat/add login"},
+		{name: "feat/add..login"},
+		{name: "feat/.hidden"},
+		{name: "feat/login.lock"},
+		{name: "feat//login"},
+		{name: "feat/login."},
+		{name: "feat/login@{1}"},
+		{name: "feat/login~1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateBranchName(tt.name)
+			if tt.valid && err != nil {
+				t.Errorf("expected %q to be valid, got %v", tt.name, err)
+			}
+			if !tt.valid && err == nil {
+				t.Errorf("expected %q to be rejected", tt.name)
+			}
+		})
+	}
+}
+
+func TestClientImpl_CreateBranch(t *testing.T) {
+	t.Run("Switches to a new branch and keeps the staged changes", func(t *testing.T) {
+		repo, client := newIndexTestRepo(t, map[string]string{"a.txt": "one\n"})
+		head, _ := repo.Head()
+		stageFiles(t, repo, map[string]string{"a.txt": "two\n"})
+
+		if err := client.CreateBranch("feat/add-login"); err != nil {
+			t.Fatalf("CreateBranch failed: %v", err)
+		}
+		newHead, err := repo.Head()
+		if err != nil {
+			t.Fatalf("failed to get HEAD: %v", err)
+		}
+		if newHead.Name() != plumbing.NewBranchReferenceName("feat/add-login") || newHead.Hash() != head.Hash() {
+			t.Errorf("expected HEAD on feat/add-login at %s, got %s at %s", head.Hash(), newHead.Name(), newHead.Hash())
+		}
+		if staged, _ := client.HasStagedChanges(); !staged {
+			t.Errorf("expected the staged change to survive the switch")
+		}
+
+		branches, err := client.ListBranches()
+		if err != nil {
+			t.Fatalf("ListBranches failed: %v", err)
+		}
+		if expected := []string{"feat/add-login", "master"}; !reflect.DeepEqual(branches, expected) {
+			t.Errorf("expected %q, got %q", expected, branches)
+		}
+
+		err = client.CreateBranch("master")
+		if err == nil || !strings.Contains(err.Error(), "branch master already exists") {
+			t.Errorf("expected an error about the existing branch, got %v", err)
+		}
+	})
+
+	t.Run("Unborn HEAD", func(t *testing.T) {
+		repo, client := newIndexTestRepo(t, nil)
+		if err := client.CreateBranch("feat/first"); err != nil {
+			t.Fatalf("CreateBranch failed: %v", err)
+		}
+		head, err := repo.Storer.Reference(plumbing.HEAD)
+		if err != nil || head.Target() != plumbing.NewBranchReferenceName("feat/first") {
+			t.Errorf("expected HEAD to point at feat/first, got %v (%v)", head, err)
+		}
+	})
+
+	t.Run("Invalid name", func(t *testing.T) {
+		_, client := newIndexTestRepo(t, nil)
+		err := client.CreateBranch("feat/add login")
+		if err == nil || !strings.Contains(err.Error(), "not a valid branch name") {
+			t.Errorf("expected a validation error, got %v", err)
+		}
+	})
+}
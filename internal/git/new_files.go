@@ -0,0 +1,90 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	git "github.com/go-git/go-git/v5"
+)
+
+// maxNewFileBytes is the largest untracked file GetNewFiles reads
+const maxNewFileBytes = 64 * 1024
+
+// NewFiles are the untracked files GetNewFiles describes
+type NewFiles struct {
+	// Diff shows each included file as an addition, like the staged diff
+	// shows a new file
+	Diff string
+	// Files are the included files, sorted by name
+	Files []StagedFile
+	// Skipped lists the files left out, each with the reason, e.g.
+	// "big.bin (binary)"
+	Skipped []string
+}
+
+// GetNewFiles reads the untracked files that the path filters include, so
+// they can be described before they are staged. Files matched by
+// .gitignore are never read, and binary files or files larger than 64 KiB
+// are skipped. Nothing is staged.
+func (c *ClientImpl) GetNewFiles() (*NewFiles, error) {
+	repo, err := c.openRepo()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get worktree: %w", err)
+	}
+	// Status leaves out ignored files
+	status, err := worktree.Status()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status: %w", err)
+	}
+	root, err := c.GetRepoRoot()
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for filePath, fileStatus := range status {
+		if fileStatus.Worktree == git.Untracked && c.options.includes(filePath) {
+			paths = append(paths, filePath)
+		}
+	}
+	sort.Strings(paths)
+
+	newFiles := &NewFiles{}
+	var diff strings.Builder
+	for _, filePath := range paths {
+		fullPath := filepath.Join(root, filePath)
+		info, err := os.Stat(fullPath)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		if info.Size() > maxNewFileBytes {
+			newFiles.Skipped = append(newFiles.Skipped, fmt.Sprintf("%s (larger than %d KiB)", filePath, maxNewFileBytes/1024))
+			continue
+		}
+		content, err := os.ReadFile(fullPath)
+		if err != nil {
+			newFiles.Skipped = append(newFiles.Skipped, fmt.Sprintf("%s (%v)", filePath, err))
+			continue
+		}
+		if bytes.IndexByte(content, 0) >= 0 {
+			newFiles.Skipped = append(newFiles.Skipped, filePath+" (binary)")
+			continue
+		}
+
+		fmt.Fprintf(&diff, "diff --git a/%s b/%s\nnew file mode 100644\n--- /dev/null\n+++ b/%s\n", filePath, filePath, filePath)
+		for _, line := range strings.Split(strings.TrimSuffix(string(content), "\n"), "\n") {
+			diff.WriteString("+" + line + "\n")
+		}
+		newFiles.Files = append(newFiles.Files, StagedFile{Path: filePath, Change: ChangeAdded})
+	}
+	newFiles.Diff = truncateDiff(diff.String())
+	return newFiles, nil
+}
@@ -0,0 +1,70 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestClientImpl_GetNewFiles(t *testing.T) {
+	repo, _ := newIndexTestRepo(t, map[string]string{".gitignore": "*.log\n", "main.go": "package main\n"})
+	stageFiles(t, repo, map[string]string{"main.go": "package main\n\nfunc main() {}\n"})
+	untracked := map[string]string{
+		"cmd/tool.go":  "package main\n\nfunc tool() {}\n",
+		"docs/new.md":  "# New\n",
+		"debug.log":    "ignored\n",
+		"logo.png":     "\x89PNG\x00\x01",
+		"big.txt":      strings.Repeat("x", maxNewFileBytes+1),
+		"vendor/a.go":  "package a\n",
+		"vendor/b.txt": "b\n",
+	}
+	for name, content := range untracked {
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	client := NewClientWithOptions(DiffOptions{ContextLines: DefaultContextLines, Ignore: []string{"vendor/"}})
+	newFiles, err := client.GetNewFiles()
+	if err != nil {
+		t.Fatalf("GetNewFiles failed: %v", err)
+	}
+
+	var paths []string
+	for _, f := range newFiles.Files {
+		paths = append(paths, f.Path+":"+string(f.Change))
+	}
+	if expected := []string{"cmd/tool.go:A", "docs/new.md:A"}; !reflect.DeepEqual(paths, expected) {
+		t.Errorf("expected files %v, got %v", expected, paths)
+	}
+	if expected := []string{"big.txt (larger than 64 KiB)", "logo.png (binary)"}; !reflect.DeepEqual(newFiles.Skipped, expected) {
+		t.Errorf("expected skipped %v, got %v", expected, newFiles.Skipped)
+	}
+	for _, want := range []string{
+		"diff --git a/cmd/tool.go b/cmd/tool.go\nnew file mode 100644\n--- /dev/null\n+++ b/cmd/tool.go\n+package main\n+\n+func tool() {}\n",
+		"+++ b/docs/new.md\n+# New\n",
+	} {
+		if !strings.Contains(newFiles.Diff, want) {
+			t.Errorf("expected %q in diff:\n%s", want, newFiles.Diff)
+		}
+	}
+	for _, unwanted := range []string{"debug.log", "main.go", "vendor/"} {
+		if strings.Contains(newFiles.Diff, unwanted) {
+			t.Errorf("expected no %s in diff:\n%s", unwanted, newFiles.Diff)
+		}
+	}
+
+	// Nothing is staged
+	files, err := client.GetStagedFiles()
+	if err != nil {
+		t.Fatalf("GetStagedFiles failed: %v", err)
+	}
+	if len(files) != 1 || files[0].Path != "main.go" {
+		t.Errorf("expected only main.go staged, got %+v", files)
+	}
+}
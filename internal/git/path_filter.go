@@ -0,0 +1,111 @@
+package git
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// DiffOptions controls which staged paths are included in diffs and how
+// they are rendered
+type DiffOptions struct {
+	// ContextLines is the number of unchanged lines around each hunk
+	ContextLines int
+	// Only restricts the diff to paths matching at least one of these globs
+	Only []string
+	// Ignore excludes paths matching any of these globs
+	Ignore []string
+	// Files, when set, are the only paths read, as repository-relative
+	// slash-separated paths. A hook that knows the staged files passes
+	// them so the working tree is not scanned for changes.
+	Files []string
+	// MaxFileBytes is the size above which a file's content is not read
+	// and its change is summarized in one line. Zero means no limit.
+	MaxFileBytes int64
+}
+
+// DefaultMaxFileBytes is the max_file_bytes used when the config does not
+// set one: big enough for hand-written sources, small enough to keep a
+// generated file or data dump from taking the whole diff
+const DefaultMaxFileBytes = 256 * 1024
+
+// tooLarge reports whether a file of size bytes is summarized instead of
+// diffed
+func (o DiffOptions) tooLarge(size int64) bool {
+	return o.MaxFileBytes > 0 && size > o.MaxFileBytes
+}
+
+// includes reports whether a staged path passes the Only/Ignore filters
+func (o DiffOptions) includes(p string) bool {
+	if len(o.Only) > 0 && !matchAny(o.Only, p) {
+		return false
+	}
+	return !matchAny(o.Ignore, p)
+}
+
+// ValidateGlobs checks that every pattern is a valid glob
+func ValidateGlobs(patterns []string) error {
+	for _, pattern := range patterns {
+		for _, segment := range strings.Split(pattern, "/") {
+			if _, err := path.Match(segment, ""); err != nil {
+				return fmt.Errorf("invalid glob %q: %w", pattern, err)
+			}
+		}
+	}
+	return nil
+}
+
+func matchAny(patterns []string, p string) bool {
+	for _, pattern := range patterns {
+		if MatchGlob(pattern, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchGlob matches a slash-separated path against a glob pattern.
+// Patterns without a slash match the file name at any depth (like
+// .gitignore), "**" matches any number of directories, and a pattern that
+// matches a directory also matches everything below it.
+func MatchGlob(pattern, p string) bool {
+	pattern = strings.TrimPrefix(pattern, "./")
+	if strings.HasSuffix(pattern, "/") {
+		pattern += "**"
+	}
+
+	if !strings.Contains(pattern, "/") {
+		for _, segment := range strings.Split(p, "/") {
+			if ok, _ := path.Match(pattern, segment); ok {
+				return true
+			}
+		}
+		return false
+	}
+
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(p, "/"))
+}
+
+// matchSegments matches pattern segments against path segments. A pattern
+// that is exhausted before the path matches, so directories match their
+// contents.
+func matchSegments(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return true
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchSegments(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], segments[0]); !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], segments[1:])
+}
@@ -0,0 +1,67 @@
+package git
+
+import "testing"
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		path     string
+		expected bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "internal/app/app.go", true},
+		{"*.go", "README.md", false},
+		{"go.sum", "go.sum", true},
+		{"go.sum", "vendor/x/go.sum", true},
+		{"internal/*.go", "internal/app.go", true},
+		{"internal/*.go", "internal/app/app.go", false},
+		{"internal/**/*.go", "internal/app/app.go", true},
+		{"internal/**/*.go", "internal/app.go", true},
+		{"**/testdata/*", "a/b/testdata/x.json", true},
+		{"docs/", "docs/guide/intro.md", true},
+		{"internal/ai", "internal/ai/prompt.go", true},
+		{"internal/ai", "internal/aitools/x.go", false},
+		{"./cmd/**", "cmd/generate-commit/main.go", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+"|"+tt.path, func(t *testing.T) {
+			if got := MatchGlob(tt.pattern, tt.path); got != tt.expected {
+				t.Errorf("MatchGlob(%q, %q) = %v, expected %v", tt.pattern, tt.path, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDiffOptions_Includes(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     DiffOptions
+		path     string
+		expected bool
+	}{
+		{"No filters", DiffOptions{}, "go.sum", true},
+		{"Only matches", DiffOptions{Only: []string{"internal/"}}, "internal/app/app.go", true},
+		{"Only misses", DiffOptions{Only: []string{"internal/"}}, "README.md", false},
+		{"Ignore matches", DiffOptions{Ignore: []string{"go.sum"}}, "go.sum", false},
+		{"Ignore wins over only", DiffOptions{Only: []string{"*.go"}, Ignore: []string{"*_test.go"}}, "app_test.go", false},
+		{"Only and ignore both pass", DiffOptions{Only: []string{"*.go"}, Ignore: []string{"*_test.go"}}, "app.go", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.opts.includes(tt.path); got != tt.expected {
+				t.Errorf("includes(%q) = %v, expected %v", tt.path, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestValidateGlobs(t *testing.T) {
+	if err := ValidateGlobs([]string{"*.go", "internal/**/x"}); err != nil {
+		t.Errorf("expected valid globs, got %v", err)
+	}
+	if err := ValidateGlobs([]string{"[abc"}); err == nil {
+		t.Error("expected error for malformed glob")
+	}
+}
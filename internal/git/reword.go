@@ -0,0 +1,238 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage"
+)
+
+// GetCommitDiff returns the diff a commit introduces against its first
+// parent, in the same format as the staged diff. A root commit is diffed
+// against the empty tree.
+func (c *ClientImpl) GetCommitDiff(hash string) (string, error) {
+	repo, err := c.openRepo()
+	if err != nil {
+		return "", fmt.Errorf("failed to open repository: %w", err)
+	}
+	commit, err := repo.CommitObject(plumbing.NewHash(hash))
+	if err != nil {
+		return "", fmt.Errorf("failed to get commit %s: %w", hash, err)
+	}
+	toTree, err := commit.Tree()
+	if err != nil {
+		return "", fmt.Errorf("failed to get the tree of %s: %w", hash, err)
+	}
+
+	var fromTree *object.Tree
+	if commit.NumParents() > 0 {
+		parent, err := commit.Parent(0)
+		if err != nil {
+			return "", fmt.Errorf("failed to get the parent of %s: %w", hash, err)
+		}
+		if fromTree, err = parent.Tree(); err != nil {
+			return "", fmt.Errorf("failed to get the parent tree of %s: %w", hash, err)
+		}
+	}
+
+	changes, err := object.DiffTreeWithOptions(context.Background(), fromTree, toTree, object.DefaultDiffTreeOptions)
+	if err != nil {
+		return "", fmt.Errorf("failed to diff %s: %w", hash, err)
+	}
+	var diffBuilder strings.Builder
+	for _, change := range changes {
+		if err := c.writeTreeChange(&diffBuilder, change); err != nil {
+			return "", err
+		}
+	}
+	return truncateDiff(diffBuilder.String()), nil
+}
+
+// FindPublished reports which of hashes can be reached from a remote-tracking
+// branch or from a local branch matching one of the protected globs, e.g.
+// main or release/*. The result maps each such hash to the first branch
+// found to contain it.
+func (c *ClientImpl) FindPublished(hashes []string, protected []string) (map[string]string, error) {
+	repo, err := c.openRepo()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+	refs, err := repo.References()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list references: %w", err)
+	}
+
+	var tips []*plumbing.Reference
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Type() != plumbing.HashReference {
+			return nil
+		}
+		name := ref.Name()
+		if name.IsRemote() || name.IsBranch() && matchesBranch(protected, name.Short()) {
+			tips = append(tips, ref)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list references: %w", err)
+	}
+
+	wanted := make(map[plumbing.Hash]string, len(hashes))
+	for _, hash := range hashes {
+		wanted[plumbing.NewHash(hash)] = hash
+	}
+	published := make(map[string]string)
+	seen := make(map[plumbing.Hash]bool)
+	for _, tip := range tips {
+		commit, err := repo.CommitObject(tip.Hash())
+		if err != nil {
+			// Tags and notes under refs/remotes are not commits
+			continue
+		}
+		err = object.NewCommitPreorderIter(commit, seen, nil).ForEach(func(commit *object.Commit) error {
+			seen[commit.Hash] = true
+			if hash, ok := wanted[commit.Hash]; ok {
+				if _, found := published[hash]; !found {
+					published[hash] = tip.Name().Short()
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to walk %s: %w", tip.Name().Short(), err)
+		}
+	}
+	return published, nil
+}
+
+// matchesBranch reports whether branch matches one of the globs
+func matchesBranch(globs []string, branch string) bool {
+	for _, glob := range globs {
+		if ok, _ := path.Match(glob, branch); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// RewordCommits replaces the messages of the commits in revRange, which
+// must end at HEAD, and moves the current branch to the rewritten tip.
+// messages maps full commit hashes to their new messages; commits without
+// an entry keep theirs, but are rewritten too when a parent changed. Trees,
+// authors and committers are kept, so the index and working tree are not
+// touched. Signatures are dropped since they no longer match. It returns
+// the hashes of the old and the new tip.
+func (c *ClientImpl) RewordCommits(revRange string, messages map[string]string) (string, string, error) {
+	from, to, err := SplitRange(revRange)
+	if err != nil {
+		return "", "", err
+	}
+	repo, err := c.openRepo()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	head, err := repo.Head()
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		// Never a base to rewrite from unless git agrees there are no commits
+		if err := c.confirmUnborn(err); err != nil {
+			return "", "", err
+		}
+		return "", "", fmt.Errorf("cannot reword %s: %w", revRange, errUnbornHead)
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get HEAD: %w", err)
+	}
+	toHash, err := repo.ResolveRevision(plumbing.Revision(to))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to resolve %q: %w", to, err)
+	}
+	if *toHash != head.Hash() {
+		return "", "", fmt.Errorf("%s does not end at HEAD; only commits of the current branch can be reworded", revRange)
+	}
+	fromHash, err := repo.ResolveRevision(plumbing.Revision(from))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to resolve %q: %w", from, err)
+	}
+	fromCommit, err := repo.CommitObject(*fromHash)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get commit for %q: %w", from, err)
+	}
+
+	excluded := make(map[plumbing.Hash]bool)
+	err = object.NewCommitPreorderIter(fromCommit, nil, nil).ForEach(func(commit *object.Commit) error {
+		excluded[commit.Hash] = true
+		return nil
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to walk %s: %w", from, err)
+	}
+
+	// rewritten maps every commit of the range to its replacement. Parents
+	// are rewritten before their children, whatever order the walk takes.
+	rewritten := make(map[plumbing.Hash]plumbing.Hash)
+	var rewrite func(hash plumbing.Hash) (plumbing.Hash, error)
+	rewrite = func(hash plumbing.Hash) (plumbing.Hash, error) {
+		if excluded[hash] {
+			return hash, nil
+		}
+		if newHash, ok := rewritten[hash]; ok {
+			return newHash, nil
+		}
+		commit, err := repo.CommitObject(hash)
+		if err != nil {
+			return plumbing.ZeroHash, fmt.Errorf("failed to get commit %s: %w", hash, err)
+		}
+
+		updated := *commit
+		updated.PGPSignature = ""
+		updated.ParentHashes = make([]plumbing.Hash, len(commit.ParentHashes))
+		changed := false
+		for i, parent := range commit.ParentHashes {
+			if updated.ParentHashes[i], err = rewrite(parent); err != nil {
+				return plumbing.ZeroHash, err
+			}
+			changed = changed || updated.ParentHashes[i] != parent
+		}
+		if message, ok := messages[hash.String()]; ok && message != commit.Message {
+			updated.Message = message
+			changed = true
+		}
+		if !changed {
+			rewritten[hash] = hash
+			return hash, nil
+		}
+
+		obj := repo.Storer.NewEncodedObject()
+		if err := updated.Encode(obj); err != nil {
+			return plumbing.ZeroHash, fmt.Errorf("failed to encode the rewritten %s: %w", hash, err)
+		}
+		newHash, err := repo.Storer.SetEncodedObject(obj)
+		if err != nil {
+			return plumbing.ZeroHash, fmt.Errorf("failed to write the rewritten %s: %w", hash, err)
+		}
+		rewritten[hash] = newHash
+		return newHash, nil
+	}
+
+	newHead, err := rewrite(head.Hash())
+	if err != nil {
+		return "", "", err
+	}
+	if newHead == head.Hash() {
+		return head.Hash().String(), newHead.String(), nil
+	}
+	// head.Name() is the branch HEAD points to, or HEAD itself when detached
+	if err := repo.Storer.CheckAndSetReference(plumbing.NewHashReference(head.Name(), newHead), head); err != nil {
+		if errors.Is(err, storage.ErrReferenceHasChanged) {
+			return "", "", fmt.Errorf("%s moved while rewording; nothing was changed", head.Name().Short())
+		}
+		return "", "", fmt.Errorf("failed to update %s: %w", head.Name().Short(), err)
+	}
+	return head.Hash().String(), newHead.String(), nil
+}
@@ -0,0 +1,216 @@
+package git
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+func TestClientImpl_GetCommitDiff(t *testing.T) {
+	repo, client := newIndexTestRepo(t, map[string]string{"a.txt": "one\n"})
+	head, _ := repo.Head()
+	root := head.Hash()
+	stageFiles(t, repo, map[string]string{"a.txt": "two\n", "b.txt": "new\n"})
+	change := commitAt(t, repo, "change", 1)
+
+	tests := []struct {
+		name     string
+		hash     plumbing.Hash
+		expected []string
+		absent   []string
+	}{
+		{
+			name:     "Against the parent",
+			hash:     change,
+			expected: []string{"--- a/a.txt\n+++ b/a.txt\n", "-one\n+two\n", "new file mode 100644\n--- /dev/null\n+++ b/b.txt\n"},
+		},
+		{
+			name:     "Root commit against the empty tree",
+			hash:     root,
+			expected: []string{"--- /dev/null\n+++ b/a.txt\n", "+one\n"},
+			absent:   []string{"b.txt"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff, err := client.GetCommitDiff(tt.hash.String())
+			if err != nil {
+				t.Fatalf("GetCommitDiff failed: %v", err)
+			}
+			for _, want := range tt.expected {
+				if !strings.Contains(diff, want) {
+					t.Errorf("expected diff to contain %q, got:\n%s", want, diff)
+				}
+			}
+			for _, unwanted := range tt.absent {
+				if strings.Contains(diff, unwanted) {
+					t.Errorf("expected diff not to contain %q, got:\n%s", unwanted, diff)
+				}
+			}
+		})
+	}
+}
+
+func TestClientImpl_FindPublished(t *testing.T) {
+	repo, client := newIndexTestRepo(t, nil)
+	base := commitAt(t, repo, "chore: initial commit", 0)
+	pushed := commitAt(t, repo, "feat: pushed", 1)
+	local := commitAt(t, repo, "wip", 2)
+	for name, hash := range map[plumbing.ReferenceName]plumbing.Hash{
+		plumbing.NewBranchReferenceName("main"):            base,
+		plumbing.NewBranchReferenceName("release/1.0"):     base,
+		plumbing.NewRemoteReferenceName("origin", "topic"): pushed,
+	} {
+		if err := repo.Storer.SetReference(plumbing.NewHashReference(name, hash)); err != nil {
+			t.Fatalf("failed to set %s: %v", name, err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		protected []string
+		expected  map[string]string
+	}{
+		{
+			name:      "Protected branch and remote",
+			protected: []string{"main"},
+			expected:  map[string]string{base.String(): "main", pushed.String(): "origin/topic"},
+		},
+		{
+			name:      "Glob",
+			protected: []string{"release/*"},
+			expected:  map[string]string{base.String(): "release/1.0", pushed.String(): "origin/topic"},
+		},
+		{
+			name:     "Remote only",
+			expected: map[string]string{base.String(): "origin/topic", pushed.String(): "origin/topic"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			published, err := client.FindPublished([]string{base.String(), pushed.String(), local.String()}, tt.protected)
+			if err != nil {
+				t.Fatalf("FindPublished failed: %v", err)
+			}
+			if !reflect.DeepEqual(published, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, published)
+			}
+		})
+	}
+}
+
+func TestClientImpl_RewordCommits(t *testing.T) {
+	setup := func(t *testing.T) (*git.Repository, Client, []plumbing.Hash) {
+		repo, client := newIndexTestRepo(t, nil)
+		base := commitAt(t, repo, "chore: initial commit", 0)
+		stageFiles(t, repo, map[string]string{"a.txt": "a\n"})
+		first := commitAt(t, repo, "wip", 1)
+		stageFiles(t, repo, map[string]string{"b.txt": "b\n"})
+		second := commitAt(t, repo, "fix stuff", 2)
+		third := commitAt(t, repo, "docs: kept", 3)
+		return repo, client, []plumbing.Hash{base, first, second, third}
+	}
+
+	t.Run("Rewrites the messages and moves the branch", func(t *testing.T) {
+		repo, client, hashes := setup(t)
+		oldSecond, _ := repo.CommitObject(hashes[2])
+
+		oldHead, newHead, err := client.RewordCommits(hashes[0].String()+"..HEAD", map[string]string{
+			hashes[1].String(): "feat: added a\n",
+			hashes[2].String(): "feat: added b\n",
+		})
+		if err != nil {
+			t.Fatalf("RewordCommits failed: %v", err)
+		}
+
+		if oldHead != hashes[3].String() {
+			t.Errorf("expected the old tip %s, got %s", hashes[3], oldHead)
+		}
+		head, _ := repo.Head()
+		if head.Name() != plumbing.NewBranchReferenceName("master") || head.Hash().String() != newHead {
+			t.Fatalf("expected master at %s, got %s at %s", newHead, head.Name(), head.Hash())
+		}
+		var messages []string
+		commit, _ := repo.CommitObject(head.Hash())
+		for commit.Hash != hashes[0] {
+			messages = append(messages, commit.Message)
+			if commit.Hash == hashes[2] || commit.Hash == hashes[3] {
+				t.Errorf("expected %s to be rewritten", commit.Hash)
+			}
+			if commit.Message == "feat: added b\n" {
+				if commit.TreeHash != oldSecond.TreeHash || commit.Author != oldSecond.Author {
+					t.Errorf("expected the tree and author to be kept")
+				}
+			}
+			parent, err := commit.Parent(0)
+			if err != nil {
+				t.Fatalf("failed to walk to the base: %v", err)
+			}
+			commit = parent
+		}
+		expected := []string{"docs: kept", "feat: added b\n", "feat: added a\n"}
+		if !reflect.DeepEqual(messages, expected) {
+			t.Errorf("expected %q, got %q", expected, messages)
+		}
+	})
+
+	t.Run("Unchanged messages leave the branch alone", func(t *testing.T) {
+		repo, client, hashes := setup(t)
+		_, newHead, err := client.RewordCommits(hashes[0].String()+"..", map[string]string{hashes[1].String(): "wip"})
+		if err != nil {
+			t.Fatalf("RewordCommits failed: %v", err)
+		}
+		if head, _ := repo.Head(); newHead != hashes[3].String() || head.Hash() != hashes[3] {
+			t.Errorf("expected HEAD to stay at %s, got %s", hashes[3], newHead)
+		}
+	})
+
+	t.Run("Range must end at HEAD", func(t *testing.T) {
+		_, client, hashes := setup(t)
+		_, _, err := client.RewordCommits(hashes[0].String()+".."+hashes[2].String(), map[string]string{hashes[1].String(): "feat: added a"})
+		if err == nil || !strings.Contains(err.Error(), "does not end at HEAD") {
+			t.Errorf("expected an error about HEAD, got %v", err)
+		}
+	})
+}
+
+func TestClientImpl_RewordCommits_LinkedWorktree(t *testing.T) {
+	requireGit(t)
+	newIndexTestRepo(t, map[string]string{"a.txt": "one\n"})
+	mainDir, _ := os.Getwd()
+	mainTip := gitOutput(t, "rev-parse", "HEAD")
+	addLinkedWorktree(t)
+	if err := os.WriteFile("a.txt", []byte("two\n"), 0644); err != nil {
+		t.Fatalf("failed to write a.txt: %v", err)
+	}
+	gitOutput(t, "commit", "-q", "-am", "wip")
+	tip := gitOutput(t, "rev-parse", "HEAD")
+
+	oldTip, newTip, err := NewClient().RewordCommits("HEAD~1..HEAD", map[string]string{tip: "fix: changed a\n"})
+	if err != nil {
+		t.Fatalf("RewordCommits failed: %v", err)
+	}
+	if oldTip != tip || newTip == tip {
+		t.Errorf("expected the tip %s to be rewritten, got %s -> %s", tip, oldTip, newTip)
+	}
+
+	// The worktree's branch moves; the main worktree's branch does not
+	if subject := gitOutput(t, "log", "-1", "--format=%s"); subject != "fix: changed a" {
+		t.Errorf("expected the reworded subject on the worktree's branch, got %q", subject)
+	}
+	if parent := gitOutput(t, "rev-parse", "HEAD~1"); parent != mainTip {
+		t.Errorf("expected the reworded commit on top of %s, got %s", mainTip, parent)
+	}
+	if tip := gitOutput(t, "-C", mainDir, "rev-parse", "HEAD"); tip != mainTip {
+		t.Errorf("expected the main worktree to stay at %s, got %s", mainTip, tip)
+	}
+	if status := gitOutput(t, "status", "--porcelain"); status != "" {
+		t.Errorf("expected a clean worktree, got %q", status)
+	}
+}
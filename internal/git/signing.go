@@ -0,0 +1,144 @@
+package git
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	git "github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+)
+
+// Signing formats of gpg.format
+const (
+	SigningOpenPGP = "openpgp"
+	SigningSSH     = "ssh"
+	SigningX509    = "x509"
+)
+
+// uncommittedMessageFile keeps a message whose commit failed, in the git
+// directory, so it can be committed by hand with git commit -F
+const uncommittedMessageFile = "AI_COMMIT_EDITMSG"
+
+// SigningConfig is what the git configuration says about signing commits
+type SigningConfig struct {
+	// Sign is commit.gpgsign
+	Sign bool
+	// Format is gpg.format: SigningOpenPGP (the default), SigningSSH or
+	// SigningX509
+	Format string
+	// Key is user.signingkey: an OpenPGP key ID, fingerprint or user ID,
+	// or for SSH a key file. Empty means the committer's email.
+	Key string
+}
+
+// readSigningConfig reads commit.gpgsign, gpg.format and user.signingkey
+// from the merged system, global and repository config
+func readSigningConfig(repo *git.Repository) (SigningConfig, error) {
+	cfg, err := repo.ConfigScoped(gitconfig.SystemScope)
+	if err != nil {
+		return SigningConfig{}, fmt.Errorf("failed to get git config: %w", err)
+	}
+	signing := SigningConfig{
+		Format: cfg.Raw.Section("gpg").Option("format"),
+		Key:    cfg.Raw.Section("user").Option("signingkey"),
+	}
+	switch strings.ToLower(cfg.Raw.Section("commit").Option("gpgsign")) {
+	case "true", "yes", "on", "1":
+		signing.Sign = true
+	}
+	if signing.Format == "" {
+		signing.Format = SigningOpenPGP
+	}
+	return signing, nil
+}
+
+// signingEntity returns the OpenPGP key go-git can sign commits with, or
+// the reason there is none. go-git signs by itself only with an OpenPGP
+// key from a secret keyring it can read, which GnuPG 2.1 and later no
+// longer write, and only when the key has no passphrase.
+func signingEntity(signing SigningConfig, email string) (*openpgp.Entity, error) {
+	if signing.Format != SigningOpenPGP {
+		return nil, fmt.Errorf("go-git cannot sign with gpg.format=%s", signing.Format)
+	}
+	path := secretKeyringPath()
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("there is no secret keyring go-git can read at %s", path)
+	}
+	defer file.Close()
+	keyring, err := openpgp.ReadKeyRing(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	want := signing.Key
+	if want == "" {
+		want = email
+	}
+	for _, entity := range keyring {
+		if !entityMatches(entity, want) {
+			continue
+		}
+		if entity.PrivateKey == nil {
+			return nil, fmt.Errorf("%s has no secret key for %s", path, want)
+		}
+		if entity.PrivateKey.Encrypted {
+			return nil, fmt.Errorf("key %s is protected by a passphrase", want)
+		}
+		return entity, nil
+	}
+	return nil, fmt.Errorf("no key matching %s in %s", want, path)
+}
+
+// secretKeyringPath returns the legacy GnuPG secret keyring
+func secretKeyringPath() string {
+	home := os.Getenv("GNUPGHOME")
+	if home == "" {
+		userHome, _ := os.UserHomeDir()
+		home = filepath.Join(userHome, ".gnupg")
+	}
+	return filepath.Join(home, "secring.gpg")
+}
+
+// entityMatches reports whether key names entity: a key ID or fingerprint
+// of the primary key or a subkey (with an optional 0x prefix and ! suffix),
+// or part of a user ID such as the email
+func entityMatches(entity *openpgp.Entity, key string) bool {
+	id := strings.ToUpper(strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(key, "0x"), "0X"), "!"))
+	if _, err := hex.DecodeString(id); err == nil && len(id) >= 8 {
+		fingerprints := []string{strings.ToUpper(hex.EncodeToString(entity.PrimaryKey.Fingerprint))}
+		for _, subkey := range entity.Subkeys {
+			fingerprints = append(fingerprints, strings.ToUpper(hex.EncodeToString(subkey.PublicKey.Fingerprint)))
+		}
+		for _, fingerprint := range fingerprints {
+			if strings.HasSuffix(fingerprint, id) {
+				return true
+			}
+		}
+		return false
+	}
+	for name := range entity.Identities {
+		if strings.Contains(strings.ToLower(name), strings.ToLower(key)) {
+			return true
+		}
+	}
+	return false
+}
+
+// keepMessage saves a message whose commit failed to the git directory and
+// adds where to find it to err, so the message is not lost
+func keepMessage(gitDir, message string, err error) error {
+	if gitDir == "" {
+		gitDir = os.TempDir()
+	}
+	path := filepath.Join(gitDir, uncommittedMessageFile)
+	if writeErr := os.WriteFile(path, []byte(message), 0644); writeErr != nil {
+		return errors.Join(err, fmt.Errorf("failed to save the message: %w", writeErr))
+	}
+	return fmt.Errorf("%w\nThe message was saved; commit it with: git commit -F %s", err, path)
+}
@@ -0,0 +1,199 @@
+package git
+
+import (
+	"bytes"
+	"encoding/hex"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+
+	"ai-commit-message-generator/internal/logging"
+)
+
+// writeSecretKeyring writes a legacy GnuPG secret keyring holding a new key
+// for test@example.com to a fresh GNUPGHOME and returns the key
+func writeSecretKeyring(t *testing.T, passphrase string) *openpgp.Entity {
+	t.Helper()
+	entity, err := openpgp.NewEntity("Test User", "", "test@example.com", nil)
+	if err != nil {
+		t.Fatalf("failed to create key: %v", err)
+	}
+	if passphrase != "" {
+		if err := entity.EncryptPrivateKeys([]byte(passphrase), nil); err != nil {
+			t.Fatalf("failed to encrypt key: %v", err)
+		}
+	}
+	var keyring bytes.Buffer
+	if err := entity.SerializePrivateWithoutSigning(&keyring, nil); err != nil {
+		t.Fatalf("failed to serialize key: %v", err)
+	}
+	home := t.TempDir()
+	t.Setenv("GNUPGHOME", home)
+	if err := os.WriteFile(filepath.Join(home, "secring.gpg"), keyring.Bytes(), 0600); err != nil {
+		t.Fatalf("failed to write keyring: %v", err)
+	}
+	return entity
+}
+
+func TestSigningEntity(t *testing.T) {
+	tests := []struct {
+		name        string
+		passphrase  string
+		signing     SigningConfig
+		noKeyring   bool
+		expectError string
+	}{
+		{name: "Key matched by email", signing: SigningConfig{Format: SigningOpenPGP}},
+		{name: "Key matched by user ID", signing: SigningConfig{Format: SigningOpenPGP, Key: "Test User"}},
+		{name: "Other key", signing: SigningConfig{Format: SigningOpenPGP, Key: "DEADBEEFDEADBEEF"}, expectError: "no key matching DEADBEEFDEADBEEF"},
+		{name: "Passphrase", passphrase: "secret", signing: SigningConfig{Format: SigningOpenPGP}, expectError: "protected by a passphrase"},
+		{name: "No keyring", noKeyring: true, signing: SigningConfig{Format: SigningOpenPGP}, expectError: "no secret keyring go-git can read"},
+		{name: "SSH", signing: SigningConfig{Format: SigningSSH, Key: "~/.ssh/id_ed25519.pub"}, expectError: "cannot sign with gpg.format=ssh"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.noKeyring {
+				t.Setenv("GNUPGHOME", t.TempDir())
+			} else {
+				writeSecretKeyring(t, tt.passphrase)
+			}
+
+			entity, err := signingEntity(tt.signing, "test@example.com")
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Fatalf("expected error containing %q, got %v", tt.expectError, err)
+				}
+				return
+			}
+			if err != nil || entity == nil {
+				t.Fatalf("expected a key, got %v", err)
+			}
+		})
+	}
+}
+
+func TestEntityMatches_KeyID(t *testing.T) {
+	entity := writeSecretKeyring(t, "")
+	fingerprint := strings.ToUpper(hex.EncodeToString(entity.PrimaryKey.Fingerprint))
+
+	for _, key := range []string{fingerprint, fingerprint[24:], "0x" + fingerprint[32:] + "!"} {
+		if !entityMatches(entity, key) {
+			t.Errorf("expected %q to match the key", key)
+		}
+	}
+	if entityMatches(entity, "0123456789ABCDEF") {
+		t.Error("expected another key ID not to match")
+	}
+}
+
+func TestClientImpl_CommitWithMessage_Signing(t *testing.T) {
+	requireGit(t)
+	if _, err := exec.LookPath("ssh-keygen"); err != nil {
+		t.Skip("ssh-keygen not found")
+	}
+
+	tests := []struct {
+		name        string
+		kind        BackendKind
+		config      map[string]string
+		openPGPKey  bool
+		expectSig   string
+		expectLog   string
+		expectError string
+	}{
+		{name: "Signing disabled", config: map[string]string{"commit.gpgsign": "false", "gpg.format": "ssh"}},
+		{
+			name:       "OpenPGP key go-git can read",
+			config:     map[string]string{"commit.gpgsign": "true"},
+			openPGPKey: true,
+			expectSig:  "-----BEGIN PGP SIGNATURE-----",
+			expectLog:  "through go-git",
+		},
+		{
+			name:      "SSH signing falls back to git",
+			config:    map[string]string{"commit.gpgsign": "true", "gpg.format": "ssh", "user.signingkey": "KEY"},
+			expectSig: "-----BEGIN SSH SIGNATURE-----",
+			expectLog: "with the git binary: go-git cannot sign with gpg.format=ssh",
+		},
+		{
+			name:        "Failed signing keeps the message",
+			config:      map[string]string{"commit.gpgsign": "true", "gpg.format": "ssh", "user.signingkey": "/nonexistent/key"},
+			expectError: "git commit -F",
+		},
+		{
+			name:        "go-git backend cannot fall back",
+			kind:        BackendGoGit,
+			config:      map[string]string{"commit.gpgsign": "true", "gpg.format": "ssh", "user.signingkey": "KEY"},
+			expectError: "set git_backend to auto or exec",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HOME", t.TempDir())
+			t.Setenv("GNUPGHOME", t.TempDir())
+			repo, _ := newIndexTestRepo(t, map[string]string{"main.go": "package main\n"})
+			if tt.openPGPKey {
+				writeSecretKeyring(t, "")
+			}
+			key := filepath.Join(t.TempDir(), "id_ed25519")
+			if out, err := exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-f", key).CombinedOutput(); err != nil {
+				t.Fatalf("failed to create SSH key: %v: %s", err, out)
+			}
+			for name, value := range tt.config {
+				if value == "KEY" {
+					value = key
+				}
+				if out, err := exec.Command("git", "config", name, value).CombinedOutput(); err != nil {
+					t.Fatalf("failed to set %s: %v: %s", name, err, out)
+				}
+			}
+			stageFiles(t, repo, map[string]string{"main.go": "package main\n\nfunc main() {}\n"})
+
+			kind := tt.kind
+			if kind == "" {
+				kind = BackendAuto
+			}
+			var log bytes.Buffer
+			client := NewClientWithBackend(DiffOptions{ContextLines: DefaultContextLines}, kind, WithLogger(logging.New(&log, logging.FormatText, true)))
+			message := "feat: added main\n"
+			err := client.CommitWithMessage(message)
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Fatalf("expected error containing %q, got %v", tt.expectError, err)
+				}
+				saved, readErr := os.ReadFile(filepath.Join(".git", uncommittedMessageFile))
+				if readErr != nil || string(saved) != message {
+					t.Errorf("expected the message to be saved, got %q, %v", saved, readErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CommitWithMessage failed: %v", err)
+			}
+
+			head, err := repo.Head()
+			if err != nil {
+				t.Fatalf("failed to resolve HEAD: %v", err)
+			}
+			commit, err := repo.CommitObject(head.Hash())
+			if err != nil {
+				t.Fatalf("failed to read commit: %v", err)
+			}
+			if commit.Message != message {
+				t.Errorf("expected message %q, got %q", message, commit.Message)
+			}
+			if !strings.Contains(commit.PGPSignature, tt.expectSig) || (tt.expectSig == "" && commit.PGPSignature != "") {
+				t.Errorf("expected signature %q, got %q", tt.expectSig, commit.PGPSignature)
+			}
+			if !strings.Contains(log.String(), tt.expectLog) || (tt.expectLog == "" && log.Len() > 0) {
+				t.Errorf("expected log %q, got %q", tt.expectLog, log.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// ListTags returns the names of all tags, lightweight and annotated, sorted
+// by name
+func (c *ClientImpl) ListTags() ([]string, error) {
+	repo, err := c.openRepo()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+	refs, err := repo.Tags()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+
+	var tags []string
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		tags = append(tags, ref.Name().Short())
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+	sort.Strings(tags)
+	return tags, nil
+}
+
+// CreateTag creates an annotated tag at HEAD with message, tagged by the
+// identity commits are recorded with
+func (c *ClientImpl) CreateTag(name, message string) error {
+	repo, err := c.openRepo()
+	if err != nil {
+		return fmt.Errorf("failed to open repository: %w", err)
+	}
+	head, err := repo.Head()
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return fmt.Errorf("cannot tag %s: %w", name, errUnbornHead)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get HEAD: %w", err)
+	}
+	identity, err := c.GetUserIdentity()
+	if err != nil {
+		return err
+	}
+
+	_, err = repo.CreateTag(name, head.Hash(), &git.CreateTagOptions{
+		Tagger: &object.Signature{
+			Name:  identity.Name,
+			Email: identity.Email,
+			When:  time.Now(),
+		},
+		Message: message,
+	})
+	if errors.Is(err, git.ErrTagExists) {
+		return fmt.Errorf("tag %s already exists", name)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create tag %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package git
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func TestClientImpl_ListTags(t *testing.T) {
+	repo, client := newIndexTestRepo(t, nil)
+	base := commitAt(t, repo, "chore: initial commit", 0)
+	if _, err := repo.CreateTag("v1.10.0", base, nil); err != nil {
+		t.Fatalf("failed to tag: %v", err)
+	}
+	annotated := &git.CreateTagOptions{Message: "v1.9.0", Tagger: &object.Signature{Name: "Test User", Email: "test@example.com"}}
+	if _, err := repo.CreateTag("v1.9.0", base, annotated); err != nil {
+		t.Fatalf("failed to tag: %v", err)
+	}
+
+	tags, err := client.ListTags()
+	if err != nil {
+		t.Fatalf("ListTags failed: %v", err)
+	}
+	if expected := []string{"v1.10.0", "v1.9.0"}; !reflect.DeepEqual(tags, expected) {
+		t.Errorf("expected %q, got %q", expected, tags)
+	}
+}
+
+func TestClientImpl_CreateTag(t *testing.T) {
+	repo, client := newIndexTestRepo(t, nil)
+	head := commitAt(t, repo, "feat: added login", 0)
+
+	message := "v1.0.0\n\nHighlights:\n- Login\n"
+	if err := client.CreateTag("v1.0.0", message); err != nil {
+		t.Fatalf("CreateTag failed: %v", err)
+	}
+	ref, err := repo.Tag("v1.0.0")
+	if err != nil {
+		t.Fatalf("expected the tag to exist: %v", err)
+	}
+	tag, err := repo.TagObject(ref.Hash())
+	if err != nil {
+		t.Fatalf("expected an annotated tag: %v", err)
+	}
+	if tag.Target != head || tag.TargetType != plumbing.CommitObject {
+		t.Errorf("expected the tag to point at HEAD %s, got %s %s", head, tag.TargetType, tag.Target)
+	}
+	if tag.Message != message {
+		t.Errorf("expected message %q, got %q", message, tag.Message)
+	}
+	if tag.Tagger.Name != "Test User" || tag.Tagger.Email != "test@example.com" {
+		t.Errorf("expected the configured identity as tagger, got %s <%s>", tag.Tagger.Name, tag.Tagger.Email)
+	}
+
+	err = client.CreateTag("v1.0.0", message)
+	if err == nil || !strings.Contains(err.Error(), "tag v1.0.0 already exists") {
+		t.Errorf("expected an error about the existing tag, got %v", err)
+	}
+}
@@ -0,0 +1,56 @@
+package git
+
+import (
+	"fmt"
+	"os"
+
+	gitconfig "github.com/go-git/go-git/v5/config"
+)
+
+// GetCommitTemplate returns the contents of the file named by the
+// commit.template setting, or "" when none is configured. Relative paths
+// are resolved against the repository root.
+func (c *ClientImpl) GetCommitTemplate() (string, error) {
+	repo, err := c.openRepo()
+	if err != nil {
+		return "", fmt.Errorf("failed to open repository: %w", err)
+	}
+	cfg, err := repo.ConfigScoped(gitconfig.SystemScope)
+	if err != nil {
+		return "", fmt.Errorf("failed to get git config: %w", err)
+	}
+	templatePath := cfg.Raw.Section("commit").Option("template")
+	if templatePath == "" {
+		return "", nil
+	}
+
+	repoRoot, err := c.GetRepoRoot()
+	if err != nil {
+		return "", err
+	}
+	if templatePath, err = resolveConfigPath(repoRoot, "commit.template", templatePath); err != nil {
+		return "", err
+	}
+	content, err := os.ReadFile(templatePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read commit.template: %w", err)
+	}
+	return string(content), nil
+}
+
+// GetCurrentBranch returns the short name of the branch HEAD points to, or
+// "" when HEAD is detached
+func (c *ClientImpl) GetCurrentBranch() (string, error) {
+	repo, err := c.openRepo()
+	if err != nil {
+		return "", fmt.Errorf("failed to open repository: %w", err)
+	}
+	head, err := repo.Storer.Reference("HEAD")
+	if err != nil {
+		return "", fmt.Errorf("failed to get HEAD: %w", err)
+	}
+	if !head.Target().IsBranch() {
+		return "", nil
+	}
+	return head.Target().Short(), nil
+}
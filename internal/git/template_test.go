@@ -0,0 +1,90 @@
+package git
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+func TestClientImpl_GetCommitTemplate(t *testing.T) {
+	tests := []struct {
+		name          string
+		template      string
+		files         map[string]string
+		expected      string
+		expectedError string
+	}{
+		{
+			name:     "Not configured",
+			expected: "",
+		},
+		{
+			name:     "Relative to the repository root",
+			template: ".gitmessage",
+			files:    map[string]string{".gitmessage": "[TICKET] <subject>\n# Keep it short\n"},
+			expected: "[TICKET] <subject>\n# Keep it short\n",
+		},
+		{
+			name:          "Missing file",
+			template:      ".gitmessage",
+			expectedError: "failed to read commit.template",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Keep a template in the user's global config out of the test
+			t.Setenv("HOME", t.TempDir())
+			t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+			repo, client := newIndexTestRepo(t, nil)
+			for name, content := range tt.files {
+				if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+					t.Fatalf("failed to write %s: %v", name, err)
+				}
+			}
+			if tt.template != "" {
+				cfg, _ := repo.Config()
+				cfg.Raw.Section("commit").SetOption("template", tt.template)
+				if err := repo.SetConfig(cfg); err != nil {
+					t.Fatalf("failed to set commit.template: %v", err)
+				}
+			}
+
+			template, err := client.GetCommitTemplate()
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("expected error %q, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetCommitTemplate failed: %v", err)
+			}
+			if template != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, template)
+			}
+		})
+	}
+}
+
+func TestClientImpl_GetCurrentBranch(t *testing.T) {
+	repo, client := newIndexTestRepo(t, map[string]string{"a.txt": "a\n"})
+
+	if branch, err := client.GetCurrentBranch(); err != nil || branch != "master" {
+		t.Errorf("expected master, got %q (err %v)", branch, err)
+	}
+	checkoutNewBranch(t, repo, "feature/PROJ-123-login")
+	if branch, err := client.GetCurrentBranch(); err != nil || branch != "feature/PROJ-123-login" {
+		t.Errorf("expected feature/PROJ-123-login, got %q (err %v)", branch, err)
+	}
+
+	head, _ := repo.Head()
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(plumbing.HEAD, head.Hash())); err != nil {
+		t.Fatalf("failed to detach HEAD: %v", err)
+	}
+	if branch, err := client.GetCurrentBranch(); err != nil || branch != "" {
+		t.Errorf("expected no branch when detached, got %q (err %v)", branch, err)
+	}
+}
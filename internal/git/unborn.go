@@ -0,0 +1,71 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// errUnbornHead is returned by operations that need a commit at HEAD when
+// the current branch has none yet
+var errUnbornHead = errors.New("the current branch has no commits yet")
+
+// headUnborn reports whether HEAD of repo points to a branch with no
+// commits yet, as in a freshly initialized repository. go-git reports that
+// as a missing reference, which is not an error here. When the layout is
+// left to the git binary, as linked worktrees are, git has the last word.
+func (c *ClientImpl) headUnborn(repo *git.Repository) (bool, error) {
+	_, err := repo.Head()
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		if c.backend() == nil {
+			return true, nil
+		}
+		hasCommits, err := c.hasCommits()
+		return !hasCommits, err
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get HEAD: %w", err)
+	}
+	return false, nil
+}
+
+// hasCommits asks the git binary whether HEAD resolves to a commit. It
+// settles a missing HEAD reported by go-git, which must not be taken for an
+// unborn branch when git finds commits: building on an empty tree then
+// would delete every tracked file.
+func (c *ClientImpl) hasCommits() (bool, error) {
+	cmd := exec.Command("git", "rev-parse", "--verify", "--quiet", "HEAD")
+	cmd.Dir = c.dir
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check for commits: %w", err)
+	}
+	return true, nil
+}
+
+// HasCommits reports whether HEAD resolves to a commit, as the git binary
+// sees it
+func (c *ClientImpl) HasCommits() (bool, error) {
+	return c.hasCommits()
+}
+
+// confirmUnborn returns nil when the git binary agrees that HEAD has no
+// commits after go-git failed to resolve it with headErr, and an error
+// otherwise
+func (c *ClientImpl) confirmUnborn(headErr error) error {
+	hasCommits, err := c.hasCommits()
+	if err != nil {
+		return fmt.Errorf("failed to resolve HEAD: %w (%v)", headErr, err)
+	}
+	if hasCommits {
+		return fmt.Errorf("failed to resolve HEAD although the repository has commits: %w", headErr)
+	}
+	return nil
+}
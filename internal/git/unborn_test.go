@@ -0,0 +1,101 @@
+package git
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestClientImpl_UnbornHead(t *testing.T) {
+	repo, client := newIndexTestRepo(t, nil)
+	stageFiles(t, repo, map[string]string{"README.md": "# demo\n"})
+
+	state, err := client.DetectState()
+	if err != nil {
+		t.Fatalf("DetectState failed: %v", err)
+	}
+	if state.Type != StateNormal || !state.InitialCommit {
+		t.Errorf("expected a normal state with InitialCommit, got %+v", state)
+	}
+
+	branch, err := client.GetCurrentBranch()
+	if err != nil {
+		t.Fatalf("GetCurrentBranch failed: %v", err)
+	}
+	if branch != "master" {
+		t.Errorf("expected the unborn branch master, got %q", branch)
+	}
+
+	for _, revRange := range []string{"HEAD~3..HEAD", "v1.0.0.."} {
+		commits, err := client.GetCommitRange(revRange)
+		if err != nil {
+			t.Errorf("GetCommitRange(%q) failed: %v", revRange, err)
+		}
+		if len(commits) != 0 {
+			t.Errorf("GetCommitRange(%q): expected no commits, got %d", revRange, len(commits))
+		}
+	}
+
+	diff, err := client.GetBranchDiff("main")
+	if err != nil || diff != "" {
+		t.Errorf("GetBranchDiff: expected an empty diff, got %q, %v", diff, err)
+	}
+	if _, err := client.DetectBaseBranch(); err == nil || !strings.Contains(err.Error(), "no commits yet") {
+		t.Errorf("DetectBaseBranch: expected a no-commits error, got %v", err)
+	}
+	if err := client.CreateTag("v1.0.0", "v1.0.0"); err == nil || !strings.Contains(err.Error(), "no commits yet") {
+		t.Errorf("CreateTag: expected a no-commits error, got %v", err)
+	}
+	if _, _, err := client.RewordCommits("HEAD~1..HEAD", nil); err == nil || !strings.Contains(err.Error(), "no commits yet") {
+		t.Errorf("RewordCommits: expected a no-commits error, got %v", err)
+	}
+
+	diff, err = client.GetStagedDiff()
+	if err != nil {
+		t.Fatalf("GetStagedDiff failed: %v", err)
+	}
+	if !strings.Contains(diff, "README.md") {
+		t.Errorf("expected the staged file in the diff, got %q", diff)
+	}
+
+	if err := client.CommitWithMessage("chore: initial commit"); err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+	if state, err = client.DetectState(); err != nil {
+		t.Fatalf("DetectState failed: %v", err)
+	}
+	if state.InitialCommit {
+		t.Error("expected InitialCommit to be cleared once HEAD has a commit")
+	}
+}
+
+func TestClientImpl_DetectState_LinkedWorktree(t *testing.T) {
+	requireGit(t)
+	newIndexTestRepo(t, map[string]string{"README.md": "# demo\n"})
+	addLinkedWorktree(t)
+
+	for _, kind := range []BackendKind{BackendAuto, BackendExec} {
+		t.Run(string(kind), func(t *testing.T) {
+			state, err := NewClientWithBackend(DiffOptions{}, kind).DetectState()
+			if err != nil {
+				t.Fatalf("DetectState failed: %v", err)
+			}
+			if state.InitialCommit {
+				t.Error("expected a worktree of a branch with commits not to be an initial commit")
+			}
+		})
+	}
+}
+
+func TestClientImpl_DetectState_UnbornExec(t *testing.T) {
+	requireGit(t)
+	repo, _ := newIndexTestRepo(t, nil)
+	stageFiles(t, repo, map[string]string{"README.md": "# demo\n"})
+
+	state, err := NewClientWithBackend(DiffOptions{}, BackendExec).DetectState()
+	if err != nil {
+		t.Fatalf("DetectState failed: %v", err)
+	}
+	if !state.InitialCommit {
+		t.Error("expected git to confirm the branch has no commits")
+	}
+}
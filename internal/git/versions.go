@@ -0,0 +1,95 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/format/index"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// GetFileVersions returns the content of a path in HEAD and in the index,
+// the two sides of its staged diff. A side where the path does not exist,
+// e.g. HEAD for a new file or the index for a deleted one, is empty.
+func (c *ClientImpl) GetFileVersions(path string) (string, string, error) {
+	if b := c.backend(); b != nil {
+		return b.GetFileVersions(path)
+	}
+	repo, err := c.openRepo()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	var head string
+	ref, err := repo.Head()
+	switch {
+	case errors.Is(err, plumbing.ErrReferenceNotFound):
+	case err != nil:
+		return "", "", fmt.Errorf("failed to get HEAD: %w", err)
+	default:
+		commit, err := repo.CommitObject(ref.Hash())
+		if err != nil {
+			return "", "", fmt.Errorf("failed to read HEAD commit: %w", err)
+		}
+		file, err := commit.File(path)
+		if err != nil && !errors.Is(err, object.ErrFileNotFound) {
+			return "", "", fmt.Errorf("failed to read %s in HEAD: %w", path, err)
+		}
+		if err == nil {
+			if head, err = file.Contents(); err != nil {
+				return "", "", fmt.Errorf("failed to read %s in HEAD: %w", path, err)
+			}
+		}
+	}
+
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read index: %w", err)
+	}
+	entry, err := idx.Entry(path)
+	if errors.Is(err, index.ErrEntryNotFound) {
+		return head, "", nil
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read %s in the index: %w", path, err)
+	}
+	blob, err := repo.BlobObject(entry.Hash)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read %s in the index: %w", path, err)
+	}
+	reader, err := blob.Reader()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read %s in the index: %w", path, err)
+	}
+	defer reader.Close()
+	staged, err := io.ReadAll(reader)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read %s in the index: %w", path, err)
+	}
+	return head, string(staged), nil
+}
+
+// GetFileVersions reads both sides of a path's staged diff with git show
+func (b *execBackend) GetFileVersions(path string) (string, string, error) {
+	// git show fails when the path is missing on a side; ls-files and
+	// ls-tree tell that apart from a real failure
+	var head, staged string
+	if out, err := b.run("ls-tree", "--name-only", "HEAD", "--", path); err == nil && strings.TrimSpace(out) != "" {
+		if head, err = b.run("show", "HEAD:"+path); err != nil {
+			return "", "", err
+		}
+	}
+	out, err := b.run("ls-files", "--cached", "--", path)
+	if err != nil {
+		return "", "", err
+	}
+	if strings.TrimSpace(out) != "" {
+		if staged, err = b.run("show", ":"+path); err != nil {
+			return "", "", err
+		}
+	}
+	return head, staged, nil
+}
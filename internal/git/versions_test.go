@@ -0,0 +1,56 @@
+package git
+
+import (
+	"os"
+	"testing"
+)
+
+func TestClientImpl_GetFileVersions(t *testing.T) {
+	for _, kind := range []BackendKind{BackendGoGit, BackendExec} {
+		t.Run(string(kind), func(t *testing.T) {
+			if kind == BackendExec {
+				requireGit(t)
+			}
+			repo, _ := newIndexTestRepo(t, map[string]string{
+				"main.go": "package main // v1\n",
+				"old.go":  "package main // old\n",
+			})
+			stageFiles(t, repo, map[string]string{
+				"main.go":     "package main // v2\n",
+				"auth/new.go": "package auth\n",
+			})
+			worktree, err := repo.Worktree()
+			if err != nil {
+				t.Fatalf("failed to get worktree: %v", err)
+			}
+			if _, err := worktree.Remove("old.go"); err != nil {
+				t.Fatalf("failed to stage the removal: %v", err)
+			}
+			// Unstaged edits are not part of the staged diff
+			if err := os.WriteFile("main.go", []byte("package main // v3\n"), 0644); err != nil {
+				t.Fatalf("failed to write main.go: %v", err)
+			}
+			client := NewClientWithBackend(DiffOptions{ContextLines: DefaultContextLines}, kind)
+
+			tests := []struct {
+				path           string
+				expectedHead   string
+				expectedStaged string
+			}{
+				{path: "main.go", expectedHead: "package main // v1\n", expectedStaged: "package main // v2\n"},
+				{path: "auth/new.go", expectedStaged: "package auth\n"},
+				{path: "old.go", expectedHead: "package main // old\n"},
+				{path: "missing.go"},
+			}
+			for _, tt := range tests {
+				head, staged, err := client.GetFileVersions(tt.path)
+				if err != nil {
+					t.Fatalf("GetFileVersions(%s) failed: %v", tt.path, err)
+				}
+				if head != tt.expectedHead || staged != tt.expectedStaged {
+					t.Errorf("%s: expected %q and %q, got %q and %q", tt.path, tt.expectedHead, tt.expectedStaged, head, staged)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package git
+
+import (
+	"fmt"
+
+	git "github.com/go-git/go-git/v5"
+)
+
+// GetWorktreeDiff returns the diff of the working tree against HEAD, staged
+// or not. Untracked files are included as new files when includeUntracked
+// is set; files matched by .gitignore never are.
+func (c *ClientImpl) GetWorktreeDiff(includeUntracked bool) (string, error) {
+	return c.diffChanges(worktreeChange(includeUntracked))
+}
+
+// GetWorktreeFiles returns the paths that differ between HEAD and the
+// working tree, sorted by name, like GetStagedFiles does for the index
+func (c *ClientImpl) GetWorktreeFiles(includeUntracked bool) ([]StagedFile, error) {
+	return c.changedFiles(worktreeChange(includeUntracked))
+}
+
+// StageAll stages every change in the working tree that the path filters
+// include, like "git add -u", or "git add -A" with includeUntracked. Files
+// matched by .gitignore are never staged.
+func (c *ClientImpl) StageAll(includeUntracked bool) error {
+	repo, err := c.openRepo()
+	if err != nil {
+		return fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("failed to get worktree: %w", err)
+	}
+
+	// Status leaves out ignored files, so only paths it lists are staged
+	status, err := worktree.Status()
+	if err != nil {
+		return fmt.Errorf("failed to get status: %w", err)
+	}
+
+	for filePath, fileStatus := range status {
+		if fileStatus.Worktree == git.Unmodified || !c.options.includes(filePath) {
+			continue
+		}
+		if fileStatus.Worktree == git.Untracked && !includeUntracked {
+			continue
+		}
+		// Add also stages deletions of files missing from the worktree
+		if _, err := worktree.Add(filePath); err != nil {
+			return fmt.Errorf("failed to stage %s: %w", filePath, err)
+		}
+	}
+	return nil
+}
+
+// worktreeChange returns how a path differs between HEAD and the working
+// tree, combining what is staged with what is not
+func worktreeChange(includeUntracked bool) func(*git.FileStatus) git.StatusCode {
+	return func(fileStatus *git.FileStatus) git.StatusCode {
+		switch {
+		case fileStatus.Worktree == git.Untracked:
+			if includeUntracked {
+				return git.Added
+			}
+			return git.Unmodified
+		case fileStatus.Staging == git.Added:
+			if fileStatus.Worktree == git.Deleted {
+				// Added and deleted again, so HEAD and the worktree agree
+				return git.Unmodified
+			}
+			return git.Added
+		case fileStatus.Staging == git.Deleted || fileStatus.Worktree == git.Deleted:
+			return git.Deleted
+		case fileStatus.Staging == git.Renamed:
+			return git.Renamed
+		case fileStatus.Staging == git.Modified || fileStatus.Worktree == git.Modified:
+			return git.Modified
+		}
+		return git.Unmodified
+	}
+}
@@ -0,0 +1,134 @@
+package git
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeWorktree sets up a working tree with one staged and one unstaged
+// modification, a deleted file, an untracked file and an ignored file
+func writeWorktree(t *testing.T) Client {
+	t.Helper()
+	repo, client := newIndexTestRepo(t, map[string]string{
+		".gitignore": "*.log\n",
+		"staged.go":  "package main\n",
+		"edited.go":  "package main\n",
+		"removed.go": "package main\n",
+	})
+	stageFiles(t, repo, map[string]string{"staged.go": "package main\n\nfunc staged() {}\n"})
+	for name, content := range map[string]string{
+		"edited.go": "package main\n\nfunc edited() {}\n",
+		"new.go":    "package main\n\nfunc added() {}\n",
+		"debug.log": "noise\n",
+	} {
+		if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+	if err := os.Remove("removed.go"); err != nil {
+		t.Fatalf("failed to remove removed.go: %v", err)
+	}
+	return client
+}
+
+func TestClientImpl_Worktree(t *testing.T) {
+	tests := []struct {
+		name             string
+		includeUntracked bool
+		add              bool
+		expectedFiles    []string
+		expectedStaged   []string
+		expectedInDiff   []string
+		unexpectedInDiff []string
+	}{
+		{
+			name:             "All describes tracked changes only",
+			expectedFiles:    []string{"edited.go:M", "removed.go:D", "staged.go:M"},
+			expectedStaged:   []string{"staged.go:M"},
+			expectedInDiff:   []string{"+func edited() {}", "+func staged() {}", "+++ /dev/null"},
+			unexpectedInDiff: []string{"new.go", "debug.log"},
+		},
+		{
+			name:             "All with untracked files",
+			includeUntracked: true,
+			expectedFiles:    []string{"edited.go:M", "new.go:A", "removed.go:D", "staged.go:M"},
+			expectedStaged:   []string{"staged.go:M"},
+			expectedInDiff:   []string{"+func added() {}", "+func edited() {}"},
+			unexpectedInDiff: []string{"debug.log"},
+		},
+		{
+			name:             "Add stages tracked changes",
+			add:              true,
+			expectedFiles:    []string{"edited.go:M", "removed.go:D", "staged.go:M"},
+			expectedStaged:   []string{"edited.go:M", "removed.go:D", "staged.go:M"},
+			expectedInDiff:   []string{"+func edited() {}"},
+			unexpectedInDiff: []string{"new.go", "debug.log"},
+		},
+		{
+			name:             "Add with untracked files never stages ignored ones",
+			includeUntracked: true,
+			add:              true,
+			expectedFiles:    []string{"edited.go:M", "new.go:A", "removed.go:D", "staged.go:M"},
+			expectedStaged:   []string{"edited.go:M", "new.go:A", "removed.go:D", "staged.go:M"},
+			expectedInDiff:   []string{"+func added() {}"},
+			unexpectedInDiff: []string{"debug.log"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := writeWorktree(t)
+
+			files, err := client.GetWorktreeFiles(tt.includeUntracked)
+			if err != nil {
+				t.Fatalf("GetWorktreeFiles failed: %v", err)
+			}
+			var paths []string
+			for _, f := range files {
+				paths = append(paths, f.Path+":"+string(f.Change))
+			}
+			if !reflect.DeepEqual(paths, tt.expectedFiles) {
+				t.Errorf("expected files %v, got %v", tt.expectedFiles, paths)
+			}
+
+			diff, err := client.GetWorktreeDiff(tt.includeUntracked)
+			if err != nil {
+				t.Fatalf("GetWorktreeDiff failed: %v", err)
+			}
+			for _, want := range tt.expectedInDiff {
+				if !strings.Contains(diff, want) {
+					t.Errorf("expected %q in diff:\n%s", want, diff)
+				}
+			}
+			for _, unwanted := range tt.unexpectedInDiff {
+				if strings.Contains(diff, unwanted) {
+					t.Errorf("did not expect %q in diff:\n%s", unwanted, diff)
+				}
+			}
+
+			if tt.add {
+				if err := client.StageAll(tt.includeUntracked); err != nil {
+					t.Fatalf("StageAll failed: %v", err)
+				}
+			}
+			if staged := stagedPaths(t, client); !reflect.DeepEqual(staged, tt.expectedStaged) {
+				t.Errorf("expected staged %v, got %v", tt.expectedStaged, staged)
+			}
+		})
+	}
+}
+
+func TestClientImpl_StageAll_PathFilters(t *testing.T) {
+	writeWorktree(t)
+	client := NewClientWithOptions(DiffOptions{Ignore: []string{"edited.go"}})
+
+	if err := client.StageAll(true); err != nil {
+		t.Fatalf("StageAll failed: %v", err)
+	}
+	expected := []string{"new.go:A", "removed.go:D", "staged.go:M"}
+	if staged := stagedPaths(t, client); !reflect.DeepEqual(staged, expected) {
+		t.Errorf("expected staged %v, got %v", expected, staged)
+	}
+}
@@ -0,0 +1,132 @@
+// Package github posts comments on pull requests through the GitHub REST
+// API, for the action command
+package github
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultAPIURL is the REST API of github.com; GitHub Enterprise runners
+// set GITHUB_API_URL to their own
+const DefaultAPIURL = "https://api.github.com"
+
+// requestTimeout caps one API request
+const requestTimeout = 30 * time.Second
+
+// commentsPerPage is the most comments the API returns per page
+const commentsPerPage = 100
+
+// maxCommentPages caps the pages of comments searched for a sticky comment
+const maxCommentPages = 10
+
+// Client calls the REST API of one repository
+type Client struct {
+	// APIURL is the API root, e.g. DefaultAPIURL
+	APIURL string
+	// Token authenticates the requests, e.g. GITHUB_TOKEN
+	Token string
+	// Repository is "owner/name"
+	Repository string
+	// HTTPClient sends the requests. Nil means a client with a timeout.
+	HTTPClient *http.Client
+}
+
+// Comment is an issue or pull request comment
+type Comment struct {
+	ID   int64  `json:"id"`
+	Body string `json:"body"`
+}
+
+// UpsertComment makes body the sticky comment of pull request number: the
+// comment whose body contains marker is edited if there is one, so a rerun
+// does not add another, and a new comment is posted otherwise. The marker
+// should be part of body, e.g. as an HTML comment. It reports whether an
+// existing comment was updated.
+func (c *Client) UpsertComment(number int, marker, body string) (bool, error) {
+	existing, err := c.findComment(number, marker)
+	if err != nil {
+		return false, err
+	}
+	payload := map[string]string{"body": body}
+	if existing != nil {
+		return true, c.do(http.MethodPatch, fmt.Sprintf("/repos/%s/issues/comments/%d", c.Repository, existing.ID), payload, nil)
+	}
+	return false, c.do(http.MethodPost, fmt.Sprintf("/repos/%s/issues/%d/comments", c.Repository, number), payload, nil)
+}
+
+// findComment returns the first comment on pull request number whose body
+// contains marker, or nil
+func (c *Client) findComment(number int, marker string) (*Comment, error) {
+	for page := 1; page <= maxCommentPages; page++ {
+		var comments []Comment
+		path := fmt.Sprintf("/repos/%s/issues/%d/comments?per_page=%d&page=%d", c.Repository, number, commentsPerPage, page)
+		if err := c.do(http.MethodGet, path, nil, &comments); err != nil {
+			return nil, err
+		}
+		for i := range comments {
+			if strings.Contains(comments[i].Body, marker) {
+				return &comments[i], nil
+			}
+		}
+		if len(comments) < commentsPerPage {
+			break
+		}
+	}
+	return nil, nil
+}
+
+// do sends a request with payload as its JSON body and decodes the response
+// into result when it is not nil
+func (c *Client) do(method, path string, payload, result interface{}) error {
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+	apiURL := c.APIURL
+	if apiURL == "" {
+		apiURL = DefaultAPIURL
+	}
+	req, err := http.NewRequest(method, strings.TrimRight(apiURL, "/")+path, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: requestTimeout}
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s failed: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s %s failed: %s: %s", method, path, resp.Status, strings.TrimSpace(string(detail)))
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 8<<20)).Decode(result); err != nil {
+		return fmt.Errorf("failed to parse the response to %s %s: %w", method, path, err)
+	}
+	return nil
+}
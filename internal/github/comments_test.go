@@ -0,0 +1,104 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeIssues serves the issue comment endpoints of octo/repo from comments
+type fakeIssues struct {
+	mu       sync.Mutex
+	comments []Comment
+	requests []string
+}
+
+func (f *fakeIssues) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+	if r.Header.Get("Authorization") != "Bearer secret" {
+		http.Error(w, `{"message": "Bad credentials"}`, http.StatusUnauthorized)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/repos/octo/repo/issues/7/comments":
+		var page int
+		fmt.Sscan(r.URL.Query().Get("page"), &page)
+		start, end := (page-1)*commentsPerPage, page*commentsPerPage
+		if start > len(f.comments) {
+			start = len(f.comments)
+		}
+		if end > len(f.comments) {
+			end = len(f.comments)
+		}
+		json.NewEncoder(w).Encode(f.comments[start:end])
+	case r.Method == http.MethodPost && r.URL.Path == "/repos/octo/repo/issues/7/comments":
+		var comment Comment
+		json.NewDecoder(r.Body).Decode(&comment)
+		comment.ID = int64(len(f.comments) + 1)
+		f.comments = append(f.comments, comment)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(comment)
+	case r.Method == http.MethodPatch && strings.HasPrefix(r.URL.Path, "/repos/octo/repo/issues/comments/"):
+		var id int64
+		fmt.Sscan(strings.TrimPrefix(r.URL.Path, "/repos/octo/repo/issues/comments/"), &id)
+		var update Comment
+		json.NewDecoder(r.Body).Decode(&update)
+		for i := range f.comments {
+			if f.comments[i].ID == id {
+				f.comments[i].Body = update.Body
+				json.NewEncoder(w).Encode(f.comments[i])
+				return
+			}
+		}
+		http.NotFound(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestClient_UpsertComment(t *testing.T) {
+	fake := &fakeIssues{}
+	for i := 0; i < commentsPerPage; i++ {
+		fake.comments = append(fake.comments, Comment{ID: int64(i + 1), Body: "LGTM"})
+	}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	client := &Client{APIURL: server.URL, Token: "secret", Repository: "octo/repo"}
+
+	updated, err := client.UpsertComment(7, "<!-- sticky -->", "<!-- sticky -->\nfirst")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if updated || len(fake.comments) != commentsPerPage+1 {
+		t.Fatalf("expected a new comment, got updated %v and %d comments", updated, len(fake.comments))
+	}
+
+	updated, err = client.UpsertComment(7, "<!-- sticky -->", "<!-- sticky -->\nsecond")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !updated || len(fake.comments) != commentsPerPage+1 {
+		t.Fatalf("expected the comment on the second page to be updated, got updated %v and %d comments", updated, len(fake.comments))
+	}
+	if body := fake.comments[commentsPerPage].Body; body != "<!-- sticky -->\nsecond" {
+		t.Errorf("expected the updated body, got %q", body)
+	}
+}
+
+func TestClient_UpsertComment_Error(t *testing.T) {
+	server := httptest.NewServer(&fakeIssues{})
+	defer server.Close()
+	client := &Client{APIURL: server.URL, Token: "wrong", Repository: "octo/repo"}
+
+	_, err := client.UpsertComment(7, "<!-- sticky -->", "body")
+	if err == nil || !strings.Contains(err.Error(), "401 Unauthorized") || !strings.Contains(err.Error(), "Bad credentials") {
+		t.Errorf("expected the API error, got %v", err)
+	}
+}
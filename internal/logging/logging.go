@@ -0,0 +1,126 @@
+// Package logging builds the log/slog logger the tool's diagnostics go
+// to: the plain stderr lines it has always printed, or JSON records that
+// CI systems can index.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Values of the log_format config key
+const (
+	// FormatText prints warnings as "Warning: ..." lines
+	FormatText = "text"
+	// FormatJSON prints one JSON object per record
+	FormatJSON = "json"
+)
+
+// Attribute keys the packages log with
+const (
+	// KeyStage is the part of the run a record is about, e.g. diff,
+	// generate or commit
+	KeyStage = "stage"
+	// KeyDuration is how long the stage took
+	KeyDuration = "duration"
+	// KeyModel is the model a request went to
+	KeyModel = "model"
+	// KeyStatus is the HTTP status of an API response
+	KeyStatus = "status"
+	// KeyError is the error behind a warning
+	KeyError = "error"
+)
+
+// New returns a logger writing to w in format, FormatText when empty.
+// Warnings and errors are logged; with verbose, the debug records about
+// each stage are too.
+func New(w io.Writer, format string, verbose bool) *slog.Logger {
+	level := slog.LevelWarn
+	if verbose {
+		level = slog.LevelDebug
+	}
+	if format == FormatJSON {
+		return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level}))
+	}
+	return slog.New(&textHandler{w: w, level: level, mu: &sync.Mutex{}})
+}
+
+// Default is the logger used when none is configured: warnings as text on
+// stderr
+func Default() *slog.Logger {
+	return New(os.Stderr, FormatText, false)
+}
+
+// textHandler writes a record as the line the tool printed before it
+// logged through slog: "Warning: <message>: <error>". Below the warn level,
+// only logged with verbose, the other attributes follow as key=value.
+type textHandler struct {
+	w     io.Writer
+	level slog.Level
+	// mu is shared by the handlers derived with WithAttrs and WithGroup
+	mu     *sync.Mutex
+	attrs  []slog.Attr
+	prefix string
+}
+
+func (h *textHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *textHandler) Handle(_ context.Context, r slog.Record) error {
+	var sb strings.Builder
+	switch {
+	case r.Level >= slog.LevelError:
+		sb.WriteString("Error: ")
+	case r.Level >= slog.LevelWarn:
+		sb.WriteString("Warning: ")
+	}
+	sb.WriteString(r.Message)
+
+	attrs := append([]slog.Attr{}, h.attrs...)
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, slog.Attr{Key: h.prefix + a.Key, Value: a.Value})
+		return true
+	})
+	for _, a := range attrs {
+		if a.Key == KeyError {
+			sb.WriteString(": " + a.Value.String())
+		}
+	}
+	if r.Level < slog.LevelWarn {
+		for _, a := range attrs {
+			if a.Key != KeyError {
+				fmt.Fprintf(&sb, " %s=%v", a.Key, a.Value.Resolve())
+			}
+		}
+	}
+	sb.WriteString("\n")
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, sb.String())
+	return err
+}
+
+func (h *textHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	derived := *h
+	derived.attrs = append([]slog.Attr{}, h.attrs...)
+	for _, a := range attrs {
+		derived.attrs = append(derived.attrs, slog.Attr{Key: h.prefix + a.Key, Value: a.Value})
+	}
+	return &derived
+}
+
+func (h *textHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	derived := *h
+	derived.prefix = h.prefix + name + "."
+	return &derived
+}
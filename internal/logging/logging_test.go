@@ -0,0 +1,70 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNew_Text(t *testing.T) {
+	tests := []struct {
+		name     string
+		verbose  bool
+		expected string
+	}{
+		{
+			name:     "Warnings only",
+			expected: "Warning: failed to load rules: permission denied\n",
+		},
+		{
+			name:     "Verbose adds the stages",
+			verbose:  true,
+			expected: "diff finished stage=diff duration=2s status=ok\nWarning: failed to load rules: permission denied\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := New(&buf, FormatText, tt.verbose)
+			logger.Debug("diff finished", KeyStage, "diff", KeyDuration, 2*time.Second, KeyStatus, "ok")
+			logger.Warn("failed to load rules", KeyStage, "rules", KeyError, errors.New("permission denied"))
+			if buf.String() != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, buf.String())
+			}
+		})
+	}
+}
+
+func TestNew_TextWithAttrs(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, "", true).With(KeyModel, "llama3").WithGroup("request")
+	logger.Debug("API response", KeyStatus, 200)
+	if expected := "API response model=llama3 request.status=200\n"; buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestNew_JSON(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, FormatJSON, false)
+	logger.Debug("API response", KeyStage, "generate")
+	logger.Warn("rate limit hit, retrying in 2s", KeyStage, "generate", KeyModel, "llama3", "attempt", 2)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("expected one record at the warn level, got:\n%s", buf.String())
+	}
+	var record map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &record); err != nil {
+		t.Fatalf("expected a JSON record, got %q: %v", lines[0], err)
+	}
+	for key, want := range map[string]interface{}{"level": "WARN", "msg": "rate limit hit, retrying in 2s", "stage": "generate", "model": "llama3", "attempt": 2.0} {
+		if record[key] != want {
+			t.Errorf("%s: expected %v, got %v", key, want, record[key])
+		}
+	}
+}
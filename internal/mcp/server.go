@@ -0,0 +1,207 @@
+// Package mcp implements the stdio transport of the Model Context Protocol
+// for a server that only offers tools: newline-delimited JSON-RPC 2.0 with
+// the initialize, ping, tools/list and tools/call methods
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// LatestProtocolVersion is the protocol revision offered to clients that
+// ask for one this server does not know
+const LatestProtocolVersion = "2025-06-18"
+
+// supportedVersions are the protocol revisions a client may ask for; the
+// tool methods are the same in all of them
+var supportedVersions = map[string]bool{
+	"2024-11-05":          true,
+	"2025-03-26":          true,
+	LatestProtocolVersion: true,
+}
+
+// maxMessageBytes caps one message, which holds at most a diff
+const maxMessageBytes = 4 << 20
+
+// JSON-RPC error codes
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+)
+
+// Tool is a tool the server offers
+type Tool struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// InputSchema is the JSON schema of the arguments
+	InputSchema json.RawMessage `json:"inputSchema"`
+	// Call runs the tool with the arguments of a tools/call request and
+	// returns its text. An error is reported to the client as a failed tool
+	// call, which the model can read, not as a protocol error.
+	Call func(arguments json.RawMessage) (string, error) `json:"-"`
+}
+
+// Server answers the requests of one client
+type Server struct {
+	// Name and Version identify the server to the client
+	Name    string
+	Version string
+	Tools   []Tool
+}
+
+// message is a JSON-RPC request, notification or response
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return e.Message
+}
+
+// content is one block of a tool result
+type content struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// callResult is the result of tools/call
+type callResult struct {
+	Content []content `json:"content"`
+	IsError bool      `json:"isError,omitempty"`
+}
+
+// Serve answers the messages read from r, one per line, on w until r ends.
+// Requests are answered in order; notifications are not answered.
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxMessageBytes)
+	encoder := json.NewEncoder(w)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		resp := s.handle(line)
+		if resp == nil {
+			continue
+		}
+		if err := encoder.Encode(resp); err != nil {
+			return fmt.Errorf("failed to write a response: %w", err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read a request: %w", err)
+	}
+	return nil
+}
+
+// handle answers one message, or returns nil for a notification
+func (s *Server) handle(line []byte) *message {
+	var req message
+	if err := json.Unmarshal(line, &req); err != nil {
+		return &message{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{Code: CodeParseError, Message: fmt.Sprintf("invalid JSON: %v", err)}}
+	}
+	if req.ID == nil {
+		// Notifications, such as notifications/initialized, need no answer
+		return nil
+	}
+	resp := &message{JSONRPC: "2.0", ID: req.ID}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		resp.Error = &rpcError{Code: CodeInvalidRequest, Message: `expected a JSON-RPC 2.0 request with a method`}
+		return resp
+	}
+
+	result, err := s.call(req.Method, req.Params)
+	if err != nil {
+		rpcErr, ok := err.(*rpcError)
+		if !ok {
+			rpcErr = &rpcError{Code: CodeInvalidParams, Message: err.Error()}
+		}
+		resp.Error = rpcErr
+		return resp
+	}
+	resp.Result = result
+	return resp
+}
+
+// call runs method and returns its result
+func (s *Server) call(method string, params json.RawMessage) (interface{}, error) {
+	switch method {
+	case "initialize":
+		return s.initialize(params)
+	case "ping":
+		return struct{}{}, nil
+	case "tools/list":
+		tools := s.Tools
+		if tools == nil {
+			tools = []Tool{}
+		}
+		return map[string]interface{}{"tools": tools}, nil
+	case "tools/call":
+		return s.callTool(params)
+	default:
+		return nil, &rpcError{Code: CodeMethodNotFound, Message: fmt.Sprintf("method %q is not supported", method)}
+	}
+}
+
+// initialize agrees on the protocol revision and describes the server
+func (s *Server) initialize(params json.RawMessage) (interface{}, error) {
+	var p struct {
+		ProtocolVersion string `json:"protocolVersion"`
+	}
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, fmt.Errorf("invalid initialize params: %w", err)
+		}
+	}
+	version := p.ProtocolVersion
+	if !supportedVersions[version] {
+		version = LatestProtocolVersion
+	}
+	return map[string]interface{}{
+		"protocolVersion": version,
+		"capabilities":    map[string]interface{}{"tools": map[string]interface{}{}},
+		"serverInfo":      map[string]string{"name": s.Name, "version": s.Version},
+	}, nil
+}
+
+// callTool runs the tool a tools/call request names
+func (s *Server) callTool(params json.RawMessage) (interface{}, error) {
+	var p struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid tools/call params: %w", err)
+	}
+	for _, tool := range s.Tools {
+		if tool.Name != p.Name {
+			continue
+		}
+		arguments := p.Arguments
+		if len(arguments) == 0 || string(arguments) == "null" {
+			arguments = json.RawMessage("{}")
+		}
+		text, err := tool.Call(arguments)
+		if err != nil {
+			return callResult{Content: []content{{Type: "text", Text: err.Error()}}, IsError: true}, nil
+		}
+		return callResult{Content: []content{{Type: "text", Text: text}}}, nil
+	}
+	return nil, fmt.Errorf("unknown tool %q", p.Name)
+}
@@ -0,0 +1,203 @@
+package mcp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// testServer offers an echo tool that fails on an empty text
+func testServer() *Server {
+	return &Server{
+		Name:    "test",
+		Version: "1.0.0",
+		Tools: []Tool{{
+			Name:        "echo",
+			Description: "Returns the text",
+			InputSchema: json.RawMessage(`{"type":"object","properties":{"text":{"type":"string"}}}`),
+			Call: func(arguments json.RawMessage) (string, error) {
+				var args struct {
+					Text string `json:"text"`
+				}
+				if err := json.Unmarshal(arguments, &args); err != nil {
+					return "", err
+				}
+				if args.Text == "" {
+					return "", errors.New("text is required")
+				}
+				return args.Text, nil
+			},
+		}},
+	}
+}
+
+// roundTrip writes requests to the server through a pipe, as a client on
+// stdin would, and returns the responses it wrote to stdout
+func roundTrip(t *testing.T, s *Server, requests ...string) []map[string]interface{} {
+	t.Helper()
+	stdin, client := io.Pipe()
+	stdout, server := io.Pipe()
+	served := make(chan error, 1)
+	go func() {
+		served <- s.Serve(stdin, server)
+		server.Close()
+	}()
+	go func() {
+		for _, req := range requests {
+			io.WriteString(client, req+"\n")
+		}
+		client.Close()
+	}()
+
+	var responses []map[string]interface{}
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		var resp map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &resp); err != nil {
+			t.Fatalf("invalid response %q: %v", scanner.Text(), err)
+		}
+		responses = append(responses, resp)
+	}
+	if err := <-served; err != nil {
+		t.Fatalf("Serve failed: %v", err)
+	}
+	return responses
+}
+
+func TestServe_Initialize(t *testing.T) {
+	tests := []struct {
+		name            string
+		params          string
+		expectedVersion string
+	}{
+		{name: "Supported version", params: `{"protocolVersion":"2024-11-05","capabilities":{}}`, expectedVersion: "2024-11-05"},
+		{name: "Unknown version", params: `{"protocolVersion":"1999-01-01"}`, expectedVersion: LatestProtocolVersion},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			responses := roundTrip(t, testServer(),
+				`{"jsonrpc":"2.0","id":1,"method":"initialize","params":`+tt.params+`}`,
+				`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+			)
+			if len(responses) != 1 {
+				t.Fatalf("expected one response, the notification unanswered, got %v", responses)
+			}
+			result := responses[0]["result"].(map[string]interface{})
+			if result["protocolVersion"] != tt.expectedVersion {
+				t.Errorf("expected protocol version %s, got %v", tt.expectedVersion, result["protocolVersion"])
+			}
+			info := result["serverInfo"].(map[string]interface{})
+			if info["name"] != "test" || info["version"] != "1.0.0" {
+				t.Errorf("unexpected server info %v", info)
+			}
+			if _, ok := result["capabilities"].(map[string]interface{})["tools"]; !ok {
+				t.Errorf("expected the tools capability, got %v", result["capabilities"])
+			}
+		})
+	}
+}
+
+func TestServe_ListTools(t *testing.T) {
+	responses := roundTrip(t, testServer(), `{"jsonrpc":"2.0","id":"a","method":"tools/list"}`)
+	if len(responses) != 1 || responses[0]["id"] != "a" {
+		t.Fatalf("expected one response with id a, got %v", responses)
+	}
+	tools := responses[0]["result"].(map[string]interface{})["tools"].([]interface{})
+	if len(tools) != 1 {
+		t.Fatalf("expected one tool, got %v", tools)
+	}
+	tool := tools[0].(map[string]interface{})
+	if tool["name"] != "echo" || tool["inputSchema"] == nil {
+		t.Errorf("unexpected tool %v", tool)
+	}
+}
+
+func TestServe_CallTool(t *testing.T) {
+	tests := []struct {
+		name          string
+		request       string
+		expectedText  string
+		expectIsError bool
+		expectedCode  float64
+	}{
+		{
+			name:         "Success",
+			request:      `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"echo","arguments":{"text":"hi"}}}`,
+			expectedText: "hi",
+		},
+		{
+			name:          "Tool error",
+			request:       `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"echo"}}`,
+			expectedText:  "text is required",
+			expectIsError: true,
+		},
+		{
+			name:         "Unknown tool",
+			request:      `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"rm"}}`,
+			expectedCode: CodeInvalidParams,
+		},
+		{
+			name:         "Unknown method",
+			request:      `{"jsonrpc":"2.0","id":2,"method":"resources/list"}`,
+			expectedCode: CodeMethodNotFound,
+		},
+		{
+			name:         "Not JSON-RPC 2.0",
+			request:      `{"id":2,"method":"ping"}`,
+			expectedCode: CodeInvalidRequest,
+		},
+		{
+			name:         "Invalid JSON",
+			request:      `{"jsonrpc":`,
+			expectedCode: CodeParseError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			responses := roundTrip(t, testServer(), tt.request)
+			if len(responses) != 1 {
+				t.Fatalf("expected one response, got %v", responses)
+			}
+			resp := responses[0]
+			if tt.expectedCode != 0 {
+				rpcErr, ok := resp["error"].(map[string]interface{})
+				if !ok || rpcErr["code"] != tt.expectedCode {
+					t.Fatalf("expected error code %v, got %v", tt.expectedCode, resp)
+				}
+				return
+			}
+			result := resp["result"].(map[string]interface{})
+			text := result["content"].([]interface{})[0].(map[string]interface{})["text"]
+			if text != tt.expectedText {
+				t.Errorf("expected text %q, got %v", tt.expectedText, text)
+			}
+			if isError, _ := result["isError"].(bool); isError != tt.expectIsError {
+				t.Errorf("expected isError %v, got %v", tt.expectIsError, result["isError"])
+			}
+		})
+	}
+}
+
+func TestServe_AnswersInOrder(t *testing.T) {
+	responses := roundTrip(t, testServer(),
+		`{"jsonrpc":"2.0","id":1,"method":"ping"}`,
+		``,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"echo","arguments":{"text":"second"}}}`,
+	)
+	if len(responses) != 2 {
+		t.Fatalf("expected two responses, got %v", responses)
+	}
+	for i, resp := range responses {
+		if resp["id"] != float64(i+1) {
+			t.Errorf("expected response %d to have id %d, got %v", i, i+1, resp["id"])
+		}
+	}
+	if !strings.Contains(responses[1]["result"].(map[string]interface{})["content"].([]interface{})[0].(map[string]interface{})["text"].(string), "second") {
+		t.Errorf("unexpected second response %v", responses[1])
+	}
+}
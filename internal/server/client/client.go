@@ -0,0 +1,121 @@
+// Package client talks to a generate-commit server, see the server package,
+// for editor integrations written in Go
+package client
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+
+	"ai-commit-message-generator/internal/app"
+	"ai-commit-message-generator/internal/server"
+)
+
+// Client sends requests over one connection to the server. It is safe for
+// concurrent use; the requests are answered one at a time.
+type Client struct {
+	mu      sync.Mutex
+	conn    net.Conn
+	reader  *bufio.Reader
+	encoder *json.Encoder
+	nextID  int
+}
+
+// Dial connects to the server listening on the unix socket or, on Windows,
+// the named pipe at path
+func Dial(path string) (*Client, error) {
+	conn, err := server.Dial(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the server on %s: %w", path, err)
+	}
+	return New(conn), nil
+}
+
+// New creates a client that sends requests over conn
+func New(conn net.Conn) *Client {
+	return &Client{
+		conn:    conn,
+		reader:  bufio.NewReader(conn),
+		encoder: json.NewEncoder(conn),
+	}
+}
+
+// Close closes the connection
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Generate asks for a commit message for the staged changes, or for diff
+// when it is not empty
+func (c *Client) Generate(diff string) (*app.Generated, error) {
+	var result app.Generated
+	if err := c.call(server.MethodGenerate, server.GenerateParams{Diff: diff}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Status reports the repository the server works in
+func (c *Client) Status() (*server.Status, error) {
+	var result server.Status
+	if err := c.call(server.MethodStatus, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Config returns the server's effective configuration with the API key
+// masked
+func (c *Client) Config() (map[string]interface{}, error) {
+	var result map[string]interface{}
+	if err := c.call(server.MethodConfig, nil, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// call sends one request and decodes its result into result. A failed
+// request is returned as a *server.Error.
+func (c *Client) call(method string, params, result interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.nextID++
+	req := server.Request{ID: strconv.Itoa(c.nextID), Method: method}
+	if params != nil {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return fmt.Errorf("failed to encode the %s params: %w", method, err)
+		}
+		req.Params = data
+	}
+	if err := c.encoder.Encode(req); err != nil {
+		return fmt.Errorf("failed to send the %s request: %w", method, err)
+	}
+
+	line, err := c.reader.ReadBytes('\n')
+	if err != nil {
+		return fmt.Errorf("failed to read the %s response: %w", method, err)
+	}
+	var resp server.Response
+	if err := json.Unmarshal(line, &resp); err != nil {
+		return fmt.Errorf("invalid %s response: %w", method, err)
+	}
+	if resp.ID != req.ID {
+		return fmt.Errorf("response %q does not match request %q", resp.ID, req.ID)
+	}
+	if resp.Error != nil {
+		return resp.Error
+	}
+	if resp.Result == nil {
+		return errors.New("the server returned neither a result nor an error")
+	}
+	if err := json.Unmarshal(resp.Result, result); err != nil {
+		return fmt.Errorf("invalid %s result: %w", method, err)
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"ai-commit-message-generator/internal/ai"
+	"ai-commit-message-generator/internal/app"
+	"ai-commit-message-generator/internal/config"
+	"ai-commit-message-generator/internal/git"
+	"ai-commit-message-generator/internal/server"
+)
+
+// fakeGit answers the calls the server makes outside a repository; any
+// other call panics on the nil Client
+type fakeGit struct {
+	git.Client
+}
+
+func (fakeGit) IsInsideRepo() (bool, error) {
+	return false, nil
+}
+
+type noRules struct{}
+
+func (noRules) LoadRules([]string) (string, error) {
+	return "", nil
+}
+
+// socketPath returns a path to listen on that is removed after the test
+func socketPath(t *testing.T) string {
+	if runtime.GOOS == "windows" {
+		return `\\.\pipe\generate-commit-test-` + strings.ReplaceAll(t.Name(), "/", "-")
+	}
+	return filepath.Join(t.TempDir(), "server.sock")
+}
+
+// startServer serves a server whose model always answers response and
+// returns a client connected to it. The server is shut down after the test.
+func startServer(t *testing.T, response string) *Client {
+	t.Helper()
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"response": "` + response + `", "done": true}`))
+	}))
+	t.Cleanup(backend.Close)
+
+	cfg := &config.Config{APIKey: "secret-api-key", Model: "test-model"}
+	aiClient := ai.NewClient(cfg.APIKey, backend.URL+"/api/generate", cfg.Model, time.Second)
+	srv := &server.Server{
+		App:    app.NewApp(fakeGit{}, noRules{}, nil, aiClient),
+		Config: cfg,
+	}
+
+	path := socketPath(t)
+	listener, err := server.Listen(path)
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- srv.Serve(ctx, listener) }()
+
+	client, err := Dial(path)
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	t.Cleanup(func() {
+		cancel()
+		if err := <-served; err != nil {
+			t.Errorf("Serve returned %v after shutdown", err)
+		}
+		client.Close()
+	})
+	return client
+}
+
+func TestGenerateWithDiff(t *testing.T) {
+	client := startServer(t, "feat(auth): add login")
+
+	diff := "diff --git a/login.go b/login.go\n--- a/login.go\n+++ b/login.go\n@@ -1 +1,2 @@\n package auth\n+func Login() {}\n"
+	// A second request reuses the connection
+	for i := 0; i < 2; i++ {
+		generated, err := client.Generate(diff)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if generated.Message != "feat(auth): add login" {
+			t.Errorf("expected the model's message, got %q", generated.Message)
+		}
+		if generated.Split {
+			t.Error("expected a commit message, got a split suggestion")
+		}
+	}
+}
+
+func TestGenerateEmptyDiff(t *testing.T) {
+	client := startServer(t, "feat: unused")
+
+	_, err := client.Generate("  \n")
+	var serverErr *server.Error
+	if !errors.As(err, &serverErr) {
+		t.Fatalf("expected a *server.Error, got %v", err)
+	}
+	if serverErr.Code != app.ExitNoStagedChanges {
+		t.Errorf("expected code %d, got %d", app.ExitNoStagedChanges, serverErr.Code)
+	}
+}
+
+func TestStatus(t *testing.T) {
+	client := startServer(t, "feat: unused")
+
+	status, err := client.Status()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if status.InsideRepo {
+		t.Error("expected the server to be outside a repository")
+	}
+	if status.Model != "test-model" {
+		t.Errorf("expected model test-model, got %q", status.Model)
+	}
+}
+
+func TestConfigMasksAPIKey(t *testing.T) {
+	client := startServer(t, "feat: unused")
+
+	values, err := client.Config()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if values["api_key"] != config.MaskSecret("secret-api-key") {
+		t.Errorf("expected the API key to be masked, got %v", values["api_key"])
+	}
+	if values["model"] != "test-model" {
+		t.Errorf("expected model test-model, got %v", values["model"])
+	}
+}
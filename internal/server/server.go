@@ -0,0 +1,264 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"ai-commit-message-generator/internal/app"
+	"ai-commit-message-generator/internal/config"
+)
+
+// DefaultTimeout caps a request when Server.Timeout is not set
+const DefaultTimeout = 2 * time.Minute
+
+// maxRequestBytes caps one request line, which holds at most a diff
+const maxRequestBytes = 2 << 20
+
+// Methods a client can call
+const (
+	MethodGenerate = "generate"
+	MethodStatus   = "status"
+	MethodConfig   = "config"
+)
+
+// Request is one line of JSON a client sends
+type Request struct {
+	// ID is copied to the response so a client can match them up
+	ID     string          `json:"id,omitempty"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// GenerateParams are the parameters of a generate request
+type GenerateParams struct {
+	// Diff is described instead of the staged changes when set, e.g. the
+	// unsaved changes of an editor
+	Diff string `json:"diff,omitempty"`
+}
+
+// Status is the result of a status request
+type Status struct {
+	Version       string `json:"version"`
+	InsideRepo    bool   `json:"inside_repo"`
+	RepoRoot      string `json:"repo_root,omitempty"`
+	StagedChanges bool   `json:"staged_changes"`
+	// State is the operation in progress, e.g. merge or rebase
+	State string `json:"state,omitempty"`
+	Model string `json:"model"`
+}
+
+// Response is one line of JSON the server answers a request with. Exactly
+// one of Result and Error is set.
+type Response struct {
+	ID     string          `json:"id,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *Error          `json:"error,omitempty"`
+}
+
+// Error is a failed request. Code is the exit code the command line would
+// have exited with, see app.ExitCode.
+type Error struct {
+	Message string `json:"message"`
+	Code    int    `json:"code"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Server answers JSON requests from editor integrations on a socket, so the
+// repository and the connection to the provider stay open between requests
+type Server struct {
+	// App generates the messages. It is used by one request at a time.
+	App *app.App
+	// Config is the loaded configuration, returned by the config method
+	Config *config.Config
+	// Timeout caps each request; 0 means DefaultTimeout
+	Timeout time.Duration
+
+	// mu serializes the requests that use App
+	mu sync.Mutex
+	// conns are the open connections, closed on shutdown
+	connsMu sync.Mutex
+	conns   map[net.Conn]struct{}
+	wg      sync.WaitGroup
+}
+
+// Serve answers requests on listener until ctx is done. It then stops
+// accepting connections, lets the requests in progress finish and returns
+// nil.
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	s.connsMu.Lock()
+	s.conns = make(map[net.Conn]struct{})
+	s.connsMu.Unlock()
+
+	stopped := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-stopped:
+		}
+		listener.Close()
+		// Wake connections waiting for their next request; one busy with a
+		// request notices after answering it
+		s.connsMu.Lock()
+		for conn := range s.conns {
+			conn.SetReadDeadline(time.Now())
+		}
+		s.connsMu.Unlock()
+	}()
+	defer close(stopped)
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				s.wg.Wait()
+				return nil
+			}
+			return fmt.Errorf("failed to accept a connection: %w", err)
+		}
+		s.connsMu.Lock()
+		s.conns[conn] = struct{}{}
+		s.connsMu.Unlock()
+		s.wg.Add(1)
+		go s.serveConn(ctx, conn)
+	}
+}
+
+// serveConn answers the requests of one connection, one line each, until
+// the client closes it or the server shuts down
+func (s *Server) serveConn(ctx context.Context, conn net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.connsMu.Lock()
+		delete(s.conns, conn)
+		s.connsMu.Unlock()
+		conn.Close()
+	}()
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 64*1024), maxRequestBytes)
+	encoder := json.NewEncoder(conn)
+	for ctx.Err() == nil && scanner.Scan() {
+		var req Request
+		var resp Response
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			resp.Error = &Error{Message: fmt.Sprintf("invalid request: %v", err), Code: app.ExitValidation}
+		} else {
+			resp = s.handle(req)
+		}
+		if err := encoder.Encode(resp); err != nil {
+			return
+		}
+	}
+}
+
+// handle answers one request within the timeout
+func (s *Server) handle(req Request) Response {
+	timeout := s.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+
+	done := make(chan Response, 1)
+	go func() {
+		result, err := s.call(req)
+		resp := Response{ID: req.ID}
+		if err == nil {
+			resp.Result, err = json.Marshal(result)
+		}
+		if err != nil {
+			resp.Result = nil
+			resp.Error = &Error{Message: err.Error(), Code: app.ExitCode(err)}
+		}
+		done <- resp
+	}()
+
+	select {
+	case resp := <-done:
+		return resp
+	case <-time.After(timeout):
+		return Response{ID: req.ID, Error: &Error{
+			Message: fmt.Sprintf("the request took longer than %v", timeout),
+			Code:    app.ExitAIUnavailable,
+		}}
+	}
+}
+
+// call runs the method of req and returns its result
+func (s *Server) call(req Request) (interface{}, error) {
+	switch req.Method {
+	case MethodGenerate:
+		var params GenerateParams
+		if len(req.Params) > 0 {
+			if err := json.Unmarshal(req.Params, &params); err != nil {
+				return nil, fmt.Errorf("invalid generate params: %w", err)
+			}
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return s.App.Generate(params.Diff)
+	case MethodStatus:
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return s.status()
+	case MethodConfig:
+		return s.config()
+	default:
+		return nil, fmt.Errorf("unknown method %q (expected %q, %q or %q)", req.Method, MethodGenerate, MethodStatus, MethodConfig)
+	}
+}
+
+// status reports the repository the server works in
+func (s *Server) status() (*Status, error) {
+	status := &Status{Version: app.CurrentBuild().Version}
+	if s.Config != nil {
+		status.Model = s.Config.Model
+	}
+
+	inside, err := s.App.Git.IsInsideRepo()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check the repository: %w", err)
+	}
+	status.InsideRepo = inside
+	if !inside {
+		return status, nil
+	}
+
+	if status.RepoRoot, err = s.App.Git.GetRepoRoot(); err != nil {
+		return nil, err
+	}
+	if status.StagedChanges, err = s.App.Git.HasStagedChanges(); err != nil {
+		return nil, fmt.Errorf("failed to check for staged changes: %w", err)
+	}
+	if state, err := s.App.Git.DetectState(); err == nil && state != nil {
+		status.State = state.Type.String()
+	}
+	return status, nil
+}
+
+// config returns the effective configuration with the API key masked
+func (s *Server) config() (map[string]interface{}, error) {
+	if s.Config == nil {
+		return nil, errors.New("no configuration is loaded")
+	}
+	data, err := json.Marshal(s.Config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the configuration: %w", err)
+	}
+	var values map[string]interface{}
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("failed to encode the configuration: %w", err)
+	}
+	if key, ok := values["api_key"].(string); ok {
+		values["api_key"] = config.MaskSecret(key)
+	}
+	return values, nil
+}
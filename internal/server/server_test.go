@@ -0,0 +1,106 @@
+package server
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"ai-commit-message-generator/internal/ai"
+	"ai-commit-message-generator/internal/app"
+)
+
+// slowAI answers commit message requests once release is closed; any other
+// call panics on the nil Client
+type slowAI struct {
+	ai.Client
+	release chan struct{}
+}
+
+func (s slowAI) GenerateCommitMessage(req ai.CommitRequest) (string, error) {
+	<-s.release
+	return "feat: late", nil
+}
+
+type noRules struct{}
+
+func (noRules) LoadRules([]string) (string, error) {
+	return "", nil
+}
+
+func TestHandleTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	s := &Server{App: app.NewApp(nil, noRules{}, nil, slowAI{release: release}), Timeout: 10 * time.Millisecond}
+
+	params, _ := json.Marshal(GenerateParams{Diff: "diff --git a/a.go b/a.go\n+x\n"})
+	resp := s.handle(Request{ID: "1", Method: MethodGenerate, Params: params})
+	if resp.ID != "1" {
+		t.Errorf("expected ID 1, got %q", resp.ID)
+	}
+	if resp.Error == nil || !strings.Contains(resp.Error.Message, "took longer than") {
+		t.Fatalf("expected a timeout error, got %+v", resp)
+	}
+	if resp.Error.Code != app.ExitAIUnavailable {
+		t.Errorf("expected code %d, got %d", app.ExitAIUnavailable, resp.Error.Code)
+	}
+}
+
+func TestHandleUnknownMethod(t *testing.T) {
+	s := &Server{}
+	resp := s.handle(Request{ID: "7", Method: "commit"})
+	if resp.Error == nil || !strings.Contains(resp.Error.Message, `unknown method "commit"`) {
+		t.Fatalf("expected an unknown method error, got %+v", resp)
+	}
+	if resp.Result != nil {
+		t.Errorf("expected no result, got %s", resp.Result)
+	}
+}
+
+func TestListen(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("named pipes have no file to check")
+	}
+	dir := t.TempDir()
+
+	t.Run("Restricts the socket to the current user", func(t *testing.T) {
+		path := filepath.Join(dir, "private.sock")
+		listener, err := Listen(path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer listener.Close()
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if perm := info.Mode().Perm(); perm != 0600 {
+			t.Errorf("expected mode 0600, got %o", perm)
+		}
+	})
+
+	t.Run("Refuses a socket in use", func(t *testing.T) {
+		path := filepath.Join(dir, "busy.sock")
+		listener, err := Listen(path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer listener.Close()
+		if _, err := Listen(path); err == nil || !strings.Contains(err.Error(), "already listening") {
+			t.Errorf("expected an already listening error, got %v", err)
+		}
+	})
+
+	t.Run("Rejects a file that is not a socket", func(t *testing.T) {
+		path := filepath.Join(dir, "file")
+		if err := os.WriteFile(path, []byte("keep me"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Listen(path); err == nil || !strings.Contains(err.Error(), "not a socket") {
+			t.Errorf("expected a not a socket error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,43 @@
+//go:build !windows
+
+package server
+
+import (
+	"fmt"
+	"net"
+	"os"
+)
+
+// Listen creates a unix socket at path that only the current user can
+// connect to. A stale socket left by a server that is gone is replaced; any
+// other file at path is an error.
+func Listen(path string) (net.Listener, error) {
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if conn, err := Dial(path); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("a server is already listening on %s", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove the stale socket: %w", err)
+		}
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", path, err)
+	}
+	// The socket's permissions are the only authentication
+	if err := os.Chmod(path, 0600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to restrict the socket to the current user: %w", err)
+	}
+	return listener, nil
+}
+
+// Dial connects to the server listening on the unix socket at path
+func Dial(path string) (net.Conn, error) {
+	return net.Dial("unix", path)
+}
@@ -0,0 +1,42 @@
+//go:build windows
+
+package server
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/Microsoft/go-winio"
+	"golang.org/x/sys/windows"
+)
+
+// Listen creates a named pipe at path, e.g. \\.\pipe\generate-commit, that
+// only the current user can connect to
+func Listen(path string) (net.Listener, error) {
+	sid, err := currentUserSID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to restrict the pipe to the current user: %w", err)
+	}
+	// The pipe's access list is the only authentication
+	listener, err := winio.ListenPipe(path, &winio.PipeConfig{
+		SecurityDescriptor: "D:P(A;;GA;;;" + sid + ")",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", path, err)
+	}
+	return listener, nil
+}
+
+// Dial connects to the server listening on the named pipe at path
+func Dial(path string) (net.Conn, error) {
+	return winio.DialPipe(path, nil)
+}
+
+// currentUserSID returns the SID of the user the process runs as
+func currentUserSID() (string, error) {
+	user, err := windows.GetCurrentProcessToken().GetTokenUser()
+	if err != nil {
+		return "", err
+	}
+	return user.User.Sid.String(), nil
+}
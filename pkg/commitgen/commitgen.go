@@ -0,0 +1,197 @@
+// Package commitgen writes Conventional Commits messages for a change,
+// the way the generate-commit command does, for programs that embed the
+// generator instead of running the command.
+//
+// A Generator sends a prompt built from the change, and the rules and
+// template it was given, to a Provider and cleans up the answer:
+//
+//	gen := commitgen.New(commitgen.WithProvider(commitgen.Ollama(commitgen.OllamaOptions{Model: "llama3"})))
+//	result, err := gen.Generate(ctx, commitgen.GenerateInput{RepoPath: "."})
+//
+// # Stability
+//
+// The exported identifiers of this package are stable: within a major
+// version none is removed or changes meaning, and existing code keeps
+// compiling. New options, struct fields and Provider implementations may
+// be added, so construct structs with field names. The prompt is not part
+// of the API; the messages a given model writes may change between
+// releases. Everything under internal/ is implementation and may change at
+// any time.
+package commitgen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"ai-commit-message-generator/internal/ai"
+	"ai-commit-message-generator/internal/git"
+)
+
+// ErrNoChanges is returned when there is nothing to describe: nothing is
+// staged in the repository, or the diff is blank
+var ErrNoChanges = errors.New("no changes to describe")
+
+// Generator writes commit messages. It is safe for concurrent use.
+type Generator struct {
+	provider Provider
+	rules    string
+	template *ai.CommitTemplate
+}
+
+// Option configures a Generator
+type Option func(*Generator)
+
+// WithProvider sends the prompts to p. The default is Ollama with its
+// default options.
+func WithProvider(p Provider) Option {
+	return func(g *Generator) {
+		g.provider = p
+	}
+}
+
+// WithRules gives the model the team's commit rules, in the format of a
+// .git-commit-rules-for-ai file. Without it the model follows plain
+// Conventional Commits. The repository's rules file is not read.
+func WithRules(rules string) Option {
+	return func(g *Generator) {
+		g.rules = rules
+	}
+}
+
+// WithTemplate makes the message fill in a commit template, in the format
+// of git's commit.template: lines starting with # are instructions for the
+// model and are not copied into the message
+func WithTemplate(template string) Option {
+	return func(g *Generator) {
+		g.template = ai.ParseCommitTemplate(template, "#")
+	}
+}
+
+// New returns a Generator configured by opts
+func New(opts ...Option) *Generator {
+	g := &Generator{}
+	for _, opt := range opts {
+		opt(g)
+	}
+	if g.provider == nil {
+		g.provider = Ollama(OllamaOptions{})
+	}
+	return g
+}
+
+// GenerateInput is the change to describe. Exactly one of RepoPath and
+// Diff is set.
+type GenerateInput struct {
+	// RepoPath is a directory inside a git repository whose staged
+	// changes are described
+	RepoPath string
+	// Diff is a unified diff, e.g. the output of git diff, described
+	// instead of a repository's staged changes
+	Diff string
+}
+
+// FileDiff is one file of the change
+type FileDiff struct {
+	// Path is the path relative to the repository root
+	Path string
+	// Change is the kind of change as git diff --name-status shows it:
+	// A, M, D, R or C
+	Change string
+}
+
+// Result is a generated commit message
+type Result struct {
+	// Message is the commit message: the header, and the body and footers
+	// when the model wrote them, without a trailing newline
+	Message string
+	// Files are the files of the change the message describes
+	Files []FileDiff
+}
+
+// GenerationResult is another name for Result
+type GenerationResult = Result
+
+// Generate writes a commit message for the change in. The model always
+// writes a single message; it is not asked whether the change should be
+// split into several commits. ctx cancels the provider's call.
+func (g *Generator) Generate(ctx context.Context, in GenerateInput) (Result, error) {
+	if (in.RepoPath == "") == (in.Diff == "") {
+		return Result{}, errors.New("set exactly one of RepoPath and Diff")
+	}
+	if err := ctx.Err(); err != nil {
+		return Result{}, err
+	}
+
+	req := ai.CommitRequest{
+		Rules:    g.rules,
+		Template: g.template,
+		NoSplit:  true,
+		GitState: &git.GitState{Type: git.StateNormal},
+	}
+	var files []git.StagedFile
+	if in.RepoPath != "" {
+		var err error
+		req.Diff, files, req.GitState, err = stagedChanges(in.RepoPath)
+		if err != nil {
+			return Result{}, err
+		}
+	} else {
+		if strings.TrimSpace(in.Diff) == "" {
+			return Result{}, ErrNoChanges
+		}
+		req.Diff, files = git.ParseDiff(in.Diff, git.DiffOptions{})
+	}
+	if len(files) > 0 {
+		req.Meta = ai.NewDiffMeta(files, ai.TestFileOptions{})
+	}
+
+	client := ai.NewClient("", "", "", 0, ai.WithComplete(func(system, prompt, format string) (string, error) {
+		return g.provider.Complete(ctx, Request{System: system, Prompt: prompt, JSON: format == "json"})
+	}))
+	message, err := client.GenerateCommitMessage(req)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to generate commit message: %w", err)
+	}
+
+	result := Result{Message: message}
+	for _, file := range files {
+		result.Files = append(result.Files, FileDiff{Path: file.Path, Change: string(file.Change)})
+	}
+	return result, nil
+}
+
+// stagedChanges reads the staged diff, the staged files and the operation
+// in progress of the repository containing dir
+func stagedChanges(dir string) (string, []git.StagedFile, *git.GitState, error) {
+	client := git.NewClientWithBackend(git.DiffOptions{ContextLines: git.DefaultContextLines, MaxFileBytes: git.DefaultMaxFileBytes}, git.BackendAuto, git.WithDir(dir))
+	inside, err := client.IsInsideRepo()
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("failed to check repository status: %w", err)
+	}
+	if !inside {
+		return "", nil, nil, fmt.Errorf("%s is not in a git repository", dir)
+	}
+	staged, err := client.HasStagedChanges()
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("failed to check for staged changes: %w", err)
+	}
+	if !staged {
+		return "", nil, nil, ErrNoChanges
+	}
+
+	diff, err := client.GetStagedDiff()
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("failed to get diff: %w", err)
+	}
+	files, err := client.GetStagedFiles()
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("failed to list staged files: %w", err)
+	}
+	state, err := client.DetectState()
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("failed to detect git state: %w", err)
+	}
+	return diff, files, state, nil
+}
@@ -0,0 +1,210 @@
+package commitgen
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	gogit "github.com/go-git/go-git/v5"
+)
+
+const loginDiff = "diff --git a/login.go b/login.go\n--- a/login.go\n+++ b/login.go\n@@ -1 +1,2 @@\n package auth\n+func Login() {}\n"
+
+// recordingProvider answers with response and keeps the last request
+type recordingProvider struct {
+	response string
+	err      error
+	last     Request
+}
+
+func (p *recordingProvider) Complete(ctx context.Context, req Request) (string, error) {
+	p.last = req
+	return p.response, p.err
+}
+
+func TestGenerator_Generate(t *testing.T) {
+	providerErr := errors.New("provider down")
+	tests := []struct {
+		name           string
+		opts           []Option
+		input          GenerateInput
+		response       string
+		err            error
+		expected       string
+		expectedFiles  []FileDiff
+		expectInPrompt []string
+		expectError    error
+		expectErrorMsg string
+	}{
+		{
+			name:          "Diff",
+			input:         GenerateInput{Diff: loginDiff},
+			response:      "feat(auth): add login\n",
+			expected:      "feat(auth): add login",
+			expectedFiles: []FileDiff{{Path: "login.go", Change: "M"}},
+			// A single message is always asked for
+			expectInPrompt: []string{"+func Login() {}", "Language: Go", "Do not suggest splitting it."},
+		},
+		{
+			name:           "Rules and template",
+			opts:           []Option{WithRules("Scopes are package names."), WithTemplate("# Explain why\n[ticket]\n")},
+			input:          GenerateInput{Diff: loginDiff},
+			response:       "feat(auth): add login",
+			expected:       "feat(auth): add login",
+			expectedFiles:  []FileDiff{{Path: "login.go", Change: "M"}},
+			expectInPrompt: []string{"Scopes are package names.", "[ticket]"},
+		},
+		{
+			name:           "Neither input",
+			input:          GenerateInput{},
+			expectErrorMsg: "set exactly one of RepoPath and Diff",
+		},
+		{
+			name:           "Both inputs",
+			input:          GenerateInput{RepoPath: ".", Diff: loginDiff},
+			expectErrorMsg: "set exactly one of RepoPath and Diff",
+		},
+		{
+			name:        "Blank diff",
+			input:       GenerateInput{Diff: " \n"},
+			expectError: ErrNoChanges,
+		},
+		{
+			name:        "Provider error",
+			input:       GenerateInput{Diff: loginDiff},
+			err:         providerErr,
+			expectError: providerErr,
+		},
+		{
+			name:           "Empty answer",
+			input:          GenerateInput{Diff: loginDiff},
+			response:       "  \n",
+			expectErrorMsg: "empty response from model",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &recordingProvider{response: tt.response, err: tt.err}
+			gen := New(append([]Option{WithProvider(provider)}, tt.opts...)...)
+
+			result, err := gen.Generate(context.Background(), tt.input)
+			if tt.expectError != nil || tt.expectErrorMsg != "" {
+				if tt.expectError != nil && !errors.Is(err, tt.expectError) {
+					t.Fatalf("expected error %v, got %v", tt.expectError, err)
+				}
+				if err == nil || !strings.Contains(err.Error(), tt.expectErrorMsg) {
+					t.Fatalf("expected error containing %q, got %v", tt.expectErrorMsg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.Message != tt.expected {
+				t.Errorf("expected message %q, got %q", tt.expected, result.Message)
+			}
+			if !reflect.DeepEqual(result.Files, tt.expectedFiles) {
+				t.Errorf("expected files %+v, got %+v", tt.expectedFiles, result.Files)
+			}
+			for _, want := range tt.expectInPrompt {
+				if !strings.Contains(provider.last.Prompt, want) {
+					t.Errorf("expected %q in the prompt:\n%s", want, provider.last.Prompt)
+				}
+			}
+			if strings.Contains(provider.last.Prompt, "split suggestion") {
+				t.Errorf("expected no split analysis in the prompt:\n%s", provider.last.Prompt)
+			}
+		})
+	}
+}
+
+func TestGenerator_Generate_Cancelled(t *testing.T) {
+	gen := New(WithProvider(ProviderFunc(func(ctx context.Context, req Request) (string, error) {
+		t.Error("the provider is not called once ctx is cancelled")
+		return "", nil
+	})))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := gen.Generate(ctx, GenerateInput{Diff: loginDiff}); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestGenerator_Generate_RepoPath(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := gogit.PlainInit(dir, false)
+	if err != nil {
+		t.Fatalf("failed to git init: %v", err)
+	}
+	provider := &recordingProvider{response: "feat: add readme"}
+	gen := New(WithProvider(provider))
+
+	if _, err := gen.Generate(context.Background(), GenerateInput{RepoPath: dir}); !errors.Is(err, ErrNoChanges) {
+		t.Fatalf("expected ErrNoChanges with nothing staged, got %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("# Demo\n"), 0644); err != nil {
+		t.Fatalf("failed to write README.md: %v", err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("failed to get worktree: %v", err)
+	}
+	if _, err := worktree.Add("README.md"); err != nil {
+		t.Fatalf("failed to stage README.md: %v", err)
+	}
+
+	result, err := gen.Generate(context.Background(), GenerateInput{RepoPath: dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Message != "feat: add readme" {
+		t.Errorf("unexpected message %q", result.Message)
+	}
+	if !reflect.DeepEqual(result.Files, []FileDiff{{Path: "README.md", Change: "A"}}) {
+		t.Errorf("unexpected files %+v", result.Files)
+	}
+	if !strings.Contains(provider.last.Prompt, "+# Demo") {
+		t.Errorf("expected the staged diff in the prompt:\n%s", provider.last.Prompt)
+	}
+
+	if _, err := gen.Generate(context.Background(), GenerateInput{RepoPath: t.TempDir()}); err == nil || !strings.Contains(err.Error(), "not in a git repository") {
+		t.Errorf("expected an error outside a repository, got %v", err)
+	}
+}
+
+func TestOllama(t *testing.T) {
+	var body, auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		auth = r.Header.Get("Authorization")
+		w.Write([]byte(`{"response":"fix: handle x","done":true}`))
+	}))
+	defer server.Close()
+
+	provider := Ollama(OllamaOptions{BaseURL: server.URL, APIKey: "secret", Model: "llama3"})
+	answer, err := provider.Complete(context.Background(), Request{System: "Be brief.", Prompt: "Describe x", JSON: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if answer != "fix: handle x" {
+		t.Errorf("unexpected answer %q", answer)
+	}
+	if auth != "Bearer secret" {
+		t.Errorf("expected the key as a bearer token, got %q", auth)
+	}
+	for _, want := range []string{`"model":"llama3"`, `"system":"Be brief."`, `"prompt":"Describe x"`, `"format":"json"`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %s in request %s", want, body)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package commitgen_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"ai-commit-message-generator/pkg/commitgen"
+)
+
+// A provider that answers every prompt with a fixed message stands in for
+// a model here
+func ExampleGenerator_Generate() {
+	provider := commitgen.ProviderFunc(func(ctx context.Context, req commitgen.Request) (string, error) {
+		if !strings.Contains(req.Prompt, "+func Login() {}") {
+			return "", fmt.Errorf("the diff is missing from the prompt")
+		}
+		return "feat(auth): add login\n\nAdds the Login handler.", nil
+	})
+	gen := commitgen.New(
+		commitgen.WithProvider(provider),
+		commitgen.WithRules("Scopes are the top-level package names."),
+	)
+
+	diff := `diff --git a/auth/login.go b/auth/login.go
+new file mode 100644
+--- /dev/null
++++ b/auth/login.go
+@@ -0,0 +1,3 @@
++package auth
++
++func Login() {}
+`
+	result, err := gen.Generate(context.Background(), commitgen.GenerateInput{Diff: diff})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(result.Message)
+	for _, file := range result.Files {
+		fmt.Println(file.Change, file.Path)
+	}
+	// Output:
+	// feat(auth): add login
+	//
+	// Adds the Login handler.
+	// A auth/login.go
+}
@@ -0,0 +1,67 @@
+package commitgen
+
+import (
+	"context"
+	"time"
+
+	"ai-commit-message-generator/internal/ai"
+)
+
+// Request is one prompt for a Provider
+type Request struct {
+	// System is the system prompt; empty leaves it to the model
+	System string
+	// Prompt is the prompt itself
+	Prompt string
+	// JSON is set when the answer must be a JSON value
+	JSON bool
+}
+
+// Provider answers prompts with a language model
+type Provider interface {
+	// Complete returns the model's answer to req. It should stop when ctx
+	// is cancelled.
+	Complete(ctx context.Context, req Request) (string, error)
+}
+
+// ProviderFunc lets an ordinary function be a Provider
+type ProviderFunc func(ctx context.Context, req Request) (string, error)
+
+// Complete calls f
+func (f ProviderFunc) Complete(ctx context.Context, req Request) (string, error) {
+	return f(ctx, req)
+}
+
+// OllamaOptions configure the Ollama provider. The zero value talks to a
+// local Ollama.
+type OllamaOptions struct {
+	// BaseURL is the generate endpoint; empty means
+	// http://localhost:11434/api/generate
+	BaseURL string
+	// APIKey is sent as a bearer token when set
+	APIKey string
+	// Model is the model to run; empty means the command's default
+	Model string
+	// Timeout bounds each HTTP request; zero means 60 seconds
+	Timeout time.Duration
+}
+
+// Ollama returns a Provider for the Ollama generate API and compatible
+// servers. Rate limits and a loading model are retried with backoff, and
+// the retries are reported on stderr.
+func Ollama(opts OllamaOptions) Provider {
+	return ollamaProvider{opts: opts}
+}
+
+type ollamaProvider struct {
+	opts OllamaOptions
+}
+
+func (p ollamaProvider) Complete(ctx context.Context, req Request) (string, error) {
+	client := ai.NewClient(p.opts.APIKey, p.opts.BaseURL, p.opts.Model, p.opts.Timeout, ai.WithContext(ctx)).(*ai.OllamaClient)
+	format := ""
+	if req.JSON {
+		format = "json"
+	}
+	return client.Complete(req.System, req.Prompt, format)
+}